@@ -0,0 +1,78 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/airgapped"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/observable"
+)
+
+// RegisterAirgappedKeystore registers the keystore of an air-gapped signer, given by the output
+// descriptor of its account. It fails if another keystore is registered.
+func (backend *Backend) RegisterAirgappedKeystore(descriptor string) error {
+	if backend.keystores.Count() != 0 {
+		return errp.New("Another keystore is registered.")
+	}
+	net := &chaincfg.MainNetParams
+	if backend.Testing() {
+		net = &chaincfg.TestNet3Params
+	}
+	airgappedKeystore, err := airgapped.NewKeystore(
+		0, descriptor, net, backend.log.WithField("keystore", "airgapped"))
+	if err != nil {
+		return err
+	}
+	airgappedKeystore.Observe(func(event observable.Event) { backend.events <- event })
+	backend.airgappedKeystore = airgappedKeystore
+	backend.RegisterKeystore(airgappedKeystore)
+	return nil
+}
+
+// DeregisterAirgappedKeystore deregisters the keystore registered with RegisterAirgappedKeystore.
+func (backend *Backend) DeregisterAirgappedKeystore() error {
+	if backend.airgappedKeystore == nil {
+		return errp.New("No air-gapped signer is registered.")
+	}
+	backend.airgappedKeystore.AbortRequest()
+	backend.DeregisterKeystore()
+	return nil
+}
+
+// AirgappedRequest returns the transaction waiting to be signed by the air-gapped signer, or nil
+// if there is none.
+func (backend *Backend) AirgappedRequest() *airgapped.Request {
+	if backend.airgappedKeystore == nil {
+		return nil
+	}
+	return backend.airgappedKeystore.Request()
+}
+
+// ReceiveAirgappedPart adds a part of the signed transaction of the air-gapped signer, see
+// airgapped.Keystore.ReceivePart.
+func (backend *Backend) ReceiveAirgappedPart(part string) error {
+	if backend.airgappedKeystore == nil {
+		return errp.New("No air-gapped signer is registered.")
+	}
+	return backend.airgappedKeystore.ReceivePart(part)
+}
+
+// AbortAirgappedRequest aborts the signing with the air-gapped signer.
+func (backend *Backend) AbortAirgappedRequest() {
+	if backend.airgappedKeystore != nil {
+		backend.airgappedKeystore.AbortRequest()
+	}
+}
@@ -22,6 +22,10 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// DefaultRegtestElectrumServer is the address of the local Electrum server started by
+// scripts/run_regtest.sh.
+const DefaultRegtestElectrumServer = "127.0.0.1:52001"
+
 // Arguments models a configuration of the backend.
 type Arguments struct {
 	mainDirectoryPath string
@@ -38,6 +42,9 @@ type Arguments struct {
 	// Testing stores whether the application is for regtest.
 	regtest bool
 
+	// regtestElectrumServer stores the address of the Electrum server used for regtest.
+	regtestElectrumServer string
+
 	// Multisig stores whether the application is in multisig mode.
 	multisig bool
 
@@ -53,12 +60,16 @@ func NewArguments(
 	mainDirectoryPath string,
 	testing bool,
 	regtest bool,
+	regtestElectrumServer string,
 	multisig bool,
 	devmode bool,
 ) *Arguments {
 	if !testing && regtest {
 		panic("Cannot use -regtest with -mainnet.")
 	}
+	if regtestElectrumServer == "" {
+		regtestElectrumServer = DefaultRegtestElectrumServer
+	}
 
 	cacheDirectoryPath := path.Join(mainDirectoryPath, "cache")
 	if err := os.MkdirAll(cacheDirectoryPath, 0700); err != nil {
@@ -67,14 +78,15 @@ func NewArguments(
 
 	log := logging.Get().WithGroup("arguments")
 	arguments := &Arguments{
-		mainDirectoryPath:  mainDirectoryPath,
-		cacheDirectoryPath: cacheDirectoryPath,
-		configFilename:     path.Join(mainDirectoryPath, "config.json"),
-		testing:            testing,
-		regtest:            regtest,
-		multisig:           multisig,
-		devmode:            devmode,
-		log:                log,
+		mainDirectoryPath:     mainDirectoryPath,
+		cacheDirectoryPath:    cacheDirectoryPath,
+		configFilename:        path.Join(mainDirectoryPath, "config.json"),
+		testing:               testing,
+		regtest:               regtest,
+		regtestElectrumServer: regtestElectrumServer,
+		multisig:              multisig,
+		devmode:               devmode,
+		log:                   log,
 	}
 
 	log.Infof("Arguments: %+v", arguments)
@@ -113,6 +125,12 @@ func (arguments *Arguments) Regtest() bool {
 	return arguments.regtest
 }
 
+// RegtestElectrumServer returns the address of the Electrum server used for regtest. The
+// connection is not encrypted, so the server is expected to run locally.
+func (arguments *Arguments) RegtestElectrumServer() string {
+	return arguments.regtestElectrumServer
+}
+
 // Multisig returns whether the backend is in multisig mode.
 func (arguments *Arguments) Multisig() bool {
 	return arguments.multisig
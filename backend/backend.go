@@ -19,13 +19,18 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
+	"path/filepath"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
 	"github.com/cloudfoundry-attic/jibber_jabber"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/arguments"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/electrum"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/electrum/client"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/signet"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/ltc"
@@ -33,14 +38,16 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/devices/device"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/devices/usb"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/airgapped"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/walletconnect"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/jsonrpc"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/observable"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/rpc"
-	"github.com/ethereum/go-ethereum/params"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/text/language"
 )
@@ -48,10 +55,20 @@ import (
 const (
 	coinBTC  = "btc"
 	coinTBTC = "tbtc"
+	coinSBTC = "sbtc"
 	coinLTC  = "ltc"
 	coinTLTC = "tltc"
 	coinETH  = "eth"
-	coinTETH = "teth"
+	// Ether on the testnets of Ethereum.
+	coinSepoliaETH = "sepeth"
+	coinHoleskyETH = "holeth"
+	// Ether on the layer-2 networks, held at the same address as on Ethereum.
+	coinArbitrumETH = "arbeth"
+	coinOptimismETH = "opeth"
+	coinBaseETH     = "baseeth"
+	// The native coins of EVM sidechains, also held at the Ethereum address.
+	coinPOL  = "pol"
+	coinXDAI = "xdai"
 )
 
 type backendEvent struct {
@@ -89,6 +106,9 @@ type Backend struct {
 	onDeviceInit    func(device.Interface)
 	onDeviceUninit  func(string)
 
+	// xpubCache caches the extended public keys of the keystores across restarts.
+	xpubCache *keystore.XPubCache
+
 	coins     map[string]coin.Coin
 	coinsLock locker.Locker
 
@@ -96,7 +116,17 @@ type Backend struct {
 	accountsLock locker.Locker
 
 	// Stored and exposed temporarily through the backend.
-	ratesUpdater coin.RatesUpdater
+	ratesUpdater *RatesUpdater
+
+	walletConnect     *walletconnect.Manager
+	walletConnectLock locker.Locker
+
+	// softwareKeystore is the registered software keystore, if it is unlocked.
+	softwareKeystore *software.Keystore
+	// hwiKeystore is the registered keystore of a device used through HWI, if any.
+	hwiKeystore keystore.Keystore
+	// airgappedKeystore is the registered keystore of an air-gapped signer, if any.
+	airgappedKeystore *airgapped.Keystore
 
 	log *logrus.Entry
 }
@@ -104,13 +134,15 @@ type Backend struct {
 // NewBackend creates a new backend with the given arguments.
 func NewBackend(arguments *arguments.Arguments) *Backend {
 	log := logging.Get().WithGroup("backend")
+	xpubCache := keystore.NewXPubCache(filepath.Join(arguments.CacheDirectoryPath(), "xpubs.json"))
 	backend := &Backend{
 		arguments: arguments,
 		config:    config.NewConfig(arguments.ConfigFilename()),
 		events:    make(chan interface{}, 1000),
 
 		devices:   map[string]device.Interface{},
-		keystores: keystore.NewKeystores(),
+		keystores: keystore.NewCachedKeystores(xpubCache),
+		xpubCache: xpubCache,
 		coins:     map[string]coin.Coin{},
 		log:       log,
 	}
@@ -120,18 +152,73 @@ func NewBackend(arguments *arguments.Arguments) *Backend {
 	return backend
 }
 
-func (backend *Backend) addAccount(
+// accountCode returns the code of the account with the given BIP44 account index. The first
+// account keeps the code without an index, so that existing databases and settings still apply.
+func accountCode(code string, index int) string {
+	if index == 0 {
+		return code
+	}
+	return fmt.Sprintf("%s-%d", code, index)
+}
+
+// accountIndex returns the keypath element of the given BIP44 account index.
+func accountIndex(index int) string {
+	return fmt.Sprintf("%d'", index)
+}
+
+// ethereumKeypathSchemeAccount is the config value of the Ethereum keypath scheme which derives
+// an account per BIP44 account index instead of per address index.
+const ethereumKeypathSchemeAccount = "account"
+
+// bip44Keypath returns the keypath of the account of the account type with the given BIP44
+// account index. The keypath of an Ethereum account type ends with the coin type, below which the
+// accounts are derived according to the given keypath scheme.
+func bip44Keypath(accountType *accountType, index int, ethereumKeypathScheme string) string {
+	if _, ok := accountType.coin.(*eth.Coin); ok {
+		if ethereumKeypathScheme == ethereumKeypathSchemeAccount {
+			return fmt.Sprintf("%s/%s/0/0", accountType.keypath, accountIndex(index))
+		}
+		return fmt.Sprintf("%s/0'/0/%d", accountType.keypath, index)
+	}
+	return accountType.keypath + "/" + accountIndex(index)
+}
+
+// accountKeypath returns the keypath of the account of the account type with the given BIP44
+// account index.
+func (backend *Backend) accountKeypath(accountType *accountType, index int) string {
+	if !accountType.bip44 {
+		return accountType.keypath
+	}
+	return bip44Keypath(accountType, index, backend.config.Config().Backend.EthereumKeypathScheme)
+}
+
+// accountName returns the name of the account with the given BIP44 account index.
+func accountName(name string, index int) string {
+	if index == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s %d", name, index+1)
+}
+
+// addAccounts adds the configured number of BIP44 accounts of the account type.
+func (backend *Backend) addAccounts(accountType *accountType) {
+	for index := 0; index < backend.accountCount(accountType.code); index++ {
+		backend.addAccount(accountType.coin, accountType.code, index, accountType.name,
+			backend.accountKeypath(accountType, index), accountType.scriptType)
+	}
+}
+
+// newAccount creates the account with the given BIP44 account index. code is the code of the
+// first account of the coin and script type.
+func (backend *Backend) newAccount(
 	coin coin.Coin,
 	code string,
+	index int,
 	name string,
 	keypath string,
 	scriptType signing.ScriptType,
-) {
-	if !backend.config.Config().Backend.AccountActive(code) {
-		backend.log.WithField("code", code).WithField("name", name).Info("skipping inactive account")
-		return
-	}
-	backend.log.WithField("code", code).WithField("name", name).Info("init account")
+	onEvent func(code string, data string),
+) btc.Interface {
 	absoluteKeypath, err := signing.NewAbsoluteKeypath(keypath)
 	if err != nil {
 		panic(err)
@@ -142,29 +229,66 @@ func (backend *Backend) addAccount(
 	if backend.arguments.Multisig() {
 		name = name + " Multisig"
 	}
+	dustLimit := backend.config.Config().Backend.DustLimit(code)
+	gapLimits := backend.config.Config().Backend.GapLimit(accountCode(code, index))
+	code, name = accountCode(code, index), accountName(name, index)
 	switch specificCoin := coin.(type) {
 	case *btc.Coin:
-		onEvent := func(code string) func(btc.Event) {
-			return func(event btc.Event) {
-				backend.events <- AccountEvent{Type: "account", Code: code, Data: string(event)}
-			}
-		}
-		account := btc.NewAccount(specificCoin, backend.arguments.CacheDirectoryPath(), code, name,
-			getSigningConfiguration, backend.keystores, onEvent(code), backend.log)
-		backend.accounts = append(backend.accounts, account)
+		return btc.NewAccount(specificCoin, backend.arguments.CacheDirectoryPath(), code, name,
+			getSigningConfiguration, backend.keystores, btcutil.Amount(dustLimit),
+			btc.GapLimits{Receive: gapLimits.Receive, Change: gapLimits.Change},
+			backend.txOrdering(),
+			func(event btc.Event) { onEvent(code, string(event)) }, backend.log)
 	case *eth.Coin:
-		onEvent := func(event eth.Event) {
-			backend.events <- AccountEvent{Type: "account", Code: code, Data: string(event)}
-		}
-		account := eth.NewAccount(specificCoin, backend.arguments.CacheDirectoryPath(),
-			code, name,
-			getSigningConfiguration, backend.keystores, onEvent, backend.log)
-		backend.accounts = append(backend.accounts, account)
+		return eth.NewAccount(specificCoin, backend.arguments.CacheDirectoryPath(),
+			code, name, getSigningConfiguration, backend.keystores,
+			func(event eth.Event) { onEvent(code, string(event)) }, backend.log)
 	default:
 		panic("unknown coin type")
 	}
 }
 
+// txOrdering returns the configured order of the inputs and outputs of new transactions.
+func (backend *Backend) txOrdering() maketx.TxOrdering {
+	return maketx.TxOrdering(backend.config.Config().Backend.TxOrdering)
+}
+
+func (backend *Backend) addAccount(
+	coin coin.Coin,
+	code string,
+	index int,
+	name string,
+	keypath string,
+	scriptType signing.ScriptType,
+) {
+	if !backend.config.Config().Backend.AccountActive(code) {
+		backend.log.WithField("code", code).WithField("name", name).Info("skipping inactive account")
+		return
+	}
+	backend.initAccount(coin, code, index, name, keypath, scriptType)
+}
+
+// initAccount creates the account with the given BIP44 account index and adds it to the accounts.
+func (backend *Backend) initAccount(
+	coin coin.Coin,
+	code string,
+	index int,
+	name string,
+	keypath string,
+	scriptType signing.ScriptType,
+) {
+	if !backend.keystores.SupportAccount(coin, scriptType, backend.arguments.Multisig()) {
+		backend.log.WithField("code", code).WithField("name", name).Info("skipping account unsupported by the keystore")
+		return
+	}
+	backend.log.WithField("code", code).WithField("index", index).WithField("name", name).Info("init account")
+	account := backend.newAccount(coin, code, index, name, keypath, scriptType,
+		func(code string, data string) {
+			backend.events <- AccountEvent{Type: "account", Code: code, Data: data}
+		})
+	backend.accounts = append(backend.accounts, account)
+}
+
 // Config returns the app config.
 func (backend *Backend) Config() *config.Config {
 	return backend.config
@@ -181,6 +305,8 @@ func (backend *Backend) defaultProdServers(code string) []*rpc.ServerInfo {
 		return backend.config.Config().Backend.BTC.ElectrumServers
 	case coinTBTC:
 		return backend.config.Config().Backend.TBTC.ElectrumServers
+	case coinSBTC:
+		return backend.config.Config().Backend.SBTC.ElectrumServers
 	case coinLTC:
 		return backend.config.Config().Backend.LTC.ElectrumServers
 	case coinTLTC:
@@ -235,6 +361,8 @@ O3nOxjgSfRAfKWQ2Ny1APKcn6I83P5PFLhtO5I12
 			{Server: "s1.dev.shiftcrypto.ch:51003", TLS: true, PEMCert: devShiftCA},
 			{Server: "s2.dev.shiftcrypto.ch:51003", TLS: true, PEMCert: devShiftCA},
 		}
+	case coinSBTC:
+		return config.NewDefaultConfig().Backend.SBTC.ElectrumServers
 	case coinLTC:
 		return []*rpc.ServerInfo{{Server: "dev.shiftcrypto.ch:50004", TLS: true, PEMCert: devShiftCA}}
 	case coinTLTC:
@@ -262,12 +390,16 @@ func (backend *Backend) Coin(code string) coin.Coin {
 	dbFolder := backend.arguments.CacheDirectoryPath()
 	switch code {
 	case "rbtc":
-		servers := []*rpc.ServerInfo{{Server: "127.0.0.1:52001", TLS: false, PEMCert: ""}}
+		servers := []*rpc.ServerInfo{{Server: backend.arguments.RegtestElectrumServer(), TLS: false, PEMCert: ""}}
 		coin = btc.NewCoin("rbtc", "RBTC", &chaincfg.RegressionNetParams, dbFolder, servers, "")
 	case coinTBTC:
 		servers := backend.defaultElectrumXServers(code)
 		coin = btc.NewCoin(coinTBTC, "TBTC", &chaincfg.TestNet3Params, dbFolder, servers,
 			"https://testnet.blockchain.info/tx/")
+	case coinSBTC:
+		servers := backend.defaultElectrumXServers(code)
+		coin = btc.NewCoin(coinSBTC, "SBTC", &signet.Params, dbFolder, servers,
+			"https://mempool.space/signet/tx/")
 	case coinBTC:
 		servers := backend.defaultElectrumXServers(code)
 		coin = btc.NewCoin(coinBTC, "BTC", &chaincfg.MainNetParams, dbFolder, servers,
@@ -281,9 +413,21 @@ func (backend *Backend) Coin(code string) coin.Coin {
 		coin = btc.NewCoin(coinLTC, "LTC", &ltc.MainNetParams, dbFolder, servers,
 			"https://insight.litecore.io/tx/")
 	case coinETH:
-		coin = eth.NewCoin(code, params.MainnetChainConfig, "https://etherscan.io/tx/")
-	case coinTETH:
-		coin = eth.NewCoin(code, params.RinkebyChainConfig, "https://rinkeby.etherscan.io/tx/")
+		coin = backend.newEthereumCoin(code, eth.MainnetNetwork)
+	case coinSepoliaETH:
+		coin = backend.newEthereumCoin(code, eth.SepoliaNetwork)
+	case coinHoleskyETH:
+		coin = backend.newEthereumCoin(code, eth.HoleskyNetwork)
+	case coinArbitrumETH:
+		coin = backend.newEthereumCoin(code, eth.ArbitrumNetwork)
+	case coinOptimismETH:
+		coin = backend.newEthereumCoin(code, eth.OptimismNetwork)
+	case coinBaseETH:
+		coin = backend.newEthereumCoin(code, eth.BaseNetwork)
+	case coinPOL:
+		coin = backend.newEthereumCoin(code, eth.PolygonNetwork)
+	case coinXDAI:
+		coin = backend.newEthereumCoin(code, eth.GnosisNetwork)
 	default:
 		panic(errp.Newf("unknown coin code %s", code))
 	}
@@ -291,67 +435,155 @@ func (backend *Backend) Coin(code string) coin.Coin {
 	return coin
 }
 
-func (backend *Backend) initAccounts() {
-	// Since initAccounts replaces all previous accounts, we need to properly close them first.
-	backend.uninitAccounts()
-	defer backend.accountsLock.Lock()()
+// newEthereumCoin creates the Ether coin with the given code, which uses the node, indexer and
+// relay configured for it.
+func (backend *Backend) newEthereumCoin(code string, network *eth.Network) *eth.Coin {
+	backendConfig := backend.config.Config().Backend
+	coin := eth.NewCoin(code, network, backendConfig.EthereumRPC(code), backendConfig.EthereumIndexer(code))
+	coin.SetRelayURL(backendConfig.EthereumRelay(code))
+	return coin
+}
 
-	backend.accounts = []btc.Interface{}
+// accountType describes the accounts of a coin and script type.
+type accountType struct {
+	coin coin.Coin
+	// code is the code of the first account.
+	code    string
+	name    string
+	keypath string
+	// scriptType is the script type of the addresses of the accounts.
+	scriptType signing.ScriptType
+	// bip44 is true if there can be several accounts, whose keypaths are derived from keypath and
+	// the BIP44 account index by bip44Keypath. Otherwise, keypath is the keypath of the only account.
+	bip44 bool
+}
+
+// accountTypes returns the supported account types.
+func (backend *Backend) accountTypes() []*accountType {
+	types := []*accountType{}
 	if backend.arguments.Testing() {
 		if backend.arguments.Regtest() {
 			RBTC := backend.Coin("rbtc")
-			backend.addAccount(RBTC, "rbtc-p2pkh", "Bitcoin Regtest Legacy", "m/44'/1'/0'",
-				signing.ScriptTypeP2PKH)
-			backend.addAccount(RBTC, "rbtc-p2wpkh-p2sh", "Bitcoin Regtest Segwit", "m/49'/1'/0'",
-				signing.ScriptTypeP2WPKHP2SH)
+			types = append(types, &accountType{RBTC, "rbtc-p2pkh", "Bitcoin Regtest Legacy", "m/44'/1'",
+				signing.ScriptTypeP2PKH, true})
+			types = append(types, &accountType{RBTC, "rbtc-p2wpkh-p2sh", "Bitcoin Regtest Segwit", "m/49'/1'",
+				signing.ScriptTypeP2WPKHP2SH, true})
+			types = append(types, &accountType{RBTC, "rbtc-p2wpkh", "Bitcoin Regtest: bech32", "m/84'/1'",
+				signing.ScriptTypeP2WPKH, true})
+			types = append(types, &accountType{RBTC, "rbtc-p2tr", "Bitcoin Regtest: bech32m", "m/86'/1'",
+				signing.ScriptTypeP2TR, true})
 		} else {
 			TBTC := backend.Coin(coinTBTC)
-			backend.addAccount(TBTC, "tbtc-p2wpkh-p2sh", "Bitcoin Testnet", "m/49'/1'/0'",
-				signing.ScriptTypeP2WPKHP2SH)
-			backend.addAccount(TBTC, "tbtc-p2wpkh", "Bitcoin Testnet: bech32", "m/84'/1'/0'",
-				signing.ScriptTypeP2WPKH)
-			backend.addAccount(TBTC, "tbtc-p2pkh", "Bitcoin Testnet Legacy", "m/44'/1'/0'",
-				signing.ScriptTypeP2PKH)
+			types = append(types, &accountType{TBTC, "tbtc-p2wpkh-p2sh", "Bitcoin Testnet", "m/49'/1'",
+				signing.ScriptTypeP2WPKHP2SH, true})
+			types = append(types, &accountType{TBTC, "tbtc-p2wpkh", "Bitcoin Testnet: bech32", "m/84'/1'",
+				signing.ScriptTypeP2WPKH, true})
+			types = append(types, &accountType{TBTC, "tbtc-p2tr", "Bitcoin Testnet: bech32m", "m/86'/1'",
+				signing.ScriptTypeP2TR, true})
+			types = append(types, &accountType{TBTC, "tbtc-p2pkh", "Bitcoin Testnet Legacy", "m/44'/1'",
+				signing.ScriptTypeP2PKH, true})
+
+			SBTC := backend.Coin(coinSBTC)
+			types = append(types, &accountType{SBTC, "sbtc-p2wpkh-p2sh", "Bitcoin Signet", "m/49'/1'",
+				signing.ScriptTypeP2WPKHP2SH, true})
+			types = append(types, &accountType{SBTC, "sbtc-p2wpkh", "Bitcoin Signet: bech32", "m/84'/1'",
+				signing.ScriptTypeP2WPKH, true})
+			types = append(types, &accountType{SBTC, "sbtc-p2tr", "Bitcoin Signet: bech32m", "m/86'/1'",
+				signing.ScriptTypeP2TR, true})
+			types = append(types, &accountType{SBTC, "sbtc-p2pkh", "Bitcoin Signet Legacy", "m/44'/1'",
+				signing.ScriptTypeP2PKH, true})
 
 			TLTC := backend.Coin(coinTLTC)
-			backend.addAccount(TLTC, "tltc-p2wpkh-p2sh", "Litecoin Testnet", "m/49'/1'/0'",
-				signing.ScriptTypeP2WPKHP2SH)
-			backend.addAccount(TLTC, "tltc-p2wpkh", "Litecoin Testnet: bech32", "m/84'/1'/0'",
-				signing.ScriptTypeP2WPKH)
+			types = append(types, &accountType{TLTC, "tltc-p2wpkh-p2sh", "Litecoin Testnet", "m/49'/1'",
+				signing.ScriptTypeP2WPKHP2SH, true})
+			types = append(types, &accountType{TLTC, "tltc-p2wpkh", "Litecoin Testnet: bech32", "m/84'/1'",
+				signing.ScriptTypeP2WPKH, true})
 
 			if backend.arguments.DevMode() {
-				teth := backend.Coin(coinTETH)
-				backend.addAccount(teth, "teth", "Ethereum Rinkeby", "m/44'/1'/0'/0/0", signing.ScriptTypeP2WPKH)
+				sepolia := backend.Coin(coinSepoliaETH)
+				types = append(types, &accountType{sepolia, "sepeth", "Ethereum Sepolia", "m/44'/1'",
+					signing.ScriptTypeP2WPKH, true})
+				holesky := backend.Coin(coinHoleskyETH)
+				types = append(types, &accountType{holesky, "holeth", "Ethereum Holesky", "m/44'/1'",
+					signing.ScriptTypeP2WPKH, true})
 			}
 		}
 	} else {
 		BTC := backend.Coin(coinBTC)
-		backend.addAccount(BTC, "btc-p2wpkh-p2sh", "Bitcoin", "m/49'/0'/0'",
-			signing.ScriptTypeP2WPKHP2SH)
-		backend.addAccount(BTC, "btc-p2wpkh", "Bitcoin: bech32", "m/84'/0'/0'",
-			signing.ScriptTypeP2WPKH)
-		backend.addAccount(BTC, "btc-p2pkh", "Bitcoin Legacy", "m/44'/0'/0'",
-			signing.ScriptTypeP2PKH)
+		types = append(types, &accountType{BTC, "btc-p2wpkh-p2sh", "Bitcoin", "m/49'/0'",
+			signing.ScriptTypeP2WPKHP2SH, true})
+		types = append(types, &accountType{BTC, "btc-p2wpkh", "Bitcoin: bech32", "m/84'/0'",
+			signing.ScriptTypeP2WPKH, true})
+		types = append(types, &accountType{BTC, "btc-p2tr", "Bitcoin: bech32m", "m/86'/0'",
+			signing.ScriptTypeP2TR, true})
+		types = append(types, &accountType{BTC, "btc-p2pkh", "Bitcoin Legacy", "m/44'/0'",
+			signing.ScriptTypeP2PKH, true})
 
 		LTC := backend.Coin(coinLTC)
-		backend.addAccount(LTC, "ltc-p2wpkh-p2sh", "Litecoin", "m/49'/2'/0'",
-			signing.ScriptTypeP2WPKHP2SH)
-		backend.addAccount(LTC, "ltc-p2wpkh", "Litecoin: bech32", "m/84'/2'/0'",
-			signing.ScriptTypeP2WPKH)
+		types = append(types, &accountType{LTC, "ltc-p2wpkh-p2sh", "Litecoin", "m/49'/2'",
+			signing.ScriptTypeP2WPKHP2SH, true})
+		types = append(types, &accountType{LTC, "ltc-p2wpkh", "Litecoin: bech32", "m/84'/2'",
+			signing.ScriptTypeP2WPKH, true})
 
 		if backend.arguments.DevMode() {
 			eth := backend.Coin(coinETH)
-			backend.addAccount(eth, "eth", "Ethereum", "m/44'/60'/0'/0/0", signing.ScriptTypeP2WPKH)
+			types = append(types, &accountType{eth, "eth", "Ethereum", "m/44'/60'",
+				signing.ScriptTypeP2WPKH, true})
+			// The layer-2 networks and sidechains use the keypath of Ethereum, so that funds
+			// bridged to them arrive at the same address.
+			arbitrum := backend.Coin(coinArbitrumETH)
+			types = append(types, &accountType{arbitrum, "arbeth", "Arbitrum", "m/44'/60'",
+				signing.ScriptTypeP2WPKH, true})
+			optimism := backend.Coin(coinOptimismETH)
+			types = append(types, &accountType{optimism, "opeth", "Optimism", "m/44'/60'",
+				signing.ScriptTypeP2WPKH, true})
+			base := backend.Coin(coinBaseETH)
+			types = append(types, &accountType{base, "baseeth", "Base", "m/44'/60'",
+				signing.ScriptTypeP2WPKH, true})
+			polygon := backend.Coin(coinPOL)
+			types = append(types, &accountType{polygon, "pol", "Polygon", "m/44'/60'",
+				signing.ScriptTypeP2WPKH, true})
+			gnosis := backend.Coin(coinXDAI)
+			types = append(types, &accountType{gnosis, "xdai", "Gnosis Chain", "m/44'/60'",
+				signing.ScriptTypeP2WPKH, true})
+		}
+	}
+	return types
+}
+
+func (backend *Backend) initAccounts() {
+	// Since initAccounts replaces all previous accounts, we need to properly close them first.
+	backend.uninitAccounts()
+	defer backend.accountsLock.Lock()()
+
+	backend.accounts = []btc.Interface{}
+	if backend.keystores.Count() > 0 {
+		for _, accountType := range backend.accountTypes() {
+			if accountType.bip44 {
+				backend.addAccounts(accountType)
+			} else {
+				backend.addAccount(accountType.coin, accountType.code, 0, accountType.name,
+					accountType.keypath, accountType.scriptType)
+			}
 		}
+		backend.addCustomAccounts()
+		backend.addERC20TokenAccounts()
+		backend.addMultisigAccounts()
 	}
+	backend.addWatchOnlyAccounts()
 	for _, account := range backend.accounts {
+		// Bitcoin accounts notify the progress of signing, see btc.SignProgress.
+		if observableAccount, ok := account.(observable.Interface); ok {
+			observableAccount.Observe(func(event observable.Event) { backend.events <- event })
+		}
 		backend.onAccountInit(account)
 	}
 }
 
-// AccountsStatus returns whether the accounts have been initialized.
+// AccountsStatus returns whether the accounts have been initialized. Watch-only accounts are
+// initialized without a keystore.
 func (backend *Backend) AccountsStatus() string {
-	if backend.keystores.Count() > 0 {
+	if backend.keystores.Count() > 0 || len(backend.accounts) > 0 {
 		return "initialized"
 	}
 	return "uninitialized"
@@ -405,6 +637,8 @@ func (backend *Backend) OnDeviceUninit(f func(string)) {
 // Start starts the background services. It returns a channel of events to handle by the library
 // client.
 func (backend *Backend) Start() <-chan interface{} {
+	// Adds the watch-only accounts, which do not wait for a keystore.
+	backend.initAccounts()
 	usb.NewManager(backend.arguments.MainDirectoryPath(), backend.Register, backend.Deregister).Start()
 	return backend.events
 }
@@ -434,6 +668,16 @@ func (backend *Backend) Keystores() keystore.Keystores {
 	return backend.keystores
 }
 
+// registerDeviceKeystore registers the keystore of the given device at this backend.
+func (backend *Backend) registerDeviceKeystore(theDevice device.Interface) {
+	deviceKeystore, err := theDevice.KeystoreForConfiguration(nil, backend.keystores.Count())
+	if err != nil {
+		backend.log.WithError(err).Error("Failed to get the keystore of the device.")
+		return
+	}
+	backend.RegisterKeystore(deviceKeystore)
+}
+
 // RegisterKeystore registers the given keystore at this backend.
 func (backend *Backend) RegisterKeystore(keystore keystore.Keystore) {
 	backend.log.Info("registering keystore")
@@ -445,14 +689,46 @@ func (backend *Backend) RegisterKeystore(keystore keystore.Keystore) {
 	}
 	backend.initAccounts()
 	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+	go backend.discoverAccounts()
 }
 
 // DeregisterKeystore removes the registered keystore.
 func (backend *Backend) DeregisterKeystore() {
 	backend.log.Info("deregistering keystore")
-	backend.keystores = keystore.NewKeystores()
+	backend.keystores = keystore.NewCachedKeystores(backend.xpubCache)
+	backend.softwareKeystore = nil
+	backend.hwiKeystore = nil
+	backend.airgappedKeystore = nil
+	// Only the watch-only accounts remain.
+	backend.initAccounts()
+	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+}
+
+// ResyncAccount deletes the synced transactions of the account with the given code and
+// reinitializes the accounts, so that they are fetched again from the blockchain backend. Notes and
+// labels are kept.
+func (backend *Backend) ResyncAccount(code string) error {
+	var btcAccount *btc.Account
+	for _, account := range backend.Accounts() {
+		if account.Code() != code {
+			continue
+		}
+		specificAccount, ok := account.(*btc.Account)
+		if !ok {
+			return errp.Newf("Resyncing is not supported for %s.", code)
+		}
+		btcAccount = specificAccount
+	}
+	if btcAccount == nil {
+		return errp.Newf("Unknown account %s.", code)
+	}
+	backend.log.WithField("code", code).Info("resyncing account")
+	// The database can only be cleared while the account is closed.
 	backend.uninitAccounts()
+	err := btcAccount.ClearTransactions()
+	backend.initAccounts()
 	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+	return err
 }
 
 // Register registers the given device at this backend.
@@ -475,14 +751,15 @@ func (backend *Backend) Register(theDevice device.Interface) error {
 			// configuration := signing.NewConfiguration(absoluteKeypath,
 			// 	[]*hdkeychain.ExtendedKey{extendedPublicKey}, 1)
 			if backend.arguments.Multisig() {
-				backend.RegisterKeystore(
-					theDevice.KeystoreForConfiguration(nil, backend.keystores.Count()))
+				backend.registerDeviceKeystore(theDevice)
 			} else if mainKeystore {
 				// HACK: for device based, only one is supported at the moment.
-				backend.keystores = keystore.NewKeystores()
+				backend.keystores = keystore.NewCachedKeystores(backend.xpubCache)
+				backend.softwareKeystore = nil
+				backend.hwiKeystore = nil
+				backend.airgappedKeystore = nil
 
-				backend.RegisterKeystore(
-					theDevice.KeystoreForConfiguration(nil, backend.keystores.Count()))
+				backend.registerDeviceKeystore(theDevice)
 			}
 		}
 		backend.events <- deviceEvent{
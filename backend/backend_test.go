@@ -0,0 +1,38 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBIP44Keypath(t *testing.T) {
+	bitcoin := &accountType{&btc.Coin{}, "btc-p2wpkh", "Bitcoin: bech32", "m/84'/0'",
+		signing.ScriptTypeP2WPKH, true}
+	require.Equal(t, "m/84'/0'/0'", bip44Keypath(bitcoin, 0, ""))
+	require.Equal(t, "m/84'/0'/2'", bip44Keypath(bitcoin, 2, ethereumKeypathSchemeAccount))
+
+	ethereum := &accountType{eth.NewCoin("eth", eth.MainnetNetwork, "", ""), "eth", "Ethereum",
+		"m/44'/60'", signing.ScriptTypeP2WPKH, true}
+	require.Equal(t, "m/44'/60'/0'/0/0", bip44Keypath(ethereum, 0, ""))
+	require.Equal(t, "m/44'/60'/0'/0/2", bip44Keypath(ethereum, 2, ""))
+	require.Equal(t, "m/44'/60'/0'/0/0", bip44Keypath(ethereum, 0, ethereumKeypathSchemeAccount))
+	require.Equal(t, "m/44'/60'/2'/0/0", bip44Keypath(ethereum, 2, ethereumKeypathSchemeAccount))
+}
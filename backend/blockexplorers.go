@@ -0,0 +1,83 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	neturl "net/url"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// checkBlockExplorerTxPrefix returns an error if the URL prefix cannot be used to open
+// transactions. Plain http is allowed for explorers on the local network or Tor onion services.
+func checkBlockExplorerTxPrefix(txPrefix string) error {
+	parsed, err := neturl.Parse(txPrefix)
+	if err != nil {
+		return errp.WithMessage(err, "Invalid block explorer URL")
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errp.New("The block explorer URL must start with http:// or https://.")
+	}
+	return nil
+}
+
+// BlockExplorerTxPrefix returns the URL prefix to which the ID of a transaction of the given coin
+// is appended to open it in a block explorer. It is the one configured by the user, or the default
+// of the coin.
+func (backend *Backend) BlockExplorerTxPrefix(coin coin.Coin) string {
+	if txPrefix := backend.config.Config().Backend.BlockExplorer(coin.Code()); txPrefix != "" {
+		return txPrefix
+	}
+	return coin.BlockExplorerTransactionURLPrefix()
+}
+
+// SetBlockExplorer configures the block explorer used to open the transactions of the given coin,
+// e.g. a self-hosted one. An empty URL prefix restores the default.
+func (backend *Backend) SetBlockExplorer(coinCode string, txPrefix string) error {
+	known := false
+	for _, accountType := range backend.accountTypes() {
+		if accountType.coin.Code() == coinCode {
+			known = true
+		}
+	}
+	if !known {
+		return errp.Newf("Unknown coin %s.", coinCode)
+	}
+	txPrefix = strings.TrimSpace(txPrefix)
+	if txPrefix != "" {
+		if err := checkBlockExplorerTxPrefix(txPrefix); err != nil {
+			return err
+		}
+	}
+	appConfig := backend.config.Config()
+	blockExplorers := map[string]string{}
+	for code, configured := range appConfig.Backend.BlockExplorers {
+		blockExplorers[code] = configured
+	}
+	if txPrefix == "" {
+		delete(blockExplorers, coinCode)
+	} else {
+		blockExplorers[coinCode] = txPrefix
+	}
+	appConfig.Backend.BlockExplorers = blockExplorers
+	if err := backend.config.Set(appConfig); err != nil {
+		return err
+	}
+	// The block explorer is part of the accounts reported to the frontend.
+	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+	return nil
+}
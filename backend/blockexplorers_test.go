@@ -0,0 +1,31 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBlockExplorerTxPrefix(t *testing.T) {
+	require.NoError(t, checkBlockExplorerTxPrefix("https://mempool.space/tx/"))
+	require.NoError(t, checkBlockExplorerTxPrefix(
+		"http://mempoolhqx4isw62xs7abwphsq7ldayuidyx2v2oethdhhj6mlo2r6ad.onion/tx/"))
+	require.NoError(t, checkBlockExplorerTxPrefix("http://192.168.1.10:3002/tx/"))
+	require.Error(t, checkBlockExplorerTxPrefix("mempool.space/tx/"))
+	require.Error(t, checkBlockExplorerTxPrefix("javascript:alert(1)//"))
+	require.Error(t, checkBlockExplorerTxPrefix("file:///etc/"))
+}
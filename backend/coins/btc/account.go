@@ -27,6 +27,9 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/headers"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/mempoolspace"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/signet"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/synchronizer"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
@@ -36,6 +39,8 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/observable"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/observable/action"
 	"github.com/sirupsen/logrus"
 )
 
@@ -44,6 +49,13 @@ const (
 	changeGapLimit = 6
 )
 
+// GapLimits are the gap limits of the receive and change address chains of an account. A zero
+// limit means that the default applies.
+type GapLimits struct {
+	Receive int
+	Change  int
+}
+
 // Interface is the API of a Account.
 type Interface interface {
 	Info() *Info
@@ -61,21 +73,29 @@ type Interface interface {
 	Balance() *coin.Balance
 	// Creates, signs and broadcasts a transaction. Returns keystore.ErrSigningAborted on user
 	// abort.
-	SendTx(string, coin.SendAmount, FeeTargetCode, map[wire.OutPoint]struct{}) error
+	SendTx(*TxProposalArgs) error
 	FeeTargets() ([]*FeeTarget, FeeTargetCode)
-	TxProposal(string, coin.SendAmount, FeeTargetCode, map[wire.OutPoint]struct{}) (
-		coin.Amount, coin.Amount, coin.Amount, error)
+	TxProposal(*TxProposalArgs) (*TxProposalResult, error)
 	GetUnusedReceiveAddresses() []coin.Address
 	VerifyAddress(addressID string) (bool, error)
 	ConvertToLegacyAddress(addressID string) (btcutil.Address, error)
 	Keystores() keystore.Keystores
 	HeadersStatus() (*headers.Status, error)
 	SpendableOutputs() []*SpendableOutput
+	// TxNote returns the note of a transaction, or an empty string if there is none.
+	TxNote(txID string) (string, error)
+	// SetTxNote stores the note of a transaction. An empty note removes it.
+	SetTxNote(txID string, note string) error
+	// AddressLabels returns the labels of the receive addresses by their encoded address.
+	AddressLabels() map[string]string
+	// SetAddressLabel labels the receive address with the given ID. An empty label removes it.
+	SetAddressLabel(addressID string, label string) error
 }
 
 // Account is a account whose addresses are derived from an xpub.
 type Account struct {
 	locker.Locker
+	observable.Implementation
 
 	coin                    *Coin
 	dbFolder                string
@@ -96,6 +116,26 @@ type Account struct {
 	synchronizer *synchronizer.Synchronizer
 
 	feeTargets []*FeeTarget
+	// dustLimit is the smallest amount of a new output which is not considered dust, in addition to
+	// the dust rules of the network.
+	dustLimit btcutil.Amount
+	// gapLimits overrides the default gap limits of the address chains.
+	gapLimits GapLimits
+	// txOrdering is the order of the inputs and outputs of new transactions.
+	txOrdering maketx.TxOrdering
+	// mempoolSpace is used for fee estimation if the blockchain backend does not report its mempool.
+	// It is nil if mempool.space does not support the coin.
+	mempoolSpace *mempoolspace.MempoolSpace
+	// keystoreFingerprint identifies the keystores in the notes of transactions. It is empty until
+	// first needed.
+	keystoreFingerprint string
+	// incomingTxs are the IDs of the unconfirmed incoming transactions seen so far, so that
+	// EventIncomingTransaction is fired only once for each of them.
+	incomingTxs map[string]struct{}
+	// pendingPSBT is the serialized PSBT exported with ExportPSBTFile, against which the signed PSBT
+	// file is validated. It is nil if no transaction is pending.
+	pendingPSBT     []byte
+	pendingPSBTLock locker.Locker
 
 	initialized bool
 	offline     bool
@@ -128,6 +168,9 @@ func NewAccount(
 	name string,
 	getSigningConfiguration func() (*signing.Configuration, error),
 	keystores keystore.Keystores,
+	dustLimit btcutil.Amount,
+	gapLimits GapLimits,
+	txOrdering maketx.TxOrdering,
 	onEvent func(Event),
 	log *logrus.Entry,
 ) *Account {
@@ -143,6 +186,9 @@ func NewAccount(
 		getSigningConfiguration: getSigningConfiguration,
 		signingConfiguration:    nil,
 		keystores:               keystores,
+		dustLimit:               dustLimit,
+		gapLimits:               gapLimits,
+		txOrdering:              txOrdering,
 
 		// feeTargets must be sorted by ascending priority.
 		feeTargets: []*FeeTarget{
@@ -151,20 +197,26 @@ func NewAccount(
 			{Blocks: 6, Code: FeeTargetCodeNormal},
 			{Blocks: 2, Code: FeeTargetCodeHigh},
 		},
+		mempoolSpace: newMempoolSpace(coin.Net()),
 		// initializing to false, to prevent flashing of offline notification in the frontend
 		offline:     false,
 		initialized: false,
+		incomingTxs: map[string]struct{}{},
 		onEvent:     onEvent,
 		log:         log,
 	}
 	account.synchronizer = synchronizer.NewSynchronizer(
 		func() { onEvent(EventSyncStarted) },
 		func() {
+			// Transactions found during the initial sync are not new to the user.
+			notifyIncoming := account.initialized
 			if !account.initialized {
 				account.initialized = true
 				onEvent(EventStatusChanged)
 			}
 			onEvent(EventSyncDone)
+			// Transactions() waits for the sync, which can only finish once this returns.
+			go account.checkIncomingTransactions(notifyIncoming)
 		},
 		log,
 	)
@@ -186,11 +238,25 @@ func (account *Account) Name() string {
 	return account.name
 }
 
+// notifySignProgress notifies the observers about the progress of signing a transaction.
+func (account *Account) notifySignProgress(progress SignProgress) {
+	account.Notify(observable.Event{
+		Subject: fmt.Sprintf("account/%s/sign-progress", account.code),
+		Action:  action.Replace,
+		Object:  progress,
+	})
+}
+
 // Coin returns the coin of the account.
 func (account *Account) Coin() coin.Coin {
 	return account.coin
 }
 
+// dbName is the filename of the database of the account. The signing configuration must be set.
+func (account *Account) dbName() string {
+	return fmt.Sprintf("account-%s-%s.db", account.signingConfiguration.Hash(), account.code)
+}
+
 // Initialize initializes the account.
 func (account *Account) Initialize() error {
 	alreadyInitialized, err := func() (bool, error) {
@@ -213,7 +279,7 @@ func (account *Account) Initialize() error {
 		account.log.Debug("Account has already been initialized")
 		return nil
 	}
-	dbName := fmt.Sprintf("account-%s-%s.db", account.signingConfiguration.Hash(), account.code)
+	dbName := account.dbName()
 	account.log.Debugf("Opening the database '%s' to persist the transactions.", dbName)
 	db, err := transactionsdb.NewDB(path.Join(account.dbFolder, dbName))
 	if err != nil {
@@ -267,6 +333,14 @@ func (account *Account) Initialize() error {
 		fixGapLimit = 60
 		account.log.Warning("increased change gap limit to 20 and gap limit to 60 for BWS compatibility")
 	}
+	if account.gapLimits.Receive > 0 {
+		fixGapLimit = account.gapLimits.Receive
+	}
+	if account.gapLimits.Change > 0 {
+		fixChangeGapLimit = account.gapLimits.Change
+	}
+	account.log.WithField("gap-limit", fixGapLimit).WithField("change-gap-limit", fixChangeGapLimit).
+		Info("Using gap limits")
 
 	account.receiveAddresses = addresses.NewAddressChain(
 		account.signingConfiguration, account.coin.Net(), fixGapLimit, 0, account.log)
@@ -298,6 +372,10 @@ func (account *Account) xpubVersionForScriptType(scriptType signing.ScriptType)
 		return version
 	case chaincfg.TestNet3Params.Net:
 		return chaincfg.TestNet3Params.HDPublicKeyID
+	case signet.Params.Net:
+		return signet.Params.HDPublicKeyID
+	case chaincfg.RegressionNetParams.Net:
+		return chaincfg.RegressionNetParams.HDPublicKeyID
 	case ltc.TestNet4Params.Net:
 		return ltc.TestNet4Params.HDPublicKeyID
 	default:
@@ -372,35 +450,111 @@ func (account *Account) Close() {
 	account.onEvent(EventStatusChanged)
 }
 
+// ClearTransactions deletes the synced transactions from the database of the account, so that
+// they are fetched again from the blockchain backend when the account is initialized the next
+// time. Notes and labels are kept. The account must be closed. Since the headers are shared by all
+// accounts of the coin and verified on their own, they are kept, but all transactions are verified
+// against them again.
+func (account *Account) ClearTransactions() error {
+	if account.signingConfiguration == nil {
+		// The account was never initialized, so there is nothing to clear.
+		return nil
+	}
+	db, err := transactionsdb.NewDB(path.Join(account.dbFolder, account.dbName()))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			account.log.WithError(err).Error("couldn't close db")
+		}
+	}()
+	dbTx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+	if err := dbTx.ClearTransactions(); err != nil {
+		return err
+	}
+	account.log.Info("Cleared the transactions")
+	return dbTx.Commit()
+}
+
+// setFeeTarget sets the fee rate of the given fee target.
+func (account *Account) setFeeTarget(feeTarget *FeeTarget, feeRatePerKb btcutil.Amount) error {
+	defer account.Lock()()
+	feeTarget.FeeRatePerKb = &feeRatePerKb
+	account.log.WithFields(logrus.Fields{"blocks": feeTarget.Blocks,
+		"fee-rate-per-kb": feeRatePerKb}).Debug("Fee estimate per kb")
+	account.onEvent(EventFeeTargetsChanged)
+	return nil
+}
+
+// estimateFee sets the fee rate of the given fee target to the estimate of the blockchain node.
+func (account *Account) estimateFee(feeTarget *FeeTarget) {
+	setFee := func(feeRatePerKb btcutil.Amount) error {
+		return account.setFeeTarget(feeTarget, feeRatePerKb)
+	}
+	account.blockchain.EstimateFee(
+		feeTarget.Blocks,
+		func(feeRatePerKb *btcutil.Amount) error {
+			if feeRatePerKb == nil {
+				if account.code != "tltc" {
+					account.log.WithField("fee-target", feeTarget.Blocks).
+						Warning("Fee could not be estimated. Taking the minimum relay fee instead")
+				}
+				account.blockchain.RelayFee(setFee, func() {})
+				return nil
+			}
+			return setFee(*feeRatePerKb)
+		},
+		func() {},
+	)
+}
+
+// updateFeeTargets updates the fee rates of the fee targets based on the mempool. If the server
+// does not report its mempool, the fee rates of mempool.space are used if available for the coin.
 func (account *Account) updateFeeTargets() {
-	defer account.RLock()()
-	for _, feeTarget := range account.feeTargets {
-		func(feeTarget *FeeTarget) {
-			setFee := func(feeRatePerKb btcutil.Amount) error {
-				defer account.Lock()()
-				feeTarget.FeeRatePerKb = &feeRatePerKb
-				account.log.WithFields(logrus.Fields{"blocks": feeTarget.Blocks,
-					"fee-rate-per-kb": feeRatePerKb}).Debug("Fee estimate per kb")
-				account.onEvent(EventFeeTargetsChanged)
+	account.blockchain.FeeHistogram(
+		func(histogram blockchain.FeeHistogram) error {
+			if len(histogram) == 0 && account.mempoolSpace != nil {
+				// Don't block the response handling of the blockchain client.
+				go account.updateFeeTargetsFromMempoolSpace()
 				return nil
 			}
+			account.updateFeeTargetsFromHistogram(histogram)
+			return nil
+		},
+		func() {},
+	)
+}
+
+// updateFeeTargetsFromHistogram uses the fee rate needed to outbid the mempool up to the depth of
+// each target. The estimate of the blockchain node is used for targets which are deeper than the
+// mempool.
+func (account *Account) updateFeeTargetsFromHistogram(histogram blockchain.FeeHistogram) {
+	for _, feeTarget := range account.feeTargets {
+		feeRatePerKb := feeRateFromHistogram(histogram, feeTarget.Blocks)
+		if feeRatePerKb == nil {
+			account.estimateFee(feeTarget)
+			continue
+		}
+		_ = account.setFeeTarget(feeTarget, *feeRatePerKb)
+	}
+}
 
-			account.blockchain.EstimateFee(
-				feeTarget.Blocks,
-				func(feeRatePerKb *btcutil.Amount) error {
-					if feeRatePerKb == nil {
-						if account.code != "tltc" {
-							account.log.WithField("fee-target", feeTarget.Blocks).
-								Warning("Fee could not be estimated. Taking the minimum relay fee instead")
-						}
-						account.blockchain.RelayFee(setFee, func() {})
-						return nil
-					}
-					return setFee(*feeRatePerKb)
-				},
-				func() {},
-			)
-		}(feeTarget)
+func (account *Account) updateFeeTargetsFromMempoolSpace() {
+	fees, err := account.mempoolSpace.RecommendedFees()
+	if err != nil {
+		account.log.WithError(err).Warning("Failed to get the recommended fees of mempool.space")
+		for _, feeTarget := range account.feeTargets {
+			account.estimateFee(feeTarget)
+		}
+		return
+	}
+	for _, feeTarget := range account.feeTargets {
+		_ = account.setFeeTarget(feeTarget, fees.FeeRatePerKb(feeTarget.Blocks))
 	}
 }
 
@@ -525,6 +679,10 @@ func (account *Account) subscribeAddress(
 		return err
 	}
 	address.HistoryStatus = addressHistory.Status()
+	address.Label, err = dbTx.AddressLabel(address.PubkeyScriptHashHex())
+	if err != nil {
+		return err
+	}
 
 	account.blockchain.ScriptHashSubscribe(
 		account.synchronizer.IncRequestsCounter,
@@ -534,6 +692,14 @@ func (account *Account) subscribeAddress(
 	return nil
 }
 
+// Used blocks until the account is synced and returns whether it has any transactions. It is used
+// to discover accounts.
+func (account *Account) Used() bool {
+	account.synchronizer.WaitSynchronized()
+	return len(account.transactions.Transactions(
+		func(blockchain.ScriptHashHex) bool { return false })) > 0
+}
+
 // Transactions wraps transaction.Transactions.Transactions()
 func (account *Account) Transactions() []coin.Transaction {
 	transactions := account.transactions.Transactions(
@@ -547,6 +713,36 @@ func (account *Account) Transactions() []coin.Transaction {
 	return cast
 }
 
+// checkIncomingTransactions looks for unconfirmed incoming transactions which were not seen before
+// and fires EventIncomingTransaction if notify is true and there are any. As all receive addresses
+// are subscribed to, they are found as soon as they enter the mempool.
+func (account *Account) checkIncomingTransactions(notify bool) {
+	found := false
+	for _, transaction := range account.Transactions() {
+		if transaction.Type() != coin.TxTypeReceive || transaction.NumConfirmations() > 0 {
+			continue
+		}
+		isNew := func() bool {
+			defer account.Lock()()
+			if _, ok := account.incomingTxs[transaction.ID()]; ok {
+				return false
+			}
+			account.incomingTxs[transaction.ID()] = struct{}{}
+			return true
+		}()
+		if !isNew {
+			continue
+		}
+		found = true
+		if notify {
+			account.log.WithField("txID", transaction.ID()).Info("Incoming transaction in the mempool")
+		}
+	}
+	if found && notify {
+		account.onEvent(EventIncomingTransaction)
+	}
+}
+
 // GetUnusedReceiveAddresses returns a number of unused addresses.
 func (account *Account) GetUnusedReceiveAddresses() []coin.Address {
 	account.synchronizer.WaitSynchronized()
@@ -554,7 +750,11 @@ func (account *Account) GetUnusedReceiveAddresses() []coin.Address {
 	account.log.Debug("Get unused receive address")
 	addresses := []coin.Address{}
 	// Limit to `gapLimit` receive addresses, even if the actual limit is higher when scanning.
-	for _, address := range account.receiveAddresses.GetUnused()[:gapLimit] {
+	unusedAddresses := account.receiveAddresses.GetUnused()
+	if len(unusedAddresses) > gapLimit {
+		unusedAddresses = unusedAddresses[:gapLimit]
+	}
+	for _, address := range unusedAddresses {
 		addresses = append(addresses, address)
 	}
 	return addresses
@@ -576,6 +776,39 @@ func (account *Account) VerifyAddress(addressID string) (bool, error) {
 	return false, nil
 }
 
+// AddressLabels implements Interface.
+func (account *Account) AddressLabels() map[string]string {
+	defer account.RLock()()
+	labels := map[string]string{}
+	for _, address := range account.receiveAddresses.Labeled() {
+		labels[address.EncodeForHumans()] = address.Label
+	}
+	return labels
+}
+
+// SetAddressLabel implements Interface. The label is persisted in the account database.
+func (account *Account) SetAddressLabel(addressID string, label string) error {
+	defer account.Lock()()
+	scriptHashHex := blockchain.ScriptHashHex(addressID)
+	address := account.receiveAddresses.LookupByScriptHashHex(scriptHashHex)
+	if address == nil {
+		return errp.New("unknown address not found")
+	}
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+	if err := dbTx.PutAddressLabel(scriptHashHex, label); err != nil {
+		return err
+	}
+	if err := dbTx.Commit(); err != nil {
+		return err
+	}
+	address.Label = label
+	return nil
+}
+
 // ConvertToLegacyAddress converts a ltc p2sh address to the legacy format (starting with
 // '3'). Returns an error for non litecoin p2sh accounts.
 func (account *Account) ConvertToLegacyAddress(addressID string) (btcutil.Address, error) {
@@ -621,15 +854,36 @@ func (p *byValue) Swap(i, j int) { p.outputs[i], p.outputs[j] = p.outputs[j], p.
 type SpendableOutput struct {
 	*transactions.SpendableOutput
 	OutPoint wire.OutPoint
+	// Label is the label of the output, or empty if it has none.
+	Label string
+	// Frozen is true if the output is excluded from being spent, see SetOutputFrozen.
+	Frozen bool
+	// FreezeReason is the reason why the output was frozen. It can be empty.
+	FreezeReason string
 }
 
 // SpendableOutputs returns the utxo set, sorted by the value descending.
 func (account *Account) SpendableOutputs() []*SpendableOutput {
 	account.synchronizer.WaitSynchronized()
 	defer account.RLock()()
+	labels, err := account.outputLabels()
+	if err != nil {
+		account.log.WithError(err).Error("Failed to retrieve the output labels")
+	}
+	frozenOutputs, err := account.frozenOutputs()
+	if err != nil {
+		account.log.WithError(err).Error("Failed to retrieve the frozen outputs")
+	}
 	result := []*SpendableOutput{}
 	for outPoint, txOut := range account.transactions.SpendableOutputs() {
-		result = append(result, &SpendableOutput{OutPoint: outPoint, SpendableOutput: txOut})
+		freezeReason, frozen := frozenOutputs[outPoint]
+		result = append(result, &SpendableOutput{
+			OutPoint:        outPoint,
+			SpendableOutput: txOut,
+			Label:           labels[outPoint],
+			Frozen:          frozen,
+			FreezeReason:    freezeReason,
+		})
 	}
 	sort.Sort(sort.Reverse(&byValue{result}))
 	return result
@@ -15,6 +15,7 @@
 package addresses
 
 import (
+	"crypto/sha256"
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -24,6 +25,7 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
 	"github.com/sirupsen/logrus"
 )
@@ -41,9 +43,15 @@ type AccountAddress struct {
 	// https://github.com/kyuupichan/electrumx/blob/46f245891cb62845f9eec0f9549526a7e569eb03/docs/protocol-basics.rst#status.
 	HistoryStatus string
 
+	// Label is a label given by the user, e.g. to track which counterparty pays to the address.
+	Label string
+
 	// redeemScript stores the redeem script of a BIP16 P2SH output or nil if address type is P2PKH.
 	redeemScript []byte
 
+	// witnessScript stores the witness script of a P2WSH output or nil for other address types.
+	witnessScript []byte
+
 	log *logrus.Entry
 }
 
@@ -61,6 +69,7 @@ func NewAccountAddress(
 
 	var err error
 	var redeemScript []byte
+	var witnessScript []byte
 	var address btcutil.Address
 
 	if configuration.Multisig() {
@@ -72,13 +81,24 @@ func NewAccountAddress(
 				log.WithError(err).Panic("Failed to get a P2PK address from a public key.")
 			}
 		}
-		redeemScript, err = txscript.MultiSigScript(addresses, configuration.SigningThreshold())
+		var multisigScript []byte
+		multisigScript, err = txscript.MultiSigScript(addresses, configuration.SigningThreshold())
 		if err != nil {
-			log.WithError(err).Panic("Failed to get the redeem script for multisig.")
+			log.WithError(err).Panic("Failed to get the script for multisig.")
 		}
-		address, err = btcutil.NewAddressScriptHash(redeemScript, net)
-		if err != nil {
-			log.WithError(err).Panic("Failed to get a P2SH address for multisig.")
+		if configuration.P2WSH() {
+			witnessScript = multisigScript
+			scriptHash := sha256.Sum256(witnessScript)
+			address, err = btcutil.NewAddressWitnessScriptHash(scriptHash[:], net)
+			if err != nil {
+				log.WithError(err).Panic("Failed to get a P2WSH address for multisig.")
+			}
+		} else {
+			redeemScript = multisigScript
+			address, err = btcutil.NewAddressScriptHash(redeemScript, net)
+			if err != nil {
+				log.WithError(err).Panic("Failed to get a P2SH address for multisig.")
+			}
 		}
 	} else {
 		publicKeyHash := btcutil.Hash160(configuration.PublicKeys()[0].SerializeCompressed())
@@ -107,6 +127,16 @@ func NewAccountAddress(
 			if err != nil {
 				log.WithError(err).Panic("Failed to get p2wpkh addr. from publ. key hash.")
 			}
+		case signing.ScriptTypeP2TR:
+			var outputKey []byte
+			outputKey, err = taproot.OutputKey(configuration.PublicKeys()[0])
+			if err != nil {
+				log.WithError(err).Panic("Failed to get the taproot output key from public key.")
+			}
+			address, err = taproot.NewAddressTaproot(outputKey, net)
+			if err != nil {
+				log.WithError(err).Panic("Failed to get p2tr addr. from output key.")
+			}
 		default:
 			log.Panic(fmt.Sprintf("Unrecognized script type: %s", configuration.ScriptType()))
 		}
@@ -117,6 +147,7 @@ func NewAccountAddress(
 		Configuration: configuration,
 		HistoryStatus: "",
 		redeemScript:  redeemScript,
+		witnessScript: witnessScript,
 		log:           log,
 	}
 }
@@ -137,7 +168,7 @@ func (address *AccountAddress) isUsed() bool {
 
 // PubkeyScript returns the pubkey script of this address. Use this in a tx output to receive funds.
 func (address *AccountAddress) PubkeyScript() []byte {
-	script, err := txscript.PayToAddrScript(address.Address)
+	script, err := taproot.PayToAddrScript(address.Address)
 	if err != nil {
 		address.log.WithError(err).Panic("Failed to get the pubkey script for an address.")
 	}
@@ -150,20 +181,48 @@ func (address *AccountAddress) PubkeyScriptHashHex() blockchain.ScriptHashHex {
 	return blockchain.ScriptHashHex(chainhash.HashH(address.PubkeyScript()).String())
 }
 
-// ScriptForHashToSign returns whether this address is a segwit output and the script used when
+// RedeemScript returns the redeem script of a P2SH address, or nil for other address types.
+func (address *AccountAddress) RedeemScript() []byte {
+	return address.redeemScript
+}
+
+// WitnessScript returns the witness script of a P2WSH address, or nil for other address types.
+func (address *AccountAddress) WitnessScript() []byte {
+	return address.witnessScript
+}
+
+// SighashVersion determines which algorithm is used to compute the hash to be signed when spending
+// from an address.
+type SighashVersion int
+
+const (
+	// SighashVersionLegacy is the pre-segwit signature hash.
+	SighashVersionLegacy SighashVersion = iota
+	// SighashVersionSegwitV0 is the BIP143 signature hash.
+	SighashVersionSegwitV0
+	// SighashVersionTaproot is the BIP341 signature hash.
+	SighashVersionTaproot
+)
+
+// ScriptForHashToSign returns the sighash version of this address and the script used when
 // calculating the hash to be signed in a transaction. This info is needed when trying to spend
 // from this address.
-func (address *AccountAddress) ScriptForHashToSign() (bool, []byte) {
+func (address *AccountAddress) ScriptForHashToSign() (SighashVersion, []byte) {
+	if address.Configuration.P2WSH() {
+		return SighashVersionSegwitV0, address.witnessScript
+	}
 	if address.Configuration.Multisig() {
-		return false, address.redeemScript
+		return SighashVersionLegacy, address.redeemScript
 	}
 	switch address.Configuration.ScriptType() {
 	case signing.ScriptTypeP2PKH:
-		return false, address.PubkeyScript()
+		return SighashVersionLegacy, address.PubkeyScript()
 	case signing.ScriptTypeP2WPKHP2SH:
-		return true, address.redeemScript
+		return SighashVersionSegwitV0, address.redeemScript
 	case signing.ScriptTypeP2WPKH:
-		return true, address.PubkeyScript()
+		return SighashVersionSegwitV0, address.PubkeyScript()
+	case signing.ScriptTypeP2TR:
+		return SighashVersionTaproot, address.PubkeyScript()
 	default:
 		address.log.Panic("Unrecognized address type.")
 	}
@@ -181,8 +240,11 @@ func index(publicKey *btcec.PublicKey, sortedPublicKeys []*btcec.PublicKey) int
 
 // SignatureScript returns the signature script (and witness) needed to spend from this address.
 // The signatures have to be provided in the order of the configuration (and some can be nil).
+// sigHashType is the sighash type the signatures commit to. The signature of a taproot address is
+// a Schnorr signature, see taproot.SignatureFromBytes.
 func (address *AccountAddress) SignatureScript(
 	signatures []*btcec.Signature,
+	sigHashType txscript.SigHashType,
 ) ([]byte, wire.TxWitness) {
 	if len(signatures) != address.Configuration.NumberOfSigners() {
 		address.log.Panic("The wrong number of signatures were provided.")
@@ -195,10 +257,20 @@ func (address *AccountAddress) SignatureScript(
 		for i := 0; i < length; i++ {
 			sortedSignatures[index(publicKeys[i], sortedPublicKeys)] = signatures[i]
 		}
+		if address.Configuration.P2WSH() {
+			// The empty element is consumed by the off-by-one bug of OP_CHECKMULTISIG.
+			txWitness := wire.TxWitness{[]byte{}}
+			for _, signature := range sortedSignatures {
+				if signature != nil {
+					txWitness = append(txWitness, append(signature.Serialize(), byte(sigHashType)))
+				}
+			}
+			return []byte{}, append(txWitness, address.witnessScript)
+		}
 		scriptBuilder := txscript.NewScriptBuilder().AddOp(txscript.OP_0)
 		for _, signature := range sortedSignatures {
 			if signature != nil {
-				scriptBuilder.AddData(append(signature.Serialize(), byte(txscript.SigHashAll)))
+				scriptBuilder.AddData(append(signature.Serialize(), byte(sigHashType)))
 			}
 		}
 		signatureScript, err := scriptBuilder.AddData(address.redeemScript).Script()
@@ -215,7 +287,7 @@ func (address *AccountAddress) SignatureScript(
 	switch address.Configuration.ScriptType() {
 	case signing.ScriptTypeP2PKH:
 		signatureScript, err := txscript.NewScriptBuilder().
-			AddData(append(signature.Serialize(), byte(txscript.SigHashAll))).
+			AddData(append(signature.Serialize(), byte(sigHashType))).
 			AddData(publicKey.SerializeCompressed()).
 			Script()
 		if err != nil {
@@ -230,16 +302,24 @@ func (address *AccountAddress) SignatureScript(
 			address.log.WithError(err).Panic("Failed to build segwit signature script.")
 		}
 		txWitness := wire.TxWitness{
-			append(signature.Serialize(), byte(txscript.SigHashAll)),
+			append(signature.Serialize(), byte(sigHashType)),
 			publicKey.SerializeCompressed(),
 		}
 		return signatureScript, txWitness
 	case signing.ScriptTypeP2WPKH:
 		txWitness := wire.TxWitness{
-			append(signature.Serialize(), byte(txscript.SigHashAll)),
+			append(signature.Serialize(), byte(sigHashType)),
 			publicKey.SerializeCompressed(),
 		}
 		return []byte{}, txWitness
+	case signing.ScriptTypeP2TR:
+		// The signature is a Schnorr signature, whose sighash type is omitted if it is
+		// SigHashDefault (BIP341).
+		taprootSignature := taproot.SerializeSignature(signature)
+		if sigHashType != taproot.SigHashDefault {
+			taprootSignature = append(taprootSignature, byte(sigHashType))
+		}
+		return []byte{}, wire.TxWitness{taprootSignature}
 	default:
 		address.log.Panic("Unrecognized address type.")
 	}
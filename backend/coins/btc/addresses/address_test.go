@@ -15,13 +15,20 @@
 package addresses_test
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
 	"testing"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses/test"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
@@ -61,3 +68,71 @@ func (s *addressTestSuite) TestScriptHashHex() {
 		blockchain.ScriptHashHex("0466d0029406f583feadaccb91c7b5b855eb5d6782316cafa4f390b7c784436b"),
 		s.address.PubkeyScriptHashHex())
 }
+
+// TestNewAddressP2TR checks the first receive and change addresses against the BIP86 test vectors.
+func TestNewAddressP2TR(t *testing.T) {
+	accountXPub, err := hdkeychain.NewKeyFromString(
+		"xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ")
+	require.NoError(t, err)
+	accountKeypath, err := signing.NewAbsoluteKeypath("m/86'/0'/0'")
+	require.NoError(t, err)
+	accountConfiguration := signing.NewSinglesigConfiguration(
+		signing.ScriptTypeP2TR, accountKeypath, accountXPub)
+	for _, vector := range []struct {
+		keypath      string
+		pubkeyScript string
+		address      string
+	}{
+		{
+			keypath:      "0/0",
+			pubkeyScript: "5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+			address:      "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+		},
+		{
+			keypath:      "0/1",
+			pubkeyScript: "5120a82f29944d65b86ae6b5e5cc75e294ead6c59391a1edc5e016e3498c67fc7bbb",
+			address:      "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh",
+		},
+		{
+			keypath:      "1/0",
+			pubkeyScript: "5120882d74e5d0572d5a816cef0041a96b6c1de832f6f9676d9605c44d5e9a97d3dc",
+			address:      "bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7",
+		},
+	} {
+		relativeKeypath, err := signing.NewRelativeKeypath(vector.keypath)
+		require.NoError(t, err)
+		configuration, err := accountConfiguration.Derive(relativeKeypath)
+		require.NoError(t, err)
+		address := addresses.NewAccountAddress(
+			configuration, &chaincfg.MainNetParams, logging.Get().WithGroup("addresses_test"))
+		require.Equal(t, vector.address, address.EncodeAddress())
+		require.Equal(t, vector.pubkeyScript, hex.EncodeToString(address.PubkeyScript()))
+		require.True(t, address.IsForNet(&chaincfg.MainNetParams))
+		require.False(t, address.IsForNet(&chaincfg.TestNet3Params))
+		require.Nil(t, address.RedeemScript())
+	}
+}
+
+func TestNewAddressP2WSH(t *testing.T) {
+	address := test.GetP2WSHMultisigAddress(2, 3)
+	publicKeys := address.Configuration.SortedPublicKeys()
+	multisigAddresses := make([]*btcutil.AddressPubKey, len(publicKeys))
+	for index, publicKey := range publicKeys {
+		var err error
+		multisigAddresses[index], err = btcutil.NewAddressPubKey(publicKey.SerializeCompressed(), net)
+		require.NoError(t, err)
+	}
+	witnessScript, err := txscript.MultiSigScript(multisigAddresses, 2)
+	require.NoError(t, err)
+	require.Equal(t, witnessScript, address.WitnessScript())
+	require.Nil(t, address.RedeemScript())
+
+	scriptHash := sha256.Sum256(witnessScript)
+	require.Equal(t, append([]byte{txscript.OP_0, txscript.OP_DATA_32}, scriptHash[:]...),
+		address.PubkeyScript())
+	require.True(t, strings.HasPrefix(address.EncodeAddress(), "tb1q"))
+
+	sighashVersion, script := address.ScriptForHashToSign()
+	require.Equal(t, addresses.SighashVersionSegwitV0, sighashVersion)
+	require.Equal(t, witnessScript, script)
+}
@@ -111,6 +111,17 @@ func (addresses *AddressChain) LookupByScriptHashHex(hashHex blockchain.ScriptHa
 	return nil
 }
 
+// Labeled returns the addresses which have a label.
+func (addresses *AddressChain) Labeled() []*AccountAddress {
+	labeled := []*AccountAddress{}
+	for _, address := range addresses.addresses {
+		if address.Label != "" {
+			labeled = append(labeled, address)
+		}
+	}
+	return labeled
+}
+
 // EnsureAddresses appends addresses to the address chain until there are `gapLimit` unused unused
 // ones, and returns the new addresses.
 func (addresses *AddressChain) EnsureAddresses() []*AccountAddress {
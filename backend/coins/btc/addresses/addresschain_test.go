@@ -127,3 +127,10 @@ func (s *addressChainTestSuite) TestEnsureAddresses() {
 	newAddresses[s.gapLimit-1].HistoryStatus = "used"
 	require.Len(s.T(), s.addresses.EnsureAddresses(), s.gapLimit)
 }
+
+func (s *addressChainTestSuite) TestLabeled() {
+	newAddresses := s.addresses.EnsureAddresses()
+	require.Empty(s.T(), s.addresses.Labeled())
+	newAddresses[1].Label = "Alice"
+	require.Equal(s.T(), []*addresses.AccountAddress{newAddresses[1]}, s.addresses.Labeled())
+}
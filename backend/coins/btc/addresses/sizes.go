@@ -18,6 +18,9 @@ import "github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
 
 // SigScriptWitnessSize returns the maximum possible sigscript size for a given address type.
 func SigScriptWitnessSize(configuration *signing.Configuration) (int, bool) {
+	if configuration.P2WSH() {
+		return 0, true
+	}
 	if configuration.Multisig() {
 		// OP_N (1 byte, signingThreshold)
 		// numberOfSigners*(
@@ -59,6 +62,8 @@ func SigScriptWitnessSize(configuration *signing.Configuration) (int, bool) {
 		return 1 + redeemScriptSize, true
 	case signing.ScriptTypeP2WPKH:
 		return 0, true // hooray
+	case signing.ScriptTypeP2TR:
+		return 0, true
 	default:
 		panic("unknown address type")
 	}
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses/test"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
@@ -29,6 +30,7 @@ var scriptTypes = []signing.ScriptType{
 	signing.ScriptTypeP2PKH,
 	signing.ScriptTypeP2WPKHP2SH,
 	signing.ScriptTypeP2WPKH,
+	signing.ScriptTypeP2TR,
 }
 
 func TestSigScriptWitnessSize(t *testing.T) {
@@ -45,7 +47,7 @@ func TestSigScriptWitnessSize(t *testing.T) {
 		address := test.GetAddress(scriptType)
 		t.Run(address.Configuration.String(), func(t *testing.T) {
 			sigScriptSize, hasWitness := addresses.SigScriptWitnessSize(address.Configuration)
-			sigScript, witness := address.SignatureScript([]*btcec.Signature{sig})
+			sigScript, witness := address.SignatureScript([]*btcec.Signature{sig}, txscript.SigHashAll)
 			require.Equal(t, len(sigScript), sigScriptSize)
 			require.Equal(t, witness != nil, hasWitness)
 		})
@@ -63,10 +65,19 @@ func TestSigScriptWitnessSize(t *testing.T) {
 					sigs[numSigs] = sig
 				}
 				sigScriptSize, hasWitness := addresses.SigScriptWitnessSize(address.Configuration)
-				sigScript, _ := address.SignatureScript(sigs)
+				sigScript, _ := address.SignatureScript(sigs, txscript.SigHashAll)
 				require.Equal(t, len(sigScript), sigScriptSize)
 				require.False(t, hasWitness)
 			})
+			address = test.GetP2WSHMultisigAddress(signingThreshold, numberOfSigners)
+			t.Run(address.Configuration.String(), func(t *testing.T) {
+				sigScriptSize, hasWitness := addresses.SigScriptWitnessSize(address.Configuration)
+				sigScript, witness := address.SignatureScript(
+					make([]*btcec.Signature, numberOfSigners), txscript.SigHashAll)
+				require.Equal(t, len(sigScript), sigScriptSize)
+				require.True(t, hasWitness)
+				require.NotNil(t, witness)
+			})
 		}
 	}
 }
@@ -64,8 +64,18 @@ func GetAddress(scriptType signing.ScriptType) *addresses.AccountAddress {
 	)
 }
 
-// GetMultisigAddress returns a dummy multisig address.
+// GetMultisigAddress returns a dummy P2SH multisig address.
 func GetMultisigAddress(signingThreshold, numberOfSigners int) *addresses.AccountAddress {
+	return getMultisigAddress(signing.ScriptTypeP2PKH, signingThreshold, numberOfSigners)
+}
+
+// GetP2WSHMultisigAddress returns a dummy P2WSH multisig address.
+func GetP2WSHMultisigAddress(signingThreshold, numberOfSigners int) *addresses.AccountAddress {
+	return getMultisigAddress(signing.ScriptTypeP2WSH, signingThreshold, numberOfSigners)
+}
+
+func getMultisigAddress(
+	scriptType signing.ScriptType, signingThreshold, numberOfSigners int) *addresses.AccountAddress {
 	xpubs := make([]*hdkeychain.ExtendedKey, numberOfSigners)
 	for i := range xpubs {
 		seed, err := hdkeychain.GenerateSeed(32)
@@ -82,7 +92,7 @@ func GetMultisigAddress(signingThreshold, numberOfSigners int) *addresses.Accoun
 		}
 		xpubs[i] = xpub
 	}
-	configuration := signing.NewConfiguration(signing.ScriptTypeP2PKH, absoluteKeypath, xpubs, signingThreshold)
+	configuration := signing.NewConfiguration(scriptType, absoluteKeypath, xpubs, signingThreshold)
 	return addresses.NewAccountAddress(
 		configuration,
 		net,
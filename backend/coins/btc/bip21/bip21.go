@@ -0,0 +1,85 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bip21 parses payment URIs as specified in BIP21, e.g.
+// bitcoin:<address>?amount=<amount>&label=<label>&message=<message>.
+package bip21
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// amountRegexp matches decimal amounts without exponent, as required by BIP21.
+var amountRegexp = regexp.MustCompile(`^([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+// URI is a parsed payment URI.
+type URI struct {
+	Address string
+	// Amount is the amount in the unit of the coin (e.g. BTC) as given in the URI. It is empty if
+	// the URI does not request an amount.
+	Amount  string
+	Label   string
+	Message string
+	// Params are the other parameters of the URI, e.g. "pj" for payjoin.
+	Params map[string]string
+}
+
+// Parse parses a payment URI with the given scheme, e.g. "bitcoin". The scheme is case
+// insensitive. URIs with required parameters ("req-" prefix) which are not supported are rejected.
+func Parse(uri string, scheme string) (*URI, error) {
+	prefix := scheme + ":"
+	if len(uri) < len(prefix) || !strings.EqualFold(uri[:len(prefix)], prefix) {
+		return nil, errp.Newf("The URI does not start with %s", prefix)
+	}
+	address, query := uri[len(prefix):], ""
+	if index := strings.Index(address, "?"); index >= 0 {
+		address, query = address[:index], address[index+1:]
+	}
+	// Some wallets prepend slashes like in bitcoin://<address>.
+	address = strings.TrimPrefix(address, "//")
+	if address == "" {
+		return nil, errp.New("The URI does not contain an address")
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	result := &URI{Address: address, Params: map[string]string{}}
+	for key, value := range values {
+		if len(value) != 1 {
+			return nil, errp.Newf("The parameter %s is given more than once", key)
+		}
+		switch key {
+		case "amount":
+			if !amountRegexp.MatchString(value[0]) {
+				return nil, errp.Newf("Invalid amount %s", value[0])
+			}
+			result.Amount = value[0]
+		case "label":
+			result.Label = value[0]
+		case "message":
+			result.Message = value[0]
+		default:
+			if strings.HasPrefix(key, "req-") {
+				return nil, errp.Newf("The required parameter %s is not supported", key)
+			}
+			result.Params[key] = value[0]
+		}
+	}
+	return result, nil
+}
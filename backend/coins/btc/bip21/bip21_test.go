@@ -0,0 +1,67 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bip21_test
+
+import (
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/bip21"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	uri, err := bip21.Parse("bitcoin:175tWpb8K1S7NmH4Zx6rewF9WQrcZv245W", "bitcoin")
+	require.NoError(t, err)
+	require.Equal(t, &bip21.URI{
+		Address: "175tWpb8K1S7NmH4Zx6rewF9WQrcZv245W",
+		Params:  map[string]string{},
+	}, uri)
+
+	uri, err = bip21.Parse(
+		"BITCOIN:175tWpb8K1S7NmH4Zx6rewF9WQrcZv245W?amount=20.3&label=Luke-Jr&message=Donation%20for%20project%20xyz&pj=https://example.com/pj",
+		"bitcoin")
+	require.NoError(t, err)
+	require.Equal(t, &bip21.URI{
+		Address: "175tWpb8K1S7NmH4Zx6rewF9WQrcZv245W",
+		Amount:  "20.3",
+		Label:   "Luke-Jr",
+		Message: "Donation for project xyz",
+		Params:  map[string]string{"pj": "https://example.com/pj"},
+	}, uri)
+
+	uri, err = bip21.Parse("litecoin:MQMcJhpWHYVeQArcZR3sBgyPZxxRtnH441?amount=.5", "litecoin")
+	require.NoError(t, err)
+	require.Equal(t, "MQMcJhpWHYVeQArcZR3sBgyPZxxRtnH441", uri.Address)
+	require.Equal(t, ".5", uri.Amount)
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, uri := range []string{
+		"",
+		"175tWpb8K1S7NmH4Zx6rewF9WQrcZv245W",
+		"litecoin:175tWpb8K1S7NmH4Zx6rewF9WQrcZv245W",
+		"bitcoin:",
+		"bitcoin:?amount=1",
+		"bitcoin:175tWpb8K1S7NmH4Zx6rewF9WQrcZv245W?amount=1e-3",
+		"bitcoin:175tWpb8K1S7NmH4Zx6rewF9WQrcZv245W?amount=-1",
+		"bitcoin:175tWpb8K1S7NmH4Zx6rewF9WQrcZv245W?amount=1,5",
+		"bitcoin:175tWpb8K1S7NmH4Zx6rewF9WQrcZv245W?amount=1&amount=2",
+		"bitcoin:175tWpb8K1S7NmH4Zx6rewF9WQrcZv245W?req-somethingyoudontunderstand=50",
+		"bitcoin:175tWpb8K1S7NmH4Zx6rewF9WQrcZv245W?label=%zz",
+	} {
+		_, err := bip21.Parse(uri, "bitcoin")
+		require.Error(t, err, uri)
+	}
+}
@@ -77,6 +77,14 @@ func (history TxHistory) Status() string {
 	return hex.EncodeToString(chainhash.HashB(status.Bytes()))
 }
 
+// UTXO is an unspent output of a script, as returned by ScriptHashListUnspent.
+type UTXO struct {
+	TXPos  int    `json:"tx_pos"`
+	Value  int64  `json:"value"`
+	TXHash TXHash `json:"tx_hash"`
+	Height int    `json:"height"`
+}
+
 // ScriptHashHex is the hash of a pkScript in reverse hex format.
 type ScriptHashHex string
 
@@ -85,6 +93,17 @@ type Header struct {
 	BlockHeight int `json:"block_height"`
 }
 
+// FeeHistogramEntry is a fee rate bucket of the mempool.
+type FeeHistogramEntry struct {
+	// FeeRatePerKb is the lowest fee rate of the transactions in the bucket.
+	FeeRatePerKb btcutil.Amount
+	// VSize is the total virtual size of the transactions in the bucket.
+	VSize int64
+}
+
+// FeeHistogram describes the transactions in the mempool, sorted by decreasing fee rate.
+type FeeHistogram []*FeeHistogramEntry
+
 // Status is the connection status to the blockchain node
 type Status int
 
@@ -102,10 +121,12 @@ type Interface interface {
 	ScriptHashGetHistory(ScriptHashHex, func(TxHistory) error, func())
 	TransactionGet(chainhash.Hash, func(*wire.MsgTx) error, func())
 	ScriptHashSubscribe(func() func(), ScriptHashHex, func(string) error)
+	ScriptHashListUnspent(ScriptHashHex) ([]*UTXO, error)
 	HeadersSubscribe(func() func(), func(*Header) error)
 	TransactionBroadcast(*wire.MsgTx) error
 	RelayFee(func(btcutil.Amount) error, func())
 	EstimateFee(int, func(*btcutil.Amount) error, func())
+	FeeHistogram(func(FeeHistogram) error, func())
 	Headers(int, int, func([]*wire.BlockHeader, int) error, func())
 	GetMerkle(chainhash.Hash, int, func(merkle []TXHash, pos int) error, func())
 	Close()
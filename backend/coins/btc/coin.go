@@ -118,6 +118,25 @@ func (coin *Coin) Net() *chaincfg.Params {
 	return coin.net
 }
 
+// URIScheme returns the scheme of payment URIs (BIP21) of the coin.
+func (coin *Coin) URIScheme() string {
+	if strings.HasSuffix(coin.code, "ltc") {
+		return "litecoin"
+	}
+	return "bitcoin"
+}
+
+// SupportsTaproot returns whether taproot (BIP341) outputs can be spent on the network of the coin.
+// Litecoin has not activated taproot.
+func (coin *Coin) SupportsTaproot() bool {
+	switch coin.code {
+	case "btc", "tbtc", "sbtc", "rbtc":
+		return true
+	default:
+		return false
+	}
+}
+
 // Unit implements coin.Coin.
 func (coin *Coin) Unit() string {
 	return coin.unit
@@ -0,0 +1,138 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/sirupsen/logrus"
+)
+
+// ConsolidationArgs are the arguments needed to consolidate coins.
+type ConsolidationArgs struct {
+	// FeeTargetCode should be a low fee target, as consolidating only pays off if the coins are
+	// spent later at a higher fee rate.
+	FeeTargetCode FeeTargetCode
+	// CustomFee is the fee rate in sat/vB. It is only used if FeeTargetCode is FeeTargetCodeCustom.
+	CustomFee string
+	// SelectedUTXOs are the coins to consolidate. If empty, all unspent coins are consolidated.
+	SelectedUTXOs map[wire.OutPoint]struct{}
+}
+
+// ConsolidationResult contains the information about a proposed consolidation which is displayed
+// in the UI.
+type ConsolidationResult struct {
+	// Amount is the value of the consolidated output.
+	Amount coin.Amount
+	// Fee is the absolute fee paid by the consolidation.
+	Fee coin.Amount
+	// VSize is the estimated virtual size of the signed consolidation in vbytes.
+	VSize int
+	// InputCount is the number of consolidated coins.
+	InputCount int
+	// Savings is the fee saved by consolidating now compared to spending the coins later at the
+	// highest estimated fee rate. It is negative if the consolidation does not pay off, and nil if
+	// no fee rate has been estimated yet.
+	Savings *coin.Amount
+}
+
+// highestFeeRatePerKb returns the highest estimated fee rate of all fee targets, or nil if none has
+// been estimated.
+func (account *Account) highestFeeRatePerKb() *btcutil.Amount {
+	var highest *btcutil.Amount
+	for _, target := range account.feeTargets {
+		if target.FeeRatePerKb != nil && (highest == nil || *target.FeeRatePerKb > *highest) {
+			highest = target.FeeRatePerKb
+		}
+	}
+	return highest
+}
+
+// newConsolidationTx creates a transaction which spends the coins to be consolidated to a fresh
+// change address.
+func (account *Account) newConsolidationTx(args *ConsolidationArgs) (
+	map[wire.OutPoint]*transactions.SpendableOutput, *maketx.TxProposal, error) {
+	feeRatePerKb, err := account.txFeeRatePerKb(args.FeeTargetCode, args.CustomFee)
+	if err != nil {
+		return nil, nil, err
+	}
+	utxo, wireUTXO, err := account.coinControlOutputs(args.SelectedUTXOs)
+	if err != nil {
+		return nil, nil, err
+	}
+	txProposal, err := maketx.NewTxConsolidation(
+		account.coin,
+		account.signingConfiguration,
+		wireUTXO,
+		account.changeAddresses.GetUnused()[0],
+		feeRatePerKb,
+		account.dustLimit,
+		account.log,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	txProposal.SetOrdering(account.txOrdering)
+	maketx.SetAntiFeeSnipingLockTime(txProposal.Transaction, account.antiFeeSnipingTipHeight())
+	return utxo, txProposal, nil
+}
+
+// ConsolidationProposal creates a consolidation like Consolidate and returns information about it
+// for display in the UI, including the estimated fee savings.
+func (account *Account) ConsolidationProposal(args *ConsolidationArgs) (*ConsolidationResult, error) {
+	account.log.Debug("Proposing consolidation")
+	utxo, txProposal, err := account.newConsolidationTx(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := DryRunSignTransaction(txProposal, utxo, account.getAddress, nil); err != nil {
+		return nil, err
+	}
+	result := &ConsolidationResult{
+		Amount:     coin.NewAmountFromInt64(int64(txProposal.Amount)),
+		Fee:        coin.NewAmountFromInt64(int64(txProposal.Fee)),
+		VSize:      txProposal.VSize(),
+		InputCount: len(txProposal.Transaction.TxIn),
+	}
+	if futureFeePerKb := account.highestFeeRatePerKb(); futureFeePerKb != nil {
+		savings := coin.NewAmountFromInt64(int64(
+			maketx.ConsolidationSavings(txProposal, *futureFeePerKb, account.log)))
+		result.Savings = &savings
+	}
+	return result, nil
+}
+
+// Consolidate sweeps the coins to a fresh change address in a single transaction, so that fewer
+// inputs are needed to spend them later. Returns keystore.ErrSigningAborted on user abort.
+func (account *Account) Consolidate(args *ConsolidationArgs) error {
+	account.log.Info("Consolidating coins")
+	utxo, txProposal, err := account.newConsolidationTx(args)
+	if err != nil {
+		return errp.WithMessage(err, "Failed to create consolidation transaction")
+	}
+	if err := SignTransaction(account.keystores, txProposal, utxo, account.getAddress,
+		account.transactions.Transaction, nil, account.notifySignProgress, account.log); err != nil {
+		return errp.WithMessage(err, "Failed to sign consolidation transaction")
+	}
+	account.log.WithFields(logrus.Fields{
+		"inputs": len(txProposal.Transaction.TxIn),
+		"fee":    txProposal.Fee,
+	}).Info("Signed consolidation transaction is broadcasted")
+	return account.blockchain.TransactionBroadcast(txProposal.Transaction)
+}
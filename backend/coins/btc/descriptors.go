@@ -0,0 +1,313 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+const (
+	descriptorInputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// Descriptors are the output descriptors of the receive and change addresses of an account.
+type Descriptors struct {
+	Receive string `json:"receive"`
+	Change  string `json:"change"`
+}
+
+// descriptorPolymod is the checksum step of BIP-0380.
+func descriptorPolymod(checksum uint64, value uint64) uint64 {
+	top := checksum >> 35
+	checksum = (checksum&0x7ffffffff)<<5 ^ value
+	for index, generator := range []uint64{
+		0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd,
+	} {
+		if (top>>uint(index))&1 == 1 {
+			checksum ^= generator
+		}
+	}
+	return checksum
+}
+
+// descriptorChecksum returns the checksum of the descriptor as defined in BIP-0380.
+func descriptorChecksum(descriptor string) (string, error) {
+	checksum := uint64(1)
+	class, classCount := uint64(0), 0
+	for _, char := range descriptor {
+		position := strings.IndexRune(descriptorInputCharset, char)
+		if position < 0 {
+			return "", errp.Newf("Invalid character %q in descriptor.", char)
+		}
+		checksum = descriptorPolymod(checksum, uint64(position&31))
+		class = class*3 + uint64(position>>5)
+		classCount++
+		if classCount == 3 {
+			checksum = descriptorPolymod(checksum, class)
+			class, classCount = 0, 0
+		}
+	}
+	if classCount > 0 {
+		checksum = descriptorPolymod(checksum, class)
+	}
+	for index := 0; index < 8; index++ {
+		checksum = descriptorPolymod(checksum, 0)
+	}
+	checksum ^= 1
+	result := make([]byte, 8)
+	for index := range result {
+		result[index] = descriptorChecksumCharset[(checksum>>uint(5*(7-index)))&31]
+	}
+	return string(result), nil
+}
+
+// descriptorKeys returns the key expressions of the extended public keys of the configuration,
+// followed by the given derivation. The key origin is included for the cosigners whose master key
+// fingerprint is known.
+func descriptorKeys(
+	configuration *signing.Configuration,
+	net *chaincfg.Params,
+	fingerprints []uint32,
+	derivation string,
+) ([]string, error) {
+	keypath := strings.TrimPrefix(
+		strings.Replace(configuration.AbsoluteKeypath().Encode(), "'", "h", -1), "m/")
+	keys := make([]string, len(configuration.ExtendedPublicKeys()))
+	for index, extendedPublicKey := range configuration.ExtendedPublicKeys() {
+		// Descriptors use the version bytes of the network, not the ones of the script type.
+		extendedPublicKey, err := hdkeychain.NewKeyFromString(extendedPublicKey.String())
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		extendedPublicKey.SetNet(net)
+		origin := ""
+		if index < len(fingerprints) {
+			fingerprint := make([]byte, 4)
+			binary.LittleEndian.PutUint32(fingerprint, fingerprints[index])
+			origin = hex.EncodeToString(fingerprint)
+			if keypath != "" {
+				origin += "/" + keypath
+			}
+			origin = "[" + origin + "]"
+		}
+		keys[index] = origin + extendedPublicKey.String() + derivation
+	}
+	return keys, nil
+}
+
+// Descriptor returns the output descriptor of the configuration with its checksum. derivation is
+// the derivation following the extended public keys, e.g. `/0/*` for the receive addresses.
+func Descriptor(
+	configuration *signing.Configuration,
+	net *chaincfg.Params,
+	fingerprints []uint32,
+	derivation string,
+) (string, error) {
+	keys, err := descriptorKeys(configuration, net, fingerprints, derivation)
+	if err != nil {
+		return "", err
+	}
+	var result string
+	switch {
+	case configuration.Multisig():
+		script := fmt.Sprintf("sortedmulti(%d,%s)",
+			configuration.SigningThreshold(), strings.Join(keys, ","))
+		if configuration.P2WSH() {
+			result = "wsh(" + script + ")"
+		} else {
+			result = "sh(" + script + ")"
+		}
+	case configuration.ScriptType() == signing.ScriptTypeP2PKH:
+		result = "pkh(" + keys[0] + ")"
+	case configuration.ScriptType() == signing.ScriptTypeP2WPKHP2SH:
+		result = "sh(wpkh(" + keys[0] + "))"
+	case configuration.ScriptType() == signing.ScriptTypeP2WPKH:
+		result = "wpkh(" + keys[0] + ")"
+	case configuration.ScriptType() == signing.ScriptTypeP2TR:
+		result = "tr(" + keys[0] + ")"
+	default:
+		return "", errp.Newf("Descriptors are not supported for %s.", configuration.ScriptType())
+	}
+	checksum, err := descriptorChecksum(result)
+	if err != nil {
+		return "", err
+	}
+	return result + "#" + checksum, nil
+}
+
+// Descriptors returns the output descriptors of the account, including the key origins of the
+// keystores, so that the account can be imported as watch-only wallet into other wallets.
+func (account *Account) Descriptors() (*Descriptors, error) {
+	fingerprints, err := account.masterKeyFingerprints()
+	if err != nil {
+		return nil, err
+	}
+	receive, err := Descriptor(account.signingConfiguration, account.coin.Net(), fingerprints, "/0/*")
+	if err != nil {
+		return nil, err
+	}
+	change, err := Descriptor(account.signingConfiguration, account.coin.Net(), fingerprints, "/1/*")
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptors{Receive: receive, Change: change}, nil
+}
+
+// descriptorScripts are the script expressions of the supported descriptors. The multisig
+// expressions are followed by the threshold and the keys, the other ones by a single key.
+var descriptorScripts = []struct {
+	prefix     string
+	scriptType signing.ScriptType
+	multisig   bool
+}{
+	{"sh(wpkh(", signing.ScriptTypeP2WPKHP2SH, false},
+	{"wpkh(", signing.ScriptTypeP2WPKH, false},
+	{"pkh(", signing.ScriptTypeP2PKH, false},
+	{"tr(", signing.ScriptTypeP2TR, false},
+	{"wsh(sortedmulti(", signing.ScriptTypeP2WSH, true},
+	// The script type does not apply to P2SH multisig.
+	{"sh(sortedmulti(", signing.ScriptTypeP2PKH, true},
+}
+
+// parseDescriptorKey parses a key expression like `[d34db33f/84h/0h/0h]xpub.../0/*`. It returns
+// the keypath and the root fingerprint of the key origin, which are empty and nil if there is
+// none, and the extended public key. Only the derivations of the receive and change addresses can
+// follow the key.
+func parseDescriptorKey(expression string, net *chaincfg.Params) (
+	signing.AbsoluteKeypath, []byte, *hdkeychain.ExtendedKey, error) {
+	keypath := signing.NewEmptyAbsoluteKeypath()
+	var fingerprint []byte
+	if strings.HasPrefix(expression, "[") {
+		end := strings.Index(expression, "]")
+		if end < 0 {
+			return nil, nil, nil, errp.New("The key origin is not closed.")
+		}
+		origin := strings.Split(expression[1:end], "/")
+		var err error
+		fingerprint, err = hex.DecodeString(origin[0])
+		if err != nil || len(fingerprint) != 4 {
+			return nil, nil, nil, errp.New("Invalid fingerprint in the key origin.")
+		}
+		keypath, err = signing.NewAbsoluteKeypath(strings.NewReplacer("h", "'", "H", "'").Replace(
+			"m/" + strings.Join(origin[1:], "/")))
+		if err != nil {
+			return nil, nil, nil, errp.WithMessage(err, "Invalid keypath in the key origin")
+		}
+		expression = expression[end+1:]
+	}
+	key := expression
+	if index := strings.Index(expression, "/"); index >= 0 {
+		key = expression[:index]
+		switch expression[index:] {
+		case "/0/*", "/1/*", "/<0;1>/*":
+		default:
+			return nil, nil, nil, errp.New("The key has to be followed by /0/*, /1/* or /<0;1>/*.")
+		}
+	}
+	extendedPublicKey, err := hdkeychain.NewKeyFromString(key)
+	if err != nil {
+		return nil, nil, nil, errp.WithMessage(errp.WithStack(err), "Invalid extended public key")
+	}
+	if extendedPublicKey.IsPrivate() {
+		return nil, nil, nil, errp.New("The key is private. Only extended public keys are accepted.")
+	}
+	if !extendedPublicKey.IsForNet(net) {
+		return nil, nil, nil, errp.Newf("The extended public key %s is not for %s.", key, net.Name)
+	}
+	return keypath, fingerprint, extendedPublicKey, nil
+}
+
+// ParseDescriptor parses a singlesig or sorted multisig output descriptor into the signing
+// configuration of an account. If the descriptor has a checksum, it is verified. All keys need to
+// have the same key origin keypath, which becomes the keypath of the configuration.
+func ParseDescriptor(descriptor string, net *chaincfg.Params) (*signing.Configuration, error) {
+	configuration, _, err := ParseDescriptorWithFingerprints(descriptor, net)
+	return configuration, err
+}
+
+// ParseDescriptorWithFingerprints is like ParseDescriptor, but also returns the root fingerprints
+// of the key origins, in the order of the keys. The fingerprint of a key without origin is nil.
+func ParseDescriptorWithFingerprints(descriptor string, net *chaincfg.Params) (
+	*signing.Configuration, [][]byte, error) {
+	descriptor = strings.TrimSpace(descriptor)
+	if index := strings.Index(descriptor, "#"); index >= 0 {
+		expectedChecksum, err := descriptorChecksum(descriptor[:index])
+		if err != nil {
+			return nil, nil, err
+		}
+		if descriptor[index+1:] != expectedChecksum {
+			return nil, nil, errp.New("The checksum of the descriptor is invalid.")
+		}
+		descriptor = descriptor[:index]
+	}
+	for _, script := range descriptorScripts {
+		closing := strings.Repeat(")", strings.Count(script.prefix, "("))
+		if !strings.HasPrefix(descriptor, script.prefix) || !strings.HasSuffix(descriptor, closing) {
+			continue
+		}
+		expressions := strings.Split(descriptor[len(script.prefix):len(descriptor)-len(closing)], ",")
+		signingThreshold := 1
+		if script.multisig {
+			var err error
+			signingThreshold, err = strconv.Atoi(expressions[0])
+			if err != nil {
+				return nil, nil, errp.New("Invalid threshold of the multisig descriptor.")
+			}
+			expressions = expressions[1:]
+			if len(expressions) < 2 {
+				return nil, nil, errp.New("A multisig descriptor needs at least two keys.")
+			}
+			if signingThreshold < 1 || signingThreshold > len(expressions) {
+				return nil, nil, errp.Newf("The threshold has to be between 1 and %d.", len(expressions))
+			}
+		} else if len(expressions) != 1 {
+			return nil, nil, errp.Newf("%s) descriptors have a single key.", script.prefix)
+		}
+		var keypath signing.AbsoluteKeypath
+		extendedPublicKeys := make([]*hdkeychain.ExtendedKey, len(expressions))
+		fingerprints := make([][]byte, len(expressions))
+		for index, expression := range expressions {
+			keyKeypath, fingerprint, extendedPublicKey, err := parseDescriptorKey(expression, net)
+			if err != nil {
+				return nil, nil, err
+			}
+			if index > 0 && keyKeypath.Encode() != keypath.Encode() {
+				return nil, nil, errp.New("All keys of the descriptor need the same keypath.")
+			}
+			keypath = keyKeypath
+			extendedPublicKeys[index] = extendedPublicKey
+			fingerprints[index] = fingerprint
+		}
+		return signing.NewConfiguration(
+			script.scriptType, keypath, extendedPublicKeys, signingThreshold), fingerprints, nil
+	}
+	if strings.Contains(descriptor, "multi(") {
+		return nil, nil, errp.New("Only sorted multisig descriptors (sortedmulti) are supported.")
+	}
+	return nil, nil, errp.New("Only pkh, sh(wpkh), wpkh, tr, wsh(sortedmulti) and sh(sortedmulti) " +
+		"descriptors are supported.")
+}
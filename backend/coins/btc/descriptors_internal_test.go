@@ -0,0 +1,208 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/stretchr/testify/require"
+)
+
+const testDescriptorXPub = "xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrg" +
+	"Zw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL"
+
+// testDescriptorFingerprint is the master key fingerprint d34db33f.
+const testDescriptorFingerprint = 0x3fb34dd3
+
+func TestDescriptorChecksum(t *testing.T) {
+	checksum, err := descriptorChecksum("raw(deadbeef)")
+	require.NoError(t, err)
+	require.Equal(t, "89f8spxm", checksum)
+	checksum, err = descriptorChecksum("pkh([d34db33f/44'/0'/0']" + testDescriptorXPub + "/1/*)")
+	require.NoError(t, err)
+	require.Equal(t, "ml40v0wf", checksum)
+	_, err = descriptorChecksum("raw(deadbeef)\n")
+	require.Error(t, err)
+}
+
+func TestDescriptor(t *testing.T) {
+	extendedPublicKey, err := hdkeychain.NewKeyFromString(testDescriptorXPub)
+	require.NoError(t, err)
+	keypath, err := signing.NewAbsoluteKeypath("m/44'/0'/0'")
+	require.NoError(t, err)
+
+	configuration := signing.NewSinglesigConfiguration(signing.ScriptTypeP2PKH, keypath, extendedPublicKey)
+	result, err := Descriptor(configuration, &chaincfg.MainNetParams,
+		[]uint32{testDescriptorFingerprint}, "/0/*")
+	require.NoError(t, err)
+	require.Equal(t, "pkh([d34db33f/44h/0h/0h]"+testDescriptorXPub+"/0/*)#e603tqfj", result)
+
+	configuration = signing.NewSinglesigConfiguration(signing.ScriptTypeP2WPKH, keypath, extendedPublicKey)
+	result, err = Descriptor(configuration, &chaincfg.MainNetParams,
+		[]uint32{testDescriptorFingerprint}, "/1/*")
+	require.NoError(t, err)
+	require.Equal(t, "wpkh([d34db33f/44h/0h/0h]"+testDescriptorXPub+"/1/*)#yg59sje7", result)
+
+	// Without fingerprints, the key origin is omitted.
+	configuration = signing.NewSinglesigConfiguration(signing.ScriptTypeP2TR, keypath, extendedPublicKey)
+	result, err = Descriptor(configuration, &chaincfg.MainNetParams, nil, "/0/*")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(result, "tr("+testDescriptorXPub+"/0/*)#"))
+
+	configuration = signing.NewSinglesigConfiguration(signing.ScriptTypeP2WPKHP2SH, keypath, extendedPublicKey)
+	result, err = Descriptor(configuration, &chaincfg.MainNetParams, nil, "/0/*")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(result, "sh(wpkh("+testDescriptorXPub+"/0/*))#"))
+
+	// The keys use the version bytes of the network.
+	result, err = Descriptor(configuration, &chaincfg.TestNet3Params, nil, "/0/*")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(result, "sh(wpkh(tpub"))
+}
+
+func TestDescriptorMultisig(t *testing.T) {
+	master, err := hdkeychain.NewMaster(make([]byte, hdkeychain.RecommendedSeedLen), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	cosigner, err := master.Neuter()
+	require.NoError(t, err)
+	extendedPublicKey, err := hdkeychain.NewKeyFromString(testDescriptorXPub)
+	require.NoError(t, err)
+	keypath, err := signing.NewAbsoluteKeypath("m/48'/0'/0'/2'")
+	require.NoError(t, err)
+	configuration := signing.NewConfiguration(signing.ScriptTypeP2WSH, keypath,
+		[]*hdkeychain.ExtendedKey{extendedPublicKey, cosigner}, 2)
+	// Only the fingerprint of the first cosigner is known.
+	result, err := Descriptor(configuration, &chaincfg.MainNetParams,
+		[]uint32{testDescriptorFingerprint}, "/0/*")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(result, "wsh(sortedmulti(2,[d34db33f/48h/0h/0h/2h]"+
+		testDescriptorXPub+"/0/*,"+cosigner.String()+"/0/*))#"))
+
+	configuration = signing.NewConfiguration(signing.ScriptTypeP2PKH, keypath,
+		[]*hdkeychain.ExtendedKey{extendedPublicKey, cosigner}, 1)
+	result, err = Descriptor(configuration, &chaincfg.MainNetParams, nil, "/1/*")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(result, "sh(sortedmulti(1,"))
+}
+
+func TestParseDescriptor(t *testing.T) {
+	configuration, err := ParseDescriptor(
+		"pkh([d34db33f/44'/0'/0']"+testDescriptorXPub+"/1/*)#ml40v0wf", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.True(t, configuration.Singlesig())
+	require.Equal(t, signing.ScriptTypeP2PKH, configuration.ScriptType())
+	require.Equal(t, "m/44'/0'/0'", configuration.AbsoluteKeypath().Encode())
+	require.Equal(t, testDescriptorXPub, configuration.ExtendedPublicKeys()[0].String())
+
+	for descriptor, scriptType := range map[string]signing.ScriptType{
+		"sh(wpkh([d34db33f]" + testDescriptorXPub + "/<0;1>/*))": signing.ScriptTypeP2WPKHP2SH,
+		"wpkh([d34db33f/84h/0h/0h]" + testDescriptorXPub + ")":   signing.ScriptTypeP2WPKH,
+		"tr(" + testDescriptorXPub + "/0/*)":                     signing.ScriptTypeP2TR,
+	} {
+		configuration, err := ParseDescriptor(descriptor, &chaincfg.MainNetParams)
+		require.NoError(t, err, descriptor)
+		require.Equal(t, scriptType, configuration.ScriptType(), descriptor)
+	}
+
+	// Exported descriptors can be imported again.
+	for _, scriptType := range []signing.ScriptType{
+		signing.ScriptTypeP2PKH, signing.ScriptTypeP2WPKHP2SH, signing.ScriptTypeP2WPKH, signing.ScriptTypeP2TR,
+	} {
+		configuration := signing.NewSinglesigConfiguration(scriptType, configuration.AbsoluteKeypath(),
+			configuration.ExtendedPublicKeys()[0])
+		descriptor, err := Descriptor(configuration, &chaincfg.MainNetParams,
+			[]uint32{testDescriptorFingerprint}, "/0/*")
+		require.NoError(t, err)
+		parsed, err := ParseDescriptor(descriptor, &chaincfg.MainNetParams)
+		require.NoError(t, err)
+		require.Equal(t, configuration.Hash(), parsed.Hash())
+	}
+
+	for _, descriptor := range []string{
+		// Invalid checksum.
+		"pkh([d34db33f/44'/0'/0']" + testDescriptorXPub + "/1/*)#ml40v0wg",
+		"wsh(multi(1," + testDescriptorXPub + "," + testDescriptorXPub + "))",
+		"wpkh(" + testDescriptorXPub + "/2/*)",
+		"wpkh([d34db33f/84h/1h/0h" + testDescriptorXPub + ")",
+		"wpkh([xyz/84h]" + testDescriptorXPub + ")",
+		"wpkh([d34db33f/84x]" + testDescriptorXPub + ")",
+		"wpkh(" + testDescriptorXPub + "," + testDescriptorXPub + ")",
+		"tr(" + testDescriptorXPub + ",{pk(" + testDescriptorXPub + ")})",
+		"wsh(pkh(" + testDescriptorXPub + "))",
+	} {
+		_, err := ParseDescriptor(descriptor, &chaincfg.MainNetParams)
+		require.Error(t, err, descriptor)
+	}
+	// The keys have to be for the network.
+	_, err = ParseDescriptor("wpkh("+testDescriptorXPub+")", &chaincfg.TestNet3Params)
+	require.Error(t, err)
+}
+
+func TestParseDescriptorWithFingerprints(t *testing.T) {
+	configuration, fingerprints, err := ParseDescriptorWithFingerprints(
+		"wpkh([d34db33f/84h/0h/0h]"+testDescriptorXPub+"/<0;1>/*)", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, "m/84'/0'/0'", configuration.AbsoluteKeypath().Encode())
+	require.Equal(t, [][]byte{{0xd3, 0x4d, 0xb3, 0x3f}}, fingerprints)
+
+	_, fingerprints, err = ParseDescriptorWithFingerprints(
+		"wpkh("+testDescriptorXPub+")", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{nil}, fingerprints)
+}
+
+func TestParseDescriptorMultisig(t *testing.T) {
+	master, err := hdkeychain.NewMaster(make([]byte, hdkeychain.RecommendedSeedLen), &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	cosigner, err := master.Neuter()
+	require.NoError(t, err)
+	keys := "[d34db33f/48h/0h/0h/2h]" + testDescriptorXPub + "/0/*,[00000000/48h/0h/0h/2h]" +
+		cosigner.String() + "/0/*"
+
+	configuration, err := ParseDescriptor("wsh(sortedmulti(2,"+keys+"))", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.True(t, configuration.P2WSH())
+	require.Equal(t, 2, configuration.SigningThreshold())
+	require.Equal(t, 2, configuration.NumberOfSigners())
+	require.Equal(t, "m/48'/0'/0'/2'", configuration.AbsoluteKeypath().Encode())
+
+	configuration, err = ParseDescriptor("sh(sortedmulti(1,"+keys+"))", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.True(t, configuration.Multisig())
+	require.False(t, configuration.P2WSH())
+
+	descriptor, err := Descriptor(configuration, &chaincfg.MainNetParams,
+		[]uint32{testDescriptorFingerprint, 0}, "/1/*")
+	require.NoError(t, err)
+	parsed, err := ParseDescriptor(descriptor, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, configuration.Hash(), parsed.Hash())
+
+	for _, descriptor := range []string{
+		"wsh(sortedmulti(3," + keys + "))",
+		"wsh(sortedmulti(0," + keys + "))",
+		"wsh(sortedmulti(x," + keys + "))",
+		"wsh(sortedmulti(1," + testDescriptorXPub + "))",
+		// The keypaths of the keys differ.
+		"wsh(sortedmulti(1,[d34db33f/48h/0h/0h/2h]" + testDescriptorXPub + "," + cosigner.String() + "))",
+	} {
+		_, err := ParseDescriptor(descriptor, &chaincfg.MainNetParams)
+		require.Error(t, err, descriptor)
+	}
+}
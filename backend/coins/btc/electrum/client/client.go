@@ -353,19 +353,12 @@ func (txHash *TXHash) UnmarshalJSON(jsonBytes []byte) error {
 	return nil
 }
 
-// UTXO is the data returned by the listunspent RPC call.
-type UTXO struct {
-	TXPos  int    `json:"tx_pos"`
-	Value  int64  `json:"value"`
-	TXHash string `json:"tx_hash"`
-	Height int    `json:"height"`
-}
-
 // ScriptHashListUnspent does the blockchain.address.listunspent() RPC call.
 // https://github.com/kyuupichan/electrumx/blob/159db3f8e70b2b2cbb8e8cd01d1e9df3fe83828f/docs/PROTOCOL.rst#blockchainscripthashlistunspent
-func (client *ElectrumClient) ScriptHashListUnspent(scriptHashHex string) ([]*UTXO, error) {
-	response := []*UTXO{}
-	if err := client.rpc.MethodSync(&response, "blockchain.scripthash.listunspent", scriptHashHex); err != nil {
+func (client *ElectrumClient) ScriptHashListUnspent(
+	scriptHashHex blockchain.ScriptHashHex) ([]*blockchain.UTXO, error) {
+	response := []*blockchain.UTXO{}
+	if err := client.rpc.MethodSync(&response, "blockchain.scripthash.listunspent", string(scriptHashHex)); err != nil {
 		return nil, errp.WithStack(err)
 	}
 	return response, nil
@@ -440,6 +433,34 @@ func (client *ElectrumClient) EstimateFee(
 		number)
 }
 
+// FeeHistogram does the mempool.get_fee_histogram() RPC call.
+// https://github.com/kyuupichan/electrumx/blob/1.3/docs/protocol-methods.rst#mempoolget_fee_histogram
+func (client *ElectrumClient) FeeHistogram(
+	success func(blockchain.FeeHistogram) error,
+	cleanup func(),
+) {
+	client.rpc.Method(
+		func(responseBytes []byte) error {
+			// Each entry is a pair of the fee rate in unit/vbyte and the total vsize of the bucket.
+			var response [][2]float64
+			if err := json.Unmarshal(responseBytes, &response); err != nil {
+				return errp.Wrap(err, "Failed to unmarshal JSON")
+			}
+			histogram := make(blockchain.FeeHistogram, len(response))
+			for index, entry := range response {
+				histogram[index] = &blockchain.FeeHistogramEntry{
+					FeeRatePerKb: btcutil.Amount(entry[0] * 1000),
+					VSize:        int64(entry[1]),
+				}
+			}
+			return success(histogram)
+		},
+		func() func() {
+			return cleanup
+		},
+		"mempool.get_fee_histogram")
+}
+
 func parseHeaders(reader io.Reader) ([]*wire.BlockHeader, error) {
 	headers := []*wire.BlockHeader{}
 	for {
@@ -34,4 +34,38 @@ const (
 
 	// EventFeeTargetsChanged is fired when the fee targets change.
 	EventFeeTargetsChanged Event = "feeTargetsChanged"
+
+	// EventIncomingTransaction is fired when a new payment to the account appears in the mempool,
+	// before it is confirmed. The transaction is listed in Transactions().
+	EventIncomingTransaction Event = "incomingTransaction"
 )
+
+// SignStage is a stage of signing a transaction, see SignProgress.
+type SignStage string
+
+const (
+	// SignStageHashing is reported while the signature hashes of the inputs are computed.
+	SignStageHashing SignStage = "hashing"
+
+	// SignStageAwaitingConfirmation is reported when the keystores are asked to sign, which
+	// usually requires the confirmation of the user on the device.
+	SignStageAwaitingConfirmation SignStage = "awaitingConfirmation"
+
+	// SignStageReceivingSignatures is reported by a keystore when it received its signatures and
+	// adds them to the transaction.
+	SignStageReceivingSignatures SignStage = "receivingSignatures"
+
+	// SignStageFinalizing is reported while the signature scripts and witnesses are built and the
+	// signed transaction is checked.
+	SignStageFinalizing SignStage = "finalizing"
+)
+
+// SignProgress is notified to the observers of an account while a transaction is signed, with
+// the subject "account/<code>/sign-progress".
+type SignProgress struct {
+	Stage SignStage `json:"stage"`
+	// Signatures is the number of signatures received so far.
+	Signatures int `json:"signatures"`
+	// Inputs is the number of inputs which are signed.
+	Inputs int `json:"inputs"`
+}
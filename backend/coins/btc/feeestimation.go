@@ -0,0 +1,58 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/mempoolspace"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/signet"
+)
+
+// maxBlockVSize is the maximum virtual size of a block, used to convert a number of blocks into a
+// depth in the mempool.
+const maxBlockVSize = 1000000
+
+// feeRateFromHistogram returns the fee rate needed to be among the transactions filling the given
+// number of blocks, assuming no transactions with a higher fee rate arrive in the meantime. The fee
+// rate of the bucket at that depth is outbid by 1 sat/vbyte. nil is returned if the mempool is not
+// that deep, in which case it does not tell which fee rate is needed.
+func feeRateFromHistogram(histogram blockchain.FeeHistogram, blocks int) *btcutil.Amount {
+	depth := int64(0)
+	for _, entry := range histogram {
+		depth += entry.VSize
+		if depth >= int64(blocks)*maxBlockVSize {
+			feeRatePerKb := entry.FeeRatePerKb + 1000
+			return &feeRatePerKb
+		}
+	}
+	return nil
+}
+
+// newMempoolSpace returns a mempool.space client for the given network, or nil if it is not
+// supported.
+func newMempoolSpace(net *chaincfg.Params) *mempoolspace.MempoolSpace {
+	switch net.Net {
+	case chaincfg.MainNetParams.Net:
+		return mempoolspace.NewMempoolSpace("https://mempool.space/api")
+	case chaincfg.TestNet3Params.Net:
+		return mempoolspace.NewMempoolSpace("https://mempool.space/testnet/api")
+	case signet.Params.Net:
+		return mempoolspace.NewMempoolSpace("https://mempool.space/signet/api")
+	default:
+		return nil
+	}
+}
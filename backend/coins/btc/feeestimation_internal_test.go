@@ -0,0 +1,54 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/ltc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeRateFromHistogram(t *testing.T) {
+	histogram := blockchain.FeeHistogram{
+		{FeeRatePerKb: 50000, VSize: 500000},
+		{FeeRatePerKb: 20000, VSize: 1000000},
+		{FeeRatePerKb: 10000, VSize: 2500000},
+		{FeeRatePerKb: 1000, VSize: 2000000},
+	}
+	for blocks, expected := range map[int]btcutil.Amount{
+		1: 21000,
+		2: 11000,
+		4: 11000,
+		6: 2000,
+	} {
+		feeRatePerKb := feeRateFromHistogram(histogram, blocks)
+		require.NotNil(t, feeRatePerKb, blocks)
+		require.Equal(t, expected, *feeRatePerKb, blocks)
+	}
+	// The mempool is not deep enough.
+	require.Nil(t, feeRateFromHistogram(histogram, 12))
+	require.Nil(t, feeRateFromHistogram(blockchain.FeeHistogram{}, 2))
+}
+
+func TestNewMempoolSpace(t *testing.T) {
+	require.NotNil(t, newMempoolSpace(&chaincfg.MainNetParams))
+	require.NotNil(t, newMempoolSpace(&chaincfg.TestNet3Params))
+	require.Nil(t, newMempoolSpace(&ltc.MainNetParams))
+	require.Nil(t, newMempoolSpace(&chaincfg.RegressionNetParams))
+}
@@ -15,6 +15,8 @@
 package btc
 
 import (
+	"math/big"
+
 	"github.com/btcsuite/btcutil"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 )
@@ -29,6 +31,7 @@ func NewFeeTargetCode(code string) (FeeTargetCode, error) {
 	case string(FeeTargetCodeEconomy):
 	case string(FeeTargetCodeNormal):
 	case string(FeeTargetCodeHigh):
+	case string(FeeTargetCodeCustom):
 	default:
 		return "", errp.WithStack(errp.Newf("Unrecognized fee target code %s", code))
 	}
@@ -48,6 +51,9 @@ const (
 	// FeeTargetCodeHigh is the high priority fee target.
 	FeeTargetCodeHigh FeeTargetCode = "high"
 
+	// FeeTargetCodeCustom means that the fee rate is entered by the user.
+	FeeTargetCodeCustom FeeTargetCode = "custom"
+
 	defaultFeeTarget = FeeTargetCodeNormal
 )
 
@@ -61,4 +67,8 @@ type FeeTarget struct {
 
 	// FeeRatePerKb is the fee rate needed for this target. Can be nil until populated.
 	FeeRatePerKb *btcutil.Amount
+
+	// GasTipCap is the priority fee per gas in wei of an Ethereum fee target. It is nil for other
+	// coins.
+	GasTipCap *big.Int
 }
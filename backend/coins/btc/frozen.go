@@ -0,0 +1,54 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/util"
+)
+
+// frozenOutputs returns the freeze reasons of the frozen outputs by outpoint.
+func (account *Account) frozenOutputs() (map[wire.OutPoint]string, error) {
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+	return dbTx.FrozenOutputs()
+}
+
+// SetOutputFrozen freezes or unfreezes the output with the given outpoint, formatted as
+// `<txID>:<index>`. Frozen outputs are not spent unless they are unfrozen again, e.g. to avoid
+// linking coins received in a dust attack. The reason is shown to the user.
+func (account *Account) SetOutputFrozen(outPointString string, frozen bool, reason string) error {
+	outPoint, err := util.ParseOutPoint([]byte(outPointString))
+	if err != nil {
+		return err
+	}
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+	if frozen {
+		err = dbTx.FreezeOutput(*outPoint, reason)
+	} else {
+		err = dbTx.UnfreezeOutput(*outPoint)
+	}
+	if err != nil {
+		return err
+	}
+	return dbTx.Commit()
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// exportEntry is one confirmed transaction in the exported history.
+type exportEntry struct {
+	Time   string `json:"time"`
+	TxID   string `json:"txID"`
+	Type   string `json:"type"`
+	Amount string `json:"amount"`
+	Fee    string `json:"fee"`
+	Unit   string `json:"unit"`
+	// FiatValue is the value of the amount at the current exchange rate. It is empty if the rate
+	// is not available.
+	FiatValue string `json:"fiatValue"`
+	Fiat      string `json:"fiat"`
+	Note      string `json:"note"`
+}
+
+// exportEntries returns the confirmed transactions of the account. The fiat values are computed
+// with the given exchange rate, which is 0 if not available.
+func (handlers *Handlers) exportEntries(fiat string, rate float64) []*exportEntry {
+	entries := []*exportEntry{}
+	for _, txInfo := range handlers.account.Transactions() {
+		if txInfo.NumConfirmations() <= 0 {
+			continue
+		}
+		entry := &exportEntry{
+			TxID: txInfo.ID(),
+			Type: map[coin.TxType]string{
+				coin.TxTypeReceive:  "receive",
+				coin.TxTypeSend:     "send",
+				coin.TxTypeSendSelf: "send_to_self",
+			}[txInfo.Type()],
+			Amount: handlers.account.Coin().FormatAmount(txInfo.Amount()),
+			Unit:   handlers.account.Coin().Unit(),
+			Fiat:   fiat,
+		}
+		if timestamp := txInfo.Timestamp(); timestamp != nil {
+			entry.Time = timestamp.Format(time.RFC3339)
+		}
+		if fee := txInfo.Fee(); fee != nil {
+			entry.Fee = handlers.account.Coin().FormatAmount(*fee)
+		}
+		if amount, err := strconv.ParseFloat(entry.Amount, 64); err == nil && rate != 0 {
+			entry.FiatValue = strconv.FormatFloat(amount*rate, 'f', 2, 64)
+		}
+		note, err := handlers.account.TxNote(txInfo.ID())
+		if err != nil {
+			handlers.log.WithError(err).Error("Failed to retrieve the note of a transaction")
+		}
+		entry.Note = note
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// exportCSV encodes the entries as CSV with a header row.
+func exportCSV(entries []*exportEntry) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	records := [][]string{{"Time", "Transaction ID", "Type", "Amount", "Fee", "Unit", "Fiat Value", "Fiat", "Note"}}
+	for _, entry := range entries {
+		records = append(records, []string{
+			entry.Time, entry.TxID, entry.Type, entry.Amount, entry.Fee, entry.Unit,
+			entry.FiatValue, entry.Fiat, entry.Note,
+		})
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// getExport returns the confirmed transaction history for accounting. The query parameter
+// `format` is either "csv" or "json", `fiat` is the currency of the fiat values.
+func (handlers *Handlers) getExport(r *http.Request) (interface{}, error) {
+	format := r.URL.Query().Get("format")
+	fiat := r.URL.Query().Get("fiat")
+	rate := handlers.rates()[handlers.account.Coin().Unit()][fiat]
+	entries := handlers.exportEntries(fiat, rate)
+	var data []byte
+	var err error
+	switch format {
+	case "csv":
+		data, err = exportCSV(entries)
+	case "json":
+		data, err = json.MarshalIndent(entries, "", "  ")
+	default:
+		return nil, errp.Newf("Unknown export format %s.", format)
+	}
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return map[string]interface{}{
+		"filename": fmt.Sprintf("%s-transactions.%s", handlers.account.Code(), format),
+		"data":     string(data),
+	}, nil
+}
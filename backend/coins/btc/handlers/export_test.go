@@ -0,0 +1,40 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCSV(t *testing.T) {
+	data, err := exportCSV([]*exportEntry{{
+		Time:      "2018-09-01T10:00:00Z",
+		TxID:      "abcd",
+		Type:      "send",
+		Amount:    "0.1",
+		Fee:       "0.0001",
+		Unit:      "BTC",
+		FiatValue: "700.00",
+		Fiat:      "USD",
+		Note:      "rent, september",
+	}})
+	require.NoError(t, err)
+	require.Equal(t,
+		"Time,Transaction ID,Type,Amount,Fee,Unit,Fiat Value,Fiat,Note\n"+
+			"2018-09-01T10:00:00Z,abcd,send,0.1,0.0001,BTC,700.00,USD,\"rent, september\"\n",
+		string(data))
+}
@@ -15,18 +15,29 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"math/big"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/bip21"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/util"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
@@ -34,13 +45,17 @@ import (
 // Handlers provides a web api to the account.
 type Handlers struct {
 	account btc.Interface
-	log     *logrus.Entry
+	// rates returns the current exchange rates by coin unit and fiat currency.
+	rates func() map[string]map[string]float64
+	log   *logrus.Entry
 }
 
 // NewHandlers creates a new Handlers instance.
 func NewHandlers(
-	handleFunc func(string, func(*http.Request) (interface{}, error)) *mux.Route, log *logrus.Entry) *Handlers {
-	handlers := &Handlers{log: log}
+	handleFunc func(string, func(*http.Request) (interface{}, error)) *mux.Route,
+	rates func() map[string]map[string]float64,
+	log *logrus.Entry) *Handlers {
+	handlers := &Handlers{rates: rates, log: log}
 
 	handleFunc("/init", handlers.postInit).Methods("POST")
 	handleFunc("/status", handlers.getAccountStatus).Methods("GET")
@@ -49,12 +64,42 @@ func NewHandlers(
 	handleFunc("/utxos", handlers.ensureAccountInitialized(handlers.getUTXOs)).Methods("GET")
 	handleFunc("/balance", handlers.ensureAccountInitialized(handlers.getAccountBalance)).Methods("GET")
 	handleFunc("/sendtx", handlers.ensureAccountInitialized(handlers.postAccountSendTx)).Methods("POST")
+	handleFunc("/cancel-signing", handlers.ensureAccountInitialized(handlers.postCancelSigning)).Methods("POST")
 	handleFunc("/fee-targets", handlers.ensureAccountInitialized(handlers.getAccountFeeTargets)).Methods("GET")
 	handleFunc("/tx-proposal", handlers.ensureAccountInitialized(handlers.getAccountTxProposal)).Methods("POST")
 	handleFunc("/headers/status", handlers.ensureAccountInitialized(handlers.getHeadersStatus)).Methods("GET")
 	handleFunc("/receive-addresses", handlers.ensureAccountInitialized(handlers.getReceiveAddresses)).Methods("GET")
 	handleFunc("/verify-address", handlers.ensureAccountInitialized(handlers.postVerifyAddress)).Methods("POST")
 	handleFunc("/convert-to-legacy-address", handlers.ensureAccountInitialized(handlers.postConvertToLegacyAddress)).Methods("POST")
+	handleFunc("/parse-payment-uri", handlers.ensureAccountInitialized(handlers.postParsePaymentURI)).Methods("POST")
+	handleFunc("/tx-note", handlers.ensureAccountInitialized(handlers.postTxNote)).Methods("POST")
+	handleFunc("/address-label", handlers.ensureAccountInitialized(handlers.postAddressLabel)).Methods("POST")
+	handleFunc("/output-label", handlers.ensureAccountInitialized(handlers.postOutputLabel)).Methods("POST")
+	handleFunc("/output-frozen", handlers.ensureAccountInitialized(handlers.postOutputFrozen)).Methods("POST")
+	handleFunc("/labels", handlers.ensureAccountInitialized(handlers.getLabels)).Methods("GET")
+	handleFunc("/labels", handlers.ensureAccountInitialized(handlers.postLabels)).Methods("POST")
+	handleFunc("/export", handlers.ensureAccountInitialized(handlers.getExport)).Methods("GET")
+	handleFunc("/sweep-proposal", handlers.ensureAccountInitialized(handlers.postSweepProposal)).Methods("POST")
+	handleFunc("/sweep", handlers.ensureAccountInitialized(handlers.postSweep)).Methods("POST")
+	handleFunc("/psbt/export", handlers.ensureAccountInitialized(handlers.postExportPSBT)).Methods("POST")
+	handleFunc("/psbt/cosign", handlers.ensureAccountInitialized(handlers.postCosignPSBT)).Methods("POST")
+	handleFunc("/psbt/send", handlers.ensureAccountInitialized(handlers.postSendPSBT)).Methods("POST")
+	handleFunc("/psbt/export-file", handlers.ensureAccountInitialized(handlers.postExportPSBTFile)).Methods("POST")
+	handleFunc("/psbt/import-file", handlers.ensureAccountInitialized(handlers.postImportPSBTFile)).Methods("POST")
+	handleFunc("/sign-message", handlers.ensureAccountInitialized(handlers.postSignMessage)).Methods("POST")
+	handleFunc("/verify-message", handlers.ensureAccountInitialized(handlers.postVerifyMessage)).Methods("POST")
+	handleFunc("/descriptors", handlers.ensureAccountInitialized(handlers.getDescriptors)).Methods("GET")
+	handleFunc("/ens/resolve", handlers.ensureAccountInitialized(handlers.postResolveENSName)).Methods("POST")
+	handleFunc("/ens/lookup", handlers.ensureAccountInitialized(handlers.postLookupENSName)).Methods("POST")
+	handleFunc("/nonces", handlers.ensureAccountInitialized(handlers.getNonceQueue)).Methods("GET")
+	handleFunc("/replace-tx", handlers.ensureAccountInitialized(handlers.postReplaceTx)).Methods("POST")
+	handleFunc("/typed-data/hash", handlers.ensureAccountInitialized(handlers.postTypedDataHash)).Methods("POST")
+	handleFunc("/typed-data/sign", handlers.ensureAccountInitialized(handlers.postSignTypedData)).Methods("POST")
+	handleFunc("/nfts", handlers.ensureAccountInitialized(handlers.getNFTs)).Methods("GET")
+	handleFunc("/nft-metadata", handlers.ensureAccountInitialized(handlers.getNFTMetadata)).Methods("GET")
+	handleFunc("/nft-transfer", handlers.ensureAccountInitialized(handlers.postNFTTransfer)).Methods("POST")
+	handleFunc("/approvals", handlers.ensureAccountInitialized(handlers.getApprovals)).Methods("GET")
+	handleFunc("/approval-revoke", handlers.ensureAccountInitialized(handlers.postApprovalRevoke)).Methods("POST")
 	return handlers
 }
 
@@ -98,12 +143,46 @@ type Transaction struct {
 	Fee              formattedAmount `json:"fee"`
 	Time             *string         `json:"time"`
 	Addresses        []string        `json:"addresses"`
+	Note             string          `json:"note"`
+	// AddressLabels are the labels of the addresses of the transaction which have one.
+	AddressLabels map[string]string `json:"addressLabels"`
 
 	// BTC specific fields.
 	VSize        int64           `json:"vsize"`
 	Size         int64           `json:"size"`
 	Weight       int64           `json:"weight"`
 	FeeRatePerKb formattedAmount `json:"feeRatePerKb"`
+	// RBF is true if the transaction signals replaceability (BIP125), so that its fee can be
+	// bumped while it is pending.
+	RBF bool `json:"rbf"`
+	// FeeRatePerVByte is the fee rate in sat/vB, or empty if the fee is unknown.
+	FeeRatePerVByte string              `json:"feeRatePerVByte"`
+	Inputs          []transactionInput  `json:"inputs"`
+	Outputs         []transactionOutput `json:"outputs"`
+
+	// ETH specific fields.
+	// Internal is true for transfers of Ether by a contract within the transaction with the ID.
+	Internal bool `json:"internal"`
+	// Failed is true if the execution of the transaction failed, so that only the fee was paid.
+	Failed bool `json:"failed"`
+	// RevertReason is the reason with which a failed transaction was reverted, or empty.
+	RevertReason string `json:"revertReason"`
+}
+
+// transactionInput is an input of a transaction. The amount and address are only known for inputs
+// spending coins of the account.
+type transactionInput struct {
+	OutPoint string           `json:"outPoint"`
+	Amount   *formattedAmount `json:"amount"`
+	Address  string           `json:"address"`
+}
+
+// transactionOutput is an output of a transaction.
+type transactionOutput struct {
+	Amount  formattedAmount `json:"amount"`
+	Address string          `json:"address"`
+	Ours    bool            `json:"ours"`
+	Change  bool            `json:"change"`
 }
 
 func (handlers *Handlers) ensureAccountInitialized(h func(*http.Request) (interface{}, error)) func(*http.Request) (interface{}, error) {
@@ -118,6 +197,7 @@ func (handlers *Handlers) ensureAccountInitialized(h func(*http.Request) (interf
 func (handlers *Handlers) getAccountTransactions(_ *http.Request) (interface{}, error) {
 	result := []Transaction{}
 	txs := handlers.account.Transactions()
+	labels := handlers.account.AddressLabels()
 	for _, txInfo := range txs {
 		var feeString formattedAmount
 		fee := txInfo.Fee()
@@ -143,15 +223,56 @@ func (handlers *Handlers) getAccountTransactions(_ *http.Request) (interface{},
 			Time:      formattedTime,
 			Addresses: txInfo.Addresses(),
 		}
+		note, err := handlers.account.TxNote(txInfo.ID())
+		if err != nil {
+			handlers.log.WithError(err).Error("Failed to retrieve the note of a transaction")
+		}
+		txInfoJSON.Note = note
+		txInfoJSON.AddressLabels = map[string]string{}
+		for _, address := range txInfo.Addresses() {
+			if label, ok := labels[address]; ok {
+				txInfoJSON.AddressLabels[address] = label
+			}
+		}
 		switch specificInfo := txInfo.(type) {
 		case *transactions.TxInfo:
 			txInfoJSON.VSize = specificInfo.VSize
 			txInfoJSON.Size = specificInfo.Size
 			txInfoJSON.Weight = specificInfo.Weight
+			txInfoJSON.RBF = maketx.SignalsReplacement(specificInfo.Tx)
 			feeRatePerKb := specificInfo.FeeRatePerKb()
 			if feeRatePerKb != nil {
 				txInfoJSON.FeeRatePerKb = handlers.formatBTCAmountAsJSON(*feeRatePerKb)
 			}
+			if feeRatePerVByte := specificInfo.FeeRatePerVByte(); feeRatePerVByte != nil {
+				txInfoJSON.FeeRatePerVByte = strconv.FormatFloat(*feeRatePerVByte, 'f', 1, 64)
+			}
+			txInfoJSON.Inputs = make([]transactionInput, len(specificInfo.Inputs))
+			for index, input := range specificInfo.Inputs {
+				txInfoJSON.Inputs[index] = transactionInput{
+					OutPoint: input.OutPoint.String(),
+					Address:  input.Address,
+				}
+				if input.Value != nil {
+					amount := handlers.formatBTCAmountAsJSON(*input.Value)
+					txInfoJSON.Inputs[index].Amount = &amount
+				}
+			}
+			txInfoJSON.Outputs = make([]transactionOutput, len(specificInfo.Outputs))
+			for index, output := range specificInfo.Outputs {
+				txInfoJSON.Outputs[index] = transactionOutput{
+					Amount:  handlers.formatBTCAmountAsJSON(output.Value),
+					Address: output.Address,
+					Ours:    output.Ours,
+					Change:  output.Change,
+				}
+			}
+		case eth.IndexedTransaction:
+			txInfoJSON.Internal = specificInfo.Internal()
+			if historyTx, ok := specificInfo.(*eth.HistoryTransaction); ok {
+				txInfoJSON.Failed = historyTx.Failed()
+				txInfoJSON.RevertReason = historyTx.RevertReason()
+			}
 		}
 		result = append(result, txInfoJSON)
 	}
@@ -167,9 +288,12 @@ func (handlers *Handlers) getUTXOs(_ *http.Request) (interface{}, error) {
 	for _, output := range handlers.account.SpendableOutputs() {
 		result = append(result,
 			map[string]interface{}{
-				"outPoint": output.OutPoint.String(),
-				"amount":   handlers.formatBTCAmountAsJSON(btcutil.Amount(output.TxOut.Value)),
-				"address":  output.Address,
+				"outPoint":     output.OutPoint.String(),
+				"amount":       handlers.formatBTCAmountAsJSON(btcutil.Amount(output.TxOut.Value)),
+				"address":      output.Address,
+				"label":        output.Label,
+				"frozen":       output.Frozen,
+				"freezeReason": output.FreezeReason,
 			})
 	}
 	return result, nil
@@ -178,17 +302,16 @@ func (handlers *Handlers) getUTXOs(_ *http.Request) (interface{}, error) {
 func (handlers *Handlers) getAccountBalance(_ *http.Request) (interface{}, error) {
 	balance := handlers.account.Balance()
 	return map[string]interface{}{
-		"available":   handlers.formatAmountAsJSON(balance.Available()),
-		"incoming":    handlers.formatAmountAsJSON(balance.Incoming()),
-		"hasIncoming": balance.Incoming().BigInt().Sign() > 0,
+		"available":        handlers.formatAmountAsJSON(balance.Available()),
+		"incoming":         handlers.formatAmountAsJSON(balance.Incoming()),
+		"hasIncoming":      balance.Incoming().BigInt().Sign() > 0,
+		"pendingChange":    handlers.formatAmountAsJSON(balance.PendingChange()),
+		"hasPendingChange": balance.PendingChange().BigInt().Sign() > 0,
 	}, nil
 }
 
 type sendTxInput struct {
-	address       string
-	sendAmount    coin.SendAmount
-	feeTargetCode btc.FeeTargetCode
-	selectedUTXOs map[wire.OutPoint]struct{}
+	btc.TxProposalArgs
 }
 
 func (input *sendTxInput) UnmarshalJSON(jsonBytes []byte) error {
@@ -196,30 +319,64 @@ func (input *sendTxInput) UnmarshalJSON(jsonBytes []byte) error {
 		Address       string   `json:"address"`
 		SendAll       string   `json:"sendAll"`
 		FeeTarget     string   `json:"feeTarget"`
+		CustomFee     string   `json:"customFee"`
 		Amount        string   `json:"amount"`
 		SelectedUTXOS []string `json:"selectedUTXOS"`
+		// Recipients are the additional recipients of a batch transaction.
+		Recipients []struct {
+			Address string `json:"address"`
+			Amount  string `json:"amount"`
+		} `json:"recipients"`
+		PayjoinEndpoint string `json:"payjoinEndpoint"`
+		DisableRBF      bool   `json:"disableRBF"`
+		// OpReturnData is hex encoded.
+		OpReturnData string `json:"opReturnData"`
+		// Nonce, Data and GasLimit are only used by Ethereum accounts. Data is hex encoded.
+		Nonce    *uint64 `json:"nonce"`
+		Data     string  `json:"data"`
+		GasLimit uint64  `json:"gasLimit"`
 	}{}
 	if err := json.Unmarshal(jsonBytes, &jsonBody); err != nil {
 		return errp.WithStack(err)
 	}
-	input.address = jsonBody.Address
+	var amount coin.SendAmount
+	if jsonBody.SendAll == "yes" {
+		amount = coin.NewSendAmountAll()
+	} else {
+		amount = coin.NewSendAmount(jsonBody.Amount)
+	}
+	input.Recipients = []*btc.Recipient{{Address: jsonBody.Address, Amount: amount}}
+	for _, recipient := range jsonBody.Recipients {
+		input.Recipients = append(input.Recipients, &btc.Recipient{
+			Address: recipient.Address,
+			Amount:  coin.NewSendAmount(recipient.Amount),
+		})
+	}
 	var err error
-	input.feeTargetCode, err = btc.NewFeeTargetCode(jsonBody.FeeTarget)
+	input.FeeTargetCode, err = btc.NewFeeTargetCode(jsonBody.FeeTarget)
 	if err != nil {
 		return errp.WithMessage(err, "Failed to retrieve fee target code")
 	}
-	if jsonBody.SendAll == "yes" {
-		input.sendAmount = coin.NewSendAmountAll()
-	} else {
-		input.sendAmount = coin.NewSendAmount(jsonBody.Amount)
+	input.CustomFee = jsonBody.CustomFee
+	input.PayjoinEndpoint = jsonBody.PayjoinEndpoint
+	input.DisableRBF = jsonBody.DisableRBF
+	input.Nonce = jsonBody.Nonce
+	input.OpReturnData, err = hex.DecodeString(jsonBody.OpReturnData)
+	if err != nil {
+		return errp.WithMessage(err, "Invalid OP_RETURN data")
 	}
-	input.selectedUTXOs = map[wire.OutPoint]struct{}{}
+	input.Data, err = hex.DecodeString(strings.TrimPrefix(jsonBody.Data, "0x"))
+	if err != nil {
+		return errp.WithMessage(err, "Invalid data")
+	}
+	input.GasLimit = jsonBody.GasLimit
+	input.SelectedUTXOs = map[wire.OutPoint]struct{}{}
 	for _, outPointString := range jsonBody.SelectedUTXOS {
 		outPoint, err := util.ParseOutPoint([]byte(outPointString))
 		if err != nil {
 			return err
 		}
-		input.selectedUTXOs[*outPoint] = struct{}{}
+		input.SelectedUTXOs[*outPoint] = struct{}{}
 	}
 	return nil
 }
@@ -229,7 +386,7 @@ func (handlers *Handlers) postAccountSendTx(r *http.Request) (interface{}, error
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		return nil, errp.WithStack(err)
 	}
-	err := handlers.account.SendTx(input.address, input.sendAmount, input.feeTargetCode, input.selectedUTXOs)
+	err := handlers.account.SendTx(&input.TxProposalArgs)
 	if errp.Cause(err) == keystore.ErrSigningAborted {
 		return map[string]interface{}{"success": false}, nil
 	}
@@ -239,6 +396,14 @@ func (handlers *Handlers) postAccountSendTx(r *http.Request) (interface{}, error
 	return map[string]interface{}{"success": true}, nil
 }
 
+// postCancelSigning stops waiting for the signing in progress. The request signing the transaction
+// responds as if the user aborted it on the device, but the device is not aborted: the user still
+// has to confirm or abort it there, and further commands wait until then.
+func (handlers *Handlers) postCancelSigning(r *http.Request) (interface{}, error) {
+	cancelled := handlers.account.Keystores().SigningSession().Cancel()
+	return map[string]interface{}{"success": cancelled}, nil
+}
+
 func txProposalError(err error) (interface{}, error) {
 	if validationErr, ok := errp.Cause(err).(coin.TxValidationError); ok {
 		return map[string]interface{}{
@@ -246,6 +411,13 @@ func txProposalError(err error) (interface{}, error) {
 			"errorCode": validationErr.Error(),
 		}, nil
 	}
+	if revertedErr, ok := errp.Cause(err).(*eth.TxRevertedError); ok {
+		return map[string]interface{}{
+			"success":      false,
+			"errorCode":    "txReverted",
+			"errorMessage": revertedErr.Reason,
+		}, nil
+	}
 	return nil, errp.WithMessage(err, "Failed to create transaction proposal")
 }
 
@@ -254,23 +426,622 @@ func (handlers *Handlers) getAccountTxProposal(r *http.Request) (interface{}, er
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		return txProposalError(errp.WithStack(err))
 	}
-	outputAmount, fee, total, err := handlers.account.TxProposal(
-		input.address,
-		input.sendAmount,
-		input.feeTargetCode,
-		input.selectedUTXOs,
-	)
+	txProposal, err := handlers.account.TxProposal(&input.TxProposalArgs)
 	if err != nil {
 		return txProposalError(err)
 	}
+	fee := handlers.formatAmountAsJSON(txProposal.Fee)
+	if txProposal.FeeCoin != nil {
+		fee = formattedAmount{
+			Amount: txProposal.FeeCoin.FormatAmount(txProposal.Fee),
+			Unit:   txProposal.FeeCoin.Unit(),
+		}
+	}
+	var dustChange interface{}
+	if txProposal.DustChange.BigInt().Sign() > 0 {
+		dustChange = handlers.formatAmountAsJSON(txProposal.DustChange)
+	}
+	var data interface{}
+	if len(txProposal.Data) != 0 {
+		data = "0x" + hex.EncodeToString(txProposal.Data)
+	}
+	return map[string]interface{}{
+		"success":               true,
+		"amount":                handlers.formatAmountAsJSON(txProposal.Amount),
+		"fee":                   fee,
+		"total":                 handlers.formatAmountAsJSON(txProposal.Total),
+		"vsize":                 txProposal.VSize,
+		"dustChange":            dustChange,
+		"data":                  data,
+		"gasLimit":              txProposal.GasLimit,
+		"warnings":              txProposal.Warnings,
+		"recipientContractName": txProposal.RecipientContractName,
+	}, nil
+}
+
+type sweepInput struct {
+	wif           string
+	feeTargetCode btc.FeeTargetCode
+	customFee     string
+}
+
+func (input *sweepInput) UnmarshalJSON(jsonBytes []byte) error {
+	jsonBody := struct {
+		WIF       string `json:"wif"`
+		FeeTarget string `json:"feeTarget"`
+		CustomFee string `json:"customFee"`
+	}{}
+	if err := json.Unmarshal(jsonBytes, &jsonBody); err != nil {
+		return errp.WithStack(err)
+	}
+	var err error
+	input.feeTargetCode, err = btc.NewFeeTargetCode(jsonBody.FeeTarget)
+	if err != nil {
+		return errp.WithMessage(err, "Failed to retrieve fee target code")
+	}
+	input.wif = jsonBody.WIF
+	input.customFee = jsonBody.CustomFee
+	return nil
+}
+
+// btcAccount returns the account if it supports bitcoin-only features like sweeping.
+func (handlers *Handlers) btcAccount() (*btc.Account, error) {
+	account, ok := handlers.account.(*btc.Account)
+	if !ok {
+		return nil, errp.New("This feature is not supported by the account.")
+	}
+	return account, nil
+}
+
+func (handlers *Handlers) ethAccount() (*eth.Account, error) {
+	account, ok := handlers.account.(*eth.Account)
+	if !ok {
+		return nil, errp.New("This feature is not supported by the account.")
+	}
+	return account, nil
+}
+
+func (handlers *Handlers) postResolveENSName(r *http.Request) (interface{}, error) {
+	account, err := handlers.ethAccount()
+	if err != nil {
+		return nil, err
+	}
+	var name string
+	if err := json.NewDecoder(r.Body).Decode(&name); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	address, err := account.ResolveENSName(name)
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "address": address.Hex()}, nil
+}
+
+func (handlers *Handlers) postLookupENSName(r *http.Request) (interface{}, error) {
+	account, err := handlers.ethAccount()
+	if err != nil {
+		return nil, err
+	}
+	var address string
+	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	name, err := account.LookupENSName(address)
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "name": name}, nil
+}
+
+func (handlers *Handlers) getNonceQueue(_ *http.Request) (interface{}, error) {
+	account, err := handlers.ethAccount()
+	if err != nil {
+		return nil, err
+	}
+	nonceQueue, err := account.NonceQueue()
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	type pendingTransaction struct {
+		Nonce     uint64          `json:"nonce"`
+		ID        string          `json:"id"`
+		Recipient string          `json:"recipient"`
+		Amount    formattedAmount `json:"amount"`
+		// PriorityFee is in Gwei.
+		PriorityFee string `json:"priorityFee"`
+	}
+	transactions := []pendingTransaction{}
+	etherCoin := account.Coin().(*eth.Coin).Ether()
+	for _, transaction := range nonceQueue.Transactions {
+		var recipient string
+		if transaction.To != nil {
+			recipient = transaction.To.Hex()
+		}
+		amount := formattedAmount{
+			Amount: etherCoin.FormatAmount(coin.NewAmount(transaction.Value)),
+			Unit:   etherCoin.Unit(),
+		}
+		if transaction.Token != nil {
+			recipient = transaction.Token.Recipient.Hex()
+			amount = formattedAmount{
+				Amount: transaction.Token.FormattedAmount(),
+				Unit:   transaction.Token.Symbol,
+			}
+		}
+		transactions = append(transactions, pendingTransaction{
+			Nonce:       transaction.Nonce,
+			ID:          transaction.Hash.Hex(),
+			Recipient:   recipient,
+			Amount:      amount,
+			PriorityFee: transaction.PriorityFeeGwei(),
+		})
+	}
+	return map[string]interface{}{
+		"success":        true,
+		"confirmedNonce": nonceQueue.ConfirmedNonce,
+		"pendingNonce":   nonceQueue.PendingNonce,
+		"transactions":   transactions,
+	}, nil
+}
+
+func (handlers *Handlers) postReplaceTx(r *http.Request) (interface{}, error) {
+	account, err := handlers.ethAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		Nonce uint64 `json:"nonce"`
+		// Cancel replaces the transaction by a self-send instead of speeding it up.
+		Cancel bool `json:"cancel"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if input.Cancel {
+		err = account.CancelTransaction(input.Nonce)
+	} else {
+		err = account.SpeedUpTransaction(input.Nonce)
+	}
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		return map[string]interface{}{"success": false}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+// parseNFT parses the contract address and the decimal token ID of an NFT.
+func parseNFT(contract string, tokenID string) (common.Address, *big.Int, error) {
+	if !common.IsHexAddress(contract) {
+		return common.Address{}, nil, errp.New("Invalid contract address.")
+	}
+	id, ok := new(big.Int).SetString(tokenID, 10)
+	if !ok || id.Sign() < 0 {
+		return common.Address{}, nil, errp.New("Invalid token ID.")
+	}
+	return common.HexToAddress(contract), id, nil
+}
+
+func (handlers *Handlers) getNFTs(_ *http.Request) (interface{}, error) {
+	account, err := handlers.ethAccount()
+	if err != nil {
+		return nil, err
+	}
+	nfts, err := account.NFTs()
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	type nftJSON struct {
+		Contract       string `json:"contract"`
+		TokenID        string `json:"tokenID"`
+		Standard       string `json:"standard"`
+		Amount         string `json:"amount"`
+		CollectionName string `json:"collectionName"`
+	}
+	result := []nftJSON{}
+	for _, nft := range nfts {
+		result = append(result, nftJSON{
+			Contract:       nft.Contract.Hex(),
+			TokenID:        nft.TokenID.String(),
+			Standard:       nft.Standard,
+			Amount:         nft.Amount.String(),
+			CollectionName: nft.CollectionName,
+		})
+	}
+	return map[string]interface{}{"success": true, "nfts": result}, nil
+}
+
+func (handlers *Handlers) getNFTMetadata(r *http.Request) (interface{}, error) {
+	account, err := handlers.ethAccount()
+	if err != nil {
+		return nil, err
+	}
+	query := r.URL.Query()
+	contract, tokenID, err := parseNFT(query.Get("contract"), query.Get("tokenID"))
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := account.NFTMetadata(contract, tokenID, query.Get("standard"))
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "metadata": metadata}, nil
+}
+
+func (handlers *Handlers) postNFTTransfer(r *http.Request) (interface{}, error) {
+	account, err := handlers.ethAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		Contract  string `json:"contract"`
+		TokenID   string `json:"tokenID"`
+		Standard  string `json:"standard"`
+		Amount    string `json:"amount"`
+		Recipient string `json:"recipient"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	contract, tokenID, err := parseNFT(input.Contract, input.TokenID)
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	amount, ok := new(big.Int).SetString(input.Amount, 10)
+	if !ok {
+		return map[string]interface{}{"success": false, "errorMessage": "Invalid amount."}, nil
+	}
+	txHash, err := account.TransferNFT(contract, tokenID, input.Standard, amount, input.Recipient)
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		return map[string]interface{}{"success": false}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "txID": txHash.Hex()}, nil
+}
+
+func (handlers *Handlers) getApprovals(_ *http.Request) (interface{}, error) {
+	account, err := handlers.ethAccount()
+	if err != nil {
+		return nil, err
+	}
+	approvals, err := account.Approvals()
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	type approvalJSON struct {
+		Token   string `json:"token"`
+		Symbol  string `json:"symbol"`
+		Spender string `json:"spender"`
+		// Allowance is formatted in the unit of the token, or in its smallest unit if the token
+		// has no symbol.
+		Allowance string `json:"allowance"`
+		Unlimited bool   `json:"unlimited"`
+	}
+	result := []approvalJSON{}
+	for _, approval := range approvals {
+		result = append(result, approvalJSON{
+			Token:     approval.Token.Hex(),
+			Symbol:    approval.Symbol,
+			Spender:   approval.Spender.Hex(),
+			Allowance: approval.FormatAllowance(),
+			Unlimited: approval.Unlimited,
+		})
+	}
+	return map[string]interface{}{"success": true, "approvals": result}, nil
+}
+
+func (handlers *Handlers) postApprovalRevoke(r *http.Request) (interface{}, error) {
+	account, err := handlers.ethAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		Token   string `json:"token"`
+		Spender string `json:"spender"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if !common.IsHexAddress(input.Token) || !common.IsHexAddress(input.Spender) {
+		return nil, errp.New("Invalid token or spender address.")
+	}
+	txHash, err := account.RevokeApproval(common.HexToAddress(input.Token), common.HexToAddress(input.Spender))
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		return map[string]interface{}{"success": false}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "txID": txHash.Hex()}, nil
+}
+
+// decodeTypedData decodes a request body which is the JSON encoded typed data as a string.
+func decodeTypedData(r *http.Request) (*eth.TypedData, error) {
+	var jsonTypedData string
+	if err := json.NewDecoder(r.Body).Decode(&jsonTypedData); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return eth.ParseTypedData([]byte(jsonTypedData))
+}
+
+func (handlers *Handlers) postTypedDataHash(r *http.Request) (interface{}, error) {
+	if _, err := handlers.ethAccount(); err != nil {
+		return nil, err
+	}
+	typedData, err := decodeTypedData(r)
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	domainSeparator, err := typedData.DomainSeparator()
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	messageHash, err := typedData.MessageHash()
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{
+		"success":         true,
+		"primaryType":     typedData.PrimaryType,
+		"domainSeparator": "0x" + hex.EncodeToString(domainSeparator),
+		"messageHash":     "0x" + hex.EncodeToString(messageHash),
+		"signatureHash":   "0x" + hex.EncodeToString(eth.TypedDataSignatureHash(domainSeparator, messageHash)),
+	}, nil
+}
+
+func (handlers *Handlers) postSignTypedData(r *http.Request) (interface{}, error) {
+	account, err := handlers.ethAccount()
+	if err != nil {
+		return nil, err
+	}
+	typedData, err := decodeTypedData(r)
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	signature, err := account.SignTypedData(typedData)
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		return map[string]interface{}{"success": false}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "signature": signature}, nil
+}
+
+func (handlers *Handlers) getDescriptors(_ *http.Request) (interface{}, error) {
+	account, err := handlers.btcAccount()
+	if err != nil {
+		return nil, err
+	}
+	descriptors, err := account.Descriptors()
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "descriptors": descriptors}, nil
+}
+
+func (handlers *Handlers) postSweepProposal(r *http.Request) (interface{}, error) {
+	account, err := handlers.btcAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input sweepInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	amount, fee, err := account.SweepProposal(input.wif, input.feeTargetCode, input.customFee)
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
 	return map[string]interface{}{
 		"success": true,
-		"amount":  handlers.formatAmountAsJSON(outputAmount),
+		"amount":  handlers.formatAmountAsJSON(amount),
 		"fee":     handlers.formatAmountAsJSON(fee),
-		"total":   handlers.formatAmountAsJSON(total),
 	}, nil
 }
 
+func (handlers *Handlers) postSweep(r *http.Request) (interface{}, error) {
+	account, err := handlers.btcAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input sweepInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := account.Sweep(input.wif, input.feeTargetCode, input.customFee); err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postExportPSBT(r *http.Request) (interface{}, error) {
+	account, err := handlers.btcAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input sendTxInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	packet, err := account.ExportPSBT(&input.TxProposalArgs)
+	if err != nil {
+		return txProposalError(err)
+	}
+	encoded, err := packet.Base64()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"success": true, "psbt": encoded}, nil
+}
+
+func (handlers *Handlers) postCosignPSBT(r *http.Request) (interface{}, error) {
+	account, err := handlers.btcAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		PSBTs []string `json:"psbts"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	packets := make([]*psbt.Packet, len(input.PSBTs))
+	for index, encoded := range input.PSBTs {
+		packets[index], err = psbt.ParseBase64(encoded)
+		if err != nil {
+			return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+		}
+	}
+	packet, status, err := account.CosignPSBT(packets...)
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		return map[string]interface{}{"success": false}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	encoded, err := packet.Base64()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"success":          true,
+		"psbt":             encoded,
+		"signed":           status.Signed,
+		"signingThreshold": status.SigningThreshold,
+		"complete":         status.Complete,
+	}, nil
+}
+
+func (handlers *Handlers) postSendPSBT(r *http.Request) (interface{}, error) {
+	account, err := handlers.btcAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		PSBT string `json:"psbt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	packet, err := psbt.ParseBase64(input.PSBT)
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	if err := account.SendPSBT(packet); err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postExportPSBTFile(r *http.Request) (interface{}, error) {
+	account, err := handlers.btcAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input sendTxInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	filename, data, err := account.ExportPSBTFile(&input.TxProposalArgs)
+	if err != nil {
+		return txProposalError(err)
+	}
+	return map[string]interface{}{
+		"success":  true,
+		"filename": filename,
+		"data":     base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+func (handlers *Handlers) postImportPSBTFile(r *http.Request) (interface{}, error) {
+	account, err := handlers.btcAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		// Data are the base64 encoded contents of the file.
+		Data string `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	data, err := base64.StdEncoding.DecodeString(input.Data)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	packet, status, err := account.ImportPSBTFile(data)
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	encoded, err := packet.Base64()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"success":          true,
+		"psbt":             encoded,
+		"signed":           status.Signed,
+		"signingThreshold": status.SigningThreshold,
+		"complete":         status.Complete,
+	}, nil
+}
+
+// messageSigner is implemented by the accounts which can sign messages.
+type messageSigner interface {
+	SignMessage(address string, message []byte) (string, error)
+}
+
+// messageVerifier is implemented by the coins which can verify signed messages.
+type messageVerifier interface {
+	VerifyMessage(address string, message []byte, signature string) (bool, error)
+}
+
+func (handlers *Handlers) postSignMessage(r *http.Request) (interface{}, error) {
+	account, ok := handlers.account.(messageSigner)
+	if !ok {
+		return nil, errp.New("This feature is not supported by the account.")
+	}
+	var input struct {
+		Address string `json:"address"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	signature, err := account.SignMessage(input.Address, []byte(input.Message))
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		return map[string]interface{}{"success": false}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "signature": signature}, nil
+}
+
+func (handlers *Handlers) postVerifyMessage(r *http.Request) (interface{}, error) {
+	verifier, ok := handlers.account.Coin().(messageVerifier)
+	if !ok {
+		return nil, errp.New("This feature is not supported by the account.")
+	}
+	var input struct {
+		Address   string `json:"address"`
+		Message   string `json:"message"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	valid, err := verifier.VerifyMessage(input.Address, []byte(input.Message), input.Signature)
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "valid": valid}, nil
+}
+
 func (handlers *Handlers) getHeadersStatus(r *http.Request) (interface{}, error) {
 	return handlers.account.HeadersStatus()
 }
@@ -283,10 +1054,15 @@ func (handlers *Handlers) getAccountFeeTargets(_ *http.Request) (interface{}, er
 		if feeTarget.FeeRatePerKb != nil {
 			feeRatePerKb = handlers.formatBTCAmountAsJSON(*feeTarget.FeeRatePerKb)
 		}
+		var priorityFeeGwei interface{}
+		if feeTarget.GasTipCap != nil {
+			priorityFeeGwei = eth.FormatGwei(feeTarget.GasTipCap)
+		}
 		result = append(result,
 			map[string]interface{}{
-				"code":         feeTarget.Code,
-				"feeRatePerKb": feeRatePerKb,
+				"code":            feeTarget.Code,
+				"feeRatePerKb":    feeRatePerKb,
+				"priorityFeeGwei": priorityFeeGwei,
 			})
 	}
 	return map[string]interface{}{
@@ -320,13 +1096,16 @@ func (handlers *Handlers) getAccountStatus(_ *http.Request) (interface{}, error)
 
 func (handlers *Handlers) getReceiveAddresses(_ *http.Request) (interface{}, error) {
 	addresses := []interface{}{}
+	labels := handlers.account.AddressLabels()
 	for _, address := range handlers.account.GetUnusedReceiveAddresses() {
 		addresses = append(addresses, struct {
 			Address   string `json:"address"`
 			AddressID string `json:"addressID"`
+			Label     string `json:"label"`
 		}{
 			Address:   address.EncodeForHumans(),
 			AddressID: address.ID(),
+			Label:     labels[address.EncodeForHumans()],
 		})
 	}
 	return addresses, nil
@@ -351,3 +1130,137 @@ func (handlers *Handlers) postConvertToLegacyAddress(r *http.Request) (interface
 	}
 	return address.EncodeAddress(), nil
 }
+
+// postParsePaymentURI parses a payment URI (BIP21 or EIP-681), e.g. from a scanned QR code, into
+// the address and the amount to prefill the send form with.
+func (handlers *Handlers) postParsePaymentURI(r *http.Request) (interface{}, error) {
+	var uri string
+	if err := json.NewDecoder(r.Body).Decode(&uri); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if ethCoin, ok := handlers.account.Coin().(*eth.Coin); ok {
+		paymentRequest, err := ethCoin.ParsePaymentURI(uri)
+		if err != nil {
+			return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+		}
+		amount := ""
+		if paymentRequest.Amount != nil {
+			amount = ethCoin.FormatAmount(coin.NewAmount(paymentRequest.Amount))
+		}
+		return map[string]interface{}{
+			"success":  true,
+			"address":  paymentRequest.Recipient,
+			"amount":   amount,
+			"gasLimit": paymentRequest.GasLimit,
+			"params":   map[string]string{},
+		}, nil
+	}
+	btcCoin, ok := handlers.account.Coin().(*btc.Coin)
+	if !ok {
+		return nil, errp.New("Payment URIs are not supported for this coin.")
+	}
+	parsed, err := bip21.Parse(uri, btcCoin.URIScheme())
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{
+		"success": true,
+		"address": parsed.Address,
+		"amount":  parsed.Amount,
+		"label":   parsed.Label,
+		"message": parsed.Message,
+		"params":  parsed.Params,
+	}, nil
+}
+
+// postAddressLabel stores the label of a receive address.
+func (handlers *Handlers) postAddressLabel(r *http.Request) (interface{}, error) {
+	var input struct {
+		AddressID string `json:"addressID"`
+		Label     string `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return nil, handlers.account.SetAddressLabel(input.AddressID, input.Label)
+}
+
+// postOutputLabel stores the label of an output.
+func (handlers *Handlers) postOutputLabel(r *http.Request) (interface{}, error) {
+	account, err := handlers.btcAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		OutPoint string `json:"outPoint"`
+		Label    string `json:"label"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return nil, account.SetOutputLabel(input.OutPoint, input.Label)
+}
+
+// postOutputFrozen freezes or unfreezes an output.
+func (handlers *Handlers) postOutputFrozen(r *http.Request) (interface{}, error) {
+	account, err := handlers.btcAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		OutPoint string `json:"outPoint"`
+		Frozen   bool   `json:"frozen"`
+		Reason   string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return nil, account.SetOutputFrozen(input.OutPoint, input.Frozen, input.Reason)
+}
+
+// getLabels exports the labels of the account in the BIP-0329 format.
+func (handlers *Handlers) getLabels(_ *http.Request) (interface{}, error) {
+	account, err := handlers.btcAccount()
+	if err != nil {
+		return nil, err
+	}
+	data, err := account.ExportLabels()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"filename": fmt.Sprintf("%s-labels.jsonl", account.Code()),
+		"data":     string(data),
+	}, nil
+}
+
+// postLabels imports labels in the BIP-0329 format.
+func (handlers *Handlers) postLabels(r *http.Request) (interface{}, error) {
+	account, err := handlers.btcAccount()
+	if err != nil {
+		return nil, err
+	}
+	var input struct {
+		Data string `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	imported, err := account.ImportLabels([]byte(input.Data))
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true, "imported": imported}, nil
+}
+
+// postTxNote stores the note of a transaction.
+func (handlers *Handlers) postTxNote(r *http.Request) (interface{}, error) {
+	var input struct {
+		TxID string `json:"txID"`
+		Note string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return nil, handlers.account.SetTxNote(input.TxID, input.Note)
+}
@@ -0,0 +1,218 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/util"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// Label is a record of the BIP-0329 label format. Records of other types than tx, addr and output
+// are ignored on import.
+type Label struct {
+	Type  string `json:"type"`
+	Ref   string `json:"ref"`
+	Label string `json:"label"`
+	// Origin is the descriptor of the wallet which created the label. It is ignored on import.
+	Origin string `json:"origin,omitempty"`
+}
+
+const (
+	labelTypeTx      = "tx"
+	labelTypeAddress = "addr"
+	labelTypeOutput  = "output"
+)
+
+// lookupAddress returns the receive or change address of the account with the given encoding, or
+// nil if it does not belong to the account.
+func (account *Account) lookupAddress(encodedAddress string) *addresses.AccountAddress {
+	decodedAddress, err := taproot.DecodeAddress(encodedAddress, account.coin.Net())
+	if err != nil {
+		return nil
+	}
+	pkScript, err := taproot.PayToAddrScript(decodedAddress)
+	if err != nil {
+		return nil
+	}
+	scriptHashHex := blockchain.ScriptHashHex(chainhash.HashH(pkScript).String())
+	if address := account.receiveAddresses.LookupByScriptHashHex(scriptHashHex); address != nil {
+		return address
+	}
+	return account.changeAddresses.LookupByScriptHashHex(scriptHashHex)
+}
+
+// ExportLabels returns the transaction notes and the labels of addresses and outputs in the
+// BIP-0329 format, one JSON record per line.
+func (account *Account) ExportLabels() ([]byte, error) {
+	keystoreFingerprint, err := account.notesKeystoreFingerprint()
+	if err != nil {
+		return nil, err
+	}
+	defer account.RLock()()
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+	notes, err := dbTx.TxNotes(keystoreFingerprint)
+	if err != nil {
+		return nil, err
+	}
+	outputLabels, err := dbTx.OutputLabels()
+	if err != nil {
+		return nil, err
+	}
+	labels := []*Label{}
+	for txHash, note := range notes {
+		labels = append(labels, &Label{Type: labelTypeTx, Ref: txHash.String(), Label: note})
+	}
+	for _, address := range append(account.receiveAddresses.Labeled(), account.changeAddresses.Labeled()...) {
+		labels = append(labels,
+			&Label{Type: labelTypeAddress, Ref: address.EncodeForHumans(), Label: address.Label})
+	}
+	for outPoint, label := range outputLabels {
+		labels = append(labels, &Label{Type: labelTypeOutput, Ref: outPoint.String(), Label: label})
+	}
+	// Sort for a stable output.
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Type != labels[j].Type {
+			return labels[i].Type > labels[j].Type
+		}
+		return labels[i].Ref < labels[j].Ref
+	})
+	var result bytes.Buffer
+	for _, label := range labels {
+		jsonBytes, err := json.Marshal(label)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		result.Write(jsonBytes)
+		result.WriteByte('\n')
+	}
+	return result.Bytes(), nil
+}
+
+// ImportLabels stores the transaction notes and the labels of addresses and outputs given in the
+// BIP-0329 format. Existing labels are overwritten. Addresses which do not belong to the account
+// are skipped. It returns the number of imported labels.
+func (account *Account) ImportLabels(data []byte) (int, error) {
+	labels := []*Label{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		label := &Label{}
+		if err := json.Unmarshal([]byte(line), label); err != nil {
+			return 0, errp.Newf("Invalid label in line %d.", lineNumber)
+		}
+		labels = append(labels, label)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, errp.WithStack(err)
+	}
+	keystoreFingerprint, err := account.notesKeystoreFingerprint()
+	if err != nil {
+		return 0, err
+	}
+	defer account.Lock()()
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer dbTx.Rollback()
+	addressLabels := map[*addresses.AccountAddress]string{}
+	imported := 0
+	for _, label := range labels {
+		switch label.Type {
+		case labelTypeTx:
+			txHash, err := chainhash.NewHashFromStr(label.Ref)
+			if err != nil {
+				return 0, errp.Newf("Invalid transaction ID %s.", label.Ref)
+			}
+			if err := dbTx.PutTxNote(keystoreFingerprint, *txHash, label.Label); err != nil {
+				return 0, err
+			}
+		case labelTypeAddress:
+			address := account.lookupAddress(label.Ref)
+			if address == nil {
+				continue
+			}
+			if err := dbTx.PutAddressLabel(address.PubkeyScriptHashHex(), label.Label); err != nil {
+				return 0, err
+			}
+			addressLabels[address] = label.Label
+		case labelTypeOutput:
+			outPoint, err := util.ParseOutPoint([]byte(label.Ref))
+			if err != nil {
+				return 0, errp.Newf("Invalid output %s.", label.Ref)
+			}
+			if err := dbTx.PutOutputLabel(*outPoint, label.Label); err != nil {
+				return 0, err
+			}
+		default:
+			continue
+		}
+		imported++
+	}
+	if err := dbTx.Commit(); err != nil {
+		return 0, err
+	}
+	for address, label := range addressLabels {
+		address.Label = label
+	}
+	return imported, nil
+}
+
+// outputLabels returns the labels of the outputs by outpoint.
+func (account *Account) outputLabels() (map[wire.OutPoint]string, error) {
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer dbTx.Rollback()
+	return dbTx.OutputLabels()
+}
+
+// SetOutputLabel labels the output with the given outpoint, formatted as `<txID>:<index>`. An
+// empty label removes it.
+func (account *Account) SetOutputLabel(outPointString string, label string) error {
+	outPoint, err := util.ParseOutPoint([]byte(outPointString))
+	if err != nil {
+		return err
+	}
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+	if err := dbTx.PutOutputLabel(*outPoint, label); err != nil {
+		return err
+	}
+	return dbTx.Commit()
+}
@@ -15,6 +15,14 @@ import (
 // feeForSerializeSize calculates the required fee for a transaction of some
 // arbitrary size given a mempool's relay fee policy.
 func feeForSerializeSize(relayFeePerKb btcutil.Amount, txSerializeSize int, log *logrus.Entry) btcutil.Amount {
+	fee := calcFee(relayFeePerKb, txSerializeSize)
+	log.WithFields(logrus.Fields{"relayFeePerKb": relayFeePerKb, "txSerializeSize": txSerializeSize, "fee": fee}).Debugf("Calculated fee is %s", fee)
+
+	return fee
+}
+
+// calcFee is like feeForSerializeSize, but does not log. It is used where many fees are computed.
+func calcFee(relayFeePerKb btcutil.Amount, txSerializeSize int) btcutil.Amount {
 	fee := relayFeePerKb * btcutil.Amount(txSerializeSize) / 1000
 
 	if fee == 0 && relayFeePerKb > 0 {
@@ -24,19 +32,32 @@ func feeForSerializeSize(relayFeePerKb btcutil.Amount, txSerializeSize int, log
 	if fee < 0 || fee > btcutil.MaxSatoshi {
 		fee = btcutil.MaxSatoshi
 	}
-	log.WithFields(logrus.Fields{"relayFeePerKb": relayFeePerKb, "txSerializeSize": txSerializeSize, "fee": fee}).Debugf("Calculated fee is %s", fee)
-
 	return fee
 }
 
+// IsDustOutput returns whether an output of the given amount to the given address would be
+// dust, like the outputs rejected by NewTx.
+func IsDustOutput(
+	amount btcutil.Amount,
+	address *addresses.AccountAddress,
+	relayFeePerKb btcutil.Amount,
+	dustLimit btcutil.Amount) bool {
+	return isDustAmount(amount, len(address.PubkeyScript()), address.Configuration, relayFeePerKb, dustLimit)
+}
+
 // isDustAmount determines whether a transaction output value and script length would
 // cause the output to be considered dust.  Transactions with dust outputs are
-// not standard and are rejected by mempools with default policies.
+// not standard and are rejected by mempools with default policies. Amounts below dustLimit are
+// considered dust as well.
 func isDustAmount(
 	amount btcutil.Amount,
 	pkScriptSize int,
 	configuration *signing.Configuration,
-	relayFeePerKb btcutil.Amount) bool {
+	relayFeePerKb btcutil.Amount,
+	dustLimit btcutil.Amount) bool {
+	if amount < dustLimit {
+		return true
+	}
 	// Calculate the total (estimated) cost to the network.  This is
 	// calculated using the serialize size of the output plus the serial
 	// size of a transaction input which redeems it.
@@ -0,0 +1,140 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	"sort"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+)
+
+// branchAndBoundMaxTries limits the number of combinations visited by the branch and bound search.
+const branchAndBoundMaxTries = 100000
+
+// branchAndBound is the state of the search for a changeless coin selection.
+type branchAndBound struct {
+	outPoints []wire.OutPoint
+	values    []btcutil.Amount
+	// effectiveValues are the values minus the fee of spending the output.
+	effectiveValues []btcutil.Amount
+	// remaining are the sums of the effective values from each index to the end.
+	remaining    []btcutil.Amount
+	targetAmount btcutil.Amount
+	// target is the target amount plus the fee of a transaction without inputs and change.
+	target       btcutil.Amount
+	costOfChange btcutil.Amount
+	// fee returns the fee of a transaction without change with the given number of inputs.
+	fee func(inputCount int) btcutil.Amount
+
+	tries    int
+	selected []int
+	best     []int
+	bestSum  btcutil.Amount
+	// bestExcess is the amount of the best selection which is paid as fee on top of the required
+	// fee. It is -1 if no selection was found.
+	bestExcess btcutil.Amount
+}
+
+// search visits the selections which include or exclude the output at the given index, in
+// addition to the outputs selected so far, of which the effective values sum up to
+// effectiveValuesSum. excludedPrevious is whether the output before the index was excluded.
+func (bnb *branchAndBound) search(index int, effectiveValuesSum btcutil.Amount, excludedPrevious bool) {
+	bnb.tries++
+	if bnb.tries > branchAndBoundMaxTries || bnb.bestExcess == 0 {
+		return
+	}
+	if effectiveValuesSum > bnb.target+bnb.costOfChange {
+		return
+	}
+	if effectiveValuesSum >= bnb.target {
+		// The effective values are an estimate, the fee is checked exactly.
+		sum := btcutil.Amount(0)
+		for _, selectedIndex := range bnb.selected {
+			sum += bnb.values[selectedIndex]
+		}
+		excess := sum - bnb.targetAmount - bnb.fee(len(bnb.selected))
+		if excess >= 0 && excess <= bnb.costOfChange && (bnb.bestExcess < 0 || excess < bnb.bestExcess) {
+			bnb.best = append([]int{}, bnb.selected...)
+			bnb.bestSum = sum
+			bnb.bestExcess = excess
+		}
+		return
+	}
+	if index == len(bnb.values) || effectiveValuesSum+bnb.remaining[index] < bnb.target {
+		return
+	}
+	// Including an output of the same value as the excluded previous one would visit the same
+	// selections again.
+	if !excludedPrevious || bnb.effectiveValues[index] != bnb.effectiveValues[index-1] {
+		bnb.selected = append(bnb.selected, index)
+		bnb.search(index+1, effectiveValuesSum+bnb.effectiveValues[index], false)
+		bnb.selected = bnb.selected[:len(bnb.selected)-1]
+	}
+	bnb.search(index+1, effectiveValuesSum, true)
+}
+
+// selectCoinsBranchAndBound searches for a selection of the outputs which pays the target amount
+// and the fee of a transaction without change, paying at most costOfChange more than that, as a
+// change output would cost more than that to create and to spend later. fee returns the fee of a
+// transaction without change with the given number of inputs and inputFee is the fee of one input.
+// Among the selections found, the one paying the least excess is returned. Returns false if no
+// such selection was found.
+func selectCoinsBranchAndBound(
+	outputs map[wire.OutPoint]*wire.TxOut,
+	targetAmount btcutil.Amount,
+	fee func(inputCount int) btcutil.Amount,
+	inputFee btcutil.Amount,
+	costOfChange btcutil.Amount,
+) (btcutil.Amount, []wire.OutPoint, bool) {
+	outPoints := []wire.OutPoint{}
+	for outPoint := range outputs {
+		outPoints = append(outPoints, outPoint)
+	}
+	// Sort by outpoint first so that the search is deterministic among outputs of equal value.
+	sort.Slice(outPoints, func(i, j int) bool { return outPoints[i].String() < outPoints[j].String() })
+	sort.Stable(sort.Reverse(&byValue{outPoints, outputs}))
+
+	bnb := &branchAndBound{
+		targetAmount: targetAmount,
+		target:       targetAmount + fee(0),
+		costOfChange: costOfChange,
+		fee:          fee,
+		bestExcess:   -1,
+	}
+	for _, outPoint := range outPoints {
+		value := btcutil.Amount(outputs[outPoint].Value)
+		// Outputs which do not pay for their own fee are not worth spending.
+		if value <= inputFee {
+			continue
+		}
+		bnb.outPoints = append(bnb.outPoints, outPoint)
+		bnb.values = append(bnb.values, value)
+		bnb.effectiveValues = append(bnb.effectiveValues, value-inputFee)
+	}
+	bnb.remaining = make([]btcutil.Amount, len(bnb.values)+1)
+	for index := len(bnb.values) - 1; index >= 0; index-- {
+		bnb.remaining[index] = bnb.remaining[index+1] + bnb.effectiveValues[index]
+	}
+	bnb.search(0, 0, false)
+	if bnb.bestExcess < 0 {
+		return 0, nil, false
+	}
+	selectedOutPoints := make([]wire.OutPoint, len(bnb.best))
+	for i, index := range bnb.best {
+		selectedOutPoints[i] = bnb.outPoints[index]
+	}
+	return bnb.bestSum, selectedOutPoints, true
+}
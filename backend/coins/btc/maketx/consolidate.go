@@ -0,0 +1,69 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	coinpkg "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/sirupsen/logrus"
+)
+
+// NewTxConsolidation creates a transaction which spends all of the given outputs to a single
+// output to changeAddress, so that fewer inputs are needed to spend the coins later.
+func NewTxConsolidation(
+	coin coinpkg.Coin,
+	inputConfiguration *signing.Configuration,
+	spendableOutputs map[wire.OutPoint]*wire.TxOut,
+	changeAddress *addresses.AccountAddress,
+	feePerKb btcutil.Amount,
+	dustLimit btcutil.Amount,
+	log *logrus.Entry,
+) (*TxProposal, error) {
+	if len(spendableOutputs) < 2 {
+		return nil, errp.New("At least two outputs are needed for a consolidation.")
+	}
+	txProposal, err := NewTxSpendAll(
+		coin, inputConfiguration, spendableOutputs, changeAddress.PubkeyScript(), nil, feePerKb, dustLimit,
+		log)
+	if err != nil {
+		return nil, err
+	}
+	// The output belongs to the wallet.
+	txProposal.ChangeAddress = changeAddress
+	return txProposal, nil
+}
+
+// ConsolidationSavings estimates how much less fees are paid in total by making the given
+// consolidation now and spending its output later at futureFeePerKb, compared to spending all of
+// the consolidated outputs later at futureFeePerKb. It is negative if the consolidation does not
+// pay off.
+func ConsolidationSavings(
+	txProposal *TxProposal,
+	futureFeePerKb btcutil.Amount,
+	log *logrus.Entry,
+) btcutil.Amount {
+	pkScriptSize := len(txProposal.ChangeAddress.PubkeyScript())
+	// The later transaction is assumed to have one output and change.
+	futureFee := func(inputCount int) btcutil.Amount {
+		txSize := estimateTxSize(inputCount, txProposal.AccountConfiguration,
+			[]int{pkScriptSize}, pkScriptSize)
+		return feeForSerializeSize(futureFeePerKb, txSize, log)
+	}
+	return futureFee(len(txProposal.Transaction.TxIn)) - futureFee(1) - txProposal.Fee
+}
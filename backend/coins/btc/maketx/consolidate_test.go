@@ -0,0 +1,73 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	coinpkg "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func newTxConsolidation(
+	t *testing.T, feePerKb btcutil.Amount, satoshis ...int64,
+) (*newTxSuite, *maketx.TxProposal, error) {
+	s := &newTxSuite{}
+	s.SetT(t)
+	s.SetupTest()
+	txProposal, err := maketx.NewTxConsolidation(
+		tbtc,
+		s.inputConfiguration,
+		s.buildUTXO(satoshis...),
+		s.changeAddress,
+		feePerKb,
+		s.dustLimit,
+		s.log,
+	)
+	return s, txProposal, err
+}
+
+func TestNewTxConsolidation(t *testing.T) {
+	s, txProposal, err := newTxConsolidation(t, 1000, 10000, 20000, 30000, 40000, 50000)
+	require.NoError(t, err)
+	vsize := maketx.TstEstimateTxSize(5, s.inputConfiguration, len(s.changeAddress.PubkeyScript()), 0)
+	require.Equal(t, btcutil.Amount(vsize), txProposal.Fee)
+	require.Equal(t, vsize, txProposal.VSize())
+	require.Equal(t, s.changeAddress, txProposal.ChangeAddress)
+	require.Len(t, txProposal.Transaction.TxIn, 5)
+	require.Len(t, txProposal.Transaction.TxOut, 1)
+	require.Equal(t, int64(150000)-int64(txProposal.Fee), txProposal.Transaction.TxOut[0].Value)
+	require.Equal(t, s.changeAddress.PubkeyScript(), txProposal.Transaction.TxOut[0].PkScript)
+
+	// Spending five inputs at 20 sat/vbyte later costs four more inputs than spending one.
+	changeSize := len(s.changeAddress.PubkeyScript())
+	fourInputsFee := btcutil.Amount(20 * (maketx.TstEstimateTxSize(5, s.inputConfiguration, changeSize, changeSize) -
+		maketx.TstEstimateTxSize(1, s.inputConfiguration, changeSize, changeSize)))
+	require.Equal(t, fourInputsFee-txProposal.Fee, maketx.ConsolidationSavings(txProposal, 20000, s.log))
+	// At the same fee rate, the consolidation does not pay off.
+	require.True(t, maketx.ConsolidationSavings(txProposal, 1000, s.log) < 0)
+}
+
+func TestNewTxConsolidationErrors(t *testing.T) {
+	_, _, err := newTxConsolidation(t, 1000, 50000)
+	require.Error(t, err)
+	_, _, err = newTxConsolidation(t, 1000, 300, 300)
+	require.Equal(t, coinpkg.ErrDustAmount, errp.Cause(err))
+	_, _, err = newTxConsolidation(t, 1000, 100, 100)
+	require.Equal(t, coinpkg.ErrInsufficientFunds, errp.Cause(err))
+}
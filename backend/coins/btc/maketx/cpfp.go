@@ -0,0 +1,104 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/txsort"
+	coinpkg "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/sirupsen/logrus"
+)
+
+// CPFPTxProposal is a child transaction which pays for an unconfirmed parent transaction.
+type CPFPTxProposal struct {
+	*TxProposal
+	// EffectiveFeePerKb is the fee rate of the parent and the child combined, which is what miners
+	// consider when including them.
+	EffectiveFeePerKb btcutil.Amount
+}
+
+// effectiveFeePerKb returns the fee rate of the given fee and size.
+func effectiveFeePerKb(fee btcutil.Amount, txSize int) btcutil.Amount {
+	return fee * 1000 / btcutil.Amount(txSize)
+}
+
+// NewTxCPFP creates a child transaction which spends the given outputs of an unconfirmed parent
+// transaction to outputPkScript. The fee of the child is chosen such that the parent and the child
+// together pay the given fee rate. It is at least the minimum relay fee of the child itself.
+// parentFee and parentVSize are the fee and the virtual size of the parent. The outputs can also
+// include other outputs than the ones of the parent.
+func NewTxCPFP(
+	coin coinpkg.Coin,
+	inputConfiguration *signing.Configuration,
+	spendableOutputs map[wire.OutPoint]*wire.TxOut,
+	outputPkScript []byte,
+	parentFee btcutil.Amount,
+	parentVSize int,
+	feePerKb btcutil.Amount,
+	dustLimit btcutil.Amount,
+	log *logrus.Entry,
+) (*CPFPTxProposal, error) {
+	if len(spendableOutputs) == 0 {
+		return nil, errp.New("The parent transaction has no outputs to spend.")
+	}
+	if parentVSize <= 0 {
+		return nil, errp.New("Invalid size of the parent transaction.")
+	}
+	if effectiveFeePerKb(parentFee, parentVSize) >= feePerKb {
+		return nil, errp.WithStack(coinpkg.ErrFeeTooLow)
+	}
+	inputs := []*wire.TxIn{}
+	outputsSum := btcutil.Amount(0)
+	for outPoint, output := range spendableOutputs {
+		outPoint := outPoint // avoid reference reuse due to range loop
+		outputsSum += btcutil.Amount(output.Value)
+		inputs = append(inputs, wire.NewTxIn(&outPoint, nil, nil))
+	}
+	txSize := estimateTxSize(len(inputs), inputConfiguration, []int{len(outputPkScript)}, 0)
+	fee := feeForSerializeSize(feePerKb, parentVSize+txSize, log) - parentFee
+	if minimumFee := feeForSerializeSize(incrementalRelayFeePerKb, txSize, log); fee < minimumFee {
+		fee = minimumFee
+	}
+	if outputsSum <= fee {
+		return nil, errp.WithStack(coinpkg.ErrInsufficientFunds)
+	}
+	if isDustAmount(outputsSum-fee, len(outputPkScript), inputConfiguration, feePerKb, dustLimit) {
+		return nil, errp.WithStack(coinpkg.ErrDustAmount)
+	}
+	output := wire.NewTxOut(int64(outputsSum-fee), outputPkScript)
+	unsignedTransaction := &wire.MsgTx{
+		Version:  wire.TxVersion,
+		TxIn:     inputs,
+		TxOut:    []*wire.TxOut{output},
+		LockTime: 0,
+	}
+	txsort.InPlaceSort(unsignedTransaction)
+	effectiveFee := effectiveFeePerKb(parentFee+fee, parentVSize+txSize)
+	log.WithFields(logrus.Fields{"fee": fee, "effectiveFeePerKb": effectiveFee}).
+		Debug("Preparing child transaction")
+	return &CPFPTxProposal{
+		TxProposal: &TxProposal{
+			Coin:                 coin,
+			AccountConfiguration: inputConfiguration,
+			Amount:               btcutil.Amount(output.Value),
+			Fee:                  fee,
+			Transaction:          unsignedTransaction,
+		},
+		EffectiveFeePerKb: effectiveFee,
+	}, nil
+}
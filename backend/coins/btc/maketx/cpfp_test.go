@@ -0,0 +1,80 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	coinpkg "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+const parentVSize = 200
+
+func newTxCPFP(
+	t *testing.T, parentFee btcutil.Amount, feePerKb btcutil.Amount, satoshis ...int64,
+) (*newTxSuite, *maketx.CPFPTxProposal, error) {
+	s := &newTxSuite{}
+	s.SetT(t)
+	s.SetupTest()
+	txProposal, err := maketx.NewTxCPFP(
+		tbtc,
+		s.inputConfiguration,
+		s.buildUTXO(satoshis...),
+		s.changeAddress.PubkeyScript(),
+		parentFee,
+		parentVSize,
+		feePerKb,
+		s.dustLimit,
+		s.log,
+	)
+	return s, txProposal, err
+}
+
+func TestNewTxCPFP(t *testing.T) {
+	// The parent pays 1 sat/vbyte, the target is 10 sat/vbyte.
+	s, txProposal, err := newTxCPFP(t, parentVSize, 10000, 50000, 20000)
+	require.NoError(t, err)
+	childVSize := maketx.TstEstimateTxSize(2, s.inputConfiguration, len(s.changeAddress.PubkeyScript()), 0)
+	require.Equal(t, btcutil.Amount(10*(parentVSize+childVSize)-parentVSize), txProposal.Fee)
+	require.Equal(t, btcutil.Amount(10000), txProposal.EffectiveFeePerKb)
+	require.Len(t, txProposal.Transaction.TxIn, 2)
+	require.Len(t, txProposal.Transaction.TxOut, 1)
+	require.Equal(t, int64(70000)-int64(txProposal.Fee), txProposal.Transaction.TxOut[0].Value)
+	require.Equal(t, s.changeAddress.PubkeyScript(), txProposal.Transaction.TxOut[0].PkScript)
+}
+
+func TestNewTxCPFPMinimumFee(t *testing.T) {
+	// With a target below the minimum relay fee rate, the child still has to pay for its own relay.
+	s, txProposal, err := newTxCPFP(t, parentVSize/2-1, 500, 50000)
+	require.NoError(t, err)
+	childVSize := maketx.TstEstimateTxSize(1, s.inputConfiguration, len(s.changeAddress.PubkeyScript()), 0)
+	require.Equal(t, btcutil.Amount(childVSize), txProposal.Fee)
+}
+
+func TestNewTxCPFPFeeTooLow(t *testing.T) {
+	_, _, err := newTxCPFP(t, 2*parentVSize, 2000, 50000)
+	require.Equal(t, coinpkg.ErrFeeTooLow, errp.Cause(err))
+}
+
+func TestNewTxCPFPInsufficientFunds(t *testing.T) {
+	_, _, err := newTxCPFP(t, parentVSize, 10000, 3000)
+	require.Equal(t, coinpkg.ErrInsufficientFunds, errp.Cause(err))
+	_, _, err = newTxCPFP(t, parentVSize, 10000)
+	require.Error(t, err)
+}
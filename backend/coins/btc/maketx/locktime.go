@@ -0,0 +1,59 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	"math/rand"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// lockTimeRandomizationChance is the chance of 1 in lockTimeRandomizationChance that the
+	// anti-fee-sniping locktime is set further back, as done by Bitcoin Core.
+	lockTimeRandomizationChance = 10
+	// maxLockTimeRandomization is the maximum number of blocks by which the locktime is set back.
+	maxLockTimeRandomization = 100
+)
+
+// SetAntiFeeSnipingLockTime sets the locktime of the transaction to the height of the current tip,
+// so that it can only be mined in the next block. As in Bitcoin Core, this discourages miners from
+// reorging the chain to collect the fees of past blocks, and makes our transactions look like the
+// ones of Bitcoin Core. Occasionally, the locktime is set up to 99 blocks back so that
+// transactions which were delayed before being broadcast do not stand out. The sequence numbers of
+// the inputs are lowered to enable the locktime, unless they already are. Without a known tip
+// (tipHeight <= 0), the transaction is not changed.
+func SetAntiFeeSnipingLockTime(transaction *wire.MsgTx, tipHeight int) {
+	setAntiFeeSnipingLockTime(transaction, tipHeight, rand.Intn)
+}
+
+func setAntiFeeSnipingLockTime(transaction *wire.MsgTx, tipHeight int, randIntn func(int) int) {
+	if tipHeight <= 0 {
+		return
+	}
+	lockTime := tipHeight
+	if randIntn(lockTimeRandomizationChance) == 0 {
+		lockTime -= randIntn(maxLockTimeRandomization)
+		if lockTime < 0 {
+			lockTime = 0
+		}
+	}
+	transaction.LockTime = uint32(lockTime)
+	for _, txIn := range transaction.TxIn {
+		if txIn.Sequence == wire.MaxTxInSequenceNum {
+			txIn.Sequence = wire.MaxTxInSequenceNum - 1
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAntiFeeSnipingLockTime(t *testing.T) {
+	newTransaction := func() *wire.MsgTx {
+		transaction := wire.NewMsgTx(wire.TxVersion)
+		transaction.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH([]byte("a"))}, nil, nil))
+		replaceable := wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH([]byte("b"))}, nil, nil)
+		replaceable.Sequence = wire.MaxTxInSequenceNum - 2
+		transaction.AddTxIn(replaceable)
+		return transaction
+	}
+	// randIntn returns the given values in order.
+	randIntn := func(values ...int) func(int) int {
+		return func(n int) int {
+			value := values[0]
+			values = values[1:]
+			require.True(t, value < n)
+			return value
+		}
+	}
+
+	// The locktime is the tip height and enabled by the sequence numbers.
+	transaction := newTransaction()
+	setAntiFeeSnipingLockTime(transaction, 540000, randIntn(5))
+	require.Equal(t, uint32(540000), transaction.LockTime)
+	require.Equal(t, uint32(wire.MaxTxInSequenceNum-1), transaction.TxIn[0].Sequence)
+	require.Equal(t, uint32(wire.MaxTxInSequenceNum-2), transaction.TxIn[1].Sequence)
+
+	// Occasionally, the locktime is set back.
+	transaction = newTransaction()
+	setAntiFeeSnipingLockTime(transaction, 540000, randIntn(0, 99))
+	require.Equal(t, uint32(539901), transaction.LockTime)
+
+	// The locktime does not become negative.
+	transaction = newTransaction()
+	setAntiFeeSnipingLockTime(transaction, 10, randIntn(0, 20))
+	require.Equal(t, uint32(0), transaction.LockTime)
+
+	// Without a known tip, the transaction is not changed.
+	transaction = newTransaction()
+	setAntiFeeSnipingLockTime(transaction, -1, randIntn())
+	require.Equal(t, newTransaction(), transaction)
+}
@@ -15,9 +15,11 @@
 package maketx
 
 import (
+	"bytes"
 	"sort"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/txsort"
@@ -40,6 +42,12 @@ type TxProposal struct {
 	Transaction *wire.MsgTx
 	// ChangeAddress is the address of the wallet to which the change of the transaction is sent.
 	ChangeAddress *addresses.AccountAddress
+	// DustChange is the change which was added to the fee instead, as a change output of this value
+	// would be dust or would cost more to create and spend than its value. It is included in Fee.
+	DustChange btcutil.Amount
+	// Ordering is the order of the inputs and outputs, see SetOrdering. They are sorted according
+	// to BIP69 unless it is TxOrderingRandom.
+	Ordering TxOrdering
 }
 
 // Total is amount+fee.
@@ -47,6 +55,38 @@ func (txProposal *TxProposal) Total() btcutil.Amount {
 	return txProposal.Amount + txProposal.Fee
 }
 
+// VSize returns the estimated virtual size of the transaction once it is signed.
+func (txProposal *TxProposal) VSize() int {
+	outputPkScriptSizes := []int{}
+	changePkScriptSize := 0
+	for _, txOut := range txProposal.Transaction.TxOut {
+		if txProposal.ChangeAddress != nil &&
+			bytes.Equal(txOut.PkScript, txProposal.ChangeAddress.PubkeyScript()) {
+			changePkScriptSize = len(txOut.PkScript)
+		} else {
+			outputPkScriptSizes = append(outputPkScriptSizes, len(txOut.PkScript))
+		}
+	}
+	return estimateTxSize(len(txProposal.Transaction.TxIn), txProposal.AccountConfiguration,
+		outputPkScriptSizes, changePkScriptSize)
+}
+
+// NewOpReturnOutput returns an output without value which carries the given data in an OP_RETURN
+// script, e.g. for proofs of existence. The data can be at most txscript.MaxDataCarrierSize (80)
+// bytes, the limit of standard transactions.
+func NewOpReturnOutput(data []byte) (*wire.TxOut, error) {
+	pkScript, err := txscript.NullDataScript(data)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return wire.NewTxOut(0, pkScript), nil
+}
+
+// isOpReturnOutput returns whether the output carries data instead of paying a recipient.
+func isOpReturnOutput(output *wire.TxOut) bool {
+	return txscript.GetScriptClass(output.PkScript) == txscript.NullDataTy
+}
+
 type byValue struct {
 	outPoints []wire.OutPoint
 	outputs   map[wire.OutPoint]*wire.TxOut
@@ -87,13 +127,17 @@ func coinSelection(
 	return outputsSum, selectedOutPoints, nil
 }
 
-// NewTxSpendAll creates a transaction which spends all available unspent outputs.
+// NewTxSpendAll creates a transaction which spends all of the given unspent outputs, which can be
+// all coins of the account or the ones selected by the user. opReturnOutput is added to the
+// transaction if it is not nil.
 func NewTxSpendAll(
 	coin coinpkg.Coin,
 	inputConfiguration *signing.Configuration,
 	spendableOutputs map[wire.OutPoint]*wire.TxOut,
 	outputPkScript []byte,
+	opReturnOutput *wire.TxOut,
 	feePerKb btcutil.Amount,
+	dustLimit btcutil.Amount,
 	log *logrus.Entry,
 ) (*TxProposal, error) {
 	selectedOutPoints := []wire.OutPoint{}
@@ -105,11 +149,18 @@ func NewTxSpendAll(
 		outputsSum += btcutil.Amount(output.Value)
 		inputs = append(inputs, wire.NewTxIn(&outPoint, nil, nil))
 	}
-	txSize := estimateTxSize(len(selectedOutPoints), inputConfiguration, len(outputPkScript), 0)
+	outputPkScriptSizes := []int{len(outputPkScript)}
+	if opReturnOutput != nil {
+		outputPkScriptSizes = append(outputPkScriptSizes, len(opReturnOutput.PkScript))
+	}
+	txSize := estimateTxSize(len(selectedOutPoints), inputConfiguration, outputPkScriptSizes, 0)
 	maxRequiredFee := feeForSerializeSize(feePerKb, txSize, log)
 	if outputsSum < maxRequiredFee {
 		return nil, errp.WithStack(coinpkg.ErrInsufficientFunds)
 	}
+	if isDustAmount(outputsSum-maxRequiredFee, len(outputPkScript), inputConfiguration, feePerKb, dustLimit) {
+		return nil, errp.WithStack(coinpkg.ErrDustAmount)
+	}
 	output := wire.NewTxOut(int64(outputsSum-maxRequiredFee), outputPkScript)
 	unsignedTransaction := &wire.MsgTx{
 		Version:  wire.TxVersion,
@@ -117,6 +168,9 @@ func NewTxSpendAll(
 		TxOut:    []*wire.TxOut{output},
 		LockTime: 0,
 	}
+	if opReturnOutput != nil {
+		unsignedTransaction.TxOut = append(unsignedTransaction.TxOut, opReturnOutput)
+	}
 	txsort.InPlaceSort(unsignedTransaction)
 	log.WithField("fee", maxRequiredFee).Debug("Preparing transaction to spend all outputs")
 	return &TxProposal{
@@ -128,28 +182,146 @@ func NewTxSpendAll(
 	}, nil
 }
 
-// NewTx creates a transaction from a set of unspent outputs, targeting an output value. A subset of
-// the unspent outputs is selected to cover the needed amount. A change output is added if needed.
+// selectAllCoins selects all outputs. It is used if the user chose the outputs to spend.
+func selectAllCoins(
+	minAmount btcutil.Amount,
+	outputs map[wire.OutPoint]*wire.TxOut,
+) (btcutil.Amount, []wire.OutPoint, error) {
+	outPoints := []wire.OutPoint{}
+	outputsSum := btcutil.Amount(0)
+	for outPoint, output := range outputs {
+		outPoints = append(outPoints, outPoint)
+		outputsSum += btcutil.Amount(output.Value)
+	}
+	if outputsSum < minAmount {
+		return 0, nil, errp.WithStack(coinpkg.ErrInsufficientFunds)
+	}
+	return outputsSum, outPoints, nil
+}
+
+// NewTx creates a transaction from a set of unspent outputs, targeting the values of the given
+// outputs. A subset of the unspent outputs is selected to cover the needed amount. A selection
+// which does not need change is searched for first, see selectCoinsBranchAndBound. Otherwise, the
+// largest outputs are selected and a change output is added if needed. Outputs which would be dust
+// are rejected with coinpkg.ErrDustAmount, while change which would be dust is added to the fee.
+// The outputs can include one OP_RETURN output created by NewOpReturnOutput.
 func NewTx(
 	coin coinpkg.Coin,
 	inputConfiguration *signing.Configuration,
 	spendableOutputs map[wire.OutPoint]*wire.TxOut,
-	output *wire.TxOut,
+	outputs []*wire.TxOut,
 	feePerKb btcutil.Amount,
+	dustLimit btcutil.Amount,
 	getChangeAddress func() *addresses.AccountAddress,
 	log *logrus.Entry,
 ) (*TxProposal, error) {
-	targetAmount := btcutil.Amount(output.Value)
-	if targetAmount <= 0 {
-		panic("amount must be positive")
+	return newTx(coin, inputConfiguration, spendableOutputs, outputs, feePerKb, dustLimit,
+		getChangeAddress, coinSelection, true, log)
+}
+
+// NewTxFromSelectedOutputs is like NewTx, but spends all of the given outputs instead of selecting
+// a subset, as when the user chose the outputs to spend.
+func NewTxFromSelectedOutputs(
+	coin coinpkg.Coin,
+	inputConfiguration *signing.Configuration,
+	selectedOutputs map[wire.OutPoint]*wire.TxOut,
+	outputs []*wire.TxOut,
+	feePerKb btcutil.Amount,
+	dustLimit btcutil.Amount,
+	getChangeAddress func() *addresses.AccountAddress,
+	log *logrus.Entry,
+) (*TxProposal, error) {
+	return newTx(coin, inputConfiguration, selectedOutputs, outputs, feePerKb, dustLimit,
+		getChangeAddress, selectAllCoins, false, log)
+}
+
+func newTx(
+	coin coinpkg.Coin,
+	inputConfiguration *signing.Configuration,
+	spendableOutputs map[wire.OutPoint]*wire.TxOut,
+	outputs []*wire.TxOut,
+	feePerKb btcutil.Amount,
+	dustLimit btcutil.Amount,
+	getChangeAddress func() *addresses.AccountAddress,
+	selectCoins func(
+		btcutil.Amount, map[wire.OutPoint]*wire.TxOut) (btcutil.Amount, []wire.OutPoint, error),
+	searchChangeless bool,
+	log *logrus.Entry,
+) (*TxProposal, error) {
+	if len(outputs) == 0 {
+		panic("at least one output is needed")
+	}
+	targetAmount := btcutil.Amount(0)
+	outputPkScriptSizes := make([]int, len(outputs))
+	opReturnOutputs := 0
+	for i, output := range outputs {
+		outputPkScriptSizes[i] = len(output.PkScript)
+		if isOpReturnOutput(output) {
+			opReturnOutputs++
+			if opReturnOutputs > 1 {
+				return nil, errp.New("Only one OP_RETURN output is allowed.")
+			}
+			if output.Value != 0 {
+				return nil, errp.New("OP_RETURN outputs must not have a value.")
+			}
+			continue
+		}
+		if output.Value <= 0 {
+			panic("amount must be positive")
+		}
+		if isDustAmount(btcutil.Amount(output.Value), len(output.PkScript), inputConfiguration,
+			feePerKb, dustLimit) {
+			return nil, errp.WithStack(coinpkg.ErrDustAmount)
+		}
+		targetAmount += btcutil.Amount(output.Value)
 	}
-	outputs := []*wire.TxOut{output}
 	changeAddress := getChangeAddress()
 	changePKScript := changeAddress.PubkeyScript()
-	estimatedSize := estimateTxSize(1, inputConfiguration, len(output.PkScript), len(changePKScript))
+	if searchChangeless {
+		feeWithoutChange := func(inputCount int) btcutil.Amount {
+			return calcFee(feePerKb, estimateTxSize(inputCount, inputConfiguration, outputPkScriptSizes, 0))
+		}
+		// A change output costs its own fee and the fee of spending it later.
+		costOfChange := calcFee(feePerKb,
+			outputSize(len(changePKScript))+InputVSize(changeAddress.Configuration))
+		selectedOutputsSum, selectedOutPoints, ok := selectCoinsBranchAndBound(
+			spendableOutputs, targetAmount, feeWithoutChange,
+			calcFee(feePerKb, InputVSize(inputConfiguration)), costOfChange)
+		if ok {
+			inputs := make([]*wire.TxIn, len(selectedOutPoints))
+			for i, outPoint := range selectedOutPoints {
+				outPoint := outPoint // avoid reference reuse due to range loop
+				inputs[i] = wire.NewTxIn(&outPoint, nil, nil)
+			}
+			unsignedTransaction := &wire.MsgTx{
+				Version:  wire.TxVersion,
+				TxIn:     inputs,
+				TxOut:    append([]*wire.TxOut{}, outputs...),
+				LockTime: 0,
+			}
+			txsort.InPlaceSort(unsignedTransaction)
+			finalFee := selectedOutputsSum - targetAmount
+			// The change which is saved, compared to the fee of a transaction with change.
+			dustChange := finalFee - feeForSerializeSize(feePerKb,
+				estimateTxSize(len(inputs), inputConfiguration, outputPkScriptSizes, len(changePKScript)), log)
+			if dustChange < 0 {
+				dustChange = 0
+			}
+			log.WithField("fee", finalFee).Debug("Preparing transaction without change")
+			return &TxProposal{
+				Coin:                 coin,
+				AccountConfiguration: inputConfiguration,
+				Amount:               targetAmount,
+				Fee:                  finalFee,
+				Transaction:          unsignedTransaction,
+				DustChange:           dustChange,
+			}, nil
+		}
+	}
+	estimatedSize := estimateTxSize(1, inputConfiguration, outputPkScriptSizes, len(changePKScript))
 	targetFee := feeForSerializeSize(feePerKb, estimatedSize, log)
 	for {
-		selectedOutputsSum, selectedOutPoints, err := coinSelection(
+		selectedOutputsSum, selectedOutPoints, err := selectCoins(
 			targetAmount+targetFee,
 			spendableOutputs,
 		)
@@ -157,7 +329,7 @@ func NewTx(
 			return nil, err
 		}
 
-		txSize := estimateTxSize(len(selectedOutPoints), inputConfiguration, len(output.PkScript), len(changePKScript))
+		txSize := estimateTxSize(len(selectedOutPoints), inputConfiguration, outputPkScriptSizes, len(changePKScript))
 		maxRequiredFee := feeForSerializeSize(feePerKb, txSize, log)
 		if selectedOutputsSum-targetAmount < maxRequiredFee {
 			targetFee = maxRequiredFee
@@ -171,16 +343,18 @@ func NewTx(
 		unsignedTransaction := &wire.MsgTx{
 			Version:  wire.TxVersion,
 			TxIn:     inputs,
-			TxOut:    outputs,
+			TxOut:    append([]*wire.TxOut{}, outputs...),
 			LockTime: 0,
 		}
 		changeAmount := selectedOutputsSum - targetAmount - maxRequiredFee
 		changeIsDust := isDustAmount(
-			changeAmount, len(changePKScript), changeAddress.Configuration, feePerKb)
+			changeAmount, len(changePKScript), changeAddress.Configuration, feePerKb, dustLimit)
 		finalFee := maxRequiredFee
+		dustChange := btcutil.Amount(0)
 		if changeIsDust {
-			log.Info("change is dust")
+			log.WithField("change", changeAmount).Info("change is dust")
 			finalFee = selectedOutputsSum - targetAmount
+			dustChange = changeAmount
 		}
 		if changeAmount != 0 && !changeIsDust {
 			unsignedTransaction.TxOut = append(unsignedTransaction.TxOut,
@@ -197,6 +371,7 @@ func NewTx(
 			Fee:                  finalFee,
 			Transaction:          unsignedTransaction,
 			ChangeAddress:        changeAddress,
+			DustChange:           dustChange,
 		}, nil
 	}
 }
@@ -55,6 +55,9 @@ const (
 	txSizeOneInput   = 226
 	txSizeTwoInputs  = 374
 	txSizeFiveInputs = 818
+	// The vsizes of txs without change output.
+	txSizeOneInputNoChange  = 192
+	txSizeTwoInputsNoChange = 340
 )
 
 type newTxSuite struct {
@@ -66,12 +69,14 @@ type newTxSuite struct {
 	changeAddress      *addresses.AccountAddress
 	getChangeAddress   func() *addresses.AccountAddress
 	outputPkScript     []byte
+	dustLimit          btcutil.Amount
 
 	log *logrus.Entry
 }
 
 func (s *newTxSuite) SetupTest() {
 	s.log = logging.Get().WithGroup("newTxTest")
+	s.dustLimit = 0
 	s.inputConfiguration, s.addressChain = addressesTest.NewAddressChain()
 	someAddresses := s.addressChain.EnsureAddresses()
 	s.outputPkScript = someAddresses[1].PubkeyScript()
@@ -98,8 +103,9 @@ func (s *newTxSuite) newTx(
 		tbtc,
 		s.inputConfiguration,
 		utxo,
-		s.output(amount),
+		[]*wire.TxOut{s.output(amount)},
 		feePerKb,
+		s.dustLimit,
 		s.getChangeAddress,
 		s.log,
 	)
@@ -184,7 +190,15 @@ func (s *newTxSuite) check(
 		s.log) + expectedDustDonation
 	require.Equal(s.T(), expectedFee, txFee)
 	require.Equal(s.T(), expectedFee, txProposal.Fee)
+	require.Equal(s.T(), expectedDustDonation, txProposal.DustChange)
 	require.Equal(s.T(), expectedAmount, txProposal.Amount)
+	changePkScriptSize := 0
+	if expectedChange != 0 {
+		changePkScriptSize = len(s.changeAddress.PubkeyScript())
+	}
+	require.Equal(s.T(),
+		maketx.TstEstimateTxSize(len(tx.TxIn), s.inputConfiguration, len(output.PkScript), changePkScriptSize),
+		txProposal.VSize())
 
 	// Check the coin selection related results.
 
@@ -222,8 +236,10 @@ func (s *newTxSuite) TestNewTxNoFee() {
 	require.Equal(s.T(), coinpkg.ErrInsufficientFunds, errp.Cause(err))
 
 	s.check(btcutil.Amount(1), feePerKb, s.buildUTXO(1), s.change(0), noDust, s.selectCoins(0))
-	s.check(btcutil.Amount(1), feePerKb, s.buildUTXO(1, 2), s.change(1), noDust, s.selectCoins(1))
-	s.check(btcutil.Amount(1), feePerKb, s.buildUTXO(1, 2, 3), s.change(2), noDust, s.selectCoins(2))
+	// A coin matching the amount exactly is spent without change.
+	s.check(btcutil.Amount(1), feePerKb, s.buildUTXO(1, 2), s.change(0), noDust, s.selectCoins(0))
+	s.check(btcutil.Amount(1), feePerKb, s.buildUTXO(1, 2, 3), s.change(0), noDust, s.selectCoins(0))
+	s.check(btcutil.Amount(3), feePerKb, s.buildUTXO(1, 2, 4), s.change(0), noDust, s.selectCoins(0, 1))
 	s.check(btcutil.Amount(1), feePerKb, s.buildUTXO(2), s.change(1), noDust, s.selectCoins(0))
 }
 
@@ -231,10 +247,10 @@ func (s *newTxSuite) TestNewTxDust() {
 	// Have one coin be exactly the amount to spend + required fee, so there is no change.  We then
 	// add some dust, which does not produce change, but folds into the fee.  Also iterate through
 	// some amounts to spend, to check that the dust property is independent of the amount being
-	// spent.
+	// spent. The amount to spend itself must not be dust.
 	feePerKb := btcutil.Amount(1000) // 1 sat / vbyte
 	const maxDust = 545              // dust threshold for a p2pkh change output.
-	for baseAmount := int64(500); baseAmount <= 5000000000; baseAmount += 5000000000 / 10 {
+	for baseAmount := int64(maxDust + 1); baseAmount <= 5000000000; baseAmount += 5000000000 / 10 {
 		for dust := int64(0); dust <= maxDust; dust++ {
 			s.check(btcutil.Amount(baseAmount), feePerKb, s.buildUTXO(400, baseAmount+txSizeOneInput+dust, 450), s.change(0), btcutil.Amount(dust), s.selectCoins(1))
 		}
@@ -259,11 +275,11 @@ func (s *newTxSuite) TestNewTxInsufficientFunds() {
 	// exact coin not enough, as fees need to be covered.
 	_, err = s.newTx(amount, feePerKb, s.buildUTXO(1000*mBTC))
 	require.Equal(s.T(), coinpkg.ErrInsufficientFunds, errp.Cause(err))
-	// One satoshi short of covering the amount and fee.
-	_, err = s.newTx(amount, feePerKb, s.buildUTXO(1000*mBTC+txSizeOneInput-1))
+	// One satoshi short of covering the amount and the fee of a tx without change.
+	_, err = s.newTx(amount, feePerKb, s.buildUTXO(1000*mBTC+txSizeOneInputNoChange-1))
 	require.Equal(s.T(), coinpkg.ErrInsufficientFunds, errp.Cause(err))
 	// Just enough:
-	_, err = s.newTx(amount, feePerKb, s.buildUTXO(1000*mBTC+txSizeOneInput))
+	_, err = s.newTx(amount, feePerKb, s.buildUTXO(1000*mBTC+txSizeOneInputNoChange))
 	require.NoError(s.T(), err)
 
 	// Using two coins.
@@ -272,11 +288,11 @@ func (s *newTxSuite) TestNewTxInsufficientFunds() {
 	// exact coin not enough, as fees need to be covered.
 	_, err = s.newTx(amount, feePerKb, s.buildUTXO(mBTC, 999*mBTC))
 	require.Equal(s.T(), coinpkg.ErrInsufficientFunds, errp.Cause(err))
-	// One satoshi short of covering the amount and fee.
-	_, err = s.newTx(amount, feePerKb, s.buildUTXO(mBTC, 999*mBTC+txSizeTwoInputs-1))
+	// One satoshi short of covering the amount and the fee of a tx without change.
+	_, err = s.newTx(amount, feePerKb, s.buildUTXO(mBTC, 999*mBTC+txSizeTwoInputsNoChange-1))
 	require.Equal(s.T(), coinpkg.ErrInsufficientFunds, errp.Cause(err))
 	// Just enough:
-	_, err = s.newTx(amount, feePerKb, s.buildUTXO(mBTC, 999*mBTC+txSizeTwoInputs))
+	_, err = s.newTx(amount, feePerKb, s.buildUTXO(mBTC, 999*mBTC+txSizeTwoInputsNoChange))
 	require.NoError(s.T(), err)
 }
 
@@ -291,3 +307,129 @@ func (s *newTxSuite) TestNewTxCoinSelection() {
 	// coins: .5, .3, .1, .1, .9, .8, .6. select .5+.3+.1+.1 to get 1BTC, take .9 to cover the fees.
 	s.check(amount, feePerKb, s.buildUTXO(500*mBTC, 300*mBTC, 100*mBTC, 100*mBTC, 90*mBTC, 80*mBTC, 70*mBTC), s.change(90*mBTC-txSizeFiveInputs), noDust, s.selectCoins(0, 1, 2, 3, 4))
 }
+
+func (s *newTxSuite) TestNewTxChangeless() {
+	const mBTC = 100000
+	feePerKb := btcutil.Amount(1000) // 1 sat / vbyte
+	amount := btcutil.Amount(100 * mBTC)
+	// The largest coin would be spent with change, but the two coins at the end cover the amount
+	// and the fee of a tx without change exactly.
+	utxo := s.buildUTXO(500*mBTC, 60*mBTC, 40*mBTC+txSizeTwoInputsNoChange)
+	txProposal, err := s.newTx(amount, feePerKb, utxo)
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), txProposal.ChangeAddress)
+	require.Len(s.T(), txProposal.Transaction.TxOut, 1)
+	require.Len(s.T(), txProposal.Transaction.TxIn, 2)
+	require.Equal(s.T(), btcutil.Amount(txSizeTwoInputsNoChange), txProposal.Fee)
+	require.Equal(s.T(), btcutil.Amount(0), txProposal.DustChange)
+	require.Equal(s.T(), txSizeTwoInputsNoChange, txProposal.VSize())
+
+	// If the excess exceeds the cost of creating and spending change, change is created.
+	utxo = s.buildUTXO(500*mBTC, 60*mBTC, 41*mBTC)
+	txProposal, err = s.newTx(amount, feePerKb, utxo)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), s.changeAddress, txProposal.ChangeAddress)
+	require.Len(s.T(), txProposal.Transaction.TxIn, 1)
+}
+
+func (s *newTxSuite) TestNewTxDustOutput() {
+	feePerKb := btcutil.Amount(1000) // 1 sat / vbyte
+	const maxDust = 545              // dust threshold for a p2pkh output.
+	_, err := s.newTx(maxDust, feePerKb, s.buildUTXO(100000))
+	require.Equal(s.T(), coinpkg.ErrDustAmount, errp.Cause(err))
+	s.check(maxDust+1, feePerKb, s.buildUTXO(100000), s.change(100000-maxDust-1-txSizeOneInput), noDust, s.selectCoins(0))
+}
+
+func (s *newTxSuite) TestNewTxDustLimit() {
+	feePerKb := btcutil.Amount(1000) // 1 sat / vbyte
+	s.dustLimit = 10000
+	_, err := s.newTx(9999, feePerKb, s.buildUTXO(100000))
+	require.Equal(s.T(), coinpkg.ErrDustAmount, errp.Cause(err))
+	// Change below the dust limit is added to the fee.
+	s.check(10000, feePerKb, s.buildUTXO(20000), s.change(0), 20000-10000-txSizeOneInput, s.selectCoins(0))
+	s.check(10000, feePerKb, s.buildUTXO(100000), s.change(100000-10000-txSizeOneInput), noDust, s.selectCoins(0))
+}
+
+func (s *newTxSuite) TestNewTxFromSelectedOutputs() {
+	const mBTC = 100000
+	feePerKb := btcutil.Amount(1000) // 1 sat / vbyte
+	utxo := s.buildUTXO(1000*mBTC, 2*mBTC)
+	// Coin selection would only take the first coin, but both coins chosen by the user are spent.
+	txProposal, err := maketx.NewTxFromSelectedOutputs(
+		tbtc, s.inputConfiguration, utxo, []*wire.TxOut{s.output(500 * mBTC)}, feePerKb, s.dustLimit, s.getChangeAddress, s.log)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), txProposal.Transaction.TxIn, 2)
+	require.Equal(s.T(), btcutil.Amount(txSizeTwoInputs), txProposal.Fee)
+	require.Equal(s.T(), s.changeAddress, txProposal.ChangeAddress)
+
+	_, err = maketx.NewTxFromSelectedOutputs(
+		tbtc, s.inputConfiguration, utxo, []*wire.TxOut{s.output(1002 * mBTC)}, feePerKb, s.dustLimit, s.getChangeAddress, s.log)
+	require.Equal(s.T(), coinpkg.ErrInsufficientFunds, errp.Cause(err))
+}
+
+func (s *newTxSuite) TestNewTxMultipleOutputs() {
+	const mBTC = 100000
+	feePerKb := btcutil.Amount(1000) // 1 sat / vbyte
+	otherPkScript := s.someAddresses[1].PubkeyScript()
+	outputs := []*wire.TxOut{
+		s.output(300 * mBTC),
+		wire.NewTxOut(200*mBTC, otherPkScript),
+	}
+	txProposal, err := maketx.NewTx(
+		tbtc, s.inputConfiguration, s.buildUTXO(1000*mBTC), outputs, feePerKb, s.dustLimit, s.getChangeAddress, s.log)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), btcutil.Amount(500*mBTC), txProposal.Amount)
+	require.Equal(s.T(), s.changeAddress, txProposal.ChangeAddress)
+	tx := txProposal.Transaction
+	require.Len(s.T(), tx.TxOut, 3)
+	// One more output on top of the reference size of a tx with one output and change.
+	expectedVSize := txSizeOneInput + maketx.TstOutputSize(len(otherPkScript))
+	require.Equal(s.T(), expectedVSize, txProposal.VSize())
+	require.Equal(s.T(), btcutil.Amount(expectedVSize), txProposal.Fee)
+	values := map[string]int64{}
+	for _, txOut := range tx.TxOut {
+		values[string(txOut.PkScript)] = txOut.Value
+	}
+	require.Equal(s.T(), int64(300*mBTC), values[string(s.outputPkScript)])
+	require.Equal(s.T(), int64(200*mBTC), values[string(otherPkScript)])
+	require.Equal(s.T(), int64(500*mBTC-expectedVSize), values[string(s.changeAddress.PubkeyScript())])
+	// The outputs passed in are not modified by sorting.
+	require.Len(s.T(), outputs, 2)
+	require.Equal(s.T(), int64(300*mBTC), outputs[0].Value)
+}
+
+func (s *newTxSuite) TestNewTxOpReturn() {
+	const mBTC = 100000
+	feePerKb := btcutil.Amount(1000) // 1 sat / vbyte
+	_, err := maketx.NewOpReturnOutput(bytes.Repeat([]byte{1}, 81))
+	require.Error(s.T(), err)
+	opReturnOutput, err := maketx.NewOpReturnOutput(bytes.Repeat([]byte{1}, 80))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), int64(0), opReturnOutput.Value)
+
+	txProposal, err := maketx.NewTx(
+		tbtc, s.inputConfiguration, s.buildUTXO(1000*mBTC),
+		[]*wire.TxOut{s.output(300 * mBTC), opReturnOutput},
+		feePerKb, s.dustLimit, s.getChangeAddress, s.log)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), btcutil.Amount(300*mBTC), txProposal.Amount)
+	require.Len(s.T(), txProposal.Transaction.TxOut, 3)
+	expectedVSize := txSizeOneInput + maketx.TstOutputSize(len(opReturnOutput.PkScript))
+	require.Equal(s.T(), expectedVSize, txProposal.VSize())
+	require.Equal(s.T(), btcutil.Amount(expectedVSize), txProposal.Fee)
+
+	// Only one OP_RETURN output is standard.
+	_, err = maketx.NewTx(
+		tbtc, s.inputConfiguration, s.buildUTXO(1000*mBTC),
+		[]*wire.TxOut{s.output(300 * mBTC), opReturnOutput, opReturnOutput},
+		feePerKb, s.dustLimit, s.getChangeAddress, s.log)
+	require.Error(s.T(), err)
+
+	txProposal, err = maketx.NewTxSpendAll(
+		tbtc, s.inputConfiguration, s.buildUTXO(1000*mBTC), s.outputPkScript, opReturnOutput,
+		feePerKb, s.dustLimit, s.log)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), txProposal.Transaction.TxOut, 2)
+	require.Equal(s.T(), txProposal.VSize(), int(txProposal.Fee))
+	require.Equal(s.T(), btcutil.Amount(1000*mBTC)-txProposal.Fee, txProposal.Amount)
+}
@@ -0,0 +1,63 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	"math/rand"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/txsort"
+)
+
+// TxOrdering is the order of the inputs and outputs of new transactions. A fixed order can reveal
+// which wallet created a transaction, so the user can choose the one which blends in best.
+type TxOrdering string
+
+const (
+	// TxOrderingBIP69 sorts the inputs and outputs lexicographically as specified in BIP69. It is
+	// the default.
+	TxOrderingBIP69 TxOrdering = "bip69"
+	// TxOrderingRandom shuffles the inputs and outputs, as done by Bitcoin Core.
+	TxOrderingRandom TxOrdering = "random"
+)
+
+// OrderTransaction orders the inputs and outputs of the transaction. Unknown orderings are treated
+// as TxOrderingBIP69.
+func OrderTransaction(transaction *wire.MsgTx, ordering TxOrdering) {
+	orderTransaction(transaction, ordering, rand.Shuffle)
+}
+
+func orderTransaction(
+	transaction *wire.MsgTx, ordering TxOrdering, shuffle func(int, func(int, int))) {
+	// Sorting first makes the shuffled order independent of the order in which the coins were
+	// selected.
+	txsort.InPlaceSort(transaction)
+	if ordering != TxOrderingRandom {
+		return
+	}
+	shuffle(len(transaction.TxIn), func(i, j int) {
+		transaction.TxIn[i], transaction.TxIn[j] = transaction.TxIn[j], transaction.TxIn[i]
+	})
+	shuffle(len(transaction.TxOut), func(i, j int) {
+		transaction.TxOut[i], transaction.TxOut[j] = transaction.TxOut[j], transaction.TxOut[i]
+	})
+}
+
+// SetOrdering orders the inputs and outputs of the transaction of the proposal and records the
+// ordering, so that the BIP69 sanity check is skipped when signing a shuffled transaction.
+func (txProposal *TxProposal) SetOrdering(ordering TxOrdering) {
+	OrderTransaction(txProposal.Transaction, ordering)
+	txProposal.Ordering = ordering
+}
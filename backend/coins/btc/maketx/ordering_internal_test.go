@@ -0,0 +1,63 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/txsort"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderTransaction(t *testing.T) {
+	newTransaction := func() *wire.MsgTx {
+		transaction := wire.NewMsgTx(wire.TxVersion)
+		for _, hash := range []string{"b", "a", "c"} {
+			transaction.AddTxIn(wire.NewTxIn(
+				&wire.OutPoint{Hash: chainhash.HashH([]byte(hash))}, nil, nil))
+		}
+		for _, value := range []int64{2, 3, 1} {
+			transaction.AddTxOut(wire.NewTxOut(value, []byte{0x51}))
+		}
+		return transaction
+	}
+	// reverse is a deterministic shuffle.
+	reverse := func(n int, swap func(int, int)) {
+		for i := 0; i < n/2; i++ {
+			swap(i, n-1-i)
+		}
+	}
+
+	transaction := newTransaction()
+	orderTransaction(transaction, TxOrderingBIP69, reverse)
+	require.True(t, txsort.IsSorted(transaction))
+	require.Equal(t, txsort.Sort(newTransaction()), transaction)
+
+	transaction = newTransaction()
+	orderTransaction(transaction, "", reverse)
+	require.True(t, txsort.IsSorted(transaction))
+
+	// The shuffle is applied to the sorted transaction.
+	transaction = newTransaction()
+	orderTransaction(transaction, TxOrderingRandom, reverse)
+	sorted := txsort.Sort(newTransaction())
+	require.Equal(t, sorted.TxIn[2], transaction.TxIn[0])
+	require.Equal(t, sorted.TxIn[0], transaction.TxIn[2])
+	require.Equal(t, []int64{3, 2, 1},
+		[]int64{transaction.TxOut[0].Value, transaction.TxOut[1].Value, transaction.TxOut[2].Value})
+	require.False(t, txsort.IsSorted(transaction))
+}
@@ -0,0 +1,150 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx
+
+import (
+	"bytes"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/txsort"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	coinpkg "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/sirupsen/logrus"
+)
+
+// incrementalRelayFeePerKb is the default fee rate of Bitcoin Core by which a replacement has to pay
+// for its own relay on top of the fee of the replaced transaction (rule 4 of BIP125).
+const incrementalRelayFeePerKb = btcutil.Amount(1000)
+
+// rbfSequence is the sequence number of inputs which opt in to replaceability (BIP125). It also
+// enables the locktime of the transaction.
+const rbfSequence = wire.MaxTxInSequenceNum - 2
+
+// SetReplaceable lowers the sequence numbers of all inputs so that the transaction opts in to be
+// replaceable according to BIP125, which allows to bump its fee later.
+func SetReplaceable(transaction *wire.MsgTx) {
+	for _, txIn := range transaction.TxIn {
+		if txIn.Sequence > rbfSequence {
+			txIn.Sequence = rbfSequence
+		}
+	}
+}
+
+// SignalsReplacement returns whether the transaction opts in to be replaceable according to BIP125.
+func SignalsReplacement(transaction *wire.MsgTx) bool {
+	for _, txIn := range transaction.TxIn {
+		if txIn.Sequence < wire.MaxTxInSequenceNum-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// NewTxBumpFee creates a replacement of the given transaction which pays the given fee rate. The
+// replacement spends the same inputs and pays the same recipient, the higher fee is deducted from
+// the change output. The change output is dropped if it would become dust. spentOutputs must
+// contain the outputs spent by the transaction and the transaction must have exactly one output
+// apart from the change output to changeAddress.
+func NewTxBumpFee(
+	coin coinpkg.Coin,
+	inputConfiguration *signing.Configuration,
+	transaction *wire.MsgTx,
+	spentOutputs map[wire.OutPoint]*wire.TxOut,
+	changeAddress *addresses.AccountAddress,
+	feePerKb btcutil.Amount,
+	dustLimit btcutil.Amount,
+	log *logrus.Entry,
+) (*TxProposal, error) {
+	if !SignalsReplacement(transaction) {
+		return nil, errp.New("The transaction does not signal replaceability.")
+	}
+	if changeAddress == nil {
+		return nil, errp.New("The transaction has no change output to pay the higher fee.")
+	}
+	inputsSum := btcutil.Amount(0)
+	for _, txIn := range transaction.TxIn {
+		spentOutput, ok := spentOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			return nil, errp.New("An output spent by the transaction is missing.")
+		}
+		inputsSum += btcutil.Amount(spentOutput.Value)
+	}
+
+	unsignedTransaction := transaction.Copy()
+	var output, changeOutput *wire.TxOut
+	outputsSum := btcutil.Amount(0)
+	for _, txOut := range unsignedTransaction.TxOut {
+		outputsSum += btcutil.Amount(txOut.Value)
+		switch {
+		case changeOutput == nil && bytes.Equal(txOut.PkScript, changeAddress.PubkeyScript()):
+			changeOutput = txOut
+		case output == nil:
+			output = txOut
+		default:
+			return nil, errp.New("Only transactions with one recipient can be replaced.")
+		}
+	}
+	if output == nil || changeOutput == nil {
+		return nil, errp.New("The transaction needs a recipient and a change output.")
+	}
+	if outputsSum > inputsSum {
+		return nil, errp.New("The outputs of the transaction exceed its inputs.")
+	}
+	previousFee := inputsSum - outputsSum
+
+	txSize := estimateTxSize(len(unsignedTransaction.TxIn), inputConfiguration,
+		[]int{len(output.PkScript)}, len(changeOutput.PkScript))
+	requiredFee := feeForSerializeSize(feePerKb, txSize, log)
+	if requiredFee <= previousFee {
+		return nil, errp.WithStack(coinpkg.ErrFeeTooLow)
+	}
+	if minimumFee := previousFee + feeForSerializeSize(incrementalRelayFeePerKb, txSize, log); requiredFee < minimumFee {
+		requiredFee = minimumFee
+	}
+	changeAmount := btcutil.Amount(changeOutput.Value) - (requiredFee - previousFee)
+	if changeAmount < 0 {
+		return nil, errp.WithStack(coinpkg.ErrInsufficientFunds)
+	}
+	finalFee := requiredFee
+	dustChange := btcutil.Amount(0)
+	if isDustAmount(changeAmount, len(changeOutput.PkScript), changeAddress.Configuration, feePerKb, dustLimit) {
+		log.WithField("change", changeAmount).Info("change of the replacement is dust")
+		finalFee = previousFee + btcutil.Amount(changeOutput.Value)
+		dustChange = changeAmount
+		unsignedTransaction.TxOut = []*wire.TxOut{output}
+		changeAddress = nil
+	} else {
+		changeOutput.Value = int64(changeAmount)
+	}
+	for _, txIn := range unsignedTransaction.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	txsort.InPlaceSort(unsignedTransaction)
+	log.WithFields(logrus.Fields{"previousFee": previousFee, "fee": finalFee}).
+		Debug("Preparing replacement transaction")
+	return &TxProposal{
+		Coin:                 coin,
+		AccountConfiguration: inputConfiguration,
+		Amount:               btcutil.Amount(output.Value),
+		Fee:                  finalFee,
+		Transaction:          unsignedTransaction,
+		ChangeAddress:        changeAddress,
+		DustChange:           dustChange,
+	}, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maketx_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	coinpkg "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const rbfSequence = wire.MaxTxInSequenceNum - 2
+
+type bumpFeeSuite struct {
+	suite.Suite
+
+	// newTxSuite creates the transaction to be replaced. Its tests are not embedded.
+	newTxSuite  *newTxSuite
+	utxo        map[wire.OutPoint]*wire.TxOut
+	transaction *wire.MsgTx
+}
+
+func TestBumpFeeSuite(t *testing.T) {
+	suite.Run(t, &bumpFeeSuite{})
+}
+
+// SetupTest creates a replaceable tx with one input of 100000 sat and a fee of 1 sat/vbyte.
+func (s *bumpFeeSuite) SetupTest() {
+	s.newTxSuite = &newTxSuite{}
+	s.newTxSuite.SetT(s.T())
+	s.newTxSuite.SetupTest()
+	s.utxo = s.newTxSuite.buildUTXO(100000)
+	txProposal, err := s.newTxSuite.newTx(50000, 1000, s.utxo)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), btcutil.Amount(txSizeOneInput), txProposal.Fee)
+	s.transaction = txProposal.Transaction
+	for _, txIn := range s.transaction.TxIn {
+		txIn.Sequence = rbfSequence
+		txIn.SignatureScript = []byte{0x01}
+	}
+}
+
+func (s *bumpFeeSuite) bumpFee(feePerKb btcutil.Amount) (*maketx.TxProposal, error) {
+	return maketx.NewTxBumpFee(
+		tbtc,
+		s.newTxSuite.inputConfiguration,
+		s.transaction,
+		s.utxo,
+		s.newTxSuite.changeAddress,
+		feePerKb,
+		s.newTxSuite.dustLimit,
+		s.newTxSuite.log,
+	)
+}
+
+// changeOutput returns the change output of the transaction or nil if there is none.
+func (s *bumpFeeSuite) changeOutput(transaction *wire.MsgTx) *wire.TxOut {
+	for _, txOut := range transaction.TxOut {
+		if bytes.Equal(txOut.PkScript, s.newTxSuite.changeAddress.PubkeyScript()) {
+			return txOut
+		}
+	}
+	return nil
+}
+
+func (s *bumpFeeSuite) TestBumpFee() {
+	txProposal, err := s.bumpFee(5000)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), btcutil.Amount(5*txSizeOneInput), txProposal.Fee)
+	require.Equal(s.T(), btcutil.Amount(50000), txProposal.Amount)
+	require.Equal(s.T(), s.newTxSuite.changeAddress, txProposal.ChangeAddress)
+	require.Equal(s.T(), int64(100000-50000-5*txSizeOneInput),
+		s.changeOutput(txProposal.Transaction).Value)
+	for _, txIn := range txProposal.Transaction.TxIn {
+		require.Nil(s.T(), txIn.SignatureScript)
+		require.Equal(s.T(), uint32(rbfSequence), txIn.Sequence)
+	}
+	// The original transaction is not modified.
+	require.Equal(s.T(), int64(100000-50000-txSizeOneInput), s.changeOutput(s.transaction).Value)
+}
+
+func (s *bumpFeeSuite) TestBumpFeeIncrementalRelayFee() {
+	// The replacement has to pay at least the previous fee plus 1 sat/vbyte for itself.
+	txProposal, err := s.bumpFee(1500)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), btcutil.Amount(2*txSizeOneInput), txProposal.Fee)
+}
+
+func (s *bumpFeeSuite) TestBumpFeeTooLow() {
+	_, err := s.bumpFee(1000)
+	require.Equal(s.T(), coinpkg.ErrFeeTooLow, errp.Cause(err))
+}
+
+func (s *bumpFeeSuite) TestBumpFeeInsufficientFunds() {
+	_, err := s.bumpFee(1000000)
+	require.Equal(s.T(), coinpkg.ErrInsufficientFunds, errp.Cause(err))
+}
+
+func (s *bumpFeeSuite) TestBumpFeeDustChange() {
+	// Leaves about 100 sat of change, which is dust.
+	const feePerKb = 1000 * (100000 - 50000 - 100) / txSizeOneInput
+	txProposal, err := s.bumpFee(feePerKb)
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), txProposal.ChangeAddress)
+	require.Nil(s.T(), s.changeOutput(txProposal.Transaction))
+	require.Len(s.T(), txProposal.Transaction.TxOut, 1)
+	require.Equal(s.T(), btcutil.Amount(100000-50000), txProposal.Fee)
+}
+
+func (s *bumpFeeSuite) TestBumpFeeNotReplaceable() {
+	for _, txIn := range s.transaction.TxIn {
+		txIn.Sequence = wire.MaxTxInSequenceNum
+	}
+	require.False(s.T(), maketx.SignalsReplacement(s.transaction))
+	_, err := s.bumpFee(5000)
+	require.Error(s.T(), err)
+}
+
+func (s *bumpFeeSuite) TestBumpFeeNoChange() {
+	s.newTxSuite.changeAddress = s.newTxSuite.someAddresses[1]
+	_, err := s.bumpFee(5000)
+	require.Error(s.T(), err)
+}
+
+func TestSetReplaceable(t *testing.T) {
+	transaction := wire.NewMsgTx(wire.TxVersion)
+	transaction.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 0}, nil, nil))
+	transaction.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	transaction.TxIn[1].Sequence = 0
+	require.False(t, maketx.SignalsReplacement(&wire.MsgTx{TxIn: transaction.TxIn[:1]}))
+	maketx.SetReplaceable(transaction)
+	require.True(t, maketx.SignalsReplacement(transaction))
+	require.Equal(t, uint32(rbfSequence), transaction.TxIn[0].Sequence)
+	// Lower sequence numbers, e.g. relative locktimes, are kept.
+	require.Equal(t, uint32(0), transaction.TxIn[1].Sequence)
+
+	// The locktime does not undo the replaceability.
+	maketx.SetAntiFeeSnipingLockTime(transaction, 100)
+	require.Equal(t, uint32(rbfSequence), transaction.TxIn[0].Sequence)
+}
@@ -32,43 +32,69 @@ func outputSize(pkScriptSize int) int {
 	return 8 + wire.VarIntSerializeSize(uint64(pkScriptSize)) + pkScriptSize
 }
 
+// witnessSize returns the maximum size of the witness of a segwit input.
+func witnessSize(inputConfiguration *signing.Configuration) int {
+	if inputConfiguration.P2WSH() {
+		// A multisig input has a witness serialization of this format:
+		// <empty> <serialized sig>*signingThreshold <witness script>
+		const signatureSize = 72 // including SIGHASH op
+		signingThreshold := inputConfiguration.SigningThreshold()
+		// OP_N <OP_DATA_33 compressed pubkey>*numberOfSigners OP_N OP_CHECKMULTISIG
+		witnessScriptSize := 1 + inputConfiguration.NumberOfSigners()*(1+33) + 1 + 1
+		return wire.VarIntSerializeSize(uint64(2+signingThreshold)) +
+			wire.VarIntSerializeSize(0) +
+			signingThreshold*(wire.VarIntSerializeSize(signatureSize)+signatureSize) +
+			wire.VarIntSerializeSize(uint64(witnessScriptSize)) + witnessScriptSize
+	}
+	if !inputConfiguration.Multisig() && inputConfiguration.ScriptType() == signing.ScriptTypeP2TR {
+		// A key path spend has a witness serialization of this format:
+		// <serialized schnorr sig>
+		const signatureSize = 65 // including the optional SIGHASH op
+		return wire.VarIntSerializeSize(1) +
+			wire.VarIntSerializeSize(signatureSize) + signatureSize
+	}
+	// Other segwit inputs have a witness serialization of this format:
+	// <serialized sig> <serialized compressed pubkey>
+	const (
+		signatureSize = 73 // including SIGHASH op
+		pubkeySize    = 33
+	)
+	return wire.VarIntSerializeSize(2) +
+		wire.VarIntSerializeSize(signatureSize) + signatureSize +
+		wire.VarIntSerializeSize(pubkeySize) + pubkeySize
+}
+
 // estimateTxSize gives the worst case tx size estimate. All inputs are assumed to be of the same
 // structure.
 // inputCount is the number of inputs in the tx.
 // inputConfiguration defines the structure of every input.
-// outputPkScriptSize is the size of the output pkScript. One output is assumed (apart from change).
+// outputPkScriptSizes are the sizes of the pkScripts of the outputs (apart from change).
 // changePkScriptSize  is the size of the change pkScript. A value of 0 means that there is no change output.
 // This function computes the virtual size of a transaction, taking segwit discount into account.
 func estimateTxSize(
 	inputCount int,
 	inputConfiguration *signing.Configuration,
-	outputPkScriptSize int,
+	outputPkScriptSizes []int,
 	changePkScriptSize int) int {
 	const (
-		outputCount  = 2 // 1 output + 1 change output
 		versionSize  = 4
 		lockTimeSize = 4
 		nonWitness   = 4 // factor for non-witness fields
 	)
 	sigScriptSize, hasWitness := addresses.SigScriptWitnessSize(inputConfiguration)
 	inputSize := calcInputSize(sigScriptSize)
+	outputCount := len(outputPkScriptSizes) + 1 // outputs + 1 change output
+	outputsSize := outputSize(changePkScriptSize)
+	for _, outputPkScriptSize := range outputPkScriptSizes {
+		outputsSize += outputSize(outputPkScriptSize)
+	}
 
 	txWeight := nonWitness * (versionSize + lockTimeSize + wire.VarIntSerializeSize(uint64(inputCount)) +
 		wire.VarIntSerializeSize(uint64(outputCount)) +
 		inputCount*inputSize +
-		outputSize(outputPkScriptSize) +
-		outputSize(changePkScriptSize))
+		outputsSize)
 	if hasWitness {
-		// For now, every input has a witness serialization of this format:
-		// <serialized sig> <serialized compressed pubkey>
-		const (
-			signatureSize = 73 // including SIGHASH op
-			pubkeySize    = 33
-		)
-		witnessSize := wire.VarIntSerializeSize(2) +
-			wire.VarIntSerializeSize(signatureSize) + signatureSize +
-			wire.VarIntSerializeSize(pubkeySize) + pubkeySize
-		txWeight += inputCount * witnessSize
+		txWeight += inputCount * witnessSize(inputConfiguration)
 		txWeight += 2 // segwit marker + segwit flag
 	}
 	// return txWeight/4 rounded up.
@@ -77,3 +103,9 @@ func estimateTxSize(
 	}
 	return txWeight/4 + 1
 }
+
+// InputVSize returns the estimated virtual size which one more input of the given configuration
+// adds to a transaction.
+func InputVSize(inputConfiguration *signing.Configuration) int {
+	return estimateTxSize(2, inputConfiguration, nil, 0) - estimateTxSize(1, inputConfiguration, nil, 0)
+}
@@ -22,6 +22,10 @@ func TstEstimateTxSize(inputCount int,
 	changePkScriptSize int) int {
 	return estimateTxSize(inputCount,
 		inputConfiguration,
-		outputPkScriptSize,
+		[]int{outputPkScriptSize},
 		changePkScriptSize)
 }
+
+func TstOutputSize(pkScriptSize int) int {
+	return outputSize(pkScriptSize)
+}
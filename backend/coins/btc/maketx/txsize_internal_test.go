@@ -21,6 +21,7 @@ import (
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	addressesTest "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses/test"
@@ -50,7 +51,7 @@ func TestEstimateTxSize(t *testing.T) {
 		t.Run(fmt.Sprintf("%s/%s/%s", inputScriptType, outputScriptType, changeStr),
 			func(t *testing.T) {
 				inputAddress := addressesTest.GetAddress(inputScriptType)
-				sigScript, witness := inputAddress.SignatureScript([]*btcec.Signature{sig})
+				sigScript, witness := inputAddress.SignatureScript([]*btcec.Signature{sig}, txscript.SigHashAll)
 				outputPkScript := addressesTest.GetAddress(outputScriptType).PubkeyScript()
 				tx := &wire.MsgTx{
 					Version: wire.TxVersion,
@@ -89,7 +90,7 @@ func TestEstimateTxSize(t *testing.T) {
 				estimatedSize := estimateTxSize(
 					len(tx.TxIn),
 					inputAddress.Configuration,
-					len(outputPkScript), changePkScriptSize)
+					[]int{len(outputPkScript)}, changePkScriptSize)
 				require.Equal(t, mempool.GetTxVirtualSize(btcutil.NewTx(tx)), int64(estimatedSize))
 			})
 	}
@@ -103,3 +104,39 @@ func TestEstimateTxSize(t *testing.T) {
 		}
 	}
 }
+
+func TestEstimateTxSizeP2WSH(t *testing.T) {
+	sigBytes, err := hex.DecodeString(
+		`3045022100a97dc23e47bb79dbff73e33be4a4e476d6ef67c8c23a9ee4a9ee21f4dd80f0f202201c5d4be437308539e1193d9118fae03bae1942e9ce27c86803bb5f18aa044a46`)
+	require.NoError(t, err)
+	sig, err := btcec.ParseDERSignature(sigBytes, btcec.S256())
+	require.NoError(t, err)
+	outputPkScript := addressesTest.GetAddress(signing.ScriptTypeP2WPKH).PubkeyScript()
+
+	for _, multisig := range []struct{ signingThreshold, numberOfSigners int }{
+		{1, 2}, {2, 3}, {3, 5}, {11, 15},
+	} {
+		inputAddress := addressesTest.GetP2WSHMultisigAddress(multisig.signingThreshold, multisig.numberOfSigners)
+		t.Run(inputAddress.Configuration.String(), func(t *testing.T) {
+			sigs := make([]*btcec.Signature, multisig.numberOfSigners)
+			for index := 0; index < multisig.signingThreshold; index++ {
+				sigs[index] = sig
+			}
+			sigScript, witness := inputAddress.SignatureScript(sigs, txscript.SigHashAll)
+			tx := &wire.MsgTx{
+				Version: wire.TxVersion,
+				TxIn: []*wire.TxIn{
+					{SignatureScript: sigScript, Witness: witness},
+					{SignatureScript: sigScript, Witness: witness},
+				},
+				TxOut: []*wire.TxOut{
+					{Value: 1, PkScript: outputPkScript},
+					{Value: 1, PkScript: inputAddress.PubkeyScript()},
+				},
+			}
+			estimatedSize := estimateTxSize(len(tx.TxIn), inputAddress.Configuration,
+				[]int{len(outputPkScript)}, len(inputAddress.PubkeyScript()))
+			require.Equal(t, mempool.GetTxVirtualSize(btcutil.NewTx(tx)), int64(estimatedSize))
+		})
+	}
+}
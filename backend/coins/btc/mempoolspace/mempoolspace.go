@@ -0,0 +1,90 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mempoolspace is a client for the fee estimation API of mempool.space.
+// See https://mempool.space/docs/api/rest#get-recommended-fees.
+package mempoolspace
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// RecommendedFees are the fee rates in sat/vB suggested by mempool.space, based on its mempool.
+type RecommendedFees struct {
+	// FastestFee is the fee rate needed to be confirmed in the next block.
+	FastestFee float64 `json:"fastestFee"`
+	// HalfHourFee is the fee rate needed to be confirmed within three blocks.
+	HalfHourFee float64 `json:"halfHourFee"`
+	// HourFee is the fee rate needed to be confirmed within six blocks.
+	HourFee float64 `json:"hourFee"`
+	// EconomyFee is the fee rate for transactions which are not urgent.
+	EconomyFee float64 `json:"economyFee"`
+	// MinimumFee is the lowest fee rate accepted into the mempool.
+	MinimumFee float64 `json:"minimumFee"`
+}
+
+// FeeRatePerKb returns the recommended fee rate in sat/kB to be confirmed within the given number
+// of blocks.
+func (fees *RecommendedFees) FeeRatePerKb(blocks int) btcutil.Amount {
+	var feeRate float64
+	switch {
+	case blocks <= 1:
+		feeRate = fees.FastestFee
+	case blocks <= 3:
+		feeRate = fees.HalfHourFee
+	case blocks <= 6:
+		feeRate = fees.HourFee
+	default:
+		feeRate = fees.EconomyFee
+	}
+	if feeRate < fees.MinimumFee {
+		feeRate = fees.MinimumFee
+	}
+	return btcutil.Amount(feeRate * 1000)
+}
+
+// MempoolSpace is a mempool.space API client.
+type MempoolSpace struct {
+	url string
+}
+
+// NewMempoolSpace creates a new instance of MempoolSpace. url is the API endpoint of the network,
+// e.g. https://mempool.space/api.
+func NewMempoolSpace(url string) *MempoolSpace {
+	return &MempoolSpace{url: url}
+}
+
+// RecommendedFees queries the currently recommended fee rates.
+func (mempoolSpace *MempoolSpace) RecommendedFees() (*RecommendedFees, error) {
+	response, err := http.Get(mempoolSpace.url + "/v1/fees/recommended")
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode != http.StatusOK {
+		return nil, errp.Newf("Unexpected response status %d", response.StatusCode)
+	}
+	fees := &RecommendedFees{}
+	if err := json.NewDecoder(response.Body).Decode(fees); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if fees.MinimumFee <= 0 {
+		return nil, errp.New("The minimum fee rate is missing in the response")
+	}
+	return fees, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mempoolspace_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/mempoolspace"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecommendedFees(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/fees/recommended", r.URL.Path)
+		_, _ = w.Write([]byte(
+			`{"fastestFee":40,"halfHourFee":30,"hourFee":20,"economyFee":10,"minimumFee":5}`))
+	}))
+	defer server.Close()
+
+	fees, err := mempoolspace.NewMempoolSpace(server.URL + "/api").RecommendedFees()
+	require.NoError(t, err)
+	for blocks, expected := range map[int]btcutil.Amount{
+		1: 40000, 2: 30000, 3: 30000, 6: 20000, 12: 10000, 24: 10000,
+	} {
+		require.Equal(t, expected, fees.FeeRatePerKb(blocks), blocks)
+	}
+}
+
+func TestRecommendedFeesMinimum(t *testing.T) {
+	fees := &mempoolspace.RecommendedFees{FastestFee: 3, HalfHourFee: 2, HourFee: 1, EconomyFee: 1, MinimumFee: 1.5}
+	require.Equal(t, btcutil.Amount(3000), fees.FeeRatePerKb(1))
+	require.Equal(t, btcutil.Amount(1500), fees.FeeRatePerKb(6))
+}
+
+func TestRecommendedFeesError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	_, err := mempoolspace.NewMempoolSpace(server.URL).RecommendedFees()
+	require.Error(t, err)
+
+	emptyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer emptyServer.Close()
+	_, err = mempoolspace.NewMempoolSpace(emptyServer.URL).RecommendedFees()
+	require.Error(t, err)
+}
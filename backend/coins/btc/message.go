@@ -0,0 +1,181 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"encoding/base64"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	coinpkg "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// messageMagic returns the prefix of messages signed with `signmessage`.
+func (coin *Coin) messageMagic() string {
+	switch coin.code {
+	case "ltc", "tltc":
+		return "Litecoin Signed Message:\n"
+	default:
+		return "Bitcoin Signed Message:\n"
+	}
+}
+
+// SignedMessageHash returns the hash which is signed by `signmessage`: the double SHA256 of the
+// length-prefixed magic followed by the length-prefixed message.
+func (coin *Coin) SignedMessageHash(message []byte) []byte {
+	var buffer bytes.Buffer
+	// Writing to a bytes.Buffer does not fail.
+	_ = wire.WriteVarString(&buffer, 0, coin.messageMagic())
+	_ = wire.WriteVarBytes(&buffer, 0, message)
+	return chainhash.DoubleHashB(buffer.Bytes())
+}
+
+// messageSignatureHeaderOffsets are added to the header byte of a compact signature of a compressed
+// public key to indicate the script type of the signing address according to BIP137.
+var messageSignatureHeaderOffsets = map[signing.ScriptType]byte{
+	signing.ScriptTypeP2PKH:      0,
+	signing.ScriptTypeP2WPKHP2SH: 4,
+	signing.ScriptTypeP2WPKH:     8,
+}
+
+// messagePkScripts returns the pubkey scripts of the addresses which can have created a signature
+// with the given header byte from the given public key. Signatures of segwit addresses created by
+// Electrum use the header bytes of P2PKH with a compressed public key.
+func (coin *Coin) messagePkScripts(header byte, publicKey []byte) ([][]byte, error) {
+	pubKeyHash := btcutil.Hash160(publicKey)
+	p2pkhAddress, err := btcutil.NewAddressPubKeyHash(pubKeyHash, coin.Net())
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	p2wpkhAddress, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, coin.Net())
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	redeemScript, err := txscript.PayToAddrScript(p2wpkhAddress)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	p2shAddress, err := btcutil.NewAddressScriptHash(redeemScript, coin.Net())
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	var candidates []btcutil.Address
+	switch {
+	case header < 31:
+		candidates = []btcutil.Address{p2pkhAddress}
+	case header < 35:
+		candidates = []btcutil.Address{p2pkhAddress, p2shAddress, p2wpkhAddress}
+	case header < 39:
+		candidates = []btcutil.Address{p2shAddress}
+	default:
+		candidates = []btcutil.Address{p2wpkhAddress}
+	}
+	pkScripts := make([][]byte, len(candidates))
+	for index, candidate := range candidates {
+		pkScripts[index], err = txscript.PayToAddrScript(candidate)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	return pkScripts, nil
+}
+
+// VerifyMessage checks that the base64 encoded signature of the message was created with the key
+// of the given address. The signature is expected in the format of `signmessage`, with the header
+// byte indicating the script type of the address according to BIP137. Returns an error if the
+// address or the signature are malformed.
+func (coin *Coin) VerifyMessage(address string, message []byte, signature string) (bool, error) {
+	decodedAddress, err := taproot.DecodeAddress(address, coin.Net())
+	if err != nil || !decodedAddress.IsForNet(coin.Net()) {
+		return false, errp.WithStack(coinpkg.ErrInvalidAddress)
+	}
+	pkScript, err := taproot.PayToAddrScript(decodedAddress)
+	if err != nil {
+		return false, errp.WithStack(err)
+	}
+	compactSignature, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(compactSignature) != 65 {
+		return false, errp.New("The signature is malformed.")
+	}
+	header := compactSignature[0]
+	if header < 27 || header > 42 {
+		return false, errp.Newf("The signature has an invalid header byte %d.", header)
+	}
+	// btcec expects the header byte of a P2PKH address.
+	normalized := append([]byte{27 + (header-27)%4}, compactSignature[1:]...)
+	if header >= 31 {
+		normalized[0] += 4
+	}
+	publicKey, compressed, err := btcec.RecoverCompact(
+		btcec.S256(), normalized, coin.SignedMessageHash(message))
+	if err != nil {
+		return false, nil
+	}
+	serializedPublicKey := publicKey.SerializeUncompressed()
+	if compressed {
+		serializedPublicKey = publicKey.SerializeCompressed()
+	}
+	candidates, err := coin.messagePkScripts(header, serializedPublicKey)
+	if err != nil {
+		return false, err
+	}
+	for _, candidate := range candidates {
+		if bytes.Equal(candidate, pkScript) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SignMessage signs the message with the key of the given address of the account, which has to be
+// one of its singlesig P2PKH or P2WPKH addresses. Returns the signature in the format of
+// `signmessage`, base64 encoded, with the header byte indicating the script type of the address
+// according to BIP137. Returns keystore.ErrSigningAborted on user abort.
+func (account *Account) SignMessage(address string, message []byte) (string, error) {
+	account.log.Info("Signing message")
+	pkScript, err := account.recipientPkScript(address)
+	if err != nil {
+		return "", err
+	}
+	account.synchronizer.WaitSynchronized()
+	unlock := account.RLock()
+	accountAddress := account.getAddress(blockchain.ScriptHashHex(chainhash.HashH(pkScript).String()))
+	unlock()
+	if accountAddress == nil {
+		return "", errp.New("The address does not belong to the account.")
+	}
+	configuration := accountAddress.Configuration
+	headerOffset, ok := messageSignatureHeaderOffsets[configuration.ScriptType()]
+	if !ok || configuration.Multisig() {
+		return "", errp.Newf("Messages cannot be signed with %s addresses.", configuration.ScriptType())
+	}
+	signature, err := account.keystores.SignMessage(configuration, message, account.coin)
+	if err != nil {
+		return "", err
+	}
+	if len(signature) != 65 || signature[0] < 31 || signature[0] > 34 {
+		return "", errp.New("The keystore returned a malformed signature.")
+	}
+	signature[0] += headerOffset
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyMessageVector(t *testing.T) {
+	// From the signmessage functional test of Bitcoin Core.
+	coin := btc.NewCoin("tbtc", "TBTC", &chaincfg.TestNet3Params, ".", nil, "")
+	valid, err := coin.VerifyMessage(
+		"mpLQjfK79b7CCV4VMJWEWAj5Mpx8Up5zxB",
+		[]byte("This is just a test message"),
+		"INbVnW4e6PeRmsv2Qgu8NuopvrVjkcxob+sX8OcZG0SALhWybUjzMLPdAsXI46YZGb0KQTRii+wWIQzRpG/U+S0=",
+	)
+	require.NoError(t, err)
+	require.True(t, valid)
+}
+
+func TestVerifyMessage(t *testing.T) {
+	coin := btc.NewCoin("tbtc", "TBTC", &chaincfg.TestNet3Params, ".", nil, "")
+	keystore := software.NewKeystoreFromPIN(0, "1234")
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/1'/0'/0/0")
+	require.NoError(t, err)
+	message := []byte("message")
+	signature, err := keystore.SignMessage(keypath, message, coin)
+	require.NoError(t, err)
+
+	extendedPublicKey, err := keystore.ExtendedPublicKey(keypath)
+	require.NoError(t, err)
+	publicKey, err := extendedPublicKey.ECPubKey()
+	require.NoError(t, err)
+	pubKeyHash := btcutil.Hash160(publicKey.SerializeCompressed())
+	p2pkhAddress, err := btcutil.NewAddressPubKeyHash(pubKeyHash, coin.Net())
+	require.NoError(t, err)
+	p2wpkhAddress, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, coin.Net())
+	require.NoError(t, err)
+	redeemScript, err := txscript.PayToAddrScript(p2wpkhAddress)
+	require.NoError(t, err)
+	p2shAddress, err := btcutil.NewAddressScriptHash(redeemScript, coin.Net())
+	require.NoError(t, err)
+
+	verify := func(address btcutil.Address, message []byte, signature []byte) bool {
+		valid, err := coin.VerifyMessage(
+			address.EncodeAddress(), message, base64.StdEncoding.EncodeToString(signature))
+		require.NoError(t, err)
+		return valid
+	}
+	// The header byte of a P2PKH signature is accepted for segwit addresses like in Electrum.
+	require.True(t, verify(p2pkhAddress, message, signature))
+	require.True(t, verify(p2shAddress, message, signature))
+	require.True(t, verify(p2wpkhAddress, message, signature))
+	require.False(t, verify(p2pkhAddress, []byte("other message"), signature))
+
+	p2wpkhSignature := append([]byte{signature[0] + 8}, signature[1:]...)
+	require.True(t, verify(p2wpkhAddress, message, p2wpkhSignature))
+	require.False(t, verify(p2pkhAddress, message, p2wpkhSignature))
+	require.False(t, verify(p2shAddress, message, p2wpkhSignature))
+
+	_, err = coin.VerifyMessage("invalid", message, base64.StdEncoding.EncodeToString(signature))
+	require.Error(t, err)
+	_, err = coin.VerifyMessage(p2pkhAddress.EncodeAddress(), message, "invalid")
+	require.Error(t, err)
+}
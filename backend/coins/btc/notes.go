@@ -0,0 +1,83 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// notesKeystoreFingerprint returns the hex encoded master key fingerprints of the keystores of
+// the account, which identify the keystores the notes belong to. The result is cached, as
+// retrieving the master keys can involve the device.
+func (account *Account) notesKeystoreFingerprint() (string, error) {
+	defer account.Lock()()
+	if account.keystoreFingerprint != "" {
+		return account.keystoreFingerprint, nil
+	}
+	fingerprints, err := account.masterKeyFingerprints()
+	if err != nil {
+		return "", err
+	}
+	encoded := make([]string, len(fingerprints))
+	for index, fingerprint := range fingerprints {
+		encoded[index] = fmt.Sprintf("%08x", fingerprint)
+	}
+	account.keystoreFingerprint = strings.Join(encoded, "-")
+	return account.keystoreFingerprint, nil
+}
+
+// TxNote returns the note of the transaction with the given ID, or an empty string if there is
+// none.
+func (account *Account) TxNote(txID string) (string, error) {
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	keystoreFingerprint, err := account.notesKeystoreFingerprint()
+	if err != nil {
+		return "", err
+	}
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer dbTx.Rollback()
+	return dbTx.TxNote(keystoreFingerprint, *txHash)
+}
+
+// SetTxNote stores the note of the transaction with the given ID. An empty note removes it.
+func (account *Account) SetTxNote(txID string, note string) error {
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	keystoreFingerprint, err := account.notesKeystoreFingerprint()
+	if err != nil {
+		return err
+	}
+	dbTx, err := account.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+	if err := dbTx.PutTxNote(keystoreFingerprint, *txHash, note); err != nil {
+		return err
+	}
+	return dbTx.Commit()
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/payjoin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// payjoinTimeout is how long the sender waits for the payjoin proposal of the receiver.
+const payjoinTimeout = time.Minute
+
+// finalizedPSBT returns the signed transaction as a finalized PSBT, which is the original
+// transaction of a payjoin.
+func (account *Account) finalizedPSBT(
+	transaction *wire.MsgTx, previousOutputs map[wire.OutPoint]*transactions.SpendableOutput,
+) (*psbt.Packet, error) {
+	unsignedTx := transaction.Copy()
+	for _, txIn := range unsignedTx.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	packet, err := psbt.New(unsignedTx)
+	if err != nil {
+		return nil, err
+	}
+	for index, txIn := range transaction.TxIn {
+		input := packet.Inputs[index]
+		input.FinalScriptSig = txIn.SignatureScript
+		input.FinalScriptWitness = txIn.Witness
+		if len(txIn.Witness) != 0 {
+			input.WitnessUtxo = previousOutputs[txIn.PreviousOutPoint].TxOut
+			continue
+		}
+		input.NonWitnessUtxo = account.transactions.Transaction(txIn.PreviousOutPoint.Hash)
+		if input.NonWitnessUtxo == nil {
+			return nil, errp.Newf("The transaction spent by input %d is missing.", index)
+		}
+	}
+	return packet, nil
+}
+
+// changeOutputIndex returns the index of the output paying to the given change address, or -1.
+func changeOutputIndex(transaction *wire.MsgTx, changeAddress *addresses.AccountAddress) int {
+	if changeAddress == nil {
+		return -1
+	}
+	for index, txOut := range transaction.TxOut {
+		if bytes.Equal(txOut.PkScript, changeAddress.PubkeyScript()) {
+			return index
+		}
+	}
+	return -1
+}
+
+// sendPayjoinTx sends a payjoin according to BIP78. The original transaction is signed and posted
+// to the payjoin endpoint of the receiver, who adds own inputs. The proposal of the receiver is
+// validated and signed again. If the receiver does not return a valid proposal, the original
+// transaction is broadcasted instead. Returns keystore.ErrSigningAborted on user abort, in which
+// case the receiver might still broadcast the original transaction.
+func (account *Account) sendPayjoinTx(args *TxProposalArgs) error {
+	account.log.Info("Signing and sending payjoin transaction")
+	utxo, txProposal, err := account.newTx(args)
+	if err != nil {
+		return errp.WithMessage(err, "Failed to create transaction")
+	}
+	feeRatePerKb, err := account.txFeeRatePerKb(args.FeeTargetCode, args.CustomFee)
+	if err != nil {
+		return err
+	}
+	if err := SignTransaction(account.keystores, txProposal, utxo, account.getAddress,
+		account.transactions.Transaction, nil, account.notifySignProgress, account.log); err != nil {
+		return errp.WithMessage(err, "Failed to sign transaction")
+	}
+	broadcastOriginal := func(reason error) error {
+		account.log.WithError(reason).Warning("Payjoin failed, broadcasting the original transaction")
+		return account.blockchain.TransactionBroadcast(txProposal.Transaction)
+	}
+	original, err := account.finalizedPSBT(txProposal.Transaction, utxo)
+	if err != nil {
+		return broadcastOriginal(err)
+	}
+	params := &payjoin.Params{
+		AdditionalFeeOutputIndex: changeOutputIndex(txProposal.Transaction, txProposal.ChangeAddress),
+		// The receiver may take the fee of one of its inputs from the change.
+		MaxAdditionalFeeContribution: feeRatePerKb *
+			btcutil.Amount(maketx.InputVSize(account.signingConfiguration)) / 1000,
+		MinFeeRatePerKb: feeRatePerKb,
+	}
+	if params.AdditionalFeeOutputIndex < 0 {
+		params.MaxAdditionalFeeContribution = 0
+	}
+	proposal, err := payjoin.Request(
+		&http.Client{Timeout: payjoinTimeout}, args.PayjoinEndpoint, original, params)
+	if err != nil {
+		return broadcastOriginal(err)
+	}
+	externalInputs, err := payjoin.CheckProposal(original, proposal, params)
+	if err != nil {
+		return broadcastOriginal(err)
+	}
+
+	transaction := proposal.UnsignedTx.Copy()
+	previousOutputs := map[wire.OutPoint]*transactions.SpendableOutput{}
+	for index, txIn := range transaction.TxIn {
+		if _, ok := externalInputs[txIn.PreviousOutPoint]; !ok {
+			previousOutputs[txIn.PreviousOutPoint] = utxo[txIn.PreviousOutPoint]
+			continue
+		}
+		spentOutput, err := proposal.SpentOutput(index)
+		if err != nil {
+			return broadcastOriginal(err)
+		}
+		previousOutput := &transactions.SpendableOutput{TxOut: spentOutput}
+		if account.getAddress(previousOutput.ScriptHashHex()) != nil {
+			return broadcastOriginal(errp.Newf("The receiver input %d belongs to the account.", index))
+		}
+		previousOutputs[txIn.PreviousOutPoint] = previousOutput
+		txIn.SignatureScript = proposal.Inputs[index].FinalScriptSig
+		txIn.Witness = proposal.Inputs[index].FinalScriptWitness
+	}
+	fee := txProposal.Fee
+	if changeIndex := params.AdditionalFeeOutputIndex; changeIndex >= 0 {
+		change := txProposal.Transaction.TxOut[changeIndex]
+		for _, txOut := range transaction.TxOut {
+			if bytes.Equal(txOut.PkScript, change.PkScript) {
+				fee += btcutil.Amount(change.Value - txOut.Value)
+				break
+			}
+		}
+	}
+	payjoinProposal := &maketx.TxProposal{
+		Coin:                 account.coin,
+		AccountConfiguration: account.signingConfiguration,
+		Amount:               txProposal.Amount,
+		Fee:                  fee,
+		Transaction:          transaction,
+		ChangeAddress:        txProposal.ChangeAddress,
+	}
+	err = signTransaction(account.keystores, payjoinProposal, previousOutputs, account.getAddress,
+		account.transactions.Transaction, nil, externalInputs, account.notifySignProgress, account.log)
+	if err != nil {
+		if errp.Cause(err) == keystore.ErrSigningAborted {
+			return err
+		}
+		return broadcastOriginal(err)
+	}
+	account.log.Info("Signed payjoin transaction is broadcasted")
+	return account.blockchain.TransactionBroadcast(transaction)
+}
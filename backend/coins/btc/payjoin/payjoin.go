@@ -0,0 +1,288 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package payjoin implements the sender side of the payjoin protocol of BIP78, in which the
+// receiver of a payment adds own inputs to the transaction of the sender.
+package payjoin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// maxResponseSize limits the size of the payjoin proposal read from the receiver.
+const maxResponseSize = 1000000
+
+// Params are the parameters of a payjoin request. Output substitution is always disabled, so the
+// receiver can not change the outputs of the original transaction other than the fee output.
+type Params struct {
+	// AdditionalFeeOutputIndex is the index of the output from which the receiver may take the
+	// additional fee for its inputs, usually the change. It is -1 if there is no such output.
+	AdditionalFeeOutputIndex int
+	// MaxAdditionalFeeContribution is the maximum amount the receiver may take from the fee output.
+	MaxAdditionalFeeContribution btcutil.Amount
+	// MinFeeRatePerKb is the minimum fee rate of the payjoin proposal.
+	MinFeeRatePerKb btcutil.Amount
+}
+
+// query returns the query parameters of the request as specified in BIP78.
+func (params *Params) query() url.Values {
+	query := url.Values{}
+	query.Set("v", "1")
+	query.Set("disableoutputsubstitution", "true")
+	if params.AdditionalFeeOutputIndex >= 0 {
+		query.Set("additionalfeeoutputindex", strconv.Itoa(params.AdditionalFeeOutputIndex))
+		query.Set("maxadditionalfeecontribution", strconv.FormatInt(int64(params.MaxAdditionalFeeContribution), 10))
+	}
+	// The minimum fee rate is given in sat/vB.
+	query.Set("minfeerate", strconv.FormatFloat(float64(params.MinFeeRatePerKb)/1000, 'f', -1, 64))
+	return query
+}
+
+// Error is an error returned by the payjoin receiver.
+type Error struct {
+	Code    string `json:"errorCode"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (err *Error) Error() string {
+	return fmt.Sprintf("payjoin error %s: %s", err.Code, err.Message)
+}
+
+// endpointURL checks that the endpoint is either encrypted or a Tor hidden service and adds
+// the parameters to it.
+func endpointURL(endpoint string, params *Params) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && strings.HasSuffix(parsed.Hostname(), ".onion")) {
+		return "", errp.New("The payjoin endpoint has to use https or be an onion address.")
+	}
+	query := parsed.Query()
+	for key, values := range params.query() {
+		query[key] = values
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+// Request sends the finalized original transaction to the payjoin endpoint of the receiver and
+// returns the proposal of the receiver. The proposal has to be validated with CheckProposal.
+func Request(client *http.Client, endpoint string, original *psbt.Packet, params *Params) (
+	*psbt.Packet, error) {
+	requestURL, err := endpointURL(endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := original.Base64()
+	if err != nil {
+		return nil, err
+	}
+	httpResponse, err := client.Post(requestURL, "text/plain", strings.NewReader(encoded))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	defer func() { _ = httpResponse.Body.Close() }()
+	body, err := ioutil.ReadAll(&io.LimitedReader{R: httpResponse.Body, N: maxResponseSize})
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if httpResponse.StatusCode != http.StatusOK {
+		var receiverError Error
+		if err := json.Unmarshal(body, &receiverError); err != nil || receiverError.Code == "" {
+			return nil, errp.Newf("The payjoin request failed with status %d.", httpResponse.StatusCode)
+		}
+		return nil, &receiverError
+	}
+	return psbt.ParseBase64(string(bytes.TrimSpace(body)))
+}
+
+// isFinalized returns whether the input of a PSBT contains its final signature script or witness.
+func isFinalized(input *psbt.Input) bool {
+	return len(input.FinalScriptSig) != 0 || len(input.FinalScriptWitness) != 0
+}
+
+// CheckProposal validates the payjoin proposal of the receiver against the finalized original
+// transaction of the sender according to BIP78. On success, the outpoints of the inputs added by
+// the receiver are returned. These inputs are finalized, while the inputs of the original have to
+// be signed again.
+func CheckProposal(original, proposal *psbt.Packet, params *Params) (map[wire.OutPoint]struct{}, error) {
+	originalTx := original.UnsignedTx
+	proposalTx := proposal.UnsignedTx
+	if len(proposal.Inputs) != len(proposalTx.TxIn) || len(proposal.Outputs) != len(proposalTx.TxOut) {
+		return nil, errp.New("The proposal is malformed.")
+	}
+	if proposalTx.Version != originalTx.Version || proposalTx.LockTime != originalTx.LockTime {
+		return nil, errp.New("The proposal changed the version or the locktime.")
+	}
+
+	originalInputs := map[wire.OutPoint]int{}
+	for index, txIn := range originalTx.TxIn {
+		originalInputs[txIn.PreviousOutPoint] = index
+	}
+	sequence := originalTx.TxIn[0].Sequence
+	originalSpentOutput, err := original.SpentOutput(0)
+	if err != nil {
+		return nil, err
+	}
+	scriptClass := txscript.GetScriptClass(originalSpentOutput.PkScript)
+
+	// The proposal with the final scripts of the original inputs, to estimate its size.
+	estimatedTx := proposalTx.Copy()
+	spentOutputs := make([]*wire.TxOut, len(proposalTx.TxIn))
+	externalInputs := map[wire.OutPoint]struct{}{}
+	seen := map[wire.OutPoint]struct{}{}
+	inputSum := btcutil.Amount(0)
+	for index, txIn := range proposalTx.TxIn {
+		if _, ok := seen[txIn.PreviousOutPoint]; ok {
+			return nil, errp.New("The proposal spends an output twice.")
+		}
+		seen[txIn.PreviousOutPoint] = struct{}{}
+		if txIn.Sequence != sequence {
+			return nil, errp.Newf("The proposal changed the sequence of input %d.", index)
+		}
+		input := proposal.Inputs[index]
+		if originalIndex, ok := originalInputs[txIn.PreviousOutPoint]; ok {
+			if isFinalized(input) || len(input.PartialSigs) != 0 {
+				return nil, errp.Newf("The proposal contains signatures of the sender input %d.", index)
+			}
+			spentOutputs[index], err = original.SpentOutput(originalIndex)
+			if err != nil {
+				return nil, err
+			}
+			estimatedTx.TxIn[index].SignatureScript = original.Inputs[originalIndex].FinalScriptSig
+			estimatedTx.TxIn[index].Witness = original.Inputs[originalIndex].FinalScriptWitness
+		} else {
+			if !isFinalized(input) {
+				return nil, errp.Newf("The receiver input %d is not finalized.", index)
+			}
+			spentOutputs[index], err = proposal.SpentOutput(index)
+			if err != nil {
+				return nil, err
+			}
+			if txscript.GetScriptClass(spentOutputs[index].PkScript) != scriptClass {
+				return nil, errp.Newf("The receiver input %d has a different script type.", index)
+			}
+			estimatedTx.TxIn[index].SignatureScript = input.FinalScriptSig
+			estimatedTx.TxIn[index].Witness = input.FinalScriptWitness
+			externalInputs[txIn.PreviousOutPoint] = struct{}{}
+		}
+		inputSum += btcutil.Amount(spentOutputs[index].Value)
+	}
+	if len(seen)-len(externalInputs) != len(originalInputs) {
+		return nil, errp.New("The proposal does not spend all inputs of the original transaction.")
+	}
+	if len(externalInputs) == 0 {
+		return nil, errp.New("The receiver did not add any inputs.")
+	}
+
+	// The signatures of the receiver do not depend on the signatures of the sender, so they can be
+	// verified before the sender signs.
+	sigHashes := txscript.NewTxSigHashes(estimatedTx)
+	for index, txIn := range estimatedTx.TxIn {
+		if _, ok := externalInputs[txIn.PreviousOutPoint]; !ok {
+			continue
+		}
+		if taproot.IsPayToTaproot(spentOutputs[index].PkScript) {
+			// The script engine of our btcd version does not know about BIP341.
+			continue
+		}
+		engine, err := txscript.NewEngine(spentOutputs[index].PkScript, estimatedTx, index,
+			txscript.StandardVerifyFlags, nil, sigHashes, spentOutputs[index].Value)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if err := engine.Execute(); err != nil {
+			return nil, errp.WithMessage(err, fmt.Sprintf("The receiver input %d is invalid", index))
+		}
+	}
+
+	// Every original output has to be present. Only the fee output may decrease.
+	used := make([]bool, len(proposalTx.TxOut))
+	additionalFeeContribution := btcutil.Amount(0)
+	for originalIndex, originalTxOut := range originalTx.TxOut {
+		found := false
+		for index, txOut := range proposalTx.TxOut {
+			if used[index] || !bytes.Equal(txOut.PkScript, originalTxOut.PkScript) {
+				continue
+			}
+			used[index] = true
+			found = true
+			if txOut.Value < originalTxOut.Value {
+				if originalIndex != params.AdditionalFeeOutputIndex {
+					return nil, errp.Newf("The proposal decreased the output %d.", originalIndex)
+				}
+				additionalFeeContribution = btcutil.Amount(originalTxOut.Value - txOut.Value)
+			}
+			break
+		}
+		if !found {
+			return nil, errp.Newf("The proposal removed the output %d.", originalIndex)
+		}
+	}
+	if additionalFeeContribution > params.MaxAdditionalFeeContribution {
+		return nil, errp.New("The proposal takes more than the maximum additional fee contribution.")
+	}
+
+	outputSum := btcutil.Amount(0)
+	for _, txOut := range proposalTx.TxOut {
+		outputSum += btcutil.Amount(txOut.Value)
+	}
+	originalFee, err := fee(original)
+	if err != nil {
+		return nil, err
+	}
+	proposalFee := inputSum - outputSum
+	if additionalFeeContribution > proposalFee-originalFee {
+		return nil, errp.New("The additional fee contribution is not used for the fee.")
+	}
+	vsize := mempool.GetTxVirtualSize(btcutil.NewTx(estimatedTx))
+	if proposalFee*1000 < params.MinFeeRatePerKb*btcutil.Amount(vsize) {
+		return nil, errp.New("The fee rate of the proposal is too low.")
+	}
+	return externalInputs, nil
+}
+
+// fee returns the fee paid by the transaction of the given PSBT.
+func fee(packet *psbt.Packet) (btcutil.Amount, error) {
+	fee := btcutil.Amount(0)
+	for index := range packet.UnsignedTx.TxIn {
+		spentOutput, err := packet.SpentOutput(index)
+		if err != nil {
+			return 0, err
+		}
+		fee += btcutil.Amount(spentOutput.Value)
+	}
+	for _, txOut := range packet.UnsignedTx.TxOut {
+		fee -= btcutil.Amount(txOut.Value)
+	}
+	return fee, nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payjoin_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/payjoin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	changeIndex = 1
+	feePerKb    = btcutil.Amount(1000)
+)
+
+var (
+	senderKey, _   = btcec.PrivKeyFromBytes(btcec.S256(), []byte("sender-private-key-32-bytes-long"))
+	receiverKey, _ = btcec.PrivKeyFromBytes(btcec.S256(), []byte("receiver-privatekey-32bytes-long"))
+)
+
+func p2wpkhScript(key *btcec.PrivateKey) []byte {
+	script, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(btcutil.Hash160(key.PubKey().SerializeCompressed())).Script()
+	if err != nil {
+		panic(err)
+	}
+	return script
+}
+
+// sign adds the witness of the P2WPKH input at the given index to the packet.
+func sign(t *testing.T, packet *psbt.Packet, index int, key *btcec.PrivateKey) {
+	t.Helper()
+	spentOutput := packet.Inputs[index].WitnessUtxo
+	witness, err := txscript.WitnessSignature(packet.UnsignedTx, txscript.NewTxSigHashes(packet.UnsignedTx),
+		index, spentOutput.Value, spentOutput.PkScript, txscript.SigHashAll, key, true)
+	require.NoError(t, err)
+	packet.Inputs[index].FinalScriptWitness = witness
+}
+
+func params() *payjoin.Params {
+	return &payjoin.Params{
+		AdditionalFeeOutputIndex:     changeIndex,
+		MaxAdditionalFeeContribution: 69,
+		MinFeeRatePerKb:              feePerKb,
+	}
+}
+
+// newOriginal returns the finalized original transaction, which pays 50000 sat to the receiver.
+func newOriginal(t *testing.T) *psbt.Packet {
+	t.Helper()
+	transaction := wire.NewMsgTx(2)
+	transaction.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH([]byte("sender")), Index: 0}, nil, nil))
+	transaction.TxIn[0].Sequence = 0xfffffffd
+	transaction.AddTxOut(wire.NewTxOut(50000, p2wpkhScript(receiverKey)))
+	transaction.AddTxOut(wire.NewTxOut(49859, p2wpkhScript(senderKey)))
+	packet, err := psbt.New(transaction)
+	require.NoError(t, err)
+	packet.Inputs[0].WitnessUtxo = wire.NewTxOut(100000, p2wpkhScript(senderKey))
+	sign(t, packet, 0, senderKey)
+	return packet
+}
+
+// newProposal returns a proposal which adds an input of 30000 sat of the receiver. The receiver
+// takes the given amount from the change for the additional fee. modify is called before the
+// receiver signs.
+func newProposal(
+	t *testing.T, original *psbt.Packet, contribution int64, modify func(*psbt.Packet)) *psbt.Packet {
+	t.Helper()
+	transaction := original.UnsignedTx.Copy()
+	transaction.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH([]byte("receiver")), Index: 1}, nil, nil))
+	transaction.TxIn[1].Sequence = transaction.TxIn[0].Sequence
+	transaction.TxOut[0].Value += 30000
+	transaction.TxOut[changeIndex].Value -= contribution
+	packet, err := psbt.New(transaction)
+	require.NoError(t, err)
+	packet.Inputs[0].WitnessUtxo = original.Inputs[0].WitnessUtxo
+	packet.Inputs[1].WitnessUtxo = wire.NewTxOut(30000, p2wpkhScript(receiverKey))
+	if modify != nil {
+		modify(packet)
+	}
+	sign(t, packet, 1, receiverKey)
+	return packet
+}
+
+func TestCheckProposal(t *testing.T) {
+	original := newOriginal(t)
+	proposal := newProposal(t, original, 69, nil)
+	externalInputs, err := payjoin.CheckProposal(original, proposal, params())
+	require.NoError(t, err)
+	require.Equal(t, map[wire.OutPoint]struct{}{proposal.UnsignedTx.TxIn[1].PreviousOutPoint: {}}, externalInputs)
+}
+
+func TestCheckProposalInvalid(t *testing.T) {
+	tests := []struct {
+		name         string
+		contribution int64
+		modify       func(*psbt.Packet)
+		// tamper is called after the receiver signed.
+		tamper func(*psbt.Packet)
+		params func(*payjoin.Params)
+	}{
+		{name: "version", modify: func(packet *psbt.Packet) { packet.UnsignedTx.Version = 1 }},
+		{name: "locktime", modify: func(packet *psbt.Packet) { packet.UnsignedTx.LockTime = 1 }},
+		{name: "sequence", modify: func(packet *psbt.Packet) { packet.UnsignedTx.TxIn[1].Sequence = 0 }},
+		{name: "input spent twice", modify: func(packet *psbt.Packet) {
+			packet.UnsignedTx.TxIn[1].PreviousOutPoint = packet.UnsignedTx.TxIn[0].PreviousOutPoint
+		}},
+		{name: "sender input missing", modify: func(packet *psbt.Packet) {
+			packet.UnsignedTx.TxIn[0].PreviousOutPoint.Index = 5
+		}},
+		{name: "sender input signed", modify: func(packet *psbt.Packet) {
+			packet.Inputs[0].FinalScriptWitness = wire.TxWitness{{1}}
+		}},
+		{name: "script type", modify: func(packet *psbt.Packet) {
+			packet.Inputs[1].WitnessUtxo.PkScript = append([]byte{txscript.OP_DUP, txscript.OP_HASH160, 20},
+				append(make([]byte, 20), txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)...)
+		}},
+		{name: "invalid signature", tamper: func(packet *psbt.Packet) {
+			packet.UnsignedTx.TxOut[0].Value--
+		}},
+		{name: "output removed", modify: func(packet *psbt.Packet) {
+			packet.UnsignedTx.TxOut[0].PkScript = p2wpkhScript(senderKey)
+		}},
+		{name: "output decreased", modify: func(packet *psbt.Packet) {
+			packet.UnsignedTx.TxOut[0].Value = 49000
+		}},
+		{name: "contribution too high", contribution: 70},
+		{name: "contribution not allowed", contribution: 69, params: func(params *payjoin.Params) {
+			params.AdditionalFeeOutputIndex = -1
+		}},
+		{name: "contribution not used for the fee", contribution: 69, modify: func(packet *psbt.Packet) {
+			packet.UnsignedTx.TxOut[0].Value += 69
+		}},
+		{name: "fee rate too low", params: func(params *payjoin.Params) {
+			params.MinFeeRatePerKb = 2 * feePerKb
+		}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			original := newOriginal(t)
+			proposal := newProposal(t, original, test.contribution, test.modify)
+			if test.tamper != nil {
+				test.tamper(proposal)
+			}
+			params := params()
+			if test.params != nil {
+				test.params(params)
+			}
+			_, err := payjoin.CheckProposal(original, proposal, params)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestRequest(t *testing.T) {
+	original := newOriginal(t)
+	proposal := newProposal(t, original, 69, nil)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+		require.Equal(t, "1", query.Get("v"))
+		require.Equal(t, "abc", query.Get("pj"))
+		require.Equal(t, "true", query.Get("disableoutputsubstitution"))
+		require.Equal(t, "1", query.Get("additionalfeeoutputindex"))
+		require.Equal(t, "69", query.Get("maxadditionalfeecontribution"))
+		require.Equal(t, "1", query.Get("minfeerate"))
+		body, err := ioutil.ReadAll(request.Body)
+		require.NoError(t, err)
+		received, err := psbt.ParseBase64(string(body))
+		require.NoError(t, err)
+		if received.UnsignedTx.LockTime != 0 {
+			writer.WriteHeader(http.StatusBadRequest)
+			_, _ = writer.Write([]byte(`{"errorCode": "not-enough-money", "message": "too bad"}`))
+			return
+		}
+		encoded, err := proposal.Base64()
+		require.NoError(t, err)
+		_, _ = writer.Write([]byte(encoded))
+	}))
+	defer server.Close()
+
+	received, err := payjoin.Request(server.Client(), server.URL+"?pj=abc", original, params())
+	require.NoError(t, err)
+	require.Equal(t, proposal.UnsignedTx.TxHash(), received.UnsignedTx.TxHash())
+
+	original.UnsignedTx.LockTime = 1
+	_, err = payjoin.Request(server.Client(), server.URL+"?pj=abc", original, params())
+	require.Equal(t, &payjoin.Error{Code: "not-enough-money", Message: "too bad"}, err)
+
+	_, err = payjoin.Request(server.Client(), "http://example.com/pj", original, params())
+	require.Error(t, err)
+}
@@ -0,0 +1,486 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// masterKeyFingerprints returns the fingerprint of the master key of each keystore, in the order
+// of the cosigners.
+func (account *Account) masterKeyFingerprints() ([]uint32, error) {
+	if account.keystores.Count() == 0 {
+		// Watch-only accounts have no keystores.
+		return []uint32{}, nil
+	}
+	rootFingerprints, err := account.keystores.RootFingerprints()
+	if err != nil {
+		return nil, errp.WithMessage(err, "Failed to get the root fingerprints")
+	}
+	fingerprints := make([]uint32, len(rootFingerprints))
+	for index, rootFingerprint := range rootFingerprints {
+		fingerprints[index] = binary.LittleEndian.Uint32(rootFingerprint)
+	}
+	return fingerprints, nil
+}
+
+// bip32Derivations returns the derivations of the public keys of the given address. Only the
+// public keys of the cosigners whose master key fingerprint is known are included.
+func bip32Derivations(
+	address *addresses.AccountAddress, fingerprints []uint32) []*psbt.Bip32Derivation {
+	path := address.Configuration.AbsoluteKeypath().ToUInt32()
+	derivations := []*psbt.Bip32Derivation{}
+	for index, publicKey := range address.Configuration.PublicKeys() {
+		if index >= len(fingerprints) {
+			break
+		}
+		derivations = append(derivations, &psbt.Bip32Derivation{
+			PubKey:               publicKey.SerializeCompressed(),
+			MasterKeyFingerprint: fingerprints[index],
+			Path:                 path,
+		})
+	}
+	return derivations
+}
+
+// ExportPSBT creates a tx like SendTx, but returns it unsigned as a PSBT, so that it can be signed
+// by an external signer. The PSBT contains the spent outputs and the derivations of our keys.
+func (account *Account) ExportPSBT(args *TxProposalArgs) (*psbt.Packet, error) {
+	account.log.Info("Exporting transaction as PSBT")
+	utxo, txProposal, err := account.newTx(args)
+	if err != nil {
+		return nil, errp.WithMessage(err, "Failed to create transaction")
+	}
+	if err := DryRunSignTransaction(txProposal, utxo, account.getAddress, nil); err != nil {
+		return nil, err
+	}
+	fingerprints, err := account.masterKeyFingerprints()
+	if err != nil {
+		return nil, err
+	}
+	packet, err := psbt.New(txProposal.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	for index, txIn := range txProposal.Transaction.TxIn {
+		spentOutput := utxo[txIn.PreviousOutPoint]
+		address := account.getAddress(spentOutput.ScriptHashHex())
+		input := packet.Inputs[index]
+		// Segwit signers need the previous transaction as well to verify the spent amounts.
+		input.NonWitnessUtxo = account.transactions.Transaction(txIn.PreviousOutPoint.Hash)
+		if sighashVersion, _ := address.ScriptForHashToSign(); sighashVersion != addresses.SighashVersionLegacy {
+			input.WitnessUtxo = spentOutput.TxOut
+		} else if input.NonWitnessUtxo == nil {
+			return nil, errp.Newf("The transaction spent by input %d is missing.", index)
+		}
+		input.RedeemScript = address.RedeemScript()
+		input.WitnessScript = address.WitnessScript()
+		input.Bip32Derivation = bip32Derivations(address, fingerprints)
+	}
+	if changeAddress := txProposal.ChangeAddress; changeAddress != nil {
+		for index, txOut := range txProposal.Transaction.TxOut {
+			if bytes.Equal(txOut.PkScript, changeAddress.PubkeyScript()) {
+				packet.Outputs[index].RedeemScript = changeAddress.RedeemScript()
+				packet.Outputs[index].WitnessScript = changeAddress.WitnessScript()
+				packet.Outputs[index].Bip32Derivation = bip32Derivations(changeAddress, fingerprints)
+			}
+		}
+	}
+	return packet, nil
+}
+
+// partialSignatures returns the signatures of the cosigners of the given address which are
+// contained in the input of a PSBT, indexed by cosigner. Signatures of other keys are ignored.
+func partialSignatures(
+	input *psbt.Input, index int, address *addresses.AccountAddress, sigHashType txscript.SigHashType) (
+	[]*btcec.Signature, error) {
+	publicKeys := address.Configuration.PublicKeys()
+	signatures := make([]*btcec.Signature, len(publicKeys))
+	for _, partialSig := range input.PartialSigs {
+		for cosignerIndex, publicKey := range publicKeys {
+			if signatures[cosignerIndex] != nil ||
+				!bytes.Equal(publicKey.SerializeCompressed(), partialSig.PubKey) {
+				continue
+			}
+			length := len(partialSig.Signature)
+			if length == 0 || txscript.SigHashType(partialSig.Signature[length-1]) != sigHashType {
+				return nil, errp.Newf("The signature of input %d has the wrong sighash type.", index)
+			}
+			signature, err := btcec.ParseDERSignature(partialSig.Signature[:length-1], btcec.S256())
+			if err != nil {
+				return nil, errp.WithMessage(errp.WithStack(err),
+					fmt.Sprintf("Failed to parse a signature of input %d", index))
+			}
+			signatures[cosignerIndex] = signature
+		}
+	}
+	return signatures, nil
+}
+
+// keystoreBip32Derivation returns the derivation of the public key of the given signer in the
+// given address.
+func keystoreBip32Derivation(
+	address *addresses.AccountAddress, signer keystore.Keystore, fingerprint uint32) *psbt.Bip32Derivation {
+	configuration := address.Configuration
+	return &psbt.Bip32Derivation{
+		PubKey:               configuration.PublicKeys()[signer.CosignerIndex()].SerializeCompressed(),
+		MasterKeyFingerprint: fingerprint,
+		Path:                 configuration.AbsoluteKeypath().ToUInt32(),
+	}
+}
+
+// KeystorePSBT returns the unsigned PSBT of the proposed transaction for a keystore which signs
+// PSBTs instead of signature hashes, e.g. a device used through HWI. The inputs and the change
+// output contain the derivations of the keys of the signer, so that it can verify the amounts and
+// recognize the change. External inputs are included with their final scripts.
+func (proposedTransaction *ProposedTransaction) KeystorePSBT(
+	signer keystore.Keystore, signatureHashes []*InputSignatureHash) (*psbt.Packet, error) {
+	rootFingerprint, err := signer.RootFingerprint()
+	if err != nil {
+		return nil, err
+	}
+	if len(rootFingerprint) != 4 {
+		return nil, errp.New("Invalid root fingerprint.")
+	}
+	fingerprint := binary.LittleEndian.Uint32(rootFingerprint)
+	transaction := proposedTransaction.TXProposal.Transaction
+	unsignedTransaction := transaction.Copy()
+	for _, txIn := range unsignedTransaction.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	packet, err := psbt.New(unsignedTransaction)
+	if err != nil {
+		return nil, err
+	}
+	for index, txIn := range transaction.TxIn {
+		input := packet.Inputs[index]
+		spentOutput, ok := proposedTransaction.PreviousOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			return nil, errp.Newf("The output spent by input %d is missing.", index)
+		}
+		if proposedTransaction.GetPreviousTransaction != nil {
+			input.NonWitnessUtxo = proposedTransaction.GetPreviousTransaction(txIn.PreviousOutPoint.Hash)
+		}
+		signatureHash := signatureHashes[index]
+		if signatureHash == nil {
+			input.WitnessUtxo = spentOutput.TxOut
+			input.FinalScriptSig = txIn.SignatureScript
+			input.FinalScriptWitness = txIn.Witness
+			continue
+		}
+		if signatureHash.SighashVersion != addresses.SighashVersionLegacy {
+			input.WitnessUtxo = spentOutput.TxOut
+		} else if input.NonWitnessUtxo == nil {
+			return nil, errp.Newf("The transaction spent by input %d is missing.", index)
+		}
+		if len(proposedTransaction.SigHashType) != 0 {
+			input.SighashType = proposedTransaction.SigHashType[index]
+		}
+		input.RedeemScript = signatureHash.Address.RedeemScript()
+		input.WitnessScript = signatureHash.Address.WitnessScript()
+		input.Bip32Derivation = []*psbt.Bip32Derivation{
+			keystoreBip32Derivation(signatureHash.Address, signer, fingerprint)}
+	}
+	if changeAddress := proposedTransaction.TXProposal.ChangeAddress; changeAddress != nil {
+		for index, txOut := range transaction.TxOut {
+			if bytes.Equal(txOut.PkScript, changeAddress.PubkeyScript()) {
+				output := packet.Outputs[index]
+				output.RedeemScript = changeAddress.RedeemScript()
+				output.WitnessScript = changeAddress.WitnessScript()
+				output.Bip32Derivation = []*psbt.Bip32Derivation{
+					keystoreBip32Derivation(changeAddress, signer, fingerprint)}
+			}
+		}
+	}
+	return packet, nil
+}
+
+// AddKeystorePSBTSignatures verifies the signatures of the given signer in the PSBT signed by it,
+// see KeystorePSBT, and adds them to the proposed transaction. Returns
+// keystore.ErrSignatureCountMismatch if an input was not signed.
+func (proposedTransaction *ProposedTransaction) AddKeystorePSBTSignatures(
+	signer keystore.Keystore, signatureHashes []*InputSignatureHash, signedPacket *psbt.Packet) error {
+	if len(signedPacket.Inputs) != len(signatureHashes) {
+		return errp.New("The signed PSBT has a different number of inputs.")
+	}
+	cosignerIndex := signer.CosignerIndex()
+	signatures, inputs := 0, 0
+	for index, signatureHash := range signatureHashes {
+		if signatureHash == nil {
+			continue
+		}
+		inputs++
+		cosignerSignatures, err := partialSignatures(signedPacket.Inputs[index], index,
+			signatureHash.Address, proposedTransaction.InputSigHashType(index, txscript.SigHashAll))
+		if err != nil {
+			return err
+		}
+		signature := cosignerSignatures[cosignerIndex]
+		if signature == nil {
+			continue
+		}
+		publicKey := signatureHash.Address.Configuration.PublicKeys()[cosignerIndex]
+		if !signature.Verify(signatureHash.Hash, publicKey) {
+			return errp.Newf("The signature of input %d is invalid.", index)
+		}
+		proposedTransaction.Signatures[index][cosignerIndex] = signature
+		signatures++
+	}
+	if signatures != inputs {
+		return errp.WithMessage(errp.WithStack(keystore.ErrSignatureCountMismatch),
+			fmt.Sprintf("Expected %d signatures, got %d", inputs, signatures))
+	}
+	proposedTransaction.ReportProgress(SignProgress{
+		Stage:      SignStageReceivingSignatures,
+		Signatures: signatures,
+		Inputs:     inputs,
+	})
+	return nil
+}
+
+// proposedTransactionFromPSBT returns the proposed transaction of the given PSBT, whose signatures
+// are the partial signatures of the PSBT, together with the signature hashes of its inputs. An
+// error is returned if an input does not belong to the account or if its spent output is missing
+// from the PSBT.
+func (account *Account) proposedTransactionFromPSBT(packet *psbt.Packet) (
+	*ProposedTransaction, []*InputSignatureHash, error) {
+	// The keystores may modify the transaction while signing.
+	transaction := packet.UnsignedTx.Copy()
+	previousOutputs := map[wire.OutPoint]*transactions.SpendableOutput{}
+	sigHashTypes := make([]txscript.SigHashType, len(transaction.TxIn))
+	inputAmount := btcutil.Amount(0)
+	for index, txIn := range transaction.TxIn {
+		spentOutput, err := packet.SpentOutput(index)
+		if err != nil {
+			return nil, nil, err
+		}
+		previousOutputs[txIn.PreviousOutPoint] = &transactions.SpendableOutput{TxOut: spentOutput}
+		inputAmount += btcutil.Amount(spentOutput.Value)
+		address := account.getAddress(previousOutputs[txIn.PreviousOutPoint].ScriptHashHex())
+		if address == nil {
+			return nil, nil, errp.Newf("The output spent by input %d does not belong to the account.", index)
+		}
+		if sighashVersion, _ := address.ScriptForHashToSign(); sighashVersion == addresses.SighashVersionTaproot {
+			return nil, nil, errp.Newf("Signing the taproot input %d of a PSBT is not supported.", index)
+		}
+		sigHashTypes[index] = packet.InputSigHashType(index)
+	}
+
+	txProposal := &maketx.TxProposal{
+		Coin:                 account.coin,
+		AccountConfiguration: account.signingConfiguration,
+		Transaction:          transaction,
+	}
+	outputAmount := btcutil.Amount(0)
+	for _, txOut := range transaction.TxOut {
+		outputAmount += btcutil.Amount(txOut.Value)
+		scriptHashHex := blockchain.ScriptHashHex(chainhash.HashH(txOut.PkScript).String())
+		if account.getAddress(scriptHashHex) == nil {
+			txProposal.Amount += btcutil.Amount(txOut.Value)
+		} else if changeAddress := account.changeAddresses.LookupByScriptHashHex(scriptHashHex); changeAddress != nil &&
+			txProposal.ChangeAddress == nil {
+			txProposal.ChangeAddress = changeAddress
+		}
+	}
+	if outputAmount > inputAmount {
+		return nil, nil, errp.New("The outputs of the PSBT exceed its inputs.")
+	}
+	txProposal.Fee = inputAmount - outputAmount
+
+	proposedTransaction, err := newProposedTransaction(
+		txProposal, previousOutputs, account.getAddress, sigHashTypes, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	signatureHashes, err := proposedTransaction.SignatureHashes()
+	if err != nil {
+		return nil, nil, err
+	}
+	for index, signatureHash := range signatureHashes {
+		proposedTransaction.Signatures[index], err = partialSignatures(
+			packet.Inputs[index], index, signatureHash.Address, sigHashTypes[index])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return proposedTransaction, signatureHashes, nil
+}
+
+// SignPSBT signs all inputs of the given PSBT with the keystores of the account and adds the
+// signatures to the PSBT. Keystores of cosigners whose signatures are already contained in the
+// PSBT are skipped. The inputs are not finalized. An error is returned if an input does not
+// belong to the account or if its spent output is missing from the PSBT. Returns
+// keystore.ErrSigningAborted on user abort.
+func (account *Account) SignPSBT(packet *psbt.Packet) error {
+	account.log.Info("Signing PSBT")
+	proposedTransaction, signatureHashes, err := account.proposedTransactionFromPSBT(packet)
+	if err != nil {
+		return err
+	}
+	proposedTransaction.GetPreviousTransaction = account.transactions.Transaction
+	proposedTransaction.OnProgress = account.notifySignProgress
+	if err := verifyChangeAddress(proposedTransaction.TXProposal, account.log); err != nil {
+		return err
+	}
+	previousSignatures := make([][]*btcec.Signature, len(proposedTransaction.Signatures))
+	for index, signatures := range proposedTransaction.Signatures {
+		previousSignatures[index] = append([]*btcec.Signature{}, signatures...)
+	}
+	proposedTransaction.ReportProgress(SignProgress{
+		Stage: SignStageAwaitingConfirmation, Inputs: len(packet.Inputs)})
+	if err := account.keystores.SignTransaction(proposedTransaction); err != nil {
+		return err
+	}
+
+	for index, signatures := range proposedTransaction.Signatures {
+		publicKeys := signatureHashes[index].Address.Configuration.PublicKeys()
+		for cosignerIndex, signature := range signatures {
+			if signature == nil || previousSignatures[index][cosignerIndex] != nil {
+				continue
+			}
+			publicKey := publicKeys[cosignerIndex]
+			if !signature.Verify(signatureHashes[index].Hash, publicKey) {
+				return errp.Newf("The signature of input %d is invalid.", index)
+			}
+			packet.Inputs[index].PartialSigs = append(packet.Inputs[index].PartialSigs, &psbt.PartialSig{
+				PubKey: publicKey.SerializeCompressed(),
+				Signature: append(signature.Serialize(),
+					byte(proposedTransaction.InputSigHashType(index, txscript.SigHashAll))),
+			})
+		}
+	}
+	return nil
+}
+
+// FinalizePSBT combines the partial signatures of the cosigners contained in the PSBT and returns
+// the signed transaction. An error is returned if an input does not belong to the account or if
+// it does not have enough signatures.
+func (account *Account) FinalizePSBT(packet *psbt.Packet) (*wire.MsgTx, error) {
+	transaction := packet.UnsignedTx.Copy()
+	previousOutputs := map[wire.OutPoint]*transactions.SpendableOutput{}
+	for index, txIn := range transaction.TxIn {
+		spentOutput, err := packet.SpentOutput(index)
+		if err != nil {
+			return nil, err
+		}
+		previousOutputs[txIn.PreviousOutPoint] = &transactions.SpendableOutput{TxOut: spentOutput}
+		address := account.getAddress(previousOutputs[txIn.PreviousOutPoint].ScriptHashHex())
+		if address == nil {
+			return nil, errp.Newf("The output spent by input %d does not belong to the account.", index)
+		}
+		if sighashVersion, _ := address.ScriptForHashToSign(); sighashVersion == addresses.SighashVersionTaproot {
+			return nil, errp.Newf("Finalizing the taproot input %d of a PSBT is not supported.", index)
+		}
+		sigHashType := packet.Inputs[index].SighashType
+		if sigHashType == 0 {
+			sigHashType = txscript.SigHashAll
+		}
+		signatures, err := partialSignatures(packet.Inputs[index], index, address, sigHashType)
+		if err != nil {
+			return nil, err
+		}
+		// Only as many signatures as required are included in the signature script.
+		signatureCount := 0
+		for cosignerIndex, signature := range signatures {
+			if signature == nil {
+				continue
+			}
+			if signatureCount == address.Configuration.SigningThreshold() {
+				signatures[cosignerIndex] = nil
+				continue
+			}
+			signatureCount++
+		}
+		if signatureCount < address.Configuration.SigningThreshold() {
+			return nil, errp.Newf("Input %d has %d of %d required signatures.",
+				index, signatureCount, address.Configuration.SigningThreshold())
+		}
+		txIn.SignatureScript, txIn.Witness = address.SignatureScript(signatures, sigHashType)
+	}
+	if err := txValidityCheck(transaction, previousOutputs,
+		txscript.NewTxSigHashes(transaction), false); err != nil {
+		return nil, errp.WithMessage(err, "The signatures of the PSBT are invalid")
+	}
+	return transaction, nil
+}
+
+// PSBTCosigningStatus returns which cosigners have signed the given PSBT.
+func (account *Account) PSBTCosigningStatus(packet *psbt.Packet) (*CosigningStatus, error) {
+	proposedTransaction, _, err := account.proposedTransactionFromPSBT(packet)
+	if err != nil {
+		return nil, err
+	}
+	return proposedTransaction.CosigningStatus(), nil
+}
+
+// CosignPSBT combines the given PSBTs of the same transaction, e.g. the ones returned by the
+// cosigners, and adds the signatures of the connected keystores of cosigners which have not
+// signed yet. The combined PSBT is returned together with its cosigning status. Once the status is
+// complete, the PSBT can be sent with SendPSBT. Returns keystore.ErrSigningAborted on user abort.
+func (account *Account) CosignPSBT(packets ...*psbt.Packet) (*psbt.Packet, *CosigningStatus, error) {
+	if len(packets) == 0 {
+		return nil, nil, errp.New("No PSBT given.")
+	}
+	packet := packets[0]
+	if err := packet.Combine(packets[1:]...); err != nil {
+		return nil, nil, err
+	}
+	status, err := account.PSBTCosigningStatus(packet)
+	if err != nil {
+		return nil, nil, err
+	}
+	if status.Complete {
+		return packet, status, nil
+	}
+	if err := account.SignPSBT(packet); err != nil {
+		return nil, nil, err
+	}
+	status, err = account.PSBTCosigningStatus(packet)
+	if err != nil {
+		return nil, nil, err
+	}
+	return packet, status, nil
+}
+
+// SendPSBT finalizes the given PSBT, which needs the signatures of enough cosigners, and
+// broadcasts the signed transaction.
+func (account *Account) SendPSBT(packet *psbt.Packet) error {
+	account.log.Info("Sending PSBT")
+	transaction, err := account.FinalizePSBT(packet)
+	if err != nil {
+		return err
+	}
+	if err := account.blockchain.TransactionBroadcast(transaction); err != nil {
+		return err
+	}
+	account.clearPendingPSBT(packet)
+	return nil
+}
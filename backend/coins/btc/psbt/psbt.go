@@ -0,0 +1,656 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package psbt implements the partially signed bitcoin transaction format of BIP174. The btcutil
+// version we depend on does not include it.
+package psbt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"strings"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// magic is the prefix of every serialized PSBT.
+var magic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+// maxValueSize limits the size of a key or value to prevent huge allocations when parsing.
+const maxValueSize = 4000000
+
+// Key types of the global map.
+const (
+	globalUnsignedTx = 0x00
+)
+
+// Key types of the input maps.
+const (
+	inputNonWitnessUtxo     = 0x00
+	inputWitnessUtxo        = 0x01
+	inputPartialSig         = 0x02
+	inputSighashType        = 0x03
+	inputRedeemScript       = 0x04
+	inputWitnessScript      = 0x05
+	inputBip32Derivation    = 0x06
+	inputFinalScriptSig     = 0x07
+	inputFinalScriptWitness = 0x08
+)
+
+// Key types of the output maps.
+const (
+	outputRedeemScript    = 0x00
+	outputWitnessScript   = 0x01
+	outputBip32Derivation = 0x02
+)
+
+// Unknown is a key-value pair which is not interpreted, but preserved when serializing.
+type Unknown struct {
+	Key   []byte
+	Value []byte
+}
+
+// Bip32Derivation specifies the derivation of a public key from a master key.
+type Bip32Derivation struct {
+	PubKey []byte
+	// MasterKeyFingerprint are the first four bytes of the hash160 of the master public key,
+	// interpreted as a little endian integer.
+	MasterKeyFingerprint uint32
+	Path                 []uint32
+}
+
+// PartialSig is a signature of an input together with the public key it belongs to.
+type PartialSig struct {
+	PubKey []byte
+	// Signature is the DER encoded signature followed by the sighash type.
+	Signature []byte
+}
+
+// Input contains the information needed to sign an input of the unsigned transaction.
+type Input struct {
+	// NonWitnessUtxo is the transaction containing the spent output.
+	NonWitnessUtxo *wire.MsgTx
+	// WitnessUtxo is the spent output. It is only set for segwit inputs.
+	WitnessUtxo        *wire.TxOut
+	PartialSigs        []*PartialSig
+	SighashType        txscript.SigHashType
+	RedeemScript       []byte
+	WitnessScript      []byte
+	Bip32Derivation    []*Bip32Derivation
+	FinalScriptSig     []byte
+	FinalScriptWitness wire.TxWitness
+	Unknowns           []*Unknown
+}
+
+// Output contains the information needed to verify an output of the unsigned transaction.
+type Output struct {
+	RedeemScript    []byte
+	WitnessScript   []byte
+	Bip32Derivation []*Bip32Derivation
+	Unknowns        []*Unknown
+}
+
+// Packet is a partially signed bitcoin transaction.
+type Packet struct {
+	UnsignedTx *wire.MsgTx
+	Inputs     []*Input
+	Outputs    []*Output
+	Unknowns   []*Unknown
+}
+
+// New returns a packet for the given transaction with empty input and output maps. The signature
+// scripts and witnesses of the transaction have to be empty.
+func New(transaction *wire.MsgTx) (*Packet, error) {
+	for _, txIn := range transaction.TxIn {
+		if len(txIn.SignatureScript) != 0 || len(txIn.Witness) != 0 {
+			return nil, errp.New("The transaction of a PSBT has to be unsigned.")
+		}
+	}
+	packet := &Packet{
+		UnsignedTx: transaction,
+		Inputs:     make([]*Input, len(transaction.TxIn)),
+		Outputs:    make([]*Output, len(transaction.TxOut)),
+	}
+	for index := range packet.Inputs {
+		packet.Inputs[index] = &Input{}
+	}
+	for index := range packet.Outputs {
+		packet.Outputs[index] = &Output{}
+	}
+	return packet, nil
+}
+
+// SpentOutput returns the output spent by the input at the given index. An error is returned if
+// the input does not contain the spent output or if it does not match the outpoint.
+func (packet *Packet) SpentOutput(index int) (*wire.TxOut, error) {
+	input := packet.Inputs[index]
+	outPoint := packet.UnsignedTx.TxIn[index].PreviousOutPoint
+	if input.NonWitnessUtxo != nil {
+		if input.NonWitnessUtxo.TxHash() != outPoint.Hash {
+			return nil, errp.Newf("The previous transaction of input %d does not match.", index)
+		}
+		if int(outPoint.Index) >= len(input.NonWitnessUtxo.TxOut) {
+			return nil, errp.Newf("The previous transaction of input %d has no output %d.",
+				index, outPoint.Index)
+		}
+		spentOutput := input.NonWitnessUtxo.TxOut[outPoint.Index]
+		if input.WitnessUtxo != nil && (input.WitnessUtxo.Value != spentOutput.Value ||
+			!bytes.Equal(input.WitnessUtxo.PkScript, spentOutput.PkScript)) {
+			return nil, errp.Newf("The witness output of input %d does not match.", index)
+		}
+		return spentOutput, nil
+	}
+	if input.WitnessUtxo != nil {
+		return input.WitnessUtxo, nil
+	}
+	return nil, errp.Newf("The output spent by input %d is missing.", index)
+}
+
+// Combine merges the given packets into this packet, as done by the combiner of BIP174, e.g. to
+// collect the partial signatures of all cosigners of a multisig input. Fields which are set in
+// this packet are kept. All packets have to be for the same unsigned transaction.
+func (packet *Packet) Combine(others ...*Packet) error {
+	txHash := packet.UnsignedTx.TxHash()
+	for _, other := range others {
+		if other.UnsignedTx.TxHash() != txHash {
+			return errp.New("The PSBTs to combine are for different transactions.")
+		}
+	}
+	for _, other := range others {
+		for index, input := range other.Inputs {
+			packet.Inputs[index].combine(input)
+		}
+		for index, output := range other.Outputs {
+			packet.Outputs[index].combine(output)
+		}
+		packet.Unknowns = combineUnknowns(packet.Unknowns, other.Unknowns)
+	}
+	return nil
+}
+
+func (input *Input) combine(other *Input) {
+	if input.NonWitnessUtxo == nil {
+		input.NonWitnessUtxo = other.NonWitnessUtxo
+	}
+	if input.WitnessUtxo == nil {
+		input.WitnessUtxo = other.WitnessUtxo
+	}
+	for _, partialSig := range other.PartialSigs {
+		found := false
+		for _, existing := range input.PartialSigs {
+			if bytes.Equal(existing.PubKey, partialSig.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			input.PartialSigs = append(input.PartialSigs, partialSig)
+		}
+	}
+	if input.SighashType == 0 {
+		input.SighashType = other.SighashType
+	}
+	if input.RedeemScript == nil {
+		input.RedeemScript = other.RedeemScript
+	}
+	if input.WitnessScript == nil {
+		input.WitnessScript = other.WitnessScript
+	}
+	input.Bip32Derivation = combineBip32Derivations(input.Bip32Derivation, other.Bip32Derivation)
+	if input.FinalScriptSig == nil {
+		input.FinalScriptSig = other.FinalScriptSig
+	}
+	if input.FinalScriptWitness == nil {
+		input.FinalScriptWitness = other.FinalScriptWitness
+	}
+	input.Unknowns = combineUnknowns(input.Unknowns, other.Unknowns)
+}
+
+func (output *Output) combine(other *Output) {
+	if output.RedeemScript == nil {
+		output.RedeemScript = other.RedeemScript
+	}
+	if output.WitnessScript == nil {
+		output.WitnessScript = other.WitnessScript
+	}
+	output.Bip32Derivation = combineBip32Derivations(output.Bip32Derivation, other.Bip32Derivation)
+	output.Unknowns = combineUnknowns(output.Unknowns, other.Unknowns)
+}
+
+// combineBip32Derivations returns the derivations with the ones of other public keys appended.
+func combineBip32Derivations(derivations, others []*Bip32Derivation) []*Bip32Derivation {
+	for _, derivation := range others {
+		found := false
+		for _, existing := range derivations {
+			if bytes.Equal(existing.PubKey, derivation.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			derivations = append(derivations, derivation)
+		}
+	}
+	return derivations
+}
+
+// combineUnknowns returns the unknowns with the ones of other keys appended.
+func combineUnknowns(unknowns, others []*Unknown) []*Unknown {
+	for _, unknown := range others {
+		found := false
+		for _, existing := range unknowns {
+			if bytes.Equal(existing.Key, unknown.Key) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknowns = append(unknowns, unknown)
+		}
+	}
+	return unknowns
+}
+
+// Parse parses a serialized PSBT.
+func Parse(reader io.Reader) (*Packet, error) {
+	prefix := make([]byte, len(magic))
+	if _, err := io.ReadFull(reader, prefix); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if !bytes.Equal(prefix, magic) {
+		return nil, errp.New("Invalid PSBT magic bytes.")
+	}
+
+	packet := &Packet{}
+	err := readMap(reader, func(key, value []byte) error {
+		switch {
+		case key[0] == globalUnsignedTx && len(key) == 1:
+			if packet.UnsignedTx != nil {
+				return errp.New("Duplicate unsigned transaction.")
+			}
+			transaction := wire.NewMsgTx(wire.TxVersion)
+			if err := transaction.DeserializeNoWitness(bytes.NewReader(value)); err != nil {
+				return errp.WithStack(err)
+			}
+			packet.UnsignedTx = transaction
+		default:
+			packet.Unknowns = append(packet.Unknowns, &Unknown{Key: key, Value: value})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if packet.UnsignedTx == nil {
+		return nil, errp.New("The PSBT does not contain an unsigned transaction.")
+	}
+	for _, txIn := range packet.UnsignedTx.TxIn {
+		if len(txIn.SignatureScript) != 0 {
+			return nil, errp.New("The transaction of a PSBT has to be unsigned.")
+		}
+	}
+
+	for range packet.UnsignedTx.TxIn {
+		input := &Input{}
+		if err := readMap(reader, input.parse); err != nil {
+			return nil, err
+		}
+		packet.Inputs = append(packet.Inputs, input)
+	}
+	for range packet.UnsignedTx.TxOut {
+		output := &Output{}
+		if err := readMap(reader, output.parse); err != nil {
+			return nil, err
+		}
+		packet.Outputs = append(packet.Outputs, output)
+	}
+	return packet, nil
+}
+
+// ParseBase64 parses a base64 encoded PSBT.
+func ParseBase64(encoded string) (*Packet, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return Parse(bytes.NewReader(decoded))
+}
+
+// ParseFile parses the contents of a PSBT file. Signers store PSBTs in the binary format, but some
+// write them base64 or hex encoded instead, so all three are accepted.
+func ParseFile(data []byte) (*Packet, error) {
+	if bytes.HasPrefix(data, magic) {
+		return Parse(bytes.NewReader(data))
+	}
+	encoded := strings.TrimSpace(string(data))
+	if decoded, err := hex.DecodeString(encoded); err == nil {
+		return Parse(bytes.NewReader(decoded))
+	}
+	return ParseBase64(encoded)
+}
+
+// Serialize writes the serialized PSBT.
+func (packet *Packet) Serialize(writer io.Writer) error {
+	if len(packet.Inputs) != len(packet.UnsignedTx.TxIn) ||
+		len(packet.Outputs) != len(packet.UnsignedTx.TxOut) {
+		return errp.New("The number of inputs and outputs has to match the unsigned transaction.")
+	}
+	if _, err := writer.Write(magic); err != nil {
+		return errp.WithStack(err)
+	}
+	var unsignedTx bytes.Buffer
+	if err := packet.UnsignedTx.SerializeNoWitness(&unsignedTx); err != nil {
+		return errp.WithStack(err)
+	}
+	mapWriter := &mapWriter{writer: writer}
+	mapWriter.write([]byte{globalUnsignedTx}, unsignedTx.Bytes())
+	mapWriter.writeUnknowns(packet.Unknowns)
+	mapWriter.end()
+	for _, input := range packet.Inputs {
+		input.serialize(mapWriter)
+	}
+	for _, output := range packet.Outputs {
+		output.serialize(mapWriter)
+	}
+	return mapWriter.err
+}
+
+// Base64 returns the base64 encoded PSBT.
+func (packet *Packet) Base64() (string, error) {
+	var buffer bytes.Buffer
+	if err := packet.Serialize(&buffer); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buffer.Bytes()), nil
+}
+
+// Bytes returns the serialized PSBT, as stored in PSBT files.
+func (packet *Packet) Bytes() ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := packet.Serialize(&buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (input *Input) parse(key, value []byte) error {
+	keyType, keyData := key[0], key[1:]
+	switch {
+	case keyType == inputNonWitnessUtxo && len(keyData) == 0:
+		transaction := wire.NewMsgTx(wire.TxVersion)
+		if err := transaction.Deserialize(bytes.NewReader(value)); err != nil {
+			return errp.WithStack(err)
+		}
+		input.NonWitnessUtxo = transaction
+	case keyType == inputWitnessUtxo && len(keyData) == 0:
+		txOut, err := parseTxOut(value)
+		if err != nil {
+			return err
+		}
+		input.WitnessUtxo = txOut
+	case keyType == inputPartialSig:
+		input.PartialSigs = append(input.PartialSigs, &PartialSig{PubKey: keyData, Signature: value})
+	case keyType == inputSighashType && len(keyData) == 0:
+		if len(value) != 4 {
+			return errp.New("Invalid sighash type.")
+		}
+		input.SighashType = txscript.SigHashType(binary.LittleEndian.Uint32(value))
+	case keyType == inputRedeemScript && len(keyData) == 0:
+		input.RedeemScript = value
+	case keyType == inputWitnessScript && len(keyData) == 0:
+		input.WitnessScript = value
+	case keyType == inputBip32Derivation:
+		derivation, err := parseBip32Derivation(keyData, value)
+		if err != nil {
+			return err
+		}
+		input.Bip32Derivation = append(input.Bip32Derivation, derivation)
+	case keyType == inputFinalScriptSig && len(keyData) == 0:
+		input.FinalScriptSig = value
+	case keyType == inputFinalScriptWitness && len(keyData) == 0:
+		witness, err := parseWitness(value)
+		if err != nil {
+			return err
+		}
+		input.FinalScriptWitness = witness
+	default:
+		input.Unknowns = append(input.Unknowns, &Unknown{Key: key, Value: value})
+	}
+	return nil
+}
+
+func (input *Input) serialize(mapWriter *mapWriter) {
+	if input.NonWitnessUtxo != nil {
+		var buffer bytes.Buffer
+		if err := input.NonWitnessUtxo.Serialize(&buffer); err != nil {
+			mapWriter.fail(err)
+		}
+		mapWriter.write([]byte{inputNonWitnessUtxo}, buffer.Bytes())
+	}
+	if input.WitnessUtxo != nil {
+		var buffer bytes.Buffer
+		if err := wire.WriteTxOut(&buffer, 0, 0, input.WitnessUtxo); err != nil {
+			mapWriter.fail(err)
+		}
+		mapWriter.write([]byte{inputWitnessUtxo}, buffer.Bytes())
+	}
+	for _, partialSig := range input.PartialSigs {
+		mapWriter.write(append([]byte{inputPartialSig}, partialSig.PubKey...), partialSig.Signature)
+	}
+	if input.SighashType != 0 {
+		value := make([]byte, 4)
+		binary.LittleEndian.PutUint32(value, uint32(input.SighashType))
+		mapWriter.write([]byte{inputSighashType}, value)
+	}
+	if input.RedeemScript != nil {
+		mapWriter.write([]byte{inputRedeemScript}, input.RedeemScript)
+	}
+	if input.WitnessScript != nil {
+		mapWriter.write([]byte{inputWitnessScript}, input.WitnessScript)
+	}
+	mapWriter.writeBip32Derivations(inputBip32Derivation, input.Bip32Derivation)
+	if input.FinalScriptSig != nil {
+		mapWriter.write([]byte{inputFinalScriptSig}, input.FinalScriptSig)
+	}
+	if input.FinalScriptWitness != nil {
+		var buffer bytes.Buffer
+		if err := wire.WriteVarInt(&buffer, 0, uint64(len(input.FinalScriptWitness))); err != nil {
+			mapWriter.fail(err)
+		}
+		for _, item := range input.FinalScriptWitness {
+			if err := wire.WriteVarBytes(&buffer, 0, item); err != nil {
+				mapWriter.fail(err)
+			}
+		}
+		mapWriter.write([]byte{inputFinalScriptWitness}, buffer.Bytes())
+	}
+	mapWriter.writeUnknowns(input.Unknowns)
+	mapWriter.end()
+}
+
+func (output *Output) parse(key, value []byte) error {
+	keyType, keyData := key[0], key[1:]
+	switch {
+	case keyType == outputRedeemScript && len(keyData) == 0:
+		output.RedeemScript = value
+	case keyType == outputWitnessScript && len(keyData) == 0:
+		output.WitnessScript = value
+	case keyType == outputBip32Derivation:
+		derivation, err := parseBip32Derivation(keyData, value)
+		if err != nil {
+			return err
+		}
+		output.Bip32Derivation = append(output.Bip32Derivation, derivation)
+	default:
+		output.Unknowns = append(output.Unknowns, &Unknown{Key: key, Value: value})
+	}
+	return nil
+}
+
+func (output *Output) serialize(mapWriter *mapWriter) {
+	if output.RedeemScript != nil {
+		mapWriter.write([]byte{outputRedeemScript}, output.RedeemScript)
+	}
+	if output.WitnessScript != nil {
+		mapWriter.write([]byte{outputWitnessScript}, output.WitnessScript)
+	}
+	mapWriter.writeBip32Derivations(outputBip32Derivation, output.Bip32Derivation)
+	mapWriter.writeUnknowns(output.Unknowns)
+	mapWriter.end()
+}
+
+// readMap reads key-value pairs up to the separator and calls handle for each of them. The key is
+// never empty and the keys of a map have to be unique.
+func readMap(reader io.Reader, handle func(key, value []byte) error) error {
+	keys := map[string]struct{}{}
+	for {
+		key, err := readBytes(reader)
+		if err != nil {
+			return err
+		}
+		if len(key) == 0 {
+			return nil
+		}
+		if _, ok := keys[string(key)]; ok {
+			return errp.Newf("Duplicate key %x.", key)
+		}
+		keys[string(key)] = struct{}{}
+		value, err := readBytes(reader)
+		if err != nil {
+			return err
+		}
+		if err := handle(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+func readBytes(reader io.Reader) ([]byte, error) {
+	length, err := wire.ReadVarInt(reader, 0)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if length > maxValueSize {
+		return nil, errp.New("Invalid PSBT: a key or value is too large.")
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return data, nil
+}
+
+func parseTxOut(value []byte) (*wire.TxOut, error) {
+	if len(value) < 8 {
+		return nil, errp.New("Invalid witness output.")
+	}
+	reader := bytes.NewReader(value[8:])
+	pkScript, err := wire.ReadVarBytes(reader, 0, maxValueSize, "pkScript")
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if reader.Len() != 0 {
+		return nil, errp.New("Invalid witness output.")
+	}
+	return wire.NewTxOut(int64(binary.LittleEndian.Uint64(value[:8])), pkScript), nil
+}
+
+func parseWitness(value []byte) (wire.TxWitness, error) {
+	reader := bytes.NewReader(value)
+	count, err := wire.ReadVarInt(reader, 0)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if count > uint64(len(value)) {
+		return nil, errp.New("Invalid witness.")
+	}
+	witness := make(wire.TxWitness, count)
+	for index := range witness {
+		witness[index], err = wire.ReadVarBytes(reader, 0, maxValueSize, "witness")
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	return witness, nil
+}
+
+func parseBip32Derivation(pubKey, value []byte) (*Bip32Derivation, error) {
+	if len(pubKey) != 33 && len(pubKey) != 65 {
+		return nil, errp.New("Invalid public key of a BIP32 derivation.")
+	}
+	if len(value) < 4 || len(value)%4 != 0 {
+		return nil, errp.New("Invalid BIP32 derivation.")
+	}
+	derivation := &Bip32Derivation{
+		PubKey:               pubKey,
+		MasterKeyFingerprint: binary.LittleEndian.Uint32(value[:4]),
+	}
+	for offset := 4; offset < len(value); offset += 4 {
+		derivation.Path = append(derivation.Path, binary.LittleEndian.Uint32(value[offset:offset+4]))
+	}
+	return derivation, nil
+}
+
+// mapWriter writes key-value maps and remembers the first error.
+type mapWriter struct {
+	writer io.Writer
+	err    error
+}
+
+func (mapWriter *mapWriter) fail(err error) {
+	if mapWriter.err == nil {
+		mapWriter.err = errp.WithStack(err)
+	}
+}
+
+func (mapWriter *mapWriter) writeBytes(data []byte) {
+	if mapWriter.err != nil {
+		return
+	}
+	if err := wire.WriteVarBytes(mapWriter.writer, 0, data); err != nil {
+		mapWriter.fail(err)
+	}
+}
+
+func (mapWriter *mapWriter) write(key, value []byte) {
+	mapWriter.writeBytes(key)
+	mapWriter.writeBytes(value)
+}
+
+func (mapWriter *mapWriter) writeUnknowns(unknowns []*Unknown) {
+	for _, unknown := range unknowns {
+		mapWriter.write(unknown.Key, unknown.Value)
+	}
+}
+
+func (mapWriter *mapWriter) writeBip32Derivations(keyType byte, derivations []*Bip32Derivation) {
+	for _, derivation := range derivations {
+		value := make([]byte, 4*(len(derivation.Path)+1))
+		binary.LittleEndian.PutUint32(value, derivation.MasterKeyFingerprint)
+		for index, child := range derivation.Path {
+			binary.LittleEndian.PutUint32(value[4*(index+1):], child)
+		}
+		mapWriter.write(append([]byte{keyType}, derivation.PubKey...), value)
+	}
+}
+
+// end writes the separator which terminates a map.
+func (mapWriter *mapWriter) end() {
+	mapWriter.writeBytes(nil)
+}
@@ -0,0 +1,204 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package psbt_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/stretchr/testify/require"
+)
+
+// serializedPacket was created with github.com/btcsuite/btcd/btcutil/psbt from the packet returned
+// by newTestPacket.
+const serializedPacket = "70736274ff01007b020000000223153498c48d31ca4be5bfa3541e3fd9ea4f65b099e7cb816364a70471" +
+	"7830fe0000000000ffffffff3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d03" +
+	"00000000fdffffff01905f0100000000001600140102030405060708090a0b0c0d0e0f1011121314c027090000" +
+	"0100560200000001ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb0100000001" +
+	"51ffffffff01a0860100000000001976a9140102030405060708090a0b0c0d0e0f101112131488ac0000000022" +
+	"0202111111111111111111111111111111111111111111111111111111111111111104300102010103040100" +
+	"0000220602111111111111111111111111111111111111111111111111111111111111111118123456782c00" +
+	"0080000000800000008000000000050000000001012050c300000000000017a9140102030405060708090a0b0c" +
+	"0d0e0f1011121314870108060201aa02bbcc02fc0101420022020211111111111111111111111111111111111111" +
+	"111111111111111111111111111812345678540000800000008000000080010000000200000000"
+
+func newTestPacket(t *testing.T) *psbt.Packet {
+	t.Helper()
+	previousTx := wire.NewMsgTx(2)
+	previousTx.AddTxIn(wire.NewTxIn(
+		&wire.OutPoint{Hash: chainhash.HashH([]byte("a")), Index: 1}, []byte{txscript.OP_TRUE}, nil))
+	previousTx.AddTxOut(wire.NewTxOut(100000, mustDecodeHex(t,
+		"76a9140102030405060708090a0b0c0d0e0f101112131488ac")))
+
+	transaction := wire.NewMsgTx(2)
+	transaction.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: previousTx.TxHash(), Index: 0}, nil, nil))
+	transaction.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.HashH([]byte("b")), Index: 3}, nil, nil))
+	transaction.TxIn[1].Sequence = 0xfffffffd
+	transaction.AddTxOut(wire.NewTxOut(90000, mustDecodeHex(t,
+		"00140102030405060708090a0b0c0d0e0f1011121314")))
+	transaction.LockTime = 600000
+
+	packet, err := psbt.New(transaction)
+	require.NoError(t, err)
+	pubKey := append([]byte{0x02}, bytes.Repeat([]byte{0x11}, 32)...)
+	packet.Inputs[0].NonWitnessUtxo = previousTx
+	packet.Inputs[0].SighashType = txscript.SigHashAll
+	packet.Inputs[0].PartialSigs = []*psbt.PartialSig{
+		{PubKey: pubKey, Signature: []byte{0x30, 0x01, 0x02, 0x01}},
+	}
+	packet.Inputs[0].Bip32Derivation = []*psbt.Bip32Derivation{{
+		PubKey:               pubKey,
+		MasterKeyFingerprint: 0x78563412,
+		Path:                 []uint32{0x8000002c, 0x80000000, 0x80000000, 0, 5},
+	}}
+	packet.Inputs[1].WitnessUtxo = wire.NewTxOut(50000, mustDecodeHex(t,
+		"a9140102030405060708090a0b0c0d0e0f101112131487"))
+	packet.Inputs[1].FinalScriptWitness = wire.TxWitness{{0xaa}, {0xbb, 0xcc}}
+	packet.Inputs[1].Unknowns = []*psbt.Unknown{{Key: []byte{0xfc, 0x01}, Value: []byte{0x42}}}
+	packet.Outputs[0].Bip32Derivation = []*psbt.Bip32Derivation{{
+		PubKey:               pubKey,
+		MasterKeyFingerprint: 0x78563412,
+		Path:                 []uint32{0x80000054, 0x80000000, 0x80000000, 1, 2},
+	}}
+	return packet
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	decoded, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return decoded
+}
+
+func TestSerialize(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, newTestPacket(t).Serialize(&buffer))
+	require.Equal(t, serializedPacket, hex.EncodeToString(buffer.Bytes()))
+}
+
+func TestParse(t *testing.T) {
+	packet, err := psbt.Parse(bytes.NewReader(mustDecodeHex(t, serializedPacket)))
+	require.NoError(t, err)
+	expected := newTestPacket(t)
+	require.Equal(t, expected.UnsignedTx.TxHash(), packet.UnsignedTx.TxHash())
+	require.Equal(t, expected.Inputs[0].NonWitnessUtxo.TxHash(), packet.Inputs[0].NonWitnessUtxo.TxHash())
+	require.Equal(t, expected.Inputs[0].PartialSigs, packet.Inputs[0].PartialSigs)
+	require.Equal(t, expected.Inputs[0].SighashType, packet.Inputs[0].SighashType)
+	require.Equal(t, expected.Inputs[0].Bip32Derivation, packet.Inputs[0].Bip32Derivation)
+	require.Equal(t, expected.Inputs[1].WitnessUtxo, packet.Inputs[1].WitnessUtxo)
+	require.Equal(t, expected.Inputs[1].FinalScriptWitness, packet.Inputs[1].FinalScriptWitness)
+	require.Equal(t, expected.Inputs[1].Unknowns, packet.Inputs[1].Unknowns)
+	require.Equal(t, expected.Outputs[0].Bip32Derivation, packet.Outputs[0].Bip32Derivation)
+
+	encoded, err := packet.Base64()
+	require.NoError(t, err)
+	reparsed, err := psbt.ParseBase64(encoded)
+	require.NoError(t, err)
+	require.Equal(t, packet, reparsed)
+}
+
+func TestParseInvalid(t *testing.T) {
+	valid := mustDecodeHex(t, serializedPacket)
+	// The unsigned transaction is the first key-value pair: key length, key, value length and the
+	// 123 bytes of the transaction.
+	globalEnd := 5 + 3 + 123
+	for name, invalid := range map[string][]byte{
+		"magic":     append([]byte{0x70, 0x73, 0x62, 0x74, 0x00}, valid[5:]...),
+		"truncated": valid[:len(valid)-1],
+		// The key of the unsigned transaction appears twice in the global map.
+		"duplicate key": append(append(append([]byte{}, valid[:globalEnd]...), valid[5:globalEnd]...),
+			valid[globalEnd:]...),
+	} {
+		_, err := psbt.Parse(bytes.NewReader(invalid))
+		require.Error(t, err, name)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	binary := mustDecodeHex(t, serializedPacket)
+	packet, err := psbt.Parse(bytes.NewReader(binary))
+	require.NoError(t, err)
+	serialized, err := packet.Bytes()
+	require.NoError(t, err)
+	require.Equal(t, binary, serialized)
+
+	encoded, err := packet.Base64()
+	require.NoError(t, err)
+	for name, data := range map[string][]byte{
+		"binary": binary,
+		"base64": []byte(encoded + "\n"),
+		"hex":    []byte(serializedPacket + "\n"),
+	} {
+		parsed, err := psbt.ParseFile(data)
+		require.NoError(t, err, name)
+		require.Equal(t, packet, parsed, name)
+	}
+	_, err = psbt.ParseFile([]byte("not a psbt"))
+	require.Error(t, err)
+}
+
+func TestNewSigned(t *testing.T) {
+	transaction := wire.NewMsgTx(2)
+	transaction.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, []byte{txscript.OP_TRUE}, nil))
+	_, err := psbt.New(transaction)
+	require.Error(t, err)
+}
+
+func TestSpentOutput(t *testing.T) {
+	packet := newTestPacket(t)
+	spentOutput, err := packet.SpentOutput(0)
+	require.NoError(t, err)
+	require.Equal(t, packet.Inputs[0].NonWitnessUtxo.TxOut[0], spentOutput)
+	spentOutput, err = packet.SpentOutput(1)
+	require.NoError(t, err)
+	require.Equal(t, packet.Inputs[1].WitnessUtxo, spentOutput)
+
+	// The previous transaction has to match the outpoint.
+	packet.Inputs[0].NonWitnessUtxo.LockTime++
+	_, err = packet.SpentOutput(0)
+	require.Error(t, err)
+
+	packet.Inputs[1].WitnessUtxo = nil
+	_, err = packet.SpentOutput(1)
+	require.Error(t, err)
+}
+
+func TestCombine(t *testing.T) {
+	packet := newTestPacket(t)
+	other := newTestPacket(t)
+	otherPubKey := append([]byte{0x03}, bytes.Repeat([]byte{0x22}, 32)...)
+	otherSig := &psbt.PartialSig{PubKey: otherPubKey, Signature: []byte{0x30, 0x03, 0x04, 0x01}}
+	other.Inputs[0].PartialSigs = append(other.Inputs[0].PartialSigs, otherSig)
+	other.Inputs[1].WitnessScript = []byte{txscript.OP_TRUE}
+	packet.Inputs[1].WitnessUtxo = nil
+
+	require.NoError(t, packet.Combine(other))
+	require.Len(t, packet.Inputs[0].PartialSigs, 2)
+	require.Equal(t, otherSig, packet.Inputs[0].PartialSigs[1])
+	require.Len(t, packet.Inputs[0].Bip32Derivation, 1)
+	require.Equal(t, []byte{txscript.OP_TRUE}, packet.Inputs[1].WitnessScript)
+	require.Equal(t, other.Inputs[1].WitnessUtxo, packet.Inputs[1].WitnessUtxo)
+	require.Len(t, packet.Inputs[1].Unknowns, 1)
+
+	// Only packets of the same transaction can be combined.
+	other = newTestPacket(t)
+	other.UnsignedTx.LockTime++
+	require.Error(t, packet.Combine(other))
+}
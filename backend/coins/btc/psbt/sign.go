@@ -0,0 +1,107 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package psbt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// MasterKeyFingerprint returns the fingerprint of the given master public key, which identifies
+// the signer in the BIP32 derivations of a PSBT.
+func MasterKeyFingerprint(publicKey *btcec.PublicKey) uint32 {
+	return binary.LittleEndian.Uint32(btcutil.Hash160(publicKey.SerializeCompressed())[:4])
+}
+
+// Finalized returns whether the input already contains its final signature script or witness, in
+// which case it must not be signed anymore.
+func (input *Input) Finalized() bool {
+	return len(input.FinalScriptSig) != 0 || len(input.FinalScriptWitness) != 0
+}
+
+// InputSigHashType returns the sighash type with which the input at the given index is signed,
+// which is SigHashAll if the PSBT does not specify it.
+func (packet *Packet) InputSigHashType(index int) txscript.SigHashType {
+	if sigHashType := packet.Inputs[index].SighashType; sigHashType != 0 {
+		return sigHashType
+	}
+	return txscript.SigHashAll
+}
+
+// SignatureHash returns the hash which has to be signed for the input at the given index. The
+// redeem and witness scripts of the input need to match the spent output. Taproot inputs are not
+// supported. Only SIGHASH_ALL, optionally with SIGHASH_ANYONECANPAY, is accepted, as the other
+// sighash types of a PSBT from an untrusted source would let anyone change the outputs after
+// signing.
+func (packet *Packet) SignatureHash(index int) ([]byte, error) {
+	input := packet.Inputs[index]
+	if sigHashType := packet.InputSigHashType(index); sigHashType != txscript.SigHashAll &&
+		sigHashType != txscript.SigHashAll|txscript.SigHashAnyOneCanPay {
+		return nil, errp.Newf("Input %d cannot be signed with the sighash type 0x%02x.",
+			index, uint32(sigHashType))
+	}
+	spentOutput, err := packet.SpentOutput(index)
+	if err != nil {
+		return nil, err
+	}
+	script := spentOutput.PkScript
+	if txscript.IsPayToScriptHash(script) {
+		if len(input.RedeemScript) == 0 ||
+			!bytes.Equal(script[2:22], btcutil.Hash160(input.RedeemScript)) {
+			return nil, errp.Newf("The redeem script of input %d does not match.", index)
+		}
+		script = input.RedeemScript
+	}
+	switch {
+	case taproot.IsPayToTaproot(script):
+		return nil, errp.Newf("Signing the taproot input %d of a PSBT is not supported.", index)
+	case txscript.IsPayToWitnessScriptHash(script):
+		witnessScriptHash := sha256.Sum256(input.WitnessScript)
+		if len(input.WitnessScript) == 0 || !bytes.Equal(script[2:], witnessScriptHash[:]) {
+			return nil, errp.Newf("The witness script of input %d does not match.", index)
+		}
+		return packet.witnessSignatureHash(index, input.WitnessScript, spentOutput.Value)
+	case txscript.IsPayToWitnessPubKeyHash(script):
+		return packet.witnessSignatureHash(index, script, spentOutput.Value)
+	default:
+		// Without the previous transaction, the spent amount of a legacy input cannot be verified.
+		if input.NonWitnessUtxo == nil {
+			return nil, errp.Newf("The transaction spent by input %d is missing.", index)
+		}
+		signatureHash, err := txscript.CalcSignatureHash(
+			script, packet.InputSigHashType(index), packet.UnsignedTx, index)
+		if err != nil {
+			return nil, errp.Wrap(err, "Failed to calculate legacy signature hash")
+		}
+		return signatureHash, nil
+	}
+}
+
+// witnessSignatureHash returns the BIP143 signature hash of the input at the given index.
+func (packet *Packet) witnessSignatureHash(index int, script []byte, amount int64) ([]byte, error) {
+	signatureHash, err := txscript.CalcWitnessSigHash(script, txscript.NewTxSigHashes(packet.UnsignedTx),
+		packet.InputSigHashType(index), packet.UnsignedTx, index, amount)
+	if err != nil {
+		return nil, errp.Wrap(err, "Failed to calculate SegWit signature hash")
+	}
+	return signatureHash, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// ExportPSBTFile creates a tx like ExportPSBT and returns it as the contents of a PSBT file
+// together with its file name, e.g. to sign it with an air-gapped signer through an SD card. The
+// PSBT is kept as the pending proposal, against which the signed PSBT loaded with ImportPSBTFile
+// is validated.
+func (account *Account) ExportPSBTFile(args *TxProposalArgs) (string, []byte, error) {
+	packet, err := account.ExportPSBT(args)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := packet.Bytes()
+	if err != nil {
+		return "", nil, err
+	}
+	defer account.pendingPSBTLock.Lock()()
+	account.pendingPSBT = data
+	txID := packet.UnsignedTx.TxHash().String()
+	return fmt.Sprintf("%s-%s.psbt", account.code, txID[:8]), data, nil
+}
+
+// ImportPSBTFile loads the contents of a PSBT file signed by an external signer. The PSBT has to
+// be for the transaction of the pending proposal exported with ExportPSBTFile. Only the
+// signatures are taken from the loaded PSBT, the spent outputs and scripts are the ones of the
+// proposal. The signatures are verified and added to the pending proposal, so that the files of
+// several cosigners can be loaded one after the other. The combined PSBT is returned together
+// with its cosigning status. Once the status is complete, the PSBT can be sent with SendPSBT.
+func (account *Account) ImportPSBTFile(data []byte) (*psbt.Packet, *CosigningStatus, error) {
+	account.log.Info("Importing signed PSBT file")
+	signedPacket, err := psbt.ParseFile(data)
+	if err != nil {
+		return nil, nil, errp.WithMessage(err, "Failed to parse the PSBT file")
+	}
+	defer account.pendingPSBTLock.Lock()()
+	if account.pendingPSBT == nil {
+		return nil, nil, errp.New("No transaction has been exported to a PSBT file.")
+	}
+	packet, err := psbt.Parse(bytes.NewReader(account.pendingPSBT))
+	if err != nil {
+		return nil, nil, err
+	}
+	if signedPacket.UnsignedTx.TxHash() != packet.UnsignedTx.TxHash() {
+		return nil, nil, errp.New("The PSBT does not match the exported transaction.")
+	}
+	for index, input := range signedPacket.Inputs {
+		if input.SighashType != 0 && input.SighashType != packet.InputSigHashType(index) {
+			return nil, nil, errp.Newf("The PSBT signs input %d with a different sighash type.", index)
+		}
+	}
+	if err := packet.Combine(signedPacket); err != nil {
+		return nil, nil, err
+	}
+	proposedTransaction, signatureHashes, err := account.proposedTransactionFromPSBT(packet)
+	if err != nil {
+		return nil, nil, err
+	}
+	for index, signatures := range proposedTransaction.Signatures {
+		publicKeys := signatureHashes[index].Address.Configuration.PublicKeys()
+		for cosignerIndex, signature := range signatures {
+			if signature != nil && !signature.Verify(signatureHashes[index].Hash, publicKeys[cosignerIndex]) {
+				return nil, nil, errp.Newf("The signature of input %d is invalid.", index)
+			}
+		}
+	}
+	combined, err := packet.Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	account.pendingPSBT = combined
+	return packet, proposedTransaction.CosigningStatus(), nil
+}
+
+// clearPendingPSBT forgets the pending proposal of ExportPSBTFile if it is for the transaction of
+// the given PSBT.
+func (account *Account) clearPendingPSBT(packet *psbt.Packet) {
+	defer account.pendingPSBTLock.Lock()()
+	if account.pendingPSBT == nil {
+		return
+	}
+	pending, err := psbt.Parse(bytes.NewReader(account.pendingPSBT))
+	if err != nil || pending.UnsignedTx.TxHash() == packet.UnsignedTx.TxHash() {
+		account.pendingPSBT = nil
+	}
+}
@@ -15,15 +15,23 @@
 package btc
 
 import (
+	"bytes"
+	"runtime"
+	"sync"
+
 	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/btcsuite/btcutil/txsort"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/sirupsen/logrus"
 )
@@ -33,47 +41,487 @@ type ProposedTransaction struct {
 	TXProposal      *maketx.TxProposal
 	PreviousOutputs map[wire.OutPoint]*transactions.SpendableOutput
 	GetAddress      func(blockchain.ScriptHashHex) *addresses.AccountAddress
+	// GetPreviousTransaction returns the transaction with the given hash, which contains an output
+	// spent by the transaction, or nil if it is unknown. Keystores which sign PSBTs need it to
+	// verify the spent amounts. It can be nil.
+	GetPreviousTransaction func(chainhash.Hash) *wire.MsgTx
 	// Signatures collects the signatures (signatures[transactionInput][cosignerIndex]).
 	Signatures [][]*btcec.Signature
 	SigHashes  *txscript.TxSigHashes
+	// TaprootSigHashes commit to all spent outputs and are needed to sign taproot inputs.
+	TaprootSigHashes *taproot.SigHashes
+	// SigHashType contains the sighash type of each input. If empty, all inputs are signed with
+	// the default sighash type of their script type.
+	SigHashType []txscript.SigHashType
+	// ExternalInputs are the inputs which are signed by another party, e.g. by the receiver of a
+	// payjoin. They have no signature hash and are not signed by the keystores.
+	ExternalInputs map[wire.OutPoint]struct{}
+	// OnProgress is called with the progress of the signing. It can be nil.
+	OnProgress func(SignProgress)
+
+	// signatureHashes are computed once by SignatureHashes, as they are needed before and by every
+	// keystore.
+	signatureHashes []*InputSignatureHash
 }
 
-// SignTransaction signs all inputs. It assumes all outputs spent belong to this
-// wallet. previousOutputs must contain all outputs which are spent by the transaction.
-func SignTransaction(
-	keystores keystore.Keystores,
+// IsExternalInput returns whether the input at the given index is signed by another party.
+func (proposedTransaction *ProposedTransaction) IsExternalInput(index int) bool {
+	outPoint := proposedTransaction.TXProposal.Transaction.TxIn[index].PreviousOutPoint
+	_, ok := proposedTransaction.ExternalInputs[outPoint]
+	return ok
+}
+
+// ReportProgress reports the given progress of the signing, see OnProgress.
+func (proposedTransaction *ProposedTransaction) ReportProgress(progress SignProgress) {
+	if proposedTransaction.OnProgress != nil {
+		proposedTransaction.OnProgress(progress)
+	}
+}
+
+// InputSigHashType returns the sighash type with which the input at the given index is signed.
+// defaultType is returned if no sighash types were specified.
+func (proposedTransaction *ProposedTransaction) InputSigHashType(
+	index int, defaultType txscript.SigHashType) txscript.SigHashType {
+	if len(proposedTransaction.SigHashType) == 0 {
+		return defaultType
+	}
+	return proposedTransaction.SigHashType[index]
+}
+
+// PreviousOutput returns the output spent by the given outpoint, or nil if it is not part of the
+// transaction.
+func (proposedTransaction *ProposedTransaction) PreviousOutput(outPoint wire.OutPoint) *wire.TxOut {
+	spentOutput, ok := proposedTransaction.PreviousOutputs[outPoint]
+	if !ok {
+		return nil
+	}
+	return spentOutput.TxOut
+}
+
+// HasSigned returns whether the cosigner at the given index has signed all inputs which are signed
+// by the keystores. It implements keystore.CosignedTransaction, so that the keystores of cosigners
+// whose signatures were already collected, e.g. from a PSBT, are skipped.
+func (proposedTransaction *ProposedTransaction) HasSigned(cosignerIndex int) bool {
+	signed := false
+	for _, signatures := range proposedTransaction.Signatures {
+		if len(signatures) == 0 {
+			// External input or an input which is not signed by the keystores.
+			continue
+		}
+		if cosignerIndex >= len(signatures) || signatures[cosignerIndex] == nil {
+			return false
+		}
+		signed = true
+	}
+	return signed
+}
+
+// CosigningStatus describes which cosigners have signed a transaction.
+type CosigningStatus struct {
+	// Signed contains for each cosigner whether it has signed all inputs.
+	Signed []bool
+	// SigningThreshold is the number of signatures required per input.
+	SigningThreshold int
+	// Complete is true if all inputs have enough signatures to be finalized.
+	Complete bool
+}
+
+// CosigningStatus returns which cosigners have signed the transaction according to Signatures.
+func (proposedTransaction *ProposedTransaction) CosigningStatus() *CosigningStatus {
+	status := &CosigningStatus{Signed: []bool{}, Complete: true}
+	for index, txIn := range proposedTransaction.TXProposal.Transaction.TxIn {
+		spentOutput, ok := proposedTransaction.PreviousOutputs[txIn.PreviousOutPoint]
+		if !ok || proposedTransaction.IsExternalInput(index) {
+			continue
+		}
+		address := proposedTransaction.GetAddress(spentOutput.ScriptHashHex())
+		if address == nil {
+			continue
+		}
+		if threshold := address.Configuration.SigningThreshold(); threshold > status.SigningThreshold {
+			status.SigningThreshold = threshold
+		}
+		for len(status.Signed) < address.Configuration.NumberOfSigners() {
+			status.Signed = append(status.Signed, true)
+		}
+		signatureCount := 0
+		for cosignerIndex := range status.Signed {
+			if cosignerIndex < len(proposedTransaction.Signatures[index]) &&
+				proposedTransaction.Signatures[index][cosignerIndex] != nil {
+				signatureCount++
+			} else {
+				status.Signed[cosignerIndex] = false
+			}
+		}
+		if signatureCount < address.Configuration.SigningThreshold() {
+			status.Complete = false
+		}
+	}
+	return status
+}
+
+// InputSignatureHash contains the hash to be signed for one input of a transaction.
+type InputSignatureHash struct {
+	Hash []byte
+	// Address is the address of the spent output, its configuration contains the signing keypath.
+	Address *addresses.AccountAddress
+	// SubScript is the script committed to by the hash.
+	SubScript []byte
+	// SighashVersion is the version of the hash, which also determines the signature scheme.
+	SighashVersion addresses.SighashVersion
+}
+
+// SignatureHashes computes the hashes to be signed for all inputs. The entries of external inputs
+// are nil. It returns an error if the proposal is malformed, e.g. if a spent output or its address
+// is unknown. The hashes are computed concurrently, as legacy signature hashes take quadratic time
+// in the number of inputs, and only once, so the transaction must not be changed afterwards.
+func (proposedTransaction *ProposedTransaction) SignatureHashes() ([]*InputSignatureHash, error) {
+	if proposedTransaction.signatureHashes != nil {
+		return proposedTransaction.signatureHashes, nil
+	}
+	signatureHashes, err := proposedTransaction.computeSignatureHashes(runtime.NumCPU())
+	if err != nil {
+		return nil, err
+	}
+	proposedTransaction.signatureHashes = signatureHashes
+	return signatureHashes, nil
+}
+
+// computeSignatureHashes computes the signature hashes with the given number of workers.
+func (proposedTransaction *ProposedTransaction) computeSignatureHashes(
+	workers int) ([]*InputSignatureHash, error) {
+	transaction := proposedTransaction.TXProposal.Transaction
+	signatureHashes := make([]*InputSignatureHash, len(transaction.TxIn))
+	// The addresses are looked up serially, as GetAddress is not required to be safe for concurrent
+	// use.
+	for index, txIn := range transaction.TxIn {
+		spentOutput, ok := proposedTransaction.PreviousOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			return nil, errp.Newf("The output spent by input %d is missing.", index)
+		}
+		if proposedTransaction.IsExternalInput(index) {
+			continue
+		}
+		address := proposedTransaction.GetAddress(spentOutput.ScriptHashHex())
+		if address == nil {
+			return nil, errp.Newf("The output spent by input %d does not belong to the account.", index)
+		}
+		sighashVersion, subScript := address.ScriptForHashToSign()
+		signatureHashes[index] = &InputSignatureHash{
+			Address:        address,
+			SubScript:      subScript,
+			SighashVersion: sighashVersion,
+		}
+	}
+
+	if workers > len(signatureHashes) {
+		workers = len(signatureHashes)
+	}
+	errs := make([]error, len(signatureHashes))
+	indices := make(chan int)
+	var wait sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for index := range indices {
+				signatureHashes[index].Hash, errs[index] = proposedTransaction.signatureHash(
+					index, signatureHashes[index].SighashVersion, signatureHashes[index].SubScript)
+			}
+		}()
+	}
+	for index := range signatureHashes {
+		if signatureHashes[index] != nil {
+			indices <- index
+		}
+	}
+	close(indices)
+	wait.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return signatureHashes, nil
+}
+
+// signatureHash computes the hash to be signed for the input at the given index. It only reads
+// the transaction and can be called concurrently.
+func (proposedTransaction *ProposedTransaction) signatureHash(
+	index int, sighashVersion addresses.SighashVersion, subScript []byte) ([]byte, error) {
+	transaction := proposedTransaction.TXProposal.Transaction
+	spentOutput := proposedTransaction.PreviousOutputs[transaction.TxIn[index].PreviousOutPoint]
+	switch sighashVersion {
+	case addresses.SighashVersionTaproot:
+		signatureHash, err := taproot.CalcSignatureHash(proposedTransaction.TaprootSigHashes,
+			proposedTransaction.InputSigHashType(index, taproot.SigHashDefault),
+			transaction, index, spentOutput.TxOut)
+		if err != nil {
+			return nil, errp.Wrap(err, "Failed to calculate taproot signature hash")
+		}
+		return signatureHash, nil
+	case addresses.SighashVersionSegwitV0:
+		signatureHash, err := txscript.CalcWitnessSigHash(subScript, proposedTransaction.SigHashes,
+			proposedTransaction.InputSigHashType(index, txscript.SigHashAll),
+			transaction, index, spentOutput.Value)
+		if err != nil {
+			return nil, errp.Wrap(err, "Failed to calculate SegWit signature hash")
+		}
+		return signatureHash, nil
+	default:
+		signatureHash, err := txscript.CalcSignatureHash(subScript,
+			proposedTransaction.InputSigHashType(index, txscript.SigHashAll), transaction, index)
+		if err != nil {
+			return nil, errp.Wrap(err, "Failed to calculate legacy signature hash")
+		}
+		return signatureHash, nil
+	}
+}
+
+// CheckCanSign returns an error if the keystore cannot sign one of the inputs.
+func (proposedTransaction *ProposedTransaction) CheckCanSign(keystore keystore.Keystore) error {
+	for index, txIn := range proposedTransaction.TXProposal.Transaction.TxIn {
+		spentOutput, ok := proposedTransaction.PreviousOutputs[txIn.PreviousOutPoint]
+		if !ok {
+			continue
+		}
+		address := proposedTransaction.GetAddress(spentOutput.ScriptHashHex())
+		if address == nil || address.Configuration.Multisig() {
+			continue
+		}
+		scriptType := address.Configuration.ScriptType()
+		if !keystore.SupportsScriptType(proposedTransaction.TXProposal.Coin, scriptType) {
+			return errp.Newf("The keystore cannot sign input %d of script type %s.", index, scriptType)
+		}
+	}
+	return nil
+}
+
+// changeChainIndex is the index of the address chain of the change addresses of an account.
+const changeChainIndex = 1
+
+// verifyChangeAddress returns an error if the change address of the proposal does not derive from
+// the account configuration at its keypath on the change chain. The keystores trust the change
+// address to recognize the change output, so an address which is not actually ours, e.g. from a
+// tampered PSBT or another process, would make them hide a payment to a third party.
+func verifyChangeAddress(txProposal *maketx.TxProposal, log *logrus.Entry) error {
+	changeAddress := txProposal.ChangeAddress
+	if changeAddress == nil {
+		return nil
+	}
+	accountConfiguration := txProposal.AccountConfiguration
+	if accountConfiguration == nil {
+		return errp.New("The change address cannot be verified without the account configuration.")
+	}
+	btcCoin, ok := txProposal.Coin.(*Coin)
+	if !ok {
+		return errp.New("The change address can only be verified for bitcoin-based coins.")
+	}
+	accountKeypath := accountConfiguration.AbsoluteKeypath().ToUInt32()
+	changeKeypath := changeAddress.Configuration.AbsoluteKeypath().ToUInt32()
+	if len(changeKeypath) != len(accountKeypath)+2 {
+		return errp.New("The keypath of the change address does not belong to the account.")
+	}
+	for index, childIndex := range accountKeypath {
+		if changeKeypath[index] != childIndex {
+			return errp.New("The keypath of the change address does not belong to the account.")
+		}
+	}
+	chainIndex, addressIndex := changeKeypath[len(accountKeypath)], changeKeypath[len(accountKeypath)+1]
+	if chainIndex != changeChainIndex || addressIndex >= hdkeychain.HardenedKeyStart {
+		return errp.New("The keypath of the change address is not on the change chain.")
+	}
+	configuration, err := accountConfiguration.Derive(signing.NewEmptyRelativeKeypath().
+		Child(chainIndex, signing.NonHardened).Child(addressIndex, signing.NonHardened))
+	if err != nil {
+		return err
+	}
+	expected := addresses.NewAccountAddress(configuration, btcCoin.Net(), log)
+	publicKeys := changeAddress.Configuration.PublicKeys()
+	if len(publicKeys) != len(configuration.PublicKeys()) {
+		return errp.New("The change address does not belong to the account.")
+	}
+	for index, publicKey := range configuration.PublicKeys() {
+		if !publicKey.IsEqual(publicKeys[index]) {
+			return errp.New("The change address does not belong to the account.")
+		}
+	}
+	if !bytes.Equal(expected.PubkeyScript(), changeAddress.PubkeyScript()) {
+		return errp.New("The change address does not belong to the account.")
+	}
+	return nil
+}
+
+// checkSigHashType returns an error if the input at the given index must not be signed with the
+// given sighash type. SigHashNone and undefined types would let anyone change the outputs after
+// signing, and SigHashSingle without an output at the index of the input signs the constant hash 1
+// in legacy inputs, which can spend the input to anyone.
+func checkSigHashType(
+	transaction *wire.MsgTx,
+	previousOutputs map[wire.OutPoint]*transactions.SpendableOutput,
+	index int,
+	sigHashType txscript.SigHashType,
+) error {
+	switch sigHashType &^ txscript.SigHashAnyOneCanPay {
+	case txscript.SigHashAll:
+		return nil
+	case txscript.SigHashSingle:
+		if index >= len(transaction.TxOut) {
+			return errp.Newf("Input %d cannot be signed with SIGHASH_SINGLE without an output at its index.", index)
+		}
+		return nil
+	case taproot.SigHashDefault:
+		spentOutput, ok := previousOutputs[transaction.TxIn[index].PreviousOutPoint]
+		if sigHashType == taproot.SigHashDefault && ok && taproot.IsPayToTaproot(spentOutput.PkScript) {
+			return nil
+		}
+	}
+	return errp.Newf("Input %d cannot be signed with the sighash type 0x%02x.", index, uint32(sigHashType))
+}
+
+func newProposedTransaction(
 	txProposal *maketx.TxProposal,
 	previousOutputs map[wire.OutPoint]*transactions.SpendableOutput,
 	getAddress func(blockchain.ScriptHashHex) *addresses.AccountAddress,
-	log *logrus.Entry,
-) error {
+	sigHashTypes []txscript.SigHashType,
+	externalInputs map[wire.OutPoint]struct{},
+) (*ProposedTransaction, error) {
+	if len(sigHashTypes) != 0 && len(sigHashTypes) != len(txProposal.Transaction.TxIn) {
+		return nil, errp.Newf("Expected %d sighash types, got %d.",
+			len(txProposal.Transaction.TxIn), len(sigHashTypes))
+	}
+	for index, sigHashType := range sigHashTypes {
+		if err := checkSigHashType(txProposal.Transaction, previousOutputs, index, sigHashType); err != nil {
+			return nil, err
+		}
+	}
 	proposedTransaction := &ProposedTransaction{
 		TXProposal:      txProposal,
 		PreviousOutputs: previousOutputs,
 		GetAddress:      getAddress,
 		Signatures:      make([][]*btcec.Signature, len(txProposal.Transaction.TxIn)),
 		SigHashes:       txscript.NewTxSigHashes(txProposal.Transaction),
+		SigHashType:     sigHashTypes,
+		ExternalInputs:  externalInputs,
+	}
+	taprootSigHashes, err := taproot.NewSigHashes(
+		txProposal.Transaction, proposedTransaction.PreviousOutput)
+	if err != nil {
+		return nil, err
+	}
+	proposedTransaction.TaprootSigHashes = taprootSigHashes
+	return proposedTransaction, nil
+}
+
+// DryRunSignTransaction validates the proposal like SignTransaction, including the computation of
+// all signature hashes, but does not involve the keystores. Use it to catch malformed proposals
+// before the user is asked to confirm on a device.
+func DryRunSignTransaction(
+	txProposal *maketx.TxProposal,
+	previousOutputs map[wire.OutPoint]*transactions.SpendableOutput,
+	getAddress func(blockchain.ScriptHashHex) *addresses.AccountAddress,
+	sigHashTypes []txscript.SigHashType,
+) error {
+	proposedTransaction, err := newProposedTransaction(
+		txProposal, previousOutputs, getAddress, sigHashTypes, nil)
+	if err != nil {
+		return err
+	}
+	_, err = proposedTransaction.SignatureHashes()
+	return err
+}
+
+// SignTransaction signs all inputs. It assumes all outputs spent belong to this
+// wallet. previousOutputs must contain all outputs which are spent by the transaction.
+// getPreviousTransaction returns the transactions containing the spent outputs, see
+// ProposedTransaction.GetPreviousTransaction, and can be nil. sigHashTypes optionally contains the
+// sighash type of each input (SigHashAll if nil). onProgress is called with the progress of the
+// signing and can be nil.
+func SignTransaction(
+	keystores keystore.Keystores,
+	txProposal *maketx.TxProposal,
+	previousOutputs map[wire.OutPoint]*transactions.SpendableOutput,
+	getAddress func(blockchain.ScriptHashHex) *addresses.AccountAddress,
+	getPreviousTransaction func(chainhash.Hash) *wire.MsgTx,
+	sigHashTypes []txscript.SigHashType,
+	onProgress func(SignProgress),
+	log *logrus.Entry,
+) error {
+	return signTransaction(keystores, txProposal, previousOutputs, getAddress, getPreviousTransaction,
+		sigHashTypes, nil, onProgress, log)
+}
+
+// signTransaction is like SignTransaction, but does not sign the given external inputs, which
+// must already contain their final signature script and witness. previousOutputs must contain the
+// outputs spent by the external inputs as well. The inputs do not need to be sorted according to
+// BIP69 if there are external inputs, as their position is chosen by the other party, or if the
+// transaction was shuffled, see maketx.TxOrderingRandom.
+func signTransaction(
+	keystores keystore.Keystores,
+	txProposal *maketx.TxProposal,
+	previousOutputs map[wire.OutPoint]*transactions.SpendableOutput,
+	getAddress func(blockchain.ScriptHashHex) *addresses.AccountAddress,
+	getPreviousTransaction func(chainhash.Hash) *wire.MsgTx,
+	sigHashTypes []txscript.SigHashType,
+	externalInputs map[wire.OutPoint]struct{},
+	onProgress func(SignProgress),
+	log *logrus.Entry,
+) error {
+	proposedTransaction, err := newProposedTransaction(
+		txProposal, previousOutputs, getAddress, sigHashTypes, externalInputs)
+	if err != nil {
+		return err
+	}
+	proposedTransaction.GetPreviousTransaction = getPreviousTransaction
+	proposedTransaction.OnProgress = onProgress
+	inputs := len(txProposal.Transaction.TxIn) - len(externalInputs)
+	proposedTransaction.ReportProgress(SignProgress{Stage: SignStageHashing, Inputs: inputs})
+	// Fail early, before any keystore is involved.
+	signatureHashes, err := proposedTransaction.SignatureHashes()
+	if err != nil {
+		return err
+	}
+	if err := verifyChangeAddress(txProposal, log); err != nil {
+		return err
 	}
 
-	for i := range proposedTransaction.Signatures {
-		// TODO: Replace count with configuration.NumberOfSigners()
-		proposedTransaction.Signatures[i] = make([]*btcec.Signature, keystores.Count())
+	for index, signatureHash := range signatureHashes {
+		if signatureHash == nil {
+			continue
+		}
+		configuration := signatureHash.Address.Configuration
+		if configuration.SigningThreshold() > keystores.Count() {
+			return errp.Newf("Input %d needs %d signatures, but only %d keystores can sign. "+
+				"Export the transaction as PSBT to collect the signatures of the cosigners.",
+				index, configuration.SigningThreshold(), keystores.Count())
+		}
+		proposedTransaction.Signatures[index] = make([]*btcec.Signature, configuration.NumberOfSigners())
 	}
 
+	proposedTransaction.ReportProgress(SignProgress{Stage: SignStageAwaitingConfirmation, Inputs: inputs})
 	if err := keystores.SignTransaction(proposedTransaction); err != nil {
 		return err
 	}
 
+	proposedTransaction.ReportProgress(SignProgress{Stage: SignStageFinalizing, Signatures: inputs, Inputs: inputs})
 	for index, input := range txProposal.Transaction.TxIn {
-		spentOutput := previousOutputs[input.PreviousOutPoint]
-		address := proposedTransaction.GetAddress(spentOutput.ScriptHashHex())
-		input.SignatureScript, input.Witness = address.SignatureScript(
-			proposedTransaction.Signatures[index])
+		signatureHash := signatureHashes[index]
+		if signatureHash == nil {
+			continue
+		}
+		defaultSigHashType := txscript.SigHashAll
+		if signatureHash.SighashVersion == addresses.SighashVersionTaproot {
+			defaultSigHashType = taproot.SigHashDefault
+		}
+		input.SignatureScript, input.Witness = signatureHash.Address.SignatureScript(
+			proposedTransaction.Signatures[index],
+			proposedTransaction.InputSigHashType(index, defaultSigHashType))
 	}
 
 	// Sanity check: see if the created transaction is valid.
+	checkSorted := len(externalInputs) == 0 && txProposal.Ordering != maketx.TxOrderingRandom
 	if err := txValidityCheck(txProposal.Transaction, previousOutputs,
-		proposedTransaction.SigHashes); err != nil {
+		proposedTransaction.SigHashes, checkSorted); err != nil {
 		log.WithError(err).Panic("Failed to pass transaction validity check.")
 	}
 
@@ -81,15 +529,35 @@ func SignTransaction(
 }
 
 func txValidityCheck(transaction *wire.MsgTx, previousOutputs map[wire.OutPoint]*transactions.SpendableOutput,
-	sigHashes *txscript.TxSigHashes) error {
-	if !txsort.IsSorted(transaction) {
+	sigHashes *txscript.TxSigHashes, checkSorted bool) error {
+	if checkSorted && !txsort.IsSorted(transaction) {
 		return errp.New("tx not bip69 conformant")
 	}
+	var taprootSigHashes *taproot.SigHashes
 	for index, txIn := range transaction.TxIn {
 		spentOutput, ok := previousOutputs[txIn.PreviousOutPoint]
 		if !ok {
 			return errp.New("There needs to be exactly one output being spent per input!")
 		}
+		if taproot.IsPayToTaproot(spentOutput.PkScript) {
+			// The script engine of our btcd version does not know about BIP341.
+			if taprootSigHashes == nil {
+				var err error
+				taprootSigHashes, err = taproot.NewSigHashes(transaction, func(outPoint wire.OutPoint) *wire.TxOut {
+					if previousOutput, ok := previousOutputs[outPoint]; ok {
+						return previousOutput.TxOut
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if err := taproot.VerifyKeyPathSpend(taprootSigHashes, transaction, index, spentOutput.TxOut); err != nil {
+				return err
+			}
+			continue
+		}
 		engine, err := txscript.NewEngine(spentOutput.PkScript, transaction, index,
 			txscript.StandardVerifyFlags, nil, sigHashes, spentOutput.Value)
 		if err != nil {
@@ -0,0 +1,139 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/txsort"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses/test"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProposedTransaction returns a proposed transaction with one input per given script type.
+func newTestProposedTransaction(t testing.TB, scriptTypes []signing.ScriptType) *ProposedTransaction {
+	transaction := wire.NewMsgTx(wire.TxVersion)
+	previousOutputs := map[wire.OutPoint]*transactions.SpendableOutput{}
+	accountAddresses := map[blockchain.ScriptHashHex]*addresses.AccountAddress{}
+	for index, scriptType := range scriptTypes {
+		address := test.GetAddress(scriptType)
+		accountAddresses[address.PubkeyScriptHashHex()] = address
+		outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte{byte(index), byte(index >> 8)}), Index: uint32(index)}
+		transaction.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+		previousOutputs[outPoint] = &transactions.SpendableOutput{
+			TxOut: wire.NewTxOut(int64(10000+index), address.PubkeyScript()),
+		}
+	}
+	transaction.AddTxOut(wire.NewTxOut(5000, test.GetAddress(signing.ScriptTypeP2WPKH).PubkeyScript()))
+	txsort.InPlaceSort(transaction)
+	proposedTransaction, err := newProposedTransaction(
+		&maketx.TxProposal{Transaction: transaction},
+		previousOutputs,
+		func(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
+			return accountAddresses[scriptHashHex]
+		},
+		nil,
+		nil,
+	)
+	require.NoError(t, err)
+	return proposedTransaction
+}
+
+func TestSignatureHashesParallel(t *testing.T) {
+	scriptTypes := []signing.ScriptType{}
+	for i := 0; i < 50; i++ {
+		scriptTypes = append(scriptTypes,
+			signing.ScriptTypeP2PKH,
+			signing.ScriptTypeP2WPKHP2SH,
+			signing.ScriptTypeP2WPKH,
+			signing.ScriptTypeP2TR,
+		)
+	}
+	proposedTransaction := newTestProposedTransaction(t, scriptTypes)
+	serial, err := proposedTransaction.computeSignatureHashes(1)
+	require.NoError(t, err)
+	for _, workers := range []int{2, runtime.NumCPU(), 2 * len(scriptTypes)} {
+		parallel, err := proposedTransaction.computeSignatureHashes(workers)
+		require.NoError(t, err)
+		require.Equal(t, serial, parallel)
+	}
+}
+
+func TestSignatureHashesExternalInputs(t *testing.T) {
+	proposedTransaction := newTestProposedTransaction(t, []signing.ScriptType{
+		signing.ScriptTypeP2WPKH, signing.ScriptTypeP2WPKHP2SH, signing.ScriptTypeP2PKH})
+	allHashes, err := proposedTransaction.computeSignatureHashes(1)
+	require.NoError(t, err)
+	transaction := proposedTransaction.TXProposal.Transaction
+	externalOutPoint := transaction.TxIn[1].PreviousOutPoint
+	proposedTransaction.ExternalInputs = map[wire.OutPoint]struct{}{externalOutPoint: {}}
+	// The output spent by an external input does not belong to the account.
+	getAddress := proposedTransaction.GetAddress
+	proposedTransaction.GetAddress = func(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
+		if scriptHashHex == proposedTransaction.PreviousOutputs[externalOutPoint].ScriptHashHex() {
+			return nil
+		}
+		return getAddress(scriptHashHex)
+	}
+	require.False(t, proposedTransaction.IsExternalInput(0))
+	require.True(t, proposedTransaction.IsExternalInput(1))
+	signatureHashes, err := proposedTransaction.SignatureHashes()
+	require.NoError(t, err)
+	require.Equal(t, []*InputSignatureHash{allHashes[0], nil, allHashes[2]}, signatureHashes)
+}
+
+func TestSignatureHashesComputedOnce(t *testing.T) {
+	proposedTransaction := newTestProposedTransaction(t, []signing.ScriptType{
+		signing.ScriptTypeP2WPKH, signing.ScriptTypeP2PKH})
+	signatureHashes, err := proposedTransaction.SignatureHashes()
+	require.NoError(t, err)
+	for _, signatureHash := range signatureHashes {
+		sighashVersion, _ := signatureHash.Address.ScriptForHashToSign()
+		require.Equal(t, sighashVersion, signatureHash.SighashVersion)
+	}
+	again, err := proposedTransaction.SignatureHashes()
+	require.NoError(t, err)
+	require.True(t, signatureHashes[0] == again[0] && signatureHashes[1] == again[1])
+}
+
+func benchmarkSignatureHashes(b *testing.B, workers int) {
+	scriptTypes := make([]signing.ScriptType, 500)
+	for index := range scriptTypes {
+		scriptTypes[index] = signing.ScriptTypeP2PKH
+	}
+	proposedTransaction := newTestProposedTransaction(b, scriptTypes)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := proposedTransaction.computeSignatureHashes(workers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSignatureHashesSerial(b *testing.B) {
+	benchmarkSignatureHashes(b, 1)
+}
+
+func BenchmarkSignatureHashesParallel(b *testing.B) {
+	benchmarkSignatureHashes(b, runtime.NumCPU())
+}
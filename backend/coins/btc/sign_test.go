@@ -0,0 +1,318 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/btcsuite/btcutil/txsort"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	keystoreMock "github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/mocks"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
+	"github.com/stretchr/testify/require"
+)
+
+type signTestFixture struct {
+	keystores       keystore.Keystores
+	txProposal      *maketx.TxProposal
+	previousOutputs map[wire.OutPoint]*transactions.SpendableOutput
+	getAddress      func(blockchain.ScriptHashHex) *addresses.AccountAddress
+}
+
+// newSignTestFixture creates an unsigned tx spending two p2wpkh outputs of a software keystore.
+func newSignTestFixture(t *testing.T) *signTestFixture {
+	t.Helper()
+	return newSignTestFixtureWithScriptType(t, signing.ScriptTypeP2WPKH, "m/84'/1'/0'")
+}
+
+// newSignTestFixtureWithScriptType is like newSignTestFixture, but the spent outputs have the given
+// script type and belong to the account at the given keypath.
+func newSignTestFixtureWithScriptType(
+	t *testing.T, scriptType signing.ScriptType, accountKeypath string) *signTestFixture {
+	t.Helper()
+	log := logging.Get().WithGroup("sign_test")
+	softwareKeystore := software.NewKeystoreFromPIN(0, "1234")
+	keypath, err := signing.NewAbsoluteKeypath(accountKeypath)
+	require.NoError(t, err)
+	xpub, err := softwareKeystore.ExtendedPublicKey(keypath)
+	require.NoError(t, err)
+	accountConfiguration := signing.NewSinglesigConfiguration(scriptType, keypath, xpub)
+	relativeKeypath, err := signing.NewRelativeKeypath("0/0")
+	require.NoError(t, err)
+	addressConfiguration, err := accountConfiguration.Derive(relativeKeypath)
+	require.NoError(t, err)
+	address := addresses.NewAccountAddress(addressConfiguration, &chaincfg.TestNet3Params, log)
+
+	transaction := wire.NewMsgTx(wire.TxVersion)
+	previousOutputs := map[wire.OutPoint]*transactions.SpendableOutput{}
+	for i, value := range []int64{100000, 200000} {
+		outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte{byte(i)}), Index: uint32(i)}
+		transaction.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+		previousOutputs[outPoint] = &transactions.SpendableOutput{
+			TxOut: wire.NewTxOut(value, address.PubkeyScript()),
+		}
+	}
+	transaction.AddTxOut(wire.NewTxOut(250000, address.PubkeyScript()))
+	txsort.InPlaceSort(transaction)
+
+	return &signTestFixture{
+		keystores: keystore.NewKeystores(softwareKeystore),
+		txProposal: &maketx.TxProposal{
+			Coin:                 btc.NewCoin("tbtc", "TBTC", &chaincfg.TestNet3Params, ".", nil, ""),
+			AccountConfiguration: accountConfiguration,
+			Transaction:          transaction,
+		},
+		previousOutputs: previousOutputs,
+		getAddress: func(blockchain.ScriptHashHex) *addresses.AccountAddress {
+			return address
+		},
+	}
+}
+
+func (fixture *signTestFixture) sign(sigHashTypes []txscript.SigHashType) error {
+	return btc.SignTransaction(fixture.keystores, fixture.txProposal, fixture.previousOutputs,
+		fixture.getAddress, nil, sigHashTypes, nil, logging.Get().WithGroup("sign_test"))
+}
+
+// witnessSigHashType returns the sighash type appended to the signature of the input.
+func (fixture *signTestFixture) witnessSigHashType(index int) txscript.SigHashType {
+	signature := fixture.txProposal.Transaction.TxIn[index].Witness[0]
+	return txscript.SigHashType(signature[len(signature)-1])
+}
+
+func TestSignTransactionDefaultSigHashType(t *testing.T) {
+	fixture := newSignTestFixture(t)
+	require.NoError(t, fixture.sign(nil))
+	for index := range fixture.txProposal.Transaction.TxIn {
+		require.Equal(t, txscript.SigHashAll, fixture.witnessSigHashType(index))
+	}
+}
+
+func TestSignTransactionAnyoneCanPay(t *testing.T) {
+	fixture := newSignTestFixture(t)
+	// The validity check of the signed transaction would panic if the sighashes were wrong.
+	require.NoError(t, fixture.sign([]txscript.SigHashType{
+		txscript.SigHashAll,
+		txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+	}))
+	require.Equal(t, txscript.SigHashAll, fixture.witnessSigHashType(0))
+	require.Equal(t, txscript.SigHashAll|txscript.SigHashAnyOneCanPay, fixture.witnessSigHashType(1))
+}
+
+func TestSignTransactionProgress(t *testing.T) {
+	fixture := newSignTestFixture(t)
+	progress := []btc.SignProgress{}
+	require.NoError(t, btc.SignTransaction(fixture.keystores, fixture.txProposal,
+		fixture.previousOutputs, fixture.getAddress, nil, nil,
+		func(signProgress btc.SignProgress) { progress = append(progress, signProgress) },
+		logging.Get().WithGroup("sign_test")))
+	require.Equal(t, []btc.SignProgress{
+		{Stage: btc.SignStageHashing, Inputs: 2},
+		{Stage: btc.SignStageAwaitingConfirmation, Inputs: 2},
+		{Stage: btc.SignStageReceivingSignatures, Signatures: 2, Inputs: 2},
+		{Stage: btc.SignStageFinalizing, Signatures: 2, Inputs: 2},
+	}, progress)
+}
+
+func TestSignTransactionSigHashTypeCount(t *testing.T) {
+	fixture := newSignTestFixture(t)
+	require.Error(t, fixture.sign([]txscript.SigHashType{txscript.SigHashAll}))
+}
+
+func TestSignTransactionSigHashTypeNotAllowed(t *testing.T) {
+	for name, sigHashTypes := range map[string][]txscript.SigHashType{
+		"none":              {txscript.SigHashAll, txscript.SigHashNone},
+		"none anyonecanpay": {txscript.SigHashNone | txscript.SigHashAnyOneCanPay, txscript.SigHashAll},
+		"undefined":         {txscript.SigHashAll, 0x04},
+		"default":           {txscript.SigHashAll, taproot.SigHashDefault},
+		// The transaction has only one output.
+		"single without output": {txscript.SigHashAll, txscript.SigHashSingle},
+	} {
+		fixture := newSignTestFixture(t)
+		require.Error(t, fixture.sign(sigHashTypes), name)
+		for _, txIn := range fixture.txProposal.Transaction.TxIn {
+			require.Empty(t, txIn.Witness, name)
+		}
+	}
+
+	fixture := newSignTestFixture(t)
+	require.NoError(t, fixture.sign([]txscript.SigHashType{txscript.SigHashSingle, txscript.SigHashAll}))
+	require.Equal(t, txscript.SigHashSingle, fixture.witnessSigHashType(0))
+}
+
+func TestSignTransactionTaproot(t *testing.T) {
+	fixture := newSignTestFixtureWithScriptType(t, signing.ScriptTypeP2TR, "m/86'/1'/0'")
+	// The validity check of the signed transaction verifies the Schnorr signatures.
+	require.NoError(t, fixture.sign(nil))
+	for _, txIn := range fixture.txProposal.Transaction.TxIn {
+		require.Empty(t, txIn.SignatureScript)
+		require.Len(t, txIn.Witness, 1)
+		// The default sighash type is not appended.
+		require.Len(t, txIn.Witness[0], taproot.SignatureSize)
+	}
+
+	fixture = newSignTestFixtureWithScriptType(t, signing.ScriptTypeP2TR, "m/86'/1'/0'")
+	require.NoError(t, fixture.sign([]txscript.SigHashType{
+		taproot.SigHashDefault,
+		txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+	}))
+	require.Len(t, fixture.txProposal.Transaction.TxIn[0].Witness[0], taproot.SignatureSize)
+	require.Equal(t, txscript.SigHashAll|txscript.SigHashAnyOneCanPay, fixture.witnessSigHashType(1))
+}
+
+func TestSignTransactionChangeAddress(t *testing.T) {
+	log := logging.Get().WithGroup("sign_test")
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/1'/0'")
+	require.NoError(t, err)
+	otherXPub, err := software.NewKeystoreFromPIN(0, "5678").ExtendedPublicKey(keypath)
+	require.NoError(t, err)
+	otherConfiguration := signing.NewSinglesigConfiguration(signing.ScriptTypeP2WPKH, keypath, otherXPub)
+
+	for name, test := range map[string]struct {
+		// ownConfiguration derives the change address from the account configuration if true, and
+		// from the configuration of another keystore at the same keypath otherwise.
+		ownConfiguration bool
+		relativeKeypath  string
+		valid            bool
+	}{
+		"change chain":  {ownConfiguration: true, relativeKeypath: "1/3", valid: true},
+		"receive chain": {ownConfiguration: true, relativeKeypath: "0/3"},
+		"chain only":    {ownConfiguration: true, relativeKeypath: "1"},
+		"other keys":    {relativeKeypath: "1/3"},
+	} {
+		fixture := newSignTestFixture(t)
+		configuration := otherConfiguration
+		if test.ownConfiguration {
+			configuration = fixture.txProposal.AccountConfiguration
+		}
+		relativeKeypath, err := signing.NewRelativeKeypath(test.relativeKeypath)
+		require.NoError(t, err)
+		changeConfiguration, err := configuration.Derive(relativeKeypath)
+		require.NoError(t, err)
+		fixture.txProposal.ChangeAddress = addresses.NewAccountAddress(
+			changeConfiguration, &chaincfg.TestNet3Params, log)
+		if test.valid {
+			require.NoError(t, fixture.sign(nil), name)
+		} else {
+			require.Error(t, fixture.sign(nil), name)
+			require.Empty(t, fixture.txProposal.Transaction.TxIn[0].Witness, name)
+		}
+	}
+}
+
+func TestDryRunSignTransaction(t *testing.T) {
+	fixture := newSignTestFixture(t)
+	require.NoError(t, btc.DryRunSignTransaction(
+		fixture.txProposal, fixture.previousOutputs, fixture.getAddress, nil))
+	for _, txIn := range fixture.txProposal.Transaction.TxIn {
+		require.Empty(t, txIn.Witness)
+	}
+
+	require.Error(t, btc.DryRunSignTransaction(
+		fixture.txProposal, fixture.previousOutputs,
+		func(blockchain.ScriptHashHex) *addresses.AccountAddress { return nil }, nil))
+
+	delete(fixture.previousOutputs, fixture.txProposal.Transaction.TxIn[1].PreviousOutPoint)
+	require.Error(t, btc.DryRunSignTransaction(
+		fixture.txProposal, fixture.previousOutputs, fixture.getAddress, nil))
+
+	// The keystore must not be asked to sign a malformed proposal.
+	fixture.keystores = keystore.NewKeystores(new(keystoreMock.Keystore))
+	require.Error(t, fixture.sign(nil))
+}
+
+func TestCosigning(t *testing.T) {
+	log := logging.Get().WithGroup("sign_test")
+	cosigners := []*software.Keystore{
+		software.NewKeystoreFromPIN(0, "1234"),
+		software.NewKeystoreFromPIN(1, "5678"),
+		software.NewKeystoreFromPIN(2, "9012"),
+	}
+	keypath, err := signing.NewAbsoluteKeypath("m/48'/1'/0'/2'")
+	require.NoError(t, err)
+	xpubs := make([]*hdkeychain.ExtendedKey, len(cosigners))
+	for index, cosigner := range cosigners {
+		xpubs[index], err = cosigner.ExtendedPublicKey(keypath)
+		require.NoError(t, err)
+	}
+	accountConfiguration := signing.NewConfiguration(signing.ScriptTypeP2WSH, keypath, xpubs, 2)
+	relativeKeypath, err := signing.NewRelativeKeypath("0/0")
+	require.NoError(t, err)
+	addressConfiguration, err := accountConfiguration.Derive(relativeKeypath)
+	require.NoError(t, err)
+	address := addresses.NewAccountAddress(addressConfiguration, &chaincfg.TestNet3Params, log)
+
+	transaction := wire.NewMsgTx(wire.TxVersion)
+	outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte{0}), Index: 0}
+	transaction.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+	transaction.AddTxOut(wire.NewTxOut(90000, address.PubkeyScript()))
+	spentOutput := wire.NewTxOut(100000, address.PubkeyScript())
+	proposedTransaction := &btc.ProposedTransaction{
+		TXProposal: &maketx.TxProposal{
+			AccountConfiguration: accountConfiguration,
+			Transaction:          transaction,
+		},
+		PreviousOutputs: map[wire.OutPoint]*transactions.SpendableOutput{
+			outPoint: {TxOut: spentOutput},
+		},
+		GetAddress: func(blockchain.ScriptHashHex) *addresses.AccountAddress {
+			return address
+		},
+		Signatures: [][]*btcec.Signature{make([]*btcec.Signature, len(cosigners))},
+		SigHashes:  txscript.NewTxSigHashes(transaction),
+	}
+	require.False(t, proposedTransaction.HasSigned(0))
+
+	require.NoError(t, keystore.NewKeystores(cosigners[0]).SignTransaction(proposedTransaction))
+	require.True(t, proposedTransaction.HasSigned(0))
+	require.Equal(t, &btc.CosigningStatus{
+		Signed:           []bool{true, false, false},
+		SigningThreshold: 2,
+		Complete:         false,
+	}, proposedTransaction.CosigningStatus())
+
+	// The first cosigner has already signed and must not be asked again.
+	signedKeystore := new(keystoreMock.Keystore)
+	signedKeystore.On("CosignerIndex").Return(0)
+	require.NoError(t, keystore.NewKeystores(signedKeystore, cosigners[2]).SignTransaction(
+		proposedTransaction))
+	signedKeystore.AssertExpectations(t)
+	require.Equal(t, &btc.CosigningStatus{
+		Signed:           []bool{true, false, true},
+		SigningThreshold: 2,
+		Complete:         true,
+	}, proposedTransaction.CosigningStatus())
+
+	transaction.TxIn[0].SignatureScript, transaction.TxIn[0].Witness = address.SignatureScript(
+		proposedTransaction.Signatures[0], txscript.SigHashAll)
+	engine, err := txscript.NewEngine(spentOutput.PkScript, transaction, 0,
+		txscript.StandardVerifyFlags, nil, proposedTransaction.SigHashes, spentOutput.Value)
+	require.NoError(t, err)
+	require.NoError(t, engine.Execute())
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package signet contains the network parameters of the Bitcoin signet (BIP325), which are not part
+// of the chaincfg package.
+package signet
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// SigNet represents the default signet.
+const SigNet wire.BitcoinNet = 0x40cf030a
+
+// powLimit is the highest proof of work value a signet block can have.
+var powLimit, _ = new(big.Int).SetString(
+	"0x00000377ae000000000000000000000000000000000000000000000000000000", 0)
+
+// genesisHash is the hash of the genesis block of the default signet.
+var genesisHash = newHashFromStr("00000008819873e925422c1ff0f99f7cc9bbb232af63a077a480a3633bee1ef6")
+
+// genesisBlock is the genesis block of the default signet. It has the same coinbase transaction as
+// the genesis block of the main network.
+var genesisBlock = wire.MsgBlock{
+	Header: wire.BlockHeader{
+		Version:    1,
+		PrevBlock:  chainhash.Hash{},
+		MerkleRoot: chaincfg.MainNetParams.GenesisBlock.Header.MerkleRoot,
+		Timestamp:  time.Unix(1598918400, 0), // 2020-09-01 00:00:00 +0000 UTC
+		Bits:       0x1e0377ae,
+		Nonce:      52613770,
+	},
+	Transactions: chaincfg.MainNetParams.GenesisBlock.Transactions,
+}
+
+// Params defines the network parameters of the default signet. The addresses and extended keys are
+// encoded like on the test network.
+var Params = chaincfg.Params{
+	Name:        "signet",
+	Net:         SigNet,
+	DefaultPort: "38333",
+	DNSSeeds: []chaincfg.DNSSeed{
+		{Host: "seed.signet.bitcoin.sprovoost.nl", HasFiltering: false},
+	},
+
+	// Chain parameters
+	GenesisBlock:             &genesisBlock,
+	GenesisHash:              genesisHash,
+	PowLimit:                 powLimit,
+	PowLimitBits:             0x1e0377ae,
+	BIP0034Height:            1,
+	BIP0065Height:            1,
+	BIP0066Height:            1,
+	CoinbaseMaturity:         100,
+	SubsidyReductionInterval: 210000,
+	TargetTimespan:           time.Hour * 24 * 14, // 14 days
+	TargetTimePerBlock:       time.Minute * 10,    // 10 minutes
+	RetargetAdjustmentFactor: 4,                   // 25% less, 400% more
+	ReduceMinDifficulty:      false,
+	MinDiffReductionTime:     0,
+	GenerateSupported:        false,
+
+	// Checkpoints ordered from oldest to newest.
+	Checkpoints: []chaincfg.Checkpoint{
+		{Height: 0, Hash: genesisHash},
+	},
+
+	// Mempool parameters
+	RelayNonStdTxs: false,
+
+	// Human-readable part for Bech32 encoded segwit addresses, as defined in
+	// BIP 173.
+	Bech32HRPSegwit: "tb",
+
+	// Address encoding magics
+	PubKeyHashAddrID:        0x6f, // starts with m or n
+	ScriptHashAddrID:        0xc4, // starts with 2
+	WitnessPubKeyHashAddrID: 0x03, // starts with QW
+	WitnessScriptHashAddrID: 0x28, // starts with T7n
+	PrivateKeyID:            0xef, // starts with 9 (uncompressed) or c (compressed)
+
+	// BIP32 hierarchical deterministic extended key magics
+	HDPrivateKeyID: [4]byte{0x04, 0x35, 0x83, 0x94}, // starts with tprv
+	HDPublicKeyID:  [4]byte{0x04, 0x35, 0x87, 0xcf}, // starts with tpub
+
+	// BIP44 coin type used in the hierarchical deterministic path for
+	// address generation.
+	HDCoinType: 1,
+}
+
+// newHashFromStr converts the passed big-endian hex string into a chainhash.Hash. It panics on an
+// error, as it is only called with hard-coded hashes.
+func newHashFromStr(hexStr string) *chainhash.Hash {
+	hash, err := chainhash.NewHashFromStr(hexStr)
+	if err != nil {
+		panic(err)
+	}
+	return hash
+}
+
+func init() {
+	if err := chaincfg.Register(&Params); err != nil {
+		panic("failed to register network: " + err.Error())
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signet_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/signet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenesisHash(t *testing.T) {
+	require.Equal(t, *signet.Params.GenesisHash, signet.Params.GenesisBlock.BlockHash())
+}
+
+func TestDecodeAddress(t *testing.T) {
+	address, err := btcutil.DecodeAddress("tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", &signet.Params)
+	require.NoError(t, err)
+	require.True(t, address.IsForNet(&signet.Params))
+}
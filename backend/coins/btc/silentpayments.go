@@ -0,0 +1,128 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/txsort"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/silentpayments"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// silentPaymentPlaceholderPkScript returns the taproot pubkey script which stands in for the output
+// to the silent payment recipient with the given index until the inputs of the transaction are
+// selected. It has the size of the final output, so that the fee is estimated correctly.
+func silentPaymentPlaceholderPkScript(recipientIndex int) []byte {
+	outputKey := make([]byte, 32)
+	outputKey[0] = byte(recipientIndex >> 8)
+	outputKey[1] = byte(recipientIndex)
+	pkScript, err := taproot.PayToTaprootScript(outputKey)
+	if err != nil {
+		panic(err)
+	}
+	return pkScript
+}
+
+// setSilentPaymentOutputs replaces the placeholder outputs of the given silent payment recipients,
+// by recipient index, with the outputs derived from the inputs of the transaction (BIP352). The
+// shared secrets are computed by the keystore. The outputs are sorted again afterwards.
+func (account *Account) setSilentPaymentOutputs(
+	transaction *wire.MsgTx,
+	utxo map[wire.OutPoint]*transactions.SpendableOutput,
+	recipients map[int]*silentpayments.Address,
+) error {
+	keypaths := make([]signing.AbsoluteKeypath, len(transaction.TxIn))
+	publicKeys := make([]*btcec.PublicKey, len(transaction.TxIn))
+	outPoints := make([]wire.OutPoint, len(transaction.TxIn))
+	for index, txIn := range transaction.TxIn {
+		spentOutput, ok := utxo[txIn.PreviousOutPoint]
+		if !ok {
+			return errp.Newf("The output spent by input %d is unknown.", index)
+		}
+		address := account.getAddress(spentOutput.ScriptHashHex())
+		if address == nil {
+			return errp.Newf("The output spent by input %d does not belong to the account.", index)
+		}
+		configuration := address.Configuration
+		// Taproot inputs would require the tweaked private key.
+		if configuration.Multisig() || configuration.ScriptType() == signing.ScriptTypeP2TR {
+			return errp.Newf("Silent payments cannot be sent with %s inputs.", configuration.ScriptType())
+		}
+		keypaths[index] = configuration.AbsoluteKeypath()
+		publicKeys[index] = configuration.PublicKeys()[0]
+		outPoints[index] = txIn.PreviousOutPoint
+	}
+	inputsPublicKey, err := silentpayments.SumPublicKeys(publicKeys)
+	if err != nil {
+		return err
+	}
+	inputHash, err := silentpayments.InputHash(outPoints, inputsPublicKey)
+	if err != nil {
+		return err
+	}
+
+	// The outputs to the same scan key are numbered in the order of the recipients.
+	recipientIndices := make([]int, 0, len(recipients))
+	for recipientIndex := range recipients {
+		recipientIndices = append(recipientIndices, recipientIndex)
+	}
+	sort.Ints(recipientIndices)
+	sharedSecrets := map[string]*btcec.PublicKey{}
+	outputCounts := map[string]uint32{}
+	for _, recipientIndex := range recipientIndices {
+		recipient := recipients[recipientIndex]
+		scanKey := hex.EncodeToString(recipient.ScanKey.SerializeCompressed())
+		sharedSecret, ok := sharedSecrets[scanKey]
+		if !ok {
+			sharedSecret, err = account.keystores.SilentPaymentSharedSecret(
+				keypaths, inputHash, recipient.ScanKey)
+			if err != nil {
+				return err
+			}
+			sharedSecrets[scanKey] = sharedSecret
+		}
+		outputKey, err := silentpayments.OutputKey(sharedSecret, recipient.SpendKey, outputCounts[scanKey])
+		if err != nil {
+			return err
+		}
+		outputCounts[scanKey]++
+		pkScript, err := taproot.PayToTaprootScript(outputKey)
+		if err != nil {
+			return err
+		}
+		placeholder := silentPaymentPlaceholderPkScript(recipientIndex)
+		found := false
+		for _, txOut := range transaction.TxOut {
+			if bytes.Equal(txOut.PkScript, placeholder) {
+				txOut.PkScript = pkScript
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errp.Newf("The output to silent payment recipient %d is missing.", recipientIndex)
+		}
+	}
+	txsort.InPlaceSort(transaction)
+	return nil
+}
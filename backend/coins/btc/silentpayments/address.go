@@ -0,0 +1,96 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package silentpayments implements sending to silent payment addresses (BIP352). The output key of
+// a payment is derived from the keys of the inputs of the transaction and the keys of the address,
+// so that the receiver can find it without revealing the address on the blockchain.
+package silentpayments
+
+import (
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/bech32"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+const (
+	// maxAddressLength is the maximum length of a silent payment address.
+	maxAddressLength = 1023
+	// version is the highest version of silent payment addresses which is supported.
+	version = 0
+	// keysSize is the size of the serialized scan and spend public keys.
+	keysSize = 2 * btcec.PubKeyBytesLenCompressed
+)
+
+// Address is a decoded silent payment address.
+type Address struct {
+	// ScanKey is used to compute the shared secret with the receiver.
+	ScanKey *btcec.PublicKey
+	// SpendKey is tweaked with the shared secret to get the output key.
+	SpendKey *btcec.PublicKey
+}
+
+// hrp returns the human readable part of silent payment addresses of the given network.
+func hrp(net *chaincfg.Params) string {
+	switch net.Name {
+	case chaincfg.MainNetParams.Name:
+		return "sp"
+	case chaincfg.RegressionNetParams.Name:
+		return "sprt"
+	default:
+		return "tsp"
+	}
+}
+
+// DecodeAddress decodes a silent payment address of the given network. Addresses of future versions
+// are decoded as well if they start with the keys, as they are required to be backwards compatible.
+func DecodeAddress(address string, net *chaincfg.Params) (*Address, error) {
+	addressHRP, data, err := taproot.DecodeBech32m(address, maxAddressLength)
+	if err != nil {
+		return nil, err
+	}
+	if addressHRP != hrp(net) {
+		return nil, errp.New("The address is not a silent payment address of this network.")
+	}
+	if len(data) == 0 || data[0] == 31 {
+		return nil, errp.New("The silent payment address has an invalid version.")
+	}
+	payload, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if (data[0] == version && len(payload) != keysSize) || len(payload) < keysSize {
+		return nil, errp.New("The silent payment address has an invalid length.")
+	}
+	scanKey, err := btcec.ParsePubKey(payload[:btcec.PubKeyBytesLenCompressed], btcec.S256())
+	if err != nil {
+		return nil, errp.WithMessage(errp.WithStack(err), "Invalid scan key")
+	}
+	spendKey, err := btcec.ParsePubKey(payload[btcec.PubKeyBytesLenCompressed:keysSize], btcec.S256())
+	if err != nil {
+		return nil, errp.WithMessage(errp.WithStack(err), "Invalid spend key")
+	}
+	return &Address{ScanKey: scanKey, SpendKey: spendKey}, nil
+}
+
+// EncodeAddress encodes the address as a version 0 silent payment address of the given network.
+func (address *Address) EncodeAddress(net *chaincfg.Params) string {
+	payload := append(address.ScanKey.SerializeCompressed(), address.SpendKey.SerializeCompressed()...)
+	converted, err := bech32.ConvertBits(payload, 8, 5, true)
+	if err != nil {
+		panic(err)
+	}
+	return taproot.EncodeBech32m(hrp(net), append([]byte{version}, converted...))
+}
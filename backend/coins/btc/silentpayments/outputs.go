@@ -0,0 +1,92 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package silentpayments
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// serializeOutPoint serializes the outpoint like in a transaction.
+func serializeOutPoint(outPoint wire.OutPoint) []byte {
+	serialized := make([]byte, 36)
+	copy(serialized, outPoint.Hash[:])
+	binary.LittleEndian.PutUint32(serialized[32:], outPoint.Index)
+	return serialized
+}
+
+// SumPublicKeys returns the sum A of the public keys of the inputs.
+func SumPublicKeys(publicKeys []*btcec.PublicKey) (*btcec.PublicKey, error) {
+	if len(publicKeys) == 0 {
+		return nil, errp.New("A silent payment requires at least one input.")
+	}
+	curve := btcec.S256()
+	x, y := publicKeys[0].X, publicKeys[0].Y
+	for _, publicKey := range publicKeys[1:] {
+		x, y = curve.Add(x, y, publicKey.X, publicKey.Y)
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, errp.New("The sum of the input keys is the point at infinity.")
+	}
+	return &btcec.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// InputHash returns input_hash = hash_BIP0352/Inputs(outpoint_L || A), where outpoint_L is the
+// lexicographically smallest serialized outpoint of the inputs and A is the sum of their public
+// keys.
+func InputHash(outPoints []wire.OutPoint, inputsPublicKey *btcec.PublicKey) ([]byte, error) {
+	if len(outPoints) == 0 {
+		return nil, errp.New("A silent payment requires at least one input.")
+	}
+	smallestOutPoint := serializeOutPoint(outPoints[0])
+	for _, outPoint := range outPoints[1:] {
+		if serialized := serializeOutPoint(outPoint); bytes.Compare(serialized, smallestOutPoint) < 0 {
+			smallestOutPoint = serialized
+		}
+	}
+	inputHash := taproot.TaggedHash("BIP0352/Inputs", smallestOutPoint, inputsPublicKey.SerializeCompressed())
+	if new(big.Int).SetBytes(inputHash[:]).Cmp(btcec.S256().N) >= 0 {
+		return nil, errp.New("The input hash is not a valid scalar.")
+	}
+	return inputHash[:], nil
+}
+
+// OutputKey returns the x-only output key P_k = B_spend + t_k·G of the k-th output to the given
+// spend key, where t_k = hash_BIP0352/SharedSecret(ecdh_shared_secret || k) and the shared secret
+// is input_hash·a·B_scan.
+func OutputKey(sharedSecret *btcec.PublicKey, spendKey *btcec.PublicKey, k uint32) ([]byte, error) {
+	serializedK := make([]byte, 4)
+	binary.BigEndian.PutUint32(serializedK, k)
+	tweak := taproot.TaggedHash("BIP0352/SharedSecret", sharedSecret.SerializeCompressed(), serializedK)
+	curve := btcec.S256()
+	if new(big.Int).SetBytes(tweak[:]).Cmp(curve.N) >= 0 {
+		return nil, errp.New("The silent payment tweak is not a valid scalar.")
+	}
+	tweakX, tweakY := curve.ScalarBaseMult(tweak[:])
+	outputKeyX, outputKeyY := curve.Add(spendKey.X, spendKey.Y, tweakX, tweakY)
+	if outputKeyX.Sign() == 0 && outputKeyY.Sign() == 0 {
+		return nil, errp.New("The silent payment output key is the point at infinity.")
+	}
+	outputKey := make([]byte, 32)
+	xBytes := outputKeyX.Bytes()
+	copy(outputKey[32-len(xBytes):], xBytes)
+	return outputKey, nil
+}
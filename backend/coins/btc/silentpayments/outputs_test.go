@@ -0,0 +1,88 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package silentpayments_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/silentpayments"
+	"github.com/stretchr/testify/require"
+)
+
+const testAddress = "sp1qqgste7k9hx0qftg6qmwlkqtwuy6cycyavzmzj85c6qdfhjdpdjtdgqjuexzk6murw56suy3e0rd2cgqvycxttddwsvgxe2usfpxumr70xc9pkqwv"
+
+func TestDecodeAddress(t *testing.T) {
+	address, err := silentpayments.DecodeAddress(testAddress, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.Equal(t, testAddress, address.EncodeAddress(&chaincfg.MainNetParams))
+
+	_, err = silentpayments.DecodeAddress(testAddress, &chaincfg.TestNet3Params)
+	require.Error(t, err)
+	_, err = silentpayments.DecodeAddress(testAddress[:len(testAddress)-1]+"q", &chaincfg.MainNetParams)
+	require.Error(t, err)
+	_, err = silentpayments.DecodeAddress(
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", &chaincfg.MainNetParams)
+	require.Error(t, err)
+}
+
+// TestOutputKey checks the derivation with the "Simple send: two inputs" test vector of BIP352.
+func TestOutputKey(t *testing.T) {
+	inputs := []struct {
+		txID       string
+		privateKey string
+	}{
+		{"f4184fc596403b9d638783cf57adfe4c75c605f6356fbc91338530e9831e9e16",
+			"eadc78165ff1f8ea94ad7cfdc54990738a4c53f6e0507b42154201b8e5dff3b1"},
+		{"a1075db55d416d3ca199f55b6084e2115b9345e16c5cf302fc80e9d5fbf5d48d",
+			"93f5ed907ad5b2bdbbdcb5d9116ebc0a4e1f92f910d5260237fa45a9408aad16"},
+	}
+	curve := btcec.S256()
+	outPoints := []wire.OutPoint{}
+	publicKeys := []*btcec.PublicKey{}
+	privateKeysSum := new(big.Int)
+	for _, input := range inputs {
+		txHash, err := chainhash.NewHashFromStr(input.txID)
+		require.NoError(t, err)
+		outPoints = append(outPoints, wire.OutPoint{Hash: *txHash, Index: 0})
+		privateKeyBytes, err := hex.DecodeString(input.privateKey)
+		require.NoError(t, err)
+		privateKey, publicKey := btcec.PrivKeyFromBytes(curve, privateKeyBytes)
+		publicKeys = append(publicKeys, publicKey)
+		privateKeysSum.Add(privateKeysSum, privateKey.D)
+	}
+	inputsPublicKey, err := silentpayments.SumPublicKeys(publicKeys)
+	require.NoError(t, err)
+	inputHash, err := silentpayments.InputHash(outPoints, inputsPublicKey)
+	require.NoError(t, err)
+
+	address, err := silentpayments.DecodeAddress(testAddress, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	scalar := new(big.Int).Mul(privateKeysSum, new(big.Int).SetBytes(inputHash))
+	scalar.Mod(scalar, curve.N)
+	x, y := curve.ScalarMult(address.ScanKey.X, address.ScanKey.Y, scalar.Bytes())
+	sharedSecret := &btcec.PublicKey{Curve: curve, X: x, Y: y}
+
+	outputKey, err := silentpayments.OutputKey(sharedSecret, address.SpendKey, 0)
+	require.NoError(t, err)
+	require.Equal(t,
+		"3e9fce73d4e77a4809908e3c3a2e54ee147b9312dc5044a193d1fc85de46e3c1",
+		hex.EncodeToString(outputKey))
+}
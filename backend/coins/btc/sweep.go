@@ -0,0 +1,218 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"bytes"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// sweepPkScripts returns the pubkey scripts of the private key whose outputs are swept: P2PKH and,
+// if the public key is compressed, P2WPKH.
+func (account *Account) sweepPkScripts(wif *btcutil.WIF) ([][]byte, error) {
+	pubKeyHash := btcutil.Hash160(wif.SerializePubKey())
+	p2pkhAddress, err := btcutil.NewAddressPubKeyHash(pubKeyHash, account.coin.Net())
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	p2pkhPkScript, err := txscript.PayToAddrScript(p2pkhAddress)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	pkScripts := [][]byte{p2pkhPkScript}
+	if wif.CompressPubKey {
+		p2wpkhAddress, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, account.coin.Net())
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		p2wpkhPkScript, err := txscript.PayToAddrScript(p2wpkhAddress)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		pkScripts = append(pkScripts, p2wpkhPkScript)
+	}
+	return pkScripts, nil
+}
+
+// sweepOutputs returns the unspent outputs of the given pubkey scripts. The outputs reported by
+// the server are checked against the transactions which created them.
+func (account *Account) sweepOutputs(pkScripts [][]byte) (map[wire.OutPoint]*wire.TxOut, error) {
+	outputs := map[wire.OutPoint]*wire.TxOut{}
+	for _, pkScript := range pkScripts {
+		scriptHashHex := blockchain.ScriptHashHex(chainhash.HashH(pkScript).String())
+		utxos, err := account.blockchain.ScriptHashListUnspent(scriptHashHex)
+		if err != nil {
+			return nil, err
+		}
+		for _, utxo := range utxos {
+			transaction, err := account.fetchTransaction(utxo.TXHash.Hash())
+			if err != nil {
+				return nil, err
+			}
+			if utxo.TXPos < 0 || utxo.TXPos >= len(transaction.TxOut) ||
+				!bytes.Equal(transaction.TxOut[utxo.TXPos].PkScript, pkScript) {
+				return nil, errp.Newf("The server reported an invalid output %s:%d.",
+					utxo.TXHash.Hash(), utxo.TXPos)
+			}
+			outputs[wire.OutPoint{Hash: utxo.TXHash.Hash(), Index: uint32(utxo.TXPos)}] =
+				transaction.TxOut[utxo.TXPos]
+		}
+	}
+	return outputs, nil
+}
+
+// signSweepTx signs all inputs of the transaction with the private key in software.
+func signSweepTx(
+	transaction *wire.MsgTx, spentOutputs map[wire.OutPoint]*wire.TxOut, wif *btcutil.WIF) error {
+	sigHashes := txscript.NewTxSigHashes(transaction)
+	for index, txIn := range transaction.TxIn {
+		spentOutput := spentOutputs[txIn.PreviousOutPoint]
+		switch txscript.GetScriptClass(spentOutput.PkScript) {
+		case txscript.PubKeyHashTy:
+			signatureScript, err := txscript.SignatureScript(transaction, index, spentOutput.PkScript,
+				txscript.SigHashAll, wif.PrivKey, wif.CompressPubKey)
+			if err != nil {
+				return errp.WithStack(err)
+			}
+			txIn.SignatureScript = signatureScript
+		case txscript.WitnessV0PubKeyHashTy:
+			witness, err := txscript.WitnessSignature(transaction, sigHashes, index, spentOutput.Value,
+				spentOutput.PkScript, txscript.SigHashAll, wif.PrivKey, true)
+			if err != nil {
+				return errp.WithStack(err)
+			}
+			txIn.Witness = witness
+		default:
+			return errp.Newf("Input %d cannot be swept.", index)
+		}
+	}
+	for index, txIn := range transaction.TxIn {
+		spentOutput := spentOutputs[txIn.PreviousOutPoint]
+		engine, err := txscript.NewEngine(spentOutput.PkScript, transaction, index,
+			txscript.StandardVerifyFlags, nil, sigHashes, spentOutput.Value)
+		if err != nil {
+			return errp.WithStack(err)
+		}
+		if err := engine.Execute(); err != nil {
+			return errp.WithMessage(errp.WithStack(err), "The signature of the sweep transaction is invalid")
+		}
+	}
+	return nil
+}
+
+// newSweepTx creates a signed transaction which spends all coins of the given WIF encoded private
+// key to a receive address of the account. Returns the transaction, the amount received by the
+// account and the fee.
+func (account *Account) newSweepTx(
+	encodedWIF string, feeTargetCode FeeTargetCode, customFee string) (
+	*wire.MsgTx, btcutil.Amount, btcutil.Amount, error) {
+	wif, err := btcutil.DecodeWIF(encodedWIF)
+	if err != nil {
+		return nil, 0, 0, errp.WithMessage(errp.WithStack(err), "Invalid private key")
+	}
+	if !wif.IsForNet(account.coin.Net()) {
+		return nil, 0, 0, errp.New("The private key is for a different network.")
+	}
+	feeRatePerKb, err := account.txFeeRatePerKb(feeTargetCode, customFee)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	pkScripts, err := account.sweepPkScripts(wif)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	spentOutputs, err := account.sweepOutputs(pkScripts)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if len(spentOutputs) == 0 {
+		return nil, 0, 0, errp.New("There are no coins to sweep.")
+	}
+
+	account.synchronizer.WaitSynchronized()
+	defer account.RLock()()
+	address := account.receiveAddresses.GetUnused()[0]
+	transaction := wire.NewMsgTx(wire.TxVersion)
+	inputsSum := btcutil.Amount(0)
+	for outPoint, spentOutput := range spentOutputs {
+		outPoint := outPoint // avoid reference reuse due to range loop
+		transaction.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+		inputsSum += btcutil.Amount(spentOutput.Value)
+	}
+	transaction.AddTxOut(wire.NewTxOut(int64(inputsSum), address.PubkeyScript()))
+	maketx.OrderTransaction(transaction, account.txOrdering)
+	maketx.SetAntiFeeSnipingLockTime(transaction, account.antiFeeSnipingTipHeight())
+
+	// The fee is computed from the size of the signed transaction. A signature can be one byte
+	// longer when the transaction is signed again.
+	if err := signSweepTx(transaction, spentOutputs, wif); err != nil {
+		return nil, 0, 0, err
+	}
+	vsize := mempool.GetTxVirtualSize(btcutil.NewTx(transaction)) + int64(len(transaction.TxIn))
+	fee := feeRatePerKb * btcutil.Amount(vsize) / 1000
+	amount := inputsSum - fee
+	if amount <= 0 {
+		return nil, 0, 0, errp.WithStack(coin.ErrInsufficientFunds)
+	}
+	if maketx.IsDustOutput(amount, address, feeRatePerKb, account.dustLimit) {
+		return nil, 0, 0, errp.WithStack(coin.ErrDustAmount)
+	}
+	transaction.TxOut[0].Value = int64(amount)
+	for _, txIn := range transaction.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	if err := signSweepTx(transaction, spentOutputs, wif); err != nil {
+		return nil, 0, 0, err
+	}
+	return transaction, amount, fee, nil
+}
+
+// SweepProposal creates a transaction like Sweep and returns the amount received by the account
+// and the fee for display in the UI.
+func (account *Account) SweepProposal(
+	encodedWIF string, feeTargetCode FeeTargetCode, customFee string) (
+	coin.Amount, coin.Amount, error) {
+	account.log.Debug("Proposing sweep transaction")
+	_, amount, fee, err := account.newSweepTx(encodedWIF, feeTargetCode, customFee)
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, err
+	}
+	return coin.NewAmountFromInt64(int64(amount)), coin.NewAmountFromInt64(int64(fee)), nil
+}
+
+// Sweep spends all coins of the given WIF encoded private key to a receive address of the account.
+// The coins of the P2PKH and P2WPKH addresses of the key are found via the blockchain backend. The
+// transaction is signed in software with the private key, as it is not protected by the keystore
+// anyway.
+func (account *Account) Sweep(encodedWIF string, feeTargetCode FeeTargetCode, customFee string) error {
+	account.log.Info("Sweeping private key")
+	transaction, amount, fee, err := account.newSweepTx(encodedWIF, feeTargetCode, customFee)
+	if err != nil {
+		return err
+	}
+	account.log.WithField("amount", amount).WithField("fee", fee).
+		Info("Signed sweep transaction is broadcasted")
+	return account.blockchain.TransactionBroadcast(transaction)
+}
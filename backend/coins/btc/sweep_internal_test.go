@@ -0,0 +1,63 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignSweepTx(t *testing.T) {
+	privateKey, err := btcec.NewPrivateKey(btcec.S256())
+	require.NoError(t, err)
+	for _, compress := range []bool{false, true} {
+		wif, err := btcutil.NewWIF(privateKey, &chaincfg.TestNet3Params, compress)
+		require.NoError(t, err)
+		account := &Account{coin: &Coin{net: &chaincfg.TestNet3Params}}
+		pkScripts, err := account.sweepPkScripts(wif)
+		require.NoError(t, err)
+		if compress {
+			require.Len(t, pkScripts, 2)
+			require.Equal(t, txscript.WitnessV0PubKeyHashTy, txscript.GetScriptClass(pkScripts[1]))
+		} else {
+			require.Len(t, pkScripts, 1)
+		}
+		require.Equal(t, txscript.PubKeyHashTy, txscript.GetScriptClass(pkScripts[0]))
+
+		transaction := wire.NewMsgTx(wire.TxVersion)
+		spentOutputs := map[wire.OutPoint]*wire.TxOut{}
+		for index, pkScript := range pkScripts {
+			outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte{byte(index)}), Index: uint32(index)}
+			transaction.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+			spentOutputs[outPoint] = wire.NewTxOut(int64(10000+index), pkScript)
+		}
+		transaction.AddTxOut(wire.NewTxOut(5000, pkScripts[0]))
+		require.NoError(t, signSweepTx(transaction, spentOutputs, wif))
+
+		// Signing with a different key fails the verification.
+		otherPrivateKey, err := btcec.NewPrivateKey(btcec.S256())
+		require.NoError(t, err)
+		otherWIF, err := btcutil.NewWIF(otherPrivateKey, &chaincfg.TestNet3Params, compress)
+		require.NoError(t, err)
+		require.Error(t, signSweepTx(transaction, spentOutputs, otherWIF))
+	}
+}
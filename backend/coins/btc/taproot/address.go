@@ -0,0 +1,141 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taproot
+
+import (
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/bech32"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+const (
+	// witnessVersion is the segwit version of taproot outputs.
+	witnessVersion = 1
+	// witnessProgramSize is the size of the witness program of taproot outputs, an x-only public key.
+	witnessProgramSize = 32
+)
+
+// OutputKey returns the x-only output key of a BIP86 key path only output with the given internal
+// key: Q = P + int(TaggedHash("TapTweak", x(P)))G, where P has an even Y coordinate.
+func OutputKey(internalKey *btcec.PublicKey) ([]byte, error) {
+	curve := btcec.S256()
+	internalKeyX := xOnly(internalKey.X)
+	tweak := TaggedHash("TapTweak", internalKeyX)
+	if new(big.Int).SetBytes(tweak[:]).Cmp(curve.N) >= 0 {
+		return nil, errp.New("The taproot tweak is not a valid scalar.")
+	}
+	internalKeyY := internalKey.Y
+	if internalKeyY.Bit(0) == 1 {
+		internalKeyY = new(big.Int).Sub(curve.P, internalKeyY)
+	}
+	tweakX, tweakY := curve.ScalarBaseMult(tweak[:])
+	outputKeyX, outputKeyY := curve.Add(internalKey.X, internalKeyY, tweakX, tweakY)
+	if outputKeyX.Sign() == 0 && outputKeyY.Sign() == 0 {
+		return nil, errp.New("The taproot output key is the point at infinity.")
+	}
+	return xOnly(outputKeyX), nil
+}
+
+// xOnly serializes the x coordinate of a point to 32 bytes.
+func xOnly(x *big.Int) []byte {
+	serialized := make([]byte, witnessProgramSize)
+	xBytes := x.Bytes()
+	copy(serialized[witnessProgramSize-len(xBytes):], xBytes)
+	return serialized
+}
+
+// PayToTaprootScript returns the pubkey script of a taproot output with the given output key.
+func PayToTaprootScript(outputKey []byte) ([]byte, error) {
+	if len(outputKey) != witnessProgramSize {
+		return nil, errp.New("The taproot output key must be 32 bytes.")
+	}
+	return txscript.NewScriptBuilder().
+		AddOp(txscript.OP_1).
+		AddData(outputKey).
+		Script()
+}
+
+// PayToAddrScript is like txscript.PayToAddrScript, but also supports taproot addresses.
+func PayToAddrScript(address btcutil.Address) ([]byte, error) {
+	if taprootAddress, ok := address.(*AddressTaproot); ok {
+		return PayToTaprootScript(taprootAddress.ScriptAddress())
+	}
+	return txscript.PayToAddrScript(address)
+}
+
+// AddressTaproot is a bech32m encoded pay-to-taproot address (BIP350), which is not supported by
+// the btcutil version this project depends on.
+type AddressTaproot struct {
+	hrp            string
+	witnessProgram [witnessProgramSize]byte
+}
+
+// NewAddressTaproot returns the taproot address of the given output key.
+func NewAddressTaproot(outputKey []byte, net *chaincfg.Params) (*AddressTaproot, error) {
+	if len(outputKey) != witnessProgramSize {
+		return nil, errp.New("The taproot output key must be 32 bytes.")
+	}
+	address := &AddressTaproot{hrp: net.Bech32HRPSegwit}
+	copy(address.witnessProgram[:], outputKey)
+	return address, nil
+}
+
+// EncodeAddress implements btcutil.Address.
+func (address *AddressTaproot) EncodeAddress() string {
+	converted, err := bech32.ConvertBits(address.witnessProgram[:], 8, 5, true)
+	if err != nil {
+		panic(err)
+	}
+	return EncodeBech32m(address.hrp, append([]byte{witnessVersion}, converted...))
+}
+
+// ScriptAddress implements btcutil.Address. It returns the output key.
+func (address *AddressTaproot) ScriptAddress() []byte {
+	return address.witnessProgram[:]
+}
+
+// IsForNet implements btcutil.Address.
+func (address *AddressTaproot) IsForNet(net *chaincfg.Params) bool {
+	return address.hrp == net.Bech32HRPSegwit
+}
+
+// String implements btcutil.Address.
+func (address *AddressTaproot) String() string {
+	return address.EncodeAddress()
+}
+
+// DecodeAddress is like btcutil.DecodeAddress, but also decodes taproot addresses.
+func DecodeAddress(address string, net *chaincfg.Params) (btcutil.Address, error) {
+	hrp, data, err := DecodeBech32m(address, Bech32MaxLength)
+	if err != nil {
+		return btcutil.DecodeAddress(address, net)
+	}
+	if hrp != net.Bech32HRPSegwit {
+		return nil, errp.New("The address is for a different network.")
+	}
+	if len(data) == 0 || data[0] != witnessVersion {
+		return nil, errp.New("Only segwit version 1 addresses can be bech32m encoded.")
+	}
+	witnessProgram, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return NewAddressTaproot(witnessProgram, net)
+}
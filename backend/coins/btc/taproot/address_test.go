@@ -0,0 +1,96 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taproot_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOutputKey checks the key path only output key from the BIP86 test vectors.
+func TestOutputKey(t *testing.T) {
+	internalKey, err := btcec.ParsePubKey(mustDecodeHex(t,
+		"03cc8a4bc64d897bddc5fbc2f670f7a8ba0b386779106cf1223c6fc5d7cd6fc115"), btcec.S256())
+	require.NoError(t, err)
+	outputKey, err := taproot.OutputKey(internalKey)
+	require.NoError(t, err)
+	require.Equal(t,
+		"a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+		hex.EncodeToString(outputKey))
+}
+
+func TestAddressTaproot(t *testing.T) {
+	outputKey := mustDecodeHex(t, "a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c")
+	for _, vector := range []struct {
+		net     *chaincfg.Params
+		address string
+	}{
+		{&chaincfg.MainNetParams, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+		{&chaincfg.TestNet3Params, "tb1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqp3mvzv"},
+	} {
+		address, err := taproot.NewAddressTaproot(outputKey, vector.net)
+		require.NoError(t, err)
+		require.Equal(t, vector.address, address.EncodeAddress())
+		require.Equal(t, vector.address, address.String())
+		require.Equal(t, outputKey, address.ScriptAddress())
+		require.True(t, address.IsForNet(vector.net))
+
+		decoded, err := taproot.DecodeAddress(vector.address, vector.net)
+		require.NoError(t, err)
+		require.Equal(t, address, decoded)
+
+		pkScript, err := taproot.PayToAddrScript(decoded)
+		require.NoError(t, err)
+		require.True(t, taproot.IsPayToTaproot(pkScript))
+		require.Equal(t, outputKey, pkScript[2:])
+	}
+	_, err := taproot.NewAddressTaproot(outputKey[1:], &chaincfg.MainNetParams)
+	require.Error(t, err)
+}
+
+func TestDecodeAddress(t *testing.T) {
+	// Other addresses are decoded by btcutil.
+	address, err := taproot.DecodeAddress(
+		"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	require.IsType(t, &btcutil.AddressWitnessPubKeyHash{}, address)
+
+	// Uppercase addresses are valid.
+	_, err = taproot.DecodeAddress(
+		"BC1P5CYXNUXMEUWUVKWFEM96LQZSZD02N6XDCJRS20CAC6YQJJWUDPXQKEDRCR", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	for _, invalid := range []string{
+		// Wrong network.
+		"tb1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqp3mvzv",
+		// Invalid checksum.
+		"bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcq",
+		// Mixed case.
+		"bc1P5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr",
+		// Segwit v1 encoded with bech32 instead of bech32m (BIP350).
+		"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7k7grplx",
+		// Segwit v0 encoded with bech32m (BIP350).
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh",
+	} {
+		_, err := taproot.DecodeAddress(invalid, &chaincfg.MainNetParams)
+		require.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taproot
+
+import (
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+const (
+	bech32mCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	// bech32mConst is the constant the checksum of bech32m strings is xored with (BIP350).
+	bech32mConst = 0x2bc830a3
+	// Bech32MaxLength is the maximum length of a bech32 or bech32m address (BIP173).
+	Bech32MaxLength = 90
+)
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	checksum := uint32(1)
+	for _, value := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(value)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+	return checksum
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	expanded := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// EncodeBech32m encodes the 5 bit groups in data with the given human readable part.
+func EncodeBech32m(hrp string, data []byte) string {
+	values := append(bech32HrpExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ bech32mConst
+	var result strings.Builder
+	result.WriteString(hrp)
+	result.WriteByte('1')
+	for _, value := range data {
+		result.WriteByte(bech32mCharset[value])
+	}
+	for i := 0; i < 6; i++ {
+		result.WriteByte(bech32mCharset[(polymod>>uint(5*(5-i)))&31])
+	}
+	return result.String()
+}
+
+// DecodeBech32m decodes a bech32m string of at most maxLength characters into its human readable
+// part and its 5 bit groups.
+func DecodeBech32m(encoded string, maxLength int) (string, []byte, error) {
+	if len(encoded) > maxLength {
+		return "", nil, errp.New("The bech32m string is too long.")
+	}
+	lower := strings.ToLower(encoded)
+	if lower != encoded && strings.ToUpper(encoded) != encoded {
+		return "", nil, errp.New("The bech32m string has mixed case.")
+	}
+	separator := strings.LastIndexByte(lower, '1')
+	if separator < 1 || separator+7 > len(lower) {
+		return "", nil, errp.New("The bech32m string has an invalid separator position.")
+	}
+	hrp := lower[:separator]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, errp.New("The bech32m string has an invalid human readable part.")
+		}
+	}
+	data := make([]byte, 0, len(lower)-separator-1)
+	for _, char := range lower[separator+1:] {
+		value := strings.IndexRune(bech32mCharset, char)
+		if value < 0 {
+			return "", nil, errp.New("The bech32m string contains an invalid character.")
+		}
+		data = append(data, byte(value))
+	}
+	if bech32Polymod(append(bech32HrpExpand(hrp), data...)) != bech32mConst {
+		return "", nil, errp.New("The bech32m string has an invalid checksum.")
+	}
+	return hrp, data[:len(data)-6], nil
+}
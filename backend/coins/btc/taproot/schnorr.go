@@ -0,0 +1,201 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taproot
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// SignatureSize is the size of a BIP340 Schnorr signature.
+const SignatureSize = 64
+
+// liftX returns the point with the given x coordinate and an even y coordinate (BIP340).
+func liftX(x []byte) (*btcec.PublicKey, error) {
+	curve := btcec.S256()
+	pointX := new(big.Int).SetBytes(x)
+	if len(x) != witnessProgramSize || pointX.Cmp(curve.P) >= 0 {
+		return nil, errp.New("Invalid x-only public key.")
+	}
+	// y^2 = x^3 + 7. As p = 3 mod 4, the square root is c^((p+1)/4).
+	c := new(big.Int).Exp(pointX, big.NewInt(3), curve.P)
+	c.Add(c, big.NewInt(7)).Mod(c, curve.P)
+	pointY := new(big.Int).Exp(c, new(big.Int).Rsh(new(big.Int).Add(curve.P, big.NewInt(1)), 2), curve.P)
+	if new(big.Int).Exp(pointY, big.NewInt(2), curve.P).Cmp(c) != 0 {
+		return nil, errp.New("The x-only public key is not on the curve.")
+	}
+	if pointY.Bit(0) == 1 {
+		pointY.Sub(curve.P, pointY)
+	}
+	return &btcec.PublicKey{Curve: curve, X: pointX, Y: pointY}, nil
+}
+
+// evenPrivateKey returns the private key, negated if its public key has an odd y coordinate, so
+// that it matches the x-only public key.
+func evenPrivateKey(privateKey *btcec.PrivateKey) *big.Int {
+	if privateKey.PublicKey.Y.Bit(0) == 1 {
+		return new(big.Int).Sub(btcec.S256().N, privateKey.D)
+	}
+	return new(big.Int).Set(privateKey.D)
+}
+
+// TweakPrivateKey returns the private key of the BIP86 output key of the given internal key, see
+// OutputKey. It signs key path spends of taproot outputs.
+func TweakPrivateKey(internalKey *btcec.PrivateKey) (*btcec.PrivateKey, error) {
+	curve := btcec.S256()
+	tweak := TaggedHash("TapTweak", xOnly(internalKey.PublicKey.X))
+	tweakInt := new(big.Int).SetBytes(tweak[:])
+	if tweakInt.Cmp(curve.N) >= 0 {
+		return nil, errp.New("The taproot tweak is not a valid scalar.")
+	}
+	d := evenPrivateKey(internalKey)
+	d.Add(d, tweakInt).Mod(d, curve.N)
+	if d.Sign() == 0 {
+		return nil, errp.New("The tweaked private key is zero.")
+	}
+	privateKey, _ := btcec.PrivKeyFromBytes(curve, d.Bytes())
+	return privateKey, nil
+}
+
+// Sign creates a BIP340 Schnorr signature of the 32 byte hash. auxRand are 32 bytes of fresh
+// randomness, which protect against side channel attacks.
+func Sign(privateKey *btcec.PrivateKey, hash []byte, auxRand []byte) ([]byte, error) {
+	curve := btcec.S256()
+	if len(hash) != 32 || len(auxRand) != 32 {
+		return nil, errp.New("The hash and the auxiliary randomness must be 32 bytes.")
+	}
+	d := evenPrivateKey(privateKey)
+	publicKeyX := xOnly(privateKey.PublicKey.X)
+	auxHash := TaggedHash("BIP0340/aux", auxRand)
+	t := xOnly(d)
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+	nonceHash := TaggedHash("BIP0340/nonce", t, publicKeyX, hash)
+	k := new(big.Int).Mod(new(big.Int).SetBytes(nonceHash[:]), curve.N)
+	if k.Sign() == 0 {
+		return nil, errp.New("The nonce is zero.")
+	}
+	nonceX, nonceY := curve.ScalarBaseMult(k.Bytes())
+	if nonceY.Bit(0) == 1 {
+		k.Sub(curve.N, k)
+	}
+	r := xOnly(nonceX)
+	e := challenge(r, publicKeyX, hash)
+	s := e.Mul(e, d)
+	s.Add(s, k).Mod(s, curve.N)
+	signature := append(r, xOnly(s)...)
+	if !Verify(publicKeyX, hash, signature) {
+		return nil, errp.New("The created signature is invalid.")
+	}
+	return signature, nil
+}
+
+// challenge returns the BIP340 challenge e = int(hash_BIP0340/challenge(r || P || m)) mod n.
+func challenge(r []byte, publicKeyX []byte, hash []byte) *big.Int {
+	challengeHash := TaggedHash("BIP0340/challenge", r, publicKeyX, hash)
+	return new(big.Int).Mod(new(big.Int).SetBytes(challengeHash[:]), btcec.S256().N)
+}
+
+// Verify returns whether the signature is a valid BIP340 Schnorr signature of the hash for the
+// given x-only public key.
+func Verify(publicKeyX []byte, hash []byte, signature []byte) bool {
+	curve := btcec.S256()
+	if len(signature) != SignatureSize {
+		return false
+	}
+	publicKey, err := liftX(publicKeyX)
+	if err != nil {
+		return false
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if r.Cmp(curve.P) >= 0 || s.Cmp(curve.N) >= 0 {
+		return false
+	}
+	// R = s*G - e*P
+	e := challenge(signature[:32], publicKeyX, hash)
+	e.Sub(curve.N, e)
+	sX, sY := curve.ScalarBaseMult(xOnly(s))
+	eX, eY := curve.ScalarMult(publicKey.X, publicKey.Y, xOnly(e))
+	nonceX, nonceY := curve.Add(sX, sY, eX, eY)
+	if nonceX.Sign() == 0 && nonceY.Sign() == 0 {
+		return false
+	}
+	return nonceY.Bit(0) == 0 && bytes.Equal(xOnly(nonceX), signature[:32])
+}
+
+// SignatureFromBytes returns the Schnorr signature r || s in the R and S fields of a signature,
+// which is how the signatures of taproot inputs are passed along with the ECDSA signatures of
+// other inputs.
+func SignatureFromBytes(signature []byte) (*btcec.Signature, error) {
+	if len(signature) != SignatureSize {
+		return nil, errp.New("A Schnorr signature must be 64 bytes.")
+	}
+	return &btcec.Signature{
+		R: new(big.Int).SetBytes(signature[:32]),
+		S: new(big.Int).SetBytes(signature[32:]),
+	}, nil
+}
+
+// SerializeSignature returns the 64 byte encoding of the Schnorr signature r || s, see
+// SignatureFromBytes.
+func SerializeSignature(signature *btcec.Signature) []byte {
+	return append(xOnly(signature.R), xOnly(signature.S)...)
+}
+
+// VerifyKeyPathSpend checks the witness of the taproot input at the given index, which spends
+// prevOutput via the key path.
+func VerifyKeyPathSpend(
+	sigHashes *SigHashes,
+	transaction *wire.MsgTx,
+	index int,
+	prevOutput *wire.TxOut,
+) error {
+	if !IsPayToTaproot(prevOutput.PkScript) {
+		return errp.Newf("Input %d does not spend a taproot output.", index)
+	}
+	witness := transaction.TxIn[index].Witness
+	if len(witness) != 1 {
+		return errp.Newf("The key path spend of input %d needs exactly one witness element.", index)
+	}
+	signature := witness[0]
+	hashType := SigHashDefault
+	switch len(signature) {
+	case SignatureSize:
+	case SignatureSize + 1:
+		// An explicit SigHashDefault byte is not allowed (BIP341).
+		hashType = txscript.SigHashType(signature[SignatureSize])
+		if hashType == SigHashDefault {
+			return errp.Newf("The signature of input %d has an explicit default sighash type.", index)
+		}
+		signature = signature[:SignatureSize]
+	default:
+		return errp.Newf("The signature of input %d has an invalid size.", index)
+	}
+	signatureHash, err := CalcSignatureHash(sigHashes, hashType, transaction, index, prevOutput)
+	if err != nil {
+		return err
+	}
+	if !Verify(prevOutput.PkScript[2:], signatureHash, signature) {
+		return errp.Newf("The signature of input %d is invalid.", index)
+	}
+	return nil
+}
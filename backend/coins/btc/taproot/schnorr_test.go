@@ -0,0 +1,87 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taproot_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSign checks the signatures of the BIP340 test vectors.
+func TestSign(t *testing.T) {
+	vectors := []struct {
+		privateKey string
+		publicKey  string
+		auxRand    string
+		message    string
+		signature  string
+	}{
+		{
+			privateKey: "0000000000000000000000000000000000000000000000000000000000000003",
+			publicKey:  "F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+			auxRand:    "0000000000000000000000000000000000000000000000000000000000000000",
+			message:    "0000000000000000000000000000000000000000000000000000000000000000",
+			signature: "E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA8215" +
+				"25F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0",
+		},
+		{
+			privateKey: "B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF",
+			publicKey:  "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			auxRand:    "0000000000000000000000000000000000000000000000000000000000000001",
+			message:    "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			signature: "6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE3341" +
+				"8906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+		},
+	}
+	for _, vector := range vectors {
+		privateKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), mustDecodeHex(t, vector.privateKey))
+		publicKey := mustDecodeHex(t, vector.publicKey)
+		message := mustDecodeHex(t, vector.message)
+		signature, err := taproot.Sign(privateKey, message, mustDecodeHex(t, vector.auxRand))
+		require.NoError(t, err)
+		require.Equal(t, strings.ToLower(vector.signature), hex.EncodeToString(signature))
+		require.True(t, taproot.Verify(publicKey, message, signature))
+
+		// A signature of another message or by another key is invalid.
+		otherMessage := append([]byte{}, message...)
+		otherMessage[0] ^= 1
+		require.False(t, taproot.Verify(publicKey, otherMessage, signature))
+		require.False(t, taproot.Verify(mustDecodeHex(t, vectors[0].publicKey), mustDecodeHex(t,
+			"0000000000000000000000000000000000000000000000000000000000000001"), signature))
+	}
+}
+
+// TestTweakPrivateKey checks that the tweaked private key signs for the output key.
+func TestTweakPrivateKey(t *testing.T) {
+	internalKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), mustDecodeHex(t,
+		"B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF"))
+	outputKey, err := taproot.OutputKey(internalKey.PubKey())
+	require.NoError(t, err)
+	privateKey, err := taproot.TweakPrivateKey(internalKey)
+	require.NoError(t, err)
+	hash := make([]byte, 32)
+	signature, err := taproot.Sign(privateKey, hash, make([]byte, 32))
+	require.NoError(t, err)
+	require.True(t, taproot.Verify(outputKey, hash, signature))
+
+	decoded, err := taproot.SignatureFromBytes(signature)
+	require.NoError(t, err)
+	require.Equal(t, signature, taproot.SerializeSignature(decoded))
+}
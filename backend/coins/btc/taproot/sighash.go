@@ -0,0 +1,187 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package taproot implements the parts of BIP340/BIP341 which are not provided by the btcd version
+// this project depends on.
+package taproot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+const (
+	// SigHashDefault commits to the whole transaction like SigHashAll, but the signature is
+	// serialized without an appended sighash byte (BIP341).
+	SigHashDefault txscript.SigHashType = 0x00
+
+	sigHashMask = 0x03
+)
+
+// PrevOutputFetcher returns the output spent by the given outpoint, or nil if it is unknown.
+type PrevOutputFetcher func(wire.OutPoint) *wire.TxOut
+
+// IsPayToTaproot returns whether the pubkey script is a segwit v1 output with a 32 byte program.
+func IsPayToTaproot(pkScript []byte) bool {
+	return len(pkScript) == 34 && pkScript[0] == txscript.OP_1 && pkScript[1] == txscript.OP_DATA_32
+}
+
+// TaggedHash computes the BIP340 tagged hash SHA256(SHA256(tag) || SHA256(tag) || msg...).
+func TaggedHash(tag string, msgs ...[]byte) chainhash.Hash {
+	tagHash := sha256.Sum256([]byte(tag))
+	hasher := sha256.New()
+	_, _ = hasher.Write(tagHash[:])
+	_, _ = hasher.Write(tagHash[:])
+	for _, msg := range msgs {
+		_, _ = hasher.Write(msg)
+	}
+	var hash chainhash.Hash
+	copy(hash[:], hasher.Sum(nil))
+	return hash
+}
+
+// SigHashes contains the BIP341 midstate hashes which are shared among all inputs of a transaction.
+// In contrast to BIP143, they commit to the amounts and pubkey scripts of all spent outputs.
+type SigHashes struct {
+	HashPrevOuts      chainhash.Hash
+	HashAmounts       chainhash.Hash
+	HashScriptPubKeys chainhash.Hash
+	HashSequences     chainhash.Hash
+	HashOutputs       chainhash.Hash
+}
+
+// NewSigHashes computes the midstate hashes of the given transaction. The fetcher has to return the
+// spent output for every input of the transaction.
+func NewSigHashes(transaction *wire.MsgTx, fetchPrevOutput PrevOutputFetcher) (*SigHashes, error) {
+	var prevOuts, amounts, scriptPubKeys, sequences, outputs bytes.Buffer
+	for _, txIn := range transaction.TxIn {
+		prevOutput := fetchPrevOutput(txIn.PreviousOutPoint)
+		if prevOutput == nil {
+			return nil, errp.Newf("The output spent by input %s is missing.", txIn.PreviousOutPoint)
+		}
+		if err := writeOutPoint(&prevOuts, txIn.PreviousOutPoint); err != nil {
+			return nil, err
+		}
+		if err := binary.Write(&amounts, binary.LittleEndian, prevOutput.Value); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if err := wire.WriteVarBytes(&scriptPubKeys, 0, prevOutput.PkScript); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if err := binary.Write(&sequences, binary.LittleEndian, txIn.Sequence); err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	for _, txOut := range transaction.TxOut {
+		if err := wire.WriteTxOut(&outputs, 0, 0, txOut); err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	return &SigHashes{
+		HashPrevOuts:      sha256.Sum256(prevOuts.Bytes()),
+		HashAmounts:       sha256.Sum256(amounts.Bytes()),
+		HashScriptPubKeys: sha256.Sum256(scriptPubKeys.Bytes()),
+		HashSequences:     sha256.Sum256(sequences.Bytes()),
+		HashOutputs:       sha256.Sum256(outputs.Bytes()),
+	}, nil
+}
+
+func writeOutPoint(buffer *bytes.Buffer, outPoint wire.OutPoint) error {
+	_, _ = buffer.Write(outPoint.Hash[:])
+	return errp.WithStack(binary.Write(buffer, binary.LittleEndian, outPoint.Index))
+}
+
+// CalcSignatureHash computes the BIP341 signature hash of the input at the given index for a key
+// path spend. prevOutput is the output spent by this input.
+func CalcSignatureHash(
+	sigHashes *SigHashes,
+	hashType txscript.SigHashType,
+	transaction *wire.MsgTx,
+	index int,
+	prevOutput *wire.TxOut,
+) ([]byte, error) {
+	switch hashType {
+	case SigHashDefault, txscript.SigHashAll, txscript.SigHashNone, txscript.SigHashSingle,
+		txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+		txscript.SigHashNone | txscript.SigHashAnyOneCanPay,
+		txscript.SigHashSingle | txscript.SigHashAnyOneCanPay:
+	default:
+		return nil, errp.Newf("Invalid taproot sighash type %#x.", uint32(hashType))
+	}
+	if index < 0 || index >= len(transaction.TxIn) {
+		return nil, errp.Newf("Input index %d is out of range.", index)
+	}
+	outputType := hashType & sigHashMask
+	anyoneCanPay := hashType&txscript.SigHashAnyOneCanPay != 0
+	if outputType == txscript.SigHashSingle && index >= len(transaction.TxOut) {
+		return nil, errp.New("SigHashSingle requires an output at the index of the input.")
+	}
+
+	// The leading zero byte is the sighash epoch.
+	var msg bytes.Buffer
+	msg.WriteByte(0x00)
+	msg.WriteByte(byte(hashType))
+	if err := binary.Write(&msg, binary.LittleEndian, transaction.Version); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := binary.Write(&msg, binary.LittleEndian, transaction.LockTime); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if !anyoneCanPay {
+		_, _ = msg.Write(sigHashes.HashPrevOuts[:])
+		_, _ = msg.Write(sigHashes.HashAmounts[:])
+		_, _ = msg.Write(sigHashes.HashScriptPubKeys[:])
+		_, _ = msg.Write(sigHashes.HashSequences[:])
+	}
+	if outputType != txscript.SigHashNone && outputType != txscript.SigHashSingle {
+		_, _ = msg.Write(sigHashes.HashOutputs[:])
+	}
+	// spend_type: key path spend (ext_flag = 0) without annex.
+	msg.WriteByte(0x00)
+	if anyoneCanPay {
+		txIn := transaction.TxIn[index]
+		if err := writeOutPoint(&msg, txIn.PreviousOutPoint); err != nil {
+			return nil, err
+		}
+		if err := binary.Write(&msg, binary.LittleEndian, prevOutput.Value); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if err := wire.WriteVarBytes(&msg, 0, prevOutput.PkScript); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		if err := binary.Write(&msg, binary.LittleEndian, txIn.Sequence); err != nil {
+			return nil, errp.WithStack(err)
+		}
+	} else {
+		if err := binary.Write(&msg, binary.LittleEndian, uint32(index)); err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	if outputType == txscript.SigHashSingle {
+		var output bytes.Buffer
+		if err := wire.WriteTxOut(&output, 0, 0, transaction.TxOut[index]); err != nil {
+			return nil, errp.WithStack(err)
+		}
+		hash := sha256.Sum256(output.Bytes())
+		_, _ = msg.Write(hash[:])
+	}
+	hash := TaggedHash("TapSighash", msg.Bytes())
+	return hash[:], nil
+}
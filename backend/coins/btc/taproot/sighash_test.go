@@ -0,0 +1,105 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package taproot_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	decoded, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return decoded
+}
+
+// TestCalcSignatureHash checks the key path sighashes against the ones computed by btcd v0.24.
+func TestCalcSignatureHash(t *testing.T) {
+	transaction := wire.NewMsgTx(0)
+	require.NoError(t, transaction.Deserialize(bytes.NewReader(mustDecodeHex(t,
+		"0200000002bf5d3affb73efd2ec6c36ad3112dd933efed63c4e1cbffcfa88e2759c144f2d80100000000fd"+
+			"ffffff39361160903c6695c6804b7157c7bd10013e9ba89b1f954243bc8e3990b08db90000000000ffff"+
+			"ffff0250c3000000000000160014751e76e8199196d454941c45d1b3a323f1433bd63930000000000000"+
+			"225120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c10eb0900"))))
+	prevOutputs := map[wire.OutPoint]*wire.TxOut{
+		transaction.TxIn[0].PreviousOutPoint: wire.NewTxOut(70000, mustDecodeHex(t,
+			"51200f0c8db753acbd17343a39c2f3f4e35e4be6da749f9e35137ab220e7b238a667")),
+		transaction.TxIn[1].PreviousOutPoint: wire.NewTxOut(8000, mustDecodeHex(t,
+			"0014d85c2b71d0060b09c9886aeb815e50991dda124d")),
+	}
+	fetch := func(outPoint wire.OutPoint) *wire.TxOut { return prevOutputs[outPoint] }
+	sigHashes, err := taproot.NewSigHashes(transaction, fetch)
+	require.NoError(t, err)
+
+	expected := map[txscript.SigHashType][2]string{
+		taproot.SigHashDefault: {
+			"c997e25a9517331c3e25ac3c934833697c0ba4e666376cdedbfe1b86393dff8f",
+			"1ffa3bc39a8884cb9aa15673c1d9363ef0f58285fe54adc9cc7d6bb451ab6172",
+		},
+		txscript.SigHashAll: {
+			"93d1e0b8c319787674070f23e349d85faf332782e0dd32362fbbd4272a8b650c",
+			"60bb7618ad2fd1fc79a98adfa0f0662ac01f7dac63a46ea730034814120adad1",
+		},
+		txscript.SigHashNone: {
+			"ab2700f37674c49abc3c791d7d8bb3c529885a052035be29bd8e094c6c0dfd72",
+			"2b8b3826332958fb45f95d11002869ce30bf9ce1445fa5c9ff51f9e66d253b00",
+		},
+		txscript.SigHashSingle: {
+			"26f00aaff5aab3cc38c6b0e100a8dff4c75f43b7995ea9258d755283eeb05403",
+			"9655fa7cf52cc93571fb22662e9ed54f9cf7cb18b2ccd06481faff9cc0100a56",
+		},
+		txscript.SigHashAll | txscript.SigHashAnyOneCanPay: {
+			"887eabb4a78ae64df87333984b28b86ad6d671c31b07cc2c72cad72fa564649f",
+			"7396b0a85ca0a9905d0cd969591bf89da4bee8fe44718f0ea13681de36634ca9",
+		},
+		txscript.SigHashNone | txscript.SigHashAnyOneCanPay: {
+			"4c379b632632c74e1b54b6da4556f54d30624a4db444e49f5c09e82415c434a3",
+			"9ed3b7967c214166748694a0fbffcad61cb607764c9fc6653f4296cd10e6b7a1",
+		},
+		txscript.SigHashSingle | txscript.SigHashAnyOneCanPay: {
+			"db2f9ee98336721bd4b81b6c557b9b749754da6cf6307587152a919c2316bbf8",
+			"cead1a8fa1484b974165fde0d1b00085341644ea4562ff940e854e8ba93d7089",
+		},
+	}
+	for hashType, hashes := range expected {
+		for index, expectedHash := range hashes {
+			sigHash, err := taproot.CalcSignatureHash(
+				sigHashes, hashType, transaction, index,
+				fetch(transaction.TxIn[index].PreviousOutPoint))
+			require.NoError(t, err)
+			require.Equal(t, expectedHash, hex.EncodeToString(sigHash), "hashType %#x, input %d", hashType, index)
+		}
+	}
+
+	_, err = taproot.CalcSignatureHash(sigHashes, 0x04, transaction, 0, prevOutputs[transaction.TxIn[0].PreviousOutPoint])
+	require.Error(t, err)
+
+	_, err = taproot.NewSigHashes(transaction, func(wire.OutPoint) *wire.TxOut { return nil })
+	require.Error(t, err)
+}
+
+func TestIsPayToTaproot(t *testing.T) {
+	require.True(t, taproot.IsPayToTaproot(mustDecodeHex(t,
+		"51200f0c8db753acbd17343a39c2f3f4e35e4be6da749f9e35137ab220e7b238a667")))
+	require.False(t, taproot.IsPayToTaproot(mustDecodeHex(t,
+		"0014d85c2b71d0060b09c9886aeb815e50991dda124d")))
+}
@@ -17,58 +17,148 @@ package btc
 import (
 	"math/big"
 
-	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/silentpayments"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/sirupsen/logrus"
 )
 
 // unitSatoshi is 1 BTC (default unit) in Satoshi.
 const unitSatoshi = 1e8
 
-// newTx creates a new tx to the given recipient address. It also returns a set of used account
-// outputs, which contains all outputs that spent in the tx. Those are needed to be able to sign the
-// transaction. selectedUTXOs restricts the available coins; if empty, no restriction is applied and
-// all unspent coins can be used.
-func (account *Account) newTx(
-	recipientAddress string,
-	amount coin.SendAmount,
-	feeTargetCode FeeTargetCode,
-	selectedUTXOs map[wire.OutPoint]struct{},
-) (
-	map[wire.OutPoint]*transactions.SpendableOutput, *maketx.TxProposal, error) {
+// Recipient is an address and the amount to be sent to it in a new transaction.
+type Recipient struct {
+	Address string
+	Amount  coin.SendAmount
+}
 
-	account.log.Debug("Prepare new transaction")
+// TxProposalArgs are the arguments needed to create a new transaction.
+type TxProposalArgs struct {
+	// Recipients are paid in the same transaction. Sending all funds is only possible if there is
+	// exactly one recipient.
+	Recipients    []*Recipient
+	FeeTargetCode FeeTargetCode
+	// CustomFee is the fee rate in sat/vB, or the priority fee in Gwei for Ethereum. It is only used
+	// if FeeTargetCode is FeeTargetCodeCustom.
+	CustomFee string
+	// SelectedUTXOs are the coins to spend. If empty, the coins are selected among all unspent
+	// coins. When sending all funds, all selected coins are spent and the other coins are left
+	// untouched.
+	SelectedUTXOs map[wire.OutPoint]struct{}
+	// OpReturnData is added to the transaction in an OP_RETURN output if it is not empty. It can be
+	// at most 80 bytes.
+	OpReturnData []byte
+	// PayjoinEndpoint is the pj parameter of the payment URI. If set, SendTx makes a payjoin with
+	// the receiver (BIP78).
+	PayjoinEndpoint string
+	// DisableRBF opts the transaction out of replace-by-fee (BIP125). By default, the transaction
+	// is replaceable so that its fee can be bumped while it is pending.
+	DisableRBF bool
+	// Nonce is the nonce of an Ethereum transaction. A pending transaction with the same nonce is
+	// replaced. If nil, the next nonce is used.
+	Nonce *uint64
+	// Data is the calldata of an Ethereum transaction, used to interact with arbitrary contracts.
+	Data []byte
+	// GasLimit is the gas limit of an Ethereum transaction. If 0, the gas is estimated.
+	GasLimit uint64
+}
 
-	address, err := btcutil.DecodeAddress(recipientAddress, account.coin.Net())
-	if err != nil {
-		return nil, nil, errp.WithStack(coin.ErrInvalidAddress)
+// TxProposalResult contains the information about a proposed transaction which is displayed in
+// the UI.
+type TxProposalResult struct {
+	// Amount is the amount received by the recipient.
+	Amount coin.Amount
+	// Fee is the absolute fee paid by the transaction.
+	Fee coin.Amount
+	// FeeCoin is the coin in which the fee is paid, if it is not the coin of the account, e.g.
+	// Ether for ERC20 tokens. It is nil otherwise.
+	FeeCoin coin.Coin
+	// Total is Amount+Fee, or Amount if the fee is paid in another coin.
+	Total coin.Amount
+	// VSize is the estimated virtual size of the signed transaction in vbytes. It is 0 for coins
+	// which do not have this notion.
+	VSize int
+	// DustChange is the change which is added to the fee because it would be dust. It is included
+	// in Fee. It is zero if there is no such change.
+	DustChange coin.Amount
+	// Data is the calldata of an Ethereum contract interaction, which is displayed for
+	// verification. It is empty for other transactions.
+	Data []byte
+	// GasLimit is the gas limit of an Ethereum transaction. It is 0 for other coins.
+	GasLimit uint64
+	// Warnings are the properties of the proposal which the user should check before sending.
+	Warnings []coin.TxWarning
+	// RecipientContractName is the verified name of the contract receiving an Ethereum
+	// transaction, if known. It is empty for other recipients.
+	RecipientContractName string
+}
+
+// customFeeRatePerKb parses the fee rate in sat/vB entered by the user. Fee rates below the
+// minimum relay fee rate are rejected, as the transaction would not be relayed.
+func customFeeRatePerKb(customFee string) (btcutil.Amount, error) {
+	feeRatePerVByte, ok := new(big.Rat).SetString(customFee)
+	if !ok {
+		return 0, errp.WithStack(coin.ErrInvalidFeeRate)
 	}
-	if !address.IsForNet(account.coin.Net()) {
-		return nil, nil, errp.WithStack(coin.ErrInvalidAddress)
+	feeRatePerKb := new(big.Rat).Mul(feeRatePerVByte, big.NewRat(1000, 1))
+	// Round down to whole satoshis.
+	feeRatePerKbInt := new(big.Int).Quo(feeRatePerKb.Num(), feeRatePerKb.Denom())
+	if !feeRatePerKbInt.IsInt64() {
+		return 0, errp.WithStack(coin.ErrInvalidFeeRate)
+	}
+	if btcutil.Amount(feeRatePerKbInt.Int64()) < mempool.DefaultMinRelayTxFee {
+		return 0, errp.WithStack(coin.ErrFeeTooLow)
 	}
+	return btcutil.Amount(feeRatePerKbInt.Int64()), nil
+}
 
-	var feeTarget *FeeTarget
+// feeRatePerKb returns the estimated fee rate of the given fee target.
+func (account *Account) feeRatePerKb(feeTargetCode FeeTargetCode) (btcutil.Amount, error) {
 	for _, target := range account.feeTargets {
-		if target.Code == feeTargetCode {
-			feeTarget = target
-			break
+		if target.Code == feeTargetCode && target.FeeRatePerKb != nil {
+			return *target.FeeRatePerKb, nil
 		}
 	}
-	if feeTarget == nil || feeTarget.FeeRatePerKb == nil {
-		return nil, nil, errp.New("Fee could not be estimated")
+	return 0, errp.New("Fee could not be estimated")
+}
+
+// txFeeRatePerKb returns the fee rate of the given fee target, or the custom fee rate in sat/vB if
+// the fee target is FeeTargetCodeCustom.
+func (account *Account) txFeeRatePerKb(feeTargetCode FeeTargetCode, customFee string) (
+	btcutil.Amount, error) {
+	if feeTargetCode == FeeTargetCodeCustom {
+		return customFeeRatePerKb(customFee)
 	}
+	return account.feeRatePerKb(feeTargetCode)
+}
 
-	pkScript, err := txscript.PayToAddrScript(address)
+// coinControlOutputs returns all spendable outputs and the ones among them which can be spent in
+// a new transaction. If selectedUTXOs is not empty, only those can be spent. Frozen outputs
+// cannot be spent.
+func (account *Account) coinControlOutputs(selectedUTXOs map[wire.OutPoint]struct{}) (
+	map[wire.OutPoint]*transactions.SpendableOutput, map[wire.OutPoint]*wire.TxOut, error) {
+	utxo := account.transactions.SpendableOutputs()
+	frozenOutputs, err := account.frozenOutputs()
 	if err != nil {
-		return nil, nil, errp.WithStack(err)
+		return nil, nil, err
+	}
+	for outPoint := range selectedUTXOs {
+		if _, ok := utxo[outPoint]; !ok {
+			return nil, nil, errp.Newf("The selected output %s is not spendable.", outPoint)
+		}
+		if _, ok := frozenOutputs[outPoint]; ok {
+			return nil, nil, errp.Newf("The selected output %s is frozen.", outPoint)
+		}
 	}
-	utxo := account.transactions.SpendableOutputs()
 	wireUTXO := make(map[wire.OutPoint]*wire.TxOut, len(utxo))
 	for outPoint, txOut := range utxo {
 		// Apply coin control.
@@ -77,36 +167,129 @@ func (account *Account) newTx(
 				continue
 			}
 		}
+		// Frozen outputs are never selected automatically.
+		if _, ok := frozenOutputs[outPoint]; ok {
+			continue
+		}
 		wireUTXO[outPoint] = txOut.TxOut
 	}
+	return utxo, wireUTXO, nil
+}
+
+// recipientPkScript returns the output script paying to the given address.
+func (account *Account) recipientPkScript(recipientAddress string) ([]byte, error) {
+	address, err := taproot.DecodeAddress(recipientAddress, account.coin.Net())
+	if err != nil {
+		return nil, errp.WithStack(coin.ErrInvalidAddress)
+	}
+	if !address.IsForNet(account.coin.Net()) {
+		return nil, errp.WithStack(coin.ErrInvalidAddress)
+	}
+	pkScript, err := taproot.PayToAddrScript(address)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return pkScript, nil
+}
+
+// newTx creates a new tx to the given recipients. It also returns a set of used account outputs,
+// which contains all outputs that spent in the tx. Those are needed to be able to sign the
+// transaction.
+func (account *Account) newTx(args *TxProposalArgs) (
+	map[wire.OutPoint]*transactions.SpendableOutput, *maketx.TxProposal, error) {
+
+	account.log.Debug("Prepare new transaction")
+
+	if len(args.Recipients) == 0 {
+		return nil, nil, errp.WithStack(coin.ErrInvalidAddress)
+	}
+	pkScripts := make([][]byte, len(args.Recipients))
+	silentPaymentRecipients := map[int]*silentpayments.Address{}
+	for i, recipient := range args.Recipients {
+		if silentPaymentAddress, err := silentpayments.DecodeAddress(
+			recipient.Address, account.coin.Net()); err == nil {
+			if args.PayjoinEndpoint != "" {
+				return nil, nil, errp.New("Silent payments cannot be made with payjoin.")
+			}
+			silentPaymentRecipients[i] = silentPaymentAddress
+			pkScripts[i] = silentPaymentPlaceholderPkScript(i)
+			continue
+		}
+		pkScript, err := account.recipientPkScript(recipient.Address)
+		if err != nil {
+			return nil, nil, err
+		}
+		pkScripts[i] = pkScript
+	}
+
+	feeRatePerKb, err := account.txFeeRatePerKb(args.FeeTargetCode, args.CustomFee)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	utxo, wireUTXO, err := account.coinControlOutputs(args.SelectedUTXOs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var opReturnOutput *wire.TxOut
+	if len(args.OpReturnData) != 0 {
+		opReturnOutput, err = maketx.NewOpReturnOutput(args.OpReturnData)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	newTx := maketx.NewTx
+	if len(args.SelectedUTXOs) != 0 {
+		// Spend exactly the outputs chosen by the user.
+		newTx = maketx.NewTxFromSelectedOutputs
+	}
+	sendAll := false
+	for _, recipient := range args.Recipients {
+		if recipient.Amount.SendAll() {
+			sendAll = true
+		}
+	}
 	var txProposal *maketx.TxProposal
-	if amount.SendAll() {
+	if sendAll {
+		if len(args.Recipients) != 1 {
+			return nil, nil, errp.New("Sending all funds is only possible to a single recipient.")
+		}
 		txProposal, err = maketx.NewTxSpendAll(
 			account.coin,
 			account.signingConfiguration,
 			wireUTXO,
-			pkScript,
-			*feeTarget.FeeRatePerKb,
+			pkScripts[0],
+			opReturnOutput,
+			feeRatePerKb,
+			account.dustLimit,
 			account.log,
 		)
 		if err != nil {
 			return nil, nil, err
 		}
 	} else {
-		parsedAmount, err := amount.Amount(big.NewInt(unitSatoshi))
-		if err != nil {
-			return nil, nil, err
+		outputs := make([]*wire.TxOut, len(args.Recipients))
+		for i, recipient := range args.Recipients {
+			parsedAmount, err := recipient.Amount.Amount(big.NewInt(unitSatoshi))
+			if err != nil {
+				return nil, nil, err
+			}
+			parsedAmountInt64, err := parsedAmount.Int64()
+			if err != nil {
+				return nil, nil, errp.WithStack(coin.ErrInvalidAmount)
+			}
+			outputs[i] = wire.NewTxOut(parsedAmountInt64, pkScripts[i])
 		}
-		parsedAmountInt64, err := parsedAmount.Int64()
-		if err != nil {
-			return nil, nil, errp.WithStack(coin.ErrInvalidAmount)
+		if opReturnOutput != nil {
+			outputs = append(outputs, opReturnOutput)
 		}
-		txProposal, err = maketx.NewTx(
+		txProposal, err = newTx(
 			account.coin,
 			account.signingConfiguration,
 			wireUTXO,
-			wire.NewTxOut(parsedAmountInt64, pkScript),
-			*feeTarget.FeeRatePerKb,
+			outputs,
+			feeRatePerKb,
+			account.dustLimit,
 			func() *addresses.AccountAddress {
 				return account.changeAddresses.GetUnused()[0]
 			},
@@ -116,67 +299,262 @@ func (account *Account) newTx(
 			return nil, nil, err
 		}
 	}
+	if len(silentPaymentRecipients) != 0 {
+		if err := account.setSilentPaymentOutputs(
+			txProposal.Transaction, utxo, silentPaymentRecipients); err != nil {
+			return nil, nil, err
+		}
+	}
+	txProposal.SetOrdering(account.txOrdering)
+	if !args.DisableRBF {
+		maketx.SetReplaceable(txProposal.Transaction)
+	}
+	maketx.SetAntiFeeSnipingLockTime(txProposal.Transaction, account.antiFeeSnipingTipHeight())
 	account.log.Debugf("creating tx with %d inputs, %d outputs",
 		len(txProposal.Transaction.TxIn), len(txProposal.Transaction.TxOut))
 	return utxo, txProposal, nil
 }
 
+// antiFeeSnipingTipHeight returns the height of the tip to use as the locktime of new
+// transactions, or -1 if the headers are not synced, as the tip could be far behind.
+func (account *Account) antiFeeSnipingTipHeight() int {
+	status, err := account.headers.Status()
+	if err != nil {
+		account.log.WithError(err).Error("Could not get the headers status")
+		return -1
+	}
+	if status.Tip < status.TargetHeight {
+		return -1
+	}
+	return status.Tip
+}
+
+// getAddress returns the receive or change address with the given pubkey script hash, or nil if it
+// does not belong to the account.
+func (account *Account) getAddress(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
+	if address := account.receiveAddresses.LookupByScriptHashHex(scriptHashHex); address != nil {
+		return address
+	}
+	return account.changeAddresses.LookupByScriptHashHex(scriptHashHex)
+}
+
 // SendTx creates, signs and sends tx which sends `amount` to the recipient.
-func (account *Account) SendTx(
-	recipientAddress string,
-	amount coin.SendAmount,
-	feeTargetCode FeeTargetCode,
-	selectedUTXOs map[wire.OutPoint]struct{},
-) error {
+func (account *Account) SendTx(args *TxProposalArgs) error {
+	if args.PayjoinEndpoint != "" {
+		return account.sendPayjoinTx(args)
+	}
 	account.log.Info("Signing and sending transaction")
-	utxo, txProposal, err := account.newTx(
-		recipientAddress,
-		amount,
-		feeTargetCode,
-		selectedUTXOs,
-	)
+	utxo, txProposal, err := account.newTx(args)
 	if err != nil {
 		return errp.WithMessage(err, "Failed to create transaction")
 	}
-	getAddress := func(scriptHashHex blockchain.ScriptHashHex) *addresses.AccountAddress {
-		if address := account.receiveAddresses.LookupByScriptHashHex(scriptHashHex); address != nil {
-			return address
+	if err := SignTransaction(account.keystores, txProposal, utxo, account.getAddress,
+		account.transactions.Transaction, nil, account.notifySignProgress, account.log); err != nil {
+		return errp.WithMessage(err, "Failed to sign transaction")
+	}
+	account.log.Info("Signed transaction is broadcasted")
+	return account.blockchain.TransactionBroadcast(txProposal.Transaction)
+}
+
+// BumpFee replaces the given unconfirmed transaction, which has to signal replaceability, with one
+// paying the fee rate of the given fee target. The higher fee is paid from the change. The
+// replacement is signed and broadcasted. Returns keystore.ErrSigningAborted on user abort.
+func (account *Account) BumpFee(txHash chainhash.Hash, feeTargetCode FeeTargetCode) error {
+	account.log.WithField("txid", txHash.String()).Info("Bumping the fee of a transaction")
+	transaction, height := account.transactions.TransactionWithHeight(txHash)
+	if transaction == nil {
+		return errp.New("The transaction is unknown.")
+	}
+	if height > 0 {
+		return errp.New("The transaction is already confirmed.")
+	}
+	feeRatePerKb, err := account.feeRatePerKb(feeTargetCode)
+	if err != nil {
+		return err
+	}
+	previousOutputs := map[wire.OutPoint]*transactions.SpendableOutput{}
+	spentOutputs := map[wire.OutPoint]*wire.TxOut{}
+	for index, txIn := range transaction.TxIn {
+		outPoint := txIn.PreviousOutPoint
+		previousTransaction := account.transactions.Transaction(outPoint.Hash)
+		if previousTransaction == nil || int(outPoint.Index) >= len(previousTransaction.TxOut) {
+			return errp.Newf("The output spent by input %d is unknown.", index)
 		}
-		if address := account.changeAddresses.LookupByScriptHashHex(scriptHashHex); address != nil {
-			return address
+		spentOutput := &transactions.SpendableOutput{TxOut: previousTransaction.TxOut[outPoint.Index]}
+		if account.getAddress(spentOutput.ScriptHashHex()) == nil {
+			return errp.Newf("The output spent by input %d does not belong to the account.", index)
 		}
-		panic("address must be present")
+		previousOutputs[outPoint] = spentOutput
+		spentOutputs[outPoint] = spentOutput.TxOut
 	}
-	if err := SignTransaction(account.keystores, txProposal, utxo, getAddress, account.log); err != nil {
-		return errp.WithMessage(err, "Failed to sign transaction")
+	var changeAddress *addresses.AccountAddress
+	for _, txOut := range transaction.TxOut {
+		changeAddress = account.changeAddresses.LookupByScriptHashHex(
+			blockchain.ScriptHashHex(chainhash.HashH(txOut.PkScript).String()))
+		if changeAddress != nil {
+			break
+		}
 	}
-	account.log.Info("Signed transaction is broadcasted")
+	txProposal, err := maketx.NewTxBumpFee(
+		account.coin,
+		account.signingConfiguration,
+		transaction,
+		spentOutputs,
+		changeAddress,
+		feeRatePerKb,
+		account.dustLimit,
+		account.log,
+	)
+	if err != nil {
+		return errp.WithMessage(err, "Failed to create replacement transaction")
+	}
+	txProposal.SetOrdering(account.txOrdering)
+	if err := SignTransaction(account.keystores, txProposal, previousOutputs, account.getAddress,
+		account.transactions.Transaction, nil, account.notifySignProgress, account.log); err != nil {
+		return errp.WithMessage(err, "Failed to sign replacement transaction")
+	}
+	account.log.WithField("fee", txProposal.Fee).Info("Signed replacement transaction is broadcasted")
 	return account.blockchain.TransactionBroadcast(txProposal.Transaction)
 }
 
-// TxProposal creates a tx from the relevant input and returns information about it for display in
-// the UI (the output amount and the fee). At the same time, it validates the input.
-func (account *Account) TxProposal(
-	recipientAddress string,
-	amount coin.SendAmount,
-	feeTargetCode FeeTargetCode,
-	selectedUTXOs map[wire.OutPoint]struct{},
-) (
-	coin.Amount, coin.Amount, coin.Amount, error) {
+// fetchTransaction returns the transaction with the given hash from the database, or downloads it
+// if it is not stored.
+func (account *Account) fetchTransaction(txHash chainhash.Hash) (*wire.MsgTx, error) {
+	if transaction := account.transactions.Transaction(txHash); transaction != nil {
+		return transaction, nil
+	}
+	var transaction *wire.MsgTx
+	done := make(chan struct{})
+	account.blockchain.TransactionGet(
+		txHash,
+		func(tx *wire.MsgTx) error {
+			transaction = tx
+			return nil
+		},
+		func() { close(done) },
+	)
+	<-done
+	if transaction == nil {
+		return nil, errp.Newf("Failed to download transaction %s.", txHash)
+	}
+	return transaction, nil
+}
 
-	account.log.Debug("Proposing transaction")
-	_, txProposal, err := account.newTx(
-		recipientAddress,
-		amount,
-		feeTargetCode,
-		selectedUTXOs,
+// newCPFPTx creates a child transaction which spends our outputs of the given unconfirmed parent
+// transaction to a change address, paying a fee such that both together pay the fee rate of the
+// given fee target.
+func (account *Account) newCPFPTx(parentTxHash chainhash.Hash, feeTargetCode FeeTargetCode) (
+	map[wire.OutPoint]*transactions.SpendableOutput, *maketx.CPFPTxProposal, error) {
+	parent, height := account.transactions.TransactionWithHeight(parentTxHash)
+	if parent == nil {
+		return nil, nil, errp.New("The transaction is unknown.")
+	}
+	if height > 0 {
+		return nil, nil, errp.New("The transaction is already confirmed.")
+	}
+	feeRatePerKb, err := account.feeRatePerKb(feeTargetCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	parentInputsSum := btcutil.Amount(0)
+	for _, txIn := range parent.TxIn {
+		outPoint := txIn.PreviousOutPoint
+		previousTransaction, err := account.fetchTransaction(outPoint.Hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		if int(outPoint.Index) >= len(previousTransaction.TxOut) {
+			return nil, nil, errp.New("The parent transaction spends an unknown output.")
+		}
+		parentInputsSum += btcutil.Amount(previousTransaction.TxOut[outPoint.Index].Value)
+	}
+	parentOutputsSum := btcutil.Amount(0)
+	for _, txOut := range parent.TxOut {
+		parentOutputsSum += btcutil.Amount(txOut.Value)
+	}
+	if parentOutputsSum > parentInputsSum {
+		return nil, nil, errp.New("The outputs of the parent transaction exceed its inputs.")
+	}
+
+	utxo := account.transactions.OutputsOfTx(parentTxHash)
+	wireUTXO := make(map[wire.OutPoint]*wire.TxOut, len(utxo))
+	for outPoint, txOut := range utxo {
+		wireUTXO[outPoint] = txOut.TxOut
+	}
+	txProposal, err := maketx.NewTxCPFP(
+		account.coin,
+		account.signingConfiguration,
+		wireUTXO,
+		account.changeAddresses.GetUnused()[0].PubkeyScript(),
+		parentInputsSum-parentOutputsSum,
+		int(mempool.GetTxVirtualSize(btcutil.NewTx(parent))),
+		feeRatePerKb,
+		account.dustLimit,
+		account.log,
 	)
 	if err != nil {
-		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+		return nil, nil, err
+	}
+	txProposal.SetOrdering(account.txOrdering)
+	maketx.SetAntiFeeSnipingLockTime(txProposal.Transaction, account.antiFeeSnipingTipHeight())
+	return utxo, txProposal, nil
+}
+
+// CPFPProposal creates a child transaction like SendCPFP and returns its fee and the effective fee
+// rate per kB of the parent and the child combined for display in the UI.
+func (account *Account) CPFPProposal(parentTxHash chainhash.Hash, feeTargetCode FeeTargetCode) (
+	coin.Amount, coin.Amount, error) {
+	account.log.Debug("Proposing child transaction")
+	utxo, txProposal, err := account.newCPFPTx(parentTxHash, feeTargetCode)
+	if err != nil {
+		return coin.Amount{}, coin.Amount{}, err
+	}
+	if err := DryRunSignTransaction(txProposal.TxProposal, utxo, account.getAddress, nil); err != nil {
+		return coin.Amount{}, coin.Amount{}, err
+	}
+	return coin.NewAmountFromInt64(int64(txProposal.Fee)),
+		coin.NewAmountFromInt64(int64(txProposal.EffectiveFeePerKb)), nil
+}
+
+// SendCPFP speeds up the confirmation of the given unconfirmed transaction by spending our outputs
+// of it to ourselves with a fee which raises the fee rate of both to the one of the given fee
+// target (child pays for parent). Returns keystore.ErrSigningAborted on user abort.
+func (account *Account) SendCPFP(parentTxHash chainhash.Hash, feeTargetCode FeeTargetCode) error {
+	account.log.WithField("txid", parentTxHash.String()).Info("Paying for an unconfirmed transaction")
+	utxo, txProposal, err := account.newCPFPTx(parentTxHash, feeTargetCode)
+	if err != nil {
+		return errp.WithMessage(err, "Failed to create child transaction")
+	}
+	if err := SignTransaction(account.keystores, txProposal.TxProposal, utxo, account.getAddress,
+		account.transactions.Transaction, nil, account.notifySignProgress, account.log); err != nil {
+		return errp.WithMessage(err, "Failed to sign child transaction")
+	}
+	account.log.WithFields(logrus.Fields{
+		"fee":               txProposal.Fee,
+		"effectiveFeePerKb": txProposal.EffectiveFeePerKb,
+	}).Info("Signed child transaction is broadcasted")
+	return account.blockchain.TransactionBroadcast(txProposal.Transaction)
+}
+
+// TxProposal creates a tx from the relevant input and returns information about it for display in
+// the UI (the output amount, the fee and the size). At the same time, it validates the input.
+func (account *Account) TxProposal(args *TxProposalArgs) (*TxProposalResult, error) {
+	account.log.Debug("Proposing transaction")
+	utxo, txProposal, err := account.newTx(args)
+	if err != nil {
+		return nil, err
+	}
+	// Catch malformed proposals now instead of after the user confirmed sending.
+	if err := DryRunSignTransaction(txProposal, utxo, account.getAddress, nil); err != nil {
+		return nil, err
 	}
 
 	account.log.WithField("fee", txProposal.Fee).Debug("Returning fee")
-	return coin.NewAmountFromInt64(int64(txProposal.Amount)),
-		coin.NewAmountFromInt64(int64(txProposal.Fee)),
-		coin.NewAmountFromInt64(int64(txProposal.Total())), nil
+	return &TxProposalResult{
+		Amount:     coin.NewAmountFromInt64(int64(txProposal.Amount)),
+		Fee:        coin.NewAmountFromInt64(int64(txProposal.Fee)),
+		Total:      coin.NewAmountFromInt64(int64(txProposal.Total())),
+		VSize:      txProposal.VSize(),
+		DustChange: coin.NewAmountFromInt64(int64(txProposal.DustChange)),
+	}, nil
 }
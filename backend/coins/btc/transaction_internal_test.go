@@ -0,0 +1,45 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btc
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomFeeRatePerKb(t *testing.T) {
+	for customFee, expected := range map[string]btcutil.Amount{
+		"1":       1000,
+		"1.5":     1500,
+		"12.3456": 12345,
+		"250":     250000,
+	} {
+		feeRatePerKb, err := customFeeRatePerKb(customFee)
+		require.NoError(t, err, customFee)
+		require.Equal(t, expected, feeRatePerKb, customFee)
+	}
+	for _, customFee := range []string{"0", "0.999", "-1"} {
+		_, err := customFeeRatePerKb(customFee)
+		require.Equal(t, coin.ErrFeeTooLow, errp.Cause(err), customFee)
+	}
+	for _, customFee := range []string{"", "abc", "1e100"} {
+		_, err := customFeeRatePerKb(customFee)
+		require.Equal(t, coin.ErrInvalidFeeRate, errp.Cause(err), customFee)
+	}
+}
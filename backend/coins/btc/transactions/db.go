@@ -81,6 +81,46 @@ type DBTxInterface interface {
 
 	// AddressHistory retrieves an address history. If not found, returns an empty history.
 	AddressHistory(blockchain.ScriptHashHex) (blockchain.TxHistory, error)
+
+	// ClearTransactions deletes all transactions, inputs, outputs and address histories, so that
+	// they are synced again from scratch. Notes, labels and frozen outputs are kept.
+	ClearTransactions() error
+
+	// PutTxNote stores the note of a transaction. Notes are stored per keystore, identified by its
+	// fingerprint, and are kept if the transaction is deleted. An empty note deletes the note.
+	PutTxNote(keystoreFingerprint string, txHash chainhash.Hash, note string) error
+
+	// TxNote retrieves the note of a transaction. An empty string is returned if not found.
+	TxNote(keystoreFingerprint string, txHash chainhash.Hash) (string, error)
+
+	// PutAddressLabel stores the label of an address. An empty label deletes the label.
+	PutAddressLabel(scriptHashHex blockchain.ScriptHashHex, label string) error
+
+	// TxNotes retrieves all notes of the keystore with the given fingerprint by tx hash.
+	TxNotes(keystoreFingerprint string) (map[chainhash.Hash]string, error)
+
+	// AddressLabel retrieves the label of an address. An empty string is returned if not found.
+	AddressLabel(scriptHashHex blockchain.ScriptHashHex) (string, error)
+
+	// PutOutputLabel stores the label of an output. Labels are kept when the output is spent. An
+	// empty label deletes the label.
+	PutOutputLabel(outPoint wire.OutPoint, label string) error
+
+	// OutputLabel retrieves the label of an output. An empty string is returned if not found.
+	OutputLabel(outPoint wire.OutPoint) (string, error)
+
+	// OutputLabels retrieves all output labels.
+	OutputLabels() (map[wire.OutPoint]string, error)
+
+	// FreezeOutput marks an output as frozen, so that it is not spent unless unfrozen. The reason
+	// can be empty.
+	FreezeOutput(outPoint wire.OutPoint, reason string) error
+
+	// UnfreezeOutput unfreezes an output (nothing happens if it is not frozen).
+	UnfreezeOutput(outPoint wire.OutPoint) error
+
+	// FrozenOutputs retrieves the reasons of all frozen outputs by outpoint.
+	FrozenOutputs() (map[wire.OutPoint]string, error)
 }
 
 // DBInterface can be implemented by database backends to open database transactions.
@@ -15,6 +15,8 @@
 package transactions
 
 import (
+	"bytes"
+	"encoding/hex"
 	"sort"
 	"time"
 
@@ -28,6 +30,7 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/headers"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/synchronizer"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
 	"github.com/sirupsen/logrus"
@@ -232,6 +235,54 @@ func (transactions *Transactions) SpendableOutputs() map[wire.OutPoint]*Spendabl
 	return result
 }
 
+// Transaction returns the stored transaction with the given hash, or nil if it is not known.
+func (transactions *Transactions) Transaction(txHash chainhash.Hash) *wire.MsgTx {
+	tx, _ := transactions.TransactionWithHeight(txHash)
+	return tx
+}
+
+// TransactionWithHeight returns the stored transaction with the given hash and the height of the
+// block it is confirmed in, which is zero or negative if it is unconfirmed. A nil transaction is
+// returned if it is not known.
+func (transactions *Transactions) TransactionWithHeight(txHash chainhash.Hash) (*wire.MsgTx, int) {
+	defer transactions.RLock()()
+	dbTx, err := transactions.db.Begin()
+	if err != nil {
+		transactions.log.WithError(err).Panic("Failed to begin transaction")
+	}
+	defer dbTx.Rollback()
+	tx, _, height, _, err := dbTx.TxInfo(txHash)
+	if err != nil {
+		transactions.log.WithError(err).Panic("Failed to retrieve tx info")
+	}
+	return tx, height
+}
+
+// OutputsOfTx returns the unspent outputs of the wallet which were created by the given transaction,
+// regardless of whether it is confirmed.
+func (transactions *Transactions) OutputsOfTx(txHash chainhash.Hash) map[wire.OutPoint]*SpendableOutput {
+	defer transactions.RLock()()
+	dbTx, err := transactions.db.Begin()
+	if err != nil {
+		transactions.log.WithError(err).Panic("Failed to begin transaction")
+	}
+	defer dbTx.Rollback()
+	outputs, err := dbTx.Outputs()
+	if err != nil {
+		transactions.log.WithError(err).Panic("Failed to retrieve outputs")
+	}
+	result := map[wire.OutPoint]*SpendableOutput{}
+	for outPoint, txOut := range outputs {
+		if outPoint.Hash == txHash && !transactions.isInputSpent(dbTx, outPoint) {
+			result[outPoint] = &SpendableOutput{
+				TxOut:   txOut,
+				Address: transactions.outputToAddress(txOut.PkScript),
+			}
+		}
+	}
+	return result
+}
+
 func (transactions *Transactions) isInputSpent(dbTx DBTxInterface, outPoint wire.OutPoint) bool {
 	input, err := dbTx.Input(outPoint)
 	if err != nil {
@@ -381,7 +432,7 @@ func (transactions *Transactions) Balance() *coin.Balance {
 		transactions.log.WithError(err).Panic("Failed to retrieve outputs")
 	}
 	defer dbTx.Rollback()
-	var available, incoming int64
+	var available, incoming, pendingChange int64
 	for outPoint, txOut := range outputs {
 		// What is spent can not be available nor incoming.
 		if spent := transactions.isInputSpent(dbTx, outPoint); spent {
@@ -392,13 +443,22 @@ func (transactions *Transactions) Balance() *coin.Balance {
 			transactions.log.WithError(err).Panic("Failed to retrieve tx info")
 		}
 		confirmed := height > 0
-		if confirmed || transactions.allInputsOurs(dbTx, tx) {
+		switch {
+		case confirmed:
 			available += txOut.Value
-		} else {
+		case transactions.allInputsOurs(dbTx, tx):
+			// Our own unconfirmed outputs are spendable, as we do not double spend ourselves.
+			available += txOut.Value
+			pendingChange += txOut.Value
+		default:
 			incoming += txOut.Value
 		}
 	}
-	return coin.NewBalance(coin.NewAmountFromInt64(available), coin.NewAmountFromInt64(incoming))
+	return coin.NewBalance(
+		coin.NewAmountFromInt64(available),
+		coin.NewAmountFromInt64(incoming),
+		coin.NewAmountFromInt64(pendingChange),
+	)
 }
 
 // byHeight defines the methods needed to satisify sort.Interface to sort transactions by their
@@ -437,6 +497,28 @@ type TxInfo struct {
 	timestamp *time.Time
 	// addresses money was sent to / received on (without change addresses).
 	addresses []string
+	// Inputs and Outputs are the inputs and outputs of the tx, in the order of the tx.
+	Inputs  []*TxInput
+	Outputs []*TxOutput
+}
+
+// TxInput is an input of a transaction.
+type TxInput struct {
+	OutPoint wire.OutPoint
+	// Value and Address are only known if the input spends an output of the account. Value is nil
+	// otherwise.
+	Value   *btcutil.Amount
+	Address string
+}
+
+// TxOutput is an output of a transaction.
+type TxOutput struct {
+	Value   btcutil.Amount
+	Address string
+	// Ours is true if the output belongs to the account.
+	Ours bool
+	// Change is true if the output belongs to a change address of the account.
+	Change bool
 }
 
 // Fee implements coin.Transaction.
@@ -467,6 +549,15 @@ func (txInfo *TxInfo) FeeRatePerKb() *btcutil.Amount {
 	return &feeRatePerKb
 }
 
+// FeeRatePerVByte returns the fee rate of the tx in satoshi per vbyte.
+func (txInfo *TxInfo) FeeRatePerVByte() *float64 {
+	if txInfo.fee == nil || txInfo.VSize == 0 {
+		return nil
+	}
+	feeRate := float64(*txInfo.fee) / float64(txInfo.VSize)
+	return &feeRate
+}
+
 // NumConfirmations implements coin.Transaction.
 func (txInfo *TxInfo) NumConfirmations() int {
 	return txInfo.numConfirmations
@@ -488,6 +579,20 @@ func (txInfo *TxInfo) Addresses() []string {
 }
 
 func (transactions *Transactions) outputToAddress(pkScript []byte) string {
+	if taproot.IsPayToTaproot(pkScript) {
+		address, err := taproot.NewAddressTaproot(pkScript[2:], transactions.net)
+		if err != nil {
+			return "<unknown address>"
+		}
+		return address.String()
+	}
+	if txscript.GetScriptClass(pkScript) == txscript.NullDataTy {
+		data, err := txscript.PushedData(pkScript)
+		if err != nil {
+			return "<unknown address>"
+		}
+		return "OP_RETURN " + hex.EncodeToString(bytes.Join(data, nil))
+	}
 	_, extractedAddresses, _, err := txscript.ExtractPkScriptAddrs(pkScript, transactions.net)
 	// unknown addresses and multisig scripts ignored.
 	if err != nil || len(extractedAddresses) != 1 {
@@ -507,14 +612,19 @@ func (transactions *Transactions) txInfo(
 	var sumOurInputs btcutil.Amount
 	var result btcutil.Amount
 	allInputsOurs := true
-	for _, txIn := range tx.TxIn {
+	inputs := make([]*TxInput, len(tx.TxIn))
+	for index, txIn := range tx.TxIn {
 		spentOut, err := dbTx.Output(txIn.PreviousOutPoint)
 		if err != nil {
 			// TODO
 			panic(err)
 		}
+		inputs[index] = &TxInput{OutPoint: txIn.PreviousOutPoint}
 		if spentOut != nil {
-			sumOurInputs += btcutil.Amount(spentOut.Value)
+			value := btcutil.Amount(spentOut.Value)
+			sumOurInputs += value
+			inputs[index].Value = &value
+			inputs[index].Address = transactions.outputToAddress(spentOut.PkScript)
 		} else {
 			allInputsOurs = false
 		}
@@ -523,6 +633,7 @@ func (transactions *Transactions) txInfo(
 	receiveAddresses := []string{}
 	sendAddresses := []string{}
 	allOutputsOurs := true
+	outputs := make([]*TxOutput, len(tx.TxOut))
 	for index, txOut := range tx.TxOut {
 		sumAllOutputs += btcutil.Amount(txOut.Value)
 		output, err := dbTx.Output(wire.OutPoint{
@@ -534,8 +645,14 @@ func (transactions *Transactions) txInfo(
 			panic(err)
 		}
 		address := transactions.outputToAddress(txOut.PkScript)
+		outputs[index] = &TxOutput{
+			Value:   btcutil.Amount(txOut.Value),
+			Address: address,
+			Ours:    output != nil,
+		}
 		if output != nil {
 			if isChange(getScriptHashHex(output)) {
+				outputs[index].Change = true
 				sumOurChange += btcutil.Amount(txOut.Value)
 			} else {
 				sumOurReceive += btcutil.Amount(txOut.Value)
@@ -586,7 +703,30 @@ func (transactions *Transactions) txInfo(
 		fee:              feeP,
 		timestamp:        timestamp,
 		addresses:        addresses,
+		Inputs:           inputs,
+		Outputs:          outputs,
+	}
+}
+
+// mempoolFee returns the fee of an unconfirmed transaction as reported by the server in the
+// histories of the given addresses, or nil if it was not reported. This is the only way to know the
+// fee of an incoming transaction, as the outputs it spends do not belong to us.
+func mempoolFee(
+	dbTx DBTxInterface, txHash chainhash.Hash, scriptHashHexes []string) *btcutil.Amount {
+	for _, scriptHashHex := range scriptHashHexes {
+		history, err := dbTx.AddressHistory(blockchain.ScriptHashHex(scriptHashHex))
+		if err != nil {
+			// TODO
+			panic(err)
+		}
+		for _, entry := range history {
+			if entry.TXHash.Hash() == txHash && entry.Fee != nil {
+				fee := btcutil.Amount(*entry.Fee)
+				return &fee
+			}
+		}
 	}
+	return nil
 }
 
 // Transactions returns an ordered list of transactions.
@@ -607,12 +747,16 @@ func (transactions *Transactions) Transactions(
 		panic(err)
 	}
 	for _, txHash := range txHashes {
-		tx, _, height, timestamp, err := dbTx.TxInfo(txHash)
+		tx, scriptHashHexes, height, timestamp, err := dbTx.TxInfo(txHash)
 		if err != nil {
 			// TODO
 			panic(err)
 		}
-		txs = append(txs, transactions.txInfo(dbTx, tx, height, timestamp, isChange))
+		txInfo := transactions.txInfo(dbTx, tx, height, timestamp, isChange)
+		if txInfo.fee == nil && height <= 0 {
+			txInfo.fee = mempoolFee(dbTx, txHash, scriptHashHexes)
+		}
+		txs = append(txs, txInfo)
 	}
 	sort.Sort(sort.Reverse(byHeight(txs)))
 	return txs
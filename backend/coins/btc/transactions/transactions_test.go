@@ -19,6 +19,7 @@ import (
 
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
@@ -201,10 +202,11 @@ func (s *transactionsSuite) TestUpdateAddressHistorySyncStatus() {
 	require.True(s.T(), syncFinished)
 }
 
-func newBalance(available, incoming btcutil.Amount) *coin.Balance {
+func newBalance(available, incoming, pendingChange btcutil.Amount) *coin.Balance {
 	return coin.NewBalance(
 		coin.NewAmountFromInt64(int64(available)),
 		coin.NewAmountFromInt64(int64(incoming)),
+		coin.NewAmountFromInt64(int64(pendingChange)),
 	)
 }
 
@@ -221,7 +223,7 @@ func (s *transactionsSuite) TestUpdateAddressHistorySingleTxReceive() {
 		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: expectedHeight},
 	})
 	require.Equal(s.T(),
-		newBalance(expectedAmount, 0),
+		newBalance(expectedAmount, 0, 0),
 		s.transactions.Balance(),
 	)
 	utxo := &transactions.SpendableOutput{
@@ -234,10 +236,75 @@ func (s *transactionsSuite) TestUpdateAddressHistorySingleTxReceive() {
 		},
 		s.transactions.SpendableOutputs(),
 	)
+	// The spent output is not ours, so its value and address are unknown.
+	expectedInputs := []*transactions.TxInput{
+		{OutPoint: wire.OutPoint{Hash: chainhash.HashH(nil), Index: 0}},
+	}
+	expectedOutputs := []*transactions.TxOutput{
+		{Value: expectedAmount, Address: utxo.Address, Ours: true},
+	}
 	transactions := s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false })
 	require.Len(s.T(), transactions, 1)
 	require.Equal(s.T(), tx1, transactions[0].Tx)
 	require.Equal(s.T(), expectedHeight, transactions[0].Height)
+	require.Equal(s.T(), expectedInputs, transactions[0].Inputs)
+	require.Equal(s.T(), expectedOutputs, transactions[0].Outputs)
+	require.Nil(s.T(), transactions[0].FeeRatePerVByte())
+}
+
+// TestTxInfoSend checks the inputs, outputs and fee rate of an outgoing transaction with change.
+func (s *transactionsSuite) TestTxInfoSend() {
+	addresses := s.addressChain.EnsureAddresses()
+	address, changeAddress := addresses[0], addresses[1]
+	tx1 := newTx(chainhash.HashH(nil), 0, address, 1000)
+	tx2 := newTx(tx1.TxHash(), 0, changeAddress, 300)
+	externalPkScript := []byte{txscript.OP_RETURN}
+	tx2.TxOut = append(tx2.TxOut, wire.NewTxOut(600, externalPkScript))
+	s.blockchainMock.RegisterTxs(tx1, tx2)
+	s.headersMock.On("HeaderByHeight", 10).Return(nil, nil).Once()
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
+		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 0},
+	})
+	s.updateAddressHistory(changeAddress, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 0},
+	})
+	isChange := func(scriptHashHex blockchainpkg.ScriptHashHex) bool {
+		return scriptHashHex == changeAddress.PubkeyScriptHashHex()
+	}
+	inputValue := btcutil.Amount(1000)
+	expectedInputs := []*transactions.TxInput{
+		{OutPoint: wire.OutPoint{Hash: tx1.TxHash(), Index: 0}, Value: &inputValue, Address: address.EncodeForHumans()},
+	}
+	expectedOutputs := []*transactions.TxOutput{
+		{Value: 300, Address: changeAddress.EncodeForHumans(), Ours: true, Change: true},
+		{Value: 600, Address: "OP_RETURN ", Ours: false},
+	}
+	transactions := s.transactions.Transactions(isChange)
+	require.Len(s.T(), transactions, 2)
+	txInfo := transactions[0]
+	require.Equal(s.T(), tx2.TxHash().String(), txInfo.ID())
+	require.Equal(s.T(), coin.TxTypeSend, txInfo.Type())
+	require.Equal(s.T(), expectedInputs, txInfo.Inputs)
+	require.Equal(s.T(), expectedOutputs, txInfo.Outputs)
+	require.Equal(s.T(), coin.NewAmountFromInt64(100), *txInfo.Fee())
+	require.Equal(s.T(), float64(100)/float64(txInfo.VSize), *txInfo.FeeRatePerVByte())
+}
+
+// TestUpdateAddressHistoryMempoolFee checks that the fee of an incoming unconfirmed tx is the one
+// reported by the server, as it cannot be computed from our outputs.
+func (s *transactionsSuite) TestUpdateAddressHistoryMempoolFee() {
+	address := s.addressChain.EnsureAddresses()[0]
+	tx1 := newTx(chainhash.HashH(nil), 0, address, 123)
+	s.blockchainMock.RegisterTxs(tx1)
+	fee := int64(456)
+	s.updateAddressHistory(address, []*blockchainpkg.TxInfo{
+		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 0, Fee: &fee},
+	})
+	transactions := s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false })
+	require.Len(s.T(), transactions, 1)
+	require.Equal(s.T(), coin.TxTypeReceive, transactions[0].Type())
+	require.Equal(s.T(), coin.NewAmountFromInt64(fee), *transactions[0].Fee())
 }
 
 // TestUpdateAddressHistoryOppositeOrder checks that a spend is correctly recognized even if the
@@ -260,7 +327,7 @@ func (s *transactionsSuite) TestUpdateAddressHistoryOppositeOrder() {
 	s.blockchainMock.CallTransactionGetCallbacks(tx2.TxHash())
 	s.blockchainMock.CallTransactionGetCallbacks(tx1.TxHash())
 	require.Equal(s.T(),
-		newBalance(0, 0),
+		newBalance(0, 0, 0),
 		s.transactions.Balance(),
 	)
 }
@@ -328,7 +395,7 @@ func (s *transactionsSuite) TestSpendableOutputs() {
 }
 
 func (s *transactionsSuite) TestBalance() {
-	require.Equal(s.T(), newBalance(0, 0), s.transactions.Balance())
+	require.Equal(s.T(), newBalance(0, 0, 0), s.transactions.Balance())
 	addresses := s.addressChain.EnsureAddresses()
 	address1 := addresses[0]
 	otherAddress := addresses[2]
@@ -344,7 +411,7 @@ func (s *transactionsSuite) TestBalance() {
 		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 0},
 	})
 	require.Equal(s.T(),
-		newBalance(0, expectedAmount),
+		newBalance(0, expectedAmount, 0),
 		s.transactions.Balance())
 	// Confirm it, plus another one incoming.
 	s.headersMock.On("HeaderByHeight", 10).Return(nil, nil).Once()
@@ -353,7 +420,7 @@ func (s *transactionsSuite) TestBalance() {
 		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 0},
 	})
 	require.Equal(s.T(),
-		newBalance(expectedAmount, expectedAmount2),
+		newBalance(expectedAmount, expectedAmount2, 0),
 		s.transactions.Balance())
 	// Spend funds that came from tx1, first unconfirmed. Available balance decreases.
 	s.updateAddressHistory(address1, []*blockchainpkg.TxInfo{
@@ -362,7 +429,7 @@ func (s *transactionsSuite) TestBalance() {
 		{TXHash: blockchainpkg.TXHash(tx1Spend.TxHash()), Height: 0},
 	})
 	require.Equal(s.T(),
-		newBalance(0, expectedAmount2),
+		newBalance(0, expectedAmount2, 0),
 		s.transactions.Balance())
 	// Confirm it.
 	s.headersMock.On("HeaderByHeight", 10).Return(nil, nil).Once()
@@ -372,10 +439,11 @@ func (s *transactionsSuite) TestBalance() {
 		{TXHash: blockchainpkg.TXHash(tx1Spend.TxHash()), Height: 10},
 	})
 	require.Equal(s.T(),
-		newBalance(0, expectedAmount2),
+		newBalance(0, expectedAmount2, 0),
 		s.transactions.Balance())
 	// Spend the unconfirmed incoming tx to an internal address, unconfirmed (can't confirm until
-	// the first one is). The funds are still available as we own the unconfirmed output.
+	// the first one is). The funds are still available as we own the unconfirmed output, but
+	// pending.
 	s.updateAddressHistory(address1, []*blockchainpkg.TxInfo{
 		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
 		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 0},
@@ -383,7 +451,7 @@ func (s *transactionsSuite) TestBalance() {
 		{TXHash: blockchainpkg.TXHash(tx2Spend.TxHash()), Height: 0},
 	})
 	require.Equal(s.T(),
-		newBalance(expectedAmount2, 0),
+		newBalance(expectedAmount2, 0, expectedAmount2),
 		s.transactions.Balance())
 }
 
@@ -408,7 +476,7 @@ func (s *transactionsSuite) TestRemoveTransaction() {
 		{TXHash: blockchainpkg.TXHash(tx3.TxHash()), Height: 10},
 	})
 	require.Equal(s.T(),
-		newBalance(2+10+34, 0),
+		newBalance(2+10+34, 0, 0),
 		s.transactions.Balance())
 	// Remove tx3 from the history of address1. It is still referenced by address2, so the index
 	// does not change.
@@ -416,7 +484,7 @@ func (s *transactionsSuite) TestRemoveTransaction() {
 		{TXHash: blockchainpkg.TXHash(tx1.TxHash()), Height: 10},
 	})
 	require.Equal(s.T(),
-		newBalance(2+10+34, 0),
+		newBalance(2+10+34, 0, 0),
 		s.transactions.Balance())
 	require.Len(s.T(),
 		s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false }),
@@ -426,7 +494,7 @@ func (s *transactionsSuite) TestRemoveTransaction() {
 		{TXHash: blockchainpkg.TXHash(tx2.TxHash()), Height: 10},
 	})
 	require.Equal(s.T(),
-		newBalance(12+34, 0),
+		newBalance(12+34, 0, 0),
 		s.transactions.Balance())
 	require.Len(s.T(),
 		s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false }),
@@ -447,7 +515,7 @@ func (s *transactionsSuite) TestRemoveTransactionPendingDownload() {
 	// Process the tx now. It should not be indexed anymore.
 	s.blockchainMock.CallAllTransactionGetCallbacks()
 	require.Equal(s.T(),
-		newBalance(0, 0),
+		newBalance(0, 0, 0),
 		s.transactions.Balance())
 	require.Empty(s.T(),
 		s.transactions.Transactions(func(blockchainpkg.ScriptHashHex) bool { return false }))
@@ -14,17 +14,19 @@
 
 package coin
 
-// Balance contains the available and incoming balance of an account.
+// Balance contains the available, incoming and pending change balance of an account.
 type Balance struct {
-	available Amount
-	incoming  Amount
+	available     Amount
+	incoming      Amount
+	pendingChange Amount
 }
 
 // NewBalance creates a new balance with the given amounts.
-func NewBalance(available Amount, incoming Amount) *Balance {
+func NewBalance(available Amount, incoming Amount, pendingChange Amount) *Balance {
 	return &Balance{
-		available: available,
-		incoming:  incoming,
+		available:     available,
+		incoming:      incoming,
+		pendingChange: pendingChange,
 	}
 }
 
@@ -38,3 +40,10 @@ func (balance *Balance) Available() Amount {
 func (balance *Balance) Incoming() Amount {
 	return balance.incoming
 }
+
+// PendingChange returns the part of the available balance which is unconfirmed. These are the
+// outputs of unconfirmed transactions sent by the account itself, like change and transfers between
+// its own addresses. Unlike incoming funds, they can be spent before they are confirmed.
+func (balance *Balance) PendingChange() Amount {
+	return balance.pendingChange
+}
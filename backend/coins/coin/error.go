@@ -30,4 +30,31 @@ var (
 	// ErrInsufficientFunds is returned when there are not enough funds to cover the target amount
 	// and fee.
 	ErrInsufficientFunds = TxValidationError("insufficientFunds")
+	// ErrFeeTooLow is returned when the fee of a replacement transaction does not exceed the fee of
+	// the transaction it replaces.
+	ErrFeeTooLow = TxValidationError("feeTooLow")
+	// ErrInvalidFeeRate is used when the user entered fee rate is malformatted.
+	ErrInvalidFeeRate = TxValidationError("invalidFeeRate")
+	// ErrDustAmount is returned when an output of the transaction would be so small that it costs
+	// more to spend than it is worth (dust).
+	ErrDustAmount = TxValidationError("dustAmount")
+	// ErrGasLimitTooLow is returned when the user entered gas limit of an Ethereum transaction does
+	// not cover the intrinsic gas of the transaction.
+	ErrGasLimitTooLow = TxValidationError("gasLimitTooLow")
+	// ErrInvalidAddressChecksum is used when the recipient address is in mixed case, but does not
+	// match its EIP-55 checksum, which indicates a typo.
+	ErrInvalidAddressChecksum = TxValidationError("invalidAddressChecksum")
+)
+
+// TxWarning represents a property of a tx proposal which the user should check, but which does not
+// prevent sending.
+type TxWarning string
+
+const (
+	// WarningAddressNotChecksummed is used when the recipient address is all lowercase or all
+	// uppercase, so that typos in it cannot be detected by its EIP-55 checksum.
+	WarningAddressNotChecksummed TxWarning = "addressNotChecksummed"
+	// WarningRecipientIsContract is used when plain coins are sent to a contract, which can lose
+	// them if it does not expect them.
+	WarningRecipientIsContract TxWarning = "recipientIsContract"
 )
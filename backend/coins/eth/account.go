@@ -2,10 +2,11 @@ package eth
 
 import (
 	"context"
+	"encoding/json"
 	"math/big"
+	"sync"
 	"time"
 
-	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/headers"
@@ -15,7 +16,9 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
@@ -24,6 +27,10 @@ import (
 
 var pollInterval = 10 * time.Second
 
+// subscribedIndexerInterval is the interval in which the transactions are fetched from the
+// indexer when the account is updated on new blocks pushed by the node, unless the balance changes.
+var subscribedIndexerInterval = time.Minute
+
 // Event instances are sent to the onEvent callback of the wallet.
 type Event string
 
@@ -40,11 +47,32 @@ type Account struct {
 	keystores               keystore.Keystores
 
 	initialized bool
-
-	address      Address
-	balance      coin.Amount
+	// pendingTransactions are the transactions sent in this session which are not confirmed yet,
+	// by nonce.
+	pendingTransactions map[uint64]*PendingTransaction
+	// quit is closed when the account is closed, to stop polling.
+	quit      chan struct{}
+	closeOnce sync.Once
+
+	address Address
+	// balance is in the unit of the coin, which is a token for ERC20 accounts.
+	balance coin.Amount
+	// etherBalance pays the fees. It is the same as balance if the coin is Ether.
+	etherBalance coin.Amount
 	blockNumber  *big.Int
 	transactions []coin.Transaction
+	// subscribed is true if the account is updated on the new blocks pushed by the node. The
+	// transactions are then only fetched from the indexer if the balances changed since
+	// indexerBalances were recorded, or subscribedIndexerInterval after indexerUpdated.
+	subscribed      bool
+	indexerBalances string
+	indexerUpdated  time.Time
+	// txStatuses are the statuses of the confirmed transactions sent by the account, by hash, so
+	// that their receipts are only fetched once.
+	txStatuses map[common.Hash]*TxStatus
+	// contractNames are the verified names of the contracts to which transactions were proposed,
+	// by address. The name is empty if it is not known.
+	contractNames map[common.Address]string
 
 	log *logrus.Entry
 }
@@ -69,6 +97,11 @@ func NewAccount(
 		keystores:               keystores,
 
 		initialized: false,
+		quit:        make(chan struct{}),
+
+		pendingTransactions: map[uint64]*PendingTransaction{},
+		txStatuses:          map[common.Hash]*TxStatus{},
+		contractNames:       map[common.Address]string{},
 
 		log: log,
 	}
@@ -136,10 +169,18 @@ func (account *Account) Initialize() error {
 	return nil
 }
 
+// poll updates the account in the pollInterval, and on every new block if the node pushes them.
 func (account *Account) poll() {
+	newHeads := account.coin.subscribeNewHeads(account.quit, account.log)
+	account.subscribed = newHeads != nil
 	timer := time.After(0)
 	for {
-		<-timer
+		select {
+		case <-account.quit:
+			return
+		case <-timer:
+		case <-newHeads:
+		}
 		if err := account.update(); err != nil {
 			account.log.WithError(err).Error("error updating account")
 		}
@@ -149,27 +190,86 @@ func (account *Account) poll() {
 
 func (account *Account) update() error {
 	defer account.synchronizer.IncRequestsCounter()()
-	balance, err := account.coin.client.BalanceAt(context.TODO(), account.address.Address, nil)
+	etherBalance, err := account.coin.client.BalanceAt(context.TODO(), account.address.Address, nil)
 	if err != nil {
 		return errp.WithStack(err)
 	}
-	account.balance = coin.NewAmount(balance)
+	account.etherBalance = coin.NewAmount(etherBalance)
+	erc20Token := account.coin.ERC20Token()
+	if erc20Token != nil {
+		balance, err := account.coin.erc20Balance(context.TODO(), account.address.Address)
+		if err != nil {
+			return err
+		}
+		account.balance = coin.NewAmount(balance)
+	} else {
+		account.balance = account.etherBalance
+	}
 
-	header, err := account.coin.client.HeaderByNumber(context.TODO(), nil)
+	header, err := account.coin.latestHeader(context.TODO())
 	if err != nil {
-		return errp.WithStack(err)
+		return err
 	}
-	account.blockNumber = header.Number
+	account.blockNumber = header.Number.ToInt()
 
-	transactions, err := account.coin.EtherScan().Transactions(
-		account.address.Address, account.blockNumber)
+	balances := account.etherBalance.BigInt().String() + "/" + account.balance.BigInt().String()
+	if account.subscribed && balances == account.indexerBalances &&
+		time.Since(account.indexerUpdated) < subscribedIndexerInterval {
+		return nil
+	}
+
+	var transactions []coin.Transaction
+	if erc20Token != nil {
+		transactions, err = account.coin.Indexer().ERC20Transactions(
+			erc20Token.Contract, account.address.Address, account.blockNumber)
+	} else {
+		transactions, err = account.coin.Indexer().Transactions(
+			account.address.Address, account.blockNumber)
+		if err == nil {
+			transactions = account.withStatuses(transactions)
+		}
+	}
 	if err != nil {
 		return err
 	}
 	account.transactions = transactions
+	account.indexerBalances = balances
+	account.indexerUpdated = time.Now()
 	return nil
 }
 
+// withStatuses wraps the transactions of an Ether account in HistoryTransactions. The statuses of
+// the transactions sent by the account are fetched from their receipts. If that fails, the status
+// is unknown until the next update.
+func (account *Account) withStatuses(transactions []coin.Transaction) []coin.Transaction {
+	result := make([]coin.Transaction, len(transactions))
+	for index, transaction := range transactions {
+		indexed, ok := transaction.(IndexedTransaction)
+		if !ok {
+			result[index] = transaction
+			continue
+		}
+		historyTx := &HistoryTransaction{IndexedTransaction: indexed}
+		result[index] = historyTx
+		if indexed.Internal() || indexed.Type() == coin.TxTypeReceive {
+			continue
+		}
+		hash := common.HexToHash(indexed.ID())
+		status, ok := account.txStatuses[hash]
+		if !ok {
+			var err error
+			status, err = account.coin.txStatus(context.TODO(), hash, account.address.Address)
+			if err != nil {
+				account.log.WithError(err).Warning("Could not fetch the status of a transaction")
+				continue
+			}
+			account.txStatuses[hash] = status
+		}
+		historyTx.status = status
+	}
+	return result
+}
+
 // Initialized implements btc.Interface.
 func (account *Account) Initialized() bool {
 	return account.initialized
@@ -182,7 +282,7 @@ func (account *Account) Offline() bool {
 
 // Close implements btc.Interface.
 func (account *Account) Close() {
-
+	account.closeOnce.Do(func() { close(account.quit) })
 }
 
 // Transactions implements btc.Interface.
@@ -193,102 +293,320 @@ func (account *Account) Transactions() []coin.Transaction {
 // Balance implements btc.Interface.
 func (account *Account) Balance() *coin.Balance {
 	account.synchronizer.WaitSynchronized()
-	return coin.NewBalance(account.balance, coin.NewAmountFromInt64(0))
+	return coin.NewBalance(account.balance, coin.NewAmountFromInt64(0), coin.NewAmountFromInt64(0))
 }
 
 // TxProposal holds all info needed to create and sign a transacstion.
 type TxProposal struct {
-	Tx  *types.Transaction
-	Fee *big.Int
-	// Signer contains the sighash algo, which depends on the block number.
+	// Tx is a legacy transaction. It is nil if DynamicFeeTx is set.
+	Tx *types.Transaction
+	// DynamicFeeTx is an EIP-1559 transaction. It is nil for legacy transactions.
+	DynamicFeeTx *DynamicFeeTx
+	Fee          *big.Int
+	// Signer contains the sighash algo of legacy transactions, which depends on the block number.
 	Signer types.Signer
 	// KeyPath is the location of this account's address/pubkey/privkey.
 	Keypath signing.AbsoluteKeypath
+	// Token describes the transfer if the transaction is an ERC-20 transfer. It is nil for plain ETH
+	// sends and unknown contract interactions.
+	Token *TokenTransfer
+	// Warnings are the properties of the proposal which the user should check before sending.
+	Warnings []coin.TxWarning
+	// RecipientContractName is the verified name of the recipient contract, if known.
+	RecipientContractName string
 }
 
-func (account *Account) newTx(
-	recipientAddress string,
-	amount coin.SendAmount) (*TxProposal, error) {
-	if !common.IsHexAddress(recipientAddress) {
-		return nil, errp.WithStack(coin.ErrInvalidAddress)
+// To returns the recipient of the proposed transaction, which is nil for contract creations.
+func (txProposal *TxProposal) To() *common.Address {
+	if txProposal.DynamicFeeTx != nil {
+		return txProposal.DynamicFeeTx.To
 	}
-	const gasLimit = 21000 // simple transaction gas cost
+	return txProposal.Tx.To()
+}
 
-	nonce, err := account.coin.client.PendingNonceAt(context.TODO(), account.address.Address)
+// Data returns the calldata of the proposed transaction.
+func (txProposal *TxProposal) Data() []byte {
+	if txProposal.DynamicFeeTx != nil {
+		return txProposal.DynamicFeeTx.Data
+	}
+	return txProposal.Tx.Data()
+}
+
+// Type returns the EIP-2718 type of the proposed transaction.
+func (txProposal *TxProposal) Type() uint8 {
+	if txProposal.DynamicFeeTx != nil {
+		return txProposal.DynamicFeeTx.Type()
+	}
+	return LegacyTxType
+}
+
+func (account *Account) newTx(args *btc.TxProposalArgs) (*TxProposal, error) {
+	if len(args.Recipients) != 1 {
+		return nil, errp.New("Ethereum transactions have exactly one recipient.")
+	}
+	if len(args.OpReturnData) != 0 {
+		return nil, errp.New("OP_RETURN outputs are not supported.")
+	}
+	recipientAddress, amount := args.Recipients[0].Address, args.Recipients[0].Amount
+	recipient, checksummed, err := ParseAddress(recipientAddress)
 	if err != nil {
 		return nil, err
 	}
-	suggestedGasPrice, err := account.coin.client.SuggestGasPrice(context.TODO())
+	var warnings []coin.TxWarning
+	if !checksummed {
+		warnings = append(warnings, coin.WarningAddressNotChecksummed)
+	}
+	// Sending coins or tokens without calldata to a contract burns them if the contract cannot
+	// forward them.
+	var recipientContractName string
+	if len(args.Data) == 0 {
+		isContract, name, err := account.recipientContract(context.TODO(), recipient)
+		if err != nil {
+			return nil, err
+		}
+		if isContract {
+			warnings = append(warnings, coin.WarningRecipientIsContract)
+			recipientContractName = name
+		}
+	}
+
+	nonce, err := account.selectNonce(args.Nonce)
 	if err != nil {
 		return nil, err
 	}
-	fee := new(big.Int).Mul(big.NewInt(gasLimit), suggestedGasPrice)
+	baseFee, err := account.coin.baseFee(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	var gasTipCap *big.Int
+	if args.FeeTargetCode == btc.FeeTargetCodeCustom {
+		gasTipCap, err = parseGasTipCap(args.CustomFee)
+		if err == nil {
+			err = account.coin.checkGasTipCap(gasTipCap)
+		}
+	} else {
+		gasTipCap, err = account.coin.feeTargetGasTipCap(context.TODO(), args.FeeTargetCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	gasTipCap, gasFeeCap := account.replacementFees(nonce, gasTipCap, MaxFeePerGas(baseFee, gasTipCap))
+
+	if erc20Token := account.coin.ERC20Token(); erc20Token != nil {
+		if len(args.Data) != 0 {
+			return nil, errp.New("Data is not supported for token transfers.")
+		}
+		txProposal, err := account.newERC20Tx(
+			erc20Token, recipient, amount, nonce, gasTipCap, gasFeeCap, args.GasLimit)
+		if err != nil {
+			return nil, err
+		}
+		txProposal.Warnings = warnings
+		txProposal.RecipientContractName = recipientContractName
+		return txProposal, nil
+	}
 
 	var value *big.Int
+	estimatedValue := account.balance.BigInt()
+	if !amount.SendAll() {
+		parsedAmount, err := amount.Amount(big.NewInt(params.Ether))
+		if err != nil {
+			return nil, err
+		}
+		value = parsedAmount.BigInt()
+		estimatedValue = value
+	}
+	// Unless it is given, the gas is estimated, as a transfer to a contract can cost more than a
+	// simple transaction (21000).
+	gasLimit, err := account.coin.gasLimit(context.TODO(), args.GasLimit, ethereum.CallMsg{
+		From:  account.address.Address,
+		To:    &recipient,
+		Value: estimatedValue,
+		Data:  args.Data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The fee is the maximum the transaction can cost. The unused part of it is not charged.
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasFeeCap)
+
 	if amount.SendAll() {
 		value = new(big.Int).Sub(account.balance.BigInt(), fee)
 		if value.Sign() <= 0 {
 			return nil, errp.WithStack(coin.ErrInsufficientFunds)
 		}
 	} else {
-		parsedAmount, err := amount.Amount(big.NewInt(params.Ether))
-		if err != nil {
-			return nil, err
-		}
-		value = parsedAmount.BigInt()
 		total := new(big.Int).Add(value, fee)
 		if total.Cmp(account.balance.BigInt()) == 1 {
 			return nil, errp.WithStack(coin.ErrInsufficientFunds)
 		}
 	}
-	tx := types.NewTransaction(nonce,
-		common.HexToAddress(recipientAddress),
-		value, gasLimit, suggestedGasPrice, nil)
+	tx := &DynamicFeeTx{
+		ChainID:   account.coin.Net().ChainID,
+		Nonce:     nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       gasLimit,
+		To:        &recipient,
+		Value:     value,
+		Data:      args.Data,
+	}
+	if err := account.coin.simulate(context.TODO(), account.address.Address, tx); err != nil {
+		return nil, err
+	}
 	return &TxProposal{
-		Tx:      tx,
-		Fee:     fee,
-		Signer:  types.MakeSigner(account.coin.Net(), account.blockNumber),
-		Keypath: account.signingConfiguration.AbsoluteKeypath(),
+		DynamicFeeTx:          tx,
+		Fee:                   fee,
+		Keypath:               account.signingConfiguration.AbsoluteKeypath(),
+		Warnings:              warnings,
+		RecipientContractName: recipientContractName,
 	}, nil
 }
 
-// SendTx implements btc.Interface.
-func (account *Account) SendTx(
-	recipientAddress string,
+// newERC20Tx creates a transaction transferring tokens to the recipient. The fee is paid in Ether.
+func (account *Account) newERC20Tx(
+	erc20Token *ERC20Token,
+	recipient common.Address,
 	amount coin.SendAmount,
-	feeTargetCode btc.FeeTargetCode,
-	_ map[wire.OutPoint]struct{}) error {
+	nonce uint64,
+	gasTipCap *big.Int,
+	gasFeeCap *big.Int,
+	customGasLimit uint64,
+) (*TxProposal, error) {
+	var value *big.Int
+	if amount.SendAll() {
+		value = account.balance.BigInt()
+	} else {
+		unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(erc20Token.Decimals)), nil)
+		parsedAmount, err := amount.Amount(unit)
+		if err != nil {
+			return nil, err
+		}
+		value = parsedAmount.BigInt()
+	}
+	if value.Sign() <= 0 || value.Cmp(account.balance.BigInt()) == 1 {
+		return nil, errp.WithStack(coin.ErrInsufficientFunds)
+	}
+	data := ERC20TransferData(recipient, value)
+	// The estimation fails with the revert reason of the token, e.g. if transfers are paused.
+	gasLimit, err := account.coin.gasLimit(context.TODO(), customGasLimit, ethereum.CallMsg{
+		From: account.address.Address,
+		To:   &erc20Token.Contract,
+		Data: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasFeeCap)
+	if fee.Cmp(account.etherBalance.BigInt()) == 1 {
+		return nil, errp.WithStack(coin.ErrInsufficientFunds)
+	}
+	contract := erc20Token.Contract
+	tx := &DynamicFeeTx{
+		ChainID:   account.coin.Net().ChainID,
+		Nonce:     nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       gasLimit,
+		To:        &contract,
+		Value:     big.NewInt(0),
+		Data:      data,
+	}
+	if err := account.coin.simulate(context.TODO(), account.address.Address, tx); err != nil {
+		return nil, err
+	}
+	return &TxProposal{
+		DynamicFeeTx: tx,
+		Fee:          fee,
+		Keypath:      account.signingConfiguration.AbsoluteKeypath(),
+		Token: &TokenTransfer{
+			Contract:  contract,
+			Symbol:    erc20Token.Symbol,
+			Decimals:  erc20Token.Decimals,
+			Recipient: recipient,
+			Amount:    value,
+		},
+	}, nil
+}
+
+// SendTx implements btc.Interface.
+func (account *Account) SendTx(args *btc.TxProposalArgs) error {
 	account.log.Info("Signing and sending transaction")
-	txProposal, err := account.newTx(recipientAddress, amount)
+	txProposal, err := account.newTx(args)
 	if err != nil {
 		return err
 	}
+	return account.signAndSend(txProposal)
+}
+
+// signAndSend signs the transaction with the keystores, broadcasts it and records it as pending.
+func (account *Account) signAndSend(txProposal *TxProposal) error {
 	if err := account.keystores.SignTransaction(txProposal); err != nil {
 		return err
 	}
-	return account.coin.client.SendTransaction(context.TODO(), txProposal.Tx)
+	rawTx, err := txProposal.DynamicFeeTx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := account.coin.sendRawTransaction(context.TODO(), rawTx); err != nil {
+		return err
+	}
+	return account.addPendingTransaction(txProposal)
 }
 
-// FeeTargets implements btc.Interface.
+// FeeTargets implements btc.Interface. Fee targets with the same priority fee as the one below are
+// dropped. If the priority fees cannot be estimated, only the normal fee target is returned, which
+// pays the priority fee suggested by the node.
 func (account *Account) FeeTargets() ([]*btc.FeeTarget, btc.FeeTargetCode) {
-	return []*btc.FeeTarget{{Blocks: 2, Code: "low"}}, "low"
+	fees, err := account.coin.priorityFees(context.TODO())
+	if err != nil {
+		account.log.WithError(err).Warning("Could not estimate the priority fees")
+		return []*btc.FeeTarget{{Code: btc.FeeTargetCodeNormal}}, btc.FeeTargetCodeNormal
+	}
+	feeTargets := []*btc.FeeTarget{}
+	defaultFeeTarget := btc.FeeTargetCodeLow
+	for index, code := range priorityFeeTargets {
+		if index > 0 && fees[index].Cmp(fees[index-1]) == 0 {
+			continue
+		}
+		if code == btc.FeeTargetCodeNormal {
+			defaultFeeTarget = code
+		}
+		feeTargets = append(feeTargets, &btc.FeeTarget{Code: code, GasTipCap: fees[index]})
+	}
+	return feeTargets, defaultFeeTarget
 }
 
 // TxProposal implements btc.Interface.
-func (account *Account) TxProposal(
-	recipientAddress string,
-	amount coin.SendAmount,
-	feeTargetCode btc.FeeTargetCode,
-	_ map[wire.OutPoint]struct{}) (coin.Amount, coin.Amount, coin.Amount, error) {
-
-	txProposal, err := account.newTx(recipientAddress, amount)
+func (account *Account) TxProposal(args *btc.TxProposalArgs) (*btc.TxProposalResult, error) {
+	txProposal, err := account.newTx(args)
 	if err != nil {
-		return coin.Amount{}, coin.Amount{}, coin.Amount{}, err
+		return nil, err
 	}
 
-	value := txProposal.Tx.Value()
+	if txProposal.Token != nil {
+		amount := coin.NewAmount(txProposal.Token.Amount)
+		return &btc.TxProposalResult{
+			Amount:                amount,
+			Fee:                   coin.NewAmount(txProposal.Fee),
+			FeeCoin:               account.coin.Ether(),
+			Total:                 amount,
+			GasLimit:              txProposal.DynamicFeeTx.Gas,
+			Warnings:              txProposal.Warnings,
+			RecipientContractName: txProposal.RecipientContractName,
+		}, nil
+	}
+	value := txProposal.DynamicFeeTx.Value
 	total := new(big.Int).Add(value, txProposal.Fee)
-	return coin.NewAmount(value), coin.NewAmount(txProposal.Fee), coin.NewAmount(total), nil
+	return &btc.TxProposalResult{
+		Amount:                coin.NewAmount(value),
+		Fee:                   coin.NewAmount(txProposal.Fee),
+		Total:                 coin.NewAmount(total),
+		Data:                  txProposal.DynamicFeeTx.Data,
+		GasLimit:              txProposal.DynamicFeeTx.Gas,
+		Warnings:              txProposal.Warnings,
+		RecipientContractName: txProposal.RecipientContractName,
+	}, nil
 }
 
 // GetUnusedReceiveAddresses implements btc.Interface.
@@ -320,3 +638,71 @@ func (account *Account) HeadersStatus() (*headers.Status, error) {
 func (account *Account) SpendableOutputs() []*btc.SpendableOutput {
 	return nil
 }
+
+// TxNote implements btc.Interface.
+func (account *Account) TxNote(txID string) (string, error) {
+	return "", nil
+}
+
+// SetTxNote implements btc.Interface.
+func (account *Account) SetTxNote(txID string, note string) error {
+	return errp.New("Transaction notes are not supported.")
+}
+
+// AddressLabels implements btc.Interface.
+func (account *Account) AddressLabels() map[string]string {
+	return map[string]string{}
+}
+
+// SetAddressLabel implements btc.Interface.
+func (account *Account) SetAddressLabel(addressID string, label string) error {
+	return errp.New("Address labels are not supported.")
+}
+
+// ResolveENSName returns the address to which the given .eth name resolves.
+func (account *Account) ResolveENSName(name string) (common.Address, error) {
+	return account.coin.ResolveENSName(context.TODO(), name)
+}
+
+// LookupENSName returns the verified primary ENS name of the address, or an empty string if it has
+// none.
+func (account *Account) LookupENSName(address string) (string, error) {
+	if !common.IsHexAddress(address) {
+		return "", errp.WithStack(coin.ErrInvalidAddress)
+	}
+	return account.coin.LookupENSName(context.TODO(), common.HexToAddress(address))
+}
+
+// SignMessage signs the message with the key of the account, whose address has to be the given
+// one, and returns the hex encoded signature as returned by personal_sign (EIP-191). Returns
+// keystore.ErrSigningAborted on user abort.
+func (account *Account) SignMessage(address string, message []byte) (string, error) {
+	if !common.IsHexAddress(address) {
+		return "", errp.WithStack(coin.ErrInvalidAddress)
+	}
+	if common.HexToAddress(address) != account.address.Address {
+		return "", errp.New("The address does not belong to the account.")
+	}
+	account.log.Info("Signing message")
+	signature, err := account.keystores.SignMessage(
+		account.signingConfiguration, message, account.coin)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(signature), nil
+}
+
+// SignTypedData signs the EIP-712 typed data with the key of the account and returns the hex
+// encoded signature as returned by eth_signTypedData_v4.
+func (account *Account) SignTypedData(typedData *TypedData) (string, error) {
+	jsonTypedData, err := json.Marshal(typedData)
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	account.log.WithField("primary-type", typedData.PrimaryType).Info("Signing typed data")
+	signature, err := account.keystores.SignTypedData(account.signingConfiguration, jsonTypedData)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(signature), nil
+}
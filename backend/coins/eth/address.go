@@ -1,6 +1,12 @@
 package eth
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+)
 
 // Address holds an Ethereum address and implements coin.Address.
 type Address struct {
@@ -16,3 +22,21 @@ func (address Address) ID() string {
 func (address Address) EncodeForHumans() string {
 	return address.Address.Hex()
 }
+
+// ParseAddress parses a hex encoded address and validates its EIP-55 checksum. The returned
+// boolean is false if the address is all lowercase or all uppercase, i.e. it has no checksum. An
+// address in mixed case with a wrong checksum results in coin.ErrInvalidAddressChecksum.
+func ParseAddress(address string) (common.Address, bool, error) {
+	if !common.IsHexAddress(address) {
+		return common.Address{}, false, errp.WithStack(coin.ErrInvalidAddress)
+	}
+	parsed := common.HexToAddress(address)
+	digits := strings.TrimPrefix(strings.TrimPrefix(address, "0x"), "0X")
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return parsed, false, nil
+	}
+	if digits != strings.TrimPrefix(parsed.Hex(), "0x") {
+		return common.Address{}, false, errp.WithStack(coin.ErrInvalidAddressChecksum)
+	}
+	return parsed, true, nil
+}
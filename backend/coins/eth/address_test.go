@@ -0,0 +1,54 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth_test
+
+import (
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAddress(t *testing.T) {
+	expected := common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	for address, checksummed := range map[string]bool{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed": true,
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed": false,
+		"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED": false,
+		"5aaeb6053f3e94c9b9a09f33669435e7ef1beaed":   false,
+	} {
+		parsed, ok, err := eth.ParseAddress(address)
+		require.NoError(t, err, address)
+		require.Equal(t, expected, parsed, address)
+		require.Equal(t, checksummed, ok, address)
+	}
+
+	// One letter with the wrong case.
+	_, _, err := eth.ParseAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD")
+	require.Equal(t, coin.ErrInvalidAddressChecksum, errp.Cause(err))
+
+	for _, address := range []string{
+		"",
+		"0x",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA",
+		"0xzaAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	} {
+		_, _, err := eth.ParseAddress(address)
+		require.Equal(t, coin.ErrInvalidAddress, errp.Cause(err), address)
+	}
+}
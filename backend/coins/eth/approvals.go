@@ -0,0 +1,191 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Function selectors of the ERC20 allowance methods.
+var (
+	// erc20AllowanceSelector is the function selector of `allowance(address,address)`.
+	erc20AllowanceSelector = []byte{0xdd, 0x62, 0xed, 0x3e}
+	// erc20ApproveSelector is the function selector of `approve(address,uint256)`.
+	erc20ApproveSelector = []byte{0x09, 0x5e, 0xa7, 0xb3}
+)
+
+// erc20ApprovalTopic is the topic of the `Approval(address,address,uint256)` event.
+var erc20ApprovalTopic = common.HexToHash(
+	"0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925")
+
+// Approval is an outstanding allowance of a spender to transfer ERC20 tokens of the account.
+type Approval struct {
+	Token common.Address
+	// Symbol and Decimals are empty if the token does not provide them.
+	Symbol    string
+	Decimals  uint
+	Spender   common.Address
+	Allowance *big.Int
+	// Unlimited is true if the allowance is so large that it is effectively unlimited, as is
+	// requested by many dapps.
+	Unlimited bool
+}
+
+// FormatAllowance formats the allowance in the unit of the token, or in its smallest unit if the
+// token has no symbol.
+func (approval *Approval) FormatAllowance() string {
+	if approval.Symbol == "" {
+		return approval.Allowance.String()
+	}
+	return formatUnits(approval.Allowance, approval.Decimals)
+}
+
+type approvalKey struct {
+	token   common.Address
+	spender common.Address
+}
+
+// approvalKeys returns the token contracts and spenders of the ERC20 Approval events, sorted by
+// token and spender. The Approval events of ERC-721 tokens, which have the same signature but an
+// indexed token ID, are skipped.
+func approvalKeys(logs []types.Log) []approvalKey {
+	seen := map[approvalKey]struct{}{}
+	keys := []approvalKey{}
+	for _, log := range logs {
+		if len(log.Topics) != 3 || log.Topics[0] != erc20ApprovalTopic || log.Removed {
+			continue
+		}
+		key := approvalKey{token: log.Address, spender: common.BytesToAddress(log.Topics[2].Bytes())}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if cmp := bytes.Compare(keys[i].token.Bytes(), keys[j].token.Bytes()); cmp != 0 {
+			return cmp < 0
+		}
+		return bytes.Compare(keys[i].spender.Bytes(), keys[j].spender.Bytes()) < 0
+	})
+	return keys
+}
+
+// approvalLogs fetches the ERC20 Approval events of the owner until the given block.
+func (coin *Coin) approvalLogs(
+	ctx context.Context, owner common.Address, endBlock uint64) ([]types.Log, error) {
+	logs, err := coin.filterLogs(ctx, ethereum.FilterQuery{
+		Topics: [][]common.Hash{
+			{erc20ApprovalTopic},
+			{common.BytesToHash(owner.Bytes())},
+		},
+	}, 0, endBlock)
+	if err != nil {
+		return nil, errp.WithMessage(err, "Could not fetch the approvals")
+	}
+	return logs, nil
+}
+
+// erc20Allowance returns the amount of the token at the given contract which the spender may
+// transfer from the owner.
+func (coin *Coin) erc20Allowance(
+	ctx context.Context, contract common.Address, owner common.Address, spender common.Address,
+) (*big.Int, error) {
+	data := append([]byte{}, erc20AllowanceSelector...)
+	data = append(data, common.LeftPadBytes(owner.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(spender.Bytes(), 32)...)
+	result, err := coin.call(ctx, contract, data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeABIUint(result)
+}
+
+// erc20ApproveData returns the call data of `approve` setting the allowance of the spender.
+func erc20ApproveData(spender common.Address, amount *big.Int) []byte {
+	data := append([]byte{}, erc20ApproveSelector...)
+	data = append(data, common.LeftPadBytes(spender.Bytes(), 32)...)
+	return append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+}
+
+// Approvals returns the outstanding ERC20 allowances granted by the account, according to its
+// Approval events. The allowances are queried from the tokens, as they are reduced by transfers
+// of the spenders.
+func (account *Account) Approvals() ([]*Approval, error) {
+	if account.coin.ERC20Token() != nil {
+		return nil, errp.New("Approvals are listed by Ethereum accounts.")
+	}
+	ctx := context.TODO()
+	header, err := account.coin.latestHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	owner := account.address.Address
+	logs, err := account.coin.approvalLogs(ctx, owner, header.Number.ToInt().Uint64())
+	if err != nil {
+		return nil, err
+	}
+	// Allowances of at least 2^255 are considered unlimited, as tokens which reduce an allowance of
+	// 2^256-1 on transfers keep it above.
+	unlimited := new(big.Int).Lsh(big.NewInt(1), 255)
+	tokens := map[common.Address]*ERC20Token{}
+	approvals := []*Approval{}
+	for _, key := range approvalKeys(logs) {
+		allowance, err := account.coin.erc20Allowance(ctx, key.token, owner, key.spender)
+		if err != nil {
+			account.log.WithError(err).WithField("token", key.token.Hex()).Error("Could not fetch allowance")
+			continue
+		}
+		if allowance.Sign() == 0 {
+			continue
+		}
+		token, ok := tokens[key.token]
+		if !ok {
+			token, err = account.coin.FetchERC20Token(ctx, key.token)
+			if err != nil {
+				token = &ERC20Token{Contract: key.token}
+			}
+			tokens[key.token] = token
+		}
+		approvals = append(approvals, &Approval{
+			Token:     key.token,
+			Symbol:    token.Symbol,
+			Decimals:  token.Decimals,
+			Spender:   key.spender,
+			Allowance: allowance,
+			Unlimited: allowance.Cmp(unlimited) >= 0,
+		})
+	}
+	return approvals, nil
+}
+
+// RevokeApproval signs and broadcasts a transaction calling `approve(spender, 0)` of the token,
+// and returns its hash. The fee is paid in Ether. Returns keystore.ErrSigningAborted on user abort.
+func (account *Account) RevokeApproval(token common.Address, spender common.Address) (common.Hash, error) {
+	account.log.WithField("token", token.Hex()).WithField("spender", spender.Hex()).Info("Revoking approval")
+	return account.SendTransactionRequest(&TransactionRequest{
+		From: account.address.Address,
+		To:   &token,
+		Data: erc20ApproveData(spender, big.NewInt(0)),
+	})
+}
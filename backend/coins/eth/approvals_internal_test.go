@@ -0,0 +1,67 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovalKeys(t *testing.T) {
+	owner := common.BytesToHash(common.HexToAddress("0x1111111111111111111111111111111111111111").Bytes())
+	tokenA := common.HexToAddress("0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	tokenB := common.HexToAddress("0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+	spender1 := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	spender2 := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	approval := func(token common.Address, spender common.Address) types.Log {
+		return types.Log{
+			Address: token,
+			Topics:  []common.Hash{erc20ApprovalTopic, owner, common.BytesToHash(spender.Bytes())},
+		}
+	}
+	removed := approval(tokenA, spender2)
+	removed.Removed = true
+	erc721Approval := approval(tokenA, spender2)
+	erc721Approval.Topics = append(erc721Approval.Topics, common.BigToHash(big.NewInt(1)))
+
+	require.Equal(t,
+		[]approvalKey{
+			{token: tokenA, spender: spender1},
+			{token: tokenB, spender: spender1},
+			{token: tokenB, spender: spender2},
+		},
+		approvalKeys([]types.Log{
+			approval(tokenB, spender2),
+			approval(tokenA, spender1),
+			approval(tokenB, spender1),
+			approval(tokenA, spender1),
+			removed,
+			erc721Approval,
+		}))
+}
+
+func TestERC20ApproveData(t *testing.T) {
+	require.Equal(t,
+		"095ea7b3"+
+			"0000000000000000000000002222222222222222222222222222222222222222"+
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		hex.EncodeToString(erc20ApproveData(
+			common.HexToAddress("0x2222222222222222222222222222222222222222"), big.NewInt(0))))
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"math/big"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// blockHeader contains the header fields we need. The go-ethereum version we depend on does not
+// decode the base fee introduced in EIP-1559.
+type blockHeader struct {
+	Number        *hexutil.Big `json:"number"`
+	BaseFeePerGas *hexutil.Big `json:"baseFeePerGas"`
+}
+
+// baseFeeTracker keeps track of the base fee of the latest known block.
+type baseFeeTracker struct {
+	locker.Locker
+	blockNumber *big.Int
+	baseFee     *big.Int
+}
+
+// update records the base fee of the given block. Headers of blocks older than the latest known
+// one are ignored.
+func (tracker *baseFeeTracker) update(header *blockHeader) {
+	if header.Number == nil || header.BaseFeePerGas == nil {
+		return
+	}
+	defer tracker.Lock()()
+	number := header.Number.ToInt()
+	if tracker.blockNumber != nil && number.Cmp(tracker.blockNumber) < 0 {
+		return
+	}
+	tracker.blockNumber = number
+	tracker.baseFee = header.BaseFeePerGas.ToInt()
+}
+
+// BaseFee returns the base fee per gas of the latest known block, or nil if it is not known yet.
+func (tracker *baseFeeTracker) BaseFee() *big.Int {
+	defer tracker.RLock()()
+	if tracker.baseFee == nil {
+		return nil
+	}
+	return new(big.Int).Set(tracker.baseFee)
+}
+
+// MaxFeePerGas returns the fee cap of a transaction paying the given priority fee (tip). Twice the
+// base fee is allowed so the transaction stays valid for six consecutive full blocks, each of which
+// increases the base fee by at most 12.5%.
+func MaxFeePerGas(baseFee *big.Int, maxPriorityFeePerGas *big.Int) *big.Int {
+	return new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), maxPriorityFeePerGas)
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newHeader(number int64, baseFee *big.Int) *blockHeader {
+	header := &blockHeader{Number: (*hexutil.Big)(big.NewInt(number))}
+	if baseFee != nil {
+		header.BaseFeePerGas = (*hexutil.Big)(baseFee)
+	}
+	return header
+}
+
+func TestBaseFeeTracker(t *testing.T) {
+	tracker := &baseFeeTracker{}
+	require.Nil(t, tracker.BaseFee())
+
+	// Pre-London blocks have no base fee.
+	tracker.update(newHeader(10, nil))
+	require.Nil(t, tracker.BaseFee())
+
+	tracker.update(newHeader(11, big.NewInt(100)))
+	require.Equal(t, big.NewInt(100), tracker.BaseFee())
+
+	// Older blocks are ignored.
+	tracker.update(newHeader(9, big.NewInt(50)))
+	require.Equal(t, big.NewInt(100), tracker.BaseFee())
+
+	tracker.update(newHeader(12, big.NewInt(120)))
+	require.Equal(t, big.NewInt(120), tracker.BaseFee())
+
+	// The returned value is a copy.
+	tracker.BaseFee().SetInt64(0)
+	require.Equal(t, big.NewInt(120), tracker.BaseFee())
+}
+
+func TestMaxFeePerGas(t *testing.T) {
+	require.Equal(t, big.NewInt(2*100+3), MaxFeePerGas(big.NewInt(100), big.NewInt(3)))
+}
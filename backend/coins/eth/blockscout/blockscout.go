@@ -0,0 +1,498 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockscout
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// maxPages limits the number of requests of one query. Blockscout returns 50 items per page.
+const maxPages = 100
+
+const requestTimeout = 30 * time.Second
+
+// Blockscout is a client of the REST API (v2) of a Blockscout instance, which can be self-hosted.
+// See https://docs.blockscout.com/devs/apis/rest.
+type Blockscout struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewBlockscout creates a new instance of Blockscout. url is the URL of the instance, e.g.
+// https://eth.blockscout.com.
+func NewBlockscout(url string) *Blockscout {
+	return &Blockscout{
+		url:        strings.TrimRight(url, "/"),
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// page is a page of a list returned by the API.
+type page struct {
+	Items json.RawMessage `json:"items"`
+	// NextPageParams are the query parameters of the next page, or nil if this is the last one.
+	NextPageParams map[string]json.RawMessage `json:"next_page_params"`
+}
+
+// list fetches the pages of the list at the given path and calls onItems with the items of each
+// page.
+func (blockscout *Blockscout) list(
+	path string, params url.Values, onItems func(json.RawMessage) error) error {
+	for i := 0; i < maxPages; i++ {
+		var result page
+		if err := blockscout.get(path, params, &result); err != nil {
+			return err
+		}
+		if err := onItems(result.Items); err != nil {
+			return err
+		}
+		if result.NextPageParams == nil {
+			return nil
+		}
+		for key, value := range result.NextPageParams {
+			var stringValue string
+			if err := json.Unmarshal(value, &stringValue); err != nil {
+				// Numbers and null are passed as they are encoded.
+				stringValue = string(value)
+			}
+			params.Set(key, stringValue)
+		}
+	}
+	return nil
+}
+
+func (blockscout *Blockscout) get(path string, params url.Values, result interface{}) error {
+	response, err := blockscout.httpClient.Get(blockscout.url + path + "?" + params.Encode())
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode != http.StatusOK {
+		return errp.Newf("Blockscout responded with status %d.", response.StatusCode)
+	}
+	if err := json.NewDecoder(response.Body).Decode(result); err != nil {
+		return errp.WithStack(err)
+	}
+	return nil
+}
+
+type jsonBigInt big.Int
+
+func (jsBigInt *jsonBigInt) BigInt() *big.Int {
+	if jsBigInt == nil {
+		return big.NewInt(0)
+	}
+	bigInt := big.Int(*jsBigInt)
+	return &bigInt
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (jsBigInt *jsonBigInt) UnmarshalJSON(jsonBytes []byte) error {
+	var numberString string
+	if err := json.Unmarshal(jsonBytes, &numberString); err != nil {
+		return errp.WithStack(err)
+	}
+	bigInt, ok := new(big.Int).SetString(numberString, 10)
+	if !ok {
+		return errp.Newf("failed to parse %s", numberString)
+	}
+	*jsBigInt = jsonBigInt(*bigInt)
+	return nil
+}
+
+type jsonAddress struct {
+	Hash common.Address `json:"hash"`
+}
+
+type jsonTransaction struct {
+	Hash common.Hash `json:"hash"`
+	// BlockNumber is nil for pending transactions.
+	BlockNumber *uint64     `json:"block_number"`
+	Timestamp   time.Time   `json:"timestamp"`
+	From        jsonAddress `json:"from"`
+	// To is nil for contract creations.
+	To    *jsonAddress `json:"to"`
+	Value jsonBigInt   `json:"value"`
+	Fee   struct {
+		Value jsonBigInt `json:"value"`
+	} `json:"fee"`
+}
+
+type jsonTokenTransfer struct {
+	TransactionHash common.Hash `json:"transaction_hash"`
+	// TxHash is the name of TransactionHash in older versions of Blockscout.
+	TxHash      common.Hash `json:"tx_hash"`
+	BlockNumber *uint64     `json:"block_number"`
+	Timestamp   time.Time   `json:"timestamp"`
+	From        jsonAddress `json:"from"`
+	To          jsonAddress `json:"to"`
+	Total       struct {
+		// Value is nil for ERC-721 tokens.
+		Value *jsonBigInt `json:"value"`
+		// TokenID is nil for ERC-20 tokens.
+		TokenID *jsonBigInt `json:"token_id"`
+	} `json:"total"`
+	Token struct {
+		AddressHash common.Address `json:"address_hash"`
+		// Address is the name of AddressHash in older versions of Blockscout.
+		Address common.Address `json:"address"`
+		Name    string         `json:"name"`
+		Type    string         `json:"type"`
+	} `json:"token"`
+}
+
+type jsonInternalTransaction struct {
+	TransactionHash common.Hash `json:"transaction_hash"`
+	BlockNumber     *uint64     `json:"block_number"`
+	// Block is the name of BlockNumber in older versions of Blockscout.
+	Block     *uint64     `json:"block"`
+	Timestamp time.Time   `json:"timestamp"`
+	From      jsonAddress `json:"from"`
+	// To is nil for contract creations.
+	To      *jsonAddress `json:"to"`
+	Value   jsonBigInt   `json:"value"`
+	Success bool         `json:"success"`
+}
+
+func (transaction *jsonInternalTransaction) blockNumber() *uint64 {
+	if transaction.BlockNumber != nil {
+		return transaction.BlockNumber
+	}
+	return transaction.Block
+}
+
+func (transfer *jsonTokenTransfer) hash() common.Hash {
+	if transfer.TransactionHash != (common.Hash{}) {
+		return transfer.TransactionHash
+	}
+	return transfer.TxHash
+}
+
+func (transfer *jsonTokenTransfer) contract() common.Address {
+	if transfer.Token.AddressHash != (common.Address{}) {
+		return transfer.Token.AddressHash
+	}
+	return transfer.Token.Address
+}
+
+// Transaction implements coin.Transaction.
+type Transaction struct {
+	hash             common.Hash
+	timestamp        time.Time
+	numConfirmations int
+	txType           coin.TxType
+	amount           *big.Int
+	// fee is nil for token transfers, as the fee is paid in Ether, and for internal transactions.
+	fee *big.Int
+	to  common.Address
+	// internal is true for transfers of Ether by a contract.
+	internal bool
+}
+
+// Fee implements coin.Transaction.
+func (tx *Transaction) Fee() *coin.Amount {
+	if tx.fee == nil {
+		return nil
+	}
+	amount := coin.NewAmount(tx.fee)
+	return &amount
+}
+
+// Timestamp implements coin.Transaction.
+func (tx *Transaction) Timestamp() *time.Time {
+	t := tx.timestamp
+	return &t
+}
+
+// ID implements coin.Transaction.
+func (tx *Transaction) ID() string {
+	return tx.hash.Hex()
+}
+
+// NumConfirmations implements coin.Transaction.
+func (tx *Transaction) NumConfirmations() int {
+	return tx.numConfirmations
+}
+
+// Internal implements eth.IndexedTransaction.
+func (tx *Transaction) Internal() bool {
+	return tx.internal
+}
+
+// Type implements coin.Transaction.
+func (tx *Transaction) Type() coin.TxType {
+	return tx.txType
+}
+
+// Amount implements coin.Transaction.
+func (tx *Transaction) Amount() coin.Amount {
+	return coin.NewAmount(tx.amount)
+}
+
+// Addresses implements coin.Transaction.
+func (tx *Transaction) Addresses() []string {
+	return []string{tx.to.Hex()}
+}
+
+// included returns whether the transaction in the block with the given number is included in the
+// chain until endBlock, and its number of confirmations.
+func included(blockNumber *uint64, endBlock *big.Int) (bool, int) {
+	if blockNumber == nil || new(big.Int).SetUint64(*blockNumber).Cmp(endBlock) > 0 {
+		return false, 0
+	}
+	return true, int(endBlock.Uint64() - *blockNumber + 1)
+}
+
+// txType returns the type of the transaction based on the account address, or an error if the
+// transaction does not belong to it.
+func txType(from common.Address, to common.Address, address common.Address) (coin.TxType, error) {
+	switch {
+	case from == address && to == address:
+		return coin.TxTypeSendSelf, nil
+	case from == address:
+		return coin.TxTypeSend, nil
+	case to == address:
+		return coin.TxTypeReceive, nil
+	default:
+		return "", errp.New("transaction does not belong to our account")
+	}
+}
+
+// Transactions queries Blockscout for transactions for the given account, until endBlock. They
+// include the internal transactions, in which contracts transfer Ether from or to the account.
+func (blockscout *Blockscout) Transactions(address common.Address, endBlock *big.Int) (
+	[]coin.Transaction, error) {
+	transactions, err := blockscout.transactions(address, endBlock)
+	if err != nil {
+		return nil, err
+	}
+	internalTransactions, err := blockscout.internalTransactions(address, endBlock)
+	if err != nil {
+		return nil, err
+	}
+	transactions = append(transactions, internalTransactions...)
+	// Newest first. The transactions sort before the internal transactions of the same block.
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].NumConfirmations() < transactions[j].NumConfirmations()
+	})
+	return transactions, nil
+}
+
+func (blockscout *Blockscout) transactions(address common.Address, endBlock *big.Int) (
+	[]coin.Transaction, error) {
+	transactions := []coin.Transaction{}
+	err := blockscout.list("/api/v2/addresses/"+address.Hex()+"/transactions", url.Values{},
+		func(items json.RawMessage) error {
+			var jsonTransactions []*jsonTransaction
+			if err := json.Unmarshal(items, &jsonTransactions); err != nil {
+				return errp.WithStack(err)
+			}
+			for _, jsonTx := range jsonTransactions {
+				ok, numConfirmations := included(jsonTx.BlockNumber, endBlock)
+				if !ok {
+					continue
+				}
+				var to common.Address
+				if jsonTx.To != nil {
+					to = jsonTx.To.Hash
+				}
+				transactionType, err := txType(jsonTx.From.Hash, to, address)
+				if err != nil {
+					return err
+				}
+				transactions = append(transactions, &Transaction{
+					hash:             jsonTx.Hash,
+					timestamp:        jsonTx.Timestamp,
+					numConfirmations: numConfirmations,
+					txType:           transactionType,
+					amount:           jsonTx.Value.BigInt(),
+					fee:              jsonTx.Fee.Value.BigInt(),
+					to:               to,
+				})
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// internalTransactions queries Blockscout for the successful internal transactions which transfer
+// Ether from or to the given account, until endBlock.
+func (blockscout *Blockscout) internalTransactions(address common.Address, endBlock *big.Int) (
+	[]coin.Transaction, error) {
+	transactions := []coin.Transaction{}
+	err := blockscout.list("/api/v2/addresses/"+address.Hex()+"/internal-transactions", url.Values{},
+		func(items json.RawMessage) error {
+			var jsonTransactions []*jsonInternalTransaction
+			if err := json.Unmarshal(items, &jsonTransactions); err != nil {
+				return errp.WithStack(err)
+			}
+			for _, jsonTx := range jsonTransactions {
+				if !jsonTx.Success || jsonTx.Value.BigInt().Sign() == 0 {
+					continue
+				}
+				ok, numConfirmations := included(jsonTx.blockNumber(), endBlock)
+				if !ok {
+					continue
+				}
+				var to common.Address
+				if jsonTx.To != nil {
+					to = jsonTx.To.Hash
+				}
+				transactionType, err := txType(jsonTx.From.Hash, to, address)
+				if err != nil {
+					return err
+				}
+				transactions = append(transactions, &Transaction{
+					hash:             jsonTx.TransactionHash,
+					timestamp:        jsonTx.Timestamp,
+					numConfirmations: numConfirmations,
+					txType:           transactionType,
+					amount:           jsonTx.Value.BigInt(),
+					to:               to,
+					internal:         true,
+				})
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// tokenTransfers queries Blockscout for the token transfers of the given types (e.g. `ERC-20`) from
+// or to the given account, until endBlock, optionally filtered by the contract of the token.
+func (blockscout *Blockscout) tokenTransfers(
+	types string, contract *common.Address, address common.Address, endBlock *big.Int,
+	onTransfer func(*jsonTokenTransfer, int) error) error {
+	params := url.Values{}
+	params.Set("type", types)
+	if contract != nil {
+		params.Set("token", contract.Hex())
+	}
+	return blockscout.list("/api/v2/addresses/"+address.Hex()+"/token-transfers", params,
+		func(items json.RawMessage) error {
+			var transfers []*jsonTokenTransfer
+			if err := json.Unmarshal(items, &transfers); err != nil {
+				return errp.WithStack(err)
+			}
+			for _, transfer := range transfers {
+				ok, numConfirmations := included(transfer.BlockNumber, endBlock)
+				if !ok {
+					continue
+				}
+				if err := onTransfer(transfer, numConfirmations); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+// ERC20Transactions queries Blockscout for the transfers of the token at the given contract
+// address from or to the given account, until endBlock.
+func (blockscout *Blockscout) ERC20Transactions(
+	contract common.Address, address common.Address, endBlock *big.Int) ([]coin.Transaction, error) {
+	transactions := []coin.Transaction{}
+	// A transaction can contain several transfers, of which only the first one is listed, as with
+	// Etherscan.
+	seen := map[common.Hash]struct{}{}
+	err := blockscout.tokenTransfers("ERC-20", &contract, address, endBlock,
+		func(transfer *jsonTokenTransfer, numConfirmations int) error {
+			if _, ok := seen[transfer.hash()]; ok {
+				return nil
+			}
+			seen[transfer.hash()] = struct{}{}
+			transactionType, err := txType(transfer.From.Hash, transfer.To.Hash, address)
+			if err != nil {
+				return err
+			}
+			transactions = append(transactions, &Transaction{
+				hash:             transfer.hash(),
+				timestamp:        transfer.Timestamp,
+				numConfirmations: numConfirmations,
+				txType:           transactionType,
+				amount:           transfer.Total.Value.BigInt(),
+				to:               transfer.To.Hash,
+			})
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// NFTTransfers queries Blockscout for the transfers of ERC-721 and ERC-1155 tokens from or to the
+// given account, until endBlock.
+func (blockscout *Blockscout) NFTTransfers(address common.Address, endBlock *big.Int) (
+	[]*etherscan.NFTTransfer, error) {
+	transfers := []*etherscan.NFTTransfer{}
+	err := blockscout.tokenTransfers("ERC-721,ERC-1155", nil, address, endBlock,
+		func(transfer *jsonTokenTransfer, _ int) error {
+			if transfer.Total.TokenID == nil {
+				return errp.New("NFT transfer without token ID")
+			}
+			erc1155 := transfer.Token.Type == "ERC-1155"
+			amount := big.NewInt(1)
+			if erc1155 {
+				amount = transfer.Total.Value.BigInt()
+			}
+			transfers = append(transfers, &etherscan.NFTTransfer{
+				Contract: transfer.contract(),
+				From:     transfer.From.Hash,
+				To:       transfer.To.Hash,
+				TokenID:  transfer.Total.TokenID.BigInt(),
+				Amount:   amount,
+				Name:     transfer.Token.Name,
+				ERC1155:  erc1155,
+			})
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// ContractName returns the name of the verified contract at the given address, or an empty string
+// if the contract is not verified.
+func (blockscout *Blockscout) ContractName(address common.Address) (string, error) {
+	var contract struct {
+		Name       string `json:"name"`
+		IsVerified bool   `json:"is_verified"`
+	}
+	if err := blockscout.get("/api/v2/smart-contracts/"+address.Hex(), url.Values{}, &contract); err != nil {
+		return "", err
+	}
+	if !contract.IsVerified {
+		return "", nil
+	}
+	return contract.Name, nil
+}
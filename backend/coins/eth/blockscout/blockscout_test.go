@@ -0,0 +1,156 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockscout_test
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/blockscout"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	ours  = "0x1111111111111111111111111111111111111111"
+	other = "0x2222222222222222222222222222222222222222"
+	token = "0x3333333333333333333333333333333333333333"
+	hash1 = "0x0000000000000000000000000000000000000000000000000000000000000001"
+	hash2 = "0x0000000000000000000000000000000000000000000000000000000000000002"
+	hash3 = "0x0000000000000000000000000000000000000000000000000000000000000003"
+)
+
+func TestTransactions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v2/addresses/"+ours+"/internal-transactions" {
+			// Failed and zero-value internal transactions are skipped.
+			_, _ = w.Write([]byte(`{"items":[
+				{"transaction_hash":"` + hash1 + `","block_number":95,"timestamp":"2023-01-01T00:00:00.000000Z",
+				 "from":{"hash":"` + other + `"},"to":{"hash":"` + ours + `"},"value":"5","success":true},
+				{"transaction_hash":"` + hash1 + `","block":94,"timestamp":"2023-01-01T00:00:00.000000Z",
+				 "from":{"hash":"` + other + `"},"to":{"hash":"` + ours + `"},"value":"6","success":false},
+				{"transaction_hash":"` + hash1 + `","block":93,"timestamp":"2023-01-01T00:00:00.000000Z",
+				 "from":{"hash":"` + ours + `"},"to":{"hash":"` + other + `"},"value":"0","success":true}
+			],"next_page_params":null}`))
+			return
+		}
+		require.Equal(t, "/api/v2/addresses/"+ours+"/transactions", r.URL.Path)
+		if r.URL.Query().Get("block_number") == "" {
+			_, _ = w.Write([]byte(`{"items":[
+				{"hash":"` + hash1 + `","block_number":110,"timestamp":"2023-01-02T00:00:00.000000Z",
+				 "from":{"hash":"` + ours + `"},"to":{"hash":"` + other + `"},"value":"1",
+				 "fee":{"type":"actual","value":"21000"}},
+				{"hash":"` + hash2 + `","block_number":100,"timestamp":"2023-01-01T00:00:00.000000Z",
+				 "from":{"hash":"` + other + `"},"to":{"hash":"` + ours + `"},"value":"2",
+				 "fee":{"type":"actual","value":"21000"}}
+			],"next_page_params":{"block_number":100,"index":0,"items_count":50}}`))
+			return
+		}
+		require.Equal(t, "100", r.URL.Query().Get("block_number"))
+		require.Equal(t, "50", r.URL.Query().Get("items_count"))
+		_, _ = w.Write([]byte(`{"items":[
+			{"hash":"` + hash3 + `","block_number":90,"timestamp":"2022-12-31T00:00:00.000000Z",
+			 "from":{"hash":"` + ours + `"},"to":{"hash":"` + ours + `"},"value":"3",
+			 "fee":{"type":"actual","value":"42000"}}
+		],"next_page_params":null}`))
+	}))
+	defer server.Close()
+
+	transactions, err := blockscout.NewBlockscout(server.URL+"/").Transactions(
+		common.HexToAddress(ours), big.NewInt(105))
+	require.NoError(t, err)
+	// The first transaction is not included until block 105.
+	require.Len(t, transactions, 3)
+
+	require.Equal(t, hash2, transactions[0].ID())
+	require.Equal(t, coin.TxTypeReceive, transactions[0].Type())
+	require.Equal(t, 6, transactions[0].NumConfirmations())
+	require.Equal(t, "2", transactions[0].Amount().BigInt().String())
+	require.Equal(t, "21000", transactions[0].Fee().BigInt().String())
+	require.Equal(t, int64(1672531200), transactions[0].Timestamp().Unix())
+	require.False(t, transactions[0].(*blockscout.Transaction).Internal())
+
+	require.Equal(t, hash1, transactions[1].ID())
+	require.True(t, transactions[1].(*blockscout.Transaction).Internal())
+	require.Equal(t, coin.TxTypeReceive, transactions[1].Type())
+	require.Equal(t, 11, transactions[1].NumConfirmations())
+	require.Equal(t, "5", transactions[1].Amount().BigInt().String())
+	require.Nil(t, transactions[1].Fee())
+
+	require.Equal(t, coin.TxTypeSendSelf, transactions[2].Type())
+	require.Equal(t, 16, transactions[2].NumConfirmations())
+}
+
+func TestTokenTransfers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v2/addresses/"+ours+"/token-transfers", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("type") {
+		case "ERC-20":
+			require.Equal(t, common.HexToAddress(token).Hex(), r.URL.Query().Get("token"))
+			_, _ = w.Write([]byte(`{"items":[
+				{"transaction_hash":"` + hash1 + `","block_number":100,"timestamp":"2023-01-01T00:00:00Z",
+				 "from":{"hash":"` + other + `"},"to":{"hash":"` + ours + `"},
+				 "total":{"decimals":"6","value":"1000000"},
+				 "token":{"address_hash":"` + token + `","type":"ERC-20"}},
+				{"transaction_hash":"` + hash1 + `","block_number":100,"timestamp":"2023-01-01T00:00:00Z",
+				 "from":{"hash":"` + other + `"},"to":{"hash":"` + ours + `"},
+				 "total":{"decimals":"6","value":"5"},
+				 "token":{"address_hash":"` + token + `","type":"ERC-20"}}
+			],"next_page_params":null}`))
+		case "ERC-721,ERC-1155":
+			_, _ = w.Write([]byte(`{"items":[
+				{"tx_hash":"` + hash2 + `","block_number":100,"timestamp":"2023-01-01T00:00:00Z",
+				 "from":{"hash":"` + other + `"},"to":{"hash":"` + ours + `"},
+				 "total":{"token_id":"7","value":"3"},
+				 "token":{"address":"` + token + `","name":"Items","type":"ERC-1155"}},
+				{"tx_hash":"` + hash3 + `","block_number":100,"timestamp":"2023-01-01T00:00:00Z",
+				 "from":{"hash":"` + ours + `"},"to":{"hash":"` + other + `"},
+				 "total":{"token_id":"8"},
+				 "token":{"address":"` + token + `","name":"Punks","type":"ERC-721"}}
+			],"next_page_params":null}`))
+		default:
+			t.Fatalf("unexpected type %s", r.URL.Query().Get("type"))
+		}
+	}))
+	defer server.Close()
+	client := blockscout.NewBlockscout(server.URL)
+
+	transactions, err := client.ERC20Transactions(
+		common.HexToAddress(token), common.HexToAddress(ours), big.NewInt(100))
+	require.NoError(t, err)
+	// Only the first transfer of a transaction is listed.
+	require.Len(t, transactions, 1)
+	require.Equal(t, coin.TxTypeReceive, transactions[0].Type())
+	require.Equal(t, "1000000", transactions[0].Amount().BigInt().String())
+	require.Nil(t, transactions[0].Fee())
+	require.Equal(t, 1, transactions[0].NumConfirmations())
+
+	transfers, err := client.NFTTransfers(common.HexToAddress(ours), big.NewInt(100))
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.True(t, transfers[0].ERC1155)
+	require.Equal(t, common.HexToAddress(token), transfers[0].Contract)
+	require.Equal(t, "7", transfers[0].TokenID.String())
+	require.Equal(t, "3", transfers[0].Amount.String())
+	require.Equal(t, "Items", transfers[0].Name)
+	require.False(t, transfers[1].ERC1155)
+	require.Equal(t, "1", transfers[1].Amount.String())
+	require.Equal(t, common.HexToAddress(ours), transfers[1].From)
+}
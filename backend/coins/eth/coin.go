@@ -1,64 +1,163 @@
 package eth
 
 import (
+	"context"
 	"math/big"
 	"strings"
 	"sync"
 
 	coinpkg "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/blockscout"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/observable"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
-// Coin models an Ethereum coin.
+// Coin models an Ethereum coin, or an ERC20 token on Ethereum.
 type Coin struct {
 	observable.Implementation
-	initOnce              sync.Once
-	client                *ethclient.Client
-	code                  string
-	net                   *params.ChainConfig
-	blockExplorerTxPrefix string
-	etherScan             *etherscan.EtherScan
-}
-
-// NewCoin creates a new coin with the given parameters.
-func NewCoin(
-	code string,
-	net *params.ChainConfig,
-	blockExplorerTxPrefix string,
-) *Coin {
+	initOnce       sync.Once
+	client         *ethclient.Client
+	rpcClient      *rpc.Client
+	baseFeeTracker *baseFeeTracker
+	gasOracle      *gasOracle
+	code           string
+	network        *Network
+	// rpcURL is the URL of the node. The default provider is used if it is empty.
+	rpcURL string
+	// indexerURL is the URL of the Blockscout instance, or NodeIndexer to reconstruct the token
+	// transfers from the logs of the node. Etherscan is used if it is empty.
+	indexerURL string
+	indexer    Indexer
+	// relayURL is the URL of the protected relay through which transactions are broadcast instead
+	// of the node, e.g. Flashbots Protect, which keeps them out of the public mempool.
+	// Transactions are broadcast to the node if it is empty.
+	relayURL    string
+	relayClient *rpc.Client
+
+	// erc20Token is the token of the coin, or nil if the coin is Ether.
+	erc20Token *ERC20Token
+	// ether is the Ether coin of the network of an ERC20 token, whose connections are shared.
+	ether *Coin
+}
+
+// NewCoin creates the Ether coin of the given network. rpcURL is the HTTP or WebSocket URL of the
+// node to connect to, or empty to use the default provider. indexerURL is the URL of a Blockscout
+// instance from which the transaction history is fetched, NodeIndexer to reconstruct the token
+// transfers from the logs of the node, or empty to use Etherscan.
+func NewCoin(code string, network *Network, rpcURL string, indexerURL string) *Coin {
+	return &Coin{
+		code:           code,
+		network:        network,
+		rpcURL:         rpcURL,
+		indexerURL:     indexerURL,
+		baseFeeTracker: &baseFeeTracker{},
+		gasOracle:      &gasOracle{},
+	}
+}
+
+// NewERC20Coin creates the coin of an ERC20 token on the network of the given Ether coin.
+func NewERC20Coin(code string, ether *Coin, erc20Token *ERC20Token) *Coin {
 	return &Coin{
-		code:                  code,
-		net:                   net,
-		blockExplorerTxPrefix: blockExplorerTxPrefix,
+		code:           code,
+		network:        ether.network,
+		baseFeeTracker: ether.baseFeeTracker,
+		gasOracle:      ether.gasOracle,
+		erc20Token:     erc20Token,
+		ether:          ether,
 	}
 }
 
+// ERC20Token returns the token of the coin, or nil if the coin is Ether.
+func (coin *Coin) ERC20Token() *ERC20Token { return coin.erc20Token }
+
+// Ether returns the coin in which the fees of transactions are paid, which is the coin itself if
+// it is not an ERC20 token.
+func (coin *Coin) Ether() *Coin {
+	if coin.ether != nil {
+		return coin.ether
+	}
+	return coin
+}
+
+// Network returns the parameters of the network of the coin.
+func (coin *Coin) Network() *Network { return coin.network }
+
 // Net returns the network (mainnet, testnet, etc.).
-func (coin *Coin) Net() *params.ChainConfig { return coin.net }
+func (coin *Coin) Net() *params.ChainConfig { return coin.network.ChainConfig }
 
 // Initialize implements coin.Coin.
 func (coin *Coin) Initialize() {
 	coin.initOnce.Do(func() {
-		url := `https://mainnet.infura.io`
-		etherScanURL := "https://api.etherscan.io/api"
-		if coin.code == "teth" {
-			url = `https://rinkeby.infura.io`
-			etherScanURL = "https://api-rinkeby.etherscan.io/api"
+		if coin.ether != nil {
+			coin.ether.Initialize()
+			coin.rpcClient = coin.ether.rpcClient
+			coin.client = coin.ether.client
+			coin.indexer = coin.ether.indexer
+			coin.relayClient = coin.ether.relayClient
+			return
+		}
+		url := coin.network.DefaultRPCURL
+		if coin.rpcURL != "" {
+			url = coin.rpcURL
+		}
+		rpcClient, err := rpc.Dial(url)
+		if err != nil && coin.rpcURL != "" {
+			// The WebSocket connection to the configured node could not be established. Instead of
+			// falling back to the default provider, which would leak the addresses, we use a client
+			// whose calls fail, so that the error is reported by the accounts.
+			rpcClient, err = rpc.DialHTTP(url)
 		}
-		client, err := ethclient.Dial(url)
 		if err != nil {
 			// TODO: init conn lazily, feed error via EventStatusChanged
 			panic(err)
 		}
-		coin.client = client
+		coin.rpcClient = rpcClient
+		coin.client = ethclient.NewClient(rpcClient)
+		if coin.relayURL != "" {
+			relayClient, err := rpc.DialHTTP(coin.relayURL)
+			if err != nil {
+				panic(err)
+			}
+			coin.relayClient = relayClient
+		}
 
-		coin.etherScan = etherscan.NewEtherScan(etherScanURL)
+		switch coin.indexerURL {
+		case "":
+			coin.indexer = etherscan.NewEtherScan(coin.network.EtherScanURL)
+		case NodeIndexer:
+			coin.indexer = newLogsIndexer(coin, etherscan.NewEtherScan(coin.network.EtherScanURL))
+		default:
+			coin.indexer = blockscout.NewBlockscout(coin.indexerURL)
+		}
 	})
 }
 
+// SetRelayURL configures the protected relay through which the transactions are broadcast. It has
+// to be called before the coin is initialized.
+func (coin *Coin) SetRelayURL(relayURL string) {
+	coin.relayURL = relayURL
+}
+
+// Close closes the connections to the node and the relay. The coins of ERC20 tokens share the
+// connections of their Ether coin, which closes them.
+func (coin *Coin) Close() {
+	if coin.ether != nil {
+		return
+	}
+	if coin.rpcClient != nil {
+		coin.rpcClient.Close()
+	}
+	if coin.relayClient != nil {
+		coin.relayClient.Close()
+	}
+}
+
 // Code implements coin.Coin.
 func (coin *Coin) Code() string {
 	return strings.ToUpper(coin.code)
@@ -66,11 +165,17 @@ func (coin *Coin) Code() string {
 
 // Unit implements coin.Coin.
 func (coin *Coin) Unit() string {
-	return strings.ToUpper(coin.code)
+	if coin.erc20Token != nil {
+		return coin.erc20Token.Symbol
+	}
+	return coin.network.Unit
 }
 
 // FormatAmount implements coin.Coin.
 func (coin *Coin) FormatAmount(amount coinpkg.Amount) string {
+	if coin.erc20Token != nil {
+		return formatUnits(amount.BigInt(), coin.erc20Token.Decimals)
+	}
 	ether := big.NewInt(1e18)
 	return strings.TrimRight(strings.TrimRight(
 		new(big.Rat).SetFrac(amount.BigInt(), ether).FloatString(18),
@@ -79,10 +184,116 @@ func (coin *Coin) FormatAmount(amount coinpkg.Amount) string {
 
 // BlockExplorerTransactionURLPrefix implements coin.Coin.
 func (coin *Coin) BlockExplorerTransactionURLPrefix() string {
-	return coin.blockExplorerTxPrefix
+	return coin.network.BlockExplorerTxPrefix
 }
 
-// EtherScan returns an instance of EtherScan.
-func (coin *Coin) EtherScan() *etherscan.EtherScan {
-	return coin.etherScan
+// FaucetURL returns the URL of a faucet handing out the coin of the testnet, or an empty string
+// for a mainnet.
+func (coin *Coin) FaucetURL() string {
+	return coin.network.FaucetURL
+}
+
+// AddressUsed returns whether the address holds Ether or has sent transactions. The coin has to be
+// initialized.
+func (coin *Coin) AddressUsed(ctx context.Context, address common.Address) (bool, error) {
+	balance, err := coin.client.BalanceAt(ctx, address, nil)
+	if err != nil {
+		return false, errp.WithStack(err)
+	}
+	if balance.Sign() > 0 {
+		return true, nil
+	}
+	nonce, err := coin.client.NonceAt(ctx, address, nil)
+	if err != nil {
+		return false, errp.WithStack(err)
+	}
+	return nonce > 0, nil
+}
+
+// Indexer returns the client from which the transaction history is fetched.
+func (coin *Coin) Indexer() Indexer {
+	return coin.indexer
+}
+
+// latestHeader fetches the header of the latest block and updates the base fee tracker.
+func (coin *Coin) latestHeader(ctx context.Context) (*blockHeader, error) {
+	var header *blockHeader
+	if err := coin.rpcClient.CallContext(ctx, &header, "eth_getBlockByNumber", "latest", false); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if header == nil || header.Number == nil {
+		return nil, errp.New("Could not fetch the latest block.")
+	}
+	coin.baseFeeTracker.update(header)
+	return header, nil
+}
+
+// baseFee returns the base fee per gas of the latest block, fetching it if it is not known yet.
+func (coin *Coin) baseFee(ctx context.Context) (*big.Int, error) {
+	if baseFee := coin.baseFeeTracker.BaseFee(); baseFee != nil {
+		return baseFee, nil
+	}
+	header, err := coin.latestHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if header.BaseFeePerGas == nil {
+		return nil, errp.New("The network does not support EIP-1559 transactions.")
+	}
+	return header.BaseFeePerGas.ToInt(), nil
+}
+
+// suggestGasTipCap returns the priority fee per gas suggested by the node, but at least the minimum
+// of the network, or zero if the network does not pay priority fees.
+func (coin *Coin) suggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	if coin.network.IgnoresPriorityFee {
+		return big.NewInt(0), nil
+	}
+	var tip hexutil.Big
+	if err := coin.rpcClient.CallContext(ctx, &tip, "eth_maxPriorityFeePerGas"); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if minTip := coin.network.MinPriorityFee; minTip != nil && tip.ToInt().Cmp(minTip) < 0 {
+		return new(big.Int).Set(minTip), nil
+	}
+	return tip.ToInt(), nil
+}
+
+// checkGasTipCap returns an error if the priority fee per gas is below the minimum of the network.
+func (coin *Coin) checkGasTipCap(gasTipCap *big.Int) error {
+	if minTip := coin.network.MinPriorityFee; minTip != nil && gasTipCap.Cmp(minTip) < 0 {
+		return errp.WithStack(coinpkg.ErrFeeTooLow)
+	}
+	return nil
+}
+
+// sendRawTransaction broadcasts a signed, serialized transaction, through the relay if one is
+// configured.
+func (coin *Coin) sendRawTransaction(ctx context.Context, rawTx []byte) error {
+	client := coin.rpcClient
+	if coin.relayClient != nil {
+		client = coin.relayClient
+	}
+	if err := client.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Encode(rawTx)); err != nil {
+		return errp.WithStack(err)
+	}
+	return nil
+}
+
+// CheckRPC connects to the node at the given URL and returns an error if it cannot be reached or
+// if it is on another network.
+func CheckRPC(ctx context.Context, rpcURL string, net *params.ChainConfig) error {
+	rpcClient, err := rpc.DialContext(ctx, rpcURL)
+	if err != nil {
+		return errp.WithMessage(errp.WithStack(err), "Could not connect to the node")
+	}
+	defer rpcClient.Close()
+	var chainID hexutil.Big
+	if err := rpcClient.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		return errp.WithMessage(errp.WithStack(err), "Could not connect to the node")
+	}
+	if chainID.ToInt().Cmp(net.ChainID) != 0 {
+		return errp.Newf("The node is on another network (chain ID %s).", chainID.ToInt())
+	}
+	return nil
 }
@@ -0,0 +1,63 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendRawTransactionRelay(t *testing.T) {
+	newServer := func(calls *[]string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request struct {
+				ID     json.RawMessage   `json:"id"`
+				Method string            `json:"method"`
+				Params []json.RawMessage `json:"params"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			*calls = append(*calls, request.Method+" "+string(request.Params[0]))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(request.ID) + `,"result":"0x01"}`))
+		}))
+	}
+	var nodeCalls, relayCalls []string
+	node := newServer(&nodeCalls)
+	defer node.Close()
+	relay := newServer(&relayCalls)
+	defer relay.Close()
+
+	coin := NewCoin("eth", MainnetNetwork, node.URL, "")
+	coin.Initialize()
+	defer coin.Close()
+	require.NoError(t, coin.sendRawTransaction(context.Background(), []byte{0x01, 0x02}))
+	require.Equal(t, []string{`eth_sendRawTransaction "0x0102"`}, nodeCalls)
+
+	nodeCalls = nil
+	coin = NewCoin("eth", MainnetNetwork, node.URL, "")
+	coin.SetRelayURL(relay.URL)
+	coin.Initialize()
+	defer coin.Close()
+	token := NewERC20Coin("eth-erc20-test", coin, &ERC20Token{Symbol: "TEST", Decimals: 18})
+	token.Initialize()
+	require.NoError(t, token.sendRawTransaction(context.Background(), []byte{0x01, 0x02}))
+	require.Empty(t, nodeCalls)
+	require.Equal(t, []string{`eth_sendRawTransaction "0x0102"`}, relayCalls)
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// delegationPrefix is the prefix of the code of an account which delegates to a contract
+// (EIP-7702). Such an account is controlled by its key and is not a contract itself.
+var delegationPrefix = []byte{0xef, 0x01, 0x00}
+
+// contractNameIndexer is implemented by the indexers which know the names of verified contracts.
+type contractNameIndexer interface {
+	ContractName(address common.Address) (string, error)
+}
+
+// isContract returns true if a contract is deployed at the address.
+func (coin *Coin) isContract(ctx context.Context, address common.Address) (bool, error) {
+	code, err := coin.client.CodeAt(ctx, address, nil)
+	if err != nil {
+		return false, errp.WithStack(err)
+	}
+	if len(code) == len(delegationPrefix)+common.AddressLength && bytes.HasPrefix(code, delegationPrefix) {
+		return false, nil
+	}
+	return len(code) > 0, nil
+}
+
+// recipientContract returns true if the recipient is a contract, and the verified name of the
+// contract if the indexer knows it. The names are cached, also if they are not known, as the
+// indexer can be slow to respond.
+func (account *Account) recipientContract(ctx context.Context, recipient common.Address) (bool, string, error) {
+	isContract, err := account.coin.isContract(ctx, recipient)
+	if err != nil || !isContract {
+		return false, "", err
+	}
+	name, ok := func() (string, bool) {
+		defer account.RLock()()
+		name, ok := account.contractNames[recipient]
+		return name, ok
+	}()
+	if ok {
+		return true, name, nil
+	}
+	if indexer, ok := account.coin.Indexer().(contractNameIndexer); ok {
+		name, err = indexer.ContractName(recipient)
+		if err != nil {
+			account.log.WithError(err).Warning("Could not fetch the name of the recipient contract")
+			name = ""
+		}
+	}
+	defer account.Lock()()
+	account.contractNames[recipient] = name
+	return true, name, nil
+}
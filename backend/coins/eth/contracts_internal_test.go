@@ -0,0 +1,95 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// contractNames is a contractNameIndexer counting its calls.
+type contractNames struct {
+	Indexer
+	calls int
+}
+
+func (indexer *contractNames) ContractName(address common.Address) (string, error) {
+	indexer.calls++
+	return "WETH9", nil
+}
+
+func TestRecipientContract(t *testing.T) {
+	var (
+		account   = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		contract  = common.HexToAddress("0x2222222222222222222222222222222222222222")
+		delegated = common.HexToAddress("0x3333333333333333333333333333333333333333")
+	)
+	codes := map[string]string{
+		account.Hex():   "0x",
+		contract.Hex():  "0x6080604052",
+		delegated.Hex(): "0xef0100" + common.Bytes2Hex(contract.Bytes()),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		require.Equal(t, "eth_getCode", request.Method)
+		var address common.Address
+		require.NoError(t, json.Unmarshal(request.Params[0], &address))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(request.ID) + `,"result":"` + codes[address.Hex()] + `"}`))
+	}))
+	defer server.Close()
+	rpcClient, err := rpc.DialHTTP(server.URL)
+	require.NoError(t, err)
+	coin := NewCoin("eth", MainnetNetwork, "", "")
+	coin.client = ethclient.NewClient(rpcClient)
+	indexer := &contractNames{}
+	coin.indexer = indexer
+	ethAccount := &Account{
+		coin:          coin,
+		contractNames: map[common.Address]string{},
+		log:           logging.Get().WithGroup("eth"),
+	}
+
+	for _, address := range []common.Address{account, delegated} {
+		isContract, name, err := ethAccount.recipientContract(context.Background(), address)
+		require.NoError(t, err)
+		require.False(t, isContract)
+		require.Equal(t, "", name)
+	}
+	require.Equal(t, 0, indexer.calls)
+
+	for i := 0; i < 2; i++ {
+		isContract, name, err := ethAccount.recipientContract(context.Background(), contract)
+		require.NoError(t, err)
+		require.True(t, isContract)
+		require.Equal(t, "WETH9", name)
+	}
+	// The name is cached.
+	require.Equal(t, 1, indexer.calls)
+}
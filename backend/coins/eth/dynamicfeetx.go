@@ -0,0 +1,158 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"math/big"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// EIP-2718 transaction types.
+const (
+	// LegacyTxType is the type of pre EIP-2718 transactions.
+	LegacyTxType uint8 = 0x00
+	// DynamicFeeTxType is the type of EIP-1559 transactions.
+	DynamicFeeTxType uint8 = 0x02
+)
+
+// AccessTuple is an element of an EIP-2930 access list.
+type AccessTuple struct {
+	Address     common.Address
+	StorageKeys []common.Hash
+}
+
+// DynamicFeeTx is an EIP-1559 transaction. The go-ethereum version we depend on only supports
+// legacy transactions, so the encoding and signing rules are implemented here.
+type DynamicFeeTx struct {
+	ChainID   *big.Int
+	Nonce     uint64
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+	Gas       uint64
+	// To is nil for contract creations.
+	To         *common.Address
+	Value      *big.Int
+	Data       []byte
+	AccessList []AccessTuple
+
+	// V is the y-parity of the signature (0 or 1). V, R and S are nil if the tx is unsigned.
+	V, R, S *big.Int
+}
+
+// Type returns the EIP-2718 type of the transaction.
+func (tx *DynamicFeeTx) Type() uint8 {
+	return DynamicFeeTxType
+}
+
+func (tx *DynamicFeeTx) accessList() []AccessTuple {
+	if tx.AccessList == nil {
+		return []AccessTuple{}
+	}
+	return tx.AccessList
+}
+
+func (tx *DynamicFeeTx) unsignedFields() []interface{} {
+	return []interface{}{
+		tx.ChainID,
+		tx.Nonce,
+		tx.GasTipCap,
+		tx.GasFeeCap,
+		tx.Gas,
+		tx.To,
+		tx.Value,
+		tx.Data,
+		tx.accessList(),
+	}
+}
+
+func encodeTyped(txType uint8, fields []interface{}) ([]byte, error) {
+	encoded, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return append([]byte{txType}, encoded...), nil
+}
+
+// SigHash returns the hash to be signed by the sender.
+func (tx *DynamicFeeTx) SigHash() (common.Hash, error) {
+	encoded, err := encodeTyped(tx.Type(), tx.unsignedFields())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+// WithSignature returns a copy of the transaction with the given signature, which has to be in the
+// [R || S || V] format with V being the recovery id (0 or 1).
+func (tx *DynamicFeeTx) WithSignature(sig []byte) (*DynamicFeeTx, error) {
+	if len(sig) != 65 {
+		return nil, errp.Newf("Wrong size for signature: got %d, want 65.", len(sig))
+	}
+	if sig[64] > 1 {
+		return nil, errp.Newf("Invalid signature y-parity %d.", sig[64])
+	}
+	signedTx := *tx
+	signedTx.R = new(big.Int).SetBytes(sig[:32])
+	signedTx.S = new(big.Int).SetBytes(sig[32:64])
+	signedTx.V = new(big.Int).SetUint64(uint64(sig[64]))
+	return &signedTx, nil
+}
+
+// MarshalBinary returns the EIP-2718 encoding of the signed transaction, which can be broadcast
+// using eth_sendRawTransaction.
+func (tx *DynamicFeeTx) MarshalBinary() ([]byte, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return nil, errp.New("The transaction is not signed.")
+	}
+	return encodeTyped(tx.Type(), append(tx.unsignedFields(), tx.V, tx.R, tx.S))
+}
+
+// Hash returns the transaction hash of the signed transaction.
+func (tx *DynamicFeeTx) Hash() (common.Hash, error) {
+	encoded, err := tx.MarshalBinary()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+// Sender recovers the address which signed the transaction.
+func (tx *DynamicFeeTx) Sender() (common.Address, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return common.Address{}, errp.New("The transaction is not signed.")
+	}
+	if tx.V.BitLen() > 1 || !crypto.ValidateSignatureValues(byte(tx.V.Uint64()), tx.R, tx.S, true) {
+		return common.Address{}, errp.New("Invalid transaction signature.")
+	}
+	sigHash, err := tx.SigHash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	sig := make([]byte, 65)
+	copy(sig[32-len(tx.R.Bytes()):32], tx.R.Bytes())
+	copy(sig[64-len(tx.S.Bytes()):64], tx.S.Bytes())
+	sig[64] = byte(tx.V.Uint64())
+	publicKey, err := crypto.Ecrecover(sigHash[:], sig)
+	if err != nil {
+		return common.Address{}, errp.WithStack(err)
+	}
+	var address common.Address
+	copy(address[:], crypto.Keccak256(publicKey[1:])[12:])
+	return address, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDynamicFeeTx checks the encoding against the one of go-ethereum v1.13.
+func TestDynamicFeeTx(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	require.NoError(t, err)
+	recipient := common.HexToAddress("0x3535353535353535353535353535353535353535")
+	tx := &eth.DynamicFeeTx{
+		ChainID:   big.NewInt(4),
+		Nonce:     7,
+		GasTipCap: big.NewInt(1.5e9),
+		GasFeeCap: big.NewInt(30e9),
+		Gas:       21000,
+		To:        &recipient,
+		Value:     big.NewInt(1e18),
+	}
+	require.Equal(t, eth.DynamicFeeTxType, tx.Type())
+	_, err = tx.MarshalBinary()
+	require.Error(t, err)
+
+	sigHash, err := tx.SigHash()
+	require.NoError(t, err)
+	require.Equal(t, "fe05df657ae7b9bda0f5e914789395ec468c5ffbdf7b6cb71934af755225f61f", hex.EncodeToString(sigHash[:]))
+
+	sig, err := crypto.Sign(sigHash[:], privateKey)
+	require.NoError(t, err)
+	signedTx, err := tx.WithSignature(sig)
+	require.NoError(t, err)
+	require.Nil(t, tx.V)
+
+	encoded, err := signedTx.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t,
+		"02f87304078459682f008506fc23ac00825208943535353535353535353535353535353535353535880de0b6b3a"+
+			"764000080c001a04b854a993d871251e2e9a8e7436eaaa33922fab6fbaa6cf7e28e08f2508cc390a067fabd97"+
+			"7a0407b1b96fb7d1dc1fe3f5192a6cc34a580b37136a8a56cf514f55",
+		hex.EncodeToString(encoded))
+	hash, err := signedTx.Hash()
+	require.NoError(t, err)
+	require.Equal(t, "467a9ccca521b44bdedefe47979794fdf47b5146d80eff8686072dd15671bd65", hex.EncodeToString(hash[:]))
+
+	sender, err := signedTx.Sender()
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), sender)
+
+	sig[64] = 27
+	_, err = tx.WithSignature(sig)
+	require.Error(t, err)
+}
@@ -0,0 +1,146 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eip681 parses Ethereum payment URIs as specified in EIP-681, e.g.
+// ethereum:<address>@<chain_id>?value=<wei> or
+// ethereum:<token>@<chain_id>/transfer?address=<recipient>&uint256=<amount>.
+package eip681
+
+import (
+	"math/big"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// numberRegexp matches the numbers of EIP-681, which can have an exponent, e.g. 2.014e18.
+var numberRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([eE][0-9]+)?$`)
+
+// chainIDRegexp matches a decimal chain ID.
+var chainIDRegexp = regexp.MustCompile(`^[0-9]+$`)
+
+// URI is a parsed payment URI.
+type URI struct {
+	// Target is the address of the recipient or of the called contract, or an ENS name.
+	Target string
+	// ChainID is nil if the URI does not specify the chain, which then is mainnet.
+	ChainID *big.Int
+	// FunctionName is the called contract function, e.g. "transfer", or empty for a plain
+	// payment.
+	FunctionName string
+	// Value is the amount of Ether in wei, or nil if the URI does not request an amount.
+	Value *big.Int
+	// GasLimit is 0 if the URI does not specify it.
+	GasLimit uint64
+	// GasPrice is nil if the URI does not specify it.
+	GasPrice *big.Int
+	// Params are the other parameters, e.g. the arguments of the function like "address" and
+	// "uint256".
+	Params map[string]string
+}
+
+// ParseNumber parses a number parameter, which has to be an integer, e.g. 2.014e18.
+func ParseNumber(number string) (*big.Int, error) {
+	if !numberRegexp.MatchString(number) {
+		return nil, errp.Newf("Invalid number %s", number)
+	}
+	mantissa, exponent := strings.ToLower(number), "0"
+	if index := strings.Index(mantissa, "e"); index >= 0 {
+		mantissa, exponent = mantissa[:index], mantissa[index+1:]
+	}
+	rat, ok := new(big.Rat).SetString(mantissa)
+	if !ok {
+		return nil, errp.Newf("Invalid number %s", number)
+	}
+	exp, ok := new(big.Int).SetString(exponent, 10)
+	if !ok || exp.Cmp(big.NewInt(77)) > 0 {
+		// 10^78 exceeds uint256.
+		return nil, errp.Newf("Invalid number %s", number)
+	}
+	rat.Mul(rat, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), exp, nil)))
+	if !rat.IsInt() {
+		return nil, errp.Newf("The number %s is not an integer", number)
+	}
+	return rat.Num(), nil
+}
+
+// Parse parses an Ethereum payment URI. The scheme is case insensitive.
+func Parse(uri string) (*URI, error) {
+	const prefix = "ethereum:"
+	if len(uri) < len(prefix) || !strings.EqualFold(uri[:len(prefix)], prefix) {
+		return nil, errp.Newf("The URI does not start with %s", prefix)
+	}
+	path, query := strings.TrimPrefix(uri[len(prefix):], "pay-"), ""
+	if index := strings.Index(path, "?"); index >= 0 {
+		path, query = path[:index], path[index+1:]
+	}
+	result := &URI{Params: map[string]string{}}
+	if index := strings.Index(path, "/"); index >= 0 {
+		path, result.FunctionName = path[:index], path[index+1:]
+		if result.FunctionName == "" {
+			return nil, errp.New("The URI does not contain a function name")
+		}
+	}
+	if index := strings.Index(path, "@"); index >= 0 {
+		chainID := path[index+1:]
+		if !chainIDRegexp.MatchString(chainID) {
+			return nil, errp.Newf("Invalid chain ID %s", chainID)
+		}
+		result.ChainID, _ = new(big.Int).SetString(chainID, 10)
+		path = path[:index]
+	}
+	switch {
+	case common.IsHexAddress(path) && strings.HasPrefix(path, "0x"):
+		result.Target = path
+	case strings.Contains(path, ".") && !strings.HasPrefix(path, "0x"):
+		// ENS name.
+		result.Target = path
+	default:
+		return nil, errp.Newf("Invalid address %s", path)
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	for key, value := range values {
+		if len(value) != 1 {
+			return nil, errp.Newf("The parameter %s is given more than once", key)
+		}
+		switch key {
+		case "value":
+			result.Value, err = ParseNumber(value[0])
+		case "gas", "gasLimit":
+			var gasLimit *big.Int
+			gasLimit, err = ParseNumber(value[0])
+			if err == nil && !gasLimit.IsUint64() {
+				err = errp.Newf("Invalid gas limit %s", value[0])
+			}
+			if err == nil {
+				result.GasLimit = gasLimit.Uint64()
+			}
+		case "gasPrice":
+			result.GasPrice, err = ParseNumber(value[0])
+		default:
+			result.Params[key] = value[0]
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
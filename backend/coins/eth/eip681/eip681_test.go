@@ -0,0 +1,93 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eip681_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/eip681"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNumber(t *testing.T) {
+	for number, expected := range map[string]string{
+		"0":        "0",
+		"21000":    "21000",
+		"2.014e18": "2014000000000000000",
+		"1E3":      "1000",
+		"1.5e1":    "15",
+	} {
+		parsed, err := eip681.ParseNumber(number)
+		require.NoError(t, err, number)
+		require.Equal(t, expected, parsed.String(), number)
+	}
+	for _, number := range []string{"", "-1", "1.5", "1e", "0x10", "1e78", "1,5"} {
+		_, err := eip681.ParseNumber(number)
+		require.Error(t, err, number)
+	}
+}
+
+func TestParse(t *testing.T) {
+	uri, err := eip681.Parse("ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359?value=2.014e18")
+	require.NoError(t, err)
+	require.Equal(t, &eip681.URI{
+		Target: "0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359",
+		Value:  big.NewInt(2014000000000000000),
+		Params: map[string]string{},
+	}, uri)
+
+	uri, err = eip681.Parse("ETHEREUM:pay-0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359@137?value=1e18&gas=60000&gasPrice=3e10")
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(137), uri.ChainID)
+	require.Equal(t, uint64(60000), uri.GasLimit)
+	require.Equal(t, big.NewInt(30000000000), uri.GasPrice)
+
+	uri, err = eip681.Parse(
+		"ethereum:0x89205a3a3b2a69de6dbf7f01ed13b2108b2c43e7/transfer?address=0x8e23ee67d1332ad560396262c48ffbb01f93d052&uint256=1")
+	require.NoError(t, err)
+	require.Equal(t, &eip681.URI{
+		Target:       "0x89205a3a3b2a69de6dbf7f01ed13b2108b2c43e7",
+		FunctionName: "transfer",
+		Params: map[string]string{
+			"address": "0x8e23ee67d1332ad560396262c48ffbb01f93d052",
+			"uint256": "1",
+		},
+	}, uri)
+
+	uri, err = eip681.Parse("ethereum:vitalik.eth")
+	require.NoError(t, err)
+	require.Equal(t, "vitalik.eth", uri.Target)
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, uri := range []string{
+		"",
+		"0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359",
+		"bitcoin:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359",
+		"ethereum:",
+		"ethereum:0x1234",
+		"ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359@",
+		"ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359@0x1",
+		"ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359/",
+		"ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359?value=1.5",
+		"ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359?value=-1",
+		"ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359?value=1&value=2",
+		"ethereum:0xfb6916095ca1df60bb79Ce92ce3ea74c37c5d359?gas=1e30",
+	} {
+		_, err := eip681.Parse(uri)
+		require.Error(t, err, uri)
+	}
+}
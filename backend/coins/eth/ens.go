@@ -0,0 +1,151 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ensRegistry is the address of the ENS registry, which is the same on mainnet and the testnets.
+var ensRegistry = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+
+// Function selectors of the ENS methods we call.
+var (
+	// ensResolverSelector is the function selector of `resolver(bytes32)` of the registry.
+	ensResolverSelector = []byte{0x01, 0x78, 0xb8, 0xbf}
+	// ensAddrSelector is the function selector of `addr(bytes32)` of a resolver.
+	ensAddrSelector = []byte{0x3b, 0x3b, 0x57, 0xde}
+	// ensNameSelector is the function selector of `name(bytes32)` of a reverse resolver.
+	ensNameSelector = []byte{0x69, 0x1f, 0x34, 0x31}
+)
+
+// normalizeENSName returns the lowercase name, or an error if it is not a .eth name. Full UTS-46
+// normalization is not performed, so names with non-ASCII characters are rejected.
+func normalizeENSName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.HasSuffix(name, ".eth") {
+		return "", errp.New("ENS names must end with .eth.")
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return "", errp.Newf("Invalid ENS name %s.", name)
+		}
+		for _, char := range label {
+			if char > 0x7f {
+				return "", errp.Newf("Invalid ENS name %s.", name)
+			}
+		}
+	}
+	return name, nil
+}
+
+// IsENSName returns true if the recipient is an ENS name instead of an address.
+func IsENSName(recipient string) bool {
+	_, err := normalizeENSName(recipient)
+	return err == nil
+}
+
+// ensNamehash returns the namehash of the normalized name, as defined in EIP-137.
+func ensNamehash(name string) common.Hash {
+	node := common.Hash{}
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = crypto.Keccak256Hash(node.Bytes(), crypto.Keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// ensAddressResult decodes an address return value.
+func ensAddressResult(result []byte) (common.Address, error) {
+	if len(result) < 32 {
+		return common.Address{}, errp.New("Invalid address return value.")
+	}
+	return common.BytesToAddress(result[12:32]), nil
+}
+
+// ensResolver returns the resolver of the node, which is the zero address if there is none.
+func (coin *Coin) ensResolver(ctx context.Context, node common.Hash) (common.Address, error) {
+	result, err := coin.call(ctx, ensRegistry, append(append([]byte{}, ensResolverSelector...), node.Bytes()...))
+	if err != nil {
+		return common.Address{}, err
+	}
+	return ensAddressResult(result)
+}
+
+// ResolveENSName returns the address to which the given .eth name resolves.
+func (coin *Coin) ResolveENSName(ctx context.Context, name string) (common.Address, error) {
+	name, err := normalizeENSName(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+	node := ensNamehash(name)
+	resolver, err := coin.ensResolver(ctx, node)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if resolver == (common.Address{}) {
+		return common.Address{}, errp.Newf("The name %s is not registered.", name)
+	}
+	result, err := coin.call(ctx, resolver, append(append([]byte{}, ensAddrSelector...), node.Bytes()...))
+	if err != nil {
+		return common.Address{}, err
+	}
+	address, err := ensAddressResult(result)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if address == (common.Address{}) {
+		return common.Address{}, errp.Newf("The name %s does not resolve to an address.", name)
+	}
+	return address, nil
+}
+
+// LookupENSName returns the primary ENS name of the address, or an empty string if it has none.
+// Names which do not resolve back to the address are ignored, as anyone can claim any name in the
+// reverse record of their address.
+func (coin *Coin) LookupENSName(ctx context.Context, address common.Address) (string, error) {
+	node := ensNamehash(strings.ToLower(address.Hex()[2:]) + ".addr.reverse")
+	resolver, err := coin.ensResolver(ctx, node)
+	if err != nil {
+		return "", err
+	}
+	if resolver == (common.Address{}) {
+		return "", nil
+	}
+	result, err := coin.call(ctx, resolver, append(append([]byte{}, ensNameSelector...), node.Bytes()...))
+	if err != nil {
+		return "", err
+	}
+	name, err := decodeABIString(result)
+	if err != nil {
+		return "", err
+	}
+	if !IsENSName(name) {
+		return "", nil
+	}
+	resolved, err := coin.ResolveENSName(ctx, name)
+	if err != nil || resolved != address {
+		return "", nil
+	}
+	return name, nil
+}
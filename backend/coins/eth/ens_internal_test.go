@@ -0,0 +1,48 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// TestENSNamehash checks the test vectors of EIP-137.
+func TestENSNamehash(t *testing.T) {
+	require.Equal(t, common.Hash{}, ensNamehash(""))
+	require.Equal(t,
+		common.HexToHash("0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae"),
+		ensNamehash("eth"))
+	require.Equal(t,
+		common.HexToHash("0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f"),
+		ensNamehash("foo.eth"))
+}
+
+func TestNormalizeENSName(t *testing.T) {
+	name, err := normalizeENSName(" Vitalik.ETH ")
+	require.NoError(t, err)
+	require.Equal(t, "vitalik.eth", name)
+
+	for _, invalid := range []string{
+		"", "eth", ".eth", "foo..eth", "foo.com", "0x3535353535353535353535353535353535353535", "fö.eth",
+	} {
+		_, err := normalizeENSName(invalid)
+		require.Error(t, err, invalid)
+		require.False(t, IsENSName(invalid), invalid)
+	}
+	require.True(t, IsENSName("pay.vitalik.eth"))
+}
@@ -0,0 +1,159 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"unicode"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Function selectors of the ERC20 methods we call.
+var (
+	// erc20BalanceOfSelector is the function selector of `balanceOf(address)`.
+	erc20BalanceOfSelector = []byte{0x70, 0xa0, 0x82, 0x31}
+	// erc20SymbolSelector is the function selector of `symbol()`.
+	erc20SymbolSelector = []byte{0x95, 0xd8, 0x9b, 0x41}
+	// erc20DecimalsSelector is the function selector of `decimals()`.
+	erc20DecimalsSelector = []byte{0x31, 0x3c, 0xe5, 0x67}
+	// erc20NameSelector is the function selector of `name()`.
+	erc20NameSelector = []byte{0x06, 0xfd, 0xde, 0x03}
+)
+
+// ERC20Token is an ERC20 token contract.
+type ERC20Token struct {
+	Contract common.Address
+	Symbol   string
+	Decimals uint
+	// Name is the name of the token, e.g. "Tether USD". It is empty if the token has no name, which
+	// is optional according to the standard.
+	Name string
+}
+
+// decodeABIUint decodes a uint256 return value.
+func decodeABIUint(data []byte) (*big.Int, error) {
+	if len(data) < 32 {
+		return nil, errp.New("Invalid uint256 return value.")
+	}
+	return new(big.Int).SetBytes(data[:32]), nil
+}
+
+// decodeABIString decodes a string return value. Some early tokens return a bytes32 instead, which
+// is supported as well.
+func decodeABIString(data []byte) (string, error) {
+	if len(data) == 32 {
+		return string(bytes.TrimRight(data, "\x00")), nil
+	}
+	offset, err := decodeABIUint(data)
+	if err != nil {
+		return "", err
+	}
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(data))-32 {
+		return "", errp.New("Invalid string return value.")
+	}
+	start := int(offset.Uint64()) + 32
+	length, err := decodeABIUint(data[start-32:])
+	if err != nil {
+		return "", err
+	}
+	if !length.IsUint64() || length.Uint64() > uint64(len(data)-start) {
+		return "", errp.New("Invalid string return value.")
+	}
+	return string(data[start : start+int(length.Uint64())]), nil
+}
+
+// sanitizeTokenText removes invalid UTF-8 and non-printable characters from a symbol or name
+// returned by a token contract, e.g. the padding of a bytes32, and trims the spaces.
+func sanitizeTokenText(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.Map(func(r rune) rune {
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, text)
+	return strings.TrimSpace(text)
+}
+
+// call executes a contract method without creating a transaction.
+func (coin *Coin) call(ctx context.Context, contract common.Address, data []byte) ([]byte, error) {
+	result, err := coin.client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if len(result) == 0 {
+		return nil, errp.Newf("The contract %s did not return a value.", contract.Hex())
+	}
+	return result, nil
+}
+
+// FetchERC20Token queries the symbol, the decimals and the name of the ERC20 token at the given
+// contract address. The symbols and names of early tokens, which are a bytes32, are supported.
+func (coin *Coin) FetchERC20Token(ctx context.Context, contract common.Address) (*ERC20Token, error) {
+	result, err := coin.call(ctx, contract, erc20SymbolSelector)
+	if err != nil {
+		return nil, errp.WithMessage(err, "Could not fetch the token symbol")
+	}
+	symbol, err := decodeABIString(result)
+	if err != nil {
+		return nil, err
+	}
+	symbol = sanitizeTokenText(symbol)
+	if symbol == "" {
+		return nil, errp.New("The token has no symbol.")
+	}
+	result, err = coin.call(ctx, contract, erc20DecimalsSelector)
+	if err != nil {
+		return nil, errp.WithMessage(err, "Could not fetch the token decimals")
+	}
+	decimals, err := decodeABIUint(result)
+	if err != nil {
+		return nil, err
+	}
+	// The decimals are a uint8 according to the standard.
+	if decimals.Cmp(big.NewInt(255)) > 0 {
+		return nil, errp.Newf("Invalid token decimals %s.", decimals)
+	}
+	// The name is optional, so the token is usable without it.
+	var name string
+	if result, err := coin.call(ctx, contract, erc20NameSelector); err == nil {
+		if decoded, err := decodeABIString(result); err == nil {
+			name = sanitizeTokenText(decoded)
+		}
+	}
+	return &ERC20Token{
+		Contract: contract,
+		Symbol:   symbol,
+		Decimals: uint(decimals.Uint64()),
+		Name:     name,
+	}, nil
+}
+
+// erc20Balance returns the token balance of the owner in the smallest unit of the token.
+func (coin *Coin) erc20Balance(ctx context.Context, owner common.Address) (*big.Int, error) {
+	data := append([]byte{}, erc20BalanceOfSelector...)
+	data = append(data, common.LeftPadBytes(owner.Bytes(), 32)...)
+	result, err := coin.call(ctx, coin.erc20Token.Contract, data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeABIUint(result)
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	decoded, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return decoded
+}
+
+func TestDecodeABIString(t *testing.T) {
+	// Return value of symbol() of USDT.
+	symbol, err := decodeABIString(mustDecodeHex(t,
+		"0000000000000000000000000000000000000000000000000000000000000020"+
+			"0000000000000000000000000000000000000000000000000000000000000004"+
+			"5553445400000000000000000000000000000000000000000000000000000000"))
+	require.NoError(t, err)
+	require.Equal(t, "USDT", symbol)
+
+	// Return value of symbol() of MKR, which is a bytes32.
+	symbol, err = decodeABIString(mustDecodeHex(t,
+		"4d4b520000000000000000000000000000000000000000000000000000000000"))
+	require.NoError(t, err)
+	require.Equal(t, "MKR", symbol)
+
+	// The length exceeds the data.
+	_, err = decodeABIString(mustDecodeHex(t,
+		"0000000000000000000000000000000000000000000000000000000000000020"+
+			"0000000000000000000000000000000000000000000000000000000000000040"+
+			"5553445400000000000000000000000000000000000000000000000000000000"))
+	require.Error(t, err)
+
+	// The offset exceeds the data.
+	_, err = decodeABIString(mustDecodeHex(t,
+		"0000000000000000000000000000000000000000000000000000000000000060"+
+			"0000000000000000000000000000000000000000000000000000000000000004"))
+	require.Error(t, err)
+
+	_, err = decodeABIString([]byte{1, 2, 3})
+	require.Error(t, err)
+}
+
+func TestDecodeABIUint(t *testing.T) {
+	decimals, err := decodeABIUint(mustDecodeHex(t,
+		"0000000000000000000000000000000000000000000000000000000000000006"))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(6), decimals)
+
+	_, err = decodeABIUint([]byte{6})
+	require.Error(t, err)
+}
+
+func TestSanitizeTokenText(t *testing.T) {
+	require.Equal(t, "MKR", sanitizeTokenText("MKR\x00\x00"))
+	require.Equal(t, "Maker", sanitizeTokenText(" Maker\n"))
+	require.Equal(t, "AB", sanitizeTokenText("A\xffB\x01"))
+	require.Equal(t, "Ξ Token", sanitizeTokenText("Ξ Token"))
+}
+
+func TestFetchERC20Token(t *testing.T) {
+	results := map[string]string{
+		// symbol(), a bytes32 as returned by MKR.
+		"0x95d89b41": "0x4d4b520000000000000000000000000000000000000000000000000000000000",
+		// decimals()
+		"0x313ce567": "0x0000000000000000000000000000000000000000000000000000000000000012",
+		// name(), a bytes32 as well.
+		"0x06fdde03": "0x4d616b6572000000000000000000000000000000000000000000000000000000",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		require.Equal(t, "eth_call", request.Method)
+		var call struct {
+			Data string `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(request.Params[0], &call))
+		w.Header().Set("Content-Type", "application/json")
+		result, ok := results[call.Data]
+		if !ok {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(request.ID) +
+				`,"error":{"code":3,"message":"execution reverted"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(request.ID) + `,"result":"` + result + `"}`))
+	}))
+	defer server.Close()
+	rpcClient, err := rpc.DialHTTP(server.URL)
+	require.NoError(t, err)
+	coin := NewCoin("eth", MainnetNetwork, "", "")
+	coin.client = ethclient.NewClient(rpcClient)
+
+	contract := common.HexToAddress("0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2")
+	token, err := coin.FetchERC20Token(context.Background(), contract)
+	require.NoError(t, err)
+	require.Equal(t, &ERC20Token{Contract: contract, Symbol: "MKR", Decimals: 18, Name: "Maker"}, token)
+
+	// The name is optional.
+	delete(results, "0x06fdde03")
+	token, err = coin.FetchERC20Token(context.Background(), contract)
+	require.NoError(t, err)
+	require.Equal(t, "", token.Name)
+
+	delete(results, "0x95d89b41")
+	_, err = coin.FetchERC20Token(context.Background(), contract)
+	require.Error(t, err)
+}
@@ -5,7 +5,9 @@ import (
 	"math/big"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
@@ -18,7 +20,9 @@ var callInterval = 5100 * time.Millisecond
 
 // EtherScan is a rate-limited etherscan api client. See https://etherscan.io/apis.
 type EtherScan struct {
-	url         string
+	url string
+	// callLock serializes the calls, as the accounts of a coin and its tokens share the client.
+	callLock    sync.Mutex
 	rateLimiter <-chan time.Time
 }
 
@@ -31,6 +35,8 @@ func NewEtherScan(url string) *EtherScan {
 }
 
 func (etherScan *EtherScan) call(params url.Values, result interface{}) error {
+	etherScan.callLock.Lock()
+	defer etherScan.callLock.Unlock()
 	<-etherScan.rateLimiter
 	defer func() {
 		etherScan.rateLimiter = time.After(callInterval)
@@ -85,6 +91,7 @@ func (t *timestamp) UnmarshalJSON(jsonBytes []byte) error {
 }
 
 type jsonTransaction struct {
+	BlockNumber   jsonBigInt     `json:"blockNumber"`
 	GasUsed       jsonBigInt     `json:"gasUsed"`
 	GasPrice      jsonBigInt     `json:"gasPrice"`
 	Hash          common.Hash    `json:"hash"`
@@ -93,12 +100,20 @@ type jsonTransaction struct {
 	From          common.Address `json:"from"`
 	To            common.Address `json:"to"`
 	Value         jsonBigInt     `json:"value"`
+	// IsError is "1" for failed transactions.
+	IsError string `json:"isError"`
 }
 
 // Transaction implemements coin.Transaction (TODO).
 type Transaction struct {
 	jsonTransaction jsonTransaction
 	txType          coin.TxType
+	// erc20 is true for token transfers, whose amount is in the unit of the token.
+	erc20 bool
+	// internal is true for transfers of Ether by a contract. They have no fee and no
+	// confirmations field, which is computed from the block number.
+	internal         bool
+	numConfirmations int
 }
 
 // UnmarshalJSON implements json.Unmarshaler.
@@ -106,8 +121,12 @@ func (tx *Transaction) UnmarshalJSON(jsonBytes []byte) error {
 	return json.Unmarshal(jsonBytes, &tx.jsonTransaction)
 }
 
-// Fee implements coin.Transaction.
+// Fee implements coin.Transaction. It is nil for token transfers, as the fee is paid in Ether, and
+// for internal transactions, as the fee is paid by the sender of the transaction.
 func (tx *Transaction) Fee() *coin.Amount {
+	if tx.erc20 || tx.internal {
+		return nil
+	}
 	fee := new(big.Int).Mul(tx.jsonTransaction.GasUsed.BigInt(), tx.jsonTransaction.GasPrice.BigInt())
 	amount := coin.NewAmount(fee)
 	return &amount
@@ -126,9 +145,17 @@ func (tx *Transaction) ID() string {
 
 // NumConfirmations implements coin.Transaction.
 func (tx *Transaction) NumConfirmations() int {
+	if tx.internal {
+		return tx.numConfirmations
+	}
 	return int(tx.jsonTransaction.Confirmations.BigInt().Int64())
 }
 
+// Internal implements eth.IndexedTransaction.
+func (tx *Transaction) Internal() bool {
+	return tx.internal
+}
+
 // Type implements coin.Transaction.
 func (tx *Transaction) Type() coin.TxType {
 	return tx.txType
@@ -149,14 +176,19 @@ func (tx *Transaction) Addresses() []string {
 // transaction type (send, receive, send to self) based on the account address.
 func prepareTransactions(
 	transactions []*Transaction, address common.Address) ([]coin.Transaction, error) {
-	seen := map[string]struct{}{}
+	type key struct {
+		id       string
+		internal bool
+	}
+	seen := map[key]struct{}{}
 	castTransactions := []coin.Transaction{}
 	ours := address.Hex()
 	for _, transaction := range transactions {
-		if _, ok := seen[transaction.ID()]; ok {
+		k := key{transaction.ID(), transaction.internal}
+		if _, ok := seen[k]; ok {
 			continue
 		}
-		seen[transaction.ID()] = struct{}{}
+		seen[k] = struct{}{}
 
 		from := transaction.jsonTransaction.From.Hex()
 		to := transaction.jsonTransaction.To.Hex()
@@ -176,17 +208,80 @@ func prepareTransactions(
 	return castTransactions, nil
 }
 
-// Transactions queries EtherScan for transactions for the given account, until endBlock.
+// queryTransactions queries EtherScan for the transactions of the given action, `txlist` or
+// `txlistinternal`, for the given account, until endBlock.
+func (etherScan *EtherScan) queryTransactions(
+	action string, address common.Address, endBlock *big.Int) ([]*Transaction, error) {
+	params := url.Values{}
+	params.Set("module", "account")
+	params.Set("action", action)
+	params.Set("startblock", "0")
+	params.Set("tag", "latest")
+	params.Set("sort", "desc") // desc by block number
+
+	params.Set("endblock", endBlock.Text(10))
+	params.Set("address", address.Hex())
+
+	result := struct {
+		Result []*Transaction
+	}{}
+	if err := etherScan.call(params, &result); err != nil {
+		return nil, err
+	}
+	return result.Result, nil
+}
+
+// mergeInternalTransactions adds the successful internal transactions which transfer Ether to the
+// transactions, sorted descending by block number. The transactions sort before the internal
+// transactions of the same block.
+func mergeInternalTransactions(
+	transactions []*Transaction, internalTransactions []*Transaction, endBlock *big.Int,
+) []*Transaction {
+	merged := append([]*Transaction{}, transactions...)
+	for _, transaction := range internalTransactions {
+		if transaction.jsonTransaction.IsError == "1" || transaction.jsonTransaction.Value.BigInt().Sign() == 0 {
+			continue
+		}
+		transaction.internal = true
+		transaction.numConfirmations = int(new(big.Int).Sub(
+			endBlock, transaction.jsonTransaction.BlockNumber.BigInt()).Int64()) + 1
+		merged = append(merged, transaction)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].jsonTransaction.BlockNumber.BigInt().Cmp(
+			merged[j].jsonTransaction.BlockNumber.BigInt()) > 0
+	})
+	return merged
+}
+
+// Transactions queries EtherScan for transactions for the given account, until endBlock. They
+// include the internal transactions, in which contracts transfer Ether from or to the account.
 func (etherScan *EtherScan) Transactions(address common.Address, endBlock *big.Int) (
 	[]coin.Transaction, error) {
+	transactions, err := etherScan.queryTransactions("txlist", address, endBlock)
+	if err != nil {
+		return nil, err
+	}
+	internalTransactions, err := etherScan.queryTransactions("txlistinternal", address, endBlock)
+	if err != nil {
+		return nil, err
+	}
+	return prepareTransactions(mergeInternalTransactions(transactions, internalTransactions, endBlock), address)
+}
+
+// ERC20Transactions queries EtherScan for the transfers of the token at the given contract address
+// from or to the given account, until endBlock.
+func (etherScan *EtherScan) ERC20Transactions(
+	contract common.Address, address common.Address, endBlock *big.Int) ([]coin.Transaction, error) {
 	params := url.Values{}
 	params.Set("module", "account")
-	params.Set("action", "txlist")
+	params.Set("action", "tokentx")
 	params.Set("startblock", "0")
 	params.Set("tag", "latest")
 	params.Set("sort", "desc") // desc by block number
 
 	params.Set("endblock", endBlock.Text(10))
+	params.Set("contractaddress", contract.Hex())
 	params.Set("address", address.Hex())
 
 	result := struct {
@@ -195,6 +290,185 @@ func (etherScan *EtherScan) Transactions(address common.Address, endBlock *big.I
 	if err := etherScan.call(params, &result); err != nil {
 		return nil, err
 	}
+	for _, transaction := range result.Result {
+		transaction.erc20 = true
+	}
 
 	return prepareTransactions(result.Result, address)
 }
+
+// NFTTransfer is a transfer of an ERC-721 or ERC-1155 token.
+type NFTTransfer struct {
+	Contract common.Address
+	From     common.Address
+	To       common.Address
+	TokenID  *big.Int
+	// Amount is the number of transferred tokens, which is 1 for ERC-721 tokens.
+	Amount *big.Int
+	// Name is the name of the collection.
+	Name    string
+	ERC1155 bool
+}
+
+type jsonNFTTransfer struct {
+	Contract   common.Address `json:"contractAddress"`
+	From       common.Address `json:"from"`
+	To         common.Address `json:"to"`
+	TokenID    jsonBigInt     `json:"tokenID"`
+	TokenValue *jsonBigInt    `json:"tokenValue"`
+	TokenName  string         `json:"tokenName"`
+}
+
+// nftTransfers queries EtherScan for the transfers from or to the given account, until endBlock,
+// with the given action, `tokennfttx` for ERC-721 tokens or `token1155tx` for ERC-1155 tokens.
+func (etherScan *EtherScan) nftTransfers(
+	action string, address common.Address, endBlock *big.Int) ([]*NFTTransfer, error) {
+	params := url.Values{}
+	params.Set("module", "account")
+	params.Set("action", action)
+	params.Set("startblock", "0")
+	params.Set("endblock", endBlock.Text(10))
+	params.Set("sort", "asc")
+	params.Set("address", address.Hex())
+
+	result := struct {
+		Result []*jsonNFTTransfer
+	}{}
+	if err := etherScan.call(params, &result); err != nil {
+		return nil, err
+	}
+	transfers := []*NFTTransfer{}
+	for _, transfer := range result.Result {
+		amount := big.NewInt(1)
+		if transfer.TokenValue != nil {
+			amount = transfer.TokenValue.BigInt()
+		}
+		transfers = append(transfers, &NFTTransfer{
+			Contract: transfer.Contract,
+			From:     transfer.From,
+			To:       transfer.To,
+			TokenID:  transfer.TokenID.BigInt(),
+			Amount:   amount,
+			Name:     transfer.TokenName,
+			ERC1155:  action == "token1155tx",
+		})
+	}
+	return transfers, nil
+}
+
+// NFTTransfers queries EtherScan for the transfers of ERC-721 and ERC-1155 tokens from or to the
+// given account, until endBlock.
+func (etherScan *EtherScan) NFTTransfers(address common.Address, endBlock *big.Int) (
+	[]*NFTTransfer, error) {
+	erc721Transfers, err := etherScan.nftTransfers("tokennfttx", address, endBlock)
+	if err != nil {
+		return nil, err
+	}
+	erc1155Transfers, err := etherScan.nftTransfers("token1155tx", address, endBlock)
+	if err != nil {
+		return nil, err
+	}
+	return append(erc721Transfers, erc1155Transfers...), nil
+}
+
+// PriorityFees are the priority fees per gas in wei estimated for the low, normal and high
+// priority.
+type PriorityFees struct {
+	Low    *big.Int
+	Normal *big.Int
+	High   *big.Int
+}
+
+// parseGwei parses a decimal amount of Gwei, e.g. "1.5", into wei.
+func parseGwei(gwei string) (*big.Int, error) {
+	parsed, ok := new(big.Rat).SetString(gwei)
+	if !ok || parsed.Sign() < 0 {
+		return nil, errp.Newf("Invalid Gwei amount %s.", gwei)
+	}
+	wei := parsed.Mul(parsed, new(big.Rat).SetInt64(1e9))
+	return new(big.Int).Quo(wei.Num(), wei.Denom()), nil
+}
+
+// PriorityFees queries the gas oracle of EtherScan. It estimates the gas prices, of which the
+// suggested base fee is subtracted to get the priority fees.
+func (etherScan *EtherScan) PriorityFees() (*PriorityFees, error) {
+	params := url.Values{}
+	params.Set("module", "gastracker")
+	params.Set("action", "gasoracle")
+	result := struct {
+		Status  string
+		Message string
+		Result  json.RawMessage
+	}{}
+	if err := etherScan.call(params, &result); err != nil {
+		return nil, err
+	}
+	if result.Status != "1" {
+		return nil, errp.Newf("The gas oracle is not available: %s", result.Message)
+	}
+	var oracle struct {
+		SafeGasPrice    string
+		ProposeGasPrice string
+		FastGasPrice    string
+		SuggestBaseFee  string `json:"suggestBaseFee"`
+	}
+	if err := json.Unmarshal(result.Result, &oracle); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	baseFee, err := parseGwei(oracle.SuggestBaseFee)
+	if err != nil {
+		return nil, err
+	}
+	priorityFee := func(gasPrice string) (*big.Int, error) {
+		parsed, err := parseGwei(gasPrice)
+		if err != nil {
+			return nil, err
+		}
+		parsed.Sub(parsed, baseFee)
+		if parsed.Sign() < 0 {
+			parsed.SetInt64(0)
+		}
+		return parsed, nil
+	}
+	fees := &PriorityFees{}
+	if fees.Low, err = priorityFee(oracle.SafeGasPrice); err != nil {
+		return nil, err
+	}
+	if fees.Normal, err = priorityFee(oracle.ProposeGasPrice); err != nil {
+		return nil, err
+	}
+	if fees.High, err = priorityFee(oracle.FastGasPrice); err != nil {
+		return nil, err
+	}
+	return fees, nil
+}
+
+// ContractName returns the name of the verified contract at the given address, or an empty string
+// if the contract is not verified.
+func (etherScan *EtherScan) ContractName(address common.Address) (string, error) {
+	params := url.Values{}
+	params.Set("module", "contract")
+	params.Set("action", "getsourcecode")
+	params.Set("address", address.Hex())
+	result := struct {
+		Status  string
+		Message string
+		Result  json.RawMessage
+	}{}
+	if err := etherScan.call(params, &result); err != nil {
+		return "", err
+	}
+	if result.Status != "1" {
+		return "", errp.Newf("Could not fetch the contract: %s", result.Message)
+	}
+	var contracts []struct {
+		ContractName string
+	}
+	if err := json.Unmarshal(result.Result, &contracts); err != nil {
+		return "", errp.WithStack(err)
+	}
+	if len(contracts) == 0 {
+		return "", nil
+	}
+	return contracts[0].ContractName, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// feeHistoryBlocks is the number of recent blocks whose priority fees are considered.
+const feeHistoryBlocks = 20
+
+// feeHistoryPercentiles are the percentiles of the priority fees paid in a block which correspond
+// to the low, normal and high fee targets.
+var feeHistoryPercentiles = []float64{10, 50, 90}
+
+// gasOracleCacheDuration is the time for which the estimated priority fees are reused, so that
+// the oracles are not queried for every proposed transaction.
+const gasOracleCacheDuration = 30 * time.Second
+
+// maxPriorityFee is the upper sanity bound of the estimated priority fees per gas. Estimates above
+// it are discarded as bogus.
+var maxPriorityFee = new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.GWei))
+
+// priorityFeeTargets are the fee targets of Ethereum transactions, by ascending priority, in the
+// order of the estimated priority fees.
+var priorityFeeTargets = []btc.FeeTargetCode{btc.FeeTargetCodeLow, btc.FeeTargetCodeNormal, btc.FeeTargetCodeHigh}
+
+// priorityFeeIndexer is implemented by the indexers which estimate the priority fees, e.g.
+// Etherscan.
+type priorityFeeIndexer interface {
+	PriorityFees() (*etherscan.PriorityFees, error)
+}
+
+// gasOracle caches the priority fees combined from the estimates of the node and the indexer. It
+// is shared by the Ether coin and the coins of its ERC20 tokens.
+type gasOracle struct {
+	locker.Locker
+	// fees are the priority fees per gas of the priorityFeeTargets.
+	fees    []*big.Int
+	fetched time.Time
+}
+
+// median returns the median of the values, or the mean of the two middle ones if there is an even
+// number of values. values must not be empty.
+func median(values []*big.Int) *big.Int {
+	sorted := append([]*big.Int{}, values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return new(big.Int).Set(sorted[middle])
+	}
+	sum := new(big.Int).Add(sorted[middle-1], sorted[middle])
+	return sum.Div(sum, big.NewInt(2))
+}
+
+// combinePriorityFees combines the estimates of several oracles, each of which contains the
+// priority fees of the priorityFeeTargets, by taking the median per fee target. Estimates outside
+// of [0, maxPriorityFee] are discarded. The results are at least minPriorityFee, if it is not nil,
+// and do not decrease with the priority. An error is returned if no estimate is usable.
+func combinePriorityFees(estimates [][]*big.Int, minPriorityFee *big.Int) ([]*big.Int, error) {
+	fees := make([]*big.Int, len(priorityFeeTargets))
+	for index := range priorityFeeTargets {
+		values := []*big.Int{}
+		for _, estimate := range estimates {
+			value := estimate[index]
+			if value == nil || value.Sign() < 0 || value.Cmp(maxPriorityFee) > 0 {
+				continue
+			}
+			values = append(values, value)
+		}
+		if len(values) == 0 {
+			return nil, errp.New("No usable priority fee estimates.")
+		}
+		fee := median(values)
+		if minPriorityFee != nil && fee.Cmp(minPriorityFee) < 0 {
+			fee.Set(minPriorityFee)
+		}
+		if index > 0 && fee.Cmp(fees[index-1]) < 0 {
+			fee.Set(fees[index-1])
+		}
+		fees[index] = fee
+	}
+	return fees, nil
+}
+
+// feeHistoryPriorityFees estimates the priority fees from the ones paid in the recent blocks,
+// using the median of the feeHistoryPercentiles over the blocks.
+func (coin *Coin) feeHistoryPriorityFees(ctx context.Context) ([]*big.Int, error) {
+	var history struct {
+		Reward [][]*hexutil.Big `json:"reward"`
+	}
+	err := coin.rpcClient.CallContext(ctx, &history, "eth_feeHistory",
+		hexutil.EncodeUint64(feeHistoryBlocks), "latest", feeHistoryPercentiles)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	fees := make([]*big.Int, len(feeHistoryPercentiles))
+	for index := range feeHistoryPercentiles {
+		values := []*big.Int{}
+		for _, rewards := range history.Reward {
+			if index < len(rewards) && rewards[index] != nil {
+				values = append(values, rewards[index].ToInt())
+			}
+		}
+		if len(values) == 0 {
+			return nil, errp.New("The fee history contains no priority fees.")
+		}
+		fees[index] = median(values)
+	}
+	return fees, nil
+}
+
+// priorityFees returns the priority fees per gas of the priorityFeeTargets, combined from the fee
+// history of the node and the gas oracle of the indexer, if it has one.
+func (coin *Coin) priorityFees(ctx context.Context) ([]*big.Int, error) {
+	if coin.network.IgnoresPriorityFee {
+		return []*big.Int{big.NewInt(0), big.NewInt(0), big.NewInt(0)}, nil
+	}
+	defer coin.gasOracle.Lock()()
+	if coin.gasOracle.fees != nil && time.Since(coin.gasOracle.fetched) < gasOracleCacheDuration {
+		return coin.gasOracle.fees, nil
+	}
+	estimates := [][]*big.Int{}
+	feeHistoryFees, feeHistoryErr := coin.feeHistoryPriorityFees(ctx)
+	if feeHistoryErr == nil {
+		estimates = append(estimates, feeHistoryFees)
+	}
+	if indexer, ok := coin.Indexer().(priorityFeeIndexer); ok {
+		if indexerFees, err := indexer.PriorityFees(); err == nil {
+			estimates = append(estimates, []*big.Int{indexerFees.Low, indexerFees.Normal, indexerFees.High})
+		}
+	}
+	fees, err := combinePriorityFees(estimates, coin.network.MinPriorityFee)
+	if err != nil {
+		if feeHistoryErr != nil {
+			return nil, feeHistoryErr
+		}
+		return nil, err
+	}
+	coin.gasOracle.fees = fees
+	coin.gasOracle.fetched = time.Now()
+	return fees, nil
+}
+
+// feeTargetGasTipCap returns the priority fee per gas of the fee target. If the priority fees
+// cannot be estimated, the one suggested by the node is used.
+func (coin *Coin) feeTargetGasTipCap(ctx context.Context, code btc.FeeTargetCode) (*big.Int, error) {
+	fees, err := coin.priorityFees(ctx)
+	if err != nil {
+		return coin.suggestGasTipCap(ctx)
+	}
+	for index, feeTarget := range priorityFeeTargets {
+		if feeTarget == code {
+			return fees[index], nil
+		}
+	}
+	// Other fee targets, e.g. economy, pay the normal priority fee.
+	return fees[1], nil
+}
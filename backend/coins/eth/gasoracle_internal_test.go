@@ -0,0 +1,95 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func gwei(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), big.NewInt(params.GWei))
+}
+
+func TestCombinePriorityFees(t *testing.T) {
+	fees, err := combinePriorityFees([][]*big.Int{
+		{gwei(1), gwei(2), gwei(5)},
+		{gwei(3), gwei(2), gwei(3)},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []*big.Int{gwei(2), gwei(2), gwei(4)}, fees)
+
+	// Bogus estimates are discarded, the minimum is applied and the fees do not decrease.
+	fees, err = combinePriorityFees([][]*big.Int{
+		{gwei(1), gwei(40), gwei(20)},
+		{gwei(2000), big.NewInt(-1), gwei(2000)},
+	}, gwei(30))
+	require.NoError(t, err)
+	require.Equal(t, []*big.Int{gwei(30), gwei(40), gwei(40)}, fees)
+
+	_, err = combinePriorityFees([][]*big.Int{{gwei(2000), gwei(1), gwei(1)}}, nil)
+	require.Error(t, err)
+	_, err = combinePriorityFees(nil, nil)
+	require.Error(t, err)
+}
+
+func TestPriorityFees(t *testing.T) {
+	var params []json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		require.Equal(t, "eth_feeHistory", request.Method)
+		params = request.Params
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(request.ID) + `,"result":{` +
+			`"oldestBlock":"0x1","reward":[["0x1","0x2","0x9"],["0x3","0x4","0x5"],["0x2","0x6","0x7"]]}}`))
+	}))
+	defer server.Close()
+	rpcClient, err := rpc.DialHTTP(server.URL)
+	require.NoError(t, err)
+	coin := NewCoin("eth", MainnetNetwork, "", "")
+	coin.rpcClient = rpcClient
+
+	fees, err := coin.priorityFees(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []*big.Int{big.NewInt(2), big.NewInt(4), big.NewInt(7)}, fees)
+	require.Len(t, params, 3)
+	require.Equal(t, `"0x14"`, string(params[0]))
+	require.Equal(t, `"latest"`, string(params[1]))
+	require.Equal(t, `[10,50,90]`, string(params[2]))
+
+	gasTipCap, err := coin.feeTargetGasTipCap(context.Background(), btc.FeeTargetCodeHigh)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(7), gasTipCap)
+	// The estimates are cached.
+	params = nil
+	gasTipCap, err = coin.feeTargetGasTipCap(context.Background(), btc.FeeTargetCodeEconomy)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(4), gasTipCap)
+	require.Nil(t, params)
+}
@@ -0,0 +1,47 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"math/big"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Indexer provides the transaction history of addresses, which is not available from the nodes.
+// It is implemented by the etherscan and blockscout clients, and by logsIndexer.
+type Indexer interface {
+	// Transactions returns the transactions from or to the address, until endBlock, including the
+	// internal transactions, in which contracts transfer Ether from or to the address. The
+	// transactions implement IndexedTransaction.
+	Transactions(address common.Address, endBlock *big.Int) ([]coin.Transaction, error)
+	// ERC20Transactions returns the transfers of the token at the given contract address from or
+	// to the address, until endBlock.
+	ERC20Transactions(contract common.Address, address common.Address, endBlock *big.Int) (
+		[]coin.Transaction, error)
+	// NFTTransfers returns the transfers of ERC-721 and ERC-1155 tokens from or to the address,
+	// until endBlock.
+	NFTTransfers(address common.Address, endBlock *big.Int) ([]*etherscan.NFTTransfer, error)
+}
+
+// IndexedTransaction is a transaction returned by an Indexer.
+type IndexedTransaction interface {
+	coin.Transaction
+	// Internal returns true if the transaction is an internal transaction, i.e. a transfer of Ether
+	// by a contract within the transaction with the same ID.
+	Internal() bool
+}
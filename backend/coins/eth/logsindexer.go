@@ -0,0 +1,308 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// NodeIndexer is the indexer setting with which the ERC20 token transfers are reconstructed from
+// the Transfer events logged by the node instead of being fetched from Etherscan. The Ether
+// transactions and the NFT transfers are still fetched from Etherscan, as transfers of Ether are
+// not logged.
+const NodeIndexer = "node"
+
+// erc20TransferTopic is the topic of the `Transfer(address,address,uint256)` event.
+var erc20TransferTopic = common.HexToHash(
+	"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// logsBlockRange is the number of blocks per log query, as nodes limit the size of the results.
+const logsBlockRange = 1000000
+
+// logsReorgDepth is the number of recent blocks whose logs are fetched again on each update, in
+// case these blocks were replaced by a reorganization of the chain.
+const logsReorgDepth = 64
+
+// filterLogs fetches the logs matching the query from fromBlock until toBlock, in ranges of
+// logsBlockRange blocks.
+func (coin *Coin) filterLogs(
+	ctx context.Context, query ethereum.FilterQuery, fromBlock uint64, toBlock uint64,
+) ([]types.Log, error) {
+	logs := []types.Log{}
+	for from := fromBlock; from <= toBlock; from += logsBlockRange {
+		to := from + logsBlockRange - 1
+		if to > toBlock {
+			to = toBlock
+		}
+		query.FromBlock = new(big.Int).SetUint64(from)
+		query.ToBlock = new(big.Int).SetUint64(to)
+		rangeLogs, err := coin.client.FilterLogs(ctx, query)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		logs = append(logs, rangeLogs...)
+	}
+	return logs, nil
+}
+
+// logTransaction is an ERC20 token transfer reconstructed from a Transfer event. It implements
+// IndexedTransaction.
+type logTransaction struct {
+	hash             common.Hash
+	timestamp        time.Time
+	numConfirmations int
+	txType           coin.TxType
+	amount           *big.Int
+	to               common.Address
+}
+
+// Fee implements coin.Transaction. It is nil, as the fee is paid in Ether.
+func (tx *logTransaction) Fee() *coin.Amount {
+	return nil
+}
+
+// Timestamp implements coin.Transaction.
+func (tx *logTransaction) Timestamp() *time.Time {
+	t := tx.timestamp
+	return &t
+}
+
+// ID implements coin.Transaction.
+func (tx *logTransaction) ID() string {
+	return tx.hash.Hex()
+}
+
+// NumConfirmations implements coin.Transaction.
+func (tx *logTransaction) NumConfirmations() int {
+	return tx.numConfirmations
+}
+
+// Type implements coin.Transaction.
+func (tx *logTransaction) Type() coin.TxType {
+	return tx.txType
+}
+
+// Amount implements coin.Transaction.
+func (tx *logTransaction) Amount() coin.Amount {
+	return coin.NewAmount(tx.amount)
+}
+
+// Addresses implements coin.Transaction.
+func (tx *logTransaction) Addresses() []string {
+	return []string{tx.to.Hex()}
+}
+
+// Internal implements IndexedTransaction.
+func (tx *logTransaction) Internal() bool {
+	return false
+}
+
+type logsScanKey struct {
+	contract common.Address
+	address  common.Address
+}
+
+// logsScan holds the Transfer events of a token from or to an address, sorted by their position in
+// the chain.
+type logsScan struct {
+	// endBlock is the last block whose logs were fetched.
+	endBlock uint64
+	logs     []types.Log
+}
+
+// logsIndexer is an Indexer which reconstructs the ERC20 token transfers from the logs of the node
+// of the coin, and gets the Ether transactions and NFT transfers from another indexer. The logs are
+// fetched incrementally.
+type logsIndexer struct {
+	Indexer
+	coin *Coin
+
+	lock       locker.Locker
+	scans      map[logsScanKey]*logsScan
+	blockTimes map[uint64]time.Time
+}
+
+func newLogsIndexer(coin *Coin, indexer Indexer) *logsIndexer {
+	return &logsIndexer{
+		Indexer:    indexer,
+		coin:       coin,
+		scans:      map[logsScanKey]*logsScan{},
+		blockTimes: map[uint64]time.Time{},
+	}
+}
+
+// PriorityFees implements priorityFeeIndexer if the wrapped indexer does.
+func (indexer *logsIndexer) PriorityFees() (*etherscan.PriorityFees, error) {
+	if priorityFeeIndexer, ok := indexer.Indexer.(priorityFeeIndexer); ok {
+		return priorityFeeIndexer.PriorityFees()
+	}
+	return nil, errp.New("The indexer does not estimate priority fees.")
+}
+
+// ContractName implements contractNameIndexer if the wrapped indexer does.
+func (indexer *logsIndexer) ContractName(address common.Address) (string, error) {
+	if contractNameIndexer, ok := indexer.Indexer.(contractNameIndexer); ok {
+		return contractNameIndexer.ContractName(address)
+	}
+	return "", nil
+}
+
+// update fetches the Transfer events of the token from or to the address which were logged since
+// the last update, and returns all of them until endBlock.
+func (indexer *logsIndexer) update(
+	ctx context.Context, contract common.Address, address common.Address, endBlock uint64,
+) ([]types.Log, error) {
+	key := logsScanKey{contract: contract, address: address}
+	scan, ok := indexer.scans[key]
+	if !ok {
+		scan = &logsScan{}
+		indexer.scans[key] = scan
+	}
+	fromBlock := uint64(0)
+	if ok && scan.endBlock >= logsReorgDepth {
+		fromBlock = scan.endBlock - logsReorgDepth + 1
+	}
+	if fromBlock > endBlock {
+		return scan.logs, nil
+	}
+	topic := common.BytesToHash(address.Bytes())
+	sent, err := indexer.coin.filterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{contract},
+		Topics:    [][]common.Hash{{erc20TransferTopic}, {topic}},
+	}, fromBlock, endBlock)
+	if err != nil {
+		return nil, err
+	}
+	received, err := indexer.coin.filterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{contract},
+		Topics:    [][]common.Hash{{erc20TransferTopic}, nil, {topic}},
+	}, fromBlock, endBlock)
+	if err != nil {
+		return nil, err
+	}
+	logs := []types.Log{}
+	for _, log := range scan.logs {
+		if log.BlockNumber < fromBlock {
+			logs = append(logs, log)
+		}
+	}
+	// Transfers to self are in both results.
+	type logKey struct {
+		hash  common.Hash
+		index uint
+	}
+	seen := map[logKey]struct{}{}
+	for _, log := range append(sent, received...) {
+		if log.Removed || len(log.Topics) != 3 {
+			continue
+		}
+		if _, ok := seen[logKey{log.TxHash, log.Index}]; ok {
+			continue
+		}
+		seen[logKey{log.TxHash, log.Index}] = struct{}{}
+		logs = append(logs, log)
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+	scan.logs = logs
+	scan.endBlock = endBlock
+	return logs, nil
+}
+
+// blockTime returns the time of the block with the given number.
+func (indexer *logsIndexer) blockTime(ctx context.Context, number uint64) (time.Time, error) {
+	if blockTime, ok := indexer.blockTimes[number]; ok {
+		return blockTime, nil
+	}
+	var header *struct {
+		Timestamp hexutil.Uint64 `json:"timestamp"`
+	}
+	err := indexer.coin.rpcClient.CallContext(
+		ctx, &header, "eth_getBlockByNumber", hexutil.EncodeUint64(number), false)
+	if err != nil {
+		return time.Time{}, errp.WithStack(err)
+	}
+	if header == nil {
+		return time.Time{}, errp.Newf("Could not fetch block %d.", number)
+	}
+	blockTime := time.Unix(int64(header.Timestamp), 0)
+	indexer.blockTimes[number] = blockTime
+	return blockTime, nil
+}
+
+// ERC20Transactions implements Indexer. A transaction can contain several transfers, of which
+// only the first one is listed, as with Etherscan.
+func (indexer *logsIndexer) ERC20Transactions(
+	contract common.Address, address common.Address, endBlock *big.Int) ([]coin.Transaction, error) {
+	defer indexer.lock.Lock()()
+	ctx := context.TODO()
+	logs, err := indexer.update(ctx, contract, address, endBlock.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	transactions := []coin.Transaction{}
+	seen := map[common.Hash]struct{}{}
+	for index := len(logs) - 1; index >= 0; index-- {
+		log := logs[index]
+		if log.BlockNumber > endBlock.Uint64() {
+			continue
+		}
+		if _, ok := seen[log.TxHash]; ok {
+			// Keep the first transfer of the transaction, which is visited last.
+			transactions = transactions[:len(transactions)-1]
+		}
+		seen[log.TxHash] = struct{}{}
+		from := common.BytesToAddress(log.Topics[1].Bytes())
+		to := common.BytesToAddress(log.Topics[2].Bytes())
+		var transactionType coin.TxType
+		switch {
+		case from == address && to == address:
+			transactionType = coin.TxTypeSendSelf
+		case from == address:
+			transactionType = coin.TxTypeSend
+		default:
+			transactionType = coin.TxTypeReceive
+		}
+		timestamp, err := indexer.blockTime(ctx, log.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, &logTransaction{
+			hash:             log.TxHash,
+			timestamp:        timestamp,
+			numConfirmations: int(endBlock.Uint64()-log.BlockNumber) + 1,
+			txType:           transactionType,
+			amount:           new(big.Int).SetBytes(log.Data),
+			to:               to,
+		})
+	}
+	return transactions, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	coinpkg "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogsIndexer(t *testing.T) {
+	contract := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	address := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	other := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	topic := func(address common.Address) string {
+		return common.BytesToHash(address.Bytes()).Hex()
+	}
+	transferLog := func(from, to common.Address, block uint64, txHash string, index uint64, amount int64) string {
+		log, err := json.Marshal(map[string]interface{}{
+			"address":          contract,
+			"topics":           []string{erc20TransferTopic.Hex(), topic(from), topic(to)},
+			"data":             common.BytesToHash(big.NewInt(amount).Bytes()).Hex(),
+			"blockNumber":      hexutil.EncodeUint64(block),
+			"transactionHash":  txHash,
+			"transactionIndex": "0x0",
+			"blockHash":        common.Hash{}.Hex(),
+			"logIndex":         hexutil.EncodeUint64(index),
+			"removed":          false,
+		})
+		require.NoError(t, err)
+		return string(log)
+	}
+	const (
+		hash1 = "0x0000000000000000000000000000000000000000000000000000000000000001"
+		hash2 = "0x0000000000000000000000000000000000000000000000000000000000000002"
+	)
+	var logQueries []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		var result string
+		switch request.Method {
+		case "eth_getLogs":
+			var query map[string]interface{}
+			require.NoError(t, json.Unmarshal(request.Params[0], &query))
+			logQueries = append(logQueries, query)
+			topics := query["topics"].([]interface{})
+			if topics[1] != nil {
+				// Sent, including a transfer to self.
+				result = "[" + transferLog(address, other, 10, hash1, 0, 100) + "," +
+					transferLog(address, address, 12, hash2, 3, 5) + "]"
+			} else {
+				result = "[" + transferLog(address, address, 12, hash2, 3, 5) + "," +
+					transferLog(other, address, 12, hash2, 4, 7) + "]"
+			}
+		case "eth_getBlockByNumber":
+			result = `{"timestamp":"0x5f5e100"}`
+		default:
+			t.Fatalf("unexpected method %s", request.Method)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(request.ID) + `,"result":` + result + `}`))
+	}))
+	defer server.Close()
+	rpcClient, err := rpc.DialHTTP(server.URL)
+	require.NoError(t, err)
+	coin := NewCoin("eth", MainnetNetwork, "", NodeIndexer)
+	coin.rpcClient = rpcClient
+	coin.client = ethclient.NewClient(rpcClient)
+	indexer := newLogsIndexer(coin, nil)
+
+	transactions, err := indexer.ERC20Transactions(contract, address, big.NewInt(20))
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	require.Len(t, logQueries, 2)
+	require.Equal(t, "0x0", logQueries[0]["fromBlock"])
+	require.Equal(t, "0x14", logQueries[0]["toBlock"])
+
+	// Only the first transfer of the second transaction is listed.
+	require.Equal(t, common.HexToHash(hash2).Hex(), transactions[0].ID())
+	require.Equal(t, coinpkg.TxTypeSendSelf, transactions[0].Type())
+	require.Equal(t, "5", transactions[0].Amount().BigInt().String())
+	require.Equal(t, 9, transactions[0].NumConfirmations())
+	require.Equal(t, int64(100000000), transactions[0].Timestamp().Unix())
+
+	require.Equal(t, common.HexToHash(hash1).Hex(), transactions[1].ID())
+	require.Equal(t, coinpkg.TxTypeSend, transactions[1].Type())
+	require.Equal(t, "100", transactions[1].Amount().BigInt().String())
+	require.Equal(t, []string{other.Hex()}, transactions[1].Addresses())
+	require.Nil(t, transactions[1].Fee())
+	require.False(t, transactions[1].(IndexedTransaction).Internal())
+
+	// The next update only fetches the recent blocks again.
+	logQueries = nil
+	transactions, err = indexer.ERC20Transactions(contract, address, big.NewInt(100))
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	require.Len(t, logQueries, 2)
+	require.Equal(t, "0x0", logQueries[0]["fromBlock"])
+	logQueries = nil
+	_, err = indexer.ERC20Transactions(contract, address, big.NewInt(200))
+	require.NoError(t, err)
+	require.Equal(t, "0x25", logQueries[0]["fromBlock"])
+	require.Equal(t, "0xc8", logQueries[0]["toBlock"])
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	coinpkg "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/jsonp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SignedMessageHash returns the EIP-191 (version 0x45, personal_sign) hash of the message.
+func (coin *Coin) SignedMessageHash(message []byte) []byte {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))
+	return crypto.Keccak256([]byte(prefix), message)
+}
+
+// MessageMeta returns the JSON encoded message, which is passed to the device when signing so that
+// it can be shown to the user. Messages which are not valid UTF-8 are hex encoded.
+func MessageMeta(message []byte) string {
+	text := string(message)
+	if !utf8.Valid(message) {
+		text = hexutil.Encode(message)
+	}
+	return string(jsonp.MustMarshal(map[string]interface{}{
+		"type":    "personal_sign",
+		"message": text,
+	}))
+}
+
+// VerifyMessage checks that the hex encoded signature [R || S || V] of the message was created
+// with the key of the given address, as returned by personal_sign. V can be the recid or 27 +
+// recid. Returns an error if the address or the signature are malformed.
+func (coin *Coin) VerifyMessage(address string, message []byte, signature string) (bool, error) {
+	if !common.IsHexAddress(address) {
+		return false, errp.WithStack(coinpkg.ErrInvalidAddress)
+	}
+	sig, err := hexutil.Decode(strings.TrimSpace(signature))
+	if err != nil || len(sig) != 65 {
+		return false, errp.New("The signature is malformed.")
+	}
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if sig[64] > 1 {
+		return false, errp.Newf("The signature has an invalid recovery id %d.", sig[64])
+	}
+	publicKey, err := crypto.SigToPub(coin.SignedMessageHash(message), sig)
+	if err != nil {
+		return false, nil
+	}
+	return crypto.PubkeyToAddress(*publicKey) == common.HexToAddress(address), nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyMessage(t *testing.T) {
+	coin := NewCoin("eth", MainnetNetwork, "", "")
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	message := []byte("Hello BitBox")
+	sig, err := crypto.Sign(coin.SignedMessageHash(message), privateKey)
+	require.NoError(t, err)
+
+	// V as recid.
+	valid, err := coin.VerifyMessage(address, message, hexutil.Encode(sig))
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	// V as 27 + recid, as returned by personal_sign.
+	sig[64] += 27
+	valid, err = coin.VerifyMessage(address, message, hexutil.Encode(sig))
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	valid, err = coin.VerifyMessage(address, []byte("Other message"), hexutil.Encode(sig))
+	require.NoError(t, err)
+	require.False(t, valid)
+
+	_, err = coin.VerifyMessage("0x1234", message, hexutil.Encode(sig))
+	require.Error(t, err)
+	_, err = coin.VerifyMessage(address, message, hexutil.Encode(sig[:64]))
+	require.Error(t, err)
+}
+
+func TestMessageMeta(t *testing.T) {
+	require.JSONEq(t,
+		`{"type": "personal_sign", "message": "Hello BitBox"}`,
+		MessageMeta([]byte("Hello BitBox")))
+	require.JSONEq(t,
+		`{"type": "personal_sign", "message": "0xff00"}`,
+		MessageMeta([]byte{0xff, 0x00}))
+}
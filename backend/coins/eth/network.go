@@ -0,0 +1,148 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Network holds the parameters of an EVM chain, i.e. Ethereum itself, one of its layer-2 rollups
+// or a sidechain with its own native coin.
+type Network struct {
+	// ChainConfig holds the EIP-155 chain ID with which transactions are signed.
+	ChainConfig *params.ChainConfig
+	// Unit is the unit of the native coin.
+	Unit string
+	// DefaultRPCURL is the URL of the node used if none is configured.
+	DefaultRPCURL string
+	// EtherScanURL is the URL of the Etherscan compatible API from which the transactions are
+	// fetched.
+	EtherScanURL string
+	// BlockExplorerTxPrefix is the default URL prefix of the block explorer.
+	BlockExplorerTxPrefix string
+	// CoinGeckoPlatform is the CoinGecko id of the network, from which the rates of ERC20 tokens
+	// are fetched by contract address, or empty for a testnet.
+	CoinGeckoPlatform string
+	// IgnoresPriorityFee is true if the sequencer orders the transactions on a first come, first
+	// served basis, so that no priority fee (tip) is paid. The base fee is charged as on Ethereum.
+	IgnoresPriorityFee bool
+	// MinPriorityFee is the lowest priority fee per gas accepted by the validators, or nil if there
+	// is none.
+	MinPriorityFee *big.Int
+	// FaucetURL is the URL of a faucet which hands out the coin of a testnet without requiring an
+	// account, or empty for a mainnet.
+	FaucetURL string
+}
+
+// genesisChainConfig returns the chain config of a testnet, rollup or sidechain, which activated
+// all Ethereum forks at its genesis, including EIP-1559.
+func genesisChainConfig(chainID int64) *params.ChainConfig {
+	return &params.ChainConfig{
+		ChainID:             big.NewInt(chainID),
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(0),
+	}
+}
+
+var (
+	// MainnetNetwork is Ethereum.
+	MainnetNetwork = &Network{
+		ChainConfig:           params.MainnetChainConfig,
+		Unit:                  "ETH",
+		DefaultRPCURL:         "https://mainnet.infura.io",
+		EtherScanURL:          "https://api.etherscan.io/api",
+		BlockExplorerTxPrefix: "https://etherscan.io/tx/",
+		CoinGeckoPlatform:     "ethereum",
+	}
+
+	// SepoliaNetwork is the Sepolia testnet of Ethereum, on which applications are tested.
+	SepoliaNetwork = &Network{
+		ChainConfig:           genesisChainConfig(11155111),
+		Unit:                  "SEPETH",
+		DefaultRPCURL:         "https://ethereum-sepolia-rpc.publicnode.com",
+		EtherScanURL:          "https://api-sepolia.etherscan.io/api",
+		BlockExplorerTxPrefix: "https://sepolia.etherscan.io/tx/",
+		FaucetURL:             "https://sepolia-faucet.pk910.de",
+	}
+
+	// HoleskyNetwork is the Holesky testnet of Ethereum, on which staking and the infrastructure
+	// are tested.
+	HoleskyNetwork = &Network{
+		ChainConfig:           genesisChainConfig(17000),
+		Unit:                  "HOLETH",
+		DefaultRPCURL:         "https://ethereum-holesky-rpc.publicnode.com",
+		EtherScanURL:          "https://api-holesky.etherscan.io/api",
+		BlockExplorerTxPrefix: "https://holesky.etherscan.io/tx/",
+		FaucetURL:             "https://holesky-faucet.pk910.de",
+	}
+
+	// ArbitrumNetwork is the Arbitrum One rollup.
+	ArbitrumNetwork = &Network{
+		ChainConfig:           genesisChainConfig(42161),
+		Unit:                  "ETH",
+		DefaultRPCURL:         "https://arb1.arbitrum.io/rpc",
+		EtherScanURL:          "https://api.arbiscan.io/api",
+		BlockExplorerTxPrefix: "https://arbiscan.io/tx/",
+		CoinGeckoPlatform:     "arbitrum-one",
+		IgnoresPriorityFee:    true,
+	}
+
+	// OptimismNetwork is the OP Mainnet rollup.
+	OptimismNetwork = &Network{
+		ChainConfig:           genesisChainConfig(10),
+		Unit:                  "ETH",
+		DefaultRPCURL:         "https://mainnet.optimism.io",
+		EtherScanURL:          "https://api-optimistic.etherscan.io/api",
+		BlockExplorerTxPrefix: "https://optimistic.etherscan.io/tx/",
+		CoinGeckoPlatform:     "optimistic-ethereum",
+	}
+
+	// BaseNetwork is the Base rollup.
+	BaseNetwork = &Network{
+		ChainConfig:           genesisChainConfig(8453),
+		Unit:                  "ETH",
+		DefaultRPCURL:         "https://mainnet.base.org",
+		EtherScanURL:          "https://api.basescan.org/api",
+		BlockExplorerTxPrefix: "https://basescan.org/tx/",
+		CoinGeckoPlatform:     "base",
+	}
+
+	// PolygonNetwork is the Polygon PoS chain, whose fees are paid in POL.
+	PolygonNetwork = &Network{
+		ChainConfig:           genesisChainConfig(137),
+		Unit:                  "POL",
+		DefaultRPCURL:         "https://polygon-rpc.com",
+		EtherScanURL:          "https://api.polygonscan.com/api",
+		BlockExplorerTxPrefix: "https://polygonscan.com/tx/",
+		CoinGeckoPlatform:     "polygon-pos",
+		MinPriorityFee:        big.NewInt(30 * params.GWei),
+	}
+
+	// GnosisNetwork is the Gnosis Chain, whose fees are paid in xDAI.
+	GnosisNetwork = &Network{
+		ChainConfig:           genesisChainConfig(100),
+		Unit:                  "XDAI",
+		DefaultRPCURL:         "https://rpc.gnosischain.com",
+		EtherScanURL:          "https://api.gnosisscan.io/api",
+		BlockExplorerTxPrefix: "https://gnosisscan.io/tx/",
+		CoinGeckoPlatform:     "xdai",
+	}
+)
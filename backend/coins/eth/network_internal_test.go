@@ -0,0 +1,53 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayer2Networks(t *testing.T) {
+	for _, network := range []*Network{ArbitrumNetwork, OptimismNetwork, BaseNetwork} {
+		coin := NewCoin("l2", network, "", "")
+		require.Equal(t, "ETH", coin.Unit())
+		require.Equal(t, network.ChainConfig.ChainID, coin.Net().ChainID)
+	}
+	require.Equal(t, big.NewInt(42161), ArbitrumNetwork.ChainConfig.ChainID)
+	require.Equal(t, big.NewInt(10), OptimismNetwork.ChainConfig.ChainID)
+	require.Equal(t, big.NewInt(8453), BaseNetwork.ChainConfig.ChainID)
+}
+
+func TestSuggestGasTipCapIgnoresPriorityFee(t *testing.T) {
+	// The coin is not initialized, so the node must not be called.
+	tip, err := NewCoin("arbeth", ArbitrumNetwork, "", "").suggestGasTipCap(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, tip.Sign())
+}
+
+func TestCheckGasTipCap(t *testing.T) {
+	polygon := NewCoin("pol", PolygonNetwork, "", "")
+	require.Equal(t, "POL", polygon.Unit())
+	require.Equal(t, big.NewInt(137), polygon.Net().ChainID)
+	require.Equal(t, coin.ErrFeeTooLow, errp.Cause(polygon.checkGasTipCap(big.NewInt(params.GWei))))
+	require.NoError(t, polygon.checkGasTipCap(big.NewInt(30*params.GWei)))
+	require.NoError(t, NewCoin("xdai", GnosisNetwork, "", "").checkGasTipCap(big.NewInt(0)))
+}
@@ -0,0 +1,309 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// NFT standards.
+const (
+	NFTStandardERC721  = "erc721"
+	NFTStandardERC1155 = "erc1155"
+)
+
+// Function selectors of the NFT methods we call.
+var (
+	// erc721TokenURISelector is the function selector of `tokenURI(uint256)`.
+	erc721TokenURISelector = []byte{0xc8, 0x7b, 0x56, 0xdd}
+	// erc1155URISelector is the function selector of `uri(uint256)`.
+	erc1155URISelector = []byte{0x0e, 0x89, 0x34, 0x1c}
+	// erc721SafeTransferFromSelector is the function selector of
+	// `safeTransferFrom(address,address,uint256)`.
+	erc721SafeTransferFromSelector = []byte{0x42, 0x84, 0x2e, 0x0e}
+	// erc1155SafeTransferFromSelector is the function selector of
+	// `safeTransferFrom(address,address,uint256,uint256,bytes)`.
+	erc1155SafeTransferFromSelector = []byte{0xf2, 0x42, 0x43, 0x2a}
+)
+
+// ipfsGateway is the HTTP gateway through which ipfs:// URIs are fetched.
+const ipfsGateway = "https://ipfs.io/ipfs/"
+
+const (
+	nftMetadataTimeout = 10 * time.Second
+	// nftMetadataMaxSize is the maximum size of the metadata JSON, which is a few hundred bytes
+	// usually.
+	nftMetadataMaxSize = 1 << 20
+)
+
+// NFT is an ERC-721 or ERC-1155 token owned by the account.
+type NFT struct {
+	Contract common.Address
+	TokenID  *big.Int
+	// Standard is NFTStandardERC721 or NFTStandardERC1155.
+	Standard string
+	// Amount is the number of owned tokens, which is 1 for ERC-721 tokens.
+	Amount *big.Int
+	// CollectionName is the name of the contract.
+	CollectionName string
+}
+
+// NFTMetadata is the metadata of an NFT according to the ERC-721 metadata JSON schema, which is
+// used by ERC-1155 as well.
+type NFTMetadata struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Image is the HTTP(S) or data URL of the image.
+	Image string `json:"image"`
+}
+
+// nftHoldings returns the NFTs owned by the address according to its transfers of them, sorted by
+// contract and token ID.
+func nftHoldings(transfers []*etherscan.NFTTransfer, address common.Address) []*NFT {
+	type key struct {
+		contract common.Address
+		tokenID  string
+		erc1155  bool
+	}
+	nfts := map[key]*NFT{}
+	for _, transfer := range transfers {
+		k := key{transfer.Contract, transfer.TokenID.String(), transfer.ERC1155}
+		nft, ok := nfts[k]
+		if !ok {
+			standard := NFTStandardERC721
+			if transfer.ERC1155 {
+				standard = NFTStandardERC1155
+			}
+			nft = &NFT{
+				Contract:       transfer.Contract,
+				TokenID:        transfer.TokenID,
+				Standard:       standard,
+				Amount:         big.NewInt(0),
+				CollectionName: transfer.Name,
+			}
+			nfts[k] = nft
+		}
+		if transfer.To == address {
+			nft.Amount.Add(nft.Amount, transfer.Amount)
+		}
+		if transfer.From == address {
+			nft.Amount.Sub(nft.Amount, transfer.Amount)
+		}
+	}
+	holdings := []*NFT{}
+	for _, nft := range nfts {
+		if nft.Amount.Sign() > 0 {
+			holdings = append(holdings, nft)
+		}
+	}
+	sort.Slice(holdings, func(i, j int) bool {
+		if cmp := bytes.Compare(holdings[i].Contract.Bytes(), holdings[j].Contract.Bytes()); cmp != 0 {
+			return cmp < 0
+		}
+		return holdings[i].TokenID.Cmp(holdings[j].TokenID) < 0
+	})
+	return holdings
+}
+
+// NFTs returns the ERC-721 and ERC-1155 tokens owned by the account, as indexed by the indexer of
+// the coin.
+func (account *Account) NFTs() ([]*NFT, error) {
+	if account.coin.ERC20Token() != nil {
+		return nil, errp.New("NFTs are held by Ethereum accounts.")
+	}
+	header, err := account.coin.latestHeader(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	transfers, err := account.coin.Indexer().NFTTransfers(account.address.Address, header.Number.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	return nftHoldings(transfers, account.address.Address), nil
+}
+
+// resolveNFTURI returns the HTTP(S) or data URL under which the content of the URI of an NFT can
+// be fetched. ERC-1155 URIs contain the token ID as `{id}`.
+func resolveNFTURI(uri string, tokenID *big.Int) (string, error) {
+	uri = strings.TrimSpace(uri)
+	uri = strings.Replace(uri, "{id}", fmt.Sprintf("%064x", tokenID), -1)
+	switch {
+	case strings.HasPrefix(uri, "ipfs://"):
+		path := strings.TrimPrefix(strings.TrimPrefix(uri, "ipfs://"), "ipfs/")
+		return ipfsGateway + path, nil
+	case strings.HasPrefix(uri, "https://"), strings.HasPrefix(uri, "http://"), strings.HasPrefix(uri, "data:"):
+		return uri, nil
+	default:
+		return "", errp.Newf("Unsupported URI %s.", uri)
+	}
+}
+
+// decodeDataURL returns the content of a data URL, e.g. `data:application/json;base64,eyJ9`.
+func decodeDataURL(dataURL string) ([]byte, error) {
+	comma := strings.Index(dataURL, ",")
+	if comma < 0 {
+		return nil, errp.New("Invalid data URL.")
+	}
+	header, data := dataURL[len("data:"):comma], dataURL[comma+1:]
+	if strings.HasSuffix(header, ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		return decoded, nil
+	}
+	decoded, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return []byte(decoded), nil
+}
+
+// fetchNFTContent fetches the content at the resolved URI.
+func fetchNFTContent(ctx context.Context, uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		return decodeDataURL(uri)
+	}
+	request, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	response, err := http.DefaultClient.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode != http.StatusOK {
+		return nil, errp.Newf("%s returned status %d", uri, response.StatusCode)
+	}
+	content, err := ioutil.ReadAll(&io.LimitedReader{R: response.Body, N: nftMetadataMaxSize})
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return content, nil
+}
+
+// NFTMetadata fetches the metadata of the token from the URI stored in its contract.
+func (account *Account) NFTMetadata(contract common.Address, tokenID *big.Int, standard string) (
+	*NFTMetadata, error) {
+	selector := erc721TokenURISelector
+	if standard == NFTStandardERC1155 {
+		selector = erc1155URISelector
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), nftMetadataTimeout)
+	defer cancel()
+	data := append(append([]byte{}, selector...), common.LeftPadBytes(tokenID.Bytes(), 32)...)
+	result, err := account.coin.call(ctx, contract, data)
+	if err != nil {
+		return nil, errp.WithMessage(err, "Could not fetch the token URI")
+	}
+	uri, err := decodeABIString(result)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := resolveNFTURI(uri, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	content, err := fetchNFTContent(ctx, resolved)
+	if err != nil {
+		return nil, err
+	}
+	var metadata NFTMetadata
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		return nil, errp.WithMessage(errp.WithStack(err), "Invalid metadata")
+	}
+	if metadata.Image != "" {
+		if image, err := resolveNFTURI(metadata.Image, tokenID); err == nil {
+			metadata.Image = image
+		} else {
+			metadata.Image = ""
+		}
+	}
+	return &metadata, nil
+}
+
+// nftTransferData returns the call data of `safeTransferFrom` transferring the amount of tokens
+// from the sender to the recipient. The amount must be 1 for ERC-721 tokens.
+func nftTransferData(
+	standard string, from, to common.Address, tokenID *big.Int, amount *big.Int) ([]byte, error) {
+	data := []byte{}
+	switch standard {
+	case NFTStandardERC721:
+		if amount.Cmp(big.NewInt(1)) != 0 {
+			return nil, errp.New("An ERC-721 token is transferred as a whole.")
+		}
+		data = append(data, erc721SafeTransferFromSelector...)
+	case NFTStandardERC1155:
+		if amount.Sign() <= 0 {
+			return nil, errp.New("The amount must be positive.")
+		}
+		data = append(data, erc1155SafeTransferFromSelector...)
+	default:
+		return nil, errp.Newf("Unknown NFT standard %s.", standard)
+	}
+	data = append(data, common.LeftPadBytes(from.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(tokenID.Bytes(), 32)...)
+	if standard == NFTStandardERC1155 {
+		data = append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+		// The offset of the empty `bytes data` argument, which follows the five head words.
+		data = append(data, common.LeftPadBytes(big.NewInt(5*32).Bytes(), 32)...)
+		data = append(data, make([]byte, 32)...)
+	}
+	return data, nil
+}
+
+// TransferNFT signs and broadcasts a transaction calling `safeTransferFrom` of the contract of the
+// token, and returns its hash. The fee is paid in Ether. Returns keystore.ErrSigningAborted on user
+// abort.
+func (account *Account) TransferNFT(
+	contract common.Address,
+	tokenID *big.Int,
+	standard string,
+	amount *big.Int,
+	recipient string,
+) (common.Hash, error) {
+	if !common.IsHexAddress(strings.TrimSpace(recipient)) {
+		return common.Hash{}, errp.New("Invalid recipient address.")
+	}
+	to := common.HexToAddress(strings.TrimSpace(recipient))
+	data, err := nftTransferData(standard, account.address.Address, to, tokenID, amount)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	account.log.WithField("contract", contract.Hex()).Info("Transferring NFT")
+	return account.SendTransactionRequest(&TransactionRequest{
+		From: account.address.Address,
+		To:   &contract,
+		Data: data,
+	})
+}
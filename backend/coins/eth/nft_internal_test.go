@@ -0,0 +1,117 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/etherscan"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNFTSelectors(t *testing.T) {
+	for signature, selector := range map[string][]byte{
+		"tokenURI(uint256)": erc721TokenURISelector,
+		"uri(uint256)":      erc1155URISelector,
+		"safeTransferFrom(address,address,uint256)":               erc721SafeTransferFromSelector,
+		"safeTransferFrom(address,address,uint256,uint256,bytes)": erc1155SafeTransferFromSelector,
+	} {
+		require.Equal(t, crypto.Keccak256([]byte(signature))[:4], selector, signature)
+	}
+}
+
+func TestNFTHoldings(t *testing.T) {
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	other := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	collection := common.HexToAddress("0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	multiToken := common.HexToAddress("0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+	transfers := []*etherscan.NFTTransfer{
+		// Minted, kept.
+		{Contract: collection, From: common.Address{}, To: account, TokenID: big.NewInt(2), Amount: big.NewInt(1), Name: "Punks"},
+		// Received and sent away again.
+		{Contract: collection, From: other, To: account, TokenID: big.NewInt(1), Amount: big.NewInt(1), Name: "Punks"},
+		{Contract: collection, From: account, To: other, TokenID: big.NewInt(1), Amount: big.NewInt(1), Name: "Punks"},
+		// Five received, two sent.
+		{Contract: multiToken, From: other, To: account, TokenID: big.NewInt(7), Amount: big.NewInt(5), ERC1155: true},
+		{Contract: multiToken, From: account, To: other, TokenID: big.NewInt(7), Amount: big.NewInt(2), ERC1155: true},
+	}
+	require.Equal(t, []*NFT{
+		{Contract: collection, TokenID: big.NewInt(2), Standard: NFTStandardERC721, Amount: big.NewInt(1), CollectionName: "Punks"},
+		{Contract: multiToken, TokenID: big.NewInt(7), Standard: NFTStandardERC1155, Amount: big.NewInt(3)},
+	}, nftHoldings(transfers, account))
+}
+
+func TestResolveNFTURI(t *testing.T) {
+	tokenID := big.NewInt(314)
+	for uri, expected := range map[string]string{
+		"ipfs://QmHash/1.json":         "https://ipfs.io/ipfs/QmHash/1.json",
+		"ipfs://ipfs/QmHash/1.json":    "https://ipfs.io/ipfs/QmHash/1.json",
+		" https://example.com/1 ":      "https://example.com/1",
+		"data:application/json,%7B%7D": "data:application/json,%7B%7D",
+		"https://example.com/{id}.json": "https://example.com/" +
+			"000000000000000000000000000000000000000000000000000000000000013a.json",
+	} {
+		resolved, err := resolveNFTURI(uri, tokenID)
+		require.NoError(t, err)
+		require.Equal(t, expected, resolved)
+	}
+	_, err := resolveNFTURI("ar://hash", tokenID)
+	require.Error(t, err)
+}
+
+func TestDecodeDataURL(t *testing.T) {
+	content, err := decodeDataURL("data:application/json;base64,eyJuYW1lIjoiQml0Qm94In0=")
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"BitBox"}`, string(content))
+	content, err = decodeDataURL("data:application/json;utf8,%7B%22name%22:%22BitBox%22%7D")
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"BitBox"}`, string(content))
+	_, err = decodeDataURL("data:application/json")
+	require.Error(t, err)
+}
+
+func TestNFTTransferData(t *testing.T) {
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	data, err := nftTransferData(NFTStandardERC721, from, to, big.NewInt(5), big.NewInt(1))
+	require.NoError(t, err)
+	require.Equal(t, "0x42842e0e"+
+		"0000000000000000000000001111111111111111111111111111111111111111"+
+		"0000000000000000000000002222222222222222222222222222222222222222"+
+		"0000000000000000000000000000000000000000000000000000000000000005",
+		hexutil.Encode(data))
+	_, err = nftTransferData(NFTStandardERC721, from, to, big.NewInt(5), big.NewInt(2))
+	require.Error(t, err)
+
+	data, err = nftTransferData(NFTStandardERC1155, from, to, big.NewInt(5), big.NewInt(3))
+	require.NoError(t, err)
+	require.Equal(t, "0xf242432a"+
+		"0000000000000000000000001111111111111111111111111111111111111111"+
+		"0000000000000000000000002222222222222222222222222222222222222222"+
+		"0000000000000000000000000000000000000000000000000000000000000005"+
+		"0000000000000000000000000000000000000000000000000000000000000003"+
+		"00000000000000000000000000000000000000000000000000000000000000a0"+
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		hexutil.Encode(data))
+	_, err = nftTransferData(NFTStandardERC1155, from, to, big.NewInt(5), big.NewInt(0))
+	require.Error(t, err)
+	_, err = nftTransferData("erc20", from, to, big.NewInt(5), big.NewInt(1))
+	require.Error(t, err)
+}
@@ -0,0 +1,202 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// replacementFeeBump is the minimum increase in percent of the priority fee and the fee cap of a
+// transaction replacing a pending one, which nodes require to accept the replacement.
+const replacementFeeBump = 10
+
+// PendingTransaction is a transaction sent by the account which is not confirmed yet.
+type PendingTransaction struct {
+	Nonce     uint64
+	Hash      common.Hash
+	To        *common.Address
+	Value     *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+	Gas       uint64
+	Data      []byte
+	// Token describes the transfer if the transaction is an ERC-20 transfer.
+	Token *TokenTransfer
+}
+
+// PriorityFeeGwei returns the priority fee per gas in Gwei, e.g. "1.5".
+func (transaction *PendingTransaction) PriorityFeeGwei() string {
+	return FormatGwei(transaction.GasTipCap)
+}
+
+// FormatGwei formats an amount of wei in Gwei, e.g. "1.5".
+func FormatGwei(wei *big.Int) string {
+	return formatUnits(wei, 9)
+}
+
+// NonceQueue describes the nonces of the pending transactions of the account.
+type NonceQueue struct {
+	// ConfirmedNonce is the nonce of the next transaction to be confirmed. Transactions with a
+	// lower nonce cannot be replaced anymore.
+	ConfirmedNonce uint64
+	// PendingNonce is the nonce of the next new transaction. It is ConfirmedNonce if no
+	// transaction is pending.
+	PendingNonce uint64
+	// Transactions are the pending transactions sent in this session, sorted by nonce.
+	// Transactions sent by other wallets or before a restart are not known.
+	Transactions []*PendingTransaction
+}
+
+// minReplacementFee returns the smallest fee which can replace a transaction paying the given fee.
+func minReplacementFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+replacementFeeBump))
+	// Round up.
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// parseGasTipCap parses the priority fee in Gwei entered by the user.
+func parseGasTipCap(customFee string) (*big.Int, error) {
+	gwei, ok := new(big.Rat).SetString(customFee)
+	if !ok || gwei.Sign() < 0 {
+		return nil, errp.WithStack(coin.ErrInvalidFeeRate)
+	}
+	wei := new(big.Rat).Mul(gwei, new(big.Rat).SetInt64(params.GWei))
+	if !wei.IsInt() {
+		return nil, errp.WithStack(coin.ErrInvalidFeeRate)
+	}
+	return wei.Num(), nil
+}
+
+// NonceQueue returns the nonces of the pending transactions.
+func (account *Account) NonceQueue() (*NonceQueue, error) {
+	confirmedNonce, err := account.coin.client.NonceAt(context.TODO(), account.address.Address, nil)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	pendingNonce, err := account.coin.client.PendingNonceAt(context.TODO(), account.address.Address)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	account.prunePendingTransactions(confirmedNonce)
+	defer account.RLock()()
+	transactions := []*PendingTransaction{}
+	for _, transaction := range account.pendingTransactions {
+		transactions = append(transactions, transaction)
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].Nonce < transactions[j].Nonce
+	})
+	return &NonceQueue{
+		ConfirmedNonce: confirmedNonce,
+		PendingNonce:   pendingNonce,
+		Transactions:   transactions,
+	}, nil
+}
+
+// prunePendingTransactions forgets the transactions which have been confirmed or replaced by a
+// confirmed transaction.
+func (account *Account) prunePendingTransactions(confirmedNonce uint64) {
+	defer account.Lock()()
+	for nonce := range account.pendingTransactions {
+		if nonce < confirmedNonce {
+			delete(account.pendingTransactions, nonce)
+		}
+	}
+}
+
+// pendingTransaction returns the pending transaction with the given nonce, or nil if there is none
+// or if it is not known.
+func (account *Account) pendingTransaction(nonce uint64) *PendingTransaction {
+	defer account.RLock()()
+	return account.pendingTransactions[nonce]
+}
+
+// addPendingTransaction records a broadcasted transaction. It replaces a pending transaction
+// with the same nonce.
+func (account *Account) addPendingTransaction(txProposal *TxProposal) error {
+	tx := txProposal.DynamicFeeTx
+	hash, err := tx.Hash()
+	if err != nil {
+		return err
+	}
+	defer account.Lock()()
+	account.pendingTransactions[tx.Nonce] = &PendingTransaction{
+		Nonce:     tx.Nonce,
+		Hash:      hash,
+		To:        tx.To,
+		Value:     tx.Value,
+		GasTipCap: tx.GasTipCap,
+		GasFeeCap: tx.GasFeeCap,
+		Gas:       tx.Gas,
+		Data:      tx.Data,
+		Token:     txProposal.Token,
+	}
+	return nil
+}
+
+// selectNonce returns the nonce of a new transaction. If it is given explicitly, it has to be the
+// nonce of a pending transaction, which is then replaced, or the next nonce.
+func (account *Account) selectNonce(explicitNonce *uint64) (uint64, error) {
+	pendingNonce, err := account.coin.client.PendingNonceAt(context.TODO(), account.address.Address)
+	if err != nil {
+		return 0, errp.WithStack(err)
+	}
+	if explicitNonce == nil {
+		return pendingNonce, nil
+	}
+	confirmedNonce, err := account.coin.client.NonceAt(context.TODO(), account.address.Address, nil)
+	if err != nil {
+		return 0, errp.WithStack(err)
+	}
+	if *explicitNonce < confirmedNonce {
+		return 0, errp.Newf("The transaction with nonce %d has already been confirmed.", *explicitNonce)
+	}
+	if *explicitNonce > pendingNonce {
+		return 0, errp.Newf("The nonce %d is too high. The next nonce is %d.", *explicitNonce, pendingNonce)
+	}
+	return *explicitNonce, nil
+}
+
+// replacementFees raises the fees to replace the known pending transaction with the same nonce,
+// if there is one.
+func (account *Account) replacementFees(
+	nonce uint64, gasTipCap *big.Int, gasFeeCap *big.Int) (*big.Int, *big.Int) {
+	replaced := account.pendingTransaction(nonce)
+	if replaced == nil {
+		return gasTipCap, gasFeeCap
+	}
+	return bumpFees(replaced, gasTipCap, gasFeeCap)
+}
+
+// bumpFees raises the given fees to the minimum fees which can replace the given transaction.
+func bumpFees(
+	replaced *PendingTransaction, gasTipCap *big.Int, gasFeeCap *big.Int) (*big.Int, *big.Int) {
+	if minTip := minReplacementFee(replaced.GasTipCap); gasTipCap.Cmp(minTip) < 0 {
+		gasFeeCap = new(big.Int).Add(gasFeeCap, new(big.Int).Sub(minTip, gasTipCap))
+		gasTipCap = minTip
+	}
+	if minFeeCap := minReplacementFee(replaced.GasFeeCap); gasFeeCap.Cmp(minFeeCap) < 0 {
+		gasFeeCap = minFeeCap
+	}
+	return gasTipCap, gasFeeCap
+}
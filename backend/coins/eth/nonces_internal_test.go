@@ -0,0 +1,76 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinReplacementFee(t *testing.T) {
+	require.Equal(t, big.NewInt(110), minReplacementFee(big.NewInt(100)))
+	// Rounded up.
+	require.Equal(t, big.NewInt(13), minReplacementFee(big.NewInt(11)))
+	require.Zero(t, minReplacementFee(big.NewInt(0)).Sign())
+}
+
+func TestParseGasTipCap(t *testing.T) {
+	tip, err := parseGasTipCap("1.5")
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1500000000), tip)
+
+	tip, err = parseGasTipCap("0")
+	require.NoError(t, err)
+	require.Zero(t, tip.Sign())
+
+	for _, invalid := range []string{"", "abc", "-1", "0.0000000001"} {
+		_, err := parseGasTipCap(invalid)
+		require.Equal(t, coin.ErrInvalidFeeRate, errp.Cause(err), invalid)
+	}
+}
+
+func TestReplacementFees(t *testing.T) {
+	account := &Account{pendingTransactions: map[uint64]*PendingTransaction{
+		5: {Nonce: 5, GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(1000)},
+	}}
+
+	// No known transaction with this nonce.
+	tip, feeCap := account.replacementFees(6, big.NewInt(50), big.NewInt(500))
+	require.Equal(t, big.NewInt(50), tip)
+	require.Equal(t, big.NewInt(500), feeCap)
+
+	// The fees are raised to replace the transaction, keeping the base fee allowance.
+	tip, feeCap = account.replacementFees(5, big.NewInt(50), big.NewInt(1200))
+	require.Equal(t, big.NewInt(110), tip)
+	require.Equal(t, big.NewInt(1260), feeCap)
+
+	tip, feeCap = account.replacementFees(5, big.NewInt(50), big.NewInt(500))
+	require.Equal(t, big.NewInt(110), tip)
+	require.Equal(t, big.NewInt(1100), feeCap)
+
+	// Higher fees are kept.
+	tip, feeCap = account.replacementFees(5, big.NewInt(200), big.NewInt(2000))
+	require.Equal(t, big.NewInt(200), tip)
+	require.Equal(t, big.NewInt(2000), feeCap)
+}
+
+func TestPriorityFeeGwei(t *testing.T) {
+	transaction := &PendingTransaction{GasTipCap: big.NewInt(1500000000)}
+	require.Equal(t, "1.5", transaction.PriorityFeeGwei())
+}
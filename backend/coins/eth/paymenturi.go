@@ -0,0 +1,85 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"math/big"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth/eip681"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PaymentRequest is the payment requested by a payment URI, with which the send form is prefilled.
+type PaymentRequest struct {
+	// Recipient is the address or the ENS name of the recipient.
+	Recipient string
+	// Amount is in the smallest unit of the coin, or nil if the URI does not request an amount.
+	Amount *big.Int
+	// GasLimit is 0 if the URI does not specify it.
+	GasLimit uint64
+}
+
+// ParsePaymentURI parses an EIP-681 payment URI requesting a payment in this coin. Plain payments
+// are requested from Ether coins, calls of `transfer` of the token contract from ERC20 coins.
+func (coin *Coin) ParsePaymentURI(uri string) (*PaymentRequest, error) {
+	parsed, err := eip681.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	chainID := parsed.ChainID
+	if chainID == nil {
+		chainID = big.NewInt(1)
+	}
+	if chainID.Cmp(coin.Net().ChainID) != 0 {
+		return nil, errp.Newf("The URI requests a payment on the chain %s instead of %s.",
+			chainID, coin.Net().ChainID)
+	}
+	if coin.erc20Token == nil {
+		if parsed.FunctionName != "" {
+			return nil, errp.Newf("The function %s is not supported.", parsed.FunctionName)
+		}
+		return &PaymentRequest{
+			Recipient: parsed.Target,
+			Amount:    parsed.Value,
+			GasLimit:  parsed.GasLimit,
+		}, nil
+	}
+	if parsed.FunctionName != "transfer" {
+		return nil, errp.Newf("The URI does not request a transfer of %s.", coin.erc20Token.Symbol)
+	}
+	if !common.IsHexAddress(parsed.Target) || common.HexToAddress(parsed.Target) != coin.erc20Token.Contract {
+		return nil, errp.New("The URI requests a transfer of another token.")
+	}
+	if parsed.Value != nil && parsed.Value.Sign() != 0 {
+		return nil, errp.New("Token transfers which also send Ether are not supported.")
+	}
+	recipient := parsed.Params["address"]
+	if recipient == "" {
+		return nil, errp.New("The URI does not contain the recipient of the transfer.")
+	}
+	var amount *big.Int
+	if value, ok := parsed.Params["uint256"]; ok {
+		amount, err = eip681.ParseNumber(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &PaymentRequest{
+		Recipient: recipient,
+		Amount:    amount,
+		GasLimit:  parsed.GasLimit,
+	}, nil
+}
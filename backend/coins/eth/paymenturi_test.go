@@ -0,0 +1,70 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePaymentURI(t *testing.T) {
+	ether := eth.NewCoin("eth", eth.MainnetNetwork, "", "")
+	usdt := eth.NewERC20Coin("eth-erc20-usdt", ether, &eth.ERC20Token{
+		Contract: common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"),
+		Symbol:   "USDT",
+		Decimals: 6,
+	})
+	const recipient = "0x3535353535353535353535353535353535353535"
+
+	request, err := ether.ParsePaymentURI("ethereum:" + recipient + "@1?value=1.5e18&gas=25000")
+	require.NoError(t, err)
+	require.Equal(t, &eth.PaymentRequest{
+		Recipient: recipient,
+		Amount:    big.NewInt(1500000000000000000),
+		GasLimit:  25000,
+	}, request)
+
+	request, err = ether.ParsePaymentURI("ethereum:" + recipient)
+	require.NoError(t, err)
+	require.Nil(t, request.Amount)
+
+	request, err = usdt.ParsePaymentURI(
+		"ethereum:0xdac17f958d2ee523a2206206994597c13d831ec7/transfer?address=" + recipient + "&uint256=5e6")
+	require.NoError(t, err)
+	require.Equal(t, &eth.PaymentRequest{Recipient: recipient, Amount: big.NewInt(5000000)}, request)
+
+	for _, invalid := range []struct {
+		coin *eth.Coin
+		uri  string
+	}{
+		// Other chain.
+		{ether, "ethereum:" + recipient + "@137?value=1"},
+		// Contract calls.
+		{ether, "ethereum:0xdac17f958d2ee523a2206206994597c13d831ec7/transfer?address=" + recipient},
+		// Plain payment to a token account.
+		{usdt, "ethereum:" + recipient + "?value=1"},
+		// Other token.
+		{usdt, "ethereum:0x6b175474e89094c44da98b954eedeac495271d0f/transfer?address=" + recipient},
+		// No recipient.
+		{usdt, "ethereum:0xdac17f958d2ee523a2206206994597c13d831ec7/transfer?uint256=1"},
+	} {
+		_, err := invalid.coin.ParsePaymentURI(invalid.uri)
+		require.Error(t, err, invalid.uri)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// TxStatus is the result of the execution of a confirmed transaction.
+type TxStatus struct {
+	Failed bool
+	// RevertReason is the reason with which the contract reverted a failed transaction, or empty
+	// if it is unknown.
+	RevertReason string
+}
+
+// HistoryTransaction is a transaction of the history of an Ether account. The status is only
+// fetched for transactions sent by the account, as their fee is lost if they fail.
+type HistoryTransaction struct {
+	IndexedTransaction
+	status *TxStatus
+}
+
+// Failed returns whether the transaction failed, so that the fee was spent without a transfer.
+func (tx *HistoryTransaction) Failed() bool {
+	return tx.status != nil && tx.status.Failed
+}
+
+// RevertReason returns the revert reason of a failed transaction, or an empty string.
+func (tx *HistoryTransaction) RevertReason() string {
+	if tx.status == nil {
+		return ""
+	}
+	return tx.status.RevertReason
+}
+
+type jsonReceipt struct {
+	Status      hexutil.Uint64 `json:"status"`
+	BlockNumber *hexutil.Big   `json:"blockNumber"`
+}
+
+type jsonTransaction struct {
+	To    *common.Address `json:"to"`
+	Gas   hexutil.Uint64  `json:"gas"`
+	Value *hexutil.Big    `json:"value"`
+	Input hexutil.Bytes   `json:"input"`
+}
+
+// txStatus fetches the receipt of the confirmed transaction with the given hash sent from the
+// given address. The revert reason of a failed transaction is found by replaying it on top of the
+// previous block, which ignores the transactions before it in the same block, so that it is not
+// always available.
+func (coin *Coin) txStatus(ctx context.Context, hash common.Hash, from common.Address) (*TxStatus, error) {
+	var receipt *jsonReceipt
+	if err := coin.rpcClient.CallContext(ctx, &receipt, "eth_getTransactionReceipt", hash); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if receipt == nil || receipt.BlockNumber == nil {
+		return nil, errp.Newf("The receipt of the transaction %s is not available.", hash.Hex())
+	}
+	if receipt.Status == 1 {
+		return &TxStatus{}, nil
+	}
+	status := &TxStatus{Failed: true}
+	var tx *jsonTransaction
+	if err := coin.rpcClient.CallContext(ctx, &tx, "eth_getTransactionByHash", hash); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if tx == nil || tx.To == nil || receipt.BlockNumber.ToInt().Sign() == 0 {
+		return status, nil
+	}
+	args := map[string]interface{}{
+		"from":  from,
+		"to":    tx.To,
+		"gas":   tx.Gas,
+		"value": tx.Value,
+		"data":  tx.Input,
+	}
+	previousBlock := new(big.Int).Sub(receipt.BlockNumber.ToInt(), big.NewInt(1))
+	var result hexutil.Bytes
+	err := coin.rpcClient.CallContext(ctx, &result, "eth_call", args, hexutil.EncodeBig(previousBlock))
+	if err == nil {
+		return status, nil
+	}
+	if reverted, ok := errp.Cause(simulationError(err)).(*TxRevertedError); ok {
+		status.RevertReason = reverted.Reason
+	}
+	return status, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxStatus(t *testing.T) {
+	succeeded := common.HexToHash("0x01")
+	failed := common.HexToHash("0x02")
+	var callParams []json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.Header().Set("Content-Type", "application/json")
+		respond := func(result string) {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(request.ID) + `,` + result + `}`))
+		}
+		switch request.Method {
+		case "eth_getTransactionReceipt":
+			switch string(request.Params[0]) {
+			case `"` + succeeded.Hex() + `"`:
+				respond(`"result":{"status":"0x1","blockNumber":"0x64"}`)
+			case `"` + failed.Hex() + `"`:
+				respond(`"result":{"status":"0x0","blockNumber":"0x64"}`)
+			default:
+				respond(`"result":null`)
+			}
+		case "eth_getTransactionByHash":
+			respond(`"result":{"to":"0x2222222222222222222222222222222222222222","gas":"0xea60",` +
+				`"value":"0x0","input":"0xa9059cbb"}`)
+		case "eth_call":
+			callParams = request.Params
+			respond(`"error":{"code":3,"message":"execution reverted: ERC20: transfer amount exceeds balance"}`)
+		default:
+			t.Fatalf("unexpected method %s", request.Method)
+		}
+	}))
+	defer server.Close()
+	rpcClient, err := rpc.DialHTTP(server.URL)
+	require.NoError(t, err)
+	coin := NewCoin("eth", MainnetNetwork, "", "")
+	coin.rpcClient = rpcClient
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	status, err := coin.txStatus(context.Background(), succeeded, from)
+	require.NoError(t, err)
+	require.Equal(t, &TxStatus{}, status)
+	require.Nil(t, callParams)
+
+	status, err = coin.txStatus(context.Background(), failed, from)
+	require.NoError(t, err)
+	require.Equal(t, &TxStatus{Failed: true, RevertReason: "ERC20: transfer amount exceeds balance"}, status)
+	require.Len(t, callParams, 2)
+	require.JSONEq(t, `{
+		"from": "0x1111111111111111111111111111111111111111",
+		"to": "0x2222222222222222222222222222222222222222",
+		"gas": "0xea60",
+		"value": "0x0",
+		"data": "0xa9059cbb"
+	}`, string(callParams[0]))
+	// The transaction is replayed on top of the previous block.
+	require.Equal(t, `"0x63"`, string(callParams[1]))
+
+	_, err = coin.txStatus(context.Background(), common.HexToHash("0x03"), from)
+	require.Error(t, err)
+}
+
+func TestHistoryTransaction(t *testing.T) {
+	require.False(t, (&HistoryTransaction{}).Failed())
+	require.Equal(t, "", (&HistoryTransaction{}).RevertReason())
+	tx := &HistoryTransaction{status: &TxStatus{Failed: true, RevertReason: "paused"}}
+	require.True(t, tx.Failed())
+	require.Equal(t, "paused", tx.RevertReason())
+}
@@ -0,0 +1,119 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// replacementTx returns a transaction with the same nonce as the replaced one, paying at least
+// the minimum fees to replace it. If cancelTo is not nil, the transaction sends nothing to it
+// instead of repeating the replaced transaction.
+func replacementTx(
+	chainID *big.Int,
+	replaced *PendingTransaction,
+	cancelTo *common.Address,
+	gasTipCap *big.Int,
+	gasFeeCap *big.Int,
+) (*DynamicFeeTx, *TokenTransfer) {
+	gasTipCap, gasFeeCap = bumpFees(replaced, gasTipCap, gasFeeCap)
+	if cancelTo != nil {
+		to := *cancelTo
+		return &DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     replaced.Nonce,
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+			Gas:       21000, // simple transaction gas cost
+			To:        &to,
+			Value:     big.NewInt(0),
+		}, nil
+	}
+	return &DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     replaced.Nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       replaced.Gas,
+		To:        replaced.To,
+		Value:     replaced.Value,
+		Data:      replaced.Data,
+	}, replaced.Token
+}
+
+// newReplacementTx proposes a transaction replacing the known pending transaction with the given
+// nonce.
+func (account *Account) newReplacementTx(nonce uint64, cancel bool) (*TxProposal, error) {
+	confirmedNonce, err := account.coin.client.NonceAt(context.TODO(), account.address.Address, nil)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	account.prunePendingTransactions(confirmedNonce)
+	replaced := account.pendingTransaction(nonce)
+	if replaced == nil {
+		return nil, errp.Newf("There is no known pending transaction with nonce %d.", nonce)
+	}
+	baseFee, err := account.coin.baseFee(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	gasTipCap, err := account.coin.suggestGasTipCap(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	var cancelTo *common.Address
+	if cancel {
+		cancelTo = &account.address.Address
+	}
+	tx, token := replacementTx(
+		account.coin.Net().ChainID, replaced, cancelTo, gasTipCap, MaxFeePerGas(baseFee, gasTipCap))
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas), tx.GasFeeCap)
+	if new(big.Int).Add(tx.Value, fee).Cmp(account.etherBalance.BigInt()) == 1 {
+		return nil, errp.WithStack(coin.ErrInsufficientFunds)
+	}
+	return &TxProposal{
+		DynamicFeeTx: tx,
+		Fee:          fee,
+		Keypath:      account.signingConfiguration.AbsoluteKeypath(),
+		Token:        token,
+	}, nil
+}
+
+// SpeedUpTransaction replaces the pending transaction with the given nonce by the same
+// transaction paying a higher fee.
+func (account *Account) SpeedUpTransaction(nonce uint64) error {
+	account.log.WithField("nonce", nonce).Info("Speeding up transaction")
+	txProposal, err := account.newReplacementTx(nonce, false)
+	if err != nil {
+		return err
+	}
+	return account.signAndSend(txProposal)
+}
+
+// CancelTransaction replaces the pending transaction with the given nonce by a transaction
+// sending nothing to the account itself, so that the replaced transaction is never confirmed.
+func (account *Account) CancelTransaction(nonce uint64) error {
+	account.log.WithField("nonce", nonce).Info("Cancelling transaction")
+	txProposal, err := account.newReplacementTx(nonce, true)
+	if err != nil {
+		return err
+	}
+	return account.signAndSend(txProposal)
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplacementTx(t *testing.T) {
+	chainID := big.NewInt(1)
+	recipient := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	self := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	token := &TokenTransfer{Recipient: recipient, Amount: big.NewInt(5)}
+	replaced := &PendingTransaction{
+		Nonce:     7,
+		To:        &recipient,
+		Value:     big.NewInt(0),
+		GasTipCap: big.NewInt(100),
+		GasFeeCap: big.NewInt(1000),
+		Gas:       60000,
+		Data:      []byte{1, 2, 3},
+		Token:     token,
+	}
+
+	// Speed up: the same transaction with bumped fees.
+	tx, tokenTransfer := replacementTx(chainID, replaced, nil, big.NewInt(50), big.NewInt(500))
+	require.Equal(t, uint64(7), tx.Nonce)
+	require.Equal(t, &recipient, tx.To)
+	require.Equal(t, uint64(60000), tx.Gas)
+	require.Equal(t, []byte{1, 2, 3}, tx.Data)
+	require.Equal(t, big.NewInt(110), tx.GasTipCap)
+	require.Equal(t, big.NewInt(1100), tx.GasFeeCap)
+	require.Equal(t, token, tokenTransfer)
+
+	// Higher current fees are kept.
+	tx, _ = replacementTx(chainID, replaced, nil, big.NewInt(200), big.NewInt(2000))
+	require.Equal(t, big.NewInt(200), tx.GasTipCap)
+	require.Equal(t, big.NewInt(2000), tx.GasFeeCap)
+
+	// Cancel: nothing is sent to self.
+	tx, tokenTransfer = replacementTx(chainID, replaced, &self, big.NewInt(50), big.NewInt(500))
+	require.Equal(t, uint64(7), tx.Nonce)
+	require.Equal(t, &self, tx.To)
+	require.Zero(t, tx.Value.Sign())
+	require.Empty(t, tx.Data)
+	require.Equal(t, uint64(21000), tx.Gas)
+	require.Equal(t, big.NewInt(1100), tx.GasFeeCap)
+	require.Nil(t, tokenTransfer)
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TransactionRequest is a transaction requested by a dapp, e.g. with eth_sendTransaction.
+type TransactionRequest struct {
+	From common.Address
+	// To is nil for contract creations.
+	To    *common.Address
+	Value *big.Int
+	Data  []byte
+	// Gas is the gas limit. It is estimated if it is 0.
+	Gas uint64
+}
+
+// Address returns the address of the account.
+func (account *Account) Address() common.Address {
+	return account.address.Address
+}
+
+// ChainID returns the EIP-155 chain id of the network of the account.
+func (account *Account) ChainID() *big.Int {
+	return account.coin.Net().ChainID
+}
+
+// newRequestedTx proposes the requested transaction, paying the suggested fees.
+func (account *Account) newRequestedTx(request *TransactionRequest) (*TxProposal, error) {
+	if account.coin.ERC20Token() != nil {
+		return nil, errp.New("Transactions can only be requested from Ethereum accounts.")
+	}
+	if request.From != account.address.Address {
+		return nil, errp.New("The transaction is not sent from the address of the account.")
+	}
+	value := request.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	if value.Sign() < 0 {
+		return nil, errp.New("The value of the transaction is negative.")
+	}
+	nonce, err := account.selectNonce(nil)
+	if err != nil {
+		return nil, err
+	}
+	baseFee, err := account.coin.baseFee(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	gasTipCap, err := account.coin.suggestGasTipCap(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	gasFeeCap := MaxFeePerGas(baseFee, gasTipCap)
+	gasLimit := request.Gas
+	if gasLimit == 0 {
+		gasLimit, err = account.coin.estimateGas(context.TODO(), ethereum.CallMsg{
+			From:  request.From,
+			To:    request.To,
+			Value: value,
+			Data:  request.Data,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasFeeCap)
+	if new(big.Int).Add(value, fee).Cmp(account.etherBalance.BigInt()) == 1 {
+		return nil, errp.WithStack(coin.ErrInsufficientFunds)
+	}
+	tx := &DynamicFeeTx{
+		ChainID:   account.coin.Net().ChainID,
+		Nonce:     nonce,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Gas:       gasLimit,
+		To:        request.To,
+		Value:     value,
+		Data:      request.Data,
+	}
+	if err := account.coin.simulate(context.TODO(), request.From, tx); err != nil {
+		return nil, err
+	}
+	return &TxProposal{
+		DynamicFeeTx: tx,
+		Fee:          fee,
+		Keypath:      account.signingConfiguration.AbsoluteKeypath(),
+	}, nil
+}
+
+// SendTransactionRequest signs and broadcasts the requested transaction and returns its hash.
+// Returns keystore.ErrSigningAborted on user abort.
+func (account *Account) SendTransactionRequest(request *TransactionRequest) (common.Hash, error) {
+	account.log.Info("Signing and sending requested transaction")
+	txProposal, err := account.newRequestedTx(request)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := account.signAndSend(txProposal); err != nil {
+		return common.Hash{}, err
+	}
+	return txProposal.DynamicFeeTx.Hash()
+}
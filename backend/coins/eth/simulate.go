@@ -0,0 +1,120 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	coinpkg "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// TxRevertedError is returned if the simulation of a transaction shows that it would fail on-chain,
+// so that it is not broadcast and its fee is not lost.
+type TxRevertedError struct {
+	// Reason is the revert reason of the contract, or empty if there is none.
+	Reason string
+}
+
+// Error implements error.
+func (err *TxRevertedError) Error() string {
+	if err.Reason == "" {
+		return "The transaction would fail."
+	}
+	return fmt.Sprintf("The transaction would fail: %s", err.Reason)
+}
+
+// revertErrorMessages are the prefixes of the error messages with which the nodes report that the
+// simulated transaction reverts.
+var revertErrorMessages = []string{
+	"execution reverted",
+	"VM Exception while processing transaction: revert",
+	"gas required exceeds allowance or always failing transaction",
+	"always failing transaction",
+	"out of gas",
+}
+
+// simulationError returns a *TxRevertedError if the error of a simulation is a revert, and the
+// error otherwise.
+func simulationError(err error) error {
+	message := err.Error()
+	for _, prefix := range revertErrorMessages {
+		if strings.HasPrefix(message, prefix) {
+			reason := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(message, prefix), ":"))
+			return errp.WithStack(&TxRevertedError{Reason: reason})
+		}
+	}
+	return errp.WithStack(err)
+}
+
+// estimateGas returns the gas limit of the transaction, or a *TxRevertedError if it reverts.
+func (coin *Coin) estimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	gasLimit, err := coin.client.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, simulationError(err)
+	}
+	return gasLimit, nil
+}
+
+// intrinsicGas returns the gas which a transaction with the given calldata costs before any code is
+// executed (EIP-2028).
+func intrinsicGas(data []byte) uint64 {
+	gas := uint64(21000)
+	for _, b := range data {
+		if b == 0 {
+			gas += 4
+		} else {
+			gas += 16
+		}
+	}
+	return gas
+}
+
+// gasLimit returns the gas limit entered by the user, or the estimated one if it is 0. A custom gas
+// limit below the intrinsic gas of the transaction is rejected with coin.ErrGasLimitTooLow.
+func (coin *Coin) gasLimit(ctx context.Context, customGasLimit uint64, msg ethereum.CallMsg) (uint64, error) {
+	if customGasLimit == 0 {
+		return coin.estimateGas(ctx, msg)
+	}
+	if customGasLimit < intrinsicGas(msg.Data) {
+		return 0, errp.WithStack(coinpkg.ErrGasLimitTooLow)
+	}
+	return customGasLimit, nil
+}
+
+// simulate executes the transaction on top of the pending block without broadcasting it, and
+// returns a *TxRevertedError if it would fail. The fees are not part of the simulation, as the
+// balance is checked against them when the transaction is proposed.
+func (coin *Coin) simulate(ctx context.Context, from common.Address, tx *DynamicFeeTx) error {
+	args := map[string]interface{}{
+		"from":  from,
+		"gas":   hexutil.Uint64(tx.Gas),
+		"value": (*hexutil.Big)(tx.Value),
+		"data":  hexutil.Bytes(tx.Data),
+	}
+	if tx.To != nil {
+		args["to"] = tx.To
+	}
+	var result hexutil.Bytes
+	if err := coin.rpcClient.CallContext(ctx, &result, "eth_call", args, "pending"); err != nil {
+		return simulationError(err)
+	}
+	return nil
+}
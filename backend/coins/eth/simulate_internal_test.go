@@ -0,0 +1,104 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	coinpkg "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulationError(t *testing.T) {
+	for message, reason := range map[string]string{
+		"execution reverted: ERC20: transfer amount exceeds balance": "ERC20: transfer amount exceeds balance",
+		"execution reverted": "",
+		"VM Exception while processing transaction: revert Pausable: paused": "Pausable: paused",
+		"gas required exceeds allowance or always failing transaction":       "",
+		"out of gas": "",
+	} {
+		err := simulationError(errors.New(message))
+		reverted, ok := errp.Cause(err).(*TxRevertedError)
+		require.True(t, ok, message)
+		require.Equal(t, reason, reverted.Reason)
+	}
+	err := simulationError(errors.New("connection refused"))
+	_, ok := errp.Cause(err).(*TxRevertedError)
+	require.False(t, ok)
+}
+
+func TestSimulate(t *testing.T) {
+	var params []json.RawMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		require.Equal(t, "eth_call", request.Method)
+		params = request.Params
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(request.ID) +
+			`,"error":{"code":3,"message":"execution reverted: Pausable: paused"}}`))
+	}))
+	defer server.Close()
+	rpcClient, err := rpc.DialHTTP(server.URL)
+	require.NoError(t, err)
+	coin := NewCoin("eth", MainnetNetwork, "", "")
+	coin.rpcClient = rpcClient
+
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	err = coin.simulate(context.Background(), common.HexToAddress("0x1111111111111111111111111111111111111111"), &DynamicFeeTx{
+		Gas:   60000,
+		To:    &to,
+		Value: big.NewInt(0),
+		Data:  []byte{0xa9, 0x05, 0x9c, 0xbb},
+	})
+	require.Equal(t, &TxRevertedError{Reason: "Pausable: paused"}, errp.Cause(err))
+	require.Len(t, params, 2)
+	require.JSONEq(t, `{
+		"from": "0x1111111111111111111111111111111111111111",
+		"to": "0x2222222222222222222222222222222222222222",
+		"gas": "0xea60",
+		"value": "0x0",
+		"data": "0xa9059cbb"
+	}`, string(params[0]))
+	require.Equal(t, `"pending"`, string(params[1]))
+}
+
+func TestCustomGasLimit(t *testing.T) {
+	require.Equal(t, uint64(21000), intrinsicGas(nil))
+	require.Equal(t, uint64(21000+4+3*16), intrinsicGas([]byte{0x00, 0x01, 0x02, 0x03}))
+
+	// A custom gas limit is used without estimation, so no client is needed.
+	coin := NewCoin("eth", MainnetNetwork, "", "")
+	msg := ethereum.CallMsg{Data: []byte{0xa9, 0x05, 0x9c, 0xbb}}
+	gasLimit, err := coin.gasLimit(context.Background(), 100000, msg)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100000), gasLimit)
+	_, err = coin.gasLimit(context.Background(), 21000, msg)
+	require.Equal(t, coinpkg.ErrGasLimitTooLow, errp.Cause(err))
+}
@@ -0,0 +1,91 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// resubscribeDelay is the time to wait before renewing a lost subscription.
+var resubscribeDelay = 5 * time.Second
+
+// supportsSubscriptions returns true if the coin connects to its node via WebSocket, through
+// which the node pushes notifications. The default provider is polled.
+func (coin *Coin) supportsSubscriptions() bool {
+	if coin.ether != nil {
+		return coin.ether.supportsSubscriptions()
+	}
+	return strings.HasPrefix(coin.rpcURL, "ws://") || strings.HasPrefix(coin.rpcURL, "wss://")
+}
+
+// subscribeNewHeads subscribes to the new blocks announced by the node until quit is closed. The
+// returned channel receives a value when there is a new block, dropping values if the receiver is
+// busy, and the base fee tracker is updated with the block. A lost subscription is renewed. nil is
+// returned if the node does not support subscriptions.
+func (coin *Coin) subscribeNewHeads(quit <-chan struct{}, log *logrus.Entry) <-chan struct{} {
+	if !coin.supportsSubscriptions() {
+		return nil
+	}
+	notify := make(chan struct{}, 1)
+	go func() {
+		for {
+			err := coin.forwardNewHeads(quit, notify)
+			if err == nil {
+				return
+			}
+			if err == rpc.ErrNotificationsUnsupported {
+				log.Warning("The node does not support subscriptions, falling back to polling")
+				return
+			}
+			log.WithError(err).Warning("The subscription to new blocks failed")
+			select {
+			case <-quit:
+				return
+			case <-time.After(resubscribeDelay):
+			}
+		}
+	}()
+	return notify
+}
+
+// forwardNewHeads forwards the new blocks of one subscription to notify. It returns nil when quit
+// is closed, or the error by which the subscription ended.
+func (coin *Coin) forwardNewHeads(quit <-chan struct{}, notify chan<- struct{}) error {
+	heads := make(chan *blockHeader)
+	subscription, err := coin.rpcClient.EthSubscribe(context.TODO(), heads, "newHeads")
+	if err != nil {
+		return err
+	}
+	defer subscription.Unsubscribe()
+	for {
+		select {
+		case <-quit:
+			return nil
+		case err := <-subscription.Err():
+			return err
+		case header := <-heads:
+			coin.baseFeeTracker.update(header)
+			select {
+			case notify <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// NewHeadsService implements the newHeads subscription, pushing the headers sent to heads.
+type NewHeadsService struct {
+	heads chan *blockHeader
+}
+
+func (service *NewHeadsService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	subscription := notifier.CreateSubscription()
+	go func() {
+		for {
+			select {
+			case header := <-service.heads:
+				_ = notifier.Notify(subscription.ID, header)
+			case <-subscription.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return subscription, nil
+}
+
+func TestSubscribeNewHeads(t *testing.T) {
+	log := logging.Get().WithGroup("eth")
+	quit := make(chan struct{})
+	defer close(quit)
+
+	// The default provider is polled.
+	require.Nil(t, NewCoin("eth", MainnetNetwork, "", "").subscribeNewHeads(quit, log))
+	require.Nil(t, NewCoin("eth", MainnetNetwork, "https://node.example", "").subscribeNewHeads(quit, log))
+
+	service := &NewHeadsService{heads: make(chan *blockHeader)}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	coin := NewCoin("eth", MainnetNetwork, "ws://127.0.0.1:8546", "")
+	coin.rpcClient = rpc.DialInProc(server)
+	defer coin.rpcClient.Close()
+	// The token shares the connection of the Ether coin, as after initialization.
+	token := NewERC20Coin("eth-erc20-test", coin, &ERC20Token{Symbol: "TEST", Decimals: 18})
+	token.rpcClient = coin.rpcClient
+
+	newHeads := token.subscribeNewHeads(quit, log)
+	require.NotNil(t, newHeads)
+	header := &blockHeader{
+		Number:        (*hexutil.Big)(big.NewInt(100)),
+		BaseFeePerGas: (*hexutil.Big)(big.NewInt(7)),
+	}
+	// Notifications are dropped until the subscription is active, so the block is announced
+	// repeatedly.
+	timeout := time.After(5 * time.Second)
+notified:
+	for {
+		select {
+		case service.heads <- header:
+		case <-newHeads:
+			break notified
+		case <-timeout:
+			require.Fail(t, "no notification of the new block")
+		}
+	}
+	require.Equal(t, big.NewInt(7), coin.baseFeeTracker.BaseFee())
+}
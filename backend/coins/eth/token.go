@@ -0,0 +1,103 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/jsonp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// erc20TransferSelector is the function selector of `transfer(address,uint256)`.
+var erc20TransferSelector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+
+// TokenTransfer describes an ERC-20 transfer so that it can be shown to the user before signing.
+type TokenTransfer struct {
+	// Contract is the address of the token contract, which is the recipient of the transaction.
+	Contract common.Address
+	Symbol   string
+	Decimals uint
+	// Recipient is the recipient of the tokens.
+	Recipient common.Address
+	// Amount is the transferred amount in the smallest unit of the token.
+	Amount *big.Int
+}
+
+// ERC20TransferData returns the calldata of `transfer(recipient, amount)`.
+func ERC20TransferData(recipient common.Address, amount *big.Int) []byte {
+	data := append([]byte{}, erc20TransferSelector...)
+	data = append(data, common.LeftPadBytes(recipient.Bytes(), 32)...)
+	return append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+}
+
+// Validate checks that a transaction to the given address with the given calldata is the
+// described transfer, so that the user is not shown something else than what is signed.
+func (transfer *TokenTransfer) Validate(to *common.Address, data []byte) error {
+	if to == nil || *to != transfer.Contract {
+		return errp.New("The transaction is not sent to the token contract.")
+	}
+	if transfer.Amount == nil || transfer.Amount.Sign() < 0 {
+		return errp.New("Invalid token amount.")
+	}
+	if !bytes.Equal(data, ERC20TransferData(transfer.Recipient, transfer.Amount)) {
+		return errp.New("The transaction data does not match the token transfer.")
+	}
+	return nil
+}
+
+// formatUnits formats an amount given in the smallest unit of a token with the given number of
+// decimals, e.g. 50500000 with 6 decimals as "50.5".
+func formatUnits(amount *big.Int, decimals uint) string {
+	if decimals == 0 {
+		return amount.String()
+	}
+	digits := amount.String()
+	if missing := int(decimals) + 1 - len(digits); missing > 0 {
+		digits = strings.Repeat("0", missing) + digits
+	}
+	integer := digits[:len(digits)-int(decimals)]
+	fraction := strings.TrimRight(digits[len(integer):], "0")
+	if fraction == "" {
+		return integer
+	}
+	return integer + "." + fraction
+}
+
+// FormattedAmount returns the amount in the unit of the token, e.g. "50.5".
+func (transfer *TokenTransfer) FormattedAmount() string {
+	return formatUnits(transfer.Amount, transfer.Decimals)
+}
+
+// Description returns a short description of the transfer, e.g. "Send 50 USDT to 0x...".
+func (transfer *TokenTransfer) Description() string {
+	return fmt.Sprintf("Send %s %s to %s", transfer.FormattedAmount(), transfer.Symbol, transfer.Recipient.Hex())
+}
+
+// Meta returns the JSON encoded transfer, which is passed to the device when signing.
+func (transfer *TokenTransfer) Meta() string {
+	return string(jsonp.MustMarshal(map[string]interface{}{
+		"contract":    transfer.Contract.Hex(),
+		"symbol":      transfer.Symbol,
+		"decimals":    transfer.Decimals,
+		"recipient":   transfer.Recipient.Hex(),
+		"amount":      transfer.Amount.String(),
+		"description": transfer.Description(),
+	}))
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestERC20TransferData(t *testing.T) {
+	require.Equal(t,
+		"a9059cbb"+
+			"0000000000000000000000003535353535353535353535353535353535353535"+
+			"0000000000000000000000000000000000000000000000000000000002faf080",
+		hex.EncodeToString(eth.ERC20TransferData(
+			common.HexToAddress("0x3535353535353535353535353535353535353535"), big.NewInt(50e6))))
+}
+
+func TestTokenTransferFormattedAmount(t *testing.T) {
+	for amount, expected := range map[int64]string{
+		50000000: "50",
+		50500000: "50.5",
+		1:        "0.000001",
+		0:        "0",
+	} {
+		transfer := &eth.TokenTransfer{Decimals: 6, Amount: big.NewInt(amount)}
+		require.Equal(t, expected, transfer.FormattedAmount())
+	}
+	transfer := &eth.TokenTransfer{Decimals: 0, Amount: big.NewInt(42)}
+	require.Equal(t, "42", transfer.FormattedAmount())
+}
+
+func TestTokenTransferValidate(t *testing.T) {
+	contract := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	transfer := &eth.TokenTransfer{
+		Contract:  contract,
+		Symbol:    "USDT",
+		Decimals:  6,
+		Recipient: common.HexToAddress("0x3535353535353535353535353535353535353535"),
+		Amount:    big.NewInt(50e6),
+	}
+	data := eth.ERC20TransferData(transfer.Recipient, transfer.Amount)
+	require.NoError(t, transfer.Validate(&contract, data))
+	require.Error(t, transfer.Validate(nil, data))
+	require.Error(t, transfer.Validate(&transfer.Recipient, data))
+	require.Error(t, transfer.Validate(&contract, data[:len(data)-1]))
+}
+
+func TestERC20Coin(t *testing.T) {
+	ether := eth.NewCoin("eth", eth.MainnetNetwork, "", "")
+	token := &eth.ERC20Token{
+		Contract: common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"),
+		Symbol:   "USDT",
+		Decimals: 6,
+	}
+	erc20Coin := eth.NewERC20Coin("eth-erc20-0xdac17f958d2ee523a2206206994597c13d831ec7", ether, token)
+	require.Equal(t, token, erc20Coin.ERC20Token())
+	require.Equal(t, ether, erc20Coin.Ether())
+	require.Equal(t, ether, ether.Ether())
+	require.Equal(t, "USDT", erc20Coin.Unit())
+	require.Equal(t, "50.5", erc20Coin.FormatAmount(coin.NewAmountFromInt64(50500000)))
+	require.Equal(t, "https://etherscan.io/tx/", erc20Coin.BlockExplorerTransactionURLPrefix())
+}
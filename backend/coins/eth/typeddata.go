@@ -0,0 +1,312 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/jsonp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// eip712DomainType is the name of the type of the domain of typed data.
+const eip712DomainType = "EIP712Domain"
+
+var (
+	arrayTypeRegexp   = regexp.MustCompile(`^(.+)\[([0-9]*)\]$`)
+	integerTypeRegexp = regexp.MustCompile(`^(u?)int([0-9]*)$`)
+	bytesTypeRegexp   = regexp.MustCompile(`^bytes([0-9]+)$`)
+)
+
+// TypedDataField is a member of a struct type of typed data.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is a structured EIP-712 payload, as passed to eth_signTypedData_v4.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// ParseTypedData parses the JSON encoding of typed data. Numbers are kept exactly.
+func ParseTypedData(jsonTypedData []byte) (*TypedData, error) {
+	decoder := json.NewDecoder(bytes.NewReader(jsonTypedData))
+	decoder.UseNumber()
+	var typedData TypedData
+	if err := decoder.Decode(&typedData); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if _, ok := typedData.Types[eip712DomainType]; !ok {
+		return nil, errp.Newf("The type %s is missing.", eip712DomainType)
+	}
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return nil, errp.Newf("The primary type %q is not defined.", typedData.PrimaryType)
+	}
+	return &typedData, nil
+}
+
+// DomainSeparator returns the hash of the domain.
+func (typedData *TypedData) DomainSeparator() ([]byte, error) {
+	return typedData.hashStruct(eip712DomainType, typedData.Domain)
+}
+
+// MessageHash returns the hash of the message.
+func (typedData *TypedData) MessageHash() ([]byte, error) {
+	return typedData.hashStruct(typedData.PrimaryType, typedData.Message)
+}
+
+// SignatureHash returns the EIP-712 hash which is signed, see TypedDataSignatureHash.
+func (typedData *TypedData) SignatureHash() ([]byte, error) {
+	domainSeparator, err := typedData.DomainSeparator()
+	if err != nil {
+		return nil, err
+	}
+	messageHash, err := typedData.MessageHash()
+	if err != nil {
+		return nil, err
+	}
+	return TypedDataSignatureHash(domainSeparator, messageHash), nil
+}
+
+// Meta returns the JSON encoded domain, primary type and message, which is passed to the device
+// when signing so that they can be shown to the user instead of the hash.
+func (typedData *TypedData) Meta() string {
+	return string(jsonp.MustMarshal(map[string]interface{}{
+		"type":        "eth_signTypedData_v4",
+		"domain":      typedData.Domain,
+		"primaryType": typedData.PrimaryType,
+		"message":     typedData.Message,
+	}))
+}
+
+// TypedDataSignatureHash returns the EIP-712 hash which is signed: keccak256("\x19\x01" ‖
+// domainSeparator ‖ messageHash).
+func TypedDataSignatureHash(domainSeparator []byte, messageHash []byte) []byte {
+	return crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, messageHash)
+}
+
+// baseType strips all array suffixes from the type, e.g. "Person[][2]" becomes "Person".
+func baseType(typeName string) string {
+	for {
+		match := arrayTypeRegexp.FindStringSubmatch(typeName)
+		if match == nil {
+			return typeName
+		}
+		typeName = match[1]
+	}
+}
+
+// dependencies adds the struct type and all struct types referenced by it to found.
+func (typedData *TypedData) dependencies(typeName string, found map[string]bool) {
+	typeName = baseType(typeName)
+	if found[typeName] {
+		return
+	}
+	fields, ok := typedData.Types[typeName]
+	if !ok {
+		return
+	}
+	found[typeName] = true
+	for _, field := range fields {
+		typedData.dependencies(field.Type, found)
+	}
+}
+
+// encodeType returns the encoding of the struct type, e.g. "Mail(Person from,Person to,string
+// contents)Person(string name,address wallet)". Referenced types follow sorted by name.
+func (typedData *TypedData) encodeType(typeName string) string {
+	found := map[string]bool{}
+	typedData.dependencies(typeName, found)
+	delete(found, typeName)
+	referenced := []string{}
+	for name := range found {
+		referenced = append(referenced, name)
+	}
+	sort.Strings(referenced)
+	var encoded strings.Builder
+	for _, name := range append([]string{typeName}, referenced...) {
+		fields := make([]string, len(typedData.Types[name]))
+		for index, field := range typedData.Types[name] {
+			fields[index] = field.Type + " " + field.Name
+		}
+		encoded.WriteString(name + "(" + strings.Join(fields, ",") + ")")
+	}
+	return encoded.String()
+}
+
+// hashStruct returns keccak256(typeHash ‖ encodeData(data)).
+func (typedData *TypedData) hashStruct(typeName string, data map[string]interface{}) ([]byte, error) {
+	fields, ok := typedData.Types[typeName]
+	if !ok {
+		return nil, errp.Newf("The type %q is not defined.", typeName)
+	}
+	encoded := crypto.Keccak256([]byte(typedData.encodeType(typeName)))
+	for _, field := range fields {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, errp.Newf("The field %q of %s is missing.", field.Name, typeName)
+		}
+		encodedValue, err := typedData.encodeValue(field.Type, value)
+		if err != nil {
+			return nil, errp.WithMessage(err, typeName+"."+field.Name)
+		}
+		encoded = append(encoded, encodedValue...)
+	}
+	return crypto.Keccak256(encoded), nil
+}
+
+// encodeValue returns the 32 byte encoding of the value of the given type.
+func (typedData *TypedData) encodeValue(typeName string, value interface{}) ([]byte, error) {
+	if match := arrayTypeRegexp.FindStringSubmatch(typeName); match != nil {
+		elements, ok := value.([]interface{})
+		if !ok {
+			return nil, errp.Newf("Expected an array of type %s.", typeName)
+		}
+		if match[2] != "" {
+			length, err := strconv.Atoi(match[2])
+			if err != nil || length != len(elements) {
+				return nil, errp.Newf("Expected %s elements, got %d.", match[2], len(elements))
+			}
+		}
+		var encoded []byte
+		for _, element := range elements {
+			encodedElement, err := typedData.encodeValue(match[1], element)
+			if err != nil {
+				return nil, err
+			}
+			encoded = append(encoded, encodedElement...)
+		}
+		return crypto.Keccak256(encoded), nil
+	}
+	if _, ok := typedData.Types[typeName]; ok {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errp.Newf("Expected an object of type %s.", typeName)
+		}
+		return typedData.hashStruct(typeName, data)
+	}
+	switch typeName {
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return nil, errp.New("Expected a string.")
+		}
+		return crypto.Keccak256([]byte(text)), nil
+	case "bytes":
+		data, err := parseTypedDataBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(data), nil
+	case "bool":
+		boolean, ok := value.(bool)
+		if !ok {
+			return nil, errp.New("Expected a boolean.")
+		}
+		if boolean {
+			return math.PaddedBigBytes(big.NewInt(1), 32), nil
+		}
+		return make([]byte, 32), nil
+	case "address":
+		address, ok := value.(string)
+		if !ok || !common.IsHexAddress(address) {
+			return nil, errp.New("Expected an address.")
+		}
+		return common.LeftPadBytes(common.HexToAddress(address).Bytes(), 32), nil
+	}
+	if match := bytesTypeRegexp.FindStringSubmatch(typeName); match != nil {
+		size, err := strconv.Atoi(match[1])
+		if err != nil || size < 1 || size > 32 {
+			return nil, errp.Newf("Invalid type %s.", typeName)
+		}
+		data, err := parseTypedDataBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) != size {
+			return nil, errp.Newf("Expected %d bytes, got %d.", size, len(data))
+		}
+		return common.RightPadBytes(data, 32), nil
+	}
+	if match := integerTypeRegexp.FindStringSubmatch(typeName); match != nil {
+		bits := 256
+		if match[2] != "" {
+			var err error
+			bits, err = strconv.Atoi(match[2])
+			if err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+				return nil, errp.Newf("Invalid type %s.", typeName)
+			}
+		}
+		return encodeTypedDataInteger(value, match[1] == "u", bits)
+	}
+	return nil, errp.Newf("Unsupported type %s.", typeName)
+}
+
+// parseTypedDataBytes parses a 0x-prefixed hex string.
+func parseTypedDataBytes(value interface{}) ([]byte, error) {
+	text, ok := value.(string)
+	if !ok {
+		return nil, errp.New("Expected a hex string.")
+	}
+	data, err := hexutil.Decode(text)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return data, nil
+}
+
+// encodeTypedDataInteger encodes a number, a decimal string or a 0x-prefixed hex string as a
+// 256 bit two's complement integer, checking that it fits into the given number of bits.
+func encodeTypedDataInteger(value interface{}, unsigned bool, bits int) ([]byte, error) {
+	var text string
+	switch number := value.(type) {
+	case json.Number:
+		text = number.String()
+	case string:
+		text = number
+	default:
+		return nil, errp.New("Expected a number.")
+	}
+	integer, ok := new(big.Int).SetString(text, 0)
+	if !ok {
+		return nil, errp.Newf("Invalid number %q.", text)
+	}
+	var min, max *big.Int
+	if unsigned {
+		min = big.NewInt(0)
+		max = new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	} else {
+		max = new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+		min = new(big.Int).Neg(max)
+	}
+	if integer.Cmp(min) < 0 || integer.Cmp(max) >= 0 {
+		return nil, errp.Newf("The number %s does not fit into %d bits.", text, bits)
+	}
+	return math.PaddedBigBytes(math.U256(integer), 32), nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eth
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+// mailTypedData is the example of the EIP-712 specification.
+const mailTypedData = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person"},
+      {"name": "contents", "type": "string"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": 1,
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+    "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+    "contents": "Hello, Bob!"
+  }
+}`
+
+func TestTypedDataHash(t *testing.T) {
+	typedData, err := ParseTypedData([]byte(mailTypedData))
+	require.NoError(t, err)
+	require.Equal(t,
+		"Mail(Person from,Person to,string contents)Person(string name,address wallet)",
+		typedData.encodeType("Mail"))
+
+	domainSeparator, err := typedData.DomainSeparator()
+	require.NoError(t, err)
+	require.Equal(t,
+		"0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f",
+		hexutil.Encode(domainSeparator))
+	messageHash, err := typedData.MessageHash()
+	require.NoError(t, err)
+	require.Equal(t,
+		"0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e",
+		hexutil.Encode(messageHash))
+	require.Equal(t,
+		"0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2",
+		hexutil.Encode(TypedDataSignatureHash(domainSeparator, messageHash)))
+	signatureHash, err := typedData.SignatureHash()
+	require.NoError(t, err)
+	require.Equal(t,
+		"0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2",
+		hexutil.Encode(signatureHash))
+}
+
+func TestTypedDataMeta(t *testing.T) {
+	typedData, err := ParseTypedData([]byte(mailTypedData))
+	require.NoError(t, err)
+	var meta map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(typedData.Meta()), &meta))
+	require.Equal(t, "eth_signTypedData_v4", meta["type"])
+	require.Equal(t, "Mail", meta["primaryType"])
+	require.Equal(t, "Ether Mail", meta["domain"].(map[string]interface{})["name"])
+	require.Equal(t, "Hello, Bob!", meta["message"].(map[string]interface{})["contents"])
+}
+
+func TestParseTypedDataInvalid(t *testing.T) {
+	_, err := ParseTypedData([]byte(`{"types": {"Mail": []}, "primaryType": "Mail"}`))
+	require.Error(t, err)
+	_, err = ParseTypedData([]byte(`{"types": {"EIP712Domain": []}, "primaryType": "Mail"}`))
+	require.Error(t, err)
+
+	typedData, err := ParseTypedData([]byte(mailTypedData))
+	require.NoError(t, err)
+	delete(typedData.Message, "contents")
+	_, err = typedData.MessageHash()
+	require.Error(t, err)
+}
+
+func TestTypedDataEncodeValue(t *testing.T) {
+	typedData := &TypedData{Types: map[string][]TypedDataField{}}
+	encode := func(typeName string, value interface{}) []byte {
+		encoded, err := typedData.encodeValue(typeName, value)
+		require.NoError(t, err)
+		return encoded
+	}
+	fail := func(typeName string, value interface{}) {
+		_, err := typedData.encodeValue(typeName, value)
+		require.Error(t, err)
+	}
+
+	require.Equal(t, common.LeftPadBytes([]byte{1}, 32), encode("bool", true))
+	require.Equal(t, make([]byte, 32), encode("bool", false))
+	require.Equal(t, common.LeftPadBytes([]byte{0xff}, 32), encode("uint8", json.Number("255")))
+	require.Equal(t, common.LeftPadBytes([]byte{0x12, 0x34}, 32), encode("uint256", "0x1234"))
+	require.Equal(t, common.RightPadBytes([]byte{0xab, 0xcd}, 32), encode("bytes2", "0xabcd"))
+	minusOne := encode("int8", json.Number("-1"))
+	for _, b := range minusOne {
+		require.Equal(t, byte(0xff), b)
+	}
+	require.Equal(t,
+		big.NewInt(0).SetBytes(encode("uint256", json.Number("1"))),
+		big.NewInt(1))
+	require.Len(t, encode("uint256[]", []interface{}{json.Number("1"), json.Number("2")}), 32)
+
+	fail("uint8", json.Number("256"))
+	fail("uint8", json.Number("-1"))
+	fail("int8", json.Number("128"))
+	fail("uint7", json.Number("1"))
+	fail("bytes2", "0xab")
+	fail("address", "0x1234")
+	fail("uint256[2]", []interface{}{json.Number("1")})
+	fail("Unknown", "x")
+}
@@ -34,12 +34,81 @@ type Backend struct {
 	BitcoinP2PKHActive       bool `json:"bitcoinP2PKHActive"`
 	BitcoinP2WPKHP2SHActive  bool `json:"bitcoinP2WPKHP2SHActive"`
 	BitcoinP2WPKHActive      bool `json:"bitcoinP2WPKHActive"`
+	BitcoinP2TRActive        bool `json:"bitcoinP2TRActive"`
 	LitecoinP2WPKHP2SHActive bool `json:"litecoinP2WPKHP2SHActive"`
 	LitecoinP2WPKHActive     bool `json:"litecoinP2WPKHActive"`
 	EthereumActive           bool `json:"ethereumActive"`
 
+	// DustLimits are the smallest amounts in satoshi of new outputs which are not considered dust,
+	// by account code.
+	DustLimits map[string]int64 `json:"dustLimits"`
+
+	// GapLimits are the gap limits of the address chains, by account code. Accounts without
+	// configured gap limits use the defaults.
+	GapLimits map[string]GapLimits `json:"gapLimits"`
+
+	// CustomAccounts are accounts at non-standard keypaths, added in expert mode.
+	CustomAccounts []CustomAccount `json:"customAccounts"`
+
+	// MultisigAccounts are multisig accounts shared with other cosigners, added in expert mode.
+	MultisigAccounts []MultisigAccount `json:"multisigAccounts"`
+
+	// WatchOnlyAccounts are accounts without a keystore, added from an extended public key or an
+	// output descriptor.
+	WatchOnlyAccounts []WatchOnlyAccount `json:"watchOnlyAccounts"`
+
+	// ERC20Tokens are the ERC20 tokens added by the user. Each token has an account at the keypath
+	// of the first Ethereum account.
+	ERC20Tokens []ERC20Token `json:"erc20Tokens"`
+
+	// AccountCounts are the numbers of BIP44 accounts per coin and script type, by the root
+	// fingerprint of the seed and then by the code of the first account, so that the accounts of
+	// different seeds are not mixed. The additional accounts are added by the user or found by
+	// account discovery.
+	AccountCounts map[string]map[string]int `json:"seedAccountCounts"`
+
+	// EthereumKeypathScheme is the scheme by which the keypaths of the Ethereum accounts are
+	// derived from their index: "account" for m/44'/60'/{index}'/0/0, as in Ledger Live. Empty
+	// means m/44'/60'/0'/0/{index}, as in most other wallets. The first account is the same in both.
+	EthereumKeypathScheme string `json:"ethereumKeypathScheme"`
+
+	// TxOrdering is the order of the inputs and outputs of new transactions, "bip69" or "random".
+	// Empty means "bip69".
+	TxOrdering string `json:"txOrdering"`
+
+	// BlockExplorers are the URL prefixes of the block explorers in which transactions are opened,
+	// by coin code. The transaction ID is appended to the prefix. Coins without a configured block
+	// explorer use their default one.
+	BlockExplorers map[string]string `json:"blockExplorers"`
+
+	// EthereumRPCs are the URLs of the Ethereum nodes used instead of the default provider, by coin
+	// code. Coins without a configured node use the default one.
+	EthereumRPCs map[string]string `json:"ethereumRPCs"`
+
+	// EthereumIndexers are the URLs of the Blockscout instances from which the transaction history
+	// is fetched instead of Etherscan, by coin code.
+	EthereumIndexers map[string]string `json:"ethereumIndexers"`
+
+	// EthereumRelays are the URLs of the protected relays, e.g. Flashbots Protect, through which
+	// transactions are broadcast instead of the node, by coin code, to keep them out of the public
+	// mempool.
+	EthereumRelays map[string]string `json:"ethereumRelays"`
+
+	// SoftwareKeystoreEnabled allows to use a keystore whose seed is stored encrypted on this
+	// computer instead of a device, e.g. for testnet development or small spending balances.
+	SoftwareKeystoreEnabled bool `json:"softwareKeystoreEnabled"`
+
+	// HWIPath is the path of the HWI executable, with which Trezor, Ledger and Coldcard devices can
+	// be used. Empty disables their support.
+	HWIPath string `json:"hwiPath"`
+
+	// WalletConnectProjectID identifies the app at the WalletConnect relay, which rejects clients
+	// without a project ID.
+	WalletConnectProjectID string `json:"walletConnectProjectID"`
+
 	BTC  CoinConfig `json:"btc"`
 	TBTC CoinConfig `json:"tbtc"`
+	SBTC CoinConfig `json:"sbtc"`
 	LTC  CoinConfig `json:"ltc"`
 	TLTC CoinConfig `json:"tltc"`
 }
@@ -47,23 +116,114 @@ type Backend struct {
 // AccountActive returns the Active setting for a coin by code.
 func (backend Backend) AccountActive(code string) bool {
 	switch code {
-	case "tbtc-p2pkh", "btc-p2pkh", "rbtc-p2pkh":
+	case "tbtc-p2pkh", "sbtc-p2pkh", "btc-p2pkh", "rbtc-p2pkh":
 		return backend.BitcoinP2PKHActive
-	case "tbtc-p2wpkh-p2sh", "btc-p2wpkh-p2sh", "rbtc-p2wpkh-p2sh":
+	case "tbtc-p2wpkh-p2sh", "sbtc-p2wpkh-p2sh", "btc-p2wpkh-p2sh", "rbtc-p2wpkh-p2sh":
 		return backend.BitcoinP2WPKHP2SHActive
-	case "tbtc-p2wpkh", "btc-p2wpkh", "rbtc-p2wpkh":
+	case "tbtc-p2wpkh", "sbtc-p2wpkh", "btc-p2wpkh", "rbtc-p2wpkh":
 		return backend.BitcoinP2WPKHActive
+	case "tbtc-p2tr", "sbtc-p2tr", "btc-p2tr", "rbtc-p2tr":
+		return backend.BitcoinP2TRActive
 	case "tltc-p2wpkh-p2sh", "ltc-p2wpkh-p2sh":
 		return backend.LitecoinP2WPKHP2SHActive
 	case "tltc-p2wpkh", "ltc-p2wpkh":
 		return backend.LitecoinP2WPKHActive
-	case "eth", "teth":
+	case "eth", "sepeth", "holeth", "arbeth", "opeth", "baseeth", "pol", "xdai":
 		return backend.EthereumActive
 	default:
 		panic(fmt.Sprintf("unknown code %s", code))
 	}
 }
 
+// DustLimit returns the dust limit in satoshi configured for the account with the given code, or
+// 0 if none is configured.
+func (backend Backend) DustLimit(code string) int64 {
+	return backend.DustLimits[code]
+}
+
+// GapLimit returns the gap limits configured for the account with the given code. A zero limit
+// means that the default applies.
+func (backend Backend) GapLimit(code string) GapLimits {
+	return backend.GapLimits[code]
+}
+
+// AccountCount returns the number of accounts of the coin and script type of the account with the
+// given code for the seed with the given root fingerprint. There is at least one.
+func (backend Backend) AccountCount(rootFingerprint string, code string) int {
+	if count := backend.AccountCounts[rootFingerprint][code]; count > 1 {
+		return count
+	}
+	return 1
+}
+
+// BlockExplorer returns the URL prefix of the block explorer configured for the coin with the
+// given code, or an empty string if none is configured.
+func (backend Backend) BlockExplorer(coinCode string) string {
+	return backend.BlockExplorers[coinCode]
+}
+
+// EthereumRPC returns the URL of the Ethereum node configured for the coin with the given code, or
+// an empty string if none is configured.
+func (backend Backend) EthereumRPC(coinCode string) string {
+	return backend.EthereumRPCs[coinCode]
+}
+
+// EthereumIndexer returns the URL of the Blockscout instance configured for the coin with the given
+// code, or an empty string if Etherscan is used.
+func (backend Backend) EthereumIndexer(coinCode string) string {
+	return backend.EthereumIndexers[coinCode]
+}
+
+// EthereumRelay returns the URL of the relay configured for the coin with the given code, or an
+// empty string if transactions are broadcast to the node.
+func (backend Backend) EthereumRelay(coinCode string) string {
+	return backend.EthereumRelays[coinCode]
+}
+
+// GapLimits holds the number of consecutive unused addresses of the receive and change address
+// chains of an account.
+type GapLimits struct {
+	Receive int `json:"receive"`
+	Change  int `json:"change"`
+}
+
+// CustomAccount is an account at an arbitrary keypath.
+type CustomAccount struct {
+	Coin       string `json:"coin"`
+	Name       string `json:"name"`
+	Keypath    string `json:"keypath"`
+	ScriptType string `json:"scriptType"`
+}
+
+// MultisigAccount is an m-of-n multisig account spending from P2WSH outputs. The keystore is the
+// first cosigner, the other cosigners are given by their extended public keys at the keypath.
+type MultisigAccount struct {
+	Coin      string   `json:"coin"`
+	Name      string   `json:"name"`
+	Keypath   string   `json:"keypath"`
+	Threshold int      `json:"threshold"`
+	Cosigners []string `json:"cosigners"`
+}
+
+// WatchOnlyAccount is an account of an output descriptor, which cannot sign.
+type WatchOnlyAccount struct {
+	Coin       string `json:"coin"`
+	Name       string `json:"name"`
+	Descriptor string `json:"descriptor"`
+}
+
+// ERC20Token is an ERC20 token contract. The symbol, the decimals and the name are fetched from
+// the contract when the token is added.
+type ERC20Token struct {
+	Coin     string `json:"coin"`
+	Contract string `json:"contract"`
+	Symbol   string `json:"symbol"`
+	Decimals uint   `json:"decimals"`
+	// Name is the name of the token fetched from the contract. It is empty for tokens without a
+	// name and for tokens added before it was stored.
+	Name string `json:"name"`
+}
+
 // AppConfig holds the whole app configuration.
 type AppConfig struct {
 	Backend  Backend     `json:"backend"`
@@ -115,6 +275,7 @@ func NewDefaultConfig() AppConfig {
 			BitcoinP2PKHActive:       false,
 			BitcoinP2WPKHP2SHActive:  true,
 			BitcoinP2WPKHActive:      false,
+			BitcoinP2TRActive:        false,
 			LitecoinP2WPKHP2SHActive: true,
 			LitecoinP2WPKHActive:     false,
 			EthereumActive:           true,
@@ -146,6 +307,15 @@ func NewDefaultConfig() AppConfig {
 					},
 				},
 			},
+			SBTC: CoinConfig{
+				// Signed by a public CA, so no certificate is pinned.
+				ElectrumServers: []*rpc.ServerInfo{
+					{
+						Server: "mempool.space:60602",
+						TLS:    true,
+					},
+				},
+			},
 			LTC: CoinConfig{
 				ElectrumServers: []*rpc.ServerInfo{
 					{
@@ -0,0 +1,130 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// customAccountCode returns the code of a custom account. It is derived from the keypath and the
+// script type, so that it stays the same when other custom accounts are added.
+func customAccountCode(customAccount *config.CustomAccount) string {
+	parts := []string{customAccount.Coin, "custom"}
+	if customAccount.ScriptType != "" {
+		parts = append(parts, customAccount.ScriptType)
+	}
+	keypath := strings.NewReplacer("'", "h", "/", "-").Replace(customAccount.Keypath)
+	return strings.Join(append(parts, keypath), "-")
+}
+
+// CustomAccountCoins returns the coins for which custom accounts can be added, with the supported
+// script types of each coin.
+func (backend *Backend) CustomAccountCoins() map[string][]signing.ScriptType {
+	coins := map[string][]signing.ScriptType{}
+	for _, accountType := range backend.accountTypes() {
+		coinCode := accountType.coin.Code()
+		if _, ok := accountType.coin.(*eth.Coin); ok {
+			// The script type does not apply to Ethereum.
+			coins[coinCode] = []signing.ScriptType{}
+			continue
+		}
+		coins[coinCode] = append(coins[coinCode], accountType.scriptType)
+	}
+	return coins
+}
+
+// checkCustomAccount returns an error if the custom account is not supported.
+func (backend *Backend) checkCustomAccount(customAccount *config.CustomAccount) error {
+	scriptTypes, ok := backend.CustomAccountCoins()[customAccount.Coin]
+	if !ok {
+		return errp.Newf("Custom accounts are not supported for %s.", customAccount.Coin)
+	}
+	if _, err := signing.NewAbsoluteKeypath(customAccount.Keypath); err != nil {
+		return errp.WithMessage(err, "Invalid keypath")
+	}
+	if len(scriptTypes) == 0 {
+		return nil
+	}
+	for _, scriptType := range scriptTypes {
+		if string(scriptType) == customAccount.ScriptType {
+			return nil
+		}
+	}
+	return errp.Newf("The script type %s is not supported for %s.",
+		customAccount.ScriptType, customAccount.Coin)
+}
+
+// addCustomAccounts adds the custom accounts of the config. Custom accounts of coins which are
+// not available, e.g. mainnet coins in testing mode, are skipped.
+func (backend *Backend) addCustomAccounts() {
+	for _, customAccount := range backend.config.Config().Backend.CustomAccounts {
+		customAccount := customAccount
+		code := customAccountCode(&customAccount)
+		if err := backend.checkCustomAccount(&customAccount); err != nil {
+			backend.log.WithField("code", code).WithError(err).Info("skipping custom account")
+			continue
+		}
+		scriptType := signing.ScriptType(customAccount.ScriptType)
+		if scriptType == "" {
+			// Unused, but needed to create the signing configuration.
+			scriptType = signing.ScriptTypeP2WPKH
+		}
+		backend.initAccount(backend.Coin(customAccount.Coin), code, 0, customAccount.Name,
+			customAccount.Keypath, scriptType)
+	}
+}
+
+// AddCustomAccount adds an account of the given coin at an arbitrary keypath. The script type is
+// ignored for Ethereum.
+func (backend *Backend) AddCustomAccount(
+	coinCode string, name string, keypath string, scriptType signing.ScriptType) error {
+	customAccount := config.CustomAccount{
+		Coin:       coinCode,
+		Name:       strings.TrimSpace(name),
+		Keypath:    strings.TrimSpace(keypath),
+		ScriptType: string(scriptType),
+	}
+	if err := backend.checkCustomAccount(&customAccount); err != nil {
+		return err
+	}
+	if _, isETH := backend.Coin(coinCode).(*eth.Coin); isETH {
+		customAccount.ScriptType = ""
+	}
+	if customAccount.Name == "" {
+		customAccount.Name = fmt.Sprintf("%s %s", strings.ToUpper(coinCode), customAccount.Keypath)
+	}
+	appConfig := backend.config.Config()
+	code := customAccountCode(&customAccount)
+	for _, existing := range appConfig.Backend.CustomAccounts {
+		existing := existing
+		if customAccountCode(&existing) == code {
+			return errp.New("The account already exists.")
+		}
+	}
+	customAccounts := append([]config.CustomAccount{}, appConfig.Backend.CustomAccounts...)
+	appConfig.Backend.CustomAccounts = append(customAccounts, customAccount)
+	if err := backend.config.Set(appConfig); err != nil {
+		return err
+	}
+	backend.initAccounts()
+	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+	return nil
+}
@@ -0,0 +1,29 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomAccountCode(t *testing.T) {
+	require.Equal(t, "btc-custom-p2wpkh-m-84h-0h-5h", customAccountCode(&config.CustomAccount{
+		Coin: "btc", Name: "Recovered", Keypath: "m/84'/0'/5'", ScriptType: "p2wpkh"}))
+	require.Equal(t, "eth-custom-m-44h-60h-0h-0-1", customAccountCode(&config.CustomAccount{
+		Coin: "eth", Keypath: "m/44'/60'/0'/0/1"}))
+}
@@ -15,6 +15,7 @@
 package transactionsdb
 
 import (
+	"bytes"
 	"encoding/json"
 	"time"
 
@@ -33,6 +34,10 @@ const (
 	bucketInputs                 = "inputs"
 	bucketOutputs                = "outputs"
 	bucketAddressHistories       = "addressHistories"
+	bucketTxNotes                = "txNotes"
+	bucketAddressLabels          = "addressLabels"
+	bucketOutputLabels           = "outputLabels"
+	bucketFrozenOutputs          = "frozenOutputs"
 )
 
 // DB is a bbolt key/value database.
@@ -75,6 +80,22 @@ func (db *DB) Begin() (transactions.DBTxInterface, error) {
 	if err != nil {
 		return nil, err
 	}
+	bucketTxNotes, err := tx.CreateBucketIfNotExists([]byte(bucketTxNotes))
+	if err != nil {
+		return nil, err
+	}
+	bucketAddressLabels, err := tx.CreateBucketIfNotExists([]byte(bucketAddressLabels))
+	if err != nil {
+		return nil, err
+	}
+	bucketOutputLabels, err := tx.CreateBucketIfNotExists([]byte(bucketOutputLabels))
+	if err != nil {
+		return nil, err
+	}
+	bucketFrozenOutputs, err := tx.CreateBucketIfNotExists([]byte(bucketFrozenOutputs))
+	if err != nil {
+		return nil, err
+	}
 	return &Tx{
 		tx:                           tx,
 		bucketTransactions:           bucketTransactions,
@@ -82,6 +103,10 @@ func (db *DB) Begin() (transactions.DBTxInterface, error) {
 		bucketInputs:                 bucketInputs,
 		bucketOutputs:                bucketOutputs,
 		bucketAddressHistories:       bucketAddressHistories,
+		bucketTxNotes:                bucketTxNotes,
+		bucketAddressLabels:          bucketAddressLabels,
+		bucketOutputLabels:           bucketOutputLabels,
+		bucketFrozenOutputs:          bucketFrozenOutputs,
 	}, nil
 }
 
@@ -99,6 +124,10 @@ type Tx struct {
 	bucketInputs                 *bbolt.Bucket
 	bucketOutputs                *bbolt.Bucket
 	bucketAddressHistories       *bbolt.Bucket
+	bucketTxNotes                *bbolt.Bucket
+	bucketAddressLabels          *bbolt.Bucket
+	bucketOutputLabels           *bbolt.Bucket
+	bucketFrozenOutputs          *bbolt.Bucket
 }
 
 // Rollback implements transactions.DBTxInterface.
@@ -318,3 +347,127 @@ func (tx *Tx) AddressHistory(scriptHashHex blockchain.ScriptHashHex) (blockchain
 	_, err := readJSON(tx.bucketAddressHistories, []byte(string(scriptHashHex)), &history)
 	return history, err
 }
+
+// txNoteKey returns the key of a note, which is the keystore fingerprint followed by the tx hash.
+// ClearTransactions implements transactions.ClearTransactions.
+func (tx *Tx) ClearTransactions() error {
+	buckets := map[string]**bbolt.Bucket{
+		bucketTransactions:           &tx.bucketTransactions,
+		bucketUnverifiedTransactions: &tx.bucketUnverifiedTransactions,
+		bucketInputs:                 &tx.bucketInputs,
+		bucketOutputs:                &tx.bucketOutputs,
+		bucketAddressHistories:       &tx.bucketAddressHistories,
+	}
+	for name, bucket := range buckets {
+		if err := tx.tx.DeleteBucket([]byte(name)); err != nil {
+			return errp.WithStack(err)
+		}
+		newBucket, err := tx.tx.CreateBucket([]byte(name))
+		if err != nil {
+			return errp.WithStack(err)
+		}
+		*bucket = newBucket
+	}
+	return nil
+}
+
+func txNoteKey(keystoreFingerprint string, txHash chainhash.Hash) []byte {
+	return append([]byte(keystoreFingerprint), txHash[:]...)
+}
+
+// PutTxNote implements transactions.DBTxInterface.
+func (tx *Tx) PutTxNote(keystoreFingerprint string, txHash chainhash.Hash, note string) error {
+	key := txNoteKey(keystoreFingerprint, txHash)
+	if note == "" {
+		return tx.bucketTxNotes.Delete(key)
+	}
+	return tx.bucketTxNotes.Put(key, []byte(note))
+}
+
+// TxNote implements transactions.DBTxInterface.
+func (tx *Tx) TxNote(keystoreFingerprint string, txHash chainhash.Hash) (string, error) {
+	return string(tx.bucketTxNotes.Get(txNoteKey(keystoreFingerprint, txHash))), nil
+}
+
+// PutAddressLabel implements transactions.DBTxInterface.
+func (tx *Tx) PutAddressLabel(scriptHashHex blockchain.ScriptHashHex, label string) error {
+	if label == "" {
+		return tx.bucketAddressLabels.Delete([]byte(string(scriptHashHex)))
+	}
+	return tx.bucketAddressLabels.Put([]byte(string(scriptHashHex)), []byte(label))
+}
+
+// AddressLabel implements transactions.DBTxInterface.
+func (tx *Tx) AddressLabel(scriptHashHex blockchain.ScriptHashHex) (string, error) {
+	return string(tx.bucketAddressLabels.Get([]byte(string(scriptHashHex)))), nil
+}
+
+// TxNotes implements transactions.DBTxInterface.
+func (tx *Tx) TxNotes(keystoreFingerprint string) (map[chainhash.Hash]string, error) {
+	notes := map[chainhash.Hash]string{}
+	prefix := []byte(keystoreFingerprint)
+	cursor := tx.bucketTxNotes.Cursor()
+	for key, note := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, note = cursor.Next() {
+		if len(key) != len(prefix)+chainhash.HashSize {
+			// The note belongs to another keystore whose fingerprint starts with this one.
+			continue
+		}
+		txHash, err := chainhash.NewHash(key[len(prefix):])
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		notes[*txHash] = string(note)
+	}
+	return notes, nil
+}
+
+// PutOutputLabel implements transactions.DBTxInterface.
+func (tx *Tx) PutOutputLabel(outPoint wire.OutPoint, label string) error {
+	if label == "" {
+		return tx.bucketOutputLabels.Delete([]byte(outPoint.String()))
+	}
+	return tx.bucketOutputLabels.Put([]byte(outPoint.String()), []byte(label))
+}
+
+// OutputLabel implements transactions.DBTxInterface.
+func (tx *Tx) OutputLabel(outPoint wire.OutPoint) (string, error) {
+	return string(tx.bucketOutputLabels.Get([]byte(outPoint.String()))), nil
+}
+
+// OutputLabels implements transactions.DBTxInterface.
+func (tx *Tx) OutputLabels() (map[wire.OutPoint]string, error) {
+	labels := map[wire.OutPoint]string{}
+	cursor := tx.bucketOutputLabels.Cursor()
+	for outPointBytes, label := cursor.First(); outPointBytes != nil; outPointBytes, label = cursor.Next() {
+		outPoint, err := util.ParseOutPoint(outPointBytes)
+		if err != nil {
+			return nil, err
+		}
+		labels[*outPoint] = string(label)
+	}
+	return labels, nil
+}
+
+// FreezeOutput implements transactions.DBTxInterface.
+func (tx *Tx) FreezeOutput(outPoint wire.OutPoint, reason string) error {
+	return tx.bucketFrozenOutputs.Put([]byte(outPoint.String()), []byte(reason))
+}
+
+// UnfreezeOutput implements transactions.DBTxInterface.
+func (tx *Tx) UnfreezeOutput(outPoint wire.OutPoint) error {
+	return tx.bucketFrozenOutputs.Delete([]byte(outPoint.String()))
+}
+
+// FrozenOutputs implements transactions.DBTxInterface.
+func (tx *Tx) FrozenOutputs() (map[wire.OutPoint]string, error) {
+	frozenOutputs := map[wire.OutPoint]string{}
+	cursor := tx.bucketFrozenOutputs.Cursor()
+	for outPointBytes, reason := cursor.First(); outPointBytes != nil; outPointBytes, reason = cursor.Next() {
+		outPoint, err := util.ParseOutPoint(outPointBytes)
+		if err != nil {
+			return nil, err
+		}
+		frozenOutputs[*outPoint] = string(reason)
+	}
+	return frozenOutputs, nil
+}
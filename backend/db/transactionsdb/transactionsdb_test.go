@@ -0,0 +1,201 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transactionsdb_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/db/transactionsdb"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxNotes(t *testing.T) {
+	db, err := transactionsdb.NewDB(test.TstTempFile("bitbox-wallet-db-"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+	txHash := chainhash.HashH([]byte("tx"))
+
+	dbTx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, dbTx.PutTx(txHash, nil, 10))
+	require.NoError(t, dbTx.PutTxNote("01020304", txHash, "rent"))
+	require.NoError(t, dbTx.Commit())
+
+	dbTx, err = db.Begin()
+	require.NoError(t, err)
+	note, err := dbTx.TxNote("01020304", txHash)
+	require.NoError(t, err)
+	require.Equal(t, "rent", note)
+	// Notes are separate per keystore.
+	note, err = dbTx.TxNote("05060708", txHash)
+	require.NoError(t, err)
+	require.Equal(t, "", note)
+	notes, err := dbTx.TxNotes("01020304")
+	require.NoError(t, err)
+	require.Equal(t, map[chainhash.Hash]string{txHash: "rent"}, notes)
+	// A fingerprint which is a prefix of another one does not match its notes.
+	notes, err = dbTx.TxNotes("0102")
+	require.NoError(t, err)
+	require.Empty(t, notes)
+	// Notes are kept if the transaction is deleted, e.g. in a reorg.
+	dbTx.DeleteTx(txHash)
+	note, err = dbTx.TxNote("01020304", txHash)
+	require.NoError(t, err)
+	require.Equal(t, "rent", note)
+	require.NoError(t, dbTx.PutTxNote("01020304", txHash, ""))
+	note, err = dbTx.TxNote("01020304", txHash)
+	require.NoError(t, err)
+	require.Equal(t, "", note)
+	dbTx.Rollback()
+}
+
+func TestAddressLabels(t *testing.T) {
+	db, err := transactionsdb.NewDB(test.TstTempFile("bitbox-wallet-db-"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+	scriptHashHex := blockchain.ScriptHashHex("0102")
+
+	dbTx, err := db.Begin()
+	require.NoError(t, err)
+	defer dbTx.Rollback()
+	label, err := dbTx.AddressLabel(scriptHashHex)
+	require.NoError(t, err)
+	require.Equal(t, "", label)
+	require.NoError(t, dbTx.PutAddressLabel(scriptHashHex, "Alice"))
+	label, err = dbTx.AddressLabel(scriptHashHex)
+	require.NoError(t, err)
+	require.Equal(t, "Alice", label)
+	require.NoError(t, dbTx.PutAddressLabel(scriptHashHex, ""))
+	label, err = dbTx.AddressLabel(scriptHashHex)
+	require.NoError(t, err)
+	require.Equal(t, "", label)
+}
+
+func TestOutputLabels(t *testing.T) {
+	db, err := transactionsdb.NewDB(test.TstTempFile("bitbox-wallet-db-"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+	outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte("tx")), Index: 1}
+
+	dbTx, err := db.Begin()
+	require.NoError(t, err)
+	defer dbTx.Rollback()
+	label, err := dbTx.OutputLabel(outPoint)
+	require.NoError(t, err)
+	require.Equal(t, "", label)
+	require.NoError(t, dbTx.PutOutputLabel(outPoint, "KYC-free"))
+	label, err = dbTx.OutputLabel(outPoint)
+	require.NoError(t, err)
+	require.Equal(t, "KYC-free", label)
+	labels, err := dbTx.OutputLabels()
+	require.NoError(t, err)
+	require.Equal(t, map[wire.OutPoint]string{outPoint: "KYC-free"}, labels)
+	require.NoError(t, dbTx.PutOutputLabel(outPoint, ""))
+	labels, err = dbTx.OutputLabels()
+	require.NoError(t, err)
+	require.Empty(t, labels)
+}
+
+func TestFrozenOutputs(t *testing.T) {
+	db, err := transactionsdb.NewDB(test.TstTempFile("bitbox-wallet-db-"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+	outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte("tx")), Index: 1}
+	otherOutPoint := wire.OutPoint{Hash: chainhash.HashH([]byte("tx")), Index: 2}
+
+	dbTx, err := db.Begin()
+	require.NoError(t, err)
+	defer dbTx.Rollback()
+	frozenOutputs, err := dbTx.FrozenOutputs()
+	require.NoError(t, err)
+	require.Empty(t, frozenOutputs)
+	require.NoError(t, dbTx.FreezeOutput(outPoint, "dust attack"))
+	// Outputs can be frozen without a reason.
+	require.NoError(t, dbTx.FreezeOutput(otherOutPoint, ""))
+	frozenOutputs, err = dbTx.FrozenOutputs()
+	require.NoError(t, err)
+	require.Equal(t, map[wire.OutPoint]string{outPoint: "dust attack", otherOutPoint: ""}, frozenOutputs)
+	require.NoError(t, dbTx.UnfreezeOutput(otherOutPoint))
+	require.NoError(t, dbTx.UnfreezeOutput(otherOutPoint))
+	frozenOutputs, err = dbTx.FrozenOutputs()
+	require.NoError(t, err)
+	require.Equal(t, map[wire.OutPoint]string{outPoint: "dust attack"}, frozenOutputs)
+}
+
+func TestClearTransactions(t *testing.T) {
+	db, err := transactionsdb.NewDB(test.TstTempFile("bitbox-wallet-db-"))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, db.Close()) }()
+	txHash := chainhash.HashH([]byte("tx"))
+	outPoint := wire.OutPoint{Hash: txHash, Index: 0}
+	scriptHashHex := blockchain.ScriptHashHex("0102")
+
+	dbTx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, dbTx.PutTx(txHash, nil, 0))
+	require.NoError(t, dbTx.PutOutput(outPoint, wire.NewTxOut(1000, []byte{0x51})))
+	require.NoError(t, dbTx.PutInput(outPoint, txHash))
+	require.NoError(t, dbTx.PutAddressHistory(scriptHashHex,
+		blockchain.TxHistory{{TXHash: blockchain.TXHash(txHash), Height: 10}}))
+	require.NoError(t, dbTx.PutTxNote("01020304", txHash, "rent"))
+	require.NoError(t, dbTx.PutAddressLabel(scriptHashHex, "Alice"))
+	require.NoError(t, dbTx.PutOutputLabel(outPoint, "KYC-free"))
+	require.NoError(t, dbTx.FreezeOutput(outPoint, "dust attack"))
+	require.NoError(t, dbTx.Commit())
+
+	dbTx, err = db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, dbTx.ClearTransactions())
+	// The cleared buckets can be written to in the same transaction.
+	require.NoError(t, dbTx.PutOutput(outPoint, wire.NewTxOut(1000, []byte{0x51})))
+	dbTx.DeleteOutput(outPoint)
+	require.NoError(t, dbTx.Commit())
+
+	dbTx, err = db.Begin()
+	require.NoError(t, err)
+	defer dbTx.Rollback()
+	transactions, err := dbTx.Transactions()
+	require.NoError(t, err)
+	require.Empty(t, transactions)
+	unverifiedTransactions, err := dbTx.UnverifiedTransactions()
+	require.NoError(t, err)
+	require.Empty(t, unverifiedTransactions)
+	outputs, err := dbTx.Outputs()
+	require.NoError(t, err)
+	require.Empty(t, outputs)
+	input, err := dbTx.Input(outPoint)
+	require.NoError(t, err)
+	require.Nil(t, input)
+	history, err := dbTx.AddressHistory(scriptHashHex)
+	require.NoError(t, err)
+	require.Empty(t, history)
+
+	note, err := dbTx.TxNote("01020304", txHash)
+	require.NoError(t, err)
+	require.Equal(t, "rent", note)
+	label, err := dbTx.AddressLabel(scriptHashHex)
+	require.NoError(t, err)
+	require.Equal(t, "Alice", label)
+	label, err = dbTx.OutputLabel(outPoint)
+	require.NoError(t, err)
+	require.Equal(t, "KYC-free", label)
+	frozenOutputs, err := dbTx.FrozenOutputs()
+	require.NoError(t, err)
+	require.Equal(t, map[wire.OutPoint]string{outPoint: "dust attack"}, frozenOutputs)
+}
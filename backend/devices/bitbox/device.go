@@ -17,6 +17,7 @@ package bitbox
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha512"
 	"encoding/hex"
 	"encoding/json"
@@ -142,6 +143,16 @@ type Device struct {
 	// Indicates whether Close was called.
 	closed bool
 
+	xpubCacheMu sync.Mutex
+	// xpubCache maps encoded keypaths to the xpubs returned by the device. It is cleared whenever
+	// the wallet on the device might have changed and when the device is closed.
+	xpubCache map[string]*hdkeychain.ExtendedKey
+
+	abandonedCommandMu sync.Mutex
+	// abandonedCommand is closed once the device replied to the command which sendContext stopped
+	// waiting for. It is nil if no command was abandoned.
+	abandonedCommand chan struct{}
+
 	log *logrus.Entry
 }
 
@@ -287,6 +298,7 @@ func (dbb *Device) Close() {
 	dbb.log.WithFields(logrus.Fields{"deviceID": dbb.deviceID}).Debug("Close connection")
 	dbb.communication.Close()
 	dbb.closed = true
+	dbb.resetXPubCache()
 }
 
 func (dbb *Device) sendPlain(key, val string) (map[string]interface{}, error) {
@@ -298,6 +310,13 @@ func (dbb *Device) sendPlain(key, val string) (map[string]interface{}, error) {
 }
 
 func (dbb *Device) send(value interface{}, pin string) (map[string]interface{}, error) {
+	// Cannot fail, as the context is never done.
+	_ = dbb.waitForAbandonedCommand(context.Background())
+	return dbb.sendEncrypt(value, pin)
+}
+
+// sendEncrypt sends the command to the device without waiting for an abandoned command.
+func (dbb *Device) sendEncrypt(value interface{}, pin string) (map[string]interface{}, error) {
 	return dbb.communication.SendEncrypt(string(jsonp.MustMarshal(value)), pin)
 }
 
@@ -305,6 +324,61 @@ func (dbb *Device) sendKV(key, value, pin string) (map[string]interface{}, error
 	return dbb.send(map[string]string{key: value}, pin)
 }
 
+// sendContext is like send, but stops waiting for the reply and returns the error of the context
+// as soon as it is done. The device cannot be aborted: it keeps processing the abandoned command
+// until the user confirms or rejects it on the device, and its reply is discarded. The next
+// command is only sent once the device replied to the abandoned one, so that the late reply cannot
+// be mistaken for the reply to the next command.
+func (dbb *Device) sendContext(
+	ctx context.Context, value interface{}, pin string) (map[string]interface{}, error) {
+	if err := dbb.waitForAbandonedCommand(ctx); err != nil {
+		return nil, err
+	}
+	type result struct {
+		reply map[string]interface{}
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		reply, err := dbb.sendEncrypt(value, pin)
+		done <- result{reply: reply, err: err}
+	}()
+	select {
+	case result := <-done:
+		return result.reply, result.err
+	case <-ctx.Done():
+		dbb.log.WithError(ctx.Err()).Info("Stopped waiting for the device")
+		abandonedCommand := make(chan struct{})
+		dbb.abandonedCommandMu.Lock()
+		dbb.abandonedCommand = abandonedCommand
+		dbb.abandonedCommandMu.Unlock()
+		go func() {
+			result := <-done
+			dbb.log.WithError(result.err).Info("Discarded the reply to the abandoned command")
+			close(abandonedCommand)
+		}()
+		return nil, errp.WithStack(ctx.Err())
+	}
+}
+
+// waitForAbandonedCommand waits until the device replied to the command which sendContext stopped
+// waiting for, if any, or until the context is done.
+func (dbb *Device) waitForAbandonedCommand(ctx context.Context) error {
+	dbb.abandonedCommandMu.Lock()
+	abandonedCommand := dbb.abandonedCommand
+	dbb.abandonedCommandMu.Unlock()
+	if abandonedCommand != nil {
+		select {
+		case <-abandonedCommand:
+		case <-ctx.Done():
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return errp.WithStack(err)
+	}
+	return nil
+}
+
 func (dbb *Device) deviceInfo(pin string) (*DeviceInfo, error) {
 	if dbb.bootloaderStatus != nil {
 		return nil, errp.WithStack(errNoBootloader)
@@ -484,6 +558,8 @@ func (dbb *Device) Login(pin string) (bool, string, error) {
 	}
 	dbb.pin = pin
 	dbb.seeded = deviceInfo.Seeded
+	// Logging in with the hidden PIN unlocks a different wallet.
+	dbb.resetXPubCache()
 	dbb.onStatusChanged()
 
 	dbb.log.Debug("Authentication successful")
@@ -527,6 +603,7 @@ func (dbb *Device) seed(pin, backupPassword, source, filename string) error {
 		return errp.New("invalid password")
 	}
 	dbb.log.WithFields(logrus.Fields{"source": source, "filename": filename}).Debug("Seed")
+	defer dbb.resetXPubCache()
 	key := stretchKey(backupPassword)
 	reply, err := dbb.send(
 		map[string]interface{}{
@@ -778,18 +855,19 @@ func (dbb *Device) Reset(pin string) (bool, error) {
 	dbb.pin = ""
 	dbb.seeded = false
 	dbb.initialized = false
+	dbb.resetXPubCache()
 	dbb.onStatusChanged()
 	return true, nil
 }
 
 // xpub returns the extended publickey at the path.
-func (dbb *Device) xpub(path string) (*hdkeychain.ExtendedKey, error) {
+func (dbb *Device) xpub(ctx context.Context, path string) (*hdkeychain.ExtendedKey, error) {
 	if dbb.bootloaderStatus != nil {
 		return nil, errp.WithStack(errNoBootloader)
 	}
 	dbb.log.WithField("path", path).Info("XPub")
 	getXPub := func() (*hdkeychain.ExtendedKey, error) {
-		reply, err := dbb.sendKV("xpub", path, dbb.pin)
+		reply, err := dbb.sendContext(ctx, map[string]string{"xpub": path}, dbb.pin)
 		if err != nil {
 			return nil, err
 		}
@@ -815,6 +893,31 @@ func (dbb *Device) xpub(path string) (*hdkeychain.ExtendedKey, error) {
 	return xpub1, nil
 }
 
+// cachedXPub returns the extended public key at the path. The device is only queried if the
+// xpub is not yet cached.
+func (dbb *Device) cachedXPub(ctx context.Context, path string) (*hdkeychain.ExtendedKey, error) {
+	dbb.xpubCacheMu.Lock()
+	defer dbb.xpubCacheMu.Unlock()
+	if xpub, ok := dbb.xpubCache[path]; ok {
+		return xpub, nil
+	}
+	xpub, err := dbb.xpub(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if dbb.xpubCache == nil {
+		dbb.xpubCache = map[string]*hdkeychain.ExtendedKey{}
+	}
+	dbb.xpubCache[path] = xpub
+	return xpub, nil
+}
+
+func (dbb *Device) resetXPubCache() {
+	dbb.xpubCacheMu.Lock()
+	defer dbb.xpubCacheMu.Unlock()
+	dbb.xpubCache = nil
+}
+
 // Random generates a 16 byte random number, hex encoded. typ can be either "true" or "pseudo".
 func (dbb *Device) Random(typ string) (string, error) {
 	if dbb.bootloaderStatus != nil {
@@ -968,9 +1071,12 @@ func (dbb *Device) LockBootloader() error {
 }
 
 // Signs a batch of at most 15 signatures. The method returns signatures for the provided hashes.
-// The private keys used to sign them are derived using the provided keyPaths.
+// The private keys used to sign them are derived using the provided keyPaths. If meta is not
+// empty, it is passed to the device, unless it is derived from the txProposal.
 func (dbb *Device) signBatch(
+	ctx context.Context,
 	txProposal *maketx.TxProposal,
+	meta string,
 	signatureHashes [][]byte,
 	keyPaths []string,
 	locked bool,
@@ -1001,6 +1107,10 @@ func (dbb *Device) signBatch(
 		},
 	}
 
+	if meta != "" {
+		command["sign"]["meta"] = meta
+	}
+
 	var transaction string
 	if txProposal != nil {
 		buffer := new(bytes.Buffer)
@@ -1023,7 +1133,7 @@ func (dbb *Device) signBatch(
 	}
 
 	// First call returns the echo.
-	echo, err := dbb.send(command, dbb.pin)
+	echo, err := dbb.sendContext(ctx, command, dbb.pin)
 	if err != nil {
 		return nil, errp.WithMessage(err, "Failed to sign batch (1)")
 	}
@@ -1049,7 +1159,7 @@ func (dbb *Device) signBatch(
 		if dbb.channel == nil {
 			return nil, errp.New("Signing failed because the device is locked but not paired.")
 		}
-		nonce, err = dbb.channel.WaitForSigningPin(2 * time.Minute)
+		nonce, err = dbb.waitForSigningPin(ctx)
 		if err != nil {
 			return nil, errp.WithMessage(err, "waiting for signing pin failed")
 		}
@@ -1074,13 +1184,29 @@ func (dbb *Device) signBatch(
 	command2 := map[string]interface{}{
 		"sign": pin,
 	}
-	reply, err := dbb.send(command2, dbb.pin)
+	reply, err := dbb.sendContext(ctx, command2, dbb.pin)
 	if err != nil {
 		return nil, errp.WithMessage(err, "Failed to sign batch (2)")
 	}
 	return reply, nil
 }
 
+// waitForSigningPin waits for up to two minutes for the signing "PIN"/nonce from the paired
+// mobile, or until the context is done. In the latter case, it returns only once the pending pull
+// from the relay server returned, so that no waiter is left behind which could consume the PIN of
+// the next signing.
+func (dbb *Device) waitForSigningPin(ctx context.Context) (string, error) {
+	nonce, err := dbb.channel.WaitForSigningPin(ctx, 2*time.Minute)
+	if err != nil && ctx.Err() != nil {
+		// Do not leave the abandoned transaction on the screen of the mobile.
+		if err := dbb.channel.SendClear(); err != nil {
+			dbb.log.WithError(err).Warning("Could not clear the screen of the mobile.")
+		}
+		return "", errp.WithStack(ctx.Err())
+	}
+	return nonce, err
+}
+
 // SignatureWithRecID also contains the recoverable ID, with which one can more efficiently recover
 // the public key.
 type SignatureWithRecID struct {
@@ -1094,6 +1220,31 @@ func (dbb *Device) Sign(
 	txProposal *maketx.TxProposal,
 	signatureHashes [][]byte,
 	keyPaths []string,
+) ([]SignatureWithRecID, error) {
+	return dbb.SignContext(context.Background(), txProposal, signatureHashes, keyPaths)
+}
+
+// SignContext is like Sign, but stops waiting for the device and returns the error of the context
+// when it is done. The device cannot be aborted from the app: the user still has to confirm or
+// reject the signing on the device (or the mobile), and the signatures are then discarded. Further
+// commands are only sent to the device afterwards.
+func (dbb *Device) SignContext(
+	ctx context.Context,
+	txProposal *maketx.TxProposal,
+	signatureHashes [][]byte,
+	keyPaths []string,
+) ([]SignatureWithRecID, error) {
+	return dbb.sign(ctx, txProposal, "", signatureHashes, keyPaths)
+}
+
+// sign is like SignContext. If meta is not empty, it is passed to the device, e.g. to describe a
+// token transfer to the user.
+func (dbb *Device) sign(
+	ctx context.Context,
+	txProposal *maketx.TxProposal,
+	meta string,
+	signatureHashes [][]byte,
+	keyPaths []string,
 ) ([]SignatureWithRecID, error) {
 	if dbb.bootloaderStatus != nil {
 		return nil, errp.WithStack(errNoBootloader)
@@ -1109,6 +1260,9 @@ func (dbb *Device) Sign(
 		panic("non-empty list of signature hashes and keypaths expected")
 	}
 
+	if err := dbb.waitForAbandonedCommand(ctx); err != nil {
+		return nil, err
+	}
 	deviceInfo, err := dbb.DeviceInfo()
 	if err != nil {
 		dbb.log.WithError(err).Error("Failed to load the device info for signing.")
@@ -1133,7 +1287,9 @@ func (dbb *Device) Sign(
 			Steps: steps,
 		})
 		reply, err := dbb.signBatch(
+			ctx,
 			txProposal,
+			meta,
 			signatureHashes[i:upper],
 			keyPaths[i:upper],
 			deviceInfo.Lock,
@@ -1183,7 +1339,7 @@ func (dbb *Device) Sign(
 }
 
 // displayAddress triggers the display of the address at the given key path.
-func (dbb *Device) displayAddress(keyPath string, typ string) error {
+func (dbb *Device) displayAddress(ctx context.Context, keyPath string, typ string) error {
 	if dbb.bootloaderStatus != nil {
 		return errp.WithStack(errNoBootloader)
 	}
@@ -1191,7 +1347,10 @@ func (dbb *Device) displayAddress(keyPath string, typ string) error {
 		dbb.log.Debug("The address is not displayed because no pairing was found.")
 		return nil
 	}
-	reply, err := dbb.sendKV("xpub", keyPath, dbb.pin)
+	reply, err := dbb.sendContext(ctx, map[string]string{"xpub": keyPath}, dbb.pin)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return errp.WithStack(ctxErr)
+	}
 	if err != nil {
 		dbb.log.WithError(err).Error("Could not retrieve the xpub from the BitBox.")
 		return nil
@@ -1208,6 +1367,36 @@ func (dbb *Device) displayAddress(keyPath string, typ string) error {
 	return nil
 }
 
+// displayAddresses sends the xpub echos of all keypaths to the paired mobile, which displays the
+// addresses one after another. The device has no command for several keypaths, so there is one
+// xpub request and one echo per keypath. The mobile only displays the echos, so the user cannot
+// reject an individual address.
+func (dbb *Device) displayAddresses(ctx context.Context, keyPaths []string, typ string) error {
+	if dbb.bootloaderStatus != nil {
+		return errp.WithStack(errNoBootloader)
+	}
+	if dbb.channel == nil {
+		return errp.New("The addresses cannot be displayed because no pairing was found.")
+	}
+	for _, keyPath := range keyPaths {
+		reply, err := dbb.sendContext(ctx, map[string]string{"xpub": keyPath}, dbb.pin)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return errp.WithStack(ctxErr)
+		}
+		if err != nil {
+			return errp.WithMessage(err, "Could not retrieve the xpub from the BitBox.")
+		}
+		xpubEcho, ok := reply["echo"].(string)
+		if !ok {
+			return errp.New("The echo from the BitBox to display the address is not a string.")
+		}
+		if err := dbb.channel.SendXpubEcho(xpubEcho, typ); err != nil {
+			return errp.WithMessage(err, "Sending the xpub echo to the mobile failed.")
+		}
+	}
+	return nil
+}
+
 // ecdhPKhash passes the hash of the ECDH public key of the mobile to the device and returns its response.
 func (dbb *Device) ecdhPKhash(mobileECDHPKhash string) (interface{}, error) {
 	if dbb.bootloaderStatus != nil {
@@ -1344,23 +1533,29 @@ func (dbb *Device) Identifier() string {
 
 // ExtendedPublicKey implements device.Interface.
 func (dbb *Device) ExtendedPublicKey(keypath signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
-	return dbb.xpub(keypath.Encode())
+	return dbb.cachedXPub(context.Background(), keypath.Encode())
 }
 
 // KeystoreForConfiguration implements device.Interface.
 func (dbb *Device) KeystoreForConfiguration(
 	configuration *signing.Configuration,
 	cosignerIndex int,
-) keystoreInterface.Keystore {
+) (keystoreInterface.Keystore, error) {
 	if dbb.Status() != StatusSeeded {
-		return nil
+		return nil, nil
 	}
-	return &keystore{
+	keystore := &keystore{
 		dbb:           dbb,
 		configuration: configuration,
 		cosignerIndex: cosignerIndex,
 		log:           dbb.log,
 	}
+	if configuration != nil {
+		if err := keystoreInterface.ValidateCosigner(keystore, configuration); err != nil {
+			return nil, err
+		}
+	}
+	return keystore, nil
 }
 
 // Lock locks the device for 2FA. Returns true if successful and false if aborted by the user.
@@ -15,17 +15,21 @@
 package bitbox
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
 
-	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
 	keystorePkg "github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/sirupsen/logrus"
 )
 
@@ -46,6 +50,45 @@ func (keystore *keystore) CosignerIndex() int {
 	return keystore.cosignerIndex
 }
 
+// SupportsCoin implements keystore.Keystore.
+func (keystore *keystore) SupportsCoin(coin coin.Coin) bool {
+	switch coin.(type) {
+	case *btc.Coin, *eth.Coin:
+		return true
+	default:
+		return false
+	}
+}
+
+// SupportsScriptType implements keystore.Keystore. Taproot is not supported, as the BitBox only
+// creates ECDSA signatures.
+func (keystore *keystore) SupportsScriptType(coin coin.Coin, scriptType signing.ScriptType) bool {
+	switch coin.(type) {
+	case *btc.Coin:
+		switch scriptType {
+		case signing.ScriptTypeP2PKH, signing.ScriptTypeP2WPKHP2SH, signing.ScriptTypeP2WPKH:
+			return true
+		default:
+			return false
+		}
+	case *eth.Coin:
+		return true
+	default:
+		return false
+	}
+}
+
+// SupportsAccount implements keystore.Keystore.
+func (keystore *keystore) SupportsAccount(
+	coin coin.Coin, scriptType signing.ScriptType, multisig bool) bool {
+	if multisig {
+		// Multisig inputs are signed by their signature hash on all firmware versions.
+		_, ok := coin.(*btc.Coin)
+		return ok
+	}
+	return keystore.SupportsScriptType(coin, scriptType)
+}
+
 // HasSecureOutput implements keystore.Keystore.
 func (keystore *keystore) HasSecureOutput() bool {
 	return keystore.dbb.channel != nil
@@ -53,80 +96,134 @@ func (keystore *keystore) HasSecureOutput() bool {
 
 // OutputAddress implements keystore.Keystore.
 func (keystore *keystore) OutputAddress(
+	keyPath signing.AbsoluteKeypath, scriptType signing.ScriptType, coin coin.Coin) error {
+	return keystore.OutputAddressContext(context.Background(), keyPath, scriptType, coin)
+}
+
+// OutputAddressContext implements keystore.ContextKeystore.
+func (keystore *keystore) OutputAddressContext(ctx context.Context,
 	keyPath signing.AbsoluteKeypath, scriptType signing.ScriptType, coin coin.Coin) error {
 	if !keystore.HasSecureOutput() {
 		panic("HasSecureOutput must be true")
 	}
-	return keystore.dbb.displayAddress(keyPath.Encode(), fmt.Sprintf("%s-%s", coin.Code(), string(scriptType)))
+	return keystore.dbb.displayAddress(ctx, keyPath.Encode(), fmt.Sprintf("%s-%s", coin.Code(), string(scriptType)))
+}
+
+// BatchOutputAddress implements keystore.Keystore. The addresses are sent to the paired mobile one
+// after another, see displayAddresses. As the mobile cannot reject an address, this never returns
+// an *OutputAddressAbortedError.
+func (keystore *keystore) BatchOutputAddress(
+	keyPaths []signing.AbsoluteKeypath, scriptType signing.ScriptType, coin coin.Coin) error {
+	if !keystore.HasSecureOutput() {
+		return errp.New("The BitBox is not paired with a mobile to securely output the addresses.")
+	}
+	encodedKeyPaths := make([]string, len(keyPaths))
+	for index, keyPath := range keyPaths {
+		encodedKeyPaths[index] = keyPath.Encode()
+	}
+	return keystore.dbb.displayAddresses(context.Background(),
+		encodedKeyPaths, fmt.Sprintf("%s-%s", coin.Code(), string(scriptType)))
 }
 
 // ExtendedPublicKey implements keystore.Keystore.
 func (keystore *keystore) ExtendedPublicKey(
 	keyPath signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
-	return keystore.dbb.xpub(keyPath.Encode())
+	return keystore.ExtendedPublicKeyContext(context.Background(), keyPath)
 }
 
-func (keystore *keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransaction) error {
+// ExtendedPublicKeyContext implements keystore.ContextKeystore.
+func (keystore *keystore) ExtendedPublicKeyContext(
+	ctx context.Context, keyPath signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
+	return keystore.dbb.cachedXPub(ctx, keyPath.Encode())
+}
+
+// RootFingerprint implements keystore.Keystore. The master key is cached by the device.
+func (keystore *keystore) RootFingerprint() ([]byte, error) {
+	return keystorePkg.RootFingerprint(keystore)
+}
+
+func (keystore *keystore) signBTCTransaction(
+	ctx context.Context, btcProposedTx *btc.ProposedTransaction) error {
 	keystore.log.Info("Sign btc transaction")
-	signatureHashes := [][]byte{}
-	keyPaths := []string{}
+	inputSignatureHashes, err := btcProposedTx.SignatureHashes()
+	if err != nil {
+		return err
+	}
+	signatureHashes := make([][]byte, 0, len(inputSignatureHashes))
+	keyPaths := make([]string, 0, len(inputSignatureHashes))
+	// inputIndices are the indices of the signed inputs, as external inputs are skipped.
+	inputIndices := make([]int, 0, len(inputSignatureHashes))
 	transaction := btcProposedTx.TXProposal.Transaction
 	for index, txIn := range transaction.TxIn {
-		spentOutput, ok := btcProposedTx.PreviousOutputs[txIn.PreviousOutPoint]
-		if !ok {
-			keystore.log.Panic("There needs to be exactly one output being spent per input!")
+		inputSignatureHash := inputSignatureHashes[index]
+		if inputSignatureHash == nil {
+			continue
 		}
-		address := btcProposedTx.GetAddress(spentOutput.ScriptHashHex())
-		isSegwit, subScript := address.ScriptForHashToSign()
-		var signatureHash []byte
-		if isSegwit {
-			var err error
-			signatureHash, err = txscript.CalcWitnessSigHash(subScript, btcProposedTx.SigHashes,
-				txscript.SigHashAll, transaction, index, spentOutput.Value)
-			if err != nil {
-				return errp.Wrap(err, "Failed to calculate SegWit signature hash")
-			}
-			keystore.log.Debug("Calculated segwit signature hash")
-		} else {
-			var err error
-			signatureHash, err = txscript.CalcSignatureHash(
-				subScript, txscript.SigHashAll, transaction, index)
-			if err != nil {
-				return errp.Wrap(err, "Failed to calculate legacy signature hash")
-			}
-			keystore.log.Debug("Calculated legacy signature hash")
+		// In a multisig input, only the signature of this cosigner is added. The signatures of the
+		// other cosigners are added by their keystores or combined later, e.g. in a PSBT.
+		numberOfSigners := inputSignatureHash.Address.Configuration.NumberOfSigners()
+		if keystore.CosignerIndex() >= numberOfSigners {
+			return errp.Newf("The keystore is cosigner %d, but input %d has only %d signers.",
+				keystore.CosignerIndex(), index, numberOfSigners)
 		}
-
-		signatureHashes = append(signatureHashes, signatureHash)
-		keyPaths = append(keyPaths, address.Configuration.AbsoluteKeypath().Encode())
+		inputIndices = append(inputIndices, index)
+		signatureHashes = append(signatureHashes, inputSignatureHash.Hash)
+		keyPaths = append(keyPaths, inputSignatureHash.Address.Configuration.AbsoluteKeypath().Encode())
 
 		// Special serialization of the unsigned transaction for the mobile verification app.
-		txIn.SignatureScript = subScript
+		txIn.SignatureScript = inputSignatureHash.SubScript
 	}
 
-	signatures, err := keystore.dbb.Sign(btcProposedTx.TXProposal, signatureHashes, keyPaths)
+	signatures, err := keystore.dbb.SignContext(ctx, btcProposedTx.TXProposal, signatureHashes, keyPaths)
 	if isErrorAbort(err) {
 		return errp.WithStack(keystorePkg.ErrSigningAborted)
 	}
 	if err != nil {
 		return errp.WithMessage(err, "Failed to sign signature hash")
 	}
-	if len(signatures) != len(transaction.TxIn) {
-		panic("number of signatures doesn't match number of inputs")
+	if len(signatures) != len(inputIndices) {
+		return errp.WithMessage(errp.WithStack(keystorePkg.ErrSignatureCountMismatch),
+			fmt.Sprintf("Expected %d signatures, got %d", len(inputIndices), len(signatures)))
 	}
+	btcProposedTx.ReportProgress(btc.SignProgress{
+		Stage:      btc.SignStageReceivingSignatures,
+		Signatures: len(signatures),
+		Inputs:     len(inputIndices),
+	})
 	for i, signature := range signatures {
 		signature := signature
-		btcProposedTx.Signatures[i][keystore.CosignerIndex()] = &signature.Signature
+		// Check the signature, so that an invalid signature of the device is never broadcast.
+		configuration := inputSignatureHashes[inputIndices[i]].Address.Configuration
+		if !signature.Verify(signatureHashes[i], configuration.PublicKeys()[keystore.CosignerIndex()]) {
+			return errp.Newf("The signature of input %d is invalid.", inputIndices[i])
+		}
+		btcProposedTx.Signatures[inputIndices[i]][keystore.CosignerIndex()] = &signature.Signature
 	}
 	return nil
 }
 
-func (keystore *keystore) signETHTransaction(txProposal *eth.TxProposal) error {
-	signatureHashes := [][]byte{
-		txProposal.Signer.Hash(txProposal.Tx).Bytes(),
+func (keystore *keystore) signETHTransaction(ctx context.Context, txProposal *eth.TxProposal) error {
+	var signatureHash []byte
+	switch txProposal.Type() {
+	case eth.DynamicFeeTxType:
+		hash, err := txProposal.DynamicFeeTx.SigHash()
+		if err != nil {
+			return err
+		}
+		signatureHash = hash.Bytes()
+	default:
+		signatureHash = txProposal.Signer.Hash(txProposal.Tx).Bytes()
+	}
+	var meta string
+	if txProposal.Token != nil {
+		if err := txProposal.Token.Validate(txProposal.To(), txProposal.Data()); err != nil {
+			return err
+		}
+		keystore.log.WithField("token-transfer", txProposal.Token.Description()).Info("Sign token transfer")
+		meta = txProposal.Token.Meta()
 	}
-	_ = signatureHashes
-	signatures, err := keystore.dbb.Sign(nil, signatureHashes, []string{txProposal.Keypath.Encode()})
+	signatures, err := keystore.dbb.sign(
+		ctx, nil, meta, [][]byte{signatureHash}, []string{txProposal.Keypath.Encode()})
 	if isErrorAbort(err) {
 		return errp.WithStack(keystorePkg.ErrSigningAborted)
 	}
@@ -134,32 +231,170 @@ func (keystore *keystore) signETHTransaction(txProposal *eth.TxProposal) error {
 		return err
 	}
 	if len(signatures) != 1 {
-		panic("expecting one signature")
+		return errp.WithMessage(errp.WithStack(keystorePkg.ErrSignatureCountMismatch),
+			fmt.Sprintf("Expected one signature, got %d", len(signatures)))
+	}
+	if err := keystore.verifySignature(ctx, signatureHash, signatures[0], txProposal.Keypath); err != nil {
+		return err
 	}
-	signature := signatures[0]
 	// We serialize the sig (including the recid at the last byte) so we can use WithSignature()
-	// without modifications, even though it deserializes it again immediately. We do this because
-	// it also modifies the `V` value according to EIP155.
+	// without modifications, even though it deserializes it again immediately. For legacy
+	// transactions, this also modifies the `V` value according to EIP155. For EIP-1559
+	// transactions, `V` is the recid itself.
+	sig := serializeETHSignature(signatures[0])
+	switch txProposal.Type() {
+	case eth.DynamicFeeTxType:
+		signedTx, err := txProposal.DynamicFeeTx.WithSignature(sig)
+		if err != nil {
+			return err
+		}
+		txProposal.DynamicFeeTx = signedTx
+	default:
+		signedTx, err := txProposal.Tx.WithSignature(txProposal.Signer, sig)
+		if err != nil {
+			return err
+		}
+		txProposal.Tx = signedTx
+	}
+	return nil
+}
+
+// serializeETHSignature returns the signature in the [R || S || V] format with V being the recid.
+func serializeETHSignature(signature SignatureWithRecID) []byte {
 	sig := make([]byte, 65)
 	copy(sig[:32], math.PaddedBigBytes(signature.R, 32))
 	copy(sig[32:64], math.PaddedBigBytes(signature.S, 32))
 	sig[64] = byte(signature.RecID)
-	signedTx, err := txProposal.Tx.WithSignature(txProposal.Signer, sig)
+	return sig
+}
+
+// verifySignature checks that the signature of the given hash recovers to the public key at the
+// given keypath, so that a wrong S or recid from the device is neither broadcast nor passed to a
+// dapp.
+func (keystore *keystore) verifySignature(ctx context.Context,
+	signatureHash []byte, signature SignatureWithRecID, keyPath signing.AbsoluteKeypath) error {
+	if signature.RecID < 0 || signature.RecID > 1 ||
+		!crypto.ValidateSignatureValues(byte(signature.RecID), signature.R, signature.S, true) {
+		return errp.New("The signature of the BitBox is invalid.")
+	}
+	extendedPublicKey, err := keystore.ExtendedPublicKeyContext(ctx, keyPath)
 	if err != nil {
 		return err
 	}
-	txProposal.Tx = signedTx
+	publicKey, err := extendedPublicKey.ECPubKey()
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	recoveredPublicKey, err := crypto.SigToPub(signatureHash, serializeETHSignature(signature))
+	if err != nil || !publicKey.IsEqual((*btcec.PublicKey)(recoveredPublicKey)) {
+		return errp.New("The signature of the BitBox does not match the key.")
+	}
 	return nil
 }
 
+// SignMessage implements keystore.Keystore.
+func (keystore *keystore) SignMessage(
+	keyPath signing.AbsoluteKeypath, message []byte, coin coin.Coin) ([]byte, error) {
+	var signatureHash []byte
+	var meta string
+	switch specificCoin := coin.(type) {
+	case *btc.Coin:
+		signatureHash = specificCoin.SignedMessageHash(message)
+	case *eth.Coin:
+		signatureHash = specificCoin.SignedMessageHash(message)
+		// Show the message on the paired mobile, as the device only sees the hash.
+		meta = eth.MessageMeta(message)
+	default:
+		return nil, errp.Newf("Message signing is not supported for %s.", coin.Code())
+	}
+	ctx := context.Background()
+	signatures, err := keystore.dbb.sign(
+		ctx, nil, meta, [][]byte{signatureHash}, []string{keyPath.Encode()})
+	if isErrorAbort(err) {
+		return nil, errp.WithStack(keystorePkg.ErrSigningAborted)
+	}
+	if err != nil {
+		return nil, errp.WithMessage(err, "Failed to sign message")
+	}
+	if len(signatures) != 1 {
+		return nil, errp.WithMessage(errp.WithStack(keystorePkg.ErrSignatureCountMismatch),
+			fmt.Sprintf("Expected one signature, got %d", len(signatures)))
+	}
+	if err := keystore.verifySignature(ctx, signatureHash, signatures[0], keyPath); err != nil {
+		return nil, err
+	}
+	sig := serializeETHSignature(signatures[0])
+	if _, ok := coin.(*btc.Coin); ok {
+		// Compact signature: the header byte encodes the recid of a compressed public key.
+		return append([]byte{27 + 4 + sig[64]}, sig[:64]...), nil
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// SignTypedData implements keystore.Keystore.
+func (keystore *keystore) SignTypedData(
+	keyPath signing.AbsoluteKeypath, typedData []byte) ([]byte, error) {
+	return keystore.SignTypedDataContext(context.Background(), keyPath, typedData)
+}
+
+// SignTypedDataContext implements keystore.ContextKeystore.
+func (keystore *keystore) SignTypedDataContext(
+	ctx context.Context, keyPath signing.AbsoluteKeypath, jsonTypedData []byte) ([]byte, error) {
+	typedData, err := eth.ParseTypedData(jsonTypedData)
+	if err != nil {
+		return nil, err
+	}
+	signatureHash, err := typedData.SignatureHash()
+	if err != nil {
+		return nil, err
+	}
+	keystore.log.WithField("typed-data-hash", hex.EncodeToString(signatureHash)).Info("Sign typed data")
+	// Show the domain and the message on the paired mobile, as the device only sees the hash.
+	signatures, err := keystore.dbb.sign(
+		ctx, nil, typedData.Meta(), [][]byte{signatureHash}, []string{keyPath.Encode()})
+	if isErrorAbort(err) {
+		return nil, errp.WithStack(keystorePkg.ErrSigningAborted)
+	}
+	if err != nil {
+		return nil, errp.WithMessage(err, "Failed to sign typed data")
+	}
+	if len(signatures) != 1 {
+		return nil, errp.WithMessage(errp.WithStack(keystorePkg.ErrSignatureCountMismatch),
+			fmt.Sprintf("Expected one signature, got %d", len(signatures)))
+	}
+	if err := keystore.verifySignature(ctx, signatureHash, signatures[0], keyPath); err != nil {
+		return nil, err
+	}
+	sig := serializeETHSignature(signatures[0])
+	sig[64] += 27
+	return sig, nil
+}
+
 // SignTransaction implements keystore.Keystore.
 func (keystore *keystore) SignTransaction(proposedTx coin.ProposedTransaction) error {
+	return keystore.SignTransactionContext(context.Background(), proposedTx)
+}
+
+// SignTransactionContext implements keystore.ContextKeystore.
+func (keystore *keystore) SignTransactionContext(
+	ctx context.Context, proposedTx coin.ProposedTransaction) error {
 	switch specificProposedTx := proposedTx.(type) {
 	case *btc.ProposedTransaction:
-		return keystore.signBTCTransaction(specificProposedTx)
+		if err := specificProposedTx.CheckCanSign(keystore); err != nil {
+			return err
+		}
+		return keystore.signBTCTransaction(ctx, specificProposedTx)
 	case *eth.TxProposal:
-		return keystore.signETHTransaction(specificProposedTx)
+		return keystore.signETHTransaction(ctx, specificProposedTx)
 	default:
-		panic("unknown proposal type")
+		return errp.Newf("Unknown proposal type %T.", proposedTx)
 	}
 }
+
+// SignPSBT implements keystore.Keystore. A PSBT is not signed by the keystore directly, as the
+// paired mobile needs the coin and the account of the transaction to verify its outputs and fee.
+// Sign PSBTs through the account instead, see btc.Account.SignPSBT.
+func (keystore *keystore) SignPSBT(*psbt.Packet) (*psbt.Packet, error) {
+	return nil, errp.New("The BitBox signs PSBTs only through the account of the transaction.")
+}
@@ -0,0 +1,619 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bitbox
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	keystorePkg "github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const ethKeypath = "m/44'/60'/0'/0/0"
+
+// privateKey derives the private key the mocked device signs with.
+func (s *dbbTestSuite) privateKey(encodedKeypath string) *ecdsa.PrivateKey {
+	master, err := hdkeychain.NewMaster(make([]byte, 32), &chaincfg.MainNetParams)
+	require.NoError(s.T(), err)
+	keypath, err := signing.NewAbsoluteKeypath(encodedKeypath)
+	require.NoError(s.T(), err)
+	extendedKey, err := keypath.Derive(master)
+	require.NoError(s.T(), err)
+	privateKey, err := extendedKey.ECPrivKey()
+	require.NoError(s.T(), err)
+	return privateKey.ToECDSA()
+}
+
+func (s *dbbTestSuite) ethPrivateKey() *ecdsa.PrivateKey {
+	return s.privateKey(ethKeypath)
+}
+
+// mockSign makes the device sign the given hash with the private key at the given keypath. The
+// signature is returned count times in the reply.
+func (s *dbbTestSuite) mockSign(signatureHash []byte, encodedKeypath string, count int) {
+	s.mockSignWithMeta(signatureHash, encodedKeypath, count, "")
+}
+
+// mockXPub makes the device return the xpub at the given keypath. The device is queried twice per
+// xpub to detect hardware errors.
+func (s *dbbTestSuite) mockXPub(encodedKeypath string) {
+	master, err := hdkeychain.NewMaster(make([]byte, 32), &chaincfg.MainNetParams)
+	require.NoError(s.T(), err)
+	keypath, err := signing.NewAbsoluteKeypath(encodedKeypath)
+	require.NoError(s.T(), err)
+	extendedKey, err := keypath.Derive(master)
+	require.NoError(s.T(), err)
+	xpub, err := extendedKey.Neuter()
+	require.NoError(s.T(), err)
+	s.mockCommunication.On(
+		"SendEncrypt",
+		jsonArgumentMatcher(map[string]interface{}{"xpub": encodedKeypath}),
+		pin,
+	).
+		Return(map[string]interface{}{"xpub": xpub.String()}, nil).
+		Twice()
+}
+
+// mockSignWithMeta is like mockSign, but expects the given meta data in the sign command.
+func (s *dbbTestSuite) mockSignWithMeta(signatureHash []byte, encodedKeypath string, count int, meta string) {
+	sig, err := crypto.Sign(signatureHash, s.privateKey(encodedKeypath))
+	require.NoError(s.T(), err)
+	s.mockSignReply(signatureHash, encodedKeypath, count, meta, sig)
+}
+
+// mockSignReply is like mockSignWithMeta, but the device replies with the given signature in the
+// [R || S || V] format. The keystore verifies it against the xpub at the keypath.
+func (s *dbbTestSuite) mockSignReply(
+	signatureHash []byte, encodedKeypath string, count int, meta string, sig []byte) {
+	reply := []interface{}{}
+	for i := 0; i < count; i++ {
+		reply = append(reply, map[string]interface{}{
+			"sig":   hex.EncodeToString(sig[:64]),
+			"recid": hex.EncodeToString(sig[64:]),
+		})
+	}
+	element := map[string]interface{}{"hash": hex.EncodeToString(signatureHash), "keypath": encodedKeypath}
+	command := map[string]interface{}{"data": []interface{}{element}}
+	if meta != "" {
+		command["meta"] = meta
+	}
+	s.mockDeviceInfo()
+	s.mockCommunication.On(
+		"SendEncrypt",
+		jsonArgumentMatcher(map[string]interface{}{"sign": command}),
+		pin,
+	).
+		Return(nil, nil).
+		Once()
+	s.mockCommunication.On(
+		"SendEncrypt",
+		jsonArgumentMatcher(map[string]interface{}{"sign": ""}),
+		pin,
+	).
+		Return(map[string]interface{}{"sign": reply}, nil).
+		Once()
+	s.mockXPub(encodedKeypath)
+}
+
+func (s *dbbTestSuite) mockSignETH(signatureHash []byte, count int) {
+	s.mockSign(signatureHash, ethKeypath, count)
+}
+
+func (s *dbbTestSuite) newETHTxProposal() *eth.TxProposal {
+	keypath, err := signing.NewAbsoluteKeypath(ethKeypath)
+	require.NoError(s.T(), err)
+	return &eth.TxProposal{
+		Fee:     big.NewInt(21000 * 20e9),
+		Keypath: keypath,
+	}
+}
+
+func (s *dbbTestSuite) TestSignETHLegacyTransaction() {
+	require.NoError(s.T(), s.login())
+	txProposal := s.newETHTxProposal()
+	txProposal.Tx = types.NewTransaction(7, common.HexToAddress("0x3535353535353535353535353535353535353535"),
+		big.NewInt(1e18), 21000, big.NewInt(20e9), nil)
+	txProposal.Signer = types.MakeSigner(params.RinkebyChainConfig, params.RinkebyChainConfig.EIP155Block)
+	s.mockSignETH(txProposal.Signer.Hash(txProposal.Tx).Bytes(), 1)
+
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	require.NoError(s.T(), keystore.signETHTransaction(context.Background(), txProposal))
+	sender, err := types.Sender(txProposal.Signer, txProposal.Tx)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), crypto.PubkeyToAddress(s.ethPrivateKey().PublicKey), sender)
+}
+
+func (s *dbbTestSuite) TestSignETHDynamicFeeTransaction() {
+	require.NoError(s.T(), s.login())
+	recipient := common.HexToAddress("0x3535353535353535353535353535353535353535")
+	txProposal := s.newETHTxProposal()
+	txProposal.DynamicFeeTx = &eth.DynamicFeeTx{
+		ChainID:   params.RinkebyChainConfig.ChainID,
+		Nonce:     7,
+		GasTipCap: big.NewInt(1.5e9),
+		GasFeeCap: big.NewInt(30e9),
+		Gas:       21000,
+		To:        &recipient,
+		Value:     big.NewInt(1e18),
+	}
+	signatureHash, err := txProposal.DynamicFeeTx.SigHash()
+	require.NoError(s.T(), err)
+	s.mockSignETH(signatureHash.Bytes(), 1)
+
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	require.NoError(s.T(), keystore.signETHTransaction(context.Background(), txProposal))
+	require.True(s.T(), txProposal.DynamicFeeTx.V.Cmp(big.NewInt(1)) <= 0)
+	sender, err := txProposal.DynamicFeeTx.Sender()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), crypto.PubkeyToAddress(s.ethPrivateKey().PublicKey), sender)
+}
+
+func (s *dbbTestSuite) TestSignETHSignatureCountMismatch() {
+	require.NoError(s.T(), s.login())
+	txProposal := s.newETHTxProposal()
+	txProposal.Tx = types.NewTransaction(7, common.HexToAddress("0x3535353535353535353535353535353535353535"),
+		big.NewInt(1e18), 21000, big.NewInt(20e9), nil)
+	txProposal.Signer = types.MakeSigner(params.RinkebyChainConfig, params.RinkebyChainConfig.EIP155Block)
+	s.mockSignETH(txProposal.Signer.Hash(txProposal.Tx).Bytes(), 2)
+
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	var err error
+	require.NotPanics(s.T(), func() { err = keystore.signETHTransaction(context.Background(), txProposal) })
+	require.Equal(s.T(), keystorePkg.ErrSignatureCountMismatch, errp.Cause(err))
+}
+
+// newBTCProposedTransaction returns a transaction spending the given number of P2WPKH outputs of
+// the key at the given keypath.
+func (s *dbbTestSuite) newBTCProposedTransaction(
+	encodedKeypath string, inputs int) *btc.ProposedTransaction {
+	master, err := hdkeychain.NewMaster(make([]byte, 32), &chaincfg.MainNetParams)
+	require.NoError(s.T(), err)
+	keypath, err := signing.NewAbsoluteKeypath(encodedKeypath)
+	require.NoError(s.T(), err)
+	xprv, err := keypath.Derive(master)
+	require.NoError(s.T(), err)
+	xpub, err := xprv.Neuter()
+	require.NoError(s.T(), err)
+	configuration := signing.NewSinglesigConfiguration(signing.ScriptTypeP2WPKH, keypath, xpub)
+	address := addresses.NewAccountAddress(configuration, &chaincfg.MainNetParams, s.log)
+	transaction := wire.NewMsgTx(wire.TxVersion)
+	previousOutputs := map[wire.OutPoint]*transactions.SpendableOutput{}
+	for index := 0; index < inputs; index++ {
+		outPoint := wire.OutPoint{Index: uint32(index)}
+		transaction.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+		previousOutputs[outPoint] = &transactions.SpendableOutput{
+			TxOut: wire.NewTxOut(1000, address.PubkeyScript()),
+		}
+	}
+	transaction.AddTxOut(wire.NewTxOut(int64(inputs)*1000-500, address.PubkeyScript()))
+	signatures := make([][]*btcec.Signature, inputs)
+	for index := range signatures {
+		signatures[index] = make([]*btcec.Signature, 1)
+	}
+	return &btc.ProposedTransaction{
+		TXProposal: &maketx.TxProposal{
+			Coin:                 btc.NewCoin("btc", "BTC", &chaincfg.MainNetParams, ".", nil, ""),
+			AccountConfiguration: configuration,
+			Transaction:          transaction,
+		},
+		PreviousOutputs: previousOutputs,
+		GetAddress:      func(blockchain.ScriptHashHex) *addresses.AccountAddress { return address },
+		Signatures:      signatures,
+		SigHashes:       txscript.NewTxSigHashes(transaction),
+	}
+}
+
+func (s *dbbTestSuite) TestSignBTCSignatureCountMismatch() {
+	require.NoError(s.T(), s.login())
+	const encodedKeypath = "m/84'/0'/0'/0/0"
+	proposedTransaction := s.newBTCProposedTransaction(encodedKeypath, 2)
+	signatureHashes, err := proposedTransaction.SignatureHashes()
+	require.NoError(s.T(), err)
+	sig, err := crypto.Sign(signatureHashes[0].Hash, s.privateKey(encodedKeypath))
+	require.NoError(s.T(), err)
+
+	// The device is asked for two signatures, but replies with only one.
+	s.mockDeviceInfo()
+	s.mockCommunication.On("SendEncrypt", mock.MatchedBy(func(cmd string) bool {
+		var command struct {
+			Sign struct {
+				Data []map[string]string `json:"data"`
+			} `json:"sign"`
+		}
+		return json.Unmarshal([]byte(cmd), &command) == nil && len(command.Sign.Data) == 2
+	}), pin).
+		Return(nil, nil).
+		Once()
+	s.mockCommunication.On("SendEncrypt", jsonArgumentMatcher(map[string]interface{}{"sign": ""}), pin).
+		Return(map[string]interface{}{"sign": []interface{}{map[string]interface{}{
+			"sig":   hex.EncodeToString(sig[:64]),
+			"recid": hex.EncodeToString(sig[64:]),
+		}}}, nil).
+		Once()
+
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	require.NotPanics(s.T(), func() { err = keystore.signBTCTransaction(context.Background(), proposedTransaction) })
+	require.Equal(s.T(), keystorePkg.ErrSignatureCountMismatch, errp.Cause(err))
+	for _, signatures := range proposedTransaction.Signatures {
+		require.Nil(s.T(), signatures[0])
+	}
+}
+
+func (s *dbbTestSuite) TestBatchOutputAddressNotPaired() {
+	require.NoError(s.T(), s.login())
+	keypath, err := signing.NewAbsoluteKeypath("m/44'/0'/0'/0/0")
+	require.NoError(s.T(), err)
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	require.False(s.T(), keystore.HasSecureOutput())
+	require.Error(s.T(), keystore.BatchOutputAddress(
+		[]signing.AbsoluteKeypath{keypath}, signing.ScriptTypeP2WPKH, nil))
+	s.mockCommunication.AssertNotCalled(s.T(), "SendEncrypt", mock.Anything, pin)
+}
+
+func (s *dbbTestSuite) TestExtendedPublicKeyCached() {
+	require.NoError(s.T(), s.login())
+	master, err := hdkeychain.NewMaster(make([]byte, 32), &chaincfg.MainNetParams)
+	require.NoError(s.T(), err)
+	keypaths := []string{"m/44'/0'/0'", "m/49'/0'/0'"}
+	xpubs := map[string]string{}
+	for _, encodedKeypath := range keypaths {
+		keypath, err := signing.NewAbsoluteKeypath(encodedKeypath)
+		require.NoError(s.T(), err)
+		extendedKey, err := keypath.Derive(master)
+		require.NoError(s.T(), err)
+		xpub, err := extendedKey.Neuter()
+		require.NoError(s.T(), err)
+		xpubs[encodedKeypath] = xpub.String()
+		// The device is queried twice per xpub to detect hardware errors.
+		s.mockCommunication.On(
+			"SendEncrypt",
+			jsonArgumentMatcher(map[string]interface{}{"xpub": encodedKeypath}),
+			pin,
+		).
+			Return(map[string]interface{}{"xpub": xpubs[encodedKeypath]}, nil).
+			Twice()
+	}
+
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	var wait sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, encodedKeypath := range keypaths {
+			wait.Add(1)
+			go func(encodedKeypath string) {
+				defer wait.Done()
+				keypath, err := signing.NewAbsoluteKeypath(encodedKeypath)
+				require.NoError(s.T(), err)
+				xpub, err := keystore.ExtendedPublicKey(keypath)
+				require.NoError(s.T(), err)
+				require.Equal(s.T(), xpubs[encodedKeypath], xpub.String())
+			}(encodedKeypath)
+		}
+	}
+	wait.Wait()
+	s.mockCommunication.AssertNumberOfCalls(s.T(), "SendEncrypt", 2*len(keypaths))
+
+	s.dbb.Close()
+	require.Empty(s.T(), s.dbb.xpubCache)
+}
+
+func (s *dbbTestSuite) TestSignMessageBTC() {
+	require.NoError(s.T(), s.login())
+	const encodedKeypath = "m/44'/0'/0'/0/0"
+	keypath, err := signing.NewAbsoluteKeypath(encodedKeypath)
+	require.NoError(s.T(), err)
+	coin := btc.NewCoin("btc", "BTC", &chaincfg.MainNetParams, ".", nil, "")
+	message := []byte("Hello BitBox")
+	signatureHash := coin.SignedMessageHash(message)
+	s.mockSign(signatureHash, encodedKeypath, 1)
+
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	sig, err := keystore.SignMessage(keypath, message, coin)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), sig, 65)
+	publicKey, compressed, err := btcec.RecoverCompact(btcec.S256(), sig, signatureHash)
+	require.NoError(s.T(), err)
+	require.True(s.T(), compressed)
+	require.Equal(s.T(), s.privateKey(encodedKeypath).PublicKey, *publicKey.ToECDSA())
+}
+
+func (s *dbbTestSuite) TestSignMessageETH() {
+	require.NoError(s.T(), s.login())
+	keypath, err := signing.NewAbsoluteKeypath(ethKeypath)
+	require.NoError(s.T(), err)
+	coin := eth.NewCoin("eth", eth.MainnetNetwork, "", "")
+	message := []byte("Hello BitBox")
+	signatureHash := coin.SignedMessageHash(message)
+	s.mockSignWithMeta(signatureHash, ethKeypath, 1, eth.MessageMeta(message))
+
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	sig, err := keystore.SignMessage(keypath, message, coin)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), sig, 65)
+	require.True(s.T(), sig[64] == 27 || sig[64] == 28)
+	recoverableSig := append([]byte{}, sig...)
+	recoverableSig[64] -= 27
+	publicKey, err := crypto.SigToPub(signatureHash, recoverableSig)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), crypto.PubkeyToAddress(s.ethPrivateKey().PublicKey), crypto.PubkeyToAddress(*publicKey))
+}
+
+func (s *dbbTestSuite) TestSignTypedData() {
+	require.NoError(s.T(), s.login())
+	keypath, err := signing.NewAbsoluteKeypath(ethKeypath)
+	require.NoError(s.T(), err)
+	jsonTypedData := []byte(`{
+  "types": {
+    "EIP712Domain": [{"name": "name", "type": "string"}, {"name": "chainId", "type": "uint256"}],
+    "Greeting": [{"name": "text", "type": "string"}]
+  },
+  "primaryType": "Greeting",
+  "domain": {"name": "Greeter", "chainId": 1},
+  "message": {"text": "Hello BitBox"}
+}`)
+	typedData, err := eth.ParseTypedData(jsonTypedData)
+	require.NoError(s.T(), err)
+	signatureHash, err := typedData.SignatureHash()
+	require.NoError(s.T(), err)
+	// The domain and the message are shown on the paired mobile.
+	s.mockSignWithMeta(signatureHash, ethKeypath, 1, typedData.Meta())
+
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	sig, err := keystore.SignTypedData(keypath, jsonTypedData)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), sig, 65)
+	require.True(s.T(), sig[64] == 27 || sig[64] == 28)
+	recoverableSig := append([]byte{}, sig...)
+	recoverableSig[64] -= 27
+	publicKey, err := crypto.SigToPub(signatureHash, recoverableSig)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), crypto.PubkeyToAddress(s.ethPrivateKey().PublicKey), crypto.PubkeyToAddress(*publicKey))
+}
+
+func (s *dbbTestSuite) TestSignInvalidSignature() {
+	require.NoError(s.T(), s.login())
+	keypath, err := signing.NewAbsoluteKeypath(ethKeypath)
+	require.NoError(s.T(), err)
+	coin := eth.NewCoin("eth", eth.MainnetNetwork, "", "")
+	message := []byte("Hello BitBox")
+	signatureHash := coin.SignedMessageHash(message)
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+
+	// Signed with another key.
+	sig, err := crypto.Sign(signatureHash, s.privateKey("m/44'/60'/0'/0/1"))
+	require.NoError(s.T(), err)
+	s.mockSignReply(signatureHash, ethKeypath, 1, eth.MessageMeta(message), sig)
+	_, err = keystore.SignMessage(keypath, message, coin)
+	require.Error(s.T(), err)
+
+	// Wrong recid.
+	sig, err = crypto.Sign(signatureHash, s.ethPrivateKey())
+	require.NoError(s.T(), err)
+	sig[64] ^= 1
+	s.mockSignReply(signatureHash, ethKeypath, 1, eth.MessageMeta(message), sig)
+	_, err = keystore.SignMessage(keypath, message, coin)
+	require.Error(s.T(), err)
+
+	// Wrong S of an Ethereum transaction.
+	txProposal := s.newETHTxProposal()
+	txProposal.Tx = types.NewTransaction(7, common.HexToAddress("0x3535353535353535353535353535353535353535"),
+		big.NewInt(1e18), 21000, big.NewInt(20e9), nil)
+	txProposal.Signer = types.MakeSigner(params.RinkebyChainConfig, params.RinkebyChainConfig.EIP155Block)
+	signatureHash = txProposal.Signer.Hash(txProposal.Tx).Bytes()
+	sig, err = crypto.Sign(signatureHash, s.ethPrivateKey())
+	require.NoError(s.T(), err)
+	sig[63]++
+	s.mockSignReply(signatureHash, ethKeypath, 1, "", sig)
+	require.Error(s.T(), keystore.signETHTransaction(context.Background(), txProposal))
+	// The transaction is not signed.
+	_, r, _ := txProposal.Tx.RawSignatureValues()
+	require.Zero(s.T(), r.Sign())
+}
+
+func (s *dbbTestSuite) TestSignTransactionContextCanceled() {
+	require.NoError(s.T(), s.login())
+	txProposal := s.newETHTxProposal()
+	txProposal.Tx = types.NewTransaction(7, common.HexToAddress("0x3535353535353535353535353535353535353535"),
+		big.NewInt(1e18), 21000, big.NewInt(20e9), nil)
+	txProposal.Signer = types.MakeSigner(params.RinkebyChainConfig, params.RinkebyChainConfig.EIP155Block)
+	signatureHash := txProposal.Signer.Hash(txProposal.Tx).Bytes()
+	element := map[string]interface{}{"hash": hex.EncodeToString(signatureHash), "keypath": ethKeypath}
+	s.mockDeviceInfo()
+	s.mockCommunication.On(
+		"SendEncrypt",
+		jsonArgumentMatcher(map[string]interface{}{"sign": map[string]interface{}{"data": []interface{}{element}}}),
+		pin,
+	).
+		Return(nil, nil).
+		Once()
+	// The device waits for the user to confirm until the test releases it.
+	release := make(chan time.Time)
+	s.mockCommunication.On(
+		"SendEncrypt",
+		jsonArgumentMatcher(map[string]interface{}{"sign": ""}),
+		pin,
+	).
+		WaitUntil(release).
+		Return(nil, NewError("aborted by timeout", ErrTouchAbort)).
+		Once()
+
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	err := keystore.SignTransactionContext(ctx, txProposal)
+	require.Equal(s.T(), context.Canceled, errp.Cause(err))
+
+	// No command is sent before the device replied to the abandoned one.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = keystore.SignTransactionContext(ctx, txProposal)
+	require.Equal(s.T(), context.DeadlineExceeded, errp.Cause(err))
+	close(release)
+
+	// The next operation is not affected by the abandoned one.
+	s.mockSignETH(signatureHash, 1)
+	require.NoError(s.T(), keystore.SignTransactionContext(context.Background(), txProposal))
+	sender, err := types.Sender(txProposal.Signer, txProposal.Tx)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), crypto.PubkeyToAddress(s.ethPrivateKey().PublicKey), sender)
+}
+
+func (s *dbbTestSuite) TestExtendedPublicKeyContextCanceled() {
+	require.NoError(s.T(), s.login())
+	keypath, err := signing.NewAbsoluteKeypath("m/44'/0'/0'")
+	require.NoError(s.T(), err)
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = keystore.ExtendedPublicKeyContext(ctx, keypath)
+	require.Equal(s.T(), context.Canceled, errp.Cause(err))
+	s.mockCommunication.AssertNotCalled(s.T(), "SendEncrypt", mock.Anything, pin)
+}
+
+func (s *dbbTestSuite) newERC20TxProposal() *eth.TxProposal {
+	contract := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	txProposal := s.newETHTxProposal()
+	txProposal.Token = &eth.TokenTransfer{
+		Contract:  contract,
+		Symbol:    "USDT",
+		Decimals:  6,
+		Recipient: common.HexToAddress("0x3535353535353535353535353535353535353535"),
+		Amount:    big.NewInt(50e6),
+	}
+	txProposal.Tx = types.NewTransaction(7, contract, big.NewInt(0), 60000, big.NewInt(20e9),
+		eth.ERC20TransferData(txProposal.Token.Recipient, txProposal.Token.Amount))
+	txProposal.Signer = types.MakeSigner(params.RinkebyChainConfig, params.RinkebyChainConfig.EIP155Block)
+	return txProposal
+}
+
+func (s *dbbTestSuite) TestSignETHTokenTransfer() {
+	require.NoError(s.T(), s.login())
+	txProposal := s.newERC20TxProposal()
+	meta := txProposal.Token.Meta()
+	var decodedMeta map[string]interface{}
+	require.NoError(s.T(), json.Unmarshal([]byte(meta), &decodedMeta))
+	require.Equal(s.T(), map[string]interface{}{
+		"contract":    "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+		"symbol":      "USDT",
+		"decimals":    float64(6),
+		"recipient":   "0x3535353535353535353535353535353535353535",
+		"amount":      "50000000",
+		"description": "Send 50 USDT to 0x3535353535353535353535353535353535353535",
+	}, decodedMeta)
+	s.mockSignWithMeta(txProposal.Signer.Hash(txProposal.Tx).Bytes(), ethKeypath, 1, meta)
+
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	require.NoError(s.T(), keystore.signETHTransaction(context.Background(), txProposal))
+	sender, err := types.Sender(txProposal.Signer, txProposal.Tx)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), crypto.PubkeyToAddress(s.ethPrivateKey().PublicKey), sender)
+}
+
+func (s *dbbTestSuite) TestSignETHTokenTransferMismatch() {
+	require.NoError(s.T(), s.login())
+	txProposal := s.newERC20TxProposal()
+	txProposal.Token.Amount = big.NewInt(1)
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	require.Error(s.T(), keystore.signETHTransaction(context.Background(), txProposal))
+	s.mockCommunication.AssertNotCalled(s.T(), "SendEncrypt", mock.Anything, pin)
+}
+
+func (s *dbbTestSuite) TestSupportsScriptType() {
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	btcCoin := btc.NewCoin("btc", "BTC", &chaincfg.MainNetParams, ".", nil, "")
+	ethCoin := eth.NewCoin("eth", eth.MainnetNetwork, "", "")
+	for _, scriptType := range []signing.ScriptType{
+		signing.ScriptTypeP2PKH, signing.ScriptTypeP2WPKHP2SH, signing.ScriptTypeP2WPKH,
+	} {
+		require.True(s.T(), keystore.SupportsScriptType(btcCoin, scriptType))
+	}
+	require.True(s.T(), keystore.SupportsScriptType(ethCoin, ""))
+	require.False(s.T(), keystore.SupportsScriptType(btcCoin, "unknown"))
+
+	// No firmware version creates the Schnorr signatures of taproot inputs.
+	require.False(s.T(), keystore.SupportsScriptType(btcCoin, signing.ScriptTypeP2TR))
+}
+
+func (s *dbbTestSuite) TestSupportsAccount() {
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	btcCoin := btc.NewCoin("btc", "BTC", &chaincfg.MainNetParams, ".", nil, "")
+	ethCoin := eth.NewCoin("eth", eth.MainnetNetwork, "", "")
+	require.True(s.T(), keystore.SupportsCoin(btcCoin))
+	require.True(s.T(), keystore.SupportsCoin(ethCoin))
+	require.True(s.T(), keystore.SupportsAccount(ethCoin, signing.ScriptTypeP2WPKH, false))
+	require.False(s.T(), keystore.SupportsAccount(ethCoin, signing.ScriptTypeP2WPKH, true))
+
+	require.True(s.T(), keystore.SupportsAccount(btcCoin, signing.ScriptTypeP2WPKH, false))
+	// The BitBox cannot sign taproot inputs, but multisig inputs of any script type.
+	require.False(s.T(), keystore.SupportsAccount(btcCoin, signing.ScriptTypeP2TR, false))
+	require.True(s.T(), keystore.SupportsAccount(btcCoin, signing.ScriptTypeP2WSH, true))
+}
+
+func (s *dbbTestSuite) TestSignTransactionUnsupportedScriptType() {
+	require.NoError(s.T(), s.login())
+	master, err := hdkeychain.NewMaster(make([]byte, 32), &chaincfg.MainNetParams)
+	require.NoError(s.T(), err)
+	xpub, err := master.Neuter()
+	require.NoError(s.T(), err)
+	keypath, err := signing.NewAbsoluteKeypath("m/86'/0'/0'/0/0")
+	require.NoError(s.T(), err)
+	address := &addresses.AccountAddress{
+		Configuration: signing.NewSinglesigConfiguration(signing.ScriptTypeP2TR, keypath, xpub),
+	}
+	outPoint := wire.OutPoint{Index: 1}
+	transaction := wire.NewMsgTx(wire.TxVersion)
+	transaction.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+	proposedTransaction := &btc.ProposedTransaction{
+		TXProposal: &maketx.TxProposal{
+			Coin:        btc.NewCoin("btc", "BTC", &chaincfg.MainNetParams, ".", nil, ""),
+			Transaction: transaction,
+		},
+		PreviousOutputs: map[wire.OutPoint]*transactions.SpendableOutput{
+			outPoint: {TxOut: wire.NewTxOut(1000, []byte{txscript.OP_1})},
+		},
+		GetAddress: func(blockchain.ScriptHashHex) *addresses.AccountAddress { return address },
+	}
+
+	keystore := &keystore{dbb: s.dbb, log: s.log}
+	var signErr error
+	require.NotPanics(s.T(), func() { signErr = keystore.SignTransaction(proposedTransaction) })
+	require.Error(s.T(), signErr)
+	require.NotPanics(s.T(), func() { signErr = keystore.SignTransaction(nil) })
+	require.Error(s.T(), signErr)
+	s.mockCommunication.AssertNotCalled(s.T(), "SendEncrypt", mock.Anything, pin)
+}
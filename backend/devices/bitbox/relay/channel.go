@@ -15,6 +15,7 @@
 package relay
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
@@ -121,8 +122,19 @@ func (channel *Channel) getValueFromMessage(message []byte, name string) (string
 // waitForValue waits for the given duration for the value with the given name from the mobile.
 // Returns an error if no value with the given name has been received in the given duration.
 func (channel *Channel) waitForValue(duration time.Duration, name string) (string, error) {
+	return channel.waitForValueContext(context.Background(), duration, name)
+}
+
+// waitForValueContext is like waitForValue, but also stops waiting once the context is done. As a
+// pending pull from the relay server is not interrupted, this can take up to ten seconds. A value
+// received after the context is done is discarded.
+func (channel *Channel) waitForValueContext(
+	ctx context.Context, duration time.Duration, name string) (string, error) {
 	deadline := time.Now().Add(duration)
 	for {
+		if err := ctx.Err(); err != nil {
+			return "", errp.WithStack(err)
+		}
 		unlock := channel.messageBufferLock.Lock()
 		var message []byte
 		var value string
@@ -164,6 +176,10 @@ func (channel *Channel) waitForValue(duration time.Duration, name string) (strin
 				unlock()
 				continue
 			}
+			if err := ctx.Err(); err != nil {
+				channel.log.Debugf("Discarded the value %s received after the context was done", name)
+				return "", errp.WithStack(err)
+			}
 		}
 		return value, nil
 	}
@@ -274,8 +290,9 @@ func (channel *Channel) SendSigningEcho(
 // WaitForSigningPin waits for the given duration for the 2FA signing PIN from the mobile.
 // Returns an error if no 2FA signing PIN was available on the relay server in the given duration.
 // Otherwise, the returned value is either the PIN (on confirmation) or "abort" (on cancel).
-func (channel *Channel) WaitForSigningPin(duration time.Duration) (string, error) {
-	return channel.waitForValue(duration, "pin")
+// It stops waiting once the context is done, after the pending pull from the relay server returned.
+func (channel *Channel) WaitForSigningPin(ctx context.Context, duration time.Duration) (string, error) {
+	return channel.waitForValueContext(ctx, duration, "pin")
 }
 
 // SendRandomNumberEcho sends the encrypted random number echo from the BitBox to the paired mobile.
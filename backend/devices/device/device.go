@@ -48,8 +48,10 @@ type Interface interface {
 	// ExtendedPublicKey returns the extended public key at the given absolute keypath.
 	// ExtendedPublicKey(signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error)
 
-	// Keystore returns the keystore provided by the device (or an nil if not seeded).
-	KeystoreForConfiguration(configuration *signing.Configuration, cosignerIndex int) keystore.Keystore
+	// Keystore returns the keystore provided by the device (or an nil if not seeded). If a
+	// configuration is given, an error is returned if the device cannot sign for it at the given
+	// cosigner index.
+	KeystoreForConfiguration(configuration *signing.Configuration, cosignerIndex int) (keystore.Keystore, error)
 
 	// Locked() bool
 
@@ -0,0 +1,157 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// accountGapLimit is the number of consecutive unused accounts after which account discovery
+// stops.
+const accountGapLimit = 1
+
+// accountUsed returns whether the account with the given BIP44 account index has any
+// transactions. The account is synced in the background and closed again.
+func (backend *Backend) accountUsed(accountType *accountType, index int) (bool, error) {
+	account, ok := backend.newAccount(accountType.coin, accountType.code, index, accountType.name,
+		backend.accountKeypath(accountType, index), accountType.scriptType,
+		func(string, string) {}).(*btc.Account)
+	if !ok {
+		return false, errp.New("Account discovery is only supported for Bitcoin and Litecoin.")
+	}
+	if err := account.Initialize(); err != nil {
+		return false, err
+	}
+	defer account.Close()
+	return account.Used(), nil
+}
+
+// discoverAccounts performs BIP44 account discovery for all active account types. Accounts
+// following the configured ones are synced until accountGapLimit consecutive ones are unused. If
+// used accounts are found, they are added to the config and the accounts are reinitialized.
+func (backend *Backend) discoverAccounts() {
+	found := false
+	for _, accountType := range backend.accountTypes() {
+		if !accountType.bip44 || !backend.config.Config().Backend.AccountActive(accountType.code) {
+			continue
+		}
+		if _, ok := accountType.coin.(*btc.Coin); !ok {
+			// Additional Ethereum accounts are only added by the user.
+			continue
+		}
+		if !backend.keystores.SupportAccount(
+			accountType.coin, accountType.scriptType, backend.arguments.Multisig()) {
+			continue
+		}
+		log := backend.log.WithField("code", accountType.code)
+		count := backend.accountCount(accountType.code)
+		for index, unused := count, 0; unused < accountGapLimit; index++ {
+			if backend.keystores.Count() == 0 {
+				// The keystore was removed in the meantime.
+				return
+			}
+			used, err := backend.accountUsed(accountType, index)
+			if err != nil {
+				log.WithError(err).Error("Account discovery failed")
+				break
+			}
+			if !used {
+				unused++
+				continue
+			}
+			unused = 0
+			log.WithField("index", index).Info("Discovered used account")
+			if err := backend.setAccountCount(accountType.code, index+1); err != nil {
+				log.WithError(err).Error("Failed to store the discovered account")
+				break
+			}
+			found = true
+		}
+	}
+	if found {
+		backend.initAccounts()
+		backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+	}
+}
+
+// rootFingerprint returns the hex encoded root fingerprints of the keystores, joined by dashes,
+// which identify the seeds in the config. It is empty if the keystores cannot be identified.
+func (backend *Backend) rootFingerprint() string {
+	rootFingerprints, err := backend.keystores.RootFingerprints()
+	if err != nil {
+		backend.log.WithError(err).Error("Failed to get the root fingerprints")
+		return ""
+	}
+	encoded := make([]string, len(rootFingerprints))
+	for index, rootFingerprint := range rootFingerprints {
+		encoded[index] = hex.EncodeToString(rootFingerprint)
+	}
+	return strings.Join(encoded, "-")
+}
+
+// accountCount returns the number of accounts of the account type with the given code for the
+// seeds of the keystores.
+func (backend *Backend) accountCount(code string) int {
+	return backend.config.Config().Backend.AccountCount(backend.rootFingerprint(), code)
+}
+
+// setAccountCount stores the number of accounts of the account type with the given code for the
+// seeds of the keystores.
+func (backend *Backend) setAccountCount(code string, count int) error {
+	rootFingerprint := backend.rootFingerprint()
+	if rootFingerprint == "" {
+		return errp.New("The keystores cannot be identified.")
+	}
+	appConfig := backend.config.Config()
+	accountCounts := map[string]map[string]int{}
+	for seed, seedAccountCounts := range appConfig.Backend.AccountCounts {
+		accountCounts[seed] = seedAccountCounts
+	}
+	seedAccountCounts := map[string]int{}
+	for accountTypeCode, accountCount := range accountCounts[rootFingerprint] {
+		seedAccountCounts[accountTypeCode] = accountCount
+	}
+	seedAccountCounts[code] = count
+	accountCounts[rootFingerprint] = seedAccountCounts
+	appConfig.Backend.AccountCounts = accountCounts
+	return backend.config.Set(appConfig)
+}
+
+// AddAccount adds another BIP44 account of the coin and script type of the account with the given
+// code.
+func (backend *Backend) AddAccount(code string) error {
+	for _, accountType := range backend.accountTypes() {
+		count := backend.accountCount(accountType.code)
+		for index := 0; index < count; index++ {
+			if accountCode(accountType.code, index) != code {
+				continue
+			}
+			if !accountType.bip44 {
+				return errp.Newf("Only one account is supported for %s.", code)
+			}
+			if err := backend.setAccountCount(accountType.code, count+1); err != nil {
+				return err
+			}
+			backend.initAccounts()
+			backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+			return nil
+		}
+	}
+	return errp.Newf("Unknown account %s.", code)
+}
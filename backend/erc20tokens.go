@@ -0,0 +1,140 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// erc20TokenCode returns the code of the account and of the coin of an ERC20 token.
+func erc20TokenCode(token *config.ERC20Token) string {
+	return fmt.Sprintf("%s-erc20-%s", token.Coin, strings.ToLower(token.Contract))
+}
+
+// ethAccountTypes returns the Ethereum account types by coin code. The accounts of the ERC20
+// tokens of a coin use the keypath of the first account of its account type.
+func (backend *Backend) ethAccountTypes() map[string]*accountType {
+	accountTypes := map[string]*accountType{}
+	for _, accountType := range backend.accountTypes() {
+		if _, ok := accountType.coin.(*eth.Coin); ok {
+			accountTypes[accountType.code] = accountType
+		}
+	}
+	return accountTypes
+}
+
+// ERC20TokenCoins returns the codes of the coins for which ERC20 tokens can be added.
+func (backend *Backend) ERC20TokenCoins() []string {
+	coinCodes := []string{}
+	for coinCode := range backend.ethAccountTypes() {
+		coinCodes = append(coinCodes, coinCode)
+	}
+	sort.Strings(coinCodes)
+	return coinCodes
+}
+
+// erc20Coin returns the coin of the token, which shares the connections of the Ether coin.
+func (backend *Backend) erc20Coin(token *config.ERC20Token, ether *eth.Coin) *eth.Coin {
+	code := erc20TokenCode(token)
+	defer backend.coinsLock.Lock()()
+	if coin, ok := backend.coins[code]; ok {
+		return coin.(*eth.Coin)
+	}
+	coin := eth.NewERC20Coin(code, ether, &eth.ERC20Token{
+		Contract: common.HexToAddress(token.Contract),
+		Symbol:   token.Symbol,
+		Decimals: token.Decimals,
+		Name:     token.Name,
+	})
+	backend.coins[code] = coin
+	return coin
+}
+
+// addERC20TokenAccounts adds the accounts of the ERC20 tokens of the config. Tokens of coins which
+// are not available, e.g. mainnet coins in testing mode, are skipped.
+func (backend *Backend) addERC20TokenAccounts() {
+	ethAccountTypes := backend.ethAccountTypes()
+	ratesTokens := []RatesToken{}
+	for _, token := range backend.config.Config().Backend.ERC20Tokens {
+		token := token
+		code := erc20TokenCode(&token)
+		accountType, ok := ethAccountTypes[token.Coin]
+		if !ok {
+			backend.log.WithField("code", code).Info("skipping ERC20 token of unavailable coin")
+			continue
+		}
+		coin := backend.erc20Coin(&token, accountType.coin.(*eth.Coin))
+		name := token.Name
+		if name == "" {
+			name = token.Symbol
+		}
+		backend.initAccount(coin, code, 0, name, backend.accountKeypath(accountType, 0),
+			accountType.scriptType)
+		if platform := coin.Network().CoinGeckoPlatform; platform != "" {
+			ratesTokens = append(ratesTokens, RatesToken{
+				Platform: platform, Contract: token.Contract, Unit: coin.Unit()})
+		}
+	}
+	backend.ratesUpdater.SetTokens(ratesTokens)
+}
+
+// AddERC20Token adds an account for the ERC20 token at the given contract address. The symbol, the
+// decimals and the name are fetched from the contract and stored in the config, so that no token
+// list is needed.
+func (backend *Backend) AddERC20Token(coinCode string, contract string) error {
+	accountType, ok := backend.ethAccountTypes()[coinCode]
+	if !ok {
+		return errp.Newf("ERC20 tokens are not supported for %s.", coinCode)
+	}
+	contract = strings.TrimSpace(contract)
+	if !common.IsHexAddress(contract) {
+		return errp.New("Invalid contract address.")
+	}
+	contractAddress := common.HexToAddress(contract)
+	token := config.ERC20Token{Coin: coinCode, Contract: contractAddress.Hex()}
+	code := erc20TokenCode(&token)
+	appConfig := backend.config.Config()
+	for _, existing := range appConfig.Backend.ERC20Tokens {
+		existing := existing
+		if erc20TokenCode(&existing) == code {
+			return errp.New("The token has already been added.")
+		}
+	}
+	ether := accountType.coin.(*eth.Coin)
+	ether.Initialize()
+	erc20Token, err := ether.FetchERC20Token(context.TODO(), contractAddress)
+	if err != nil {
+		return err
+	}
+	token.Symbol = erc20Token.Symbol
+	token.Decimals = erc20Token.Decimals
+	token.Name = erc20Token.Name
+	erc20Tokens := append([]config.ERC20Token{}, appConfig.Backend.ERC20Tokens...)
+	appConfig.Backend.ERC20Tokens = append(erc20Tokens, token)
+	if err := backend.config.Set(appConfig); err != nil {
+		return err
+	}
+	backend.initAccounts()
+	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+	return nil
+}
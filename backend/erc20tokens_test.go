@@ -0,0 +1,28 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestERC20TokenCode(t *testing.T) {
+	require.Equal(t, "eth-erc20-0xdac17f958d2ee523a2206206994597c13d831ec7",
+		erc20TokenCode(&config.ERC20Token{
+			Coin: "eth", Contract: "0xdAC17F958D2ee523a2206206994597C13D831ec7", Symbol: "USDT"}))
+}
@@ -0,0 +1,140 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ethereumLegacyGapLimit is the number of consecutive unused addresses after which the scan of a
+// legacy keypath scheme stops.
+const ethereumLegacyGapLimit = 5
+
+// ethereumLegacyScheme is a derivation scheme of Ethereum addresses used by other wallets.
+type ethereumLegacyScheme struct {
+	name string
+	// keypath returns the keypath of the address with the given index, below the keypath of the
+	// coin type, e.g. m/44'/60'.
+	keypath func(coinTypeKeypath string, index int) string
+}
+
+// ethereumLegacySchemes are the schemes which are scanned for funds of users migrating from other
+// wallets.
+var ethereumLegacySchemes = []ethereumLegacyScheme{
+	// Used by the Ledger Chrome app, MyEtherWallet and MyCrypto.
+	{"Ledger legacy", func(coinTypeKeypath string, index int) string {
+		return fmt.Sprintf("%s/0'/%d", coinTypeKeypath, index)
+	}},
+	{"Ledger Live", func(coinTypeKeypath string, index int) string {
+		return fmt.Sprintf("%s/%s/0/0", coinTypeKeypath, accountIndex(index))
+	}},
+}
+
+// scanEthereumLegacySchemes scans the legacy schemes below the keypath of the coin type until
+// ethereumLegacyGapLimit consecutive addresses are unused, and returns custom accounts for the used
+// addresses. Known keypaths are skipped, i.e. they neither count as used nor as unused.
+func scanEthereumLegacySchemes(
+	coinCode string,
+	coinTypeKeypath string,
+	known map[string]bool,
+	used func(keypath signing.AbsoluteKeypath) (bool, error),
+) ([]config.CustomAccount, error) {
+	customAccounts := []config.CustomAccount{}
+	for _, scheme := range ethereumLegacySchemes {
+		for index, unused := 0, 0; unused < ethereumLegacyGapLimit; index++ {
+			keypath, err := signing.NewAbsoluteKeypath(scheme.keypath(coinTypeKeypath, index))
+			if err != nil {
+				return nil, err
+			}
+			if known[keypath.Encode()] {
+				continue
+			}
+			isUsed, err := used(keypath)
+			if err != nil {
+				return nil, err
+			}
+			if !isUsed {
+				unused++
+				continue
+			}
+			unused = 0
+			customAccounts = append(customAccounts, config.CustomAccount{
+				Coin:    coinCode,
+				Name:    accountName(scheme.name, index),
+				Keypath: keypath.Encode(),
+			})
+		}
+	}
+	return customAccounts, nil
+}
+
+// ScanEthereumLegacyKeypaths scans the keypaths used by other wallets, e.g. Ledger legacy, for
+// addresses with funds or transactions on the network of the coin with the given code, and adds
+// custom accounts for them. It returns the number of added accounts.
+func (backend *Backend) ScanEthereumLegacyKeypaths(coinCode string) (int, error) {
+	accountType, ok := backend.ethAccountTypes()[coinCode]
+	if !ok {
+		return 0, errp.Newf("Unknown coin %s.", coinCode)
+	}
+	if backend.keystores.Count() == 0 {
+		return 0, errp.New("No keystore is connected.")
+	}
+	appConfig := backend.config.Config()
+	known := map[string]bool{}
+	for index := 0; index < backend.accountCount(accountType.code); index++ {
+		known[backend.accountKeypath(accountType, index)] = true
+	}
+	for _, customAccount := range appConfig.Backend.CustomAccounts {
+		if customAccount.Coin != coinCode {
+			continue
+		}
+		if keypath, err := signing.NewAbsoluteKeypath(customAccount.Keypath); err == nil {
+			known[keypath.Encode()] = true
+		}
+	}
+	coin := accountType.coin.(*eth.Coin)
+	coin.Initialize()
+	customAccounts, err := scanEthereumLegacySchemes(coinCode, accountType.keypath, known,
+		func(keypath signing.AbsoluteKeypath) (bool, error) {
+			signingConfiguration, err := backend.keystores.Configuration(
+				accountType.scriptType, keypath, backend.keystores.Count())
+			if err != nil {
+				return false, err
+			}
+			address := crypto.PubkeyToAddress(*signingConfiguration.PublicKeys()[0].ToECDSA())
+			return coin.AddressUsed(context.TODO(), address)
+		})
+	if err != nil {
+		return 0, err
+	}
+	if len(customAccounts) == 0 {
+		return 0, nil
+	}
+	appConfig.Backend.CustomAccounts = append(
+		append([]config.CustomAccount{}, appConfig.Backend.CustomAccounts...), customAccounts...)
+	if err := backend.config.Set(appConfig); err != nil {
+		return 0, err
+	}
+	backend.initAccounts()
+	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+	return len(customAccounts), nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanEthereumLegacySchemes(t *testing.T) {
+	usedKeypaths := map[string]bool{
+		"m/44'/60'/0'/0":   true,
+		"m/44'/60'/0'/4":   true,
+		"m/44'/60'/0'/10":  true, // Beyond the gap limit.
+		"m/44'/60'/0'/0/0": true,
+		"m/44'/60'/2'/0/0": true,
+	}
+	scanned := []string{}
+	customAccounts, err := scanEthereumLegacySchemes("eth", "m/44'/60'",
+		map[string]bool{"m/44'/60'/0'/0/0": true},
+		func(keypath signing.AbsoluteKeypath) (bool, error) {
+			scanned = append(scanned, keypath.Encode())
+			return usedKeypaths[keypath.Encode()], nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, []config.CustomAccount{
+		{Coin: "eth", Name: "Ledger legacy", Keypath: "m/44'/60'/0'/0"},
+		{Coin: "eth", Name: "Ledger legacy 5", Keypath: "m/44'/60'/0'/4"},
+		{Coin: "eth", Name: "Ledger Live 3", Keypath: "m/44'/60'/2'/0/0"},
+	}, customAccounts)
+	// The known first account is not scanned.
+	require.NotContains(t, scanned, "m/44'/60'/0'/0/0")
+	require.Contains(t, scanned, "m/44'/60'/0'/9")
+	require.NotContains(t, scanned, "m/44'/60'/0'/10")
+}
@@ -0,0 +1,178 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// checkEthereumRPCTimeout is the time within which a configured node has to respond.
+const checkEthereumRPCTimeout = 10 * time.Second
+
+// checkEthereumRPCURL returns an error if the URL cannot be used to connect to an Ethereum node.
+func checkEthereumRPCURL(rpcURL string) error {
+	parsed, err := neturl.Parse(rpcURL)
+	if err != nil {
+		return errp.WithMessage(err, "Invalid node URL")
+	}
+	switch parsed.Scheme {
+	case "http", "https", "ws", "wss":
+	default:
+		return errp.New("The node URL must start with http://, https://, ws:// or wss://.")
+	}
+	if parsed.Host == "" {
+		return errp.New("The node URL has no host.")
+	}
+	return nil
+}
+
+// SetEthereumRPC configures the node to which the Ethereum coin with the given code connects, e.g.
+// a self-hosted one, so that the addresses are not revealed to the default provider. An empty URL
+// restores the default provider. The accounts of the coin and of its ERC20 tokens are reloaded to
+// use the node.
+func (backend *Backend) SetEthereumRPC(coinCode string, rpcURL string) error {
+	accountType, ok := backend.ethAccountTypes()[coinCode]
+	if !ok {
+		return errp.Newf("Unknown coin %s.", coinCode)
+	}
+	rpcURL = strings.TrimSpace(rpcURL)
+	if rpcURL != "" {
+		if err := checkEthereumRPCURL(rpcURL); err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), checkEthereumRPCTimeout)
+		defer cancel()
+		if err := eth.CheckRPC(ctx, rpcURL, accountType.coin.(*eth.Coin).Net()); err != nil {
+			return err
+		}
+	}
+	appConfig := backend.config.Config()
+	ethereumRPCs := map[string]string{}
+	for code, configured := range appConfig.Backend.EthereumRPCs {
+		ethereumRPCs[code] = configured
+	}
+	if rpcURL == "" {
+		delete(ethereumRPCs, coinCode)
+	} else {
+		ethereumRPCs[coinCode] = rpcURL
+	}
+	appConfig.Backend.EthereumRPCs = ethereumRPCs
+	if err := backend.config.Set(appConfig); err != nil {
+		return err
+	}
+	// The coins connect when they are initialized, so they are replaced by new ones.
+	oldCoin := backend.removeEthereumCoin(coinCode)
+	backend.initAccounts()
+	oldCoin.Close()
+	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+	return nil
+}
+
+// removeEthereumCoin removes the Ethereum coin with the given code and the coins of its ERC20
+// tokens, so that they are created again when they are needed. The removed Ether coin is returned.
+func (backend *Backend) removeEthereumCoin(coinCode string) *eth.Coin {
+	defer backend.coinsLock.Lock()()
+	oldCoin := backend.coins[coinCode].(*eth.Coin)
+	for code := range backend.coins {
+		if code == coinCode || strings.HasPrefix(code, coinCode+"-erc20-") {
+			delete(backend.coins, code)
+		}
+	}
+	return oldCoin
+}
+
+// SetEthereumIndexer configures the Blockscout instance from which the Ethereum coin with the given
+// code fetches the transaction history, e.g. a self-hosted one. With eth.NodeIndexer, the token
+// transfers are reconstructed from the logs of the node instead. An empty URL restores Etherscan.
+// The accounts of the coin and of its ERC20 tokens are reloaded to use the indexer.
+func (backend *Backend) SetEthereumIndexer(coinCode string, indexerURL string) error {
+	if _, ok := backend.ethAccountTypes()[coinCode]; !ok {
+		return errp.Newf("Unknown coin %s.", coinCode)
+	}
+	indexerURL = strings.TrimSpace(indexerURL)
+	if indexerURL != "" && indexerURL != eth.NodeIndexer {
+		parsed, err := neturl.Parse(indexerURL)
+		if err != nil {
+			return errp.WithMessage(err, "Invalid indexer URL")
+		}
+		if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errp.New("The indexer URL must start with http:// or https://.")
+		}
+	}
+	appConfig := backend.config.Config()
+	ethereumIndexers := map[string]string{}
+	for code, configured := range appConfig.Backend.EthereumIndexers {
+		ethereumIndexers[code] = configured
+	}
+	if indexerURL == "" {
+		delete(ethereumIndexers, coinCode)
+	} else {
+		ethereumIndexers[coinCode] = indexerURL
+	}
+	appConfig.Backend.EthereumIndexers = ethereumIndexers
+	if err := backend.config.Set(appConfig); err != nil {
+		return err
+	}
+	oldCoin := backend.removeEthereumCoin(coinCode)
+	backend.initAccounts()
+	oldCoin.Close()
+	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+	return nil
+}
+
+// SetEthereumRelay configures the protected relay through which the Ethereum coin with the given
+// code broadcasts transactions, e.g. Flashbots Protect, so that they are not visible in the public
+// mempool before they are mined and cannot be front-run. An empty URL restores broadcasting to the
+// node. The accounts of the coin and of its ERC20 tokens are reloaded to use the relay.
+func (backend *Backend) SetEthereumRelay(coinCode string, relayURL string) error {
+	if _, ok := backend.ethAccountTypes()[coinCode]; !ok {
+		return errp.Newf("Unknown coin %s.", coinCode)
+	}
+	relayURL = strings.TrimSpace(relayURL)
+	if relayURL != "" {
+		parsed, err := neturl.Parse(relayURL)
+		if err != nil {
+			return errp.WithMessage(err, "Invalid relay URL")
+		}
+		if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errp.New("The relay URL must start with http:// or https://.")
+		}
+	}
+	appConfig := backend.config.Config()
+	ethereumRelays := map[string]string{}
+	for code, configured := range appConfig.Backend.EthereumRelays {
+		ethereumRelays[code] = configured
+	}
+	if relayURL == "" {
+		delete(ethereumRelays, coinCode)
+	} else {
+		ethereumRelays[coinCode] = relayURL
+	}
+	appConfig.Backend.EthereumRelays = ethereumRelays
+	if err := backend.config.Set(appConfig); err != nil {
+		return err
+	}
+	oldCoin := backend.removeEthereumCoin(coinCode)
+	backend.initAccounts()
+	oldCoin.Close()
+	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+	return nil
+}
@@ -0,0 +1,31 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckEthereumRPCURL(t *testing.T) {
+	require.NoError(t, checkEthereumRPCURL("http://127.0.0.1:8545"))
+	require.NoError(t, checkEthereumRPCURL("https://node.example.com/rpc"))
+	require.NoError(t, checkEthereumRPCURL("ws://192.168.1.10:8546"))
+	require.NoError(t, checkEthereumRPCURL("wss://node.example.com"))
+	require.Error(t, checkEthereumRPCURL("127.0.0.1:8545"))
+	require.Error(t, checkEthereumRPCURL("file:///home/user/.ethereum/geth.ipc"))
+	require.Error(t, checkEthereumRPCURL("http://"))
+}
@@ -27,12 +27,17 @@ import (
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
 	accountHandlers "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/handlers"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/devices/bitbox"
 	bitboxHandlers "github.com/digitalbitbox/bitbox-wallet-app/backend/devices/bitbox/handlers"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/devices/device"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/airgapped"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/hwi"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/walletconnect"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/jsonp"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
@@ -68,6 +73,36 @@ type Backend interface {
 	Rates() map[string]map[string]float64
 	DownloadCert(string) (string, error)
 	CheckElectrumServer(string, string) error
+	AddAccount(code string) error
+	ResyncAccount(code string) error
+	CustomAccountCoins() map[string][]signing.ScriptType
+	AddCustomAccount(coinCode string, name string, keypath string, scriptType signing.ScriptType) error
+	MultisigAccountCoins() []string
+	AddMultisigAccount(coinCode string, name string, keypath string, threshold int, cosigners []string) error
+	WatchOnlyAccountCoins() []string
+	AddWatchOnlyAccount(coinCode string, name string, input string) error
+	ERC20TokenCoins() []string
+	AddERC20Token(coinCode string, contract string) error
+	BlockExplorerTxPrefix(coin.Coin) string
+	SetBlockExplorer(coinCode string, txPrefix string) error
+	SetEthereumRPC(coinCode string, rpcURL string) error
+	SetEthereumIndexer(coinCode string, indexerURL string) error
+	SetEthereumRelay(coinCode string, relayURL string) error
+	ScanEthereumLegacyKeypaths(coinCode string) (int, error)
+	SoftwareKeystoreStatus() *backend.SoftwareKeystoreStatus
+	CreateSoftwareKeystore(mnemonic string, passphrase string, password string) error
+	UnlockSoftwareKeystore(password string) error
+	LockSoftwareKeystore() error
+	SoftwareKeystoreBIP85Mnemonic(words int, index uint32) (string, error)
+	HWIDevices() ([]*hwi.Device, error)
+	RegisterHWIKeystore(fingerprint string) error
+	DeregisterHWIKeystore() error
+	RegisterAirgappedKeystore(descriptor string) error
+	DeregisterAirgappedKeystore() error
+	AirgappedRequest() *airgapped.Request
+	ReceiveAirgappedPart(part string) error
+	AbortAirgappedRequest()
+	WalletConnect() (*walletconnect.Manager, error)
 }
 
 // Handlers provides a web api to the backend.
@@ -141,7 +176,40 @@ func NewHandlers(
 	getAPIRouter(apiRouter)("/version", handlers.getVersionHandler).Methods("GET")
 	getAPIRouter(apiRouter)("/testing", handlers.getTestingHandler).Methods("GET")
 	getAPIRouter(apiRouter)("/accounts", handlers.getAccountsHandler).Methods("GET")
+	getAPIRouter(apiRouter)("/accounts/add", handlers.postAddAccountHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/accounts/resync", handlers.postResyncAccountHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/accounts/custom", handlers.getCustomAccountCoinsHandler).Methods("GET")
+	getAPIRouter(apiRouter)("/accounts/custom", handlers.postAddCustomAccountHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/accounts/multisig", handlers.getMultisigAccountCoinsHandler).Methods("GET")
+	getAPIRouter(apiRouter)("/accounts/multisig", handlers.postAddMultisigAccountHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/accounts/watch-only", handlers.getWatchOnlyAccountCoinsHandler).Methods("GET")
+	getAPIRouter(apiRouter)("/accounts/watch-only", handlers.postAddWatchOnlyAccountHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/software-keystore", handlers.getSoftwareKeystoreHandler).Methods("GET")
+	getAPIRouter(apiRouter)("/software-keystore/create", handlers.postCreateSoftwareKeystoreHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/software-keystore/unlock", handlers.postUnlockSoftwareKeystoreHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/software-keystore/lock", handlers.postLockSoftwareKeystoreHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/software-keystore/bip85", handlers.postSoftwareKeystoreBIP85Handler).Methods("POST")
+	getAPIRouter(apiRouter)("/hwi/devices", handlers.getHWIDevicesHandler).Methods("GET")
+	getAPIRouter(apiRouter)("/hwi/register", handlers.postRegisterHWIKeystoreHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/hwi/deregister", handlers.postDeregisterHWIKeystoreHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/airgapped/register", handlers.postRegisterAirgappedKeystoreHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/airgapped/deregister", handlers.postDeregisterAirgappedKeystoreHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/airgapped/request", handlers.getAirgappedRequestHandler).Methods("GET")
+	getAPIRouter(apiRouter)("/airgapped/receive", handlers.postReceiveAirgappedPartHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/airgapped/abort", handlers.postAbortAirgappedRequestHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/accounts/erc20", handlers.getERC20TokenCoinsHandler).Methods("GET")
+	getAPIRouter(apiRouter)("/accounts/erc20", handlers.postAddERC20TokenHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/block-explorer", handlers.postBlockExplorerHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/ethereum-rpc", handlers.postEthereumRPCHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/ethereum-indexer", handlers.postEthereumIndexerHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/ethereum-relay", handlers.postEthereumRelayHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/ethereum-legacy-scan", handlers.postEthereumLegacyScanHandler).Methods("POST")
 	getAPIRouter(apiRouter)("/accounts-status", handlers.getAccountsStatusHandler).Methods("GET")
+	getAPIRouter(apiRouter)("/walletconnect", handlers.getWalletConnectHandler).Methods("GET")
+	getAPIRouter(apiRouter)("/walletconnect/pair", handlers.postWalletConnectPairHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/walletconnect/proposal", handlers.postWalletConnectProposalHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/walletconnect/request", handlers.postWalletConnectRequestHandler).Methods("POST")
+	getAPIRouter(apiRouter)("/walletconnect/disconnect", handlers.postWalletConnectDisconnectHandler).Methods("POST")
 	getAPIRouter(apiRouter)("/test/register", handlers.registerTestKeyStoreHandler).Methods("POST")
 	getAPIRouter(apiRouter)("/test/deregister", handlers.deregisterTestKeyStoreHandler).Methods("POST")
 	getAPIRouter(apiRouter)("/rates", handlers.getRatesHandler).Methods("GET")
@@ -149,6 +217,7 @@ func NewHandlers(
 	getAPIRouter(apiRouter)("/coins/convertFromFiat", handlers.getConvertFromFiatHandler).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/tltc/headers/status", handlers.getHeadersStatus("tltc")).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/tbtc/headers/status", handlers.getHeadersStatus("tbtc")).Methods("GET")
+	getAPIRouter(apiRouter)("/coins/sbtc/headers/status", handlers.getHeadersStatus("sbtc")).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/ltc/headers/status", handlers.getHeadersStatus("ltc")).Methods("GET")
 	getAPIRouter(apiRouter)("/coins/btc/headers/status", handlers.getHeadersStatus("btc")).Methods("GET")
 	getAPIRouter(apiRouter)("/certs/download", handlers.postCertsDownloadHandler).Methods("POST")
@@ -165,7 +234,7 @@ func NewHandlers(
 		if _, ok := accountHandlersMap[accountCode]; !ok {
 			accountHandlersMap[accountCode] = accountHandlers.NewHandlers(getAPIRouter(
 				apiRouter.PathPrefix(fmt.Sprintf("/account/%s", accountCode)).Subrouter(),
-			), log)
+			), backend.Rates, log)
 		}
 		accHandlers := accountHandlersMap[accountCode]
 		log.WithField("account-handlers", accHandlers).Debug("Account handlers")
@@ -265,22 +334,521 @@ func (handlers *Handlers) getTestingHandler(_ *http.Request) (interface{}, error
 func (handlers *Handlers) getAccountsHandler(_ *http.Request) (interface{}, error) {
 	type accountJSON struct {
 		CoinCode              string `json:"coinCode"`
+		CoinUnit              string `json:"coinUnit"`
 		Code                  string `json:"code"`
 		Name                  string `json:"name"`
 		BlockExplorerTxPrefix string `json:"blockExplorerTxPrefix"`
+		// WatchOnly is true if the account has no keystore and cannot send.
+		WatchOnly bool `json:"watchOnly"`
+		// FaucetURL is the URL of a faucet for the coin of a testnet, or empty.
+		FaucetURL string `json:"faucetURL"`
 	}
 	accounts := []*accountJSON{}
 	for _, account := range handlers.backend.Accounts() {
-		accounts = append(accounts, &accountJSON{
+		accountInfo := &accountJSON{
 			CoinCode:              account.Coin().Code(),
+			CoinUnit:              account.Coin().Unit(),
 			Code:                  account.Code(),
 			Name:                  account.Name(),
-			BlockExplorerTxPrefix: account.Coin().BlockExplorerTransactionURLPrefix(),
-		})
+			BlockExplorerTxPrefix: handlers.backend.BlockExplorerTxPrefix(account.Coin()),
+			WatchOnly:             account.Keystores().Count() == 0,
+		}
+		if ethCoin, ok := account.Coin().(*eth.Coin); ok {
+			accountInfo.FaucetURL = ethCoin.FaucetURL()
+		}
+		accounts = append(accounts, accountInfo)
 	}
 	return accounts, nil
 }
 
+func (handlers *Handlers) postAddAccountHandler(r *http.Request) (interface{}, error) {
+	var code string
+	if err := json.NewDecoder(r.Body).Decode(&code); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.AddAccount(code); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postResyncAccountHandler(r *http.Request) (interface{}, error) {
+	var code string
+	if err := json.NewDecoder(r.Body).Decode(&code); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.ResyncAccount(code); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) getCustomAccountCoinsHandler(_ *http.Request) (interface{}, error) {
+	return handlers.backend.CustomAccountCoins(), nil
+}
+
+func (handlers *Handlers) postAddCustomAccountHandler(r *http.Request) (interface{}, error) {
+	var customAccount struct {
+		CoinCode   string             `json:"coinCode"`
+		Name       string             `json:"name"`
+		Keypath    string             `json:"keypath"`
+		ScriptType signing.ScriptType `json:"scriptType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&customAccount); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.AddCustomAccount(customAccount.CoinCode, customAccount.Name,
+		customAccount.Keypath, customAccount.ScriptType); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) getMultisigAccountCoinsHandler(_ *http.Request) (interface{}, error) {
+	return handlers.backend.MultisigAccountCoins(), nil
+}
+
+func (handlers *Handlers) postAddMultisigAccountHandler(r *http.Request) (interface{}, error) {
+	var multisigAccount struct {
+		CoinCode  string   `json:"coinCode"`
+		Name      string   `json:"name"`
+		Keypath   string   `json:"keypath"`
+		Threshold int      `json:"threshold"`
+		Cosigners []string `json:"cosigners"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&multisigAccount); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.AddMultisigAccount(multisigAccount.CoinCode, multisigAccount.Name,
+		multisigAccount.Keypath, multisigAccount.Threshold, multisigAccount.Cosigners); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) getWatchOnlyAccountCoinsHandler(_ *http.Request) (interface{}, error) {
+	return handlers.backend.WatchOnlyAccountCoins(), nil
+}
+
+func (handlers *Handlers) postAddWatchOnlyAccountHandler(r *http.Request) (interface{}, error) {
+	var watchOnlyAccount struct {
+		CoinCode string `json:"coinCode"`
+		Name     string `json:"name"`
+		// XPub is an extended public key or an output descriptor.
+		XPub string `json:"xpub"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&watchOnlyAccount); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.AddWatchOnlyAccount(watchOnlyAccount.CoinCode, watchOnlyAccount.Name,
+		watchOnlyAccount.XPub); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) getSoftwareKeystoreHandler(_ *http.Request) (interface{}, error) {
+	return handlers.backend.SoftwareKeystoreStatus(), nil
+}
+
+func (handlers *Handlers) postCreateSoftwareKeystoreHandler(r *http.Request) (interface{}, error) {
+	var input struct {
+		Mnemonic   string `json:"mnemonic"`
+		Passphrase string `json:"passphrase"`
+		Password   string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.CreateSoftwareKeystore(
+		input.Mnemonic, input.Passphrase, input.Password); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postUnlockSoftwareKeystoreHandler(r *http.Request) (interface{}, error) {
+	var input struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	err := handlers.backend.UnlockSoftwareKeystore(input.Password)
+	if errp.Cause(err) == software.ErrWrongPassword {
+		return map[string]interface{}{"success": false, "errorCode": "wrongPassword"}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postLockSoftwareKeystoreHandler(_ *http.Request) (interface{}, error) {
+	if err := handlers.backend.LockSoftwareKeystore(); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postSoftwareKeystoreBIP85Handler(r *http.Request) (interface{}, error) {
+	var input struct {
+		Words int    `json:"words"`
+		Index uint32 `json:"index"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	mnemonic, err := handlers.backend.SoftwareKeystoreBIP85Mnemonic(input.Words, input.Index)
+	if err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true, "mnemonic": mnemonic}, nil
+}
+
+func (handlers *Handlers) getHWIDevicesHandler(_ *http.Request) (interface{}, error) {
+	devices, err := handlers.backend.HWIDevices()
+	if err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true, "devices": devices}, nil
+}
+
+func (handlers *Handlers) postRegisterHWIKeystoreHandler(r *http.Request) (interface{}, error) {
+	var input struct {
+		Fingerprint string `json:"fingerprint"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.RegisterHWIKeystore(input.Fingerprint); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postDeregisterHWIKeystoreHandler(_ *http.Request) (interface{}, error) {
+	if err := handlers.backend.DeregisterHWIKeystore(); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postRegisterAirgappedKeystoreHandler(r *http.Request) (interface{}, error) {
+	var input struct {
+		Descriptor string `json:"descriptor"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.RegisterAirgappedKeystore(input.Descriptor); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postDeregisterAirgappedKeystoreHandler(_ *http.Request) (interface{}, error) {
+	if err := handlers.backend.DeregisterAirgappedKeystore(); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) getAirgappedRequestHandler(_ *http.Request) (interface{}, error) {
+	return handlers.backend.AirgappedRequest(), nil
+}
+
+func (handlers *Handlers) postReceiveAirgappedPartHandler(r *http.Request) (interface{}, error) {
+	var input struct {
+		Part string `json:"part"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.ReceiveAirgappedPart(input.Part); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postAbortAirgappedRequestHandler(_ *http.Request) (interface{}, error) {
+	handlers.backend.AbortAirgappedRequest()
+	return nil, nil
+}
+
+func (handlers *Handlers) getERC20TokenCoinsHandler(_ *http.Request) (interface{}, error) {
+	return handlers.backend.ERC20TokenCoins(), nil
+}
+
+func (handlers *Handlers) postAddERC20TokenHandler(r *http.Request) (interface{}, error) {
+	var erc20Token struct {
+		CoinCode string `json:"coinCode"`
+		Contract string `json:"contract"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&erc20Token); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.AddERC20Token(erc20Token.CoinCode, erc20Token.Contract); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postBlockExplorerHandler(r *http.Request) (interface{}, error) {
+	var blockExplorer struct {
+		CoinCode string `json:"coinCode"`
+		TxPrefix string `json:"txPrefix"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&blockExplorer); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.SetBlockExplorer(blockExplorer.CoinCode, blockExplorer.TxPrefix); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postEthereumRPCHandler(r *http.Request) (interface{}, error) {
+	var ethereumRPC struct {
+		CoinCode string `json:"coinCode"`
+		URL      string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&ethereumRPC); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.SetEthereumRPC(ethereumRPC.CoinCode, ethereumRPC.URL); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postEthereumIndexerHandler(r *http.Request) (interface{}, error) {
+	var ethereumIndexer struct {
+		CoinCode string `json:"coinCode"`
+		URL      string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&ethereumIndexer); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.SetEthereumIndexer(ethereumIndexer.CoinCode, ethereumIndexer.URL); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postEthereumRelayHandler(r *http.Request) (interface{}, error) {
+	var ethereumRelay struct {
+		CoinCode string `json:"coinCode"`
+		URL      string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&ethereumRelay); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if err := handlers.backend.SetEthereumRelay(ethereumRelay.CoinCode, ethereumRelay.URL); err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postEthereumLegacyScanHandler(r *http.Request) (interface{}, error) {
+	var coinCode string
+	if err := json.NewDecoder(r.Body).Decode(&coinCode); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	added, err := handlers.backend.ScanEthereumLegacyKeypaths(coinCode)
+	if err != nil {
+		return map[string]interface{}{
+			"success":      false,
+			"errorMessage": err.Error(),
+		}, nil
+	}
+	return map[string]interface{}{"success": true, "added": added}, nil
+}
+
+func (handlers *Handlers) getWalletConnectHandler(_ *http.Request) (interface{}, error) {
+	manager, err := handlers.backend.WalletConnect()
+	if err != nil {
+		return nil, err
+	}
+	type proposal struct {
+		ID          int64                  `json:"id"`
+		AccountCode string                 `json:"accountCode"`
+		Peer        walletconnect.Metadata `json:"peer"`
+	}
+	type session struct {
+		Topic       string                 `json:"topic"`
+		AccountCode string                 `json:"accountCode"`
+		Peer        walletconnect.Metadata `json:"peer"`
+		Expiry      int64                  `json:"expiry"`
+	}
+	type request struct {
+		ID          int64                  `json:"id"`
+		AccountCode string                 `json:"accountCode"`
+		Peer        walletconnect.Metadata `json:"peer"`
+		Method      string                 `json:"method"`
+		Params      json.RawMessage        `json:"params"`
+	}
+	proposals := []proposal{}
+	for _, p := range manager.Proposals() {
+		proposals = append(proposals, proposal{ID: p.ID, AccountCode: p.AccountCode, Peer: p.Peer})
+	}
+	sessions := []session{}
+	for _, s := range manager.Sessions() {
+		sessions = append(sessions, session{
+			Topic: s.Topic, AccountCode: s.AccountCode, Peer: s.Peer, Expiry: s.Expiry.Unix()})
+	}
+	requests := []request{}
+	for _, r := range manager.Requests() {
+		requests = append(requests, request{
+			ID: r.ID, AccountCode: r.AccountCode, Peer: r.Peer, Method: r.Method, Params: r.Params})
+	}
+	return map[string]interface{}{
+		"proposals": proposals,
+		"sessions":  sessions,
+		"requests":  requests,
+	}, nil
+}
+
+func (handlers *Handlers) postWalletConnectPairHandler(r *http.Request) (interface{}, error) {
+	var input struct {
+		AccountCode string `json:"accountCode"`
+		URI         string `json:"uri"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	manager, err := handlers.backend.WalletConnect()
+	if err == nil {
+		err = manager.Pair(input.AccountCode, input.URI)
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+// walletConnectApproval is the decision of the user about a proposal or request.
+type walletConnectApproval struct {
+	ID      int64 `json:"id"`
+	Approve bool  `json:"approve"`
+}
+
+func (handlers *Handlers) postWalletConnectProposalHandler(r *http.Request) (interface{}, error) {
+	var input walletConnectApproval
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	manager, err := handlers.backend.WalletConnect()
+	if err == nil {
+		if input.Approve {
+			err = manager.ApproveProposal(input.ID)
+		} else {
+			err = manager.RejectProposal(input.ID)
+		}
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postWalletConnectRequestHandler(r *http.Request) (interface{}, error) {
+	var input walletConnectApproval
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	manager, err := handlers.backend.WalletConnect()
+	if err == nil {
+		if input.Approve {
+			err = manager.ApproveRequest(input.ID)
+		} else {
+			err = manager.RejectRequest(input.ID)
+		}
+	}
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		return map[string]interface{}{"success": false}, nil
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (handlers *Handlers) postWalletConnectDisconnectHandler(r *http.Request) (interface{}, error) {
+	var topic string
+	if err := json.NewDecoder(r.Body).Decode(&topic); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	manager, err := handlers.backend.WalletConnect()
+	if err == nil {
+		err = manager.Disconnect(topic)
+	}
+	if err != nil {
+		return map[string]interface{}{"success": false, "errorMessage": err.Error()}, nil
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
 func (handlers *Handlers) getAccountsStatusHandler(_ *http.Request) (interface{}, error) {
 	return handlers.backend.AccountsStatus(), nil
 }
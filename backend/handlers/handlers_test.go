@@ -34,7 +34,7 @@ func TestListRoutes(t *testing.T) {
 	}
 	connectionData := handlers.NewConnectionData(8082, "")
 	backend := backend.NewBackend(arguments.NewArguments(
-		test.TstTempDir("bitbox-wallet-listroutes-"), false, false, false, false))
+		test.TstTempDir("bitbox-wallet-listroutes-"), false, false, "", false, false))
 	handlers := handlers.NewHandlers(backend, connectionData)
 	err := handlers.Router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
 		pathTemplate, err := route.GetPathTemplate()
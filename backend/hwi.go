@@ -0,0 +1,85 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"context"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/hwi"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// hwiClient returns the client of the HWI executable of the config.
+func (backend *Backend) hwiClient() (*hwi.Client, error) {
+	hwiPath := backend.config.Config().Backend.HWIPath
+	if hwiPath == "" {
+		return nil, errp.New("HWI is not configured.")
+	}
+	return hwi.NewClient(hwiPath), nil
+}
+
+// HWIDevices returns the devices which can be used through HWI.
+func (backend *Backend) HWIDevices() ([]*hwi.Device, error) {
+	client, err := backend.hwiClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.Enumerate(context.Background())
+}
+
+// RegisterHWIKeystore registers the keystore of the device with the given root fingerprint, see
+// HWIDevices. It fails if another keystore is registered or if the device is locked.
+func (backend *Backend) RegisterHWIKeystore(fingerprint string) error {
+	client, err := backend.hwiClient()
+	if err != nil {
+		return err
+	}
+	if backend.keystores.Count() != 0 {
+		return errp.New("Another keystore is registered.")
+	}
+	devices, err := client.Enumerate(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, device := range devices {
+		if device.Fingerprint != fingerprint {
+			continue
+		}
+		if device.NeedsPinSent || device.NeedsPassphraseSent {
+			return errp.New("Please unlock the device first.")
+		}
+		chain := hwi.ChainMain
+		if backend.Testing() {
+			chain = hwi.ChainTest
+		}
+		hwiKeystore, err := hwi.NewKeystore(client, 0, fingerprint, chain, backend.log.WithField("hwi", device.Type))
+		if err != nil {
+			return err
+		}
+		backend.hwiKeystore = hwiKeystore
+		backend.RegisterKeystore(hwiKeystore)
+		return nil
+	}
+	return errp.New("The device was not found.")
+}
+
+// DeregisterHWIKeystore deregisters the keystore registered with RegisterHWIKeystore.
+func (backend *Backend) DeregisterHWIKeystore() error {
+	if backend.hwiKeystore == nil {
+		return errp.New("No HWI device is registered.")
+	}
+	backend.DeregisterKeystore()
+	return nil
+}
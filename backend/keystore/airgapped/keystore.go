@@ -0,0 +1,391 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package airgapped
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	keystorePkg "github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/observable"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/observable/action"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/ur"
+	"github.com/sirupsen/logrus"
+)
+
+// maxFragmentLength is the maximum number of bytes of the PSBT in one QR code. Smaller QR codes
+// are scanned more reliably by the low resolution cameras of air-gapped signers.
+const maxFragmentLength = 100
+
+// Request is a PSBT waiting to be signed by the air-gapped signer.
+type Request struct {
+	// Parts are the frames of the animated QR code of the PSBT, in upper case so that they are
+	// encoded compactly in QR codes. The frames are to be shown in a loop.
+	Parts []string `json:"parts"`
+	// Progress is the share of the signed PSBT which has been received, between 0 and 1.
+	Progress float64 `json:"progress"`
+}
+
+// signingRequest is the pending request together with the decoder of the signed PSBT.
+type signingRequest struct {
+	request *Request
+	decoder *ur.Decoder
+	// done receives the signed PSBT, or nil if the request is aborted.
+	done chan *psbt.Packet
+}
+
+// Keystore implements a keystore for an air-gapped signer like SeedSigner or Keystone. It is set
+// up with the output descriptor of the singlesig account of the signer and only knows the keys of
+// that account. Transactions are exchanged as PSBTs encoded as animated QR codes (UR): the PSBT is
+// published as Request and blocks until the signed PSBT is received with ReceivePart.
+type Keystore struct {
+	observable.Implementation
+
+	cosignerIndex   int
+	configuration   *signing.Configuration
+	rootFingerprint []byte
+	net             *chaincfg.Params
+
+	request     *signingRequest
+	requestLock locker.Locker
+
+	log *logrus.Entry
+}
+
+// NewKeystore returns a keystore for the account with the given output descriptor, e.g.
+// `wpkh([d34db33f/84h/0h/0h]xpub.../<0;1>/*)`. The key origin is required, as the signer matches
+// the inputs of the PSBT by the root fingerprint.
+func NewKeystore(
+	cosignerIndex int, descriptor string, net *chaincfg.Params, log *logrus.Entry) (*Keystore, error) {
+	configuration, fingerprints, err := btc.ParseDescriptorWithFingerprints(descriptor, net)
+	if err != nil {
+		return nil, err
+	}
+	if !configuration.Singlesig() {
+		return nil, errp.New("Only singlesig descriptors are supported.")
+	}
+	switch configuration.ScriptType() {
+	case signing.ScriptTypeP2PKH, signing.ScriptTypeP2WPKHP2SH, signing.ScriptTypeP2WPKH:
+	default:
+		return nil, errp.Newf("%s accounts are not supported.", configuration.ScriptType())
+	}
+	if fingerprints[0] == nil || len(configuration.AbsoluteKeypath()) == 0 {
+		return nil, errp.New("The descriptor needs the key origin of the account, e.g. [d34db33f/84h/0h/0h].")
+	}
+	return &Keystore{
+		cosignerIndex:   cosignerIndex,
+		configuration:   configuration,
+		rootFingerprint: fingerprints[0],
+		net:             net,
+		log:             log.WithField("keypath", configuration.AbsoluteKeypath().Encode()),
+	}, nil
+}
+
+// notifyRequest notifies the observers of the pending request, which is nil once the request is
+// done. The lock must be held.
+func (keystore *Keystore) notifyRequest() {
+	var request *Request
+	if keystore.request != nil {
+		request = keystore.request.request
+	}
+	keystore.Notify(observable.Event{
+		Subject: "airgapped/request",
+		Action:  action.Replace,
+		Object:  request,
+	})
+}
+
+// Request returns the PSBT waiting to be signed, or nil if there is none.
+func (keystore *Keystore) Request() *Request {
+	defer keystore.requestLock.RLock()()
+	if keystore.request == nil {
+		return nil
+	}
+	return keystore.request.request
+}
+
+// ReceivePart adds a part of the signed PSBT, e.g. scanned from the animated QR code of the
+// signer. The signing continues once all parts are received.
+func (keystore *Keystore) ReceivePart(part string) error {
+	defer keystore.requestLock.Lock()()
+	signingRequest := keystore.request
+	if signingRequest == nil {
+		return errp.New("No transaction is waiting to be signed.")
+	}
+	if signingRequest.decoder.Result() != nil {
+		return nil
+	}
+	if err := signingRequest.decoder.Receive(part); err != nil {
+		return err
+	}
+	signingRequest.request = &Request{
+		Parts:    signingRequest.request.Parts,
+		Progress: signingRequest.decoder.Progress(),
+	}
+	keystore.notifyRequest()
+	result := signingRequest.decoder.Result()
+	if result == nil {
+		return nil
+	}
+	packet, err := decodePSBT(result)
+	if err != nil {
+		// Start over, as the signer may have sent another UR.
+		signingRequest.decoder = ur.NewDecoder()
+		signingRequest.request = &Request{Parts: signingRequest.request.Parts}
+		keystore.notifyRequest()
+		return err
+	}
+	signingRequest.done <- packet
+	return nil
+}
+
+// AbortRequest aborts the pending request, so that the signing returns ErrSigningAborted.
+func (keystore *Keystore) AbortRequest() {
+	defer keystore.requestLock.RLock()()
+	if keystore.request == nil {
+		return
+	}
+	select {
+	case keystore.request.done <- nil:
+	default:
+	}
+}
+
+// decodePSBT decodes the PSBT of a UR. Besides crypto-psbt, the deprecated type psbt is accepted,
+// which some signers still use.
+func decodePSBT(result *ur.UR) (*psbt.Packet, error) {
+	if result.Type != ur.TypeCryptoPSBT && result.Type != "psbt" {
+		return nil, errp.Newf("Expected a PSBT, got a UR of type %s.", result.Type)
+	}
+	data, err := ur.DecodeCBORBytes(result.CBOR)
+	if err != nil {
+		return nil, err
+	}
+	return psbt.Parse(bytes.NewReader(data))
+}
+
+// encodePSBT returns the frames of the animated QR code of the PSBT. Multipart URs repeat with
+// twice the number of fragments, so that frames which the camera misses in the first loop are
+// made up for by the mixed parts.
+func encodePSBT(packet *psbt.Packet) ([]string, error) {
+	var buffer bytes.Buffer
+	if err := packet.Serialize(&buffer); err != nil {
+		return nil, err
+	}
+	encoder, err := ur.NewEncoder(
+		&ur.UR{Type: ur.TypeCryptoPSBT, CBOR: ur.EncodeCBORBytes(buffer.Bytes())}, maxFragmentLength)
+	if err != nil {
+		return nil, err
+	}
+	count := 1
+	if encoder.SeqLen() > 1 {
+		count = 2 * encoder.SeqLen()
+	}
+	parts := make([]string, count)
+	for index := range parts {
+		parts[index] = strings.ToUpper(encoder.NextPart())
+	}
+	return parts, nil
+}
+
+// SignPSBT implements keystore.Keystore.
+func (keystore *Keystore) SignPSBT(packet *psbt.Packet) (*psbt.Packet, error) {
+	return keystore.signPSBT(context.Background(), packet)
+}
+
+// signPSBT publishes the PSBT as request and waits until the signed PSBT is received, the request
+// is aborted or the context is done.
+func (keystore *Keystore) signPSBT(ctx context.Context, packet *psbt.Packet) (*psbt.Packet, error) {
+	parts, err := encodePSBT(packet)
+	if err != nil {
+		return nil, err
+	}
+	signingRequest := &signingRequest{
+		request: &Request{Parts: parts},
+		decoder: ur.NewDecoder(),
+		done:    make(chan *psbt.Packet, 1),
+	}
+	unlock := keystore.requestLock.Lock()
+	if keystore.request != nil {
+		unlock()
+		return nil, errp.New("Another transaction is waiting to be signed.")
+	}
+	keystore.request = signingRequest
+	keystore.notifyRequest()
+	unlock()
+	defer func() {
+		defer keystore.requestLock.Lock()()
+		keystore.request = nil
+		keystore.notifyRequest()
+	}()
+	keystore.log.WithField("parts", len(parts)).Info("Waiting for the signed PSBT.")
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case signedPacket := <-signingRequest.done:
+		if signedPacket == nil {
+			return nil, errp.WithStack(keystorePkg.ErrSigningAborted)
+		}
+		if signedPacket.UnsignedTx.TxHash() != packet.UnsignedTx.TxHash() {
+			return nil, errp.New("The signer returned another transaction.")
+		}
+		return signedPacket, nil
+	}
+}
+
+// CosignerIndex implements keystore.Keystore.
+func (keystore *Keystore) CosignerIndex() int {
+	return keystore.cosignerIndex
+}
+
+// SupportsCoin implements keystore.Keystore. Only the Bitcoin network of the descriptor is
+// supported.
+func (keystore *Keystore) SupportsCoin(coin coin.Coin) bool {
+	btcCoin, ok := coin.(*btc.Coin)
+	return ok && btcCoin.Net().Net == keystore.net.Net
+}
+
+// SupportsScriptType implements keystore.Keystore. Only the script type of the descriptor is
+// supported.
+func (keystore *Keystore) SupportsScriptType(coin coin.Coin, scriptType signing.ScriptType) bool {
+	return keystore.SupportsCoin(coin) && scriptType == keystore.configuration.ScriptType()
+}
+
+// SupportsAccount implements keystore.Keystore.
+func (keystore *Keystore) SupportsAccount(
+	coin coin.Coin, scriptType signing.ScriptType, multisig bool) bool {
+	return !multisig && keystore.SupportsScriptType(coin, scriptType)
+}
+
+// HasSecureOutput implements keystore.Keystore. Addresses cannot be sent to the signer.
+func (keystore *Keystore) HasSecureOutput() bool {
+	return false
+}
+
+// OutputAddress implements keystore.Keystore.
+func (keystore *Keystore) OutputAddress(signing.AbsoluteKeypath, signing.ScriptType, coin.Coin) error {
+	return errp.New("An air-gapped signer has no secure output.")
+}
+
+// BatchOutputAddress implements keystore.Keystore.
+func (keystore *Keystore) BatchOutputAddress(
+	[]signing.AbsoluteKeypath, signing.ScriptType, coin.Coin) error {
+	return errp.New("An air-gapped signer has no secure output.")
+}
+
+// ExtendedPublicKey implements keystore.Keystore. Only the keys at and below the keypath of the
+// account can be derived. Returns ErrUnknownKeypath for all other keys.
+func (keystore *Keystore) ExtendedPublicKey(
+	keyPath signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
+	accountPath := keystore.configuration.AbsoluteKeypath().ToUInt32()
+	path := keyPath.ToUInt32()
+	if len(path) < len(accountPath) {
+		return nil, errp.WithStack(keystorePkg.ErrUnknownKeypath)
+	}
+	for index, node := range accountPath {
+		if path[index] != node {
+			return nil, errp.WithStack(keystorePkg.ErrUnknownKeypath)
+		}
+	}
+	extendedPublicKey := keystore.configuration.ExtendedPublicKeys()[0]
+	for _, node := range path[len(accountPath):] {
+		if node >= hdkeychain.HardenedKeyStart {
+			return nil, errp.WithStack(keystorePkg.ErrUnknownKeypath)
+		}
+		var err error
+		extendedPublicKey, err = extendedPublicKey.Child(node)
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+	}
+	return extendedPublicKey, nil
+}
+
+// RootFingerprint implements keystore.Keystore. It is the fingerprint of the key origin of the
+// descriptor.
+func (keystore *Keystore) RootFingerprint() ([]byte, error) {
+	return keystore.rootFingerprint, nil
+}
+
+// SignMessage implements keystore.Keystore.
+func (keystore *Keystore) SignMessage(signing.AbsoluteKeypath, []byte, coin.Coin) ([]byte, error) {
+	return nil, errp.New("Messages cannot be signed with an air-gapped signer.")
+}
+
+// SignTypedData implements keystore.Keystore.
+func (keystore *Keystore) SignTypedData(signing.AbsoluteKeypath, []byte) ([]byte, error) {
+	return nil, errp.New("An air-gapped signer does not support Ethereum.")
+}
+
+// SignTypedDataContext implements keystore.ContextKeystore.
+func (keystore *Keystore) SignTypedDataContext(
+	context.Context, signing.AbsoluteKeypath, []byte) ([]byte, error) {
+	return nil, errp.New("An air-gapped signer does not support Ethereum.")
+}
+
+// SignTransaction implements keystore.Keystore.
+func (keystore *Keystore) SignTransaction(proposedTransaction coin.ProposedTransaction) error {
+	return keystore.SignTransactionContext(context.Background(), proposedTransaction)
+}
+
+// OutputAddressContext implements keystore.ContextKeystore.
+func (keystore *Keystore) OutputAddressContext(context.Context,
+	signing.AbsoluteKeypath, signing.ScriptType, coin.Coin) error {
+	return errp.New("An air-gapped signer has no secure output.")
+}
+
+// ExtendedPublicKeyContext implements keystore.ContextKeystore.
+func (keystore *Keystore) ExtendedPublicKeyContext(
+	_ context.Context, keyPath signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
+	return keystore.ExtendedPublicKey(keyPath)
+}
+
+// SignTransactionContext implements keystore.ContextKeystore. The transaction is signed as PSBT,
+// see btc.ProposedTransaction.KeystorePSBT. It waits until the signed PSBT is received or the
+// context is done, e.g. when the signing is cancelled in the app.
+func (keystore *Keystore) SignTransactionContext(
+	ctx context.Context, proposedTransaction coin.ProposedTransaction) error {
+	btcProposedTx, ok := proposedTransaction.(*btc.ProposedTransaction)
+	if !ok {
+		return errp.New("An air-gapped signer can only sign Bitcoin transactions.")
+	}
+	if err := btcProposedTx.CheckCanSign(keystore); err != nil {
+		return err
+	}
+	keystore.log.Info("Sign transaction.")
+	signatureHashes, err := btcProposedTx.SignatureHashes()
+	if err != nil {
+		return err
+	}
+	packet, err := btcProposedTx.KeystorePSBT(keystore, signatureHashes)
+	if err != nil {
+		return err
+	}
+	signedPacket, err := keystore.signPSBT(ctx, packet)
+	if err != nil {
+		return err
+	}
+	return btcProposedTx.AddKeystorePSBTSignatures(keystore, signatureHashes, signedPacket)
+}
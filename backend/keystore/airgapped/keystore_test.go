@@ -0,0 +1,213 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package airgapped_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
+	keystorePkg "github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/airgapped"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/observable"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/ur"
+	"github.com/stretchr/testify/require"
+)
+
+var testKeypath = "m/84'/1'/0'"
+
+// newKeystore returns the air-gapped keystore of the account of the given software keystore.
+func newKeystore(t *testing.T, signer *software.Keystore) *airgapped.Keystore {
+	t.Helper()
+	keypath, err := signing.NewAbsoluteKeypath(testKeypath)
+	require.NoError(t, err)
+	xpub, err := signer.ExtendedPublicKey(keypath)
+	require.NoError(t, err)
+	rootFingerprint, err := signer.RootFingerprint()
+	require.NoError(t, err)
+	descriptor := "wpkh([" + hex.EncodeToString(rootFingerprint) + "/84h/1h/0h]" + xpub.String() + "/<0;1>/*)"
+	keystore, err := airgapped.NewKeystore(
+		0, descriptor, &chaincfg.TestNet3Params, logging.Get().WithGroup("airgapped_test"))
+	require.NoError(t, err)
+	return keystore
+}
+
+// newTxProposal returns a transaction spending two outputs of the first receive address of the
+// account.
+func newTxProposal(t *testing.T, keystore *airgapped.Keystore) (
+	*maketx.TxProposal, map[wire.OutPoint]*transactions.SpendableOutput,
+	func(blockchain.ScriptHashHex) *addresses.AccountAddress) {
+	t.Helper()
+	keypath, err := signing.NewAbsoluteKeypath(testKeypath)
+	require.NoError(t, err)
+	xpub, err := keystore.ExtendedPublicKey(keypath)
+	require.NoError(t, err)
+	accountConfiguration := signing.NewSinglesigConfiguration(signing.ScriptTypeP2WPKH, keypath, xpub)
+	relativeKeypath, err := signing.NewRelativeKeypath("0/0")
+	require.NoError(t, err)
+	addressConfiguration, err := accountConfiguration.Derive(relativeKeypath)
+	require.NoError(t, err)
+	address := addresses.NewAccountAddress(
+		addressConfiguration, &chaincfg.TestNet3Params, logging.Get().WithGroup("airgapped_test"))
+
+	transaction := wire.NewMsgTx(wire.TxVersion)
+	previousOutputs := map[wire.OutPoint]*transactions.SpendableOutput{}
+	for i, value := range []int64{100000, 200000} {
+		outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte{byte(i)}), Index: uint32(i)}
+		transaction.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+		previousOutputs[outPoint] = &transactions.SpendableOutput{
+			TxOut: wire.NewTxOut(value, address.PubkeyScript()),
+		}
+	}
+	transaction.AddTxOut(wire.NewTxOut(250000, address.PubkeyScript()))
+	txProposal := &maketx.TxProposal{
+		Coin:                 btc.NewCoin("tbtc", "TBTC", &chaincfg.TestNet3Params, ".", nil, ""),
+		AccountConfiguration: accountConfiguration,
+		Transaction:          transaction,
+	}
+	getAddress := func(blockchain.ScriptHashHex) *addresses.AccountAddress { return address }
+	return txProposal, previousOutputs, getAddress
+}
+
+// requests returns the channel receiving the requests of the keystore, without the updates of the
+// progress of a request.
+func requests(keystore *airgapped.Keystore) chan *airgapped.Request {
+	requests := make(chan *airgapped.Request, 10)
+	keystore.Observe(func(event observable.Event) {
+		request := event.Object.(*airgapped.Request)
+		if request == nil || request.Progress == 0 {
+			requests <- request
+		}
+	})
+	return requests
+}
+
+func TestExtendedPublicKey(t *testing.T) {
+	signer := software.NewKeystoreFromPIN(0, "1234")
+	keystore := newKeystore(t, signer)
+	for _, encodedKeypath := range []string{testKeypath, testKeypath + "/1/5"} {
+		keypath, err := signing.NewAbsoluteKeypath(encodedKeypath)
+		require.NoError(t, err)
+		expected, err := signer.ExtendedPublicKey(keypath)
+		require.NoError(t, err)
+		xpub, err := keystore.ExtendedPublicKey(keypath)
+		require.NoError(t, err)
+		require.Equal(t, expected.String(), xpub.String())
+	}
+	for _, encodedKeypath := range []string{"m/", "m/84'/1'/1'", testKeypath + "/0'"} {
+		keypath, err := signing.NewAbsoluteKeypath(encodedKeypath)
+		require.NoError(t, err)
+		_, err = keystore.ExtendedPublicKey(keypath)
+		require.Equal(t, keystorePkg.ErrUnknownKeypath, errp.Cause(err), encodedKeypath)
+	}
+
+	rootFingerprint, err := signer.RootFingerprint()
+	require.NoError(t, err)
+	fingerprint, err := keystore.RootFingerprint()
+	require.NoError(t, err)
+	require.Equal(t, rootFingerprint, fingerprint)
+}
+
+func TestNewKeystore(t *testing.T) {
+	signer := software.NewKeystoreFromPIN(0, "1234")
+	keypath, err := signing.NewAbsoluteKeypath(testKeypath)
+	require.NoError(t, err)
+	xpub, err := signer.ExtendedPublicKey(keypath)
+	require.NoError(t, err)
+	log := logging.Get().WithGroup("airgapped_test")
+	for _, descriptor := range []string{
+		// The key origin is missing.
+		"wpkh(" + xpub.String() + "/<0;1>/*)",
+		"tr([d34db33f/86h/1h/0h]" + xpub.String() + "/<0;1>/*)",
+		"wsh(sortedmulti(1,[d34db33f/48h/1h/0h/2h]" + xpub.String() + ",[d34db33f/48h/1h/0h/2h]" +
+			xpub.String() + "))",
+	} {
+		_, err := airgapped.NewKeystore(0, descriptor, &chaincfg.TestNet3Params, log)
+		require.Error(t, err, descriptor)
+	}
+}
+
+func TestSignTransaction(t *testing.T) {
+	signer := software.NewKeystoreFromPIN(0, "1234")
+	keystore := newKeystore(t, signer)
+	require.Nil(t, keystore.Request())
+	requests := requests(keystore)
+
+	// The signer scans the PSBT and shows the signed PSBT as animated QR code.
+	go func() {
+		request := <-requests
+		decoder := ur.NewDecoder()
+		for _, part := range request.Parts {
+			require.NoError(t, decoder.Receive(part))
+		}
+		require.NotNil(t, decoder.Result())
+		data, err := ur.DecodeCBORBytes(decoder.Result().CBOR)
+		require.NoError(t, err)
+		packet, err := psbt.Parse(bytes.NewReader(data))
+		require.NoError(t, err)
+		signedPacket, err := signer.SignPSBT(packet)
+		require.NoError(t, err)
+		var buffer bytes.Buffer
+		require.NoError(t, signedPacket.Serialize(&buffer))
+		encoder, err := ur.NewEncoder(
+			&ur.UR{Type: ur.TypeCryptoPSBT, CBOR: ur.EncodeCBORBytes(buffer.Bytes())}, 50)
+		require.NoError(t, err)
+		require.Error(t, keystore.ReceivePart("ur:bytes/aeadaolazmjendeoti"))
+		// The keystore has received the signed PSBT once it could be decoded from the parts.
+		signedDecoder := ur.NewDecoder()
+		for signedDecoder.Result() == nil {
+			part := encoder.NextPart()
+			require.NoError(t, signedDecoder.Receive(part))
+			require.NoError(t, keystore.ReceivePart(part))
+		}
+	}()
+
+	txProposal, previousOutputs, getAddress := newTxProposal(t, keystore)
+	// The signatures are verified when the transaction is finalized.
+	require.NoError(t, btc.SignTransaction(keystorePkg.NewKeystores(keystore), txProposal,
+		previousOutputs, getAddress, nil, nil, nil, logging.Get().WithGroup("airgapped_test")))
+	for _, txIn := range txProposal.Transaction.TxIn {
+		require.Len(t, txIn.Witness, 2)
+	}
+	require.Nil(t, keystore.Request())
+	require.Error(t, keystore.ReceivePart("ur:bytes/aeadaolazmjendeoti"))
+}
+
+func TestSignTransactionAborted(t *testing.T) {
+	keystore := newKeystore(t, software.NewKeystoreFromPIN(0, "1234"))
+	requests := requests(keystore)
+	go func() {
+		<-requests
+		keystore.AbortRequest()
+	}()
+	txProposal, previousOutputs, getAddress := newTxProposal(t, keystore)
+	err := btc.SignTransaction(keystorePkg.NewKeystores(keystore), txProposal,
+		previousOutputs, getAddress, nil, nil, nil, logging.Get().WithGroup("airgapped_test"))
+	require.Equal(t, keystorePkg.ErrSigningAborted, errp.Cause(err))
+	require.Nil(t, <-requests)
+}
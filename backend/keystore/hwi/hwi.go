@@ -0,0 +1,136 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hwi provides a keystore for the hardware wallets supported by HWI
+// (https://github.com/bitcoin-core/HWI), e.g. Trezor, Ledger and Coldcard devices. HWI is not
+// bundled with the app: its command line tool is run for every operation.
+package hwi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// Chain is the network of the coins with which HWI operates, e.g. to derive the keys of testnet
+// coins.
+type Chain string
+
+const (
+	// ChainMain is the Bitcoin mainnet.
+	ChainMain Chain = "main"
+	// ChainTest is the Bitcoin testnet.
+	ChainTest Chain = "test"
+	// ChainSignet is the Bitcoin signet.
+	ChainSignet Chain = "signet"
+	// ChainRegtest is the Bitcoin regtest network.
+	ChainRegtest Chain = "regtest"
+)
+
+// errCodeActionCanceled is the code of the error returned by HWI when the user rejects the
+// operation on the device.
+const errCodeActionCanceled = -14
+
+// Error is an error reported by HWI.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"error"`
+}
+
+// Error implements error.
+func (err *Error) Error() string {
+	return fmt.Sprintf("HWI error %d: %s", err.Code, err.Message)
+}
+
+// Device is a hardware wallet found by HWI.
+type Device struct {
+	// Type is the kind of device, e.g. "trezor", "ledger" or "coldcard".
+	Type  string `json:"type"`
+	Model string `json:"model"`
+	Path  string `json:"path"`
+	// Fingerprint is the hex encoded root fingerprint of the seed of the device. It is empty if
+	// the device is locked.
+	Fingerprint string `json:"fingerprint"`
+	// NeedsPinSent is true if the device has to be unlocked before it can be used.
+	NeedsPinSent bool `json:"needs_pin_sent"`
+	// NeedsPassphraseSent is true if the passphrase has to be entered before it can be used.
+	NeedsPassphraseSent bool `json:"needs_passphrase_sent"`
+	// Error describes why the device cannot be used, if it is not empty.
+	Error string `json:"error"`
+}
+
+// Client runs the HWI command line tool.
+type Client struct {
+	// run runs HWI with the given arguments and returns its standard output. It stops HWI when
+	// the context is done.
+	run func(ctx context.Context, args ...string) ([]byte, error)
+}
+
+// NewClient returns a client which runs the HWI executable at the given path.
+func NewClient(executable string) *Client {
+	return &Client{
+		run: func(ctx context.Context, args ...string) ([]byte, error) {
+			var stdout, stderr bytes.Buffer
+			cmd := exec.CommandContext(ctx, executable, args...)
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			err := cmd.Run()
+			if ctx.Err() != nil {
+				return nil, errp.WithStack(ctx.Err())
+			}
+			// HWI reports its errors as JSON with a non-zero exit code.
+			if err != nil && stdout.Len() == 0 {
+				return nil, errp.WithMessage(errp.WithStack(err),
+					fmt.Sprintf("Failed to run HWI: %s", strings.TrimSpace(stderr.String())))
+			}
+			return stdout.Bytes(), nil
+		},
+	}
+}
+
+// call runs HWI with the given arguments and decodes its output into result. If fingerprint is
+// not empty, the command is sent to the device with the given root fingerprint.
+func (client *Client) call(
+	ctx context.Context, fingerprint string, chain Chain, result interface{}, args ...string) error {
+	globalArgs := []string{"--chain", string(chain)}
+	if fingerprint != "" {
+		globalArgs = append(globalArgs, "--fingerprint", fingerprint)
+	}
+	output, err := client.run(ctx, append(globalArgs, args...)...)
+	if err != nil {
+		return err
+	}
+	hwiErr := &Error{}
+	if err := json.Unmarshal(output, hwiErr); err == nil && hwiErr.Message != "" {
+		return errp.WithStack(hwiErr)
+	}
+	if err := json.Unmarshal(output, result); err != nil {
+		return errp.WithMessage(errp.WithStack(err), "Unexpected output of HWI")
+	}
+	return nil
+}
+
+// Enumerate returns the devices which are connected to this computer.
+func (client *Client) Enumerate(ctx context.Context) ([]*Device, error) {
+	devices := []*Device{}
+	if err := client.call(ctx, "", ChainMain, &devices, "enumerate"); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
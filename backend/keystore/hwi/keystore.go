@@ -0,0 +1,321 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hwi
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/signet"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	keystorePkg "github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+	"github.com/sirupsen/logrus"
+)
+
+// Keystore implements a keystore for a device which is used through HWI. Transactions are signed
+// as PSBTs, so only Bitcoin singlesig accounts with segwit v0 addresses are supported.
+type Keystore struct {
+	client        *Client
+	cosignerIndex int
+	// rootFingerprint identifies the device at HWI.
+	rootFingerprint []byte
+	// chain is the network for which the extended public keys are derived.
+	chain Chain
+
+	// xpubs caches the extended public keys by keypath, as every call to HWI takes a while.
+	xpubs     map[string]*hdkeychain.ExtendedKey
+	xpubsLock locker.Locker
+
+	log *logrus.Entry
+}
+
+// NewKeystore returns a keystore for the device with the given hex encoded root fingerprint, see
+// Device.Fingerprint.
+func NewKeystore(
+	client *Client, cosignerIndex int, fingerprint string, chain Chain, log *logrus.Entry) (
+	*Keystore, error) {
+	rootFingerprint, err := hex.DecodeString(fingerprint)
+	if err != nil || len(rootFingerprint) != 4 {
+		return nil, errp.Newf("Invalid root fingerprint %q.", fingerprint)
+	}
+	return &Keystore{
+		client:          client,
+		cosignerIndex:   cosignerIndex,
+		rootFingerprint: rootFingerprint,
+		chain:           chain,
+		xpubs:           map[string]*hdkeychain.ExtendedKey{},
+		log:             log.WithField("fingerprint", fingerprint),
+	}, nil
+}
+
+// call runs the given HWI command on the device of the keystore.
+func (keystore *Keystore) call(
+	ctx context.Context, chain Chain, result interface{}, args ...string) error {
+	err := keystore.client.call(
+		ctx, hex.EncodeToString(keystore.rootFingerprint), chain, result, args...)
+	if hwiErr, ok := errp.Cause(err).(*Error); ok && hwiErr.Code == errCodeActionCanceled {
+		return errp.WithStack(keystorePkg.ErrSigningAborted)
+	}
+	return err
+}
+
+// encodeKeypath encodes the keypath in the format of HWI, which does not accept "m/" for the
+// master key.
+func encodeKeypath(keyPath signing.AbsoluteKeypath) string {
+	if len(keyPath) == 0 {
+		return "m"
+	}
+	return keyPath.Encode()
+}
+
+// coinChain returns the network of the given coin, or an error if HWI does not support it.
+func coinChain(coin coin.Coin) (Chain, error) {
+	btcCoin, ok := coin.(*btc.Coin)
+	if !ok {
+		return "", errp.Newf("HWI does not support %s.", coin.Code())
+	}
+	switch btcCoin.Net().Net {
+	case chaincfg.MainNetParams.Net:
+		return ChainMain, nil
+	case chaincfg.TestNet3Params.Net:
+		return ChainTest, nil
+	case signet.Params.Net:
+		return ChainSignet, nil
+	case chaincfg.RegressionNetParams.Net:
+		return ChainRegtest, nil
+	default:
+		return "", errp.Newf("HWI does not support %s.", coin.Code())
+	}
+}
+
+// addressType returns the address type of HWI for the given script type.
+func addressType(scriptType signing.ScriptType) (string, error) {
+	switch scriptType {
+	case signing.ScriptTypeP2WPKHP2SH:
+		return "sh_wit", nil
+	case signing.ScriptTypeP2WPKH:
+		return "wit", nil
+	default:
+		return "", errp.Newf("HWI does not support %s addresses.", scriptType)
+	}
+}
+
+// CosignerIndex implements keystore.Keystore.
+func (keystore *Keystore) CosignerIndex() int {
+	return keystore.cosignerIndex
+}
+
+// SupportsCoin implements keystore.Keystore.
+func (keystore *Keystore) SupportsCoin(coin coin.Coin) bool {
+	_, err := coinChain(coin)
+	return err == nil
+}
+
+// SupportsScriptType implements keystore.Keystore. Legacy inputs are not supported, as the
+// previous transactions are not always known, and taproot inputs cannot be passed in a PSBT yet.
+func (keystore *Keystore) SupportsScriptType(coin coin.Coin, scriptType signing.ScriptType) bool {
+	if !keystore.SupportsCoin(coin) {
+		return false
+	}
+	_, err := addressType(scriptType)
+	return err == nil
+}
+
+// SupportsAccount implements keystore.Keystore.
+func (keystore *Keystore) SupportsAccount(
+	coin coin.Coin, scriptType signing.ScriptType, multisig bool) bool {
+	return !multisig && keystore.SupportsScriptType(coin, scriptType)
+}
+
+// HasSecureOutput implements keystore.Keystore. All devices supported by HWI have a screen.
+func (keystore *Keystore) HasSecureOutput() bool {
+	return true
+}
+
+// OutputAddress implements keystore.Keystore.
+func (keystore *Keystore) OutputAddress(
+	keyPath signing.AbsoluteKeypath, scriptType signing.ScriptType, coin coin.Coin) error {
+	return keystore.OutputAddressContext(context.Background(), keyPath, scriptType, coin)
+}
+
+// OutputAddressContext implements keystore.ContextKeystore.
+func (keystore *Keystore) OutputAddressContext(ctx context.Context,
+	keyPath signing.AbsoluteKeypath, scriptType signing.ScriptType, coin coin.Coin) error {
+	chain, err := coinChain(coin)
+	if err != nil {
+		return err
+	}
+	addrType, err := addressType(scriptType)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Address string `json:"address"`
+	}
+	return keystore.call(ctx, chain, &result,
+		"displayaddress", "--path", encodeKeypath(keyPath), "--addr-type", addrType)
+}
+
+// BatchOutputAddress implements keystore.Keystore. The addresses are displayed one after another.
+func (keystore *Keystore) BatchOutputAddress(
+	keyPaths []signing.AbsoluteKeypath, scriptType signing.ScriptType, coin coin.Coin) error {
+	for index, keyPath := range keyPaths {
+		err := keystore.OutputAddress(keyPath, scriptType, coin)
+		if errp.Cause(err) == keystorePkg.ErrSigningAborted {
+			return errp.WithStack(&keystorePkg.OutputAddressAbortedError{Index: index})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExtendedPublicKey implements keystore.Keystore.
+func (keystore *Keystore) ExtendedPublicKey(
+	keyPath signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
+	return keystore.ExtendedPublicKeyContext(context.Background(), keyPath)
+}
+
+// ExtendedPublicKeyContext implements keystore.ContextKeystore.
+func (keystore *Keystore) ExtendedPublicKeyContext(
+	ctx context.Context, keyPath signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
+	encodedKeypath := encodeKeypath(keyPath)
+	unlock := keystore.xpubsLock.RLock()
+	xpub, ok := keystore.xpubs[encodedKeypath]
+	unlock()
+	if ok {
+		return xpub, nil
+	}
+	var result struct {
+		XPub string `json:"xpub"`
+	}
+	if err := keystore.call(ctx, keystore.chain, &result, "getxpub", encodedKeypath); err != nil {
+		return nil, err
+	}
+	xpub, err := hdkeychain.NewKeyFromString(result.XPub)
+	if err != nil {
+		return nil, errp.WithMessage(errp.WithStack(err), "HWI returned an invalid xpub")
+	}
+	defer keystore.xpubsLock.Lock()()
+	keystore.xpubs[encodedKeypath] = xpub
+	return xpub, nil
+}
+
+// RootFingerprint implements keystore.Keystore.
+func (keystore *Keystore) RootFingerprint() ([]byte, error) {
+	return keystore.rootFingerprint, nil
+}
+
+// SignMessage implements keystore.Keystore.
+func (keystore *Keystore) SignMessage(
+	keyPath signing.AbsoluteKeypath, message []byte, coin coin.Coin) ([]byte, error) {
+	chain, err := coinChain(coin)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Signature string `json:"signature"`
+	}
+	if err := keystore.call(context.Background(), chain, &result,
+		"signmessage", string(message), encodeKeypath(keyPath)); err != nil {
+		return nil, err
+	}
+	signature, err := base64.StdEncoding.DecodeString(result.Signature)
+	if err != nil {
+		return nil, errp.WithMessage(errp.WithStack(err), "HWI returned an invalid signature")
+	}
+	return signature, nil
+}
+
+// SignTypedData implements keystore.Keystore.
+func (keystore *Keystore) SignTypedData(signing.AbsoluteKeypath, []byte) ([]byte, error) {
+	return nil, errp.New("HWI does not support Ethereum.")
+}
+
+// SignTypedDataContext implements keystore.ContextKeystore.
+func (keystore *Keystore) SignTypedDataContext(
+	context.Context, signing.AbsoluteKeypath, []byte) ([]byte, error) {
+	return nil, errp.New("HWI does not support Ethereum.")
+}
+
+// SignPSBT implements keystore.Keystore.
+func (keystore *Keystore) SignPSBT(packet *psbt.Packet) (*psbt.Packet, error) {
+	return keystore.signPSBT(context.Background(), keystore.chain, packet)
+}
+
+// signPSBT signs the given PSBT on the device and returns the signed copy.
+func (keystore *Keystore) signPSBT(
+	ctx context.Context, chain Chain, packet *psbt.Packet) (*psbt.Packet, error) {
+	encoded, err := packet.Base64()
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		PSBT   string `json:"psbt"`
+		Signed bool   `json:"signed"`
+	}
+	if err := keystore.call(ctx, chain, &result, "signtx", encoded); err != nil {
+		return nil, err
+	}
+	if !result.Signed {
+		return nil, errp.WithStack(keystorePkg.ErrNoPSBTInputs)
+	}
+	return psbt.ParseBase64(result.PSBT)
+}
+
+// SignTransaction implements keystore.Keystore.
+func (keystore *Keystore) SignTransaction(proposedTransaction coin.ProposedTransaction) error {
+	return keystore.SignTransactionContext(context.Background(), proposedTransaction)
+}
+
+// SignTransactionContext implements keystore.ContextKeystore. The transaction is signed as PSBT,
+// see btc.ProposedTransaction.KeystorePSBT.
+func (keystore *Keystore) SignTransactionContext(
+	ctx context.Context, proposedTransaction coin.ProposedTransaction) error {
+	btcProposedTx, ok := proposedTransaction.(*btc.ProposedTransaction)
+	if !ok {
+		return errp.New("HWI can only sign Bitcoin transactions.")
+	}
+	if err := btcProposedTx.CheckCanSign(keystore); err != nil {
+		return err
+	}
+	chain, err := coinChain(btcProposedTx.TXProposal.Coin)
+	if err != nil {
+		return err
+	}
+	keystore.log.Info("Sign transaction.")
+	signatureHashes, err := btcProposedTx.SignatureHashes()
+	if err != nil {
+		return err
+	}
+	packet, err := btcProposedTx.KeystorePSBT(keystore, signatureHashes)
+	if err != nil {
+		return err
+	}
+	signedPacket, err := keystore.signPSBT(ctx, chain, packet)
+	if err != nil {
+		return err
+	}
+	return btcProposedTx.AddKeystorePSBTSignatures(keystore, signatureHashes, signedPacket)
+}
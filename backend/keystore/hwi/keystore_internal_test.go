@@ -0,0 +1,159 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hwi
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/blockchain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/maketx"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/transactions"
+	keystorePkg "github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
+	"github.com/stretchr/testify/require"
+)
+
+// newEmulatedKeystore returns a keystore whose HWI commands are answered by the given software
+// keystore instead of a device. The commands are recorded in commands.
+func newEmulatedKeystore(
+	t *testing.T, device *software.Keystore, commands *[]string) *Keystore {
+	t.Helper()
+	rootFingerprint, err := device.RootFingerprint()
+	require.NoError(t, err)
+	fingerprint := hex.EncodeToString(rootFingerprint)
+	client := &Client{run: func(ctx context.Context, args ...string) ([]byte, error) {
+		require.Equal(t, []string{"--chain", "test", "--fingerprint", fingerprint}, args[:4])
+		args = args[4:]
+		*commands = append(*commands, args[0])
+		switch args[0] {
+		case "getxpub":
+			keyPath := signing.NewEmptyAbsoluteKeypath()
+			if args[1] != "m" {
+				keyPath, err = signing.NewAbsoluteKeypath(args[1])
+				require.NoError(t, err)
+			}
+			xpub, err := device.ExtendedPublicKey(keyPath)
+			require.NoError(t, err)
+			return json.Marshal(map[string]string{"xpub": xpub.String()})
+		case "signtx":
+			packet, err := psbt.ParseBase64(args[1])
+			require.NoError(t, err)
+			signedPacket, err := device.SignPSBT(packet)
+			require.NoError(t, err)
+			encoded, err := signedPacket.Base64()
+			require.NoError(t, err)
+			return json.Marshal(map[string]interface{}{"psbt": encoded, "signed": true})
+		default:
+			return []byte(`{"error": "Action canceled by user", "code": -14}`), nil
+		}
+	}}
+	keystore, err := NewKeystore(client, 0, fingerprint, ChainTest, logging.Get().WithGroup("hwi_test"))
+	require.NoError(t, err)
+	return keystore
+}
+
+func TestEnumerate(t *testing.T) {
+	client := &Client{run: func(ctx context.Context, args ...string) ([]byte, error) {
+		require.Equal(t, []string{"--chain", "main", "enumerate"}, args)
+		return []byte(`[{"type": "trezor", "model": "trezor_t", "path": "webusb:001:1",
+			"fingerprint": "3442193e", "needs_pin_sent": false, "needs_passphrase_sent": false}]`), nil
+	}}
+	devices, err := client.Enumerate(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []*Device{{
+		Type:        "trezor",
+		Model:       "trezor_t",
+		Path:        "webusb:001:1",
+		Fingerprint: "3442193e",
+	}}, devices)
+
+	client.run = func(ctx context.Context, args ...string) ([]byte, error) {
+		return []byte(`{"error": "Could not open device", "code": -3}`), nil
+	}
+	_, err = client.Enumerate(context.Background())
+	require.Equal(t, &Error{Code: -3, Message: "Could not open device"}, errp.Cause(err))
+}
+
+func TestSignTransaction(t *testing.T) {
+	device := software.NewKeystoreFromPIN(0, "1234")
+	commands := []string{}
+	keystore := newEmulatedKeystore(t, device, &commands)
+
+	log := logging.Get().WithGroup("hwi_test")
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/1'/0'")
+	require.NoError(t, err)
+	xpub, err := keystore.ExtendedPublicKey(keypath)
+	require.NoError(t, err)
+	// The extended public keys are retrieved only once.
+	_, err = keystore.ExtendedPublicKey(keypath)
+	require.NoError(t, err)
+	require.Equal(t, []string{"getxpub"}, commands)
+
+	accountConfiguration := signing.NewSinglesigConfiguration(signing.ScriptTypeP2WPKH, keypath, xpub)
+	relativeKeypath, err := signing.NewRelativeKeypath("0/0")
+	require.NoError(t, err)
+	addressConfiguration, err := accountConfiguration.Derive(relativeKeypath)
+	require.NoError(t, err)
+	address := addresses.NewAccountAddress(addressConfiguration, &chaincfg.TestNet3Params, log)
+
+	transaction := wire.NewMsgTx(wire.TxVersion)
+	previousOutputs := map[wire.OutPoint]*transactions.SpendableOutput{}
+	for i, value := range []int64{100000, 200000} {
+		outPoint := wire.OutPoint{Hash: chainhash.HashH([]byte{byte(i)}), Index: uint32(i)}
+		transaction.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+		previousOutputs[outPoint] = &transactions.SpendableOutput{
+			TxOut: wire.NewTxOut(value, address.PubkeyScript()),
+		}
+	}
+	transaction.AddTxOut(wire.NewTxOut(250000, address.PubkeyScript()))
+	txProposal := &maketx.TxProposal{
+		Coin:                 btc.NewCoin("tbtc", "TBTC", &chaincfg.TestNet3Params, ".", nil, ""),
+		AccountConfiguration: accountConfiguration,
+		Transaction:          transaction,
+	}
+	getAddress := func(blockchain.ScriptHashHex) *addresses.AccountAddress { return address }
+	// The signatures are verified when the transaction is finalized.
+	require.NoError(t, btc.SignTransaction(keystorePkg.NewKeystores(keystore), txProposal,
+		previousOutputs, getAddress, nil, nil, nil, log))
+	require.Equal(t, []string{"getxpub", "signtx"}, commands)
+	for _, txIn := range transaction.TxIn {
+		require.Len(t, txIn.Witness, 2)
+	}
+}
+
+func TestBatchOutputAddressAborted(t *testing.T) {
+	device := software.NewKeystoreFromPIN(0, "1234")
+	commands := []string{}
+	keystore := newEmulatedKeystore(t, device, &commands)
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/1'/0'/0/0")
+	require.NoError(t, err)
+	coin := btc.NewCoin("tbtc", "TBTC", &chaincfg.TestNet3Params, ".", nil, "")
+	err = keystore.BatchOutputAddress(
+		[]signing.AbsoluteKeypath{keypath}, signing.ScriptTypeP2WPKH, coin)
+	require.Equal(t, &keystorePkg.OutputAddressAbortedError{Index: 0}, errp.Cause(err))
+	require.Equal(t, []string{"displayaddress"}, commands)
+}
@@ -15,16 +15,85 @@
 package keystore
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 )
 
 // ErrSigningAborted is used when the user aborts a signing in process (e.g. abort on HW wallet).
 var ErrSigningAborted = errors.New("signing aborted by user")
 
+// ErrSignatureCountMismatch is used when a keystore returns a different number of signatures than
+// requested, e.g. because the communication with the device got out of sync.
+var ErrSignatureCountMismatch = errors.New("number of signatures does not match the request")
+
+// ErrUnknownKeypath is returned by ExtendedPublicKey of keystores which only know the keys below
+// an account keypath, e.g. air-gapped signers, for the keys outside of it.
+var ErrUnknownKeypath = errors.New("the keystore does not know the key at the keypath")
+
+// OutputAddressAbortedError is returned by BatchOutputAddress if the user rejects an address.
+type OutputAddressAbortedError struct {
+	// Index is the position of the rejected address among the requested keypaths.
+	Index int
+}
+
+func (err *OutputAddressAbortedError) Error() string {
+	return fmt.Sprintf("output of address %d aborted by user", err.Index)
+}
+
+// ValidateCosigner checks that the keystore can sign for the given configuration: its cosigner
+// index has to be within the bounds of the configured signers and its extended public key at the
+// keypath of the configuration has to be the one at that index.
+func ValidateCosigner(keystore Keystore, configuration *signing.Configuration) error {
+	cosignerIndex := keystore.CosignerIndex()
+	if cosignerIndex < 0 || cosignerIndex >= configuration.NumberOfSigners() {
+		return errp.Newf("The cosigner index %d is out of range for a configuration with %d signers.",
+			cosignerIndex, configuration.NumberOfSigners())
+	}
+	extendedPublicKey, err := keystore.ExtendedPublicKey(configuration.AbsoluteKeypath())
+	if err != nil {
+		return errp.WithMessage(err, "Failed to get the extended public key of the keystore")
+	}
+	if extendedPublicKey.String() != configuration.ExtendedPublicKeys()[cosignerIndex].String() {
+		for index, configuredKey := range configuration.ExtendedPublicKeys() {
+			if extendedPublicKey.String() == configuredKey.String() {
+				return errp.Newf("The keystore is cosigner %d of the configuration, not %d.",
+					index, cosignerIndex)
+			}
+		}
+		return errp.Newf("The extended public key of the keystore at %s is not part of the configuration.",
+			configuration.AbsoluteKeypath().Encode())
+	}
+	return nil
+}
+
+// RootFingerprint returns the root fingerprint of the given keystore, computed from its extended
+// public key at the master keypath.
+func RootFingerprint(keystore Keystore) ([]byte, error) {
+	master, err := keystore.ExtendedPublicKey(signing.NewEmptyAbsoluteKeypath())
+	if err != nil {
+		return nil, errp.WithMessage(err, "Failed to get the master key")
+	}
+	return rootFingerprint(master)
+}
+
+// rootFingerprint returns the first four bytes of the hash160 of the given master public key.
+func rootFingerprint(master *hdkeychain.ExtendedKey) ([]byte, error) {
+	publicKey, err := master.ECPubKey()
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return btcutil.Hash160(publicKey.SerializeCompressed())[:4], nil
+}
+
 // Keystore supports hardened key derivation according to BIP32 and signing of transactions.
 //go:generate mockery -name Keystore
 type Keystore interface {
@@ -36,6 +105,18 @@ type Keystore interface {
 	// The returned value is always zero for a singlesig configuration.
 	CosignerIndex() int
 
+	// SupportsCoin returns whether the keystore supports accounts of the given coin.
+	SupportsCoin(coin.Coin) bool
+
+	// SupportsScriptType returns whether the keystore can sign transactions of the given coin
+	// spending singlesig outputs of the given script type, and thus supports singlesig accounts of
+	// this script type.
+	SupportsScriptType(coin.Coin, signing.ScriptType) bool
+
+	// SupportsAccount returns whether the accounts of the given coin and script type can be created
+	// with the keystore. multisig is true if the keystore is one of several cosigners.
+	SupportsAccount(coin coin.Coin, scriptType signing.ScriptType, multisig bool) bool
+
 	// HasSecureOutput returns whether the keystore supports to output an address securely.
 	// This is typically done through a screen on the device or through a paired mobile phone.
 	HasSecureOutput() bool
@@ -44,12 +125,69 @@ type Keystore interface {
 	// Please note that this is only supported if the keystore has a secure output channel.
 	OutputAddress(signing.AbsoluteKeypath, signing.ScriptType, coin.Coin) error
 
+	// BatchOutputAddress outputs the public keys at the given absolute keypaths one after another
+	// for the given coin. It returns an error if the keystore has no secure output channel and, on
+	// keystores which let the user reject an address, an *OutputAddressAbortedError.
+	BatchOutputAddress([]signing.AbsoluteKeypath, signing.ScriptType, coin.Coin) error
+
 	// ExtendedPublicKey returns the extended public key at the given absolute keypath.
 	ExtendedPublicKey(signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error)
 
-	// SignMessage(string, *signing.AbsoluteKeypath, coin.Coin) (*big.Int, error)
+	// RootFingerprint returns the first four bytes of the hash160 of the master public key, which
+	// identify the seed of the keystore (BIP32).
+	RootFingerprint() ([]byte, error)
+
+	// SignMessage signs the message with the key at the given absolute keypath and returns a
+	// recoverable signature in the format of the given coin (`signmessage` for Bitcoin-related
+	// coins, personal_sign for Ethereum). Returns ErrSigningAborted if the user aborts.
+	SignMessage(signing.AbsoluteKeypath, []byte, coin.Coin) ([]byte, error)
+
+	// SignTypedData signs EIP-712 typed data, given in the JSON encoding of eth_signTypedData_v4,
+	// with the key at the given absolute keypath and returns the signature [R || S || 27 + recid].
+	// Returns ErrSigningAborted if the user aborts.
+	SignTypedData(signing.AbsoluteKeypath, []byte) ([]byte, error)
 
 	// SignTransaction signs the given transaction proposal. Returns ErrSigningAborted if the user
-	// aborts.
+	// aborts and ErrSignatureCountMismatch if the keystore did not reply with one signature per
+	// requested signature hash.
 	SignTransaction(coin.ProposedTransaction) error
+
+	// SignPSBT signs the inputs of the given PSBT (BIP174) which contain the BIP32 derivation of a
+	// key of the keystore and returns a copy of the PSBT with the added partial signatures. The
+	// inputs are not finalized. Returns ErrSigningAborted if the user aborts and ErrNoPSBTInputs if
+	// no input belongs to the keystore. Keystores which cannot show the transaction for
+	// verification without its account, e.g. the BitBox, return an error.
+	SignPSBT(*psbt.Packet) (*psbt.Packet, error)
+}
+
+// ContextKeystore is implemented by keystores whose operations can block for a long time, e.g.
+// while waiting for the user to confirm on a device. The operations are like the ones of Keystore,
+// but stop waiting and return the error of the context as soon as it is done. This does not abort
+// the operation on a device, which might still ask the user to confirm it; its result is discarded.
+type ContextKeystore interface {
+	Keystore
+
+	// OutputAddressContext is like OutputAddress.
+	OutputAddressContext(context.Context, signing.AbsoluteKeypath, signing.ScriptType, coin.Coin) error
+
+	// ExtendedPublicKeyContext is like ExtendedPublicKey.
+	ExtendedPublicKeyContext(context.Context, signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error)
+
+	// SignTypedDataContext is like SignTypedData.
+	SignTypedDataContext(context.Context, signing.AbsoluteKeypath, []byte) ([]byte, error)
+
+	// SignTransactionContext is like SignTransaction.
+	SignTransactionContext(context.Context, coin.ProposedTransaction) error
+}
+
+// SilentPaymentsKeystore is implemented by keystores which can compute the shared secret of a
+// silent payment (BIP352) with the private keys of the inputs of a transaction.
+type SilentPaymentsKeystore interface {
+	Keystore
+
+	// SilentPaymentSharedSecret returns input_hash·a·B_scan, where a is the sum of the private keys
+	// at the given absolute keypaths, one per input, and B_scan is the scan key of the recipient.
+	SilentPaymentSharedSecret(
+		keypaths []signing.AbsoluteKeypath, inputHash []byte, scanKey *btcec.PublicKey) (
+		*btcec.PublicKey, error)
 }
@@ -0,0 +1,83 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/stretchr/testify/require"
+)
+
+// newMultisigConfiguration returns a 2-of-2 configuration of the given keystores.
+func newMultisigConfiguration(t *testing.T, keystores ...keystore.Keystore) *signing.Configuration {
+	t.Helper()
+	keypath, err := signing.NewAbsoluteKeypath("m/45'")
+	require.NoError(t, err)
+	extendedPublicKeys := make([]*hdkeychain.ExtendedKey, len(keystores))
+	for index, keystore := range keystores {
+		extendedPublicKeys[index], err = keystore.ExtendedPublicKey(keypath)
+		require.NoError(t, err)
+	}
+	return signing.NewConfiguration(signing.ScriptTypeP2PKH, keypath, extendedPublicKeys, 2)
+}
+
+func TestValidateCosigner(t *testing.T) {
+	configuration := newMultisigConfiguration(t,
+		software.NewKeystoreFromPIN(0, "1234"),
+		software.NewKeystoreFromPIN(1, "5678"),
+	)
+	require.NoError(t, keystore.ValidateCosigner(software.NewKeystoreFromPIN(0, "1234"), configuration))
+	require.NoError(t, keystore.ValidateCosigner(software.NewKeystoreFromPIN(1, "5678"), configuration))
+}
+
+func TestValidateCosignerWrongIndex(t *testing.T) {
+	configuration := newMultisigConfiguration(t,
+		software.NewKeystoreFromPIN(0, "1234"),
+		software.NewKeystoreFromPIN(1, "5678"),
+	)
+	require.Error(t, keystore.ValidateCosigner(software.NewKeystoreFromPIN(1, "1234"), configuration))
+	require.Error(t, keystore.ValidateCosigner(software.NewKeystoreFromPIN(2, "1234"), configuration))
+	require.Error(t, keystore.ValidateCosigner(software.NewKeystoreFromPIN(-1, "1234"), configuration))
+}
+
+func TestValidateCosignerUnknownExtendedPublicKey(t *testing.T) {
+	configuration := newMultisigConfiguration(t,
+		software.NewKeystoreFromPIN(0, "1234"),
+		software.NewKeystoreFromPIN(1, "5678"),
+	)
+	require.Error(t, keystore.ValidateCosigner(software.NewKeystoreFromPIN(0, "0000"), configuration))
+}
+
+func TestRootFingerprint(t *testing.T) {
+	// BIP32 test vector 1.
+	master, err := hdkeychain.NewMaster(
+		[]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	rootFingerprint, err := keystore.RootFingerprint(software.NewKeystore(0, master))
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x34, 0x42, 0x19, 0x3e}, rootFingerprint)
+
+	keystores := keystore.NewKeystores(software.NewKeystore(0, master), software.NewKeystoreFromPIN(1, "1234"))
+	rootFingerprints, err := keystores.RootFingerprints()
+	require.NoError(t, err)
+	require.Len(t, rootFingerprints, 2)
+	require.Equal(t, rootFingerprint, rootFingerprints[0])
+	require.NotEqual(t, rootFingerprint, rootFingerprints[1])
+}
@@ -15,6 +15,9 @@
 package keystore
 
 import (
+	"context"
+
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
@@ -35,26 +38,84 @@ type Keystores interface {
 	// HaveSecureOutput returns whether any of the keystores has a secure output.
 	HaveSecureOutput() bool
 
+	// SupportAccount returns whether all keystores support the accounts of the given coin and
+	// script type. See Keystore.SupportsAccount.
+	SupportAccount(coin coin.Coin, scriptType signing.ScriptType, multisig bool) bool
+
 	// OutputAddress outputs the address for the given coin with the given configuration on all
 	// keystores that have a secure output.
 	OutputAddress(*signing.Configuration, coin.Coin) error
 
-	// SignTransaction signs the given proposed transaction on all keystores. Returns
-	// ErrSigningAborted if the user aborts.
+	// SignMessage signs the message with the key of the given singlesig configuration. See
+	// Keystore.SignMessage for the format of the signature.
+	SignMessage(*signing.Configuration, []byte, coin.Coin) ([]byte, error)
+
+	// SignTypedData signs EIP-712 typed data with the key of the given singlesig configuration.
+	// See Keystore.SignTypedData. Like SignTransaction, the signing can be cancelled with the
+	// SigningSession, in which case ErrSigningAborted is returned.
+	SignTypedData(*signing.Configuration, []byte) ([]byte, error)
+
+	// SilentPaymentSharedSecret computes the shared secret of a silent payment with the keystore of
+	// a singlesig account. See SilentPaymentsKeystore.
+	SilentPaymentSharedSecret(
+		keypaths []signing.AbsoluteKeypath, inputHash []byte, scanKey *btcec.PublicKey) (
+		*btcec.PublicKey, error)
+
+	// SignTransaction signs the given proposed transaction on all keystores, except on the ones
+	// which already signed a CosignedTransaction. Returns ErrSigningAborted if the user aborts or
+	// the signing is cancelled with the SigningSession. Cancelling only stops waiting for the
+	// keystores (see ContextKeystore).
 	SignTransaction(coin.ProposedTransaction) error
 
+	// SignTransactionContext is like SignTransaction, but stops waiting and returns the error of the
+	// context as soon as it is done (see ContextKeystore). Keystores which do not implement
+	// ContextKeystore are not interrupted.
+	SignTransactionContext(context.Context, coin.ProposedTransaction) error
+
+	// SigningSession returns the session of the transactions in the process of being signed with
+	// SignTransaction.
+	SigningSession() *SigningSession
+
+	// RootFingerprints returns the root fingerprint of each keystore, in the order of the
+	// cosigners. See Keystore.RootFingerprint.
+	RootFingerprints() ([][]byte, error)
+
 	// Configuration returns the configuration at the given path with the given signing threshold.
 	Configuration(signing.ScriptType, signing.AbsoluteKeypath, int) (*signing.Configuration, error)
 }
 
+// CosignedTransaction is implemented by proposed transactions which track the signatures of the
+// cosigners, e.g. when they are collected in a PSBT. Keystores of cosigners which have already
+// signed are not asked to sign again.
+type CosignedTransaction interface {
+	// HasSigned returns whether the cosigner at the given index has signed the transaction.
+	HasSigned(cosignerIndex int) bool
+}
+
 type implementation struct {
 	keystores []Keystore
+	// xpubCache caches the extended public keys of the configurations. It is nil if the keys are
+	// always retrieved from the keystores.
+	xpubCache *XPubCache
+	// signingSession tracks the signings of SignTransaction.
+	signingSession *SigningSession
 }
 
 // NewKeystores returns a collection of the given keystores.
 func NewKeystores(keystores ...Keystore) Keystores {
 	return &implementation{
-		keystores: keystores,
+		keystores:      keystores,
+		signingSession: NewSigningSession(),
+	}
+}
+
+// NewCachedKeystores returns a collection of the given keystores whose configurations are built
+// from the extended public keys in the given cache.
+func NewCachedKeystores(xpubCache *XPubCache, keystores ...Keystore) Keystores {
+	return &implementation{
+		keystores:      keystores,
+		xpubCache:      xpubCache,
+		signingSession: NewSigningSession(),
 	}
 }
 
@@ -98,6 +159,17 @@ func (keystores *implementation) HaveSecureOutput() bool {
 	return false
 }
 
+// SupportAccount implements the above interface.
+func (keystores *implementation) SupportAccount(
+	coin coin.Coin, scriptType signing.ScriptType, multisig bool) bool {
+	for _, keystore := range keystores.keystores {
+		if !keystore.SupportsAccount(coin, scriptType, multisig) {
+			return false
+		}
+	}
+	return true
+}
+
 // OutputAddress implements the above interface.
 func (keystores *implementation) OutputAddress(
 	configuration *signing.Configuration,
@@ -119,16 +191,114 @@ func (keystores *implementation) OutputAddress(
 	return nil
 }
 
+// SignMessage implements the above interface.
+func (keystores *implementation) SignMessage(
+	configuration *signing.Configuration,
+	message []byte,
+	coin coin.Coin,
+) ([]byte, error) {
+	if !configuration.Singlesig() || len(keystores.keystores) != 1 {
+		return nil, errp.New("Messages can only be signed with a singlesig configuration.")
+	}
+	return keystores.keystores[0].SignMessage(configuration.AbsoluteKeypath(), message, coin)
+}
+
+// SignTypedData implements the above interface.
+func (keystores *implementation) SignTypedData(
+	configuration *signing.Configuration,
+	typedData []byte,
+) ([]byte, error) {
+	if !configuration.Singlesig() || len(keystores.keystores) != 1 {
+		return nil, errp.New("Typed data can only be signed with a singlesig configuration.")
+	}
+	var signature []byte
+	err := keystores.signingSession.Sign(func(ctx context.Context) error {
+		var err error
+		if contextKeystore, ok := keystores.keystores[0].(ContextKeystore); ok {
+			signature, err = contextKeystore.SignTypedDataContext(
+				ctx, configuration.AbsoluteKeypath(), typedData)
+		} else {
+			signature, err = keystores.keystores[0].SignTypedData(
+				configuration.AbsoluteKeypath(), typedData)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// SilentPaymentSharedSecret implements the above interface.
+func (keystores *implementation) SilentPaymentSharedSecret(
+	keypaths []signing.AbsoluteKeypath,
+	inputHash []byte,
+	scanKey *btcec.PublicKey,
+) (*btcec.PublicKey, error) {
+	if len(keystores.keystores) != 1 {
+		return nil, errp.New("Silent payments can only be sent from a singlesig account.")
+	}
+	silentPaymentsKeystore, ok := keystores.keystores[0].(SilentPaymentsKeystore)
+	if !ok {
+		return nil, errp.New("The keystore does not support silent payments.")
+	}
+	return silentPaymentsKeystore.SilentPaymentSharedSecret(keypaths, inputHash, scanKey)
+}
+
 // SignTransaction implements the above interface.
 func (keystores *implementation) SignTransaction(proposedTransaction coin.ProposedTransaction) error {
+	return keystores.signingSession.Sign(func(ctx context.Context) error {
+		return keystores.SignTransactionContext(ctx, proposedTransaction)
+	})
+}
+
+// SignTransactionContext implements the above interface.
+func (keystores *implementation) SignTransactionContext(
+	ctx context.Context,
+	proposedTransaction coin.ProposedTransaction,
+) error {
+	cosignedTransaction, cosigned := proposedTransaction.(CosignedTransaction)
 	for _, keystore := range keystores.keystores {
-		if err := keystore.SignTransaction(proposedTransaction); err != nil {
+		if err := ctx.Err(); err != nil {
+			return errp.WithStack(err)
+		}
+		if cosigned && cosignedTransaction.HasSigned(keystore.CosignerIndex()) {
+			continue
+		}
+		var err error
+		if contextKeystore, ok := keystore.(ContextKeystore); ok {
+			err = contextKeystore.SignTransactionContext(ctx, proposedTransaction)
+		} else {
+			err = keystore.SignTransaction(proposedTransaction)
+		}
+		if err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// SigningSession implements the above interface.
+func (keystores *implementation) SigningSession() *SigningSession {
+	return keystores.signingSession
+}
+
+// RootFingerprints implements the above interface.
+func (keystores *implementation) RootFingerprints() ([][]byte, error) {
+	rootFingerprints := make([][]byte, len(keystores.keystores))
+	for index, keystore := range keystores.keystores {
+		if keystore.CosignerIndex() != index {
+			return nil, errp.New("The keystores are in the wrong order.")
+		}
+		rootFingerprint, err := keystore.RootFingerprint()
+		if err != nil {
+			return nil, err
+		}
+		rootFingerprints[index] = rootFingerprint
+	}
+	return rootFingerprints, nil
+}
+
 // Configuration implements the above interface.
 func (keystores *implementation) Configuration(
 	scriptType signing.ScriptType,
@@ -140,7 +310,13 @@ func (keystores *implementation) Configuration(
 		if keystore.CosignerIndex() != index {
 			return nil, errp.New("The keystores are in the wrong order.")
 		}
-		extendedPublicKey, err := keystore.ExtendedPublicKey(absoluteKeypath)
+		var extendedPublicKey *hdkeychain.ExtendedKey
+		var err error
+		if keystores.xpubCache != nil {
+			extendedPublicKey, err = keystores.xpubCache.ExtendedPublicKey(keystore, absoluteKeypath)
+		} else {
+			extendedPublicKey, err = keystore.ExtendedPublicKey(absoluteKeypath)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -6,6 +6,7 @@ import coin "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
 import hdkeychain "github.com/btcsuite/btcutil/hdkeychain"
 
 import mock "github.com/stretchr/testify/mock"
+import psbt "github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
 import signing "github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
 
 // Keystore is an autogenerated mock type for the Keystore type
@@ -13,6 +14,20 @@ type Keystore struct {
 	mock.Mock
 }
 
+// BatchOutputAddress provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Keystore) BatchOutputAddress(_a0 []signing.AbsoluteKeypath, _a1 signing.ScriptType, _a2 coin.Coin) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]signing.AbsoluteKeypath, signing.ScriptType, coin.Coin) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CosignerIndex provides a mock function with given fields:
 func (_m *Keystore) CosignerIndex() int {
 	ret := _m.Called()
@@ -78,6 +93,75 @@ func (_m *Keystore) OutputAddress(_a0 signing.AbsoluteKeypath, _a1 signing.Scrip
 	return r0
 }
 
+// RootFingerprint provides a mock function with given fields:
+func (_m *Keystore) RootFingerprint() ([]byte, error) {
+	ret := _m.Called()
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func() []byte); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SignMessage provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Keystore) SignMessage(_a0 signing.AbsoluteKeypath, _a1 []byte, _a2 coin.Coin) ([]byte, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(signing.AbsoluteKeypath, []byte, coin.Coin) []byte); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(signing.AbsoluteKeypath, []byte, coin.Coin) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SignTypedData provides a mock function with given fields: _a0, _a1
+func (_m *Keystore) SignTypedData(_a0 signing.AbsoluteKeypath, _a1 []byte) ([]byte, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(signing.AbsoluteKeypath, []byte) []byte); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(signing.AbsoluteKeypath, []byte) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SignTransaction provides a mock function with given fields: _a0
 func (_m *Keystore) SignTransaction(_a0 coin.ProposedTransaction) error {
 	ret := _m.Called(_a0)
@@ -91,3 +175,68 @@ func (_m *Keystore) SignTransaction(_a0 coin.ProposedTransaction) error {
 
 	return r0
 }
+
+// SupportsAccount provides a mock function with given fields: _a0, scriptType, multisig
+func (_m *Keystore) SupportsAccount(_a0 coin.Coin, scriptType signing.ScriptType, multisig bool) bool {
+	ret := _m.Called(_a0, scriptType, multisig)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(coin.Coin, signing.ScriptType, bool) bool); ok {
+		r0 = rf(_a0, scriptType, multisig)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// SupportsCoin provides a mock function with given fields: _a0
+func (_m *Keystore) SupportsCoin(_a0 coin.Coin) bool {
+	ret := _m.Called(_a0)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(coin.Coin) bool); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// SupportsScriptType provides a mock function with given fields: _a0, _a1
+func (_m *Keystore) SupportsScriptType(_a0 coin.Coin, _a1 signing.ScriptType) bool {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(coin.Coin, signing.ScriptType) bool); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// SignPSBT provides a mock function with given fields: _a0
+func (_m *Keystore) SignPSBT(_a0 *psbt.Packet) (*psbt.Packet, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *psbt.Packet
+	if rf, ok := ret.Get(0).(func(*psbt.Packet) *psbt.Packet); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*psbt.Packet)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*psbt.Packet) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
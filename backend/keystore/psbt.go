@@ -0,0 +1,129 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// ErrNoPSBTInputs is returned by SignPSBT if no input of the PSBT can be signed by the keystore.
+var ErrNoPSBTInputs = errors.New("no input of the PSBT belongs to the keystore")
+
+// SignPSBT implements Keystore.SignPSBT for keystores which sign signature hashes. The inputs of
+// the PSBT are matched to the keystore by the master key fingerprint in their BIP32 derivations.
+// sign is called once with the signature hashes of all these inputs and the keypaths of the keys
+// to sign them with. The given PSBT is not modified.
+func SignPSBT(
+	keystore Keystore,
+	packet *psbt.Packet,
+	sign func(signatureHashes [][]byte, keyPaths []signing.AbsoluteKeypath) ([]btcec.Signature, error),
+) (*psbt.Packet, error) {
+	master, err := keystore.ExtendedPublicKey(signing.NewEmptyAbsoluteKeypath())
+	if err != nil {
+		return nil, errp.WithMessage(err, "Failed to get the master key")
+	}
+	masterPublicKey, err := master.ECPubKey()
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	fingerprint := psbt.MasterKeyFingerprint(masterPublicKey)
+
+	encoded, err := packet.Base64()
+	if err != nil {
+		return nil, err
+	}
+	signedPacket, err := psbt.ParseBase64(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	inputIndices := []int{}
+	publicKeys := []*btcec.PublicKey{}
+	signatureHashes := [][]byte{}
+	keyPaths := []signing.AbsoluteKeypath{}
+	for index, input := range signedPacket.Inputs {
+		if input.Finalized() {
+			continue
+		}
+		for _, derivation := range input.Bip32Derivation {
+			if derivation.MasterKeyFingerprint != fingerprint || hasPartialSig(input, derivation.PubKey) {
+				continue
+			}
+			keyPath := signing.NewAbsoluteKeypathFromUInt32(derivation.Path...)
+			extendedPublicKey, err := keystore.ExtendedPublicKey(keyPath)
+			if err != nil {
+				return nil, errp.WithMessage(err, "Failed to get the public key of an input")
+			}
+			publicKey, err := extendedPublicKey.ECPubKey()
+			if err != nil {
+				return nil, errp.WithStack(err)
+			}
+			if !bytes.Equal(publicKey.SerializeCompressed(), derivation.PubKey) {
+				return nil, errp.Newf("The public key of input %d does not match its derivation %s.",
+					index, keyPath.Encode())
+			}
+			signatureHash, err := signedPacket.SignatureHash(index)
+			if err != nil {
+				return nil, err
+			}
+			inputIndices = append(inputIndices, index)
+			publicKeys = append(publicKeys, publicKey)
+			signatureHashes = append(signatureHashes, signatureHash)
+			keyPaths = append(keyPaths, keyPath)
+		}
+	}
+	if len(inputIndices) == 0 {
+		return nil, errp.WithStack(ErrNoPSBTInputs)
+	}
+
+	signatures, err := sign(signatureHashes, keyPaths)
+	if err != nil {
+		return nil, err
+	}
+	if len(signatures) != len(inputIndices) {
+		return nil, errp.WithMessage(errp.WithStack(ErrSignatureCountMismatch),
+			fmt.Sprintf("Expected %d signatures, got %d", len(inputIndices), len(signatures)))
+	}
+	for i, signature := range signatures {
+		signature := signature
+		index := inputIndices[i]
+		if !signature.Verify(signatureHashes[i], publicKeys[i]) {
+			return nil, errp.Newf("The signature of input %d is invalid.", index)
+		}
+		input := signedPacket.Inputs[index]
+		input.PartialSigs = append(input.PartialSigs, &psbt.PartialSig{
+			PubKey:    publicKeys[i].SerializeCompressed(),
+			Signature: append(signature.Serialize(), byte(signedPacket.InputSigHashType(index))),
+		})
+	}
+	return signedPacket, nil
+}
+
+// hasPartialSig returns whether the input already contains a signature of the given public key.
+func hasPartialSig(input *psbt.Input, publicKey []byte) bool {
+	for _, partialSig := range input.PartialSigs {
+		if bytes.Equal(partialSig.PubKey, publicKey) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"context"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+)
+
+// SigningSession tracks the signings in progress of a collection of keystores, so that the app can
+// stop waiting for them. Cancelling does not abort a signing on a device (see ContextKeystore): it
+// only takes effect on the device once the user confirmed or rejected it there, and the signatures
+// are then discarded.
+type SigningSession struct {
+	// cancels are the cancel functions of the contexts of the signings in progress by ID.
+	cancels map[int]context.CancelFunc
+	nextID  int
+	lock    locker.Locker
+}
+
+// NewSigningSession returns a session without signings in progress.
+func NewSigningSession() *SigningSession {
+	return &SigningSession{cancels: map[int]context.CancelFunc{}}
+}
+
+// Sign calls sign with a context which is cancelled by Cancel. If sign fails because the context
+// was cancelled, ErrSigningAborted is returned.
+func (session *SigningSession) Sign(sign func(context.Context) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	unlock := session.lock.Lock()
+	id := session.nextID
+	session.nextID++
+	session.cancels[id] = cancel
+	unlock()
+	defer func() {
+		defer session.lock.Lock()()
+		delete(session.cancels, id)
+	}()
+
+	err := sign(ctx)
+	if err != nil && errp.Cause(err) == context.Canceled && ctx.Err() == context.Canceled {
+		return errp.WithStack(ErrSigningAborted)
+	}
+	return err
+}
+
+// Cancel stops waiting for all signings in progress. Returns false if there was none.
+func (session *SigningSession) Cancel() bool {
+	defer session.lock.Lock()()
+	for _, cancel := range session.cancels {
+		cancel()
+	}
+	return len(session.cancels) > 0
+}
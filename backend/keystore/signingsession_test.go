@@ -0,0 +1,49 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningSession(t *testing.T) {
+	session := keystore.NewSigningSession()
+	require.False(t, session.Cancel())
+
+	started := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- session.Sign(func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return errp.WithStack(ctx.Err())
+		})
+	}()
+	<-started
+	require.True(t, session.Cancel())
+	require.Equal(t, keystore.ErrSigningAborted, errp.Cause(<-done))
+	require.False(t, session.Cancel())
+
+	// Other errors and successful signings are returned as they are.
+	errSigning := errors.New("signing failed")
+	require.Equal(t, errSigning, session.Sign(func(context.Context) error { return errSigning }))
+	require.NoError(t, session.Sign(func(context.Context) error { return nil }))
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// bip85HMACKey is the key of the HMAC which turns a derived private key into entropy (BIP85).
+const bip85HMACKey = "bip-entropy-from-k"
+
+// bip85Entropy returns the 64 bytes of entropy of the private key at the given keypath (BIP85).
+func (keystore *Keystore) bip85Entropy(keyPath signing.AbsoluteKeypath) ([]byte, error) {
+	xprv, err := keyPath.Derive(keystore.master)
+	if err != nil {
+		return nil, err
+	}
+	prv, err := xprv.ECPrivKey()
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	mac := hmac.New(sha512.New, []byte(bip85HMACKey))
+	mac.Write(prv.Serialize())
+	return mac.Sum(nil), nil
+}
+
+// BIP85Mnemonic derives the English BIP39 mnemonic with the given number of words (12, 18 or 24)
+// at the given index from the seed of the keystore (BIP85), so that the seed can back other
+// wallets. The same seed, number of words and index always yield the same mnemonic.
+func (keystore *Keystore) BIP85Mnemonic(words int, index uint32) (string, error) {
+	if words != 12 && words != 18 && words != 24 {
+		return "", errp.Newf("A BIP85 mnemonic has 12, 18 or 24 words, not %d.", words)
+	}
+	if index >= 1<<31 {
+		return "", errp.Newf("The BIP85 index %d is too large.", index)
+	}
+	// The path is m/83696968'/39'/{language}'/{words}'/{index}', where 0 is English.
+	keyPath, err := signing.NewAbsoluteKeypath(fmt.Sprintf("m/83696968'/39'/0'/%d'/%d'", words, index))
+	if err != nil {
+		return "", err
+	}
+	entropy, err := keystore.bip85Entropy(keyPath)
+	if err != nil {
+		return "", err
+	}
+	return entropyToMnemonic(entropy[:words/3*4]), nil
+}
+
+// entropyToMnemonic encodes the entropy and its checksum as words of the English wordlist (BIP39).
+func entropyToMnemonic(entropy []byte) string {
+	// Every 32 bits of entropy get one bit of the checksum.
+	checksumLength := uint(len(entropy) / 4)
+	hash := sha256.Sum256(entropy)
+	bits := new(big.Int).SetBytes(entropy)
+	bits.Lsh(bits, checksumLength).Or(bits, big.NewInt(int64(hash[0]>>(8-checksumLength))))
+	words := make([]string, (len(entropy)*8+int(checksumLength))/11)
+	mask := big.NewInt(1<<11 - 1)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = bip39Words[new(big.Int).And(bits, mask).Int64()]
+		bits.Rsh(bits, 11)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,56 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software_test
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBIP85Mnemonic checks the BIP39 test vectors of BIP85.
+func TestBIP85Mnemonic(t *testing.T) {
+	master, err := hdkeychain.NewKeyFromString(
+		"xprv9s21ZrQH143K2LBWUUQRFXhucrQqBpKdRRxNVq2zBqsx8HVqFk2uYo8kmbaLLHRdqtQpUm98uKfu3vca1LqdGhUtyoFnCNkfmXRyPXLjbKb")
+	require.NoError(t, err)
+	keystore := software.NewKeystore(0, master)
+
+	for words, expected := range map[int]string{
+		12: "girl mad pet galaxy egg matter matrix prison refuse sense ordinary nose",
+		18: "near account window bike charge season chef number sketch tomorrow excuse sniff circle " +
+			"vital hockey outdoor supply token",
+		24: "puppy ocean match cereal symbol another shed magic wrap hammer bulb intact gadget divorce " +
+			"twin tonight reason outdoor destroy simple truth cigar social volcano",
+	} {
+		mnemonic, err := keystore.BIP85Mnemonic(words, 0)
+		require.NoError(t, err)
+		require.Equal(t, expected, mnemonic)
+		// The derived mnemonic is a valid mnemonic.
+		_, err = software.MnemonicToSeed(mnemonic, "")
+		require.NoError(t, err)
+	}
+
+	// Another index yields another mnemonic.
+	mnemonic, err := keystore.BIP85Mnemonic(12, 1)
+	require.NoError(t, err)
+	require.NotEqual(t, "girl mad pet galaxy egg matter matrix prison refuse sense ordinary nose", mnemonic)
+
+	_, err = keystore.BIP85Mnemonic(15, 0)
+	require.Error(t, err)
+	_, err = keystore.BIP85Mnemonic(12, 1<<31)
+	require.Error(t, err)
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrWrongPassword is returned if a seed file cannot be decrypted with the given password.
+var ErrWrongPassword = errors.New("wrong password")
+
+const (
+	seedFileVersion = 1
+	// The scrypt parameters recommended for interactive logins.
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// seedFile is the content of a file containing an encrypted seed.
+type seedFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// checkMnemonic checks that the words are in the English wordlist of BIP39 and that the last bits
+// they encode are the checksum of the entropy, so that mistyped words are detected.
+func checkMnemonic(words []string) error {
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return errp.Newf("A mnemonic has 12, 15, 18, 21 or 24 words, not %d.", len(words))
+	}
+	bits := new(big.Int)
+	for _, word := range words {
+		index, ok := bip39WordIndices[word]
+		if !ok {
+			return errp.Newf("The word %q is not in the BIP39 wordlist.", word)
+		}
+		bits.Lsh(bits, 11).Or(bits, big.NewInt(int64(index)))
+	}
+	// Every three words encode 32 bits of entropy and one bit of the checksum.
+	checksumLength := uint(len(words) / 3)
+	checksum := new(big.Int).And(bits, big.NewInt(1<<checksumLength-1)).Uint64()
+	entropy := bits.Rsh(bits, checksumLength).FillBytes(make([]byte, len(words)/3*4))
+	hash := sha256.Sum256(entropy)
+	if uint64(hash[0]>>(8-checksumLength)) != checksum {
+		return errp.New("The checksum of the mnemonic is invalid.")
+	}
+	return nil
+}
+
+// MnemonicToSeed returns the BIP39 seed of the given mnemonic and passphrase. The words and the
+// checksum of the mnemonic are checked against the English wordlist.
+func MnemonicToSeed(mnemonic string, passphrase string) ([]byte, error) {
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+	if err := checkMnemonic(words); err != nil {
+		return nil, err
+	}
+	salt := "mnemonic" + norm.NFKD.String(passphrase)
+	return pbkdf2.Key([]byte(strings.Join(words, " ")), []byte(salt), 2048, 64, sha512.New), nil
+}
+
+// seedFileKey derives the key with which a seed file is encrypted from the password.
+func seedFileKey(password string, salt []byte) (*[32]byte, error) {
+	derived, err := scrypt.Key([]byte(password), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	var key [32]byte
+	copy(key[:], derived)
+	return &key, nil
+}
+
+// WriteSeedFile encrypts the seed with the password and writes it to the file with the given name,
+// which must not exist yet.
+func WriteSeedFile(filename string, seed []byte, password string) error {
+	if password == "" {
+		return errp.New("The password must not be empty.")
+	}
+	content := seedFile{Version: seedFileVersion, Salt: make([]byte, 32), Nonce: make([]byte, 24)}
+	if _, err := io.ReadFull(rand.Reader, content.Salt); err != nil {
+		return errp.WithStack(err)
+	}
+	if _, err := io.ReadFull(rand.Reader, content.Nonce); err != nil {
+		return errp.WithStack(err)
+	}
+	key, err := seedFileKey(password, content.Salt)
+	if err != nil {
+		return err
+	}
+	var nonce [24]byte
+	copy(nonce[:], content.Nonce)
+	content.Ciphertext = secretbox.Seal(nil, seed, &nonce, key)
+	jsonBytes, err := json.Marshal(content)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if _, err := file.Write(jsonBytes); err != nil {
+		_ = file.Close()
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(file.Close())
+}
+
+// ReadSeedFile decrypts the seed in the file with the given name. Returns ErrWrongPassword if the
+// password does not match.
+func ReadSeedFile(filename string, password string) ([]byte, error) {
+	jsonBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	var content seedFile
+	if err := json.Unmarshal(jsonBytes, &content); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if content.Version != seedFileVersion || len(content.Nonce) != 24 {
+		return nil, errp.Newf("Unsupported seed file version %d.", content.Version)
+	}
+	key, err := seedFileKey(password, content.Salt)
+	if err != nil {
+		return nil, err
+	}
+	var nonce [24]byte
+	copy(nonce[:], content.Nonce)
+	seed, ok := secretbox.Open(nil, content.Ciphertext, &nonce, key)
+	if !ok {
+		return nil, errp.WithStack(ErrWrongPassword)
+	}
+	return seed, nil
+}
+
+// NewKeystoreFromSeed creates a keystore whose keys are derived from the given BIP39 seed.
+func NewKeystoreFromSeed(cosignerIndex int, seed []byte) (*Keystore, error) {
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return NewKeystore(cosignerIndex, master), nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software_test
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon " +
+	"abandon abandon abandon abandon abandon about"
+
+func TestMnemonicToSeed(t *testing.T) {
+	// Test vector of BIP39.
+	seed, err := software.MnemonicToSeed(testMnemonic, "TREZOR")
+	require.NoError(t, err)
+	require.Equal(t,
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e5349553"+
+			"1f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		hex.EncodeToString(seed))
+	// Surrounding and repeated whitespace is ignored.
+	spaced, err := software.MnemonicToSeed(" "+testMnemonic+"\n", "TREZOR")
+	require.NoError(t, err)
+	require.Equal(t, seed, spaced)
+
+	_, err = software.MnemonicToSeed("abandon abandon about", "")
+	require.Error(t, err)
+
+	// Valid mnemonics of other lengths.
+	_, err = software.MnemonicToSeed(
+		"legal winner thank year wave sausage worth useful legal winner thank yellow", "")
+	require.NoError(t, err)
+	_, err = software.MnemonicToSeed(strings.Repeat("zoo ", 23)+"vote", "")
+	require.NoError(t, err)
+	// The last word does not match the checksum.
+	_, err = software.MnemonicToSeed(strings.Repeat("abandon ", 12), "")
+	require.Error(t, err)
+	_, err = software.MnemonicToSeed(strings.Repeat("zoo ", 23)+"zoo", "")
+	require.Error(t, err)
+	// A word which is not in the wordlist.
+	_, err = software.MnemonicToSeed(strings.Replace(testMnemonic, "about", "bitbox", 1), "")
+	require.Error(t, err)
+}
+
+func TestSeedFile(t *testing.T) {
+	directory, err := ioutil.TempDir("", "seedfile")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(directory) }()
+	filename := path.Join(directory, "seed.json")
+
+	seed, err := software.MnemonicToSeed(testMnemonic, "TREZOR")
+	require.NoError(t, err)
+	require.Error(t, software.WriteSeedFile(filename, seed, ""))
+	require.NoError(t, software.WriteSeedFile(filename, seed, "password"))
+	// An existing seed file is never overwritten.
+	require.Error(t, software.WriteSeedFile(filename, seed, "password"))
+
+	_, err = software.ReadSeedFile(filename, "wrong")
+	require.Equal(t, software.ErrWrongPassword, errp.Cause(err))
+	decrypted, err := software.ReadSeedFile(filename, "password")
+	require.NoError(t, err)
+	require.Equal(t, seed, decrypted)
+
+	keystore, err := software.NewKeystoreFromSeed(0, decrypted)
+	require.NoError(t, err)
+	xpub, err := keystore.ExtendedPublicKey(signing.NewEmptyAbsoluteKeypath())
+	require.NoError(t, err)
+	// The master key of the BIP39 test vector.
+	require.Equal(t,
+		"xpub661MyMwAqRbcGB88KaFbLGiYAat55APKhtWg4uYMkXAmfuSTbq2QYsn9sKJ"+
+			"Cj1YqZPafsboef4h4YbXXhNhPwMbkHTpkf3zLhx7HvFw1NDy",
+		xpub.String())
+}
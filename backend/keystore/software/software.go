@@ -15,15 +15,21 @@
 package software
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"math/big"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
-	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcutil/hdkeychain"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/addresses"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/taproot"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/coin"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	keystorePkg "github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
 	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
@@ -80,6 +86,45 @@ func (keystore *Keystore) Identifier() (string, error) {
 	return keystore.identifier, nil
 }
 
+// SupportsCoin implements keystore.Keystore.
+func (keystore *Keystore) SupportsCoin(coin coin.Coin) bool {
+	switch coin.(type) {
+	case *btc.Coin, *eth.Coin:
+		return true
+	default:
+		return false
+	}
+}
+
+// SupportsScriptType implements keystore.Keystore.
+func (keystore *Keystore) SupportsScriptType(coin coin.Coin, scriptType signing.ScriptType) bool {
+	switch specificCoin := coin.(type) {
+	case *btc.Coin:
+		switch scriptType {
+		case signing.ScriptTypeP2PKH, signing.ScriptTypeP2WPKHP2SH, signing.ScriptTypeP2WPKH:
+			return true
+		case signing.ScriptTypeP2TR:
+			return specificCoin.SupportsTaproot()
+		default:
+			return false
+		}
+	case *eth.Coin:
+		return true
+	default:
+		return false
+	}
+}
+
+// SupportsAccount implements keystore.Keystore.
+func (keystore *Keystore) SupportsAccount(
+	coin coin.Coin, scriptType signing.ScriptType, multisig bool) bool {
+	if multisig {
+		_, ok := coin.(*btc.Coin)
+		return ok
+	}
+	return keystore.SupportsScriptType(coin, scriptType)
+}
+
 // HasSecureOutput implements keystore.Keystore.
 func (keystore *Keystore) HasSecureOutput() bool {
 	return false
@@ -90,6 +135,11 @@ func (keystore *Keystore) OutputAddress(signing.AbsoluteKeypath, signing.ScriptT
 	return errp.New("The software-based keystore has no secure output to display the address.")
 }
 
+// BatchOutputAddress implements keystore.Keystore.
+func (keystore *Keystore) BatchOutputAddress([]signing.AbsoluteKeypath, signing.ScriptType, coin.Coin) error {
+	return errp.New("The software-based keystore has no secure output to display the addresses.")
+}
+
 // ExtendedPublicKey implements keystore.Keystore.
 func (keystore *Keystore) ExtendedPublicKey(
 	absoluteKeypath signing.AbsoluteKeypath,
@@ -101,6 +151,95 @@ func (keystore *Keystore) ExtendedPublicKey(
 	return extendedPrivateKey.Neuter()
 }
 
+// RootFingerprint implements keystore.Keystore.
+func (keystore *Keystore) RootFingerprint() ([]byte, error) {
+	return keystorePkg.RootFingerprint(keystore)
+}
+
+// SignMessage implements keystore.Keystore.
+func (keystore *Keystore) SignMessage(
+	keyPath signing.AbsoluteKeypath, message []byte, coin coin.Coin) ([]byte, error) {
+	var signatureHash []byte
+	switch specificCoin := coin.(type) {
+	case *btc.Coin:
+		signatureHash = specificCoin.SignedMessageHash(message)
+	case *eth.Coin:
+		signatureHash = specificCoin.SignedMessageHash(message)
+	default:
+		return nil, errp.Newf("Message signing is not supported for %s.", coin.Code())
+	}
+	xprv, err := keyPath.Derive(keystore.master)
+	if err != nil {
+		return nil, err
+	}
+	prv, err := xprv.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := btcec.SignCompact(btcec.S256(), prv, signatureHash, true)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if _, ok := coin.(*eth.Coin); ok {
+		// Convert the compact signature [27 + 4 + recid || R || S] to [R || S || 27 + recid].
+		return append(sig[1:], sig[0]-4), nil
+	}
+	return sig, nil
+}
+
+// SignTypedData implements keystore.Keystore.
+func (keystore *Keystore) SignTypedData(
+	keyPath signing.AbsoluteKeypath, jsonTypedData []byte) ([]byte, error) {
+	typedData, err := eth.ParseTypedData(jsonTypedData)
+	if err != nil {
+		return nil, err
+	}
+	signatureHash, err := typedData.SignatureHash()
+	if err != nil {
+		return nil, err
+	}
+	xprv, err := keyPath.Derive(keystore.master)
+	if err != nil {
+		return nil, err
+	}
+	prv, err := xprv.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := btcec.SignCompact(btcec.S256(), prv, signatureHash, true)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	// Convert the compact signature [27 + 4 + recid || R || S] to [R || S || 27 + recid].
+	return append(sig[1:], sig[0]-4), nil
+}
+
+// SilentPaymentSharedSecret implements keystore.SilentPaymentsKeystore.
+func (keystore *Keystore) SilentPaymentSharedSecret(
+	keypaths []signing.AbsoluteKeypath, inputHash []byte, scanKey *btcec.PublicKey) (
+	*btcec.PublicKey, error) {
+	curve := btcec.S256()
+	sum := new(big.Int)
+	for _, keypath := range keypaths {
+		xprv, err := keypath.Derive(keystore.master)
+		if err != nil {
+			return nil, err
+		}
+		prv, err := xprv.ECPrivKey()
+		if err != nil {
+			return nil, err
+		}
+		sum.Add(sum, prv.D)
+	}
+	sum.Mul(sum, new(big.Int).SetBytes(inputHash))
+	sum.Mod(sum, curve.N)
+	if sum.Sign() == 0 {
+		return nil, errp.New("The tweaked sum of the input keys is zero.")
+	}
+	x, y := curve.ScalarMult(scanKey.X, scanKey.Y, sum.Bytes())
+	return &btcec.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
 func (keystore *Keystore) sign(
 	signatureHashes [][]byte,
 	keyPaths []signing.AbsoluteKeypath,
@@ -128,58 +267,145 @@ func (keystore *Keystore) sign(
 	return signatures, nil
 }
 
+// SignPSBT implements keystore.Keystore.
+func (keystore *Keystore) SignPSBT(packet *psbt.Packet) (*psbt.Packet, error) {
+	keystore.log.Info("Sign PSBT.")
+	return keystorePkg.SignPSBT(keystore, packet, keystore.sign)
+}
+
+// signTaproot returns the Schnorr signature of the hash for the key path spend of the taproot
+// output of the key at the given keypath (BIP86).
+func (keystore *Keystore) signTaproot(
+	signatureHash []byte, keyPath signing.AbsoluteKeypath) (*btcec.Signature, error) {
+	xprv, err := keyPath.Derive(keystore.master)
+	if err != nil {
+		return nil, err
+	}
+	prv, err := xprv.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	tweakedPrv, err := taproot.TweakPrivateKey(prv)
+	if err != nil {
+		return nil, err
+	}
+	auxRand := make([]byte, 32)
+	if _, err := rand.Read(auxRand); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	signature, err := taproot.Sign(tweakedPrv, signatureHash, auxRand)
+	if err != nil {
+		return nil, err
+	}
+	return taproot.SignatureFromBytes(signature)
+}
+
 // SignTransaction implements keystore.Keystore.
 func (keystore *Keystore) SignTransaction(
 	proposedTransaction coin.ProposedTransaction,
 ) error {
-	btcProposedTx, ok := proposedTransaction.(*btc.ProposedTransaction)
-	if !ok {
-		panic("Only BTC supported for now.")
+	switch specificProposedTransaction := proposedTransaction.(type) {
+	case *btc.ProposedTransaction:
+		return keystore.signBTCTransaction(specificProposedTransaction)
+	case *eth.TxProposal:
+		return keystore.signETHTransaction(specificProposedTransaction)
+	default:
+		return errp.New("The software-based keystore cannot sign this transaction.")
+	}
+}
+
+func (keystore *Keystore) signETHTransaction(txProposal *eth.TxProposal) error {
+	keystore.log.Info("Sign ETH transaction.")
+	var signatureHash []byte
+	switch txProposal.Type() {
+	case eth.DynamicFeeTxType:
+		hash, err := txProposal.DynamicFeeTx.SigHash()
+		if err != nil {
+			return err
+		}
+		signatureHash = hash.Bytes()
+	default:
+		signatureHash = txProposal.Signer.Hash(txProposal.Tx).Bytes()
+	}
+	xprv, err := txProposal.Keypath.Derive(keystore.master)
+	if err != nil {
+		return err
+	}
+	prv, err := xprv.ECPrivKey()
+	if err != nil {
+		return err
+	}
+	compact, err := btcec.SignCompact(btcec.S256(), prv, signatureHash, true)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	// Convert the compact signature [27 + 4 + recid || R || S] to [R || S || recid].
+	sig := append(compact[1:], compact[0]-27-4)
+	switch txProposal.Type() {
+	case eth.DynamicFeeTxType:
+		signedTx, err := txProposal.DynamicFeeTx.WithSignature(sig)
+		if err != nil {
+			return err
+		}
+		txProposal.DynamicFeeTx = signedTx
+	default:
+		signedTx, err := txProposal.Tx.WithSignature(txProposal.Signer, sig)
+		if err != nil {
+			return errp.WithStack(err)
+		}
+		txProposal.Tx = signedTx
+	}
+	return nil
+}
+
+func (keystore *Keystore) signBTCTransaction(btcProposedTx *btc.ProposedTransaction) error {
+	if err := btcProposedTx.CheckCanSign(keystore); err != nil {
+		return err
 	}
 	keystore.log.Info("Sign transaction.")
-	signatureHashes := [][]byte{}
-	keyPaths := []signing.AbsoluteKeypath{}
-	transaction := btcProposedTx.TXProposal.Transaction
-	for index, txIn := range transaction.TxIn {
-		spentOutput, ok := btcProposedTx.PreviousOutputs[txIn.PreviousOutPoint]
-		if !ok {
-			keystore.log.Panic("There needs to be exactly one output being spent per input!")
+	inputSignatureHashes, err := btcProposedTx.SignatureHashes()
+	if err != nil {
+		return err
+	}
+	signatureHashes := make([][]byte, 0, len(inputSignatureHashes))
+	keyPaths := make([]signing.AbsoluteKeypath, 0, len(inputSignatureHashes))
+	// inputIndices are the indices of the signed inputs, as external inputs are skipped.
+	inputIndices := make([]int, 0, len(inputSignatureHashes))
+	inputs := 0
+	for index, inputSignatureHash := range inputSignatureHashes {
+		if inputSignatureHash == nil {
+			continue
 		}
-		address := btcProposedTx.GetAddress(spentOutput.ScriptHashHex())
-		isSegwit, subScript := address.ScriptForHashToSign()
-		var signatureHash []byte
-		if isSegwit {
-			var err error
-			signatureHash, err = txscript.CalcWitnessSigHash(subScript, btcProposedTx.SigHashes,
-				txscript.SigHashAll, transaction, index, spentOutput.Value)
+		inputs++
+		keyPath := inputSignatureHash.Address.Configuration.AbsoluteKeypath()
+		if inputSignatureHash.SighashVersion == addresses.SighashVersionTaproot {
+			signature, err := keystore.signTaproot(inputSignatureHash.Hash, keyPath)
 			if err != nil {
-				return errp.Wrap(err, "Failed to calculate SegWit signature hash")
+				return errp.WithMessage(err, "Failed to sign taproot signature hash")
 			}
-			keystore.log.Debug("Calculated segwit signature hash")
-		} else {
-			var err error
-			signatureHash, err = txscript.CalcSignatureHash(
-				subScript, txscript.SigHashAll, transaction, index)
-			if err != nil {
-				return errp.Wrap(err, "Failed to calculate legacy signature hash")
-			}
-			keystore.log.Debug("Calculated legacy signature hash")
+			btcProposedTx.Signatures[index][keystore.CosignerIndex()] = signature
+			continue
 		}
-
-		signatureHashes = append(signatureHashes, signatureHash)
-		keyPaths = append(keyPaths, address.Configuration.AbsoluteKeypath())
+		signatureHashes = append(signatureHashes, inputSignatureHash.Hash)
+		keyPaths = append(keyPaths, keyPath)
+		inputIndices = append(inputIndices, index)
 	}
 
 	signatures, err := keystore.sign(signatureHashes, keyPaths)
 	if err != nil {
 		return errp.WithMessage(err, "Failed to sign signature hash")
 	}
-	if len(signatures) != len(transaction.TxIn) {
+	if len(signatures) != len(inputIndices) {
 		panic("number of signatures doesn't match number of inputs")
 	}
+	btcProposedTx.ReportProgress(btc.SignProgress{
+		Stage:      btc.SignStageReceivingSignatures,
+		Signatures: inputs,
+		Inputs:     inputs,
+	})
 	for i, signature := range signatures {
 		signature := signature
-		btcProposedTx.Signatures[i][keystore.CosignerIndex()] = &signature
+		btcProposedTx.Signatures[inputIndices[i]][keystore.CosignerIndex()] = &signature
 	}
 	return nil
 }
@@ -0,0 +1,183 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package software_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc/psbt"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/ltc"
+	keystorePkg "github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignETHTransaction(t *testing.T) {
+	keystore := software.NewKeystoreFromPIN(0, "1234")
+	keypath, err := signing.NewAbsoluteKeypath("m/44'/60'/0'/0/0")
+	require.NoError(t, err)
+	xpub, err := keystore.ExtendedPublicKey(keypath)
+	require.NoError(t, err)
+	publicKey, err := xpub.ECPubKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(*publicKey.ToECDSA())
+
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	txProposal := &eth.TxProposal{
+		DynamicFeeTx: &eth.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     3,
+			GasTipCap: big.NewInt(1000000000),
+			GasFeeCap: big.NewInt(30000000000),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(1000),
+		},
+		Keypath: keypath,
+	}
+	require.NoError(t, keystore.SignTransaction(txProposal))
+	sender, err := txProposal.DynamicFeeTx.Sender()
+	require.NoError(t, err)
+	require.Equal(t, address, sender)
+}
+
+func TestSupportsScriptType(t *testing.T) {
+	keystore := software.NewKeystoreFromPIN(0, "1234")
+	btcCoin := btc.NewCoin("btc", "BTC", &chaincfg.MainNetParams, ".", nil, "")
+	ltcCoin := btc.NewCoin("ltc", "LTC", &ltc.MainNetParams, ".", nil, "")
+	require.True(t, keystore.SupportsScriptType(btcCoin, signing.ScriptTypeP2WPKH))
+	require.True(t, keystore.SupportsScriptType(ltcCoin, signing.ScriptTypeP2WPKH))
+	require.True(t, keystore.SupportsScriptType(btcCoin, signing.ScriptTypeP2TR))
+	// Litecoin has no taproot.
+	require.False(t, keystore.SupportsScriptType(ltcCoin, signing.ScriptTypeP2TR))
+	require.False(t, keystore.SupportsAccount(ltcCoin, signing.ScriptTypeP2TR, false))
+	require.True(t, keystore.SupportsScriptType(eth.NewCoin("eth", eth.MainnetNetwork, "", ""), ""))
+}
+
+func TestSignPSBT(t *testing.T) {
+	keystore := software.NewKeystoreFromPIN(0, "1234")
+	master, err := keystore.ExtendedPublicKey(signing.NewEmptyAbsoluteKeypath())
+	require.NoError(t, err)
+	masterPublicKey, err := master.ECPubKey()
+	require.NoError(t, err)
+	fingerprint := psbt.MasterKeyFingerprint(masterPublicKey)
+
+	publicKey := func(encodedKeypath string) []byte {
+		keypath, err := signing.NewAbsoluteKeypath(encodedKeypath)
+		require.NoError(t, err)
+		xpub, err := keystore.ExtendedPublicKey(keypath)
+		require.NoError(t, err)
+		publicKey, err := xpub.ECPubKey()
+		require.NoError(t, err)
+		return publicKey.SerializeCompressed()
+	}
+	derivation := func(encodedKeypath string, fingerprint uint32) *psbt.Bip32Derivation {
+		keypath, err := signing.NewAbsoluteKeypath(encodedKeypath)
+		require.NoError(t, err)
+		return &psbt.Bip32Derivation{
+			PubKey:               publicKey(encodedKeypath),
+			MasterKeyFingerprint: fingerprint,
+			Path:                 keypath.ToUInt32(),
+		}
+	}
+	p2wpkhKeypath := "m/84'/1'/0'/0/0"
+	p2wpkhAddress, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(publicKey(p2wpkhKeypath)), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	p2pkhKeypath := "m/44'/1'/0'/0/0"
+	p2pkhAddress, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(publicKey(p2pkhKeypath)), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+
+	// The previous transaction pays to both keys of the keystore.
+	previousTransaction := wire.NewMsgTx(wire.TxVersion)
+	previousTransaction.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 0}, nil, nil))
+	for _, address := range []btcutil.Address{p2wpkhAddress, p2pkhAddress, p2wpkhAddress} {
+		pkScript, err := txscript.PayToAddrScript(address)
+		require.NoError(t, err)
+		previousTransaction.AddTxOut(wire.NewTxOut(100000, pkScript))
+	}
+	previousHash := previousTransaction.TxHash()
+	transaction := wire.NewMsgTx(wire.TxVersion)
+	for index := range previousTransaction.TxOut {
+		transaction.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&previousHash, uint32(index)), nil, nil))
+	}
+	transaction.AddTxOut(wire.NewTxOut(250000, previousTransaction.TxOut[0].PkScript))
+	packet, err := psbt.New(transaction)
+	require.NoError(t, err)
+	packet.Inputs[0].WitnessUtxo = previousTransaction.TxOut[0]
+	packet.Inputs[0].Bip32Derivation = []*psbt.Bip32Derivation{derivation(p2wpkhKeypath, fingerprint)}
+	packet.Inputs[1].NonWitnessUtxo = previousTransaction
+	packet.Inputs[1].Bip32Derivation = []*psbt.Bip32Derivation{derivation(p2pkhKeypath, fingerprint)}
+	// The last input belongs to another signer.
+	packet.Inputs[2].WitnessUtxo = previousTransaction.TxOut[2]
+	packet.Inputs[2].Bip32Derivation = []*psbt.Bip32Derivation{derivation(p2wpkhKeypath, fingerprint+1)}
+
+	// Sighash types which let others change the outputs are rejected.
+	for _, sigHashType := range []txscript.SigHashType{
+		txscript.SigHashNone, txscript.SigHashSingle, txscript.SigHashSingle | txscript.SigHashAnyOneCanPay,
+	} {
+		packet.Inputs[0].SighashType = sigHashType
+		_, err = keystore.SignPSBT(packet)
+		require.Error(t, err)
+	}
+	packet.Inputs[0].SighashType = txscript.SigHashAll | txscript.SigHashAnyOneCanPay
+	signedPacket, err := keystore.SignPSBT(packet)
+	require.NoError(t, err)
+	signature := signedPacket.Inputs[0].PartialSigs[0].Signature
+	require.Equal(t, byte(txscript.SigHashAll|txscript.SigHashAnyOneCanPay), signature[len(signature)-1])
+	packet.Inputs[0].SighashType = 0
+
+	signedPacket, err = keystore.SignPSBT(packet)
+	require.NoError(t, err)
+	for _, input := range packet.Inputs {
+		require.Empty(t, input.PartialSigs)
+	}
+	require.Empty(t, signedPacket.Inputs[2].PartialSigs)
+
+	// The signatures are valid for the spent outputs.
+	partialSigs := signedPacket.Inputs[0].PartialSigs
+	require.Len(t, partialSigs, 1)
+	transaction.TxIn[0].Witness = wire.TxWitness{partialSigs[0].Signature, partialSigs[0].PubKey}
+	partialSigs = signedPacket.Inputs[1].PartialSigs
+	require.Len(t, partialSigs, 1)
+	signatureScript, err := txscript.NewScriptBuilder().
+		AddData(partialSigs[0].Signature).AddData(partialSigs[0].PubKey).Script()
+	require.NoError(t, err)
+	transaction.TxIn[1].SignatureScript = signatureScript
+	for index := 0; index < 2; index++ {
+		spentOutput := previousTransaction.TxOut[index]
+		engine, err := txscript.NewEngine(spentOutput.PkScript, transaction, index,
+			txscript.StandardVerifyFlags, nil, txscript.NewTxSigHashes(transaction), spentOutput.Value)
+		require.NoError(t, err)
+		require.NoError(t, engine.Execute())
+	}
+
+	// Signing again does not add signatures.
+	_, err = keystore.SignPSBT(signedPacket)
+	require.Equal(t, keystorePkg.ErrNoPSBTInputs, errp.Cause(err))
+
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+)
+
+// XPubCache persists the extended public keys of the keystores by root fingerprint and keypath,
+// so that the accounts can be initialized without asking the device for every keypath.
+type XPubCache struct {
+	filename string
+	// xpubs are the encoded extended public keys by hex encoded root fingerprint and keypath. The
+	// master key is stored at "m" and has to match, so that keys are never mixed up if the
+	// fingerprints of two seeds collide. It is nil until the file has been loaded.
+	xpubs map[string]map[string]string
+	lock  locker.Locker
+}
+
+// NewXPubCache returns a cache which is stored in the file with the given name. The file is
+// created when the first key is cached.
+func NewXPubCache(filename string) *XPubCache {
+	return &XPubCache{filename: filename}
+}
+
+// load reads the cache file once. A missing or corrupt file results in an empty cache. The lock
+// must be held.
+func (cache *XPubCache) load() {
+	if cache.xpubs != nil {
+		return
+	}
+	cache.xpubs = map[string]map[string]string{}
+	jsonBytes, err := ioutil.ReadFile(cache.filename)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(jsonBytes, &cache.xpubs); err != nil {
+		cache.xpubs = map[string]map[string]string{}
+	}
+}
+
+// store writes the cache file. The lock must be held.
+func (cache *XPubCache) store() error {
+	jsonBytes, err := json.Marshal(cache.xpubs)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cache.filename), 0700); err != nil {
+		return errp.WithStack(err)
+	}
+	return errp.WithStack(ioutil.WriteFile(cache.filename, jsonBytes, 0600))
+}
+
+// ExtendedPublicKey returns the extended public key of the keystore at the given keypath from the
+// cache. If it is not cached yet, it is retrieved from the keystore and added to the cache. Only
+// the master key is retrieved from the keystore on every call, which keystores of devices keep in
+// memory. Keys of keystores without a master key, see ErrUnknownKeypath, are not cached.
+func (cache *XPubCache) ExtendedPublicKey(
+	keystore Keystore, keypath signing.AbsoluteKeypath) (*hdkeychain.ExtendedKey, error) {
+	master, err := keystore.ExtendedPublicKey(signing.NewEmptyAbsoluteKeypath())
+	if errp.Cause(err) == ErrUnknownKeypath {
+		return keystore.ExtendedPublicKey(keypath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := rootFingerprint(master)
+	if err != nil {
+		return nil, err
+	}
+	encodedFingerprint := hex.EncodeToString(fingerprint)
+	encodedKeypath := keypath.Encode()
+
+	defer cache.lock.Lock()()
+	cache.load()
+	xpubs := cache.xpubs[encodedFingerprint]
+	if xpubs["m"] != master.String() {
+		xpubs = map[string]string{"m": master.String()}
+		cache.xpubs[encodedFingerprint] = xpubs
+	}
+	if xpub, ok := xpubs[encodedKeypath]; ok {
+		if extendedPublicKey, err := hdkeychain.NewKeyFromString(xpub); err == nil {
+			return extendedPublicKey, nil
+		}
+	}
+	extendedPublicKey, err := keystore.ExtendedPublicKey(keypath)
+	if err != nil {
+		return nil, err
+	}
+	xpubs[encodedKeypath] = extendedPublicKey.String()
+	// The cache only speeds up the next start, so the key is returned even if it cannot be stored.
+	_ = cache.store()
+	return extendedPublicKey, nil
+}
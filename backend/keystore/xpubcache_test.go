@@ -0,0 +1,93 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/mocks"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/test"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newCountingKeystore returns a mock keystore which retrieves the extended public keys from a
+// software keystore with the given PIN and records the calls.
+func newCountingKeystore(pin string) *mocks.Keystore {
+	softwareKeystore := software.NewKeystoreFromPIN(0, pin)
+	keystore := &mocks.Keystore{}
+	keystore.On("ExtendedPublicKey", mock.Anything).Return(
+		func(keypath signing.AbsoluteKeypath) *hdkeychain.ExtendedKey {
+			extendedPublicKey, err := softwareKeystore.ExtendedPublicKey(keypath)
+			if err != nil {
+				panic(err)
+			}
+			return extendedPublicKey
+		}, nil)
+	return keystore
+}
+
+func TestXPubCache(t *testing.T) {
+	filename := filepath.Join(test.TstTempDir("xpubcache"), "xpubs.json")
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/0'/0'")
+	require.NoError(t, err)
+	expected, err := software.NewKeystoreFromPIN(0, "1234").ExtendedPublicKey(keypath)
+	require.NoError(t, err)
+
+	keystore1 := newCountingKeystore("1234")
+	cache := keystore.NewXPubCache(filename)
+	extendedPublicKey, err := cache.ExtendedPublicKey(keystore1, keypath)
+	require.NoError(t, err)
+	require.Equal(t, expected.String(), extendedPublicKey.String())
+	keystore1.AssertNumberOfCalls(t, "ExtendedPublicKey", 2)
+
+	// Only the master key is retrieved once the key is cached, also after a restart.
+	extendedPublicKey, err = cache.ExtendedPublicKey(keystore1, keypath)
+	require.NoError(t, err)
+	require.Equal(t, expected.String(), extendedPublicKey.String())
+	keystore1.AssertNumberOfCalls(t, "ExtendedPublicKey", 3)
+	extendedPublicKey, err = keystore.NewXPubCache(filename).ExtendedPublicKey(keystore1, keypath)
+	require.NoError(t, err)
+	require.Equal(t, expected.String(), extendedPublicKey.String())
+	keystore1.AssertNumberOfCalls(t, "ExtendedPublicKey", 4)
+
+	// The keys of other seeds are cached separately.
+	keystore2 := newCountingKeystore("5678")
+	extendedPublicKey, err = cache.ExtendedPublicKey(keystore2, keypath)
+	require.NoError(t, err)
+	require.NotEqual(t, expected.String(), extendedPublicKey.String())
+	keystore2.AssertNumberOfCalls(t, "ExtendedPublicKey", 2)
+}
+
+func TestXPubCacheWithoutMasterKey(t *testing.T) {
+	keypath, err := signing.NewAbsoluteKeypath("m/84'/0'/0'")
+	require.NoError(t, err)
+	expected, err := software.NewKeystoreFromPIN(0, "1234").ExtendedPublicKey(keypath)
+	require.NoError(t, err)
+
+	accountKeystore := &mocks.Keystore{}
+	accountKeystore.On("ExtendedPublicKey", signing.NewEmptyAbsoluteKeypath()).Return(
+		nil, keystore.ErrUnknownKeypath)
+	accountKeystore.On("ExtendedPublicKey", keypath).Return(expected, nil)
+	cache := keystore.NewXPubCache(filepath.Join(test.TstTempDir("xpubcache"), "xpubs.json"))
+	extendedPublicKey, err := cache.ExtendedPublicKey(accountKeystore, keypath)
+	require.NoError(t, err)
+	require.Equal(t, expected.String(), extendedPublicKey.String())
+}
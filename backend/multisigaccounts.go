@@ -0,0 +1,216 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// maxMultisigSigners is the maximum number of cosigners of a multisig account, including the
+// keystore.
+const maxMultisigSigners = 15
+
+// multisigAccountCode returns the code of a multisig account. It is derived from the keypath and
+// the cosigners, so that it stays the same when other multisig accounts are added.
+func multisigAccountCode(multisigAccount *config.MultisigAccount) string {
+	hash := sha256.Sum256([]byte(strings.Join(
+		append([]string{multisigAccount.Keypath}, multisigAccount.Cosigners...), "\n")))
+	return fmt.Sprintf("%s-multisig-%dof%d-%s", multisigAccount.Coin, multisigAccount.Threshold,
+		len(multisigAccount.Cosigners)+1, hex.EncodeToString(hash[:4]))
+}
+
+// MultisigAccountCoins returns the coins for which multisig accounts can be added.
+func (backend *Backend) MultisigAccountCoins() []string {
+	coins := []string{}
+	for coinCode, scriptTypes := range backend.CustomAccountCoins() {
+		if len(scriptTypes) != 0 {
+			coins = append(coins, coinCode)
+		}
+	}
+	sort.Strings(coins)
+	return coins
+}
+
+// parseCosigners parses the extended public keys of the cosigners.
+func parseCosigners(cosigners []string) ([]*hdkeychain.ExtendedKey, error) {
+	extendedPublicKeys := make([]*hdkeychain.ExtendedKey, len(cosigners))
+	for index, cosigner := range cosigners {
+		extendedPublicKey, err := hdkeychain.NewKeyFromString(cosigner)
+		if err != nil {
+			return nil, errp.WithMessage(errp.WithStack(err),
+				fmt.Sprintf("Invalid extended public key of cosigner %d", index+1))
+		}
+		if extendedPublicKey.IsPrivate() {
+			return nil, errp.Newf("The key of cosigner %d is private. Only extended public keys are accepted.",
+				index+1)
+		}
+		extendedPublicKeys[index] = extendedPublicKey
+	}
+	return extendedPublicKeys, nil
+}
+
+// checkMultisigAccount returns an error if the multisig account is not supported.
+func (backend *Backend) checkMultisigAccount(multisigAccount *config.MultisigAccount) error {
+	supported := false
+	for _, coinCode := range backend.MultisigAccountCoins() {
+		supported = supported || coinCode == multisigAccount.Coin
+	}
+	if !supported {
+		return errp.Newf("Multisig accounts are not supported for %s.", multisigAccount.Coin)
+	}
+	if _, err := signing.NewAbsoluteKeypath(multisigAccount.Keypath); err != nil {
+		return errp.WithMessage(err, "Invalid keypath")
+	}
+	numberOfSigners := len(multisigAccount.Cosigners) + 1
+	if numberOfSigners < 2 || numberOfSigners > maxMultisigSigners {
+		return errp.Newf("A multisig account needs 1 to %d cosigners.", maxMultisigSigners-1)
+	}
+	if multisigAccount.Threshold < 1 || multisigAccount.Threshold > numberOfSigners {
+		return errp.Newf("The threshold has to be between 1 and %d.", numberOfSigners)
+	}
+	_, err := parseCosigners(multisigAccount.Cosigners)
+	return err
+}
+
+// multisigConfiguration returns the signing configuration of the multisig account, in which the
+// keystore is the first cosigner.
+func (backend *Backend) multisigConfiguration(
+	multisigAccount *config.MultisigAccount) (*signing.Configuration, error) {
+	if backend.keystores.Count() != 1 {
+		return nil, errp.New("Multisig accounts need exactly one keystore.")
+	}
+	absoluteKeypath, err := signing.NewAbsoluteKeypath(multisigAccount.Keypath)
+	if err != nil {
+		return nil, err
+	}
+	keystoreConfiguration, err := backend.keystores.Configuration(
+		signing.ScriptTypeP2WSH, absoluteKeypath, multisigAccount.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	cosigners, err := parseCosigners(multisigAccount.Cosigners)
+	if err != nil {
+		return nil, err
+	}
+	extendedPublicKeys := append(keystoreConfiguration.ExtendedPublicKeys(), cosigners...)
+	publicKeys := map[string]struct{}{}
+	for _, extendedPublicKey := range extendedPublicKeys {
+		publicKey, err := extendedPublicKey.ECPubKey()
+		if err != nil {
+			return nil, errp.WithStack(err)
+		}
+		serialized := string(publicKey.SerializeCompressed())
+		if _, ok := publicKeys[serialized]; ok {
+			return nil, errp.New("The cosigners of a multisig account have to be distinct.")
+		}
+		publicKeys[serialized] = struct{}{}
+	}
+	return signing.NewConfiguration(signing.ScriptTypeP2WSH, absoluteKeypath, extendedPublicKeys,
+		multisigAccount.Threshold), nil
+}
+
+// addMultisigAccounts adds the multisig accounts of the config. Multisig accounts of coins which
+// are not available, e.g. mainnet coins in testing mode, are skipped.
+func (backend *Backend) addMultisigAccounts() {
+	if backend.arguments.Multisig() {
+		return
+	}
+	for _, multisigAccount := range backend.config.Config().Backend.MultisigAccounts {
+		multisigAccount := multisigAccount
+		code := multisigAccountCode(&multisigAccount)
+		if err := backend.checkMultisigAccount(&multisigAccount); err != nil {
+			backend.log.WithField("code", code).WithError(err).Info("skipping multisig account")
+			continue
+		}
+		btcCoin, ok := backend.Coin(multisigAccount.Coin).(*btc.Coin)
+		if !ok {
+			continue
+		}
+		if !backend.keystores.SupportAccount(btcCoin, signing.ScriptTypeP2WSH, true) {
+			backend.log.WithField("code", code).Info("skipping multisig account unsupported by the keystore")
+			continue
+		}
+		backend.log.WithField("code", code).WithField("name", multisigAccount.Name).Info("init multisig account")
+		gapLimits := backend.config.Config().Backend.GapLimit(code)
+		account := btc.NewAccount(btcCoin, backend.arguments.CacheDirectoryPath(), code,
+			multisigAccount.Name,
+			func() (*signing.Configuration, error) {
+				return backend.multisigConfiguration(&multisigAccount)
+			},
+			backend.keystores, btcutil.Amount(backend.config.Config().Backend.DustLimit(code)),
+			btc.GapLimits{Receive: gapLimits.Receive, Change: gapLimits.Change},
+			backend.txOrdering(),
+			func(event btc.Event) {
+				backend.events <- AccountEvent{Type: "account", Code: code, Data: string(event)}
+			}, backend.log)
+		backend.accounts = append(backend.accounts, account)
+	}
+}
+
+// AddMultisigAccount adds an m-of-n multisig account of the given coin, spending from P2WSH
+// outputs. The keystore is the first cosigner, the other cosigners are given by their extended
+// public keys at the keypath.
+func (backend *Backend) AddMultisigAccount(
+	coinCode string, name string, keypath string, threshold int, cosigners []string) error {
+	multisigAccount := config.MultisigAccount{
+		Coin:      coinCode,
+		Name:      strings.TrimSpace(name),
+		Keypath:   strings.TrimSpace(keypath),
+		Threshold: threshold,
+		Cosigners: []string{},
+	}
+	for _, cosigner := range cosigners {
+		if cosigner = strings.TrimSpace(cosigner); cosigner != "" {
+			multisigAccount.Cosigners = append(multisigAccount.Cosigners, cosigner)
+		}
+	}
+	if err := backend.checkMultisigAccount(&multisigAccount); err != nil {
+		return err
+	}
+	if _, err := backend.multisigConfiguration(&multisigAccount); err != nil {
+		return err
+	}
+	if multisigAccount.Name == "" {
+		multisigAccount.Name = fmt.Sprintf("%s %d-of-%d Multisig", strings.ToUpper(coinCode),
+			threshold, len(multisigAccount.Cosigners)+1)
+	}
+	appConfig := backend.config.Config()
+	code := multisigAccountCode(&multisigAccount)
+	for _, existing := range appConfig.Backend.MultisigAccounts {
+		existing := existing
+		if multisigAccountCode(&existing) == code {
+			return errp.New("The account already exists.")
+		}
+	}
+	multisigAccounts := append([]config.MultisigAccount{}, appConfig.Backend.MultisigAccounts...)
+	appConfig.Backend.MultisigAccounts = append(multisigAccounts, multisigAccount)
+	if err := backend.config.Set(appConfig); err != nil {
+		return err
+	}
+	backend.initAccounts()
+	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/stretchr/testify/require"
+)
+
+const testCosigner = "tpubDCxoQyC5JaGydxN3yprM6sgqgu65LruN3JBm1fnSmGxXR3AcuNwr" +
+	"E7J2CVaCvuLPJtJNySjshNsYbR96Y7yfEdcywYqWubzUQLVGh2b4mF9"
+
+func TestMultisigAccountCode(t *testing.T) {
+	multisigAccount := &config.MultisigAccount{
+		Coin: "tbtc", Name: "Shared", Keypath: "m/48'/1'/0'/2'", Threshold: 2,
+		Cosigners: []string{testCosigner, testCosigner + "2"},
+	}
+	code := multisigAccountCode(multisigAccount)
+	require.Regexp(t, "^tbtc-multisig-2of3-[0-9a-f]{8}$", code)
+	// The name does not change the code.
+	multisigAccount.Name = "Renamed"
+	require.Equal(t, code, multisigAccountCode(multisigAccount))
+	multisigAccount.Keypath = "m/48'/1'/1'/2'"
+	require.NotEqual(t, code, multisigAccountCode(multisigAccount))
+}
+
+func TestParseCosigners(t *testing.T) {
+	cosigners, err := parseCosigners([]string{testCosigner})
+	require.NoError(t, err)
+	require.Len(t, cosigners, 1)
+	require.Equal(t, testCosigner, cosigners[0].String())
+
+	_, err = parseCosigners([]string{testCosigner, "tpubinvalid"})
+	require.Error(t, err)
+	// Private keys are rejected.
+	master, err := hdkeychain.NewMaster(make([]byte, hdkeychain.RecommendedSeedLen), &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	_, err = parseCosigners([]string{master.String()})
+	require.Error(t, err)
+}
@@ -23,23 +23,41 @@ import (
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/observable"
 	"github.com/digitalbitbox/bitbox-wallet-app/util/observable/action"
 	"github.com/sirupsen/logrus"
 )
 
-var coins = []string{"BTC", "LTC", "ETH"}
+var coins = []string{"BTC", "LTC", "ETH", "POL", "XDAI"}
 var fiats = []string{"USD", "EUR", "CHF", "GBP", "JPY", "KRW", "CNY", "RUB"}
 
 const interval = time.Minute
 const url = "https://min-api.cryptocompare.com/data/pricemulti?fsyms=%s&tsyms=%s"
 
+// tokenRatesURL returns the rates of tokens of a CoinGecko platform by contract address.
+const tokenRatesURL = "https://api.coingecko.com/api/v3/simple/token_price/%s?contract_addresses=%s&vs_currencies=%s"
+
+// RatesToken is an ERC20 token whose rates are fetched by contract address.
+type RatesToken struct {
+	// Platform is the CoinGecko id of the network of the token, e.g. "ethereum".
+	Platform string
+	// Contract is the contract address of the token.
+	Contract string
+	// Unit is the unit of the amounts of the token, under which its rates are published.
+	Unit string
+}
+
 // RatesUpdater implements coin.RatesUpdater.
 type RatesUpdater struct {
 	observable.Implementation
 	last map[string]map[string]float64
 	log  *logrus.Entry
+
+	tokens     []RatesToken
+	tokensLock locker.Locker
 }
 
 // NewRatesUpdater returns a new rates updater.
@@ -57,26 +75,109 @@ func (updater *RatesUpdater) Last() map[string]map[string]float64 {
 	return updater.last
 }
 
-func (updater *RatesUpdater) update() {
-	response, err := http.Get(fmt.Sprintf(url,
-		strings.Join(coins, ","),
-		strings.Join(fiats, ","),
-	))
+// SetTokens sets the ERC20 tokens whose rates are fetched in addition to the ones of the coins.
+func (updater *RatesUpdater) SetTokens(tokens []RatesToken) {
+	unlock := updater.tokensLock.Lock()
+	changed := !reflect.DeepEqual(tokens, updater.tokens)
+	updater.tokens = tokens
+	unlock()
+	if changed {
+		go updater.update()
+	}
+}
+
+func getJSON(url string, result interface{}) error {
+	response, err := http.Get(url)
 	if err != nil {
-		updater.last = nil
-		return
+		return errp.WithStack(err)
 	}
 	defer func() {
 		_ = response.Body.Close()
 	}()
+	if response.StatusCode != http.StatusOK {
+		return errp.Newf("%s returned status %d", url, response.StatusCode)
+	}
+	return errp.WithStack(json.NewDecoder(response.Body).Decode(result))
+}
 
+// fetchTokenRates returns the rates of the tokens by platform and lowercase contract address.
+func fetchTokenRates(tokens []RatesToken) (map[string]map[string]map[string]float64, error) {
+	contracts := map[string][]string{}
+	for _, token := range tokens {
+		contracts[token.Platform] = append(contracts[token.Platform], strings.ToLower(token.Contract))
+	}
+	tokenRates := map[string]map[string]map[string]float64{}
+	for platform, platformContracts := range contracts {
+		var rates map[string]map[string]float64
+		err := getJSON(fmt.Sprintf(tokenRatesURL,
+			platform,
+			strings.Join(platformContracts, ","),
+			strings.ToLower(strings.Join(fiats, ",")),
+		), &rates)
+		if err != nil {
+			return nil, err
+		}
+		tokenRates[platform] = rates
+	}
+	return tokenRates, nil
+}
+
+// addTokenRates adds the rates of the tokens, which CoinGecko returns by lowercase contract address
+// and lowercase fiat, to the rates under the units of the tokens. The rates of the coins are never
+// replaced, and a unit of several tokens with different rates is skipped as it is ambiguous.
+func addTokenRates(
+	rates map[string]map[string]float64,
+	tokens []RatesToken,
+	tokenRates map[string]map[string]map[string]float64,
+) {
+	added := map[string]bool{}
+	ambiguous := map[string]bool{}
+	for _, token := range tokens {
+		fiatRates, ok := tokenRates[token.Platform][strings.ToLower(token.Contract)]
+		if !ok {
+			continue
+		}
+		if _, ok := rates[token.Unit]; ok && !added[token.Unit] {
+			continue
+		}
+		unitRates := map[string]float64{}
+		for fiat, rate := range fiatRates {
+			unitRates[strings.ToUpper(fiat)] = rate
+		}
+		if added[token.Unit] && !reflect.DeepEqual(rates[token.Unit], unitRates) {
+			ambiguous[token.Unit] = true
+		}
+		rates[token.Unit] = unitRates
+		added[token.Unit] = true
+	}
+	for unit := range ambiguous {
+		delete(rates, unit)
+	}
+}
+
+func (updater *RatesUpdater) update() {
 	var rates map[string]map[string]float64
-	err = json.NewDecoder(response.Body).Decode(&rates)
+	err := getJSON(fmt.Sprintf(url,
+		strings.Join(coins, ","),
+		strings.Join(fiats, ","),
+	), &rates)
 	if err != nil {
 		updater.last = nil
 		return
 	}
 
+	unlock := updater.tokensLock.RLock()
+	tokens := updater.tokens
+	unlock()
+	if len(tokens) > 0 {
+		tokenRates, err := fetchTokenRates(tokens)
+		if err != nil {
+			updater.log.WithError(err).Error("Could not fetch the rates of the ERC20 tokens.")
+		} else {
+			addTokenRates(rates, tokens, tokenRates)
+		}
+	}
+
 	if reflect.DeepEqual(rates, updater.last) {
 		return
 	}
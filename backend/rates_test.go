@@ -0,0 +1,57 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddTokenRates(t *testing.T) {
+	rates := map[string]map[string]float64{
+		"ETH": {"USD": 2000, "CHF": 1800},
+	}
+	tokens := []RatesToken{
+		{Platform: "ethereum", Contract: "0xdAC17F958D2ee523a2206206994597C13D831ec7", Unit: "USDT"},
+		// Same unit and rates on another network.
+		{Platform: "polygon-pos", Contract: "0xc2132D05D31c914a87C6611C10748AEb04B58e8F", Unit: "USDT"},
+		// A token must not replace the rates of a coin.
+		{Platform: "ethereum", Contract: "0x0000000000000000000000000000000000000001", Unit: "ETH"},
+		// Two tokens with the same unit but different rates.
+		{Platform: "ethereum", Contract: "0x0000000000000000000000000000000000000002", Unit: "FAKE"},
+		{Platform: "base", Contract: "0x0000000000000000000000000000000000000003", Unit: "FAKE"},
+		// No rates known.
+		{Platform: "ethereum", Contract: "0x0000000000000000000000000000000000000004", Unit: "NEW"},
+	}
+	tokenRates := map[string]map[string]map[string]float64{
+		"ethereum": {
+			"0xdac17f958d2ee523a2206206994597c13d831ec7": {"usd": 1, "chf": 0.9},
+			"0x0000000000000000000000000000000000000001": {"usd": 0.01, "chf": 0.01},
+			"0x0000000000000000000000000000000000000002": {"usd": 5, "chf": 4.5},
+		},
+		"polygon-pos": {
+			"0xc2132d05d31c914a87c6611c10748aeb04b58e8f": {"usd": 1, "chf": 0.9},
+		},
+		"base": {
+			"0x0000000000000000000000000000000000000003": {"usd": 0.1, "chf": 0.09},
+		},
+	}
+	addTokenRates(rates, tokens, tokenRates)
+	require.Equal(t, map[string]map[string]float64{
+		"ETH":  {"USD": 2000, "CHF": 1800},
+		"USDT": {"USD": 1, "CHF": 0.9},
+	}, rates)
+}
@@ -40,6 +40,13 @@ const (
 
 	// ScriptTypeP2WPKH is a segwit PayToPubKeyHash output.
 	ScriptTypeP2WPKH ScriptType = "p2wpkh"
+
+	// ScriptTypeP2TR is a segwit v1 (taproot) output spent via the key path.
+	ScriptTypeP2TR ScriptType = "p2tr"
+
+	// ScriptTypeP2WSH is a segwit multisig output, with the sorted multisig script as its witness
+	// script. It only applies to multisig configurations.
+	ScriptTypeP2WSH ScriptType = "p2wsh"
 )
 
 // Configuration models a signing configuration, which can be singlesig or multisig.
@@ -50,9 +57,10 @@ type Configuration struct {
 	signingThreshold   int
 }
 
-// NewConfiguration creates a new configuration. At the moment, multisig is a predefined
-// multisig-P2SH script, and is active if there are more than one xpubs. Otherwise, it's single sig
-// and `scriptType` defines the type of script.
+// NewConfiguration creates a new configuration. Multisig is a predefined sorted multisig script,
+// and is active if there are more than one xpubs. The script is wrapped in P2WSH if `scriptType` is
+// ScriptTypeP2WSH and in P2SH otherwise. If there is only one xpub, it's single sig and
+// `scriptType` defines the type of script.
 func NewConfiguration(
 	scriptType ScriptType,
 	absoluteKeypath AbsoluteKeypath,
@@ -148,6 +156,11 @@ func (configuration *Configuration) Multisig() bool {
 	return len(configuration.extendedPublicKeys) > 1
 }
 
+// P2WSH returns whether this is a multisig configuration whose script is wrapped in P2WSH.
+func (configuration *Configuration) P2WSH() bool {
+	return configuration.Multisig() && configuration.scriptType == ScriptTypeP2WSH
+}
+
 // Derive derives a subkeypath from the configuration's base absolute keypath.
 func (configuration *Configuration) Derive(relativeKeypath RelativeKeypath) (*Configuration, error) {
 	if relativeKeypath.Hardened() {
@@ -221,6 +234,10 @@ func (configuration *Configuration) Hash() string {
 
 // String returns a short summary of the configuration to be used in logs, etc.
 func (configuration *Configuration) String() string {
+	if configuration.P2WSH() {
+		return fmt.Sprintf("multisig p2wsh, %d/%d",
+			configuration.SigningThreshold(), configuration.NumberOfSigners())
+	}
 	if configuration.Multisig() {
 		return fmt.Sprintf("multisig, %d/%d",
 			configuration.SigningThreshold(), configuration.NumberOfSigners())
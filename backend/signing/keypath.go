@@ -82,6 +82,17 @@ func (path keypath) encode() string {
 	return strings.Join(nodes, "/")
 }
 
+func (path keypath) toUInt32() []uint32 {
+	result := make([]uint32, len(path))
+	for index, node := range path {
+		result[index] = node.index
+		if node.hardened {
+			result[index] += hdkeychain.HardenedKeyStart
+		}
+	}
+	return result
+}
+
 func (path keypath) derive(extendedKey *hdkeychain.ExtendedKey) (*hdkeychain.ExtendedKey, error) {
 	for _, node := range path {
 		offset := uint32(0)
@@ -168,6 +179,20 @@ func NewAbsoluteKeypath(input string) (AbsoluteKeypath, error) {
 	return AbsoluteKeypath(path), nil
 }
 
+// NewAbsoluteKeypathFromUInt32 creates a new absolute keypath from the given child indices, with
+// hardened indices offset by 2^31, e.g. from the derivation of a key in a PSBT.
+func NewAbsoluteKeypathFromUInt32(indices ...uint32) AbsoluteKeypath {
+	absoluteKeypath := make(AbsoluteKeypath, len(indices))
+	for index, childIndex := range indices {
+		hardened := childIndex >= hdkeychain.HardenedKeyStart
+		if hardened {
+			childIndex -= hdkeychain.HardenedKeyStart
+		}
+		absoluteKeypath[index] = keyNode{index: childIndex, hardened: hardened}
+	}
+	return absoluteKeypath
+}
+
 // Encode encodes the absolute keypath as a string.
 func (absoluteKeypath AbsoluteKeypath) Encode() string {
 	return "m/" + keypath(absoluteKeypath).encode()
@@ -192,6 +217,11 @@ func (absoluteKeypath AbsoluteKeypath) Derive(
 	return keypath(absoluteKeypath).derive(extendedKey)
 }
 
+// ToUInt32 returns the child indices of the keypath, with hardened indices offset by 2^31.
+func (absoluteKeypath AbsoluteKeypath) ToUInt32() []uint32 {
+	return keypath(absoluteKeypath).toUInt32()
+}
+
 // MarshalJSON implements json.Marshaler.
 func (absoluteKeypath AbsoluteKeypath) MarshalJSON() ([]byte, error) {
 	return json.Marshal(absoluteKeypath.Encode())
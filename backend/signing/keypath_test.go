@@ -27,6 +27,8 @@ func TestKeypath(t *testing.T) {
 	absoluteKeypath, err := signing.NewAbsoluteKeypath(input)
 	assert.NoError(t, err)
 	assert.Equal(t, "m/44'/0'/1'/0", absoluteKeypath.Encode())
+	assert.Equal(t, []uint32{0x8000002c, 0x80000000, 0x80000001, 0}, absoluteKeypath.ToUInt32())
+	assert.Equal(t, absoluteKeypath, signing.NewAbsoluteKeypathFromUInt32(absoluteKeypath.ToUInt32()...))
 
 	bytes, err := json.Marshal(absoluteKeypath)
 	assert.NoError(t, err)
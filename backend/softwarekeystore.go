@@ -0,0 +1,110 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"os"
+	"path"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore/software"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// SoftwareKeystoreStatus describes the software keystore.
+type SoftwareKeystoreStatus struct {
+	// Enabled is true if the software keystore is enabled in the settings.
+	Enabled bool `json:"enabled"`
+	// Exists is true if the encrypted seed file exists.
+	Exists bool `json:"exists"`
+	// Unlocked is true if the software keystore is registered.
+	Unlocked bool `json:"unlocked"`
+}
+
+// softwareKeystoreFilename returns the path of the file containing the encrypted seed of the
+// software keystore.
+func (backend *Backend) softwareKeystoreFilename() string {
+	return path.Join(backend.arguments.MainDirectoryPath(), "software-keystore.json")
+}
+
+// SoftwareKeystoreStatus returns the status of the software keystore.
+func (backend *Backend) SoftwareKeystoreStatus() *SoftwareKeystoreStatus {
+	_, err := os.Stat(backend.softwareKeystoreFilename())
+	return &SoftwareKeystoreStatus{
+		Enabled:  backend.config.Config().Backend.SoftwareKeystoreEnabled,
+		Exists:   err == nil,
+		Unlocked: backend.softwareKeystore != nil,
+	}
+}
+
+// CreateSoftwareKeystore stores the BIP39 seed of the given mnemonic and passphrase encrypted with
+// the password and unlocks the software keystore. An existing seed file is never overwritten.
+func (backend *Backend) CreateSoftwareKeystore(mnemonic string, passphrase string, password string) error {
+	if !backend.config.Config().Backend.SoftwareKeystoreEnabled {
+		return errp.New("The software keystore is not enabled.")
+	}
+	if backend.SoftwareKeystoreStatus().Exists {
+		return errp.New("The software keystore already exists.")
+	}
+	seed, err := software.MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := software.WriteSeedFile(backend.softwareKeystoreFilename(), seed, password); err != nil {
+		return err
+	}
+	return backend.UnlockSoftwareKeystore(password)
+}
+
+// UnlockSoftwareKeystore decrypts the seed of the software keystore with the password and
+// registers the keystore. It fails if another keystore, e.g. of a device, is registered. Returns
+// software.ErrWrongPassword if the password does not match.
+func (backend *Backend) UnlockSoftwareKeystore(password string) error {
+	if !backend.config.Config().Backend.SoftwareKeystoreEnabled {
+		return errp.New("The software keystore is not enabled.")
+	}
+	if backend.keystores.Count() != 0 {
+		return errp.New("Another keystore is registered.")
+	}
+	seed, err := software.ReadSeedFile(backend.softwareKeystoreFilename(), password)
+	if err != nil {
+		return err
+	}
+	softwareKeystore, err := software.NewKeystoreFromSeed(0, seed)
+	if err != nil {
+		return err
+	}
+	backend.softwareKeystore = softwareKeystore
+	backend.RegisterKeystore(softwareKeystore)
+	return nil
+}
+
+// LockSoftwareKeystore deregisters the software keystore.
+func (backend *Backend) LockSoftwareKeystore() error {
+	if backend.softwareKeystore == nil {
+		return errp.New("The software keystore is not unlocked.")
+	}
+	backend.DeregisterKeystore()
+	return nil
+}
+
+// SoftwareKeystoreBIP85Mnemonic derives the BIP85 child mnemonic with the given number of words at
+// the given index from the seed of the unlocked software keystore. The software keystore has no
+// secure output, so the mnemonic is returned to be shown in the app.
+func (backend *Backend) SoftwareKeystoreBIP85Mnemonic(words int, index uint32) (string, error) {
+	if backend.softwareKeystore == nil {
+		return "", errp.New("The software keystore is not unlocked.")
+	}
+	return backend.softwareKeystore.BIP85Mnemonic(words, index)
+}
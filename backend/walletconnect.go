@@ -0,0 +1,67 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/walletconnect"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/observable"
+)
+
+// walletConnectAccount returns the Ethereum account with the given code for dapps to connect to.
+func (backend *Backend) walletConnectAccount(code string) (walletconnect.Account, error) {
+	defer backend.accountsLock.RLock()()
+	for _, account := range backend.accounts {
+		if account.Code() != code {
+			continue
+		}
+		ethAccount, ok := account.(*eth.Account)
+		if !ok || ethAccount.Coin().(*eth.Coin).ERC20Token() != nil {
+			return nil, errp.New("Dapps can only connect to Ethereum accounts.")
+		}
+		if !ethAccount.Initialized() {
+			return nil, errp.New("The account is not initialized yet.")
+		}
+		return ethAccount, nil
+	}
+	return nil, errp.Newf("The account %s is not available. Please connect your BitBox.", code)
+}
+
+// WalletConnect returns the manager of the WalletConnect sessions, creating it on first use.
+func (backend *Backend) WalletConnect() (*walletconnect.Manager, error) {
+	defer backend.walletConnectLock.Lock()()
+	if backend.walletConnect != nil {
+		return backend.walletConnect, nil
+	}
+	manager, err := walletconnect.NewManager(
+		walletconnect.DefaultRelayURL,
+		func() string { return backend.config.Config().Backend.WalletConnectProjectID },
+		walletconnect.Metadata{
+			Name:        "BitBox Wallet",
+			Description: "BitBox Wallet App",
+			URL:         "https://shiftcrypto.ch",
+			Icons:       []string{},
+		},
+		backend.walletConnectAccount,
+		backend.log,
+	)
+	if err != nil {
+		return nil, err
+	}
+	manager.Observe(func(event observable.Event) { backend.events <- event })
+	backend.walletConnect = manager
+	return manager, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package walletconnect
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/random"
+	"golang.org/x/crypto/ed25519"
+)
+
+// authTokenLifetime is how long a relay auth token is valid.
+const authTokenLifetime = 24 * time.Hour
+
+// didKey returns the did:key identifier of the ed25519 public key: "did:key:z" followed by the
+// base58btc encoding of the multicodec prefix 0xed01 and the key.
+func didKey(publicKey ed25519.PublicKey) string {
+	return "did:key:z" + base58.Encode(append([]byte{0xed, 0x01}, publicKey...))
+}
+
+// relayAuthToken returns the JWT with which the client authenticates at the relay with the given
+// URL.
+func relayAuthToken(privateKey ed25519.PrivateKey, relayURL string, now time.Time) (string, error) {
+	subject, err := random.HexString(32)
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(map[string]string{"alg": "EdDSA", "typ": "JWT"})
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss": didKey(privateKey.Public().(ed25519.PublicKey)),
+		"sub": subject,
+		"aud": relayURL,
+		"iat": now.Unix(),
+		"exp": now.Add(authTokenLifetime).Unix(),
+	})
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	encoding := base64.RawURLEncoding
+	signed := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	signature := ed25519.Sign(privateKey, []byte(signed))
+	return signed + "." + encoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package walletconnect
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// envelopeType0 is the envelope of messages encrypted with a symmetric key known to both peers.
+	envelopeType0 = 0
+	ivSize        = 12
+	keySize       = 32
+)
+
+// keyPair is an X25519 key pair used to agree on the symmetric key of a session.
+type keyPair struct {
+	privateKey [keySize]byte
+	publicKey  [keySize]byte
+}
+
+// newKeyPair generates a random key pair.
+func newKeyPair() (*keyPair, error) {
+	pair := &keyPair{}
+	if _, err := io.ReadFull(rand.Reader, pair.privateKey[:]); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	curve25519.ScalarBaseMult(&pair.publicKey, &pair.privateKey)
+	return pair, nil
+}
+
+// symKey derives the symmetric key shared with the peer: HKDF-SHA256 of the X25519 shared secret.
+func (pair *keyPair) symKey(peerPublicKey []byte) ([]byte, error) {
+	if len(peerPublicKey) != keySize {
+		return nil, errp.New("Invalid public key of the peer.")
+	}
+	var peer, sharedSecret [keySize]byte
+	copy(peer[:], peerPublicKey)
+	curve25519.ScalarMult(&sharedSecret, &pair.privateKey, &peer)
+	symKey := make([]byte, keySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret[:], nil, nil), symKey); err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return symKey, nil
+}
+
+// topicOf returns the topic on which messages encrypted with the given symmetric key are sent.
+func topicOf(symKey []byte) string {
+	hash := sha256.Sum256(symKey)
+	return hex.EncodeToString(hash[:])
+}
+
+// encrypt seals the payload with ChaCha20-Poly1305 into a base64 encoded type 0 envelope:
+// type || iv || sealed payload.
+func encrypt(symKey []byte, payload []byte) (string, error) {
+	aead, err := chacha20poly1305.New(symKey)
+	if err != nil {
+		return "", errp.WithStack(err)
+	}
+	iv := make([]byte, ivSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", errp.WithStack(err)
+	}
+	envelope := append([]byte{envelopeType0}, iv...)
+	envelope = aead.Seal(envelope, iv, payload, nil)
+	return base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+// decrypt opens a base64 encoded type 0 envelope.
+func decrypt(symKey []byte, message string) ([]byte, error) {
+	envelope, err := base64.StdEncoding.DecodeString(message)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	if len(envelope) < 1+ivSize || envelope[0] != envelopeType0 {
+		return nil, errp.New("Unsupported message envelope.")
+	}
+	aead, err := chacha20poly1305.New(symKey)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	iv, sealed := envelope[1:1+ivSize], envelope[1+ivSize:]
+	payload, err := aead.Open(nil, iv, sealed, nil)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return payload, nil
+}
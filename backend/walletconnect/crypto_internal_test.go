@@ -0,0 +1,87 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package walletconnect
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/random"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestSymKey(t *testing.T) {
+	wallet, err := newKeyPair()
+	require.NoError(t, err)
+	dapp, err := newKeyPair()
+	require.NoError(t, err)
+	walletSymKey, err := wallet.symKey(dapp.publicKey[:])
+	require.NoError(t, err)
+	dappSymKey, err := dapp.symKey(wallet.publicKey[:])
+	require.NoError(t, err)
+	require.Equal(t, walletSymKey, dappSymKey)
+	require.Len(t, walletSymKey, keySize)
+	require.Len(t, topicOf(walletSymKey), 64)
+
+	_, err = wallet.symKey([]byte{1, 2, 3})
+	require.Error(t, err)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	symKey := random.BytesOrPanic(keySize)
+	encrypted, err := encrypt(symKey, []byte("payload"))
+	require.NoError(t, err)
+	envelope, err := base64.StdEncoding.DecodeString(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, byte(envelopeType0), envelope[0])
+
+	payload, err := decrypt(symKey, encrypted)
+	require.NoError(t, err)
+	require.Equal(t, []byte("payload"), payload)
+
+	_, err = decrypt(random.BytesOrPanic(keySize), encrypted)
+	require.Error(t, err)
+	envelope[0] = 1
+	_, err = decrypt(symKey, base64.StdEncoding.EncodeToString(envelope))
+	require.Error(t, err)
+}
+
+func TestRelayAuthToken(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	now := time.Unix(1600000000, 0)
+	token, err := relayAuthToken(privateKey, DefaultRelayURL, now)
+	require.NoError(t, err)
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(publicKey, []byte(parts[0]+"."+parts[1]), signature))
+
+	encodedClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var claims map[string]interface{}
+	require.NoError(t, json.Unmarshal(encodedClaims, &claims))
+	require.Equal(t, didKey(publicKey), claims["iss"])
+	require.True(t, strings.HasPrefix(didKey(publicKey), "did:key:z6Mk"))
+	require.Equal(t, DefaultRelayURL, claims["aud"])
+	require.Equal(t, float64(now.Unix()), claims["iat"])
+	require.Equal(t, float64(now.Add(authTokenLifetime).Unix()), claims["exp"])
+}
@@ -0,0 +1,639 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package walletconnect
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/observable"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/observable/action"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+// sessionLifetime is how long a session is valid after it has been settled.
+const sessionLifetime = 7 * 24 * time.Hour
+
+var (
+	supportedMethods = []string{
+		"eth_sendTransaction",
+		"personal_sign",
+		"eth_sign",
+		"eth_signTypedData",
+		"eth_signTypedData_v4",
+	}
+	supportedEvents = []string{"chainChanged", "accountsChanged"}
+)
+
+// Account is an Ethereum account to which dapps can connect.
+type Account interface {
+	Address() common.Address
+	ChainID() *big.Int
+	SendTransactionRequest(*eth.TransactionRequest) (common.Hash, error)
+	SignMessage(address string, message []byte) (string, error)
+	SignTypedData(*eth.TypedData) (string, error)
+}
+
+// Metadata describes a peer to the user.
+type Metadata struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Icons       []string `json:"icons"`
+}
+
+// namespace lists the chains, accounts, methods and events of a chain family, e.g. "eip155".
+type namespace struct {
+	Chains   []string `json:"chains,omitempty"`
+	Accounts []string `json:"accounts,omitempty"`
+	Methods  []string `json:"methods"`
+	Events   []string `json:"events"`
+}
+
+type pairing struct {
+	symKey      []byte
+	accountCode string
+}
+
+// Proposal is a request of a dapp to connect to an account.
+type Proposal struct {
+	ID          int64
+	AccountCode string
+	Peer        Metadata
+
+	pairingTopic       string
+	peerPublicKey      []byte
+	requiredNamespaces map[string]namespace
+	optionalNamespaces map[string]namespace
+}
+
+// Session is a connection to a dapp.
+type Session struct {
+	Topic       string
+	AccountCode string
+	Peer        Metadata
+	Expiry      time.Time
+
+	symKey []byte
+}
+
+// Request is a request of a connected dapp, which is executed once the user approves it.
+type Request struct {
+	ID          int64
+	Topic       string
+	AccountCode string
+	Peer        Metadata
+	Method      string
+	Params      json.RawMessage
+}
+
+// Manager pairs with dapps and handles the proposals, sessions and requests. It notifies its
+// observers with the subject "walletconnect" whenever they change.
+type Manager struct {
+	observable.Implementation
+
+	relay    relay
+	metadata Metadata
+	accounts func(code string) (Account, error)
+
+	pairings  map[string]*pairing
+	proposals map[int64]*Proposal
+	sessions  map[string]*Session
+	requests  map[int64]*Request
+	lock      locker.Locker
+
+	log *logrus.Entry
+}
+
+// NewManager creates a manager connecting to the given relay server. The metadata describes the
+// wallet to the dapps. accounts returns the account with the given code, which has to be an
+// Ethereum account.
+func NewManager(
+	relayURL string,
+	projectID func() string,
+	metadata Metadata,
+	accounts func(code string) (Account, error),
+	log *logrus.Entry,
+) (*Manager, error) {
+	manager := newManager(nil, metadata, accounts, log)
+	websocketRelay, err := newWebsocketRelay(relayURL, projectID, manager.handleMessage, manager.log)
+	if err != nil {
+		return nil, err
+	}
+	manager.relay = websocketRelay
+	return manager, nil
+}
+
+func newManager(
+	relay relay,
+	metadata Metadata,
+	accounts func(code string) (Account, error),
+	log *logrus.Entry,
+) *Manager {
+	return &Manager{
+		relay:     relay,
+		metadata:  metadata,
+		accounts:  accounts,
+		pairings:  map[string]*pairing{},
+		proposals: map[int64]*Proposal{},
+		sessions:  map[string]*Session{},
+		requests:  map[int64]*Request{},
+		log:       log.WithField("group", "walletconnect"),
+	}
+}
+
+func (manager *Manager) notify() {
+	manager.Notify(observable.Event{Subject: "walletconnect", Action: action.Reload})
+}
+
+// Pair pairs the account with the dapp showing the given pairing URI. The dapp then proposes a
+// session, which the user has to approve.
+func (manager *Manager) Pair(accountCode string, uri string) error {
+	pairingURI, err := parsePairingURI(uri)
+	if err != nil {
+		return err
+	}
+	if _, err := manager.accounts(accountCode); err != nil {
+		return err
+	}
+	unlock := manager.lock.Lock()
+	manager.pairings[pairingURI.topic] = &pairing{symKey: pairingURI.symKey, accountCode: accountCode}
+	unlock()
+	if err := manager.relay.subscribe(pairingURI.topic); err != nil {
+		unlock := manager.lock.Lock()
+		delete(manager.pairings, pairingURI.topic)
+		unlock()
+		return err
+	}
+	manager.log.Info("Paired")
+	return nil
+}
+
+// Proposals returns the proposals which wait for the approval of the user, oldest first.
+func (manager *Manager) Proposals() []*Proposal {
+	defer manager.lock.RLock()()
+	proposals := []*Proposal{}
+	for _, proposal := range manager.proposals {
+		proposals = append(proposals, proposal)
+	}
+	sort.Slice(proposals, func(i, j int) bool { return proposals[i].ID < proposals[j].ID })
+	return proposals
+}
+
+// Sessions returns the sessions sorted by expiry.
+func (manager *Manager) Sessions() []*Session {
+	defer manager.lock.RLock()()
+	sessions := []*Session{}
+	for _, session := range manager.sessions {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Expiry.Before(sessions[j].Expiry) })
+	return sessions
+}
+
+// Requests returns the requests which wait for the approval of the user, oldest first.
+func (manager *Manager) Requests() []*Request {
+	defer manager.lock.RLock()()
+	requests := []*Request{}
+	for _, request := range manager.requests {
+		requests = append(requests, request)
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].ID < requests[j].ID })
+	return requests
+}
+
+// symKey returns the symmetric key of the pairing or session with the given topic.
+func (manager *Manager) symKey(topic string) ([]byte, bool) {
+	defer manager.lock.RLock()()
+	if pairing, ok := manager.pairings[topic]; ok {
+		return pairing.symKey, true
+	}
+	if session, ok := manager.sessions[topic]; ok {
+		return session.symKey, true
+	}
+	return nil, false
+}
+
+// send encrypts and publishes the message with the relay parameters of the method.
+func (manager *Manager) send(topic string, msg *message, options publishOptions) error {
+	symKey, ok := manager.symKey(topic)
+	if !ok {
+		return errp.New("Unknown WalletConnect topic.")
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	encrypted, err := encrypt(symKey, payload)
+	if err != nil {
+		return err
+	}
+	return manager.relay.publish(topic, encrypted, options)
+}
+
+func (manager *Manager) request(topic string, method string, params interface{}) error {
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	return manager.send(topic,
+		&message{ID: payloadID(), JSONRPC: "2.0", Method: method, Params: encodedParams},
+		requestOptions[method])
+}
+
+func (manager *Manager) respond(topic string, id int64, method string, result interface{}) error {
+	encodedResult, err := json.Marshal(result)
+	if err != nil {
+		return errp.WithStack(err)
+	}
+	return manager.send(topic,
+		&message{ID: id, JSONRPC: "2.0", Result: encodedResult},
+		responseOptions[method])
+}
+
+func (manager *Manager) respondError(topic string, id int64, method string, code int, text string) error {
+	return manager.send(topic,
+		&message{ID: id, JSONRPC: "2.0", Error: &rpcError{Code: code, Message: text}},
+		responseOptions[method])
+}
+
+// handleMessage handles an encrypted message received on the topic of a pairing or session.
+func (manager *Manager) handleMessage(topic string, encrypted string) {
+	symKey, ok := manager.symKey(topic)
+	if !ok {
+		return
+	}
+	payload, err := decrypt(symKey, encrypted)
+	if err != nil {
+		manager.log.WithError(err).Error("Could not decrypt message")
+		return
+	}
+	var msg message
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		manager.log.WithError(err).Error("Invalid message")
+		return
+	}
+	if !msg.isRequest() {
+		if msg.Error != nil {
+			manager.log.WithField("error", msg.Error.Message).Error("Request failed")
+		}
+		return
+	}
+	manager.log.WithField("method", msg.Method).Info("Received request")
+	if err := manager.handleRequest(topic, &msg); err != nil {
+		manager.log.WithError(err).Error("Could not handle request")
+	}
+}
+
+func (manager *Manager) handleRequest(topic string, msg *message) error {
+	switch msg.Method {
+	case methodSessionPropose:
+		return manager.handleProposal(topic, msg)
+	case methodSessionRequest:
+		return manager.handleSessionRequest(topic, msg)
+	case methodSessionDelete, methodPairingDelete:
+		if err := manager.respond(topic, msg.ID, msg.Method, true); err != nil {
+			manager.log.WithError(err).Error("Could not acknowledge the deletion")
+		}
+		manager.remove(topic)
+		return nil
+	case methodSessionPing, methodPairingPing:
+		return manager.respond(topic, msg.ID, msg.Method, true)
+	default:
+		return manager.respondError(topic, msg.ID, msg.Method, errorCodeUnsupportedMethods,
+			"Unsupported method "+msg.Method)
+	}
+}
+
+func (manager *Manager) handleProposal(topic string, msg *message) error {
+	var params struct {
+		Proposer struct {
+			PublicKey string   `json:"publicKey"`
+			Metadata  Metadata `json:"metadata"`
+		} `json:"proposer"`
+		RequiredNamespaces map[string]namespace `json:"requiredNamespaces"`
+		OptionalNamespaces map[string]namespace `json:"optionalNamespaces"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return errp.WithStack(err)
+	}
+	peerPublicKey, err := hex.DecodeString(params.Proposer.PublicKey)
+	if err != nil || len(peerPublicKey) != keySize {
+		return manager.respondError(topic, msg.ID, msg.Method, errorCodeUnsupportedProposal,
+			"Invalid public key")
+	}
+	unlock := manager.lock.Lock()
+	pairing, ok := manager.pairings[topic]
+	if ok {
+		manager.proposals[msg.ID] = &Proposal{
+			ID:                 msg.ID,
+			AccountCode:        pairing.accountCode,
+			Peer:               params.Proposer.Metadata,
+			pairingTopic:       topic,
+			peerPublicKey:      peerPublicKey,
+			requiredNamespaces: params.RequiredNamespaces,
+			optionalNamespaces: params.OptionalNamespaces,
+		}
+	}
+	unlock()
+	if !ok {
+		return errp.New("Session proposals are only accepted on pairing topics.")
+	}
+	manager.notify()
+	return nil
+}
+
+func (manager *Manager) handleSessionRequest(topic string, msg *message) error {
+	var params struct {
+		Request struct {
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		} `json:"request"`
+		ChainID string `json:"chainId"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return errp.WithStack(err)
+	}
+	unlock := manager.lock.RLock()
+	session, ok := manager.sessions[topic]
+	unlock()
+	if !ok {
+		return errp.New("Requests are only accepted on session topics.")
+	}
+	account, err := manager.accounts(session.AccountCode)
+	if err != nil {
+		return manager.respondError(topic, msg.ID, msg.Method, errorCodeInternal, err.Error())
+	}
+	if params.ChainID != chainID(account) {
+		return manager.respondError(topic, msg.ID, msg.Method, errorCodeUnsupportedChains,
+			"Unsupported chain "+params.ChainID)
+	}
+	if !contains(supportedMethods, params.Request.Method) {
+		return manager.respondError(topic, msg.ID, msg.Method, errorCodeUnsupportedMethods,
+			"Unsupported method "+params.Request.Method)
+	}
+	unlock = manager.lock.Lock()
+	manager.requests[msg.ID] = &Request{
+		ID:          msg.ID,
+		Topic:       topic,
+		AccountCode: session.AccountCode,
+		Peer:        session.Peer,
+		Method:      params.Request.Method,
+		Params:      params.Request.Params,
+	}
+	unlock()
+	manager.notify()
+	return nil
+}
+
+// remove forgets the pairing or session with the given topic and its proposals and requests.
+func (manager *Manager) remove(topic string) {
+	unlock := manager.lock.Lock()
+	delete(manager.pairings, topic)
+	delete(manager.sessions, topic)
+	for id, proposal := range manager.proposals {
+		if proposal.pairingTopic == topic {
+			delete(manager.proposals, id)
+		}
+	}
+	for id, request := range manager.requests {
+		if request.Topic == topic {
+			delete(manager.requests, id)
+		}
+	}
+	unlock()
+	if err := manager.relay.unsubscribe(topic); err != nil {
+		manager.log.WithError(err).Error("Could not unsubscribe")
+	}
+	manager.notify()
+}
+
+// chainID returns the CAIP-2 chain id of the account, e.g. "eip155:1".
+func chainID(account Account) string {
+	return "eip155:" + account.ChainID().String()
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNamespaces returns an error code and message if the required namespaces are not
+// supported by the account.
+func checkNamespaces(namespaces map[string]namespace, chain string) (int, string) {
+	for key, required := range namespaces {
+		chains := required.Chains
+		if strings.HasPrefix(key, "eip155:") {
+			chains = append(chains, key)
+		} else if key != "eip155" {
+			return errorCodeUnsupportedChains, "Unsupported namespace " + key
+		}
+		for _, requiredChain := range chains {
+			if requiredChain != chain {
+				return errorCodeUnsupportedChains, "Unsupported chain " + requiredChain
+			}
+		}
+		for _, method := range required.Methods {
+			if !contains(supportedMethods, method) {
+				return errorCodeUnsupportedMethods, "Unsupported method " + method
+			}
+		}
+		for _, event := range required.Events {
+			if !contains(supportedEvents, event) {
+				return errorCodeUnsupportedEvents, "Unsupported event " + event
+			}
+		}
+	}
+	return 0, ""
+}
+
+func (manager *Manager) takeProposal(id int64) (*Proposal, error) {
+	defer manager.lock.Lock()()
+	proposal, ok := manager.proposals[id]
+	if !ok {
+		return nil, errp.New("The session proposal does not exist anymore.")
+	}
+	delete(manager.proposals, id)
+	return proposal, nil
+}
+
+// ApproveProposal connects the account to the dapp which proposed the session.
+func (manager *Manager) ApproveProposal(id int64) error {
+	proposal, err := manager.takeProposal(id)
+	if err != nil {
+		return err
+	}
+	defer manager.notify()
+	account, err := manager.accounts(proposal.AccountCode)
+	if err != nil {
+		return err
+	}
+	chain := chainID(account)
+	if code, text := checkNamespaces(proposal.requiredNamespaces, chain); code != 0 {
+		if err := manager.respondError(
+			proposal.pairingTopic, id, methodSessionPropose, code, text); err != nil {
+			manager.log.WithError(err).Error("Could not reject the proposal")
+		}
+		return errp.Newf("The dapp requires features which are not supported: %s.", text)
+	}
+	keyPair, err := newKeyPair()
+	if err != nil {
+		return err
+	}
+	symKey, err := keyPair.symKey(proposal.peerPublicKey)
+	if err != nil {
+		return err
+	}
+	session := &Session{
+		Topic:       topicOf(symKey),
+		AccountCode: proposal.AccountCode,
+		Peer:        proposal.Peer,
+		Expiry:      time.Now().Add(sessionLifetime),
+		symKey:      symKey,
+	}
+	unlock := manager.lock.Lock()
+	manager.sessions[session.Topic] = session
+	unlock()
+	if err := manager.relay.subscribe(session.Topic); err != nil {
+		unlock := manager.lock.Lock()
+		delete(manager.sessions, session.Topic)
+		unlock()
+		return err
+	}
+	relay := map[string]string{"protocol": "irn"}
+	publicKey := hex.EncodeToString(keyPair.publicKey[:])
+	if err := manager.respond(proposal.pairingTopic, id, methodSessionPropose, map[string]interface{}{
+		"relay":              relay,
+		"responderPublicKey": publicKey,
+	}); err != nil {
+		return err
+	}
+	namespaces := map[string]namespace{
+		"eip155": {
+			Chains:   []string{chain},
+			Accounts: []string{chain + ":" + account.Address().Hex()},
+			Methods:  supportedMethods,
+			Events:   supportedEvents,
+		},
+	}
+	if err := manager.request(session.Topic, methodSessionSettle, map[string]interface{}{
+		"relay":              relay,
+		"namespaces":         namespaces,
+		"requiredNamespaces": proposal.requiredNamespaces,
+		"optionalNamespaces": proposal.optionalNamespaces,
+		"pairingTopic":       proposal.pairingTopic,
+		"controller": map[string]interface{}{
+			"publicKey": publicKey,
+			"metadata":  manager.metadata,
+		},
+		"expiry": session.Expiry.Unix(),
+	}); err != nil {
+		return err
+	}
+	manager.log.WithField("peer", proposal.Peer.URL).Info("Session approved")
+	return nil
+}
+
+// RejectProposal rejects the session proposal.
+func (manager *Manager) RejectProposal(id int64) error {
+	proposal, err := manager.takeProposal(id)
+	if err != nil {
+		return err
+	}
+	defer manager.notify()
+	return manager.respondError(proposal.pairingTopic, id, methodSessionPropose,
+		errorCodeUserRejected, "User rejected.")
+}
+
+// Disconnect ends the session.
+func (manager *Manager) Disconnect(topic string) error {
+	unlock := manager.lock.RLock()
+	_, ok := manager.sessions[topic]
+	unlock()
+	if !ok {
+		return errp.New("The session does not exist anymore.")
+	}
+	err := manager.request(topic, methodSessionDelete, rpcError{
+		Code:    errorCodeUserDisconnected,
+		Message: "User disconnected.",
+	})
+	manager.remove(topic)
+	return err
+}
+
+func (manager *Manager) takeRequest(id int64) (*Request, error) {
+	defer manager.lock.Lock()()
+	request, ok := manager.requests[id]
+	if !ok {
+		return nil, errp.New("The request does not exist anymore.")
+	}
+	delete(manager.requests, id)
+	return request, nil
+}
+
+// ApproveRequest executes the request of the dapp, e.g. signs and sends the requested
+// transaction, and returns the result to the dapp. If the user aborts signing on the device, the
+// request is rejected and keystore.ErrSigningAborted is returned.
+func (manager *Manager) ApproveRequest(id int64) error {
+	request, err := manager.takeRequest(id)
+	if err != nil {
+		return err
+	}
+	defer manager.notify()
+	account, err := manager.accounts(request.AccountCode)
+	if err == nil {
+		var result interface{}
+		result, err = executeRequest(account, request.Method, request.Params)
+		if err == nil {
+			return manager.respond(request.Topic, id, methodSessionRequest, result)
+		}
+	}
+	if errp.Cause(err) == keystore.ErrSigningAborted {
+		if err := manager.respondError(request.Topic, id, methodSessionRequest,
+			errorCodeUserRejected, "User rejected."); err != nil {
+			manager.log.WithError(err).Error("Could not reject the request")
+		}
+		return err
+	}
+	if err := manager.respondError(
+		request.Topic, id, methodSessionRequest, errorCodeInternal, err.Error()); err != nil {
+		manager.log.WithError(err).Error("Could not respond to the request")
+	}
+	return err
+}
+
+// RejectRequest rejects the request of the dapp.
+func (manager *Manager) RejectRequest(id int64) error {
+	request, err := manager.takeRequest(id)
+	if err != nil {
+		return err
+	}
+	defer manager.notify()
+	return manager.respondError(request.Topic, id, methodSessionRequest,
+		errorCodeUserRejected, "User rejected.")
+}
@@ -0,0 +1,336 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package walletconnect
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/logging"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/random"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+type published struct {
+	topic   string
+	message string
+	options publishOptions
+}
+
+type testRelay struct {
+	subscribed map[string]bool
+	published  []published
+}
+
+func (relay *testRelay) subscribe(topic string) error {
+	relay.subscribed[topic] = true
+	return nil
+}
+
+func (relay *testRelay) unsubscribe(topic string) error {
+	delete(relay.subscribed, topic)
+	return nil
+}
+
+func (relay *testRelay) publish(topic string, message string, options publishOptions) error {
+	relay.published = append(relay.published, published{topic, message, options})
+	return nil
+}
+
+type testAccount struct {
+	address     common.Address
+	signMessage func(address string, message []byte) (string, error)
+	request     *eth.TransactionRequest
+}
+
+func (account *testAccount) Address() common.Address { return account.address }
+
+func (account *testAccount) ChainID() *big.Int { return big.NewInt(1) }
+
+func (account *testAccount) SendTransactionRequest(
+	request *eth.TransactionRequest) (common.Hash, error) {
+	account.request = request
+	return common.HexToHash("0x01"), nil
+}
+
+func (account *testAccount) SignMessage(address string, message []byte) (string, error) {
+	return account.signMessage(address, message)
+}
+
+func (account *testAccount) SignTypedData(*eth.TypedData) (string, error) {
+	return "0xtypeddata", nil
+}
+
+// dapp is the peer of the wallet in the tests.
+type dapp struct {
+	t            *testing.T
+	manager      *Manager
+	relay        *testRelay
+	keyPair      *keyPair
+	pairingTopic string
+	pairingKey   []byte
+	sessionTopic string
+	sessionKey   []byte
+}
+
+func (dapp *dapp) send(topic string, symKey []byte, method string, params interface{}) int64 {
+	encodedParams, err := json.Marshal(params)
+	require.NoError(dapp.t, err)
+	id := payloadID()
+	payload, err := json.Marshal(&message{ID: id, JSONRPC: "2.0", Method: method, Params: encodedParams})
+	require.NoError(dapp.t, err)
+	encrypted, err := encrypt(symKey, payload)
+	require.NoError(dapp.t, err)
+	dapp.manager.handleMessage(topic, encrypted)
+	return id
+}
+
+// received decrypts the last message published by the wallet.
+func (dapp *dapp) received(topic string, symKey []byte) *message {
+	require.NotEmpty(dapp.t, dapp.relay.published)
+	last := dapp.relay.published[len(dapp.relay.published)-1]
+	require.Equal(dapp.t, topic, last.topic)
+	payload, err := decrypt(symKey, last.message)
+	require.NoError(dapp.t, err)
+	var msg message
+	require.NoError(dapp.t, json.Unmarshal(payload, &msg))
+	return &msg
+}
+
+func (dapp *dapp) propose(chains []string) int64 {
+	return dapp.send(dapp.pairingTopic, dapp.pairingKey, methodSessionPropose, map[string]interface{}{
+		"relays": []interface{}{map[string]string{"protocol": "irn"}},
+		"proposer": map[string]interface{}{
+			"publicKey": hex.EncodeToString(dapp.keyPair.publicKey[:]),
+			"metadata":  Metadata{Name: "Dapp", URL: "https://dapp.example"},
+		},
+		"requiredNamespaces": map[string]namespace{
+			"eip155": {Chains: chains, Methods: []string{"personal_sign"}, Events: []string{"chainChanged"}},
+		},
+	})
+}
+
+func newTestDapp(t *testing.T, account *testAccount) *dapp {
+	relay := &testRelay{subscribed: map[string]bool{}}
+	manager := newManager(relay, Metadata{Name: "BitBox"}, func(code string) (Account, error) {
+		if code != "eth" {
+			return nil, errp.New("unknown account")
+		}
+		return account, nil
+	}, logging.Get().WithGroup("walletconnect_test"))
+	keyPair, err := newKeyPair()
+	require.NoError(t, err)
+	pairingKey := random.BytesOrPanic(keySize)
+	pairingTopic := hex.EncodeToString(random.BytesOrPanic(32))
+	require.NoError(t, manager.Pair("eth",
+		"wc:"+pairingTopic+"@2?relay-protocol=irn&symKey="+hex.EncodeToString(pairingKey)))
+	require.True(t, relay.subscribed[pairingTopic])
+	return &dapp{
+		t:            t,
+		manager:      manager,
+		relay:        relay,
+		keyPair:      keyPair,
+		pairingTopic: pairingTopic,
+		pairingKey:   pairingKey,
+	}
+}
+
+func TestManagerSession(t *testing.T) {
+	account := &testAccount{address: common.HexToAddress("0x0000000000000000000000000000000000000001")}
+	dapp := newTestDapp(t, account)
+	id := dapp.propose([]string{"eip155:1"})
+	require.Error(t, dapp.manager.Pair("other", "wc:invalid"))
+	require.Len(t, dapp.manager.Proposals(), 1)
+	require.NoError(t, dapp.manager.ApproveProposal(id))
+	require.Empty(t, dapp.manager.Proposals())
+	require.Len(t, dapp.relay.published, 2)
+
+	// The proposal is answered on the pairing topic.
+	response := dapp.relay.published[0]
+	require.Equal(t, dapp.pairingTopic, response.topic)
+	require.Equal(t, 1101, response.options.tag)
+	payload, err := decrypt(dapp.pairingKey, response.message)
+	require.NoError(t, err)
+	var approval struct {
+		ID     int64 `json:"id"`
+		Result struct {
+			ResponderPublicKey string `json:"responderPublicKey"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &approval))
+	require.Equal(t, id, approval.ID)
+	responderPublicKey, err := hex.DecodeString(approval.Result.ResponderPublicKey)
+	require.NoError(t, err)
+	dapp.sessionKey, err = dapp.keyPair.symKey(responderPublicKey)
+	require.NoError(t, err)
+	dapp.sessionTopic = topicOf(dapp.sessionKey)
+	require.True(t, dapp.relay.subscribed[dapp.sessionTopic])
+
+	// The session is settled on the session topic.
+	settle := dapp.received(dapp.sessionTopic, dapp.sessionKey)
+	require.Equal(t, methodSessionSettle, settle.Method)
+	var settleParams struct {
+		Namespaces map[string]namespace `json:"namespaces"`
+	}
+	require.NoError(t, json.Unmarshal(settle.Params, &settleParams))
+	require.Equal(t,
+		[]string{"eip155:1:" + account.address.Hex()},
+		settleParams.Namespaces["eip155"].Accounts)
+	sessions := dapp.manager.Sessions()
+	require.Len(t, sessions, 1)
+	require.Equal(t, dapp.sessionTopic, sessions[0].Topic)
+	require.Equal(t, "eth", sessions[0].AccountCode)
+
+	// Approved request.
+	account.signMessage = func(address string, message []byte) (string, error) {
+		require.Equal(t, account.address.Hex(), address)
+		require.Equal(t, []byte("hello"), message)
+		return "0xsignature", nil
+	}
+	requestID := dapp.send(dapp.sessionTopic, dapp.sessionKey, methodSessionRequest, map[string]interface{}{
+		"request": map[string]interface{}{
+			"method": "personal_sign",
+			"params": []string{"0x68656c6c6f", account.address.Hex()},
+		},
+		"chainId": "eip155:1",
+	})
+	requests := dapp.manager.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, "personal_sign", requests[0].Method)
+	require.NoError(t, dapp.manager.ApproveRequest(requestID))
+	require.Empty(t, dapp.manager.Requests())
+	result := dapp.received(dapp.sessionTopic, dapp.sessionKey)
+	require.Equal(t, requestID, result.ID)
+	require.JSONEq(t, `"0xsignature"`, string(result.Result))
+
+	// Aborted on the device.
+	account.signMessage = func(string, []byte) (string, error) {
+		return "", errp.WithStack(keystore.ErrSigningAborted)
+	}
+	requestID = dapp.send(dapp.sessionTopic, dapp.sessionKey, methodSessionRequest, map[string]interface{}{
+		"request": map[string]interface{}{
+			"method": "eth_sign",
+			"params": []string{account.address.Hex(), "0x68656c6c6f"},
+		},
+		"chainId": "eip155:1",
+	})
+	require.Equal(t, keystore.ErrSigningAborted, errp.Cause(dapp.manager.ApproveRequest(requestID)))
+	result = dapp.received(dapp.sessionTopic, dapp.sessionKey)
+	require.Equal(t, errorCodeUserRejected, result.Error.Code)
+
+	// Rejected request.
+	requestID = dapp.send(dapp.sessionTopic, dapp.sessionKey, methodSessionRequest, map[string]interface{}{
+		"request": map[string]interface{}{
+			"method": "eth_sendTransaction",
+			"params": []interface{}{map[string]string{"from": account.address.Hex(), "value": "0x10"}},
+		},
+		"chainId": "eip155:1",
+	})
+	require.NoError(t, dapp.manager.RejectRequest(requestID))
+	result = dapp.received(dapp.sessionTopic, dapp.sessionKey)
+	require.Equal(t, errorCodeUserRejected, result.Error.Code)
+	require.Nil(t, account.request)
+
+	// Requests for other chains and unsupported methods are rejected immediately.
+	requestID = dapp.send(dapp.sessionTopic, dapp.sessionKey, methodSessionRequest, map[string]interface{}{
+		"request": map[string]interface{}{"method": "personal_sign", "params": []string{}},
+		"chainId": "eip155:5",
+	})
+	result = dapp.received(dapp.sessionTopic, dapp.sessionKey)
+	require.Equal(t, requestID, result.ID)
+	require.Equal(t, errorCodeUnsupportedChains, result.Error.Code)
+	requestID = dapp.send(dapp.sessionTopic, dapp.sessionKey, methodSessionRequest, map[string]interface{}{
+		"request": map[string]interface{}{"method": "eth_accounts", "params": []string{}},
+		"chainId": "eip155:1",
+	})
+	result = dapp.received(dapp.sessionTopic, dapp.sessionKey)
+	require.Equal(t, errorCodeUnsupportedMethods, result.Error.Code)
+	require.Empty(t, dapp.manager.Requests())
+
+	// Ping.
+	pingID := dapp.send(dapp.sessionTopic, dapp.sessionKey, methodSessionPing, map[string]interface{}{})
+	result = dapp.received(dapp.sessionTopic, dapp.sessionKey)
+	require.Equal(t, pingID, result.ID)
+	require.JSONEq(t, "true", string(result.Result))
+
+	// Disconnect.
+	require.NoError(t, dapp.manager.Disconnect(dapp.sessionTopic))
+	deletion := dapp.received(dapp.sessionTopic, dapp.sessionKey)
+	require.Equal(t, methodSessionDelete, deletion.Method)
+	require.Empty(t, dapp.manager.Sessions())
+	require.False(t, dapp.relay.subscribed[dapp.sessionTopic])
+	require.Error(t, dapp.manager.Disconnect(dapp.sessionTopic))
+}
+
+func TestManagerSendTransaction(t *testing.T) {
+	account := &testAccount{address: common.HexToAddress("0x0000000000000000000000000000000000000001")}
+	result, err := executeRequest(account, "eth_sendTransaction", json.RawMessage(`[{
+		"from": "0x0000000000000000000000000000000000000001",
+		"to": "0x0000000000000000000000000000000000000002",
+		"value": "0x10",
+		"data": "0x1234",
+		"gas": "0x5208",
+		"gasPrice": "0x1"
+	}]`))
+	require.NoError(t, err)
+	require.Equal(t, common.HexToHash("0x01").Hex(), result)
+	require.Equal(t, account.address, account.request.From)
+	require.Equal(t, common.HexToAddress("0x0000000000000000000000000000000000000002"), *account.request.To)
+	require.Equal(t, big.NewInt(16), account.request.Value)
+	require.Equal(t, []byte{0x12, 0x34}, account.request.Data)
+	require.Equal(t, uint64(21000), account.request.Gas)
+
+	result, err = executeRequest(account, "eth_signTypedData_v4", json.RawMessage(`[
+		"0x0000000000000000000000000000000000000001",
+		"{\"types\": {\"EIP712Domain\": []}, \"primaryType\": \"EIP712Domain\", \"domain\": {}, \"message\": {}}"
+	]`))
+	require.NoError(t, err)
+	require.Equal(t, "0xtypeddata", result)
+	_, err = executeRequest(account, "eth_signTypedData_v4", json.RawMessage(`[
+		"0x0000000000000000000000000000000000000002",
+		{"types": {"EIP712Domain": []}, "primaryType": "EIP712Domain", "domain": {}, "message": {}}
+	]`))
+	require.Error(t, err)
+}
+
+func TestManagerRejectProposal(t *testing.T) {
+	account := &testAccount{address: common.HexToAddress("0x0000000000000000000000000000000000000001")}
+	dapp := newTestDapp(t, account)
+
+	// Unsupported chains are rejected when approving.
+	id := dapp.propose([]string{"eip155:5"})
+	require.Error(t, dapp.manager.ApproveProposal(id))
+	response := dapp.received(dapp.pairingTopic, dapp.pairingKey)
+	require.Equal(t, id, response.ID)
+	require.Equal(t, errorCodeUnsupportedChains, response.Error.Code)
+	require.Empty(t, dapp.manager.Sessions())
+
+	id = dapp.propose([]string{"eip155:1"})
+	require.NoError(t, dapp.manager.RejectProposal(id))
+	response = dapp.received(dapp.pairingTopic, dapp.pairingKey)
+	require.Equal(t, errorCodeUserRejected, response.Error.Code)
+	require.Empty(t, dapp.manager.Proposals())
+	require.Error(t, dapp.manager.ApproveProposal(id))
+
+	// The dapp deletes the pairing.
+	dapp.send(dapp.pairingTopic, dapp.pairingKey, methodPairingDelete, map[string]interface{}{})
+	require.False(t, dapp.relay.subscribed[dapp.pairingTopic])
+}
@@ -0,0 +1,320 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package walletconnect
+
+import (
+	"encoding/json"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/locker"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ed25519"
+)
+
+const (
+	// DefaultRelayURL is the relay server operated by WalletConnect.
+	DefaultRelayURL = "wss://relay.walletconnect.com"
+
+	relayResponseTimeout = 30 * time.Second
+	relayPingInterval    = 30 * time.Second
+	relayReconnectDelay  = 5 * time.Second
+)
+
+// relay publishes messages to topics and delivers the messages of the subscribed topics.
+type relay interface {
+	subscribe(topic string) error
+	unsubscribe(topic string) error
+	publish(topic string, message string, options publishOptions) error
+}
+
+// relayMessage is a JSON-RPC request or response exchanged with the relay server.
+type relayMessage struct {
+	ID      int64           `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// websocketRelay is a client of a relay server speaking the irn protocol over a websocket. It
+// connects on first use and reconnects as long as topics are subscribed.
+type websocketRelay struct {
+	url       string
+	projectID func() string
+	authKey   ed25519.PrivateKey
+	onMessage func(topic string, message string)
+
+	conn *websocket.Conn
+	// subscriptions maps the subscribed topics to the ids of their subscriptions.
+	subscriptions map[string]string
+	pending       map[int64]chan *relayMessage
+	reconnecting  bool
+	lock          locker.Locker
+	writeLock     sync.Mutex
+
+	log *logrus.Entry
+}
+
+// newWebsocketRelay creates a relay client. The project id is requested when connecting, as the
+// relay server rejects clients without one.
+func newWebsocketRelay(
+	relayURL string,
+	projectID func() string,
+	onMessage func(topic string, message string),
+	log *logrus.Entry,
+) (*websocketRelay, error) {
+	_, authKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	return &websocketRelay{
+		url:           relayURL,
+		projectID:     projectID,
+		authKey:       authKey,
+		onMessage:     onMessage,
+		subscriptions: map[string]string{},
+		pending:       map[int64]chan *relayMessage{},
+		log:           log.WithField("relay", relayURL),
+	}, nil
+}
+
+// connection returns the websocket connection, dialing the relay server if there is none.
+func (relay *websocketRelay) connection() (*websocket.Conn, error) {
+	defer relay.lock.Lock()()
+	if relay.conn != nil {
+		return relay.conn, nil
+	}
+	projectID := relay.projectID()
+	if projectID == "" {
+		return nil, errp.New("The WalletConnect project ID is not configured.")
+	}
+	token, err := relayAuthToken(relay.authKey, relay.url, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("auth", token)
+	query.Set("projectId", projectID)
+	conn, _, err := websocket.DefaultDialer.Dial(relay.url+"/?"+query.Encode(), nil)
+	if err != nil {
+		return nil, errp.WithMessage(err, "Could not connect to the WalletConnect relay")
+	}
+	relay.log.Info("Connected")
+	relay.conn = conn
+	go relay.readLoop(conn)
+	go relay.pingLoop(conn)
+	return conn, nil
+}
+
+// write sends a message to the relay server.
+func (relay *websocketRelay) write(conn *websocket.Conn, message *relayMessage) error {
+	relay.writeLock.Lock()
+	defer relay.writeLock.Unlock()
+	return errp.WithStack(conn.WriteJSON(message))
+}
+
+// call sends a request to the relay server and returns the result of its response.
+func (relay *websocketRelay) call(method string, params interface{}) (json.RawMessage, error) {
+	conn, err := relay.connection()
+	if err != nil {
+		return nil, err
+	}
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, errp.WithStack(err)
+	}
+	request := &relayMessage{ID: payloadID(), JSONRPC: "2.0", Method: method, Params: encodedParams}
+	responses := make(chan *relayMessage, 1)
+	unlock := relay.lock.Lock()
+	relay.pending[request.ID] = responses
+	unlock()
+	defer func() {
+		defer relay.lock.Lock()()
+		delete(relay.pending, request.ID)
+	}()
+	if err := relay.write(conn, request); err != nil {
+		return nil, err
+	}
+	select {
+	case response := <-responses:
+		if response == nil {
+			return nil, errp.New("The connection to the WalletConnect relay was lost.")
+		}
+		if response.Error != nil {
+			return nil, errp.Newf("WalletConnect relay error: %s", response.Error.Message)
+		}
+		return response.Result, nil
+	case <-time.After(relayResponseTimeout):
+		return nil, errp.New("The WalletConnect relay did not respond.")
+	}
+}
+
+func (relay *websocketRelay) subscribe(topic string) error {
+	result, err := relay.call("irn_subscribe", map[string]string{"topic": topic})
+	if err != nil {
+		return err
+	}
+	var subscriptionID string
+	if err := json.Unmarshal(result, &subscriptionID); err != nil {
+		return errp.WithStack(err)
+	}
+	defer relay.lock.Lock()()
+	relay.subscriptions[topic] = subscriptionID
+	return nil
+}
+
+func (relay *websocketRelay) unsubscribe(topic string) error {
+	unlock := relay.lock.Lock()
+	subscriptionID, ok := relay.subscriptions[topic]
+	delete(relay.subscriptions, topic)
+	unlock()
+	if !ok {
+		return nil
+	}
+	_, err := relay.call("irn_unsubscribe", map[string]string{"topic": topic, "id": subscriptionID})
+	return err
+}
+
+func (relay *websocketRelay) publish(topic string, message string, options publishOptions) error {
+	_, err := relay.call("irn_publish", map[string]interface{}{
+		"topic":   topic,
+		"message": message,
+		"ttl":     int64(options.ttl / time.Second),
+		"tag":     options.tag,
+		"prompt":  false,
+	})
+	return err
+}
+
+// readLoop handles the messages of the relay server until the connection is lost.
+func (relay *websocketRelay) readLoop(conn *websocket.Conn) {
+	for {
+		var received relayMessage
+		if err := conn.ReadJSON(&received); err != nil {
+			relay.log.WithError(err).Info("Disconnected")
+			relay.disconnected(conn)
+			return
+		}
+		if received.Method == "" {
+			unlock := relay.lock.RLock()
+			responses, ok := relay.pending[received.ID]
+			unlock()
+			if ok {
+				deliver(responses, &received)
+			}
+			continue
+		}
+		if received.Method != "irn_subscription" {
+			continue
+		}
+		var params struct {
+			Data struct {
+				Topic   string `json:"topic"`
+				Message string `json:"message"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(received.Params, &params); err != nil {
+			relay.log.WithError(err).Error("Invalid subscription message")
+			continue
+		}
+		ack := &relayMessage{ID: received.ID, JSONRPC: "2.0", Result: json.RawMessage("true")}
+		if err := relay.write(conn, ack); err != nil {
+			relay.log.WithError(err).Error("Could not acknowledge the message")
+		}
+		// Handle the message asynchronously, as handling it can involve calls to the relay.
+		go relay.onMessage(params.Data.Topic, params.Data.Message)
+	}
+}
+
+// pingLoop keeps the connection alive.
+func (relay *websocketRelay) pingLoop(conn *websocket.Conn) {
+	for {
+		time.Sleep(relayPingInterval)
+		unlock := relay.lock.RLock()
+		current := relay.conn == conn
+		unlock()
+		if !current {
+			return
+		}
+		relay.writeLock.Lock()
+		err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(relayResponseTimeout))
+		relay.writeLock.Unlock()
+		if err != nil {
+			relay.log.WithError(err).Info("Ping failed")
+			return
+		}
+	}
+}
+
+// deliver passes the response to the waiting call. Calls wait for one response only.
+func deliver(responses chan *relayMessage, response *relayMessage) {
+	select {
+	case responses <- response:
+	default:
+	}
+}
+
+// disconnected fails the pending calls and reconnects if topics are subscribed.
+func (relay *websocketRelay) disconnected(conn *websocket.Conn) {
+	_ = conn.Close()
+	defer relay.lock.Lock()()
+	if relay.conn == conn {
+		relay.conn = nil
+	}
+	for id, responses := range relay.pending {
+		deliver(responses, nil)
+		delete(relay.pending, id)
+	}
+	if len(relay.subscriptions) > 0 && !relay.reconnecting {
+		relay.reconnecting = true
+		go relay.reconnect()
+	}
+}
+
+// reconnect resubscribes to the subscribed topics until it succeeds or no topics are left.
+func (relay *websocketRelay) reconnect() {
+	defer func() {
+		defer relay.lock.Lock()()
+		relay.reconnecting = false
+	}()
+	for {
+		time.Sleep(relayReconnectDelay)
+		unlock := relay.lock.RLock()
+		topics := []string{}
+		for topic := range relay.subscriptions {
+			topics = append(topics, topic)
+		}
+		unlock()
+		if len(topics) == 0 {
+			return
+		}
+		resubscribed := true
+		for _, topic := range topics {
+			if err := relay.subscribe(topic); err != nil {
+				relay.log.WithError(err).Error("Could not resubscribe")
+				resubscribed = false
+				break
+			}
+		}
+		if resubscribed {
+			return
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package walletconnect
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/eth"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// transactionParams is a transaction as passed to eth_sendTransaction. The fees are chosen by the
+// wallet, so the fee parameters of the dapp are ignored.
+type transactionParams struct {
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+	Data  hexutil.Bytes   `json:"data"`
+	// Input is the newer name of data.
+	Input hexutil.Bytes   `json:"input"`
+	Gas   *hexutil.Uint64 `json:"gas"`
+}
+
+// transactionRequest converts the parameters into a transaction request.
+func (params *transactionParams) transactionRequest() *eth.TransactionRequest {
+	request := &eth.TransactionRequest{
+		From:  params.From,
+		To:    params.To,
+		Value: big.NewInt(0),
+		Data:  params.Data,
+	}
+	if params.Value != nil {
+		request.Value = params.Value.ToInt()
+	}
+	if len(params.Input) != 0 {
+		request.Data = params.Input
+	}
+	if params.Gas != nil {
+		request.Gas = uint64(*params.Gas)
+	}
+	return request
+}
+
+// decodeMessage decodes the message of personal_sign and eth_sign, which is usually hex encoded.
+// Messages which are not hex encoded are signed as text.
+func decodeMessage(message string) []byte {
+	if decoded, err := hexutil.Decode(message); err == nil {
+		return decoded
+	}
+	return []byte(message)
+}
+
+// decodeTypedData decodes the typed data of eth_signTypedData, which is passed as JSON string or
+// as object.
+func decodeTypedData(raw json.RawMessage) (*eth.TypedData, error) {
+	var jsonTypedData string
+	if err := json.Unmarshal(raw, &jsonTypedData); err == nil {
+		raw = json.RawMessage(jsonTypedData)
+	}
+	return eth.ParseTypedData(raw)
+}
+
+// executeRequest executes the request with the given method and parameters with the account and
+// returns the result for the dapp.
+func executeRequest(account Account, method string, rawParams json.RawMessage) (interface{}, error) {
+	switch method {
+	case "eth_sendTransaction":
+		var params []transactionParams
+		if err := json.Unmarshal(rawParams, &params); err != nil || len(params) != 1 {
+			return nil, errp.New("Invalid transaction.")
+		}
+		hash, err := account.SendTransactionRequest(params[0].transactionRequest())
+		if err != nil {
+			return nil, err
+		}
+		return hash.Hex(), nil
+	case "personal_sign", "eth_sign":
+		var params []string
+		if err := json.Unmarshal(rawParams, &params); err != nil || len(params) < 2 {
+			return nil, errp.New("Invalid message.")
+		}
+		message, address := params[0], params[1]
+		if method == "eth_sign" {
+			address, message = params[0], params[1]
+		}
+		return account.SignMessage(address, decodeMessage(message))
+	case "eth_signTypedData", "eth_signTypedData_v4":
+		var params []json.RawMessage
+		if err := json.Unmarshal(rawParams, &params); err != nil || len(params) != 2 {
+			return nil, errp.New("Invalid typed data.")
+		}
+		var address common.Address
+		if err := json.Unmarshal(params[0], &address); err != nil || address != account.Address() {
+			return nil, errp.New("The typed data is not signed with the address of the account.")
+		}
+		typedData, err := decodeTypedData(params[1])
+		if err != nil {
+			return nil, err
+		}
+		return account.SignTypedData(typedData)
+	default:
+		return nil, errp.Newf("Unsupported method %s.", method)
+	}
+}
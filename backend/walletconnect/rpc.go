@@ -0,0 +1,102 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package walletconnect
+
+import (
+	"encoding/json"
+	"math/rand"
+	"time"
+)
+
+// Methods of the sign protocol.
+const (
+	methodSessionPropose = "wc_sessionPropose"
+	methodSessionSettle  = "wc_sessionSettle"
+	methodSessionRequest = "wc_sessionRequest"
+	methodSessionEvent   = "wc_sessionEvent"
+	methodSessionDelete  = "wc_sessionDelete"
+	methodSessionPing    = "wc_sessionPing"
+	methodPairingDelete  = "wc_pairingDelete"
+	methodPairingPing    = "wc_pairingPing"
+)
+
+// publishOptions are the relay parameters of a message, which depend on the method.
+type publishOptions struct {
+	ttl time.Duration
+	tag int
+}
+
+// requestOptions and responseOptions are the relay parameters of requests and responses per method.
+var (
+	requestOptions = map[string]publishOptions{
+		methodSessionPropose: {5 * time.Minute, 1100},
+		methodSessionSettle:  {5 * time.Minute, 1102},
+		methodSessionRequest: {5 * time.Minute, 1108},
+		methodSessionEvent:   {5 * time.Minute, 1110},
+		methodSessionDelete:  {24 * time.Hour, 1112},
+		methodSessionPing:    {30 * time.Second, 1114},
+		methodPairingDelete:  {24 * time.Hour, 1000},
+		methodPairingPing:    {30 * time.Second, 1002},
+	}
+	responseOptions = map[string]publishOptions{
+		methodSessionPropose: {5 * time.Minute, 1101},
+		methodSessionSettle:  {5 * time.Minute, 1103},
+		methodSessionRequest: {5 * time.Minute, 1109},
+		methodSessionEvent:   {5 * time.Minute, 1111},
+		methodSessionDelete:  {24 * time.Hour, 1113},
+		methodSessionPing:    {30 * time.Second, 1115},
+		methodPairingDelete:  {24 * time.Hour, 1001},
+		methodPairingPing:    {30 * time.Second, 1003},
+	}
+)
+
+// Error codes of the sign protocol.
+const (
+	errorCodeUserRejected        = 5000
+	errorCodeUnsupportedChains   = 5100
+	errorCodeUnsupportedMethods  = 5101
+	errorCodeUnsupportedEvents   = 5102
+	errorCodeUnsupportedProposal = 5103
+	errorCodeUserDisconnected    = 6000
+	// errorCodeInternal is the JSON-RPC code of server errors, used if signing fails.
+	errorCodeInternal = -32000
+)
+
+// message is a JSON-RPC request or response exchanged with the peer.
+type message struct {
+	ID      int64           `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// isRequest returns whether the message is a request rather than a response.
+func (message *message) isRequest() bool {
+	return message.Method != ""
+}
+
+// rpcError is the error of a JSON-RPC response.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// payloadID returns a new message id: the current time in milliseconds followed by three random
+// digits.
+func payloadID() int64 {
+	return time.Now().UnixNano()/int64(time.Millisecond)*1000 + rand.Int63n(1000)
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package walletconnect implements the wallet side of the WalletConnect v2 sign protocol, which
+// lets dapps request transactions and signatures from an Ethereum account.
+package walletconnect
+
+import (
+	"encoding/hex"
+	"net/url"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// pairingURI is a parsed pairing URI, e.g. "wc:<topic>@2?relay-protocol=irn&symKey=<key>", as
+// shown by dapps as text or QR code.
+type pairingURI struct {
+	topic  string
+	symKey []byte
+}
+
+// parsePairingURI parses a WalletConnect v2 pairing URI.
+func parsePairingURI(uri string) (*pairingURI, error) {
+	parsed, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil || parsed.Scheme != "wc" {
+		return nil, errp.New("Invalid WalletConnect URI.")
+	}
+	path := parsed.Opaque
+	if path == "" {
+		// "wc://<topic>@2?..." is parsed as a host.
+		path = parsed.User.String() + "@" + parsed.Host
+	}
+	separator := strings.LastIndex(path, "@")
+	if separator < 0 {
+		return nil, errp.New("Invalid WalletConnect URI.")
+	}
+	topic, version := path[:separator], path[separator+1:]
+	if version != "2" {
+		return nil, errp.Newf("WalletConnect version %s is not supported.", version)
+	}
+	query := parsed.Query()
+	if protocol := query.Get("relay-protocol"); protocol != "irn" {
+		return nil, errp.Newf("The relay protocol %q is not supported.", protocol)
+	}
+	if topicBytes, err := hex.DecodeString(topic); err != nil || len(topicBytes) != 32 {
+		return nil, errp.New("Invalid topic in the WalletConnect URI.")
+	}
+	symKey, err := hex.DecodeString(query.Get("symKey"))
+	if err != nil || len(symKey) != 32 {
+		return nil, errp.New("Invalid key in the WalletConnect URI.")
+	}
+	return &pairingURI{topic: topic, symKey: symKey}, nil
+}
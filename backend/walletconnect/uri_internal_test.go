@@ -0,0 +1,49 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package walletconnect
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePairingURI(t *testing.T) {
+	const (
+		topic  = "7f6e504bfad60b485450578e05678ed3e8e8c4751d3c6160be17160d63ec90f9"
+		symKey = "587d5484ce2a2a6ee3ba1962fdd7e8588e06200c46823bd18fbd67def96ad303"
+	)
+	uri, err := parsePairingURI("wc:" + topic + "@2?relay-protocol=irn&symKey=" + symKey)
+	require.NoError(t, err)
+	require.Equal(t, topic, uri.topic)
+	require.Equal(t, symKey, hex.EncodeToString(uri.symKey))
+
+	uri, err = parsePairingURI("wc://" + topic + "@2?expiryTimestamp=1&relay-protocol=irn&symKey=" + symKey)
+	require.NoError(t, err)
+	require.Equal(t, topic, uri.topic)
+
+	for _, invalid := range []string{
+		"",
+		"https://example.com",
+		"wc:" + topic + "@1?bridge=https%3A%2F%2Fbridge.walletconnect.org&key=" + symKey,
+		"wc:" + topic + "@2?relay-protocol=waku&symKey=" + symKey,
+		"wc:1234@2?relay-protocol=irn&symKey=" + symKey,
+		"wc:" + topic + "@2?relay-protocol=irn&symKey=1234",
+	} {
+		_, err := parsePairingURI(invalid)
+		require.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/config"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/keystore"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// extendedPublicKeyVersion describes the version bytes of a serialized extended public key.
+type extendedPublicKeyVersion struct {
+	scriptType signing.ScriptType
+	testnet    bool
+}
+
+// extendedPublicKeyVersions are the versions of extended public keys as defined in SLIP-0132.
+var extendedPublicKeyVersions = map[[4]byte]extendedPublicKeyVersion{
+	{0x04, 0x88, 0xb2, 0x1e}: {signing.ScriptTypeP2PKH, false},      // xpub
+	{0x04, 0x9d, 0x7c, 0xb2}: {signing.ScriptTypeP2WPKHP2SH, false}, // ypub
+	{0x04, 0xb2, 0x47, 0x46}: {signing.ScriptTypeP2WPKH, false},     // zpub
+	{0x04, 0x35, 0x87, 0xcf}: {signing.ScriptTypeP2PKH, true},       // tpub
+	{0x04, 0x4a, 0x52, 0x62}: {signing.ScriptTypeP2WPKHP2SH, true},  // upub
+	{0x04, 0x5f, 0x1c, 0xf6}: {signing.ScriptTypeP2WPKH, true},      // vpub
+}
+
+// watchOnlyAccountCode returns the code of a watch-only account. It is derived from the signing
+// configuration, so that it stays the same when other accounts are added.
+func watchOnlyAccountCode(coinCode string, configuration *signing.Configuration) string {
+	return fmt.Sprintf("%s-watchonly-%s", coinCode, configuration.Hash()[:8])
+}
+
+// WatchOnlyAccountCoins returns the coins for which watch-only accounts can be added.
+func (backend *Backend) WatchOnlyAccountCoins() []string {
+	// The same script types as for multisig accounts are needed.
+	return backend.MultisigAccountCoins()
+}
+
+// parseExtendedPublicKey parses an extended public key in any of the versions of SLIP-0132.
+func parseExtendedPublicKey(
+	key string) (*hdkeychain.ExtendedKey, *extendedPublicKeyVersion, error) {
+	extendedPublicKey, err := hdkeychain.NewKeyFromString(key)
+	if err != nil {
+		return nil, nil, errp.WithMessage(errp.WithStack(err), "Invalid extended public key")
+	}
+	if extendedPublicKey.IsPrivate() {
+		return nil, nil, errp.New("The key is private. Only extended public keys are accepted.")
+	}
+	var versionBytes [4]byte
+	// The length has been checked when parsing the key.
+	copy(versionBytes[:], base58.Decode(key)[:4])
+	version, ok := extendedPublicKeyVersions[versionBytes]
+	if !ok {
+		return nil, nil, errp.Newf("Unknown extended public key version %x.", versionBytes)
+	}
+	return extendedPublicKey, &version, nil
+}
+
+// watchOnlyDescriptor returns the output descriptor of a pasted extended public key or output
+// descriptor. The script type of an extended public key is given by its version, xpub and tpub are
+// taken to be legacy.
+func watchOnlyDescriptor(btcCoin *btc.Coin, input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if strings.Contains(input, "(") {
+		if _, err := btc.ParseDescriptor(input, btcCoin.Net()); err != nil {
+			return "", errp.WithMessage(err, "Invalid descriptor")
+		}
+		return input, nil
+	}
+	extendedPublicKey, version, err := parseExtendedPublicKey(input)
+	if err != nil {
+		return "", err
+	}
+	testnet := btcCoin.Net().HDPublicKeyID != chaincfg.MainNetParams.HDPublicKeyID
+	if version.testnet != testnet {
+		return "", errp.Newf("The extended public key is not for %s.", btcCoin.Code())
+	}
+	extendedPublicKey.SetNet(btcCoin.Net())
+	return btc.Descriptor(
+		signing.NewSinglesigConfiguration(
+			version.scriptType, signing.NewEmptyAbsoluteKeypath(), extendedPublicKey),
+		btcCoin.Net(), nil, "/<0;1>/*")
+}
+
+// watchOnlyConfiguration returns the signing configuration of the watch-only account, or an error
+// if the account is not supported.
+func (backend *Backend) watchOnlyConfiguration(
+	watchOnlyAccount *config.WatchOnlyAccount) (*signing.Configuration, error) {
+	supported := false
+	for _, coinCode := range backend.WatchOnlyAccountCoins() {
+		supported = supported || coinCode == watchOnlyAccount.Coin
+	}
+	if !supported {
+		return nil, errp.Newf("Watch-only accounts are not supported for %s.", watchOnlyAccount.Coin)
+	}
+	btcCoin, ok := backend.Coin(watchOnlyAccount.Coin).(*btc.Coin)
+	if !ok {
+		return nil, errp.Newf("Watch-only accounts are not supported for %s.", watchOnlyAccount.Coin)
+	}
+	configuration, err := btc.ParseDescriptor(watchOnlyAccount.Descriptor, btcCoin.Net())
+	if err != nil {
+		return nil, err
+	}
+	if configuration.Multisig() {
+		if configuration.NumberOfSigners() > maxMultisigSigners {
+			return nil, errp.Newf("A multisig account can have at most %d cosigners.", maxMultisigSigners)
+		}
+		return configuration, nil
+	}
+	for _, scriptType := range backend.CustomAccountCoins()[watchOnlyAccount.Coin] {
+		if scriptType == configuration.ScriptType() {
+			return configuration, nil
+		}
+	}
+	return nil, errp.Newf("Watch-only %s accounts are not supported for %s.",
+		configuration.ScriptType(), watchOnlyAccount.Coin)
+}
+
+// addWatchOnlyAccounts adds the watch-only accounts of the config. They have no keystores, so they
+// are added even if no keystore is registered.
+func (backend *Backend) addWatchOnlyAccounts() {
+	for _, watchOnlyAccount := range backend.config.Config().Backend.WatchOnlyAccounts {
+		watchOnlyAccount := watchOnlyAccount
+		configuration, err := backend.watchOnlyConfiguration(&watchOnlyAccount)
+		if err != nil {
+			backend.log.WithField("name", watchOnlyAccount.Name).WithError(err).Info("skipping watch-only account")
+			continue
+		}
+		code := watchOnlyAccountCode(watchOnlyAccount.Coin, configuration)
+		btcCoin := backend.Coin(watchOnlyAccount.Coin).(*btc.Coin)
+		backend.log.WithField("code", code).WithField("name", watchOnlyAccount.Name).Info("init watch-only account")
+		gapLimits := backend.config.Config().Backend.GapLimit(code)
+		account := btc.NewAccount(btcCoin, backend.arguments.CacheDirectoryPath(), code,
+			watchOnlyAccount.Name,
+			func() (*signing.Configuration, error) { return configuration, nil },
+			keystore.NewKeystores(), btcutil.Amount(backend.config.Config().Backend.DustLimit(code)),
+			btc.GapLimits{Receive: gapLimits.Receive, Change: gapLimits.Change},
+			backend.txOrdering(),
+			func(event btc.Event) {
+				backend.events <- AccountEvent{Type: "account", Code: code, Data: string(event)}
+			}, backend.log)
+		backend.accounts = append(backend.accounts, account)
+	}
+}
+
+// AddWatchOnlyAccount adds an account of the given coin without a keystore from an extended
+// public key (xpub, ypub, zpub and their testnet versions) or a singlesig or sorted multisig output
+// descriptor. The balance and the transactions are synced, but the account cannot sign.
+func (backend *Backend) AddWatchOnlyAccount(coinCode string, name string, input string) error {
+	supported := false
+	for _, supportedCoinCode := range backend.WatchOnlyAccountCoins() {
+		supported = supported || supportedCoinCode == coinCode
+	}
+	if !supported {
+		return errp.Newf("Watch-only accounts are not supported for %s.", coinCode)
+	}
+	btcCoin, ok := backend.Coin(coinCode).(*btc.Coin)
+	if !ok {
+		return errp.Newf("Watch-only accounts are not supported for %s.", coinCode)
+	}
+	descriptor, err := watchOnlyDescriptor(btcCoin, input)
+	if err != nil {
+		return err
+	}
+	watchOnlyAccount := config.WatchOnlyAccount{
+		Coin:       coinCode,
+		Name:       strings.TrimSpace(name),
+		Descriptor: descriptor,
+	}
+	configuration, err := backend.watchOnlyConfiguration(&watchOnlyAccount)
+	if err != nil {
+		return err
+	}
+	if watchOnlyAccount.Name == "" {
+		watchOnlyAccount.Name = fmt.Sprintf("%s Watch-only", strings.ToUpper(coinCode))
+	}
+	appConfig := backend.config.Config()
+	code := watchOnlyAccountCode(coinCode, configuration)
+	for _, existing := range appConfig.Backend.WatchOnlyAccounts {
+		existing := existing
+		existingConfiguration, err := backend.watchOnlyConfiguration(&existing)
+		if err == nil && watchOnlyAccountCode(existing.Coin, existingConfiguration) == code {
+			return errp.New("The account already exists.")
+		}
+	}
+	watchOnlyAccounts := append([]config.WatchOnlyAccount{}, appConfig.Backend.WatchOnlyAccounts...)
+	appConfig.Backend.WatchOnlyAccounts = append(watchOnlyAccounts, watchOnlyAccount)
+	if err := backend.config.Set(appConfig); err != nil {
+		return err
+	}
+	backend.initAccounts()
+	backend.events <- backendEvent{Type: "backend", Data: "accountsStatusChanged"}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/coins/btc"
+	"github.com/digitalbitbox/bitbox-wallet-app/backend/signing"
+	"github.com/stretchr/testify/require"
+)
+
+// withVersion returns the extended key serialized with the given version bytes.
+func withVersion(key string, version []byte) string {
+	decoded := base58.Decode(key)
+	payload := append(append([]byte{}, version...), decoded[4:len(decoded)-4]...)
+	return base58.Encode(append(payload, chainhash.DoubleHashB(payload)[:4]...))
+}
+
+func TestWatchOnlyDescriptor(t *testing.T) {
+	tbtc := btc.NewCoin("tbtc", "TBTC", &chaincfg.TestNet3Params, "", nil, "")
+	btcCoin := btc.NewCoin("btc", "BTC", &chaincfg.MainNetParams, "", nil, "")
+
+	// The script type is given by the version of the key, which is normalized to the coin.
+	vpub := withVersion(testCosigner, []byte{0x04, 0x5f, 0x1c, 0xf6})
+	descriptor, err := watchOnlyDescriptor(tbtc, " "+vpub+" ")
+	require.NoError(t, err)
+	configuration, err := btc.ParseDescriptor(descriptor, tbtc.Net())
+	require.NoError(t, err)
+	require.Equal(t, signing.ScriptTypeP2WPKH, configuration.ScriptType())
+	require.Equal(t, testCosigner, configuration.ExtendedPublicKeys()[0].String())
+
+	descriptor, err = watchOnlyDescriptor(tbtc, testCosigner)
+	require.NoError(t, err)
+	require.Regexp(t, `^pkh\(tpub`, descriptor)
+
+	// Descriptors are kept as they are.
+	input := "tr([d34db33f/86h/1h/0h]" + testCosigner + "/0/*)"
+	descriptor, err = watchOnlyDescriptor(tbtc, input)
+	require.NoError(t, err)
+	require.Equal(t, input, descriptor)
+	_, err = watchOnlyDescriptor(tbtc, "wsh(multi(1,"+testCosigner+"))")
+	require.Error(t, err)
+
+	// Testnet keys are rejected for mainnet coins and vice versa.
+	_, err = watchOnlyDescriptor(btcCoin, vpub)
+	require.Error(t, err)
+	_, err = watchOnlyDescriptor(btcCoin, input)
+	require.Error(t, err)
+	zpub := withVersion(testCosigner, []byte{0x04, 0xb2, 0x47, 0x46})
+	_, err = watchOnlyDescriptor(tbtc, zpub)
+	require.Error(t, err)
+	descriptor, err = watchOnlyDescriptor(btcCoin, zpub)
+	require.NoError(t, err)
+	require.Regexp(t, `^wpkh\(xpub`, descriptor)
+	// Unknown versions are rejected.
+	_, err = watchOnlyDescriptor(tbtc, withVersion(testCosigner, []byte{1, 2, 3, 4}))
+	require.Error(t, err)
+}
+
+func TestWatchOnlyAccountCode(t *testing.T) {
+	receive, err := btc.ParseDescriptor("wpkh("+testCosigner+"/0/*)", &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	code := watchOnlyAccountCode("tbtc", receive)
+	require.Regexp(t, "^tbtc-watchonly-[0-9a-f]{8}$", code)
+	// The same account described differently has the same code.
+	multipath, err := btc.ParseDescriptor("wpkh("+testCosigner+"/<0;1>/*)", &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.Equal(t, code, watchOnlyAccountCode("tbtc", multipath))
+	legacy, err := btc.ParseDescriptor("pkh("+testCosigner+"/0/*)", &chaincfg.TestNet3Params)
+	require.NoError(t, err)
+	require.NotEqual(t, code, watchOnlyAccountCode("tbtc", legacy))
+}
@@ -34,6 +34,8 @@ const (
 func main() {
 	mainnet := flag.Bool("mainnet", false, "switch to mainnet instead of testnet coins")
 	regtest := flag.Bool("regtest", false, "use regtest instead of testnet coins")
+	regtestServer := flag.String("regtestserver", arguments.DefaultRegtestElectrumServer,
+		"address of the Electrum server (TCP, no TLS) used with -regtest")
 	multisig := flag.Bool("multisig", false, "use the app in multisig mode")
 	devmode := flag.Bool("devmode", true, "switch to dev mode")
 	flag.Parse()
@@ -52,7 +54,7 @@ func main() {
 	// since we are in dev-mode, we can drop the authorization token
 	connectionData := backendHandlers.NewConnectionData(-1, "")
 	backend := backend.NewBackend(
-		arguments.NewArguments(".", !*mainnet, *regtest, *multisig, *devmode))
+		arguments.NewArguments(".", !*mainnet, *regtest, *regtestServer, *multisig, *devmode))
 	handlers := backendHandlers.NewHandlers(backend, connectionData)
 	log.WithFields(logrus.Fields{"address": address, "port": port}).Info("Listening for HTTP")
 	fmt.Printf("Listening on: http://localhost:%d\n", port)
@@ -32,7 +32,7 @@ func Serve() {
 		log.WithError(err).Fatal("Failed to generate random string")
 	}
 	connectionData := backendHandlers.NewConnectionData(8082, token)
-	backend := backend.NewBackend(arguments.NewArguments(".", false, false, false, false))
+	backend := backend.NewBackend(arguments.NewArguments(".", false, false, "", false, false))
 	handlers := backendHandlers.NewHandlers(backend, connectionData)
 	err = http.ListenAndServe("localhost:8082", handlers.Router)
 	if err != nil {
@@ -122,7 +122,7 @@ func serve(pushNotificationsCallback C.pushNotificationsCallback, theResponseCal
 		log.WithError(err).Fatal("Failed to generate random string")
 	}
 	theBackend := backend.NewBackend(arguments.NewArguments(
-		config.AppDir(), *testnet, false, false, false))
+		config.AppDir(), *testnet, false, "", false, false))
 	events := theBackend.Events()
 	go func() {
 		for {
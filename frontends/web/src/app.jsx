@@ -30,11 +30,25 @@ import Account from './routes/account/account';
 import Send from './routes/account/send/send';
 import Receive from './routes/account/receive/receive';
 import Info from './routes/account/info/info';
+import Sweep from './routes/account/sweep/sweep';
+import Message from './routes/account/message/message';
+import TypedData from './routes/account/typeddata/typeddata';
+import WalletConnect from './routes/account/walletconnect/walletconnect';
+import NFTs from './routes/account/nfts/nfts';
+import Approvals from './routes/account/approvals/approvals';
 import Settings from './routes/settings/settings';
 import ElectrumSettings from './routes/settings/electrum';
+import CustomAccount from './routes/settings/customaccount';
+import ERC20Token from './routes/settings/erc20token';
+import MultisigAccount from './routes/settings/multisigaccount';
+import WatchOnlyAccount from './routes/settings/watchonlyaccount';
+import SoftwareKeystore from './routes/settings/softwarekeystore';
+import HWI from './routes/settings/hwi';
+import Airgapped from './routes/settings/airgapped';
 import ManageBackups from './routes/device/manage-backups/manage-backups';
 import { Alert } from './components/alert/Alert';
 import { Confirm } from './components/confirm/Confirm';
+import { AirgappedSigning } from './components/airgapped/airgapped';
 
 export class App extends Component {
     state = {
@@ -143,16 +157,43 @@ export class App extends Component {
                             accounts={accounts} />
                         <Receive
                             path="/account/:code/receive"
-                            deviceIDs={deviceIDs} />
+                            deviceIDs={deviceIDs}
+                            accounts={accounts} />
                         <Info
                             path="/account/:code/info"
                             accounts={accounts} />
+                        <Sweep
+                            path="/account/:code/sweep" />
+                        <Message
+                            path="/account/:code/message" />
+                        <TypedData
+                            path="/account/:code/typed-data" />
+                        <WalletConnect
+                            path="/account/:code/walletconnect" />
+                        <NFTs
+                            path="/account/:code/nfts" />
+                        <Approvals
+                            path="/account/:code/approvals" />
                         <Account
                             path="/account/:code?"
                             deviceIDs={deviceIDs}
                             accounts={accounts} />
                         <ElectrumSettings
                             path="/settings/electrum" />
+                        <CustomAccount
+                            path="/settings/custom-account" />
+                        <ERC20Token
+                            path="/settings/erc20-token" />
+                        <MultisigAccount
+                            path="/settings/multisig-account" />
+                        <WatchOnlyAccount
+                            path="/settings/watch-only-account" />
+                        <SoftwareKeystore
+                            path="/settings/software-keystore" />
+                        <HWI
+                            path="/settings/hwi" />
+                        <Airgapped
+                            path="/settings/airgapped" />
                         <Settings
                             path="/settings" />
                         {/* Use with TypeScript: {Route<{ deviceID: string }>({ path: '/manage-backups/:deviceID', component: ManageBackups })} */}
@@ -175,6 +216,7 @@ export class App extends Component {
                 </div>
                 <Alert />
                 <Confirm />
+                <AirgappedSigning />
             </div>
         );
     }
@@ -0,0 +1,126 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Component, h } from 'preact';
+import { translate } from 'react-i18next';
+import { Dialog } from '../dialog/dialog';
+import { Button, Input } from '../forms';
+import { apiGet, apiPost } from '../../utils/request';
+import { apiSubscribe } from '../../utils/event';
+
+// The frames of the animated QR code are shown for this many milliseconds each.
+const frameDuration = 400;
+
+/**
+ * Shows the transaction waiting to be signed by an air-gapped signer as animated QR code and
+ * receives the signed transaction. Keyboard-mode QR code scanners type one part per line, but the
+ * parts can also be pasted.
+ */
+@translate()
+export class AirgappedSigning extends Component {
+    state = {
+        request: null,
+        images: [],
+        frame: 0,
+        part: '',
+        error: null,
+    }
+
+    componentDidMount() {
+        apiGet('airgapped/request').then(this.setRequest);
+        this.unsubscribe = apiSubscribe('airgapped/request', ({ object }) => this.setRequest(object));
+        this.interval = setInterval(this.nextFrame, frameDuration);
+    }
+
+    componentWillUnmount() {
+        this.unsubscribe();
+        clearInterval(this.interval);
+    }
+
+    setRequest = request => {
+        const previous = this.state.request;
+        if (!request) {
+            this.setState({ request: null, images: [], frame: 0, part: '', error: null });
+            return;
+        }
+        this.setState({ request });
+        if (previous && previous.parts.join(' ') === request.parts.join(' ')) {
+            return;
+        }
+        // The images are loaded once, so that the animation does not flicker.
+        Promise.all(request.parts.map(part => apiGet('qr?data=' + encodeURIComponent(part))))
+            .then(images => this.setState({ images, frame: 0 }));
+    }
+
+    nextFrame = () => {
+        if (this.state.images.length > 1) {
+            this.setState(({ frame, images }) => ({ frame: (frame + 1) % images.length }));
+        }
+    }
+
+    handleInput = event => {
+        this.setState({ part: event.target.value });
+    }
+
+    handleKeyDown = event => {
+        // Other dialogs, e.g. the one waiting for the signature, must not swallow the input.
+        event.stopPropagation();
+        if (event.keyCode === 13) {
+            this.receive();
+        }
+    }
+
+    receive = () => {
+        const parts = this.state.part.trim().split(/\s+/).filter(part => part);
+        this.setState({ part: '' });
+        parts.reduce((previous, part) => previous.then(() => apiPost('airgapped/receive', { part })
+            .then(({ success, errorMessage }) => this.setState({ error: success ? null : errorMessage }))),
+        Promise.resolve());
+    }
+
+    abort = () => {
+        apiPost('airgapped/abort');
+    }
+
+    render({ t }, { request, images, frame, part, error }) {
+        if (!request) {
+            return null;
+        }
+        return (
+            <Dialog title={t('airgapped.title')} disableEscape onClose={this.abort}>
+                <Input
+                    id="airgappedPart"
+                    label={t('airgapped.part')}
+                    placeholder="ur:crypto-psbt/..."
+                    error={error}
+                    onInput={this.handleInput}
+                    onKeyDown={this.handleKeyDown}
+                    value={part} />
+                <p>{t('airgapped.scan')}</p>
+                <div class="flex flex-row flex-center">
+                    {images.length > 0 && <img width={256} height={256} src={images[frame]} />}
+                </div>
+                <p>{t('airgapped.progress', { progress: Math.round(request.progress * 100) })}</p>
+                <div class="buttons flex flex-row flex-between">
+                    <Button secondary onClick={this.abort}>{t('airgapped.abort')}</Button>
+                    <Button primary disabled={!part.trim()} onClick={this.receive}>
+                        {t('airgapped.receive')}
+                    </Button>
+                </div>
+            </Dialog>
+        );
+    }
+}
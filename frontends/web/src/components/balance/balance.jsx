@@ -47,6 +47,16 @@ export default function Balance({
                     </p>
                 )
             }
+            {
+                balance && balance.hasPendingChange && (
+                    <p class={style.pendingBalance}>
+                        {t('account.pendingChange', {
+                            amount: balance.pendingChange.amount,
+                            unit: balance.pendingChange.unit,
+                        })}
+                    </p>
+                )
+            }
         </header>
     );
 }
@@ -31,8 +31,9 @@ export type Coin = MainnetCoin | TestnetCoin;
 
 export type Fiat = 'USD' | 'EUR' | 'CHF' | 'GBP' | 'JPY' | 'KRW' | 'CNY' | 'RUB';
 
+// Rates holds the rates of the mainnet coins and of the ERC20 tokens, by unit.
 export type Rates = {
-    [coin in MainnetCoin]: {
+    [unit: string]: {
         [fiat in Fiat]: number;
     }
 };
@@ -123,11 +124,10 @@ function Conversion({
         return null;
     }
     const coin = amount.unit;
-    let mainnetCoin: MainnetCoin;
-    if (coin.length === 4 && coin.startsWith('T')) {
-        mainnetCoin = coin.substring(1) as MainnetCoin;
-    } else {
-        mainnetCoin = coin as MainnetCoin;
+    let mainnetCoin: string = coin;
+    // Testnet coins are valued like their mainnet coins, unless it is a token like TUSD.
+    if (!rates[coin] && coin.length === 4 && coin.startsWith('T')) {
+        mainnetCoin = coin.substring(1);
     }
     let formattedValue = '';
     if (rates[mainnetCoin]) {
@@ -35,6 +35,12 @@ const labelMap = {
     'btc-p2wpkh': 'BTC',
     'tbtc-p2wpkh-p2sh': 'TBTC SW',
     'tbtc-p2wpkh': 'TBTC NSW',
+    'sbtc-p2pkh': 'SBTC',
+    'sbtc-p2wpkh-p2sh': 'SBTC SW',
+    'sbtc-p2wpkh': 'SBTC NSW',
+    'rbtc-p2pkh': 'RBTC',
+    'rbtc-p2wpkh-p2sh': 'RBTC SW',
+    'rbtc-p2wpkh': 'RBTC NSW',
     'ltc-p2wpkh-p2sh': 'LTC',
     'ltc-p2wpkh': 'LTC',
     'tltc-p2wpkh-p2sh': 'TLTC',
@@ -16,7 +16,9 @@
 
 import { Component, h } from 'preact';
 import { translate } from 'react-i18next';
+import { apiPost } from '../../utils/request';
 import { FiatConversion } from '../rates/rates';
+import { Input } from '../forms';
 import ArrowUp from '../../assets/icons/arrow-up.svg';
 import ArrowDown from '../../assets/icons/arrow-down.svg';
 import ArrowRight from '../../assets/icons/arrow-right.svg';
@@ -28,6 +30,27 @@ import * as style from './transaction.css';
 export default class Transaction extends Component {
     state = {
         collapsed: true,
+        note: this.props.note,
+    }
+
+    componentWillReceiveProps({ note }) {
+        if (note !== this.props.note) {
+            this.setState({ note });
+        }
+    }
+
+    handleNoteInput = event => {
+        this.setState({ note: event.target.value });
+    }
+
+    saveNote = () => {
+        if (this.state.note === this.props.note) {
+            return;
+        }
+        apiPost(`account/${this.props.accountCode}/tx-note`, {
+            txID: this.props.id,
+            note: this.state.note,
+        });
     }
 
     onUncollapse = () => {
@@ -65,14 +88,23 @@ export default class Transaction extends Component {
         amount,
         fee,
         feeRatePerKb,
+        feeRatePerVByte,
         vsize,
         size,
         weight,
+        rbf,
+        internal,
+        failed,
+        revertReason,
         numConfirmations,
         time,
         addresses,
+        addressLabels,
+        inputs,
+        outputs,
     }, {
         collapsed,
+        note,
     }) {
         const badge = t(`transaction.badge.${type}`);
         const arrow = badge === 'In' ? ArrowDown : badge === 'Out' ? ArrowUp : ArrowRight;
@@ -98,10 +130,20 @@ export default class Transaction extends Component {
                                     <span>{date}</span>
                                     <span>{sDate}</span>
                                 </div>
-                                <div class={style.address}>{addresses.join(', ')}</div>
+                                <div class={style.address}>
+                                    {addresses.map(address => (addressLabels && addressLabels[address] ? `${address} (${addressLabels[address]})` : address)).join(', ')}
+                                </div>
+                                { note && <div class={style.address}>{note}</div> }
+                                {
+                                    failed && (
+                                        <div class={[style.address, style.failed].join(' ')}>
+                                            {revertReason ? t('transaction.failed.reason', { reason: revertReason }) : t('transaction.failed.label')}
+                                        </div>
+                                    )
+                                }
                             </div>
                         </div>
-                        <div class={[style.amount, style[type]].join(' ')}>
+                        <div class={[style.amount, style[type], failed ? style.failed : ''].join(' ')}>
                             <div><span class={style.amountValue}>{sign}{amount.amount}</span> <span class={style.unit}>{amount.unit}</span></div>
                             <div class={style.fiat}><FiatConversion amount={amount}>{sign}</FiatConversion></div>
                         </div>
@@ -114,6 +156,14 @@ export default class Transaction extends Component {
                                     <div class={style.transactionLabel}>{t('transaction.confirmation')}</div>
                                     <div class={style.address}>{numConfirmations}</div>
                                 </div>
+                                {
+                                    internal ? (
+                                        <div>
+                                            <div class={style.transactionLabel}>{t('transaction.internal.label')}</div>
+                                            <div class={style.address}>{t('transaction.internal.description')}</div>
+                                        </div>
+                                    ) : ''
+                                }
                                 {
                                     vsize && vsize !== 0 ? (
                                         <div>
@@ -138,6 +188,14 @@ export default class Transaction extends Component {
                                         </div>
                                     ) : ''
                                 }
+                                {
+                                    vsize && vsize !== 0 && numConfirmations <= 0 ? (
+                                        <div>
+                                            <div class={style.transactionLabel}>{t('transaction.rbf.label')}</div>
+                                            <div class={style.address}>{rbf ? t('transaction.rbf.yes') : t('transaction.rbf.no')}</div>
+                                        </div>
+                                    ) : ''
+                                }
                                 {
                                     fee && fee.amount && (
                                         <div>
@@ -146,6 +204,48 @@ export default class Transaction extends Component {
                                         </div>
                                     )
                                 }
+                                {
+                                    feeRatePerVByte && (
+                                        <div>
+                                            <div class={style.transactionLabel}>{t('transaction.feeRate')}</div>
+                                            <div class={style.address}>{feeRatePerVByte} sat/vB</div>
+                                        </div>
+                                    )
+                                }
+                            </div>
+                            {
+                                inputs && inputs.length > 0 && (
+                                    <div class={style.row}>
+                                        <div class={style.transactionLabel}>{t('transaction.inputs')}</div>
+                                        {inputs.map(input => (
+                                            <div class={style.address} title={input.outPoint}>
+                                                {input.amount ? `${input.amount.amount} ${input.amount.unit} ${input.address}` : `${input.outPoint} (${t('transaction.external')})`}
+                                            </div>
+                                        ))}
+                                    </div>
+                                )
+                            }
+                            {
+                                outputs && outputs.length > 0 && (
+                                    <div class={style.row}>
+                                        <div class={style.transactionLabel}>{t('transaction.outputs')}</div>
+                                        {outputs.map(output => (
+                                            <div class={style.address}>
+                                                {output.amount.amount} {output.amount.unit} {output.address}
+                                                {output.change ? ` (${t('transaction.change')})` : output.ours ? ` (${t('transaction.ours')})` : ''}
+                                            </div>
+                                        ))}
+                                    </div>
+                                )
+                            }
+                            <div class={style.row}>
+                                <Input
+                                    label={t('transaction.note')}
+                                    id={`note-${id}`}
+                                    placeholder={t('transaction.notePlaceholder')}
+                                    value={note}
+                                    onInput={this.handleNoteInput}
+                                    onBlur={this.saveNote} />
                             </div>
                             <div class={style.row}>
                                 <div class={style.transactionLabel}>
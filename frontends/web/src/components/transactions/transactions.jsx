@@ -22,6 +22,7 @@ import Transaction from './transaction';
 export default class Transactions extends Component {
     render({
         t,
+        accountCode,
         explorerURL,
         transactions,
         className,
@@ -31,7 +32,8 @@ export default class Transactions extends Component {
                 {
                     transactions.length > 0 ? transactions.map(props => (
                         <Transaction
-                            key={props.id}
+                            key={props.internal ? `${props.id}-internal` : props.id}
+                            accountCode={accountCode}
                             explorerURL={explorerURL}
                             {...props} />
                     )) : (
@@ -16,6 +16,7 @@
 
 import { Component, h } from 'preact';
 import { translate } from 'react-i18next';
+import { Button } from '../forms';
 import approve from '../../assets/icons/hold.png';
 import reject from '../../assets/icons/tap.png';
 import * as style from '../dialog/dialog.css';
@@ -56,6 +57,7 @@ export default class WaitDialog extends Component {
         title,
         paired = false,
         touchConfirm = true,
+        onCancel,
         children,
     }, {
         active,
@@ -105,6 +107,20 @@ export default class WaitDialog extends Component {
                             </div>
                         ) : defaultContent
                     }
+                    {
+                        onCancel && (
+                            <p>{t('confirm.cancelInfo')}</p>
+                        )
+                    }
+                    {
+                        onCancel && (
+                            <div class={['buttons', 'flex', 'flex-row', 'flex-end'].join(' ')}>
+                                <Button secondary onClick={onCancel}>
+                                    {t('confirm.cancel')}
+                                </Button>
+                            </div>
+                        )
+                    }
                 </div>
             </div>
         );
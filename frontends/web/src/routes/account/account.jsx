@@ -19,7 +19,7 @@ import { route } from 'preact-router';
 import { translate } from 'react-i18next';
 import { apiGet, apiPost } from '../../utils/request';
 import { apiWebsocket } from '../../utils/websocket';
-import { ButtonLink } from '../../components/forms';
+import { Button, ButtonLink } from '../../components/forms';
 import { Guide } from '../../components/guide/guide';
 import { Entry } from '../../components/guide/entry';
 import Header from '../../components/header/Header';
@@ -28,6 +28,7 @@ import HeadersSync from '../../components/headerssync/headerssync';
 import Status from '../../components/status/status';
 import Transactions from '../../components/transactions/transactions';
 import Spinner from '../../components/spinner/Spinner';
+import { store as fiat } from '../../components/rates/rates';
 import InfoIcon from '../../assets/icons/info.svg';
 import ArrowUp from '../../assets/icons/arrow-up.svg';
 import ArrowDown from '../../assets/icons/arrow-down.svg';
@@ -47,6 +48,9 @@ export default class Account extends Component {
         transactions: [],
         balance: null,
         hasCard: false,
+        // incomingTransaction is true if a payment to the account entered the mempool since the
+        // account was opened.
+        incomingTransaction: false,
     }
 
     componentDidMount() {
@@ -66,7 +70,7 @@ export default class Account extends Component {
 
     componentWillReceiveProps(nextProps) {
         if (nextProps.code && nextProps.code !== this.props.code) {
-            this.setState({ determiningStatus: true });
+            this.setState({ determiningStatus: true, incomingTransaction: false });
         }
     }
 
@@ -107,6 +111,10 @@ export default class Account extends Component {
         case 'syncdone':
             this.onAccountChanged();
             break;
+        case 'incomingTransaction':
+            this.setState({ incomingTransaction: true });
+            this.onAccountChanged();
+            break;
         }
     }
 
@@ -143,6 +151,16 @@ export default class Account extends Component {
         }
     }
 
+    export = format => {
+        apiGet(`account/${this.props.code}/export?format=${format}&fiat=${fiat.state.active}`).then(({ filename, data }) => {
+            const link = document.createElement('a');
+            link.href = URL.createObjectURL(new Blob([data], { type: format === 'csv' ? 'text/csv' : 'application/json' }));
+            link.download = filename;
+            link.click();
+            URL.revokeObjectURL(link.href);
+        });
+    }
+
     render({
         t,
         code,
@@ -154,6 +172,7 @@ export default class Account extends Component {
         determiningStatus,
         balance,
         hasCard,
+        incomingTransaction,
     }) {
         if (!accounts) return null;
         const account = accounts.find(account => account.code === code);
@@ -172,10 +191,18 @@ export default class Account extends Component {
                             </Status>
                         ) : null
                     }
+                    {
+                        incomingTransaction ? (
+                            <Status type="info">
+                                <p>{t('account.incomingTransaction')}</p>
+                            </Status>
+                        ) : null
+                    }
                     <Header
                         title={
                             <h2 className={componentStyle.title}>
                                 {account.name}
+                                {account.watchOnly && ` (${t('account.watchOnly')})`}
                                 <a href={`/account/${code}/info`}><img src={InfoIcon} /></a>
                             </h2>
                         }
@@ -191,13 +218,27 @@ export default class Account extends Component {
                                 <img src={ArrowDown} />
                                 <span>{t('button.receive')}</span>
                             </ButtonLink>
-                            <ButtonLink
-                                primary
-                                href={`/account/${code}/send`}
-                                disabled={!initialized || balance && balance.available.amount === '0'}>
-                                <img src={ArrowUp} />
-                                <span>{t('button.send')}</span>
-                            </ButtonLink>
+                            {!account.watchOnly && (
+                                <ButtonLink
+                                    primary
+                                    href={`/account/${code}/send`}
+                                    disabled={!initialized || balance && balance.available.amount === '0'}>
+                                    <img src={ArrowUp} />
+                                    <span>{t('button.send')}</span>
+                                </ButtonLink>
+                            )}
+                            <Button
+                                secondary
+                                onClick={() => this.export('csv')}
+                                disabled={noTransactions || !initialized}>
+                                {t('account.exportCSV')}
+                            </Button>
+                            <Button
+                                secondary
+                                onClick={() => this.export('json')}
+                                disabled={noTransactions || !initialized}>
+                                {t('account.exportJSON')}
+                            </Button>
                         </div>
                     </Header>
                     <div class={['innerContainer', ''].join(' ')}>
@@ -210,6 +251,7 @@ export default class Account extends Component {
                                 } />
                             ) : (
                                 <Transactions
+                                    accountCode={code}
                                     explorerURL={account.blockExplorerTxPrefix}
                                     transactions={transactions}
                                     className={noTransactions ? 'isVerticallyCentered' : 'scrollableContainer'}
@@ -223,7 +265,10 @@ export default class Account extends Component {
                 </div>
                 <Guide>
                     <Entry key="accountDescription" entry={t('guide.accountDescription')} />
-                    {balance && balance.available.amount === '0' && (
+                    {account.watchOnly && (
+                        <Entry key="accountWatchOnly" entry={t('guide.accountWatchOnly')} />
+                    )}
+                    {!account.watchOnly && balance && balance.available.amount === '0' && (
                         <Entry key="accountSendDisabled" entry={t('guide.accountSendDisabled', { unit: balance.available.unit })} />
                     )}
                     <Entry key="accountReload" entry={t('guide.accountReload')} />
@@ -233,7 +278,7 @@ export default class Account extends Component {
                     {transactions.length > 0 && (
                         <Entry key="accountTransactionTime" entry={t('guide.accountTransactionTime')} />
                     )}
-                    {(account.code === 'tbtc-p2pkh' || account.code === 'btc-p2pkh') && (
+                    {['tbtc-p2pkh', 'sbtc-p2pkh', 'rbtc-p2pkh', 'btc-p2pkh'].includes(account.code) && (
                         <Entry key="accountLegacyConvert" entry={t('guide.accountLegacyConvert')} />
                     )}
                     {transactions.length > 0 && (
@@ -0,0 +1,118 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Component, h } from 'preact';
+import { translate } from 'react-i18next';
+import { Button, ButtonLink } from '../../../components/forms';
+import { apiGet, apiPost } from '../../../utils/request';
+import { alertUser } from '../../../components/alert/Alert';
+import { Guide } from '../../../components/guide/guide';
+import { Entry } from '../../../components/guide/entry';
+import Header from '../../../components/header/Header';
+
+const approvalKey = approval => `${approval.token}-${approval.spender}`;
+
+@translate()
+export default class Approvals extends Component {
+    state = {
+        approvals: null,
+        // revoking is the key of the approval being revoked, or null.
+        revoking: null,
+    }
+
+    componentDidMount() {
+        this.load();
+    }
+
+    load = () => {
+        apiGet(`account/${this.props.code}/approvals`).then(({ success, approvals, errorMessage }) => {
+            if (success) {
+                this.setState({ approvals });
+            } else {
+                this.setState({ approvals: [] });
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    revoke = approval => {
+        this.setState({ revoking: approvalKey(approval) });
+        apiPost(`account/${this.props.code}/approval-revoke`, {
+            token: approval.token,
+            spender: approval.spender,
+        }).then(({ success, errorMessage }) => {
+            this.setState({ revoking: null });
+            if (success) {
+                alertUser(this.props.t('approvals.revoked'));
+            } else if (errorMessage) {
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    render({
+        t,
+        code,
+    }, {
+        approvals,
+        revoking,
+    }) {
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('approvals.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            {approvals === null && <p>{t('approvals.loading')}</p>}
+                            {approvals && approvals.length === 0 && <p>{t('approvals.empty')}</p>}
+                            {approvals && approvals.map(approval => (
+                                <div key={approvalKey(approval)}>
+                                    <h3>
+                                        {approval.unlimited ? t('approvals.unlimited') : approval.allowance}
+                                        {' '}
+                                        {approval.symbol}
+                                    </h3>
+                                    <p>
+                                        {t('approvals.token')}: <small>{approval.token}</small>
+                                        <br />
+                                        {t('approvals.spender')}: <small>{approval.spender}</small>
+                                    </p>
+                                    <Button secondary disabled={!!revoking} onClick={() => this.revoke(approval)}>
+                                        {t('approvals.revoke')}
+                                    </Button>
+                                </div>
+                            ))}
+                            <div class="flex flex-row flex-between">
+                                <ButtonLink
+                                    secondary
+                                    href={`/account/${code}/info`}>
+                                    {t('button.back')}
+                                </ButtonLink>
+                                <Button secondary disabled={approvals === null || !!revoking} onClick={this.load}>
+                                    {t('approvals.reload')}
+                                </Button>
+                            </div>
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.approvals.what" entry={t('guide.approvals.what')} />
+                    <Entry key="guide.approvals.revoke" entry={t('guide.approvals.revoke')} />
+                </Guide>
+            </div>
+        );
+    }
+}
@@ -15,12 +15,17 @@
  */
 
 import { Component, h } from 'preact';
-import { ButtonLink } from '../../../components/forms';
+import { Button, ButtonLink, Input } from '../../../components/forms';
 import Balance from '../../../components/balance/balance';
 import { route } from 'preact-router';
 import { translate } from 'react-i18next';
 import QRCode from '../../../components/qrcode/qrcode';
-import { apiGet } from '../../../utils/request';
+import { apiGet, apiPost } from '../../../utils/request';
+import { setConfig } from '../../../utils/config';
+import { isEVMCoin, isEVMBased } from '../utils';
+import { alertUser } from '../../../components/alert/Alert';
+import { confirmation } from '../../../components/confirm/Confirm';
+import InlineMessage from '../../../components/inlineMessage/InlineMessage';
 import { Guide } from '../../../components/guide/guide';
 import { Entry } from '../../../components/guide/entry';
 import Header from '../../../components/header/Header';
@@ -55,13 +60,44 @@ export default class Info extends Component {
         super(props);
         this.state = {
             balance: null,
-            info: null
+            info: null,
+            descriptors: null,
+            gapLimits: null,
+            gapLimitsSuccess: false,
+            blockExplorer: '',
+            blockExplorerSuccess: false,
+            ethereumRPC: '',
+            ethereumRPCSuccess: false,
+            ethereumIndexer: '',
+            ethereumIndexerSuccess: false,
+            ethereumRelay: '',
+            ethereumRelaySuccess: false,
+            scanningLegacyKeypaths: false,
         };
     }
 
     componentDidMount() {
         apiGet(`account/${this.props.code}/balance`).then(balance => this.setState({ balance }));
         apiGet(`account/${this.props.code}/info`).then(info => this.setState({ info }));
+        apiGet(`account/${this.props.code}/descriptors`).then(({ success, descriptors }) => {
+            if (success) {
+                this.setState({ descriptors });
+            }
+        });
+        apiGet('config').then(({ backend }) => {
+            const gapLimits = (backend.gapLimits || {})[this.props.code] || {};
+            const account = this.getAccount();
+            this.setState({
+                blockExplorer: account && (backend.blockExplorers || {})[account.coinCode] || '',
+                ethereumRPC: account && (backend.ethereumRPCs || {})[account.coinCode.toLowerCase()] || '',
+                ethereumIndexer: account && (backend.ethereumIndexers || {})[account.coinCode.toLowerCase()] || '',
+                ethereumRelay: account && (backend.ethereumRelays || {})[account.coinCode.toLowerCase()] || '',
+                gapLimits: {
+                    receive: gapLimits.receive || '',
+                    change: gapLimits.change || '',
+                },
+            });
+        });
     }
 
     componentWillMount() {
@@ -79,6 +115,177 @@ export default class Info extends Component {
         }
     }
 
+    addAccount = () => {
+        apiPost('accounts/add', this.props.code).then(({ success, errorMessage }) => {
+            if (!success) {
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    resync = () => {
+        confirmation(this.props.t('accountInfo.resync.confirm'), confirmed => {
+            if (!confirmed) {
+                return;
+            }
+            apiPost('accounts/resync', this.props.code).then(({ success, errorMessage }) => {
+                if (!success) {
+                    alertUser(errorMessage);
+                    return;
+                }
+                route(`/account/${this.props.code}`);
+            });
+        });
+    }
+
+    handleGapLimitChange = event => {
+        this.setState({
+            gapLimits: Object.assign({}, this.state.gapLimits, {
+                [event.target.id]: event.target.value,
+            }),
+            gapLimitsSuccess: false,
+        });
+    }
+
+    saveGapLimits = () => {
+        apiGet('config').then(({ backend }) => {
+            const { receive, change } = this.state.gapLimits;
+            const gapLimits = Object.assign({}, backend.gapLimits, {
+                [this.props.code]: {
+                    receive: parseInt(receive, 10) || 0,
+                    change: parseInt(change, 10) || 0,
+                },
+            });
+            return setConfig({ backend: { gapLimits } });
+        }).then(() => this.setState({ gapLimitsSuccess: true }));
+    }
+
+    handleDismissGapLimitsMessage = () => {
+        this.setState({ gapLimitsSuccess: false });
+    }
+
+    handleBlockExplorerChange = event => {
+        this.setState({ blockExplorer: event.target.value, blockExplorerSuccess: false });
+    }
+
+    saveBlockExplorer = () => {
+        apiPost('block-explorer', {
+            coinCode: this.getAccount().coinCode,
+            txPrefix: this.state.blockExplorer,
+        }).then(({ success, errorMessage }) => {
+            if (success) {
+                this.setState({ blockExplorerSuccess: true });
+            } else {
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    handleDismissBlockExplorerMessage = () => {
+        this.setState({ blockExplorerSuccess: false });
+    }
+
+    handleEthereumRPCChange = event => {
+        this.setState({ ethereumRPC: event.target.value, ethereumRPCSuccess: false });
+    }
+
+    saveEthereumRPC = () => {
+        apiPost('ethereum-rpc', {
+            coinCode: this.getAccount().coinCode.toLowerCase(),
+            url: this.state.ethereumRPC,
+        }).then(({ success, errorMessage }) => {
+            if (success) {
+                this.setState({ ethereumRPCSuccess: true });
+            } else {
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    handleDismissEthereumRPCMessage = () => {
+        this.setState({ ethereumRPCSuccess: false });
+    }
+
+    handleEthereumIndexerChange = event => {
+        this.setState({ ethereumIndexer: event.target.value, ethereumIndexerSuccess: false });
+    }
+
+    saveEthereumIndexer = () => {
+        apiPost('ethereum-indexer', {
+            coinCode: this.getAccount().coinCode.toLowerCase(),
+            url: this.state.ethereumIndexer,
+        }).then(({ success, errorMessage }) => {
+            if (success) {
+                this.setState({ ethereumIndexerSuccess: true });
+            } else {
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    handleDismissEthereumIndexerMessage = () => {
+        this.setState({ ethereumIndexerSuccess: false });
+    }
+
+    handleEthereumRelayChange = event => {
+        this.setState({ ethereumRelay: event.target.value, ethereumRelaySuccess: false });
+    }
+
+    saveEthereumRelay = () => {
+        apiPost('ethereum-relay', {
+            coinCode: this.getAccount().coinCode.toLowerCase(),
+            url: this.state.ethereumRelay,
+        }).then(({ success, errorMessage }) => {
+            if (success) {
+                this.setState({ ethereumRelaySuccess: true });
+            } else {
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    handleDismissEthereumRelayMessage = () => {
+        this.setState({ ethereumRelaySuccess: false });
+    }
+
+    scanLegacyKeypaths = () => {
+        this.setState({ scanningLegacyKeypaths: true });
+        apiPost('ethereum-legacy-scan', this.getAccount().coinCode.toLowerCase())
+            .then(({ success, added, errorMessage }) => {
+                this.setState({ scanningLegacyKeypaths: false });
+                if (!success) {
+                    alertUser(errorMessage);
+                    return;
+                }
+                alertUser(this.props.t('accountInfo.legacyKeypaths.result', { count: added }));
+            });
+    }
+
+    exportLabels = () => {
+        apiGet(`account/${this.props.code}/labels`).then(({ filename, data }) => {
+            const link = document.createElement('a');
+            link.href = URL.createObjectURL(new Blob([data], { type: 'application/jsonl' }));
+            link.download = filename;
+            link.click();
+            URL.revokeObjectURL(link.href);
+        });
+    }
+
+    importLabels = event => {
+        const file = event.target.files[0];
+        event.target.value = '';
+        if (!file) {
+            return;
+        }
+        const reader = new FileReader();
+        reader.onload = () => {
+            apiPost(`account/${this.props.code}/labels`, { data: reader.result }).then(({ success, imported, errorMessage }) => {
+                alertUser(success ? this.props.t('accountInfo.labels.imported', { count: imported }) : errorMessage);
+            });
+        };
+        reader.readAsText(file);
+    }
+
     getAccount() {
         if (!this.props.accounts) return null;
         return this.props.accounts.find(({ code }) => code === this.props.code);
@@ -89,7 +296,19 @@ export default class Info extends Component {
         code,
     }, {
         balance,
-        info
+        info,
+        descriptors,
+        gapLimits,
+        gapLimitsSuccess,
+        blockExplorer,
+        blockExplorerSuccess,
+        ethereumRPC,
+        ethereumRPCSuccess,
+        ethereumIndexer,
+        ethereumIndexerSuccess,
+        ethereumRelay,
+        ethereumRelaySuccess,
+        scanningLegacyKeypaths,
     }) {
         const account = this.getAccount();
         if (!account || !info) return null;
@@ -107,6 +326,142 @@ export default class Info extends Component {
                                 <SigningConfiguration
                                     t={t}
                                     signingConfiguration={info.signingConfiguration} />
+                                {descriptors && (
+                                    <div>
+                                        <strong>{t('accountInfo.descriptors')}</strong>
+                                        <label>{t('accountInfo.receiveDescriptor')}</label>
+                                        <CopyableInput value={descriptors.receive} />
+                                        <label>{t('accountInfo.changeDescriptor')}</label>
+                                        <CopyableInput value={descriptors.change} />
+                                    </div>
+                                )}
+                                {!isEVMBased(account.coinCode) && (
+                                    <div>
+                                        <strong>{t('accountInfo.labels.title')}</strong>
+                                        <div class="flex flex-row flex-between flex-items-center">
+                                            <Button secondary onClick={this.exportLabels}>
+                                                {t('accountInfo.labels.export')}
+                                            </Button>
+                                            <label>
+                                                {t('accountInfo.labels.import')}
+                                                <input type="file" accept=".jsonl,.json,.txt" onChange={this.importLabels} />
+                                            </label>
+                                        </div>
+                                    </div>
+                                )}
+                                {gapLimits && !isEVMBased(account.coinCode) && (
+                                    <div>
+                                        <strong>{t('accountInfo.gapLimits')}</strong>
+                                        <Input
+                                            type="number"
+                                            min="1"
+                                            id="receive"
+                                            label={t('accountInfo.receiveGapLimit')}
+                                            placeholder={t('accountInfo.defaultGapLimit')}
+                                            onInput={this.handleGapLimitChange}
+                                            value={gapLimits.receive} />
+                                        <Input
+                                            type="number"
+                                            min="1"
+                                            id="change"
+                                            label={t('accountInfo.changeGapLimit')}
+                                            placeholder={t('accountInfo.defaultGapLimit')}
+                                            onInput={this.handleGapLimitChange}
+                                            value={gapLimits.change} />
+                                        <Button secondary onClick={this.saveGapLimits}>
+                                            {t('button.save')}
+                                        </Button>
+                                        {gapLimitsSuccess && (
+                                            <InlineMessage
+                                                type="success"
+                                                align="left"
+                                                message={t('settings.success')}
+                                                onEnd={this.handleDismissGapLimitsMessage} />
+                                        )}
+                                    </div>
+                                )}
+                                <div>
+                                    <strong>{t('accountInfo.blockExplorer.title', { coinCode: account.coinCode.toUpperCase() })}</strong>
+                                    <Input
+                                        id="blockExplorer"
+                                        label={t('accountInfo.blockExplorer.label')}
+                                        placeholder={account.blockExplorerTxPrefix}
+                                        onInput={this.handleBlockExplorerChange}
+                                        value={blockExplorer} />
+                                    <Button secondary onClick={this.saveBlockExplorer}>
+                                        {t('button.save')}
+                                    </Button>
+                                    {blockExplorerSuccess && (
+                                        <InlineMessage
+                                            type="success"
+                                            align="left"
+                                            message={t('settings.success')}
+                                            onEnd={this.handleDismissBlockExplorerMessage} />
+                                    )}
+                                </div>
+                                {isEVMCoin(account.coinCode) && (
+                                    <div>
+                                        <strong>{t('accountInfo.ethereumRPC.title', { coinCode: account.coinCode.toUpperCase() })}</strong>
+                                        <Input
+                                            id="ethereumRPC"
+                                            label={t('accountInfo.ethereumRPC.label')}
+                                            placeholder="http://127.0.0.1:8545"
+                                            onInput={this.handleEthereumRPCChange}
+                                            value={ethereumRPC} />
+                                        <Button secondary onClick={this.saveEthereumRPC}>
+                                            {t('button.save')}
+                                        </Button>
+                                        {ethereumRPCSuccess && (
+                                            <InlineMessage
+                                                type="success"
+                                                align="left"
+                                                message={t('accountInfo.ethereumRPC.success')}
+                                                onEnd={this.handleDismissEthereumRPCMessage} />
+                                        )}
+                                    </div>
+                                )}
+                                {isEVMCoin(account.coinCode) && (
+                                    <div>
+                                        <strong>{t('accountInfo.ethereumIndexer.title', { coinCode: account.coinCode.toUpperCase() })}</strong>
+                                        <Input
+                                            id="ethereumIndexer"
+                                            label={t('accountInfo.ethereumIndexer.label')}
+                                            placeholder="https://eth.blockscout.com"
+                                            onInput={this.handleEthereumIndexerChange}
+                                            value={ethereumIndexer} />
+                                        <Button secondary onClick={this.saveEthereumIndexer}>
+                                            {t('button.save')}
+                                        </Button>
+                                        {ethereumIndexerSuccess && (
+                                            <InlineMessage
+                                                type="success"
+                                                align="left"
+                                                message={t('accountInfo.ethereumIndexer.success')}
+                                                onEnd={this.handleDismissEthereumIndexerMessage} />
+                                        )}
+                                    </div>
+                                )}
+                                {isEVMCoin(account.coinCode) && (
+                                    <div>
+                                        <strong>{t('accountInfo.ethereumRelay.title', { coinCode: account.coinCode.toUpperCase() })}</strong>
+                                        <Input
+                                            id="ethereumRelay"
+                                            label={t('accountInfo.ethereumRelay.label')}
+                                            placeholder="https://rpc.flashbots.net"
+                                            onInput={this.handleEthereumRelayChange}
+                                            value={ethereumRelay} />
+                                        <Button secondary onClick={this.saveEthereumRelay}>
+                                            {t('button.save')}
+                                        </Button>
+                                        {ethereumRelaySuccess && (
+                                            <InlineMessage
+                                                type="success"
+                                                align="left"
+                                                message={t('accountInfo.ethereumRelay.success')}
+                                                onEnd={this.handleDismissEthereumRelayMessage} />
+                                        )}
+                                    </div>
+                                )}
                             </div>
                         </div>
                         <div class={style.bottomButtons}>
@@ -115,11 +470,74 @@ export default class Info extends Component {
                                 href={`/account/${code}`}>
                                 {t('button.back')}
                             </ButtonLink>
+                            {!isEVMBased(account.coinCode) && (
+                                <ButtonLink
+                                    secondary
+                                    href={`/account/${code}/sweep`}>
+                                    {t('accountInfo.sweep')}
+                                </ButtonLink>
+                            )}
+                            <ButtonLink
+                                secondary
+                                href={`/account/${code}/message`}>
+                                {t('accountInfo.signMessage')}
+                            </ButtonLink>
+                            {isEVMCoin(account.coinCode) && (
+                                <ButtonLink
+                                    secondary
+                                    href={`/account/${code}/typed-data`}>
+                                    {t('accountInfo.signTypedData')}
+                                </ButtonLink>
+                            )}
+                            {isEVMCoin(account.coinCode) && (
+                                <ButtonLink
+                                    secondary
+                                    href={`/account/${code}/walletconnect`}>
+                                    {t('accountInfo.walletConnect')}
+                                </ButtonLink>
+                            )}
+                            {isEVMCoin(account.coinCode) && (
+                                <ButtonLink
+                                    secondary
+                                    href={`/account/${code}/nfts`}>
+                                    {t('accountInfo.nfts')}
+                                </ButtonLink>
+                            )}
+                            {isEVMCoin(account.coinCode) && (
+                                <ButtonLink
+                                    secondary
+                                    href={`/account/${code}/approvals`}>
+                                    {t('accountInfo.approvals')}
+                                </ButtonLink>
+                            )}
+                            {isEVMCoin(account.coinCode) && (
+                                <Button secondary disabled={scanningLegacyKeypaths} onClick={this.scanLegacyKeypaths}>
+                                    {t('accountInfo.legacyKeypaths.button')}
+                                </Button>
+                            )}
+                            {!isEVMBased(account.coinCode) && (
+                                <Button secondary onClick={this.resync}>
+                                    {t('accountInfo.resync.button')}
+                                </Button>
+                            )}
+                            {(!isEVMBased(account.coinCode) || isEVMCoin(account.coinCode)) && (
+                                <Button primary onClick={this.addAccount}>
+                                    {t('accountInfo.addAccount')}
+                                </Button>
+                            )}
                         </div>
                     </div>
                 </div>
                 <Guide>
                     <Entry key="guide.accountInfo.xpub" entry={t('guide.accountInfo.xpub')} />
+                    <Entry key="guide.accountInfo.descriptors" entry={t('guide.accountInfo.descriptors')} />
+                    <Entry key="guide.accountInfo.labels" entry={t('guide.accountInfo.labels')} />
+                    <Entry key="guide.accountInfo.resync" entry={t('guide.accountInfo.resync')} />
+                    <Entry key="guide.accountInfo.blockExplorer" entry={t('guide.accountInfo.blockExplorer')} />
+                    <Entry key="guide.accountInfo.ethereumRPC" entry={t('guide.accountInfo.ethereumRPC')} />
+                    <Entry key="guide.accountInfo.ethereumIndexer" entry={t('guide.accountInfo.ethereumIndexer')} />
+                    <Entry key="guide.accountInfo.ethereumRelay" entry={t('guide.accountInfo.ethereumRelay')} />
+                    <Entry key="guide.accountInfo.legacyKeypaths" entry={t('guide.accountInfo.legacyKeypaths')} />
                 </Guide>
             </div>
         );
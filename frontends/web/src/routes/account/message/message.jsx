@@ -0,0 +1,135 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Component, h } from 'preact';
+import { translate } from 'react-i18next';
+import { Button, ButtonLink, Input } from '../../../components/forms';
+import { apiPost } from '../../../utils/request';
+import { alertUser } from '../../../components/alert/Alert';
+import { CopyableInput } from '../../../components/copy/Copy';
+import { Guide } from '../../../components/guide/guide';
+import { Entry } from '../../../components/guide/entry';
+import Header from '../../../components/header/Header';
+
+@translate()
+export default class Message extends Component {
+    state = {
+        address: '',
+        message: '',
+        signature: '',
+        signedSignature: null,
+        isSigning: false,
+        verified: null,
+    }
+
+    handleFormChange = event => {
+        this.setState({
+            [event.target.id]: event.target.value,
+            signedSignature: null,
+            verified: null,
+        });
+    }
+
+    sign = () => {
+        const { address, message } = this.state;
+        this.setState({ isSigning: true });
+        apiPost(`account/${this.props.code}/sign-message`, { address, message })
+            .then(({ success, signature, errorMessage }) => {
+                this.setState({ isSigning: false });
+                if (success) {
+                    this.setState({ signedSignature: signature });
+                } else if (errorMessage) {
+                    alertUser(errorMessage);
+                }
+            });
+    }
+
+    verify = () => {
+        const { address, message, signature } = this.state;
+        apiPost(`account/${this.props.code}/verify-message`, { address, message, signature })
+            .then(({ success, valid, errorMessage }) => {
+                if (success) {
+                    this.setState({ verified: valid });
+                } else {
+                    alertUser(errorMessage);
+                }
+            });
+    }
+
+    render({
+        t,
+        code,
+    }, {
+        address,
+        message,
+        signature,
+        signedSignature,
+        isSigning,
+        verified,
+    }) {
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('message.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            <Input
+                                id="address"
+                                label={t('message.address')}
+                                onInput={this.handleFormChange}
+                                value={address} />
+                            <Input
+                                id="message"
+                                label={t('message.message')}
+                                onInput={this.handleFormChange}
+                                value={message} />
+                            <Input
+                                id="signature"
+                                label={t('message.signature')}
+                                placeholder={t('message.signaturePlaceholder')}
+                                onInput={this.handleFormChange}
+                                value={signature} />
+                            {signedSignature && (
+                                <CopyableInput value={signedSignature} />
+                            )}
+                            {verified !== null && (
+                                <p>{verified ? t('message.valid') : t('message.invalid')}</p>
+                            )}
+                            <div class="flex flex-row flex-between">
+                                <ButtonLink
+                                    secondary
+                                    href={`/account/${code}/info`}>
+                                    {t('button.back')}
+                                </ButtonLink>
+                                <div>
+                                    <Button secondary disabled={!address || !signature} onClick={this.verify}>
+                                        {t('message.verify')}
+                                    </Button>
+                                    <Button primary disabled={!address || isSigning} onClick={this.sign}>
+                                        {t('message.sign')}
+                                    </Button>
+                                </div>
+                            </div>
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.message.what" entry={t('guide.message.what')} />
+                </Guide>
+            </div>
+        );
+    }
+}
@@ -0,0 +1,183 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Component, h } from 'preact';
+import { translate } from 'react-i18next';
+import { Button, ButtonLink, Input } from '../../../components/forms';
+import { apiGet, apiPost } from '../../../utils/request';
+import { alertUser } from '../../../components/alert/Alert';
+import { Guide } from '../../../components/guide/guide';
+import { Entry } from '../../../components/guide/entry';
+import Header from '../../../components/header/Header';
+
+const nftKey = nft => `${nft.contract}-${nft.tokenID}`;
+
+@translate()
+export default class NFTs extends Component {
+    state = {
+        nfts: null,
+        // metadata by NFT key.
+        metadata: {},
+        // transfer is the NFT being transferred, or null.
+        transfer: null,
+        recipient: '',
+        amount: '1',
+        isSending: false,
+    }
+
+    componentDidMount() {
+        this.load();
+    }
+
+    load = () => {
+        apiGet(`account/${this.props.code}/nfts`).then(({ success, nfts, errorMessage }) => {
+            if (success) {
+                this.setState({ nfts });
+            } else {
+                this.setState({ nfts: [] });
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    loadMetadata = nft => {
+        const query = `contract=${nft.contract}&tokenID=${nft.tokenID}&standard=${nft.standard}`;
+        apiGet(`account/${this.props.code}/nft-metadata?${query}`).then(({ success, metadata, errorMessage }) => {
+            if (success) {
+                this.setState(state => ({ metadata: Object.assign({}, state.metadata, { [nftKey(nft)]: metadata }) }));
+            } else {
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    startTransfer = nft => {
+        this.setState({ transfer: nft, recipient: '', amount: '1' });
+    }
+
+    send = () => {
+        const { transfer, recipient, amount } = this.state;
+        this.setState({ isSending: true });
+        apiPost(`account/${this.props.code}/nft-transfer`, {
+            contract: transfer.contract,
+            tokenID: transfer.tokenID,
+            standard: transfer.standard,
+            amount,
+            recipient: recipient.trim(),
+        }).then(({ success, errorMessage }) => {
+            this.setState({ isSending: false });
+            if (success) {
+                this.setState({ transfer: null });
+                alertUser(this.props.t('nfts.sent'));
+            } else if (errorMessage) {
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    renderTransfer = nft => {
+        const { t } = this.props;
+        const { recipient, amount, isSending } = this.state;
+        return (
+            <div>
+                <Input
+                    label={t('nfts.recipient')}
+                    placeholder="0x..."
+                    onInput={e => this.setState({ recipient: e.target.value })}
+                    value={recipient} />
+                {nft.standard === 'erc1155' && (
+                    <Input
+                        type="number"
+                        min="1"
+                        max={nft.amount}
+                        label={t('nfts.amount')}
+                        onInput={e => this.setState({ amount: e.target.value })}
+                        value={amount} />
+                )}
+                <Button secondary disabled={isSending} onClick={() => this.setState({ transfer: null })}>
+                    {t('dialog.cancel')}
+                </Button>
+                <Button primary disabled={!recipient.trim() || isSending} onClick={this.send}>
+                    {t('button.send')}
+                </Button>
+            </div>
+        );
+    }
+
+    render({
+        t,
+        code,
+    }, {
+        nfts,
+        metadata,
+        transfer,
+    }) {
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('nfts.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            {nfts === null && <p>{t('loading')}</p>}
+                            {nfts && nfts.length === 0 && <p>{t('nfts.empty')}</p>}
+                            {nfts && nfts.map(nft => {
+                                const key = nftKey(nft);
+                                const nftMetadata = metadata[key];
+                                return (
+                                    <div key={key}>
+                                        <h3>{nftMetadata && nftMetadata.name || `${nft.collectionName} #${nft.tokenID}`}</h3>
+                                        <p>
+                                            {nft.collectionName} ({nft.standard.toUpperCase()}) #{nft.tokenID}
+                                            {nft.standard === 'erc1155' && ` × ${nft.amount}`}
+                                            <br />
+                                            <small>{nft.contract}</small>
+                                        </p>
+                                        {nftMetadata ? (
+                                            <div>
+                                                {nftMetadata.image && <img src={nftMetadata.image} alt="" width="200" />}
+                                                <p>{nftMetadata.description}</p>
+                                            </div>
+                                        ) : (
+                                            <Button secondary onClick={() => this.loadMetadata(nft)}>
+                                                {t('nfts.loadMetadata')}
+                                            </Button>
+                                        )}
+                                        {transfer && nftKey(transfer) === key ? this.renderTransfer(nft) : (
+                                            <Button secondary disabled={!!transfer} onClick={() => this.startTransfer(nft)}>
+                                                {t('nfts.transfer')}
+                                            </Button>
+                                        )}
+                                    </div>
+                                );
+                            })}
+                            <div class="flex flex-row flex-between">
+                                <ButtonLink
+                                    secondary
+                                    href={`/account/${code}/info`}>
+                                    {t('button.back')}
+                                </ButtonLink>
+                            </div>
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.nfts.what" entry={t('guide.nfts.what')} />
+                    <Entry key="guide.nfts.metadata" entry={t('guide.nfts.metadata')} />
+                </Guide>
+            </div>
+        );
+    }
+}
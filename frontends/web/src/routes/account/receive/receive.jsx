@@ -18,7 +18,7 @@ import { Component, h } from 'preact';
 import { route } from 'preact-router';
 import { translate } from 'react-i18next';
 import { apiGet, apiPost } from '../../../utils/request';
-import { Button, ButtonLink } from '../../../components/forms';
+import { Button, ButtonLink, Input } from '../../../components/forms';
 import { Guide } from '../../../components/guide/guide';
 import { Entry } from '../../../components/guide/entry';
 import { alertUser } from '../../../components/alert/Alert';
@@ -26,6 +26,7 @@ import Header from '../../../components/header/Header';
 import Status from '../../../components/status/status';
 import QRCode from '../../../components/qrcode/qrcode';
 import { CopyableInput } from '../../../components/copy/Copy';
+import A from '../../../components/anchor/anchor';
 import ArrowLeft from '../../../assets/icons/arrow-left-gray.svg';
 import ArrowRight from '../../../assets/icons/arrow-right-gray.svg';
 import * as style from './receive.css';
@@ -38,7 +39,7 @@ export default class Receive extends Component {
         /** @type {number | null} */
         activeIndex: null,
 
-        /** @type {{ addressID: any, address: any }[] | null} */
+        /** @type {{ addressID: any, address: any, label: string }[] | null} */
         receiveAddresses: null,
         paired: null,
     }
@@ -58,6 +59,25 @@ export default class Receive extends Component {
         this.registerEvents();
     }
 
+    handleLabelInput = event => {
+        const label = event.target.value;
+        this.setState(({ activeIndex, receiveAddresses }) => ({
+            receiveAddresses: receiveAddresses.map((address, index) => (
+                index === activeIndex ? { ...address, label } : address
+            )),
+        }));
+    }
+
+    saveLabel = () => {
+        const { receiveAddresses, activeIndex } = this.state;
+        if (receiveAddresses !== null && activeIndex !== null) {
+            apiPost('account/' + this.props.code + '/address-label', {
+                addressID: receiveAddresses[activeIndex].addressID,
+                label: receiveAddresses[activeIndex].label,
+            });
+        }
+    }
+
     componentWillUnmount() {
         this.unregisterEvents();
     }
@@ -118,6 +138,11 @@ export default class Receive extends Component {
         }
     }
 
+    getAccount() {
+        if (!this.props.accounts) return null;
+        return this.props.accounts.find(({ code }) => code === this.props.code);
+    }
+
     render({
         t,
         coinCode,
@@ -128,6 +153,7 @@ export default class Receive extends Component {
         receiveAddresses,
         paired,
     }) {
+        const account = this.getAccount();
         let uriPrefix = 'bitcoin:';
         if (coinCode === 'ltc' || coinCode === 'tltc') {
             uriPrefix = 'litecoin:';
@@ -136,6 +162,13 @@ export default class Receive extends Component {
             <div>
                 <QRCode data={uriPrefix + receiveAddresses[activeIndex].address} />
                 <CopyableInput value={receiveAddresses[activeIndex].address} />
+                <Input
+                    label={t('receive.addressLabel')}
+                    id="addressLabel"
+                    placeholder={t('receive.addressLabelPlaceholder')}
+                    value={receiveAddresses[activeIndex].label}
+                    onInput={this.handleLabelInput}
+                    onBlur={this.saveLabel} />
                 <div class={['flex flex-row flex-center flex-items-center', style.labels].join(' ')}>
                     <Button
                         transparent
@@ -167,6 +200,14 @@ export default class Receive extends Component {
                         </div>
                     )
                 }
+                {
+                    account && account.faucetURL && (
+                        <p>
+                            {t('receive.faucet')}{' '}
+                            <A href={account.faucetURL}>{account.faucetURL}</A>
+                        </p>
+                    )
+                }
             </div>
         ) : (
             t('loading')
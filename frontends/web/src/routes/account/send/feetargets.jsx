@@ -38,7 +38,9 @@ export default class FeeTargets extends Component {
 
     updateFeeTargets = (accountCode) => {
         apiGet('account/' + accountCode + '/fee-targets').then(({ feeTargets, defaultFeeTarget }) => {
-            // feeTargets.push({code: 'custom'});
+            if (this.props.allowCustom) {
+                feeTargets.push({ code: 'custom' });
+            }
             this.setState({ feeTargets });
             this.setFeeTarget(defaultFeeTarget);
         });
@@ -82,10 +84,12 @@ export default class FeeTargets extends Component {
                 disabled={disabled}
                 onChange={this.handleFeeTargetChange}
                 selected={feeTarget}
-                options={feeTargets.map(({ code }) => {
+                options={feeTargets.map(({ code, priorityFeeGwei }) => {
                     return {
                         value: code,
-                        text: t(`send.feeTarget.label.${code}`),
+                        text: priorityFeeGwei
+                            ? `${t(`send.feeTarget.label.${code}`)} (${priorityFeeGwei} Gwei)`
+                            : t(`send.feeTarget.label.${code}`),
                     };
                 })} />
         );
@@ -19,6 +19,8 @@ import { route } from 'preact-router';
 import { translate } from 'react-i18next';
 import { apiGet, apiPost } from '../../../utils/request';
 import { apiWebsocket } from '../../../utils/websocket';
+import { apiSubscribe } from '../../../utils/event';
+import { isEVMBased, isEVMCoin } from '../utils';
 import { debug } from '../../../utils/env';
 import { Button, ButtonLink, Checkbox, Input } from '../../../components/forms';
 import { Guide } from '../../../components/guide/guide';
@@ -45,12 +47,15 @@ export default class Send extends Component {
             balance: null,
             amount: null,
             feeTarget: null,
+            customFee: '',
             proposedFee: null,
             proposedAmount: null,
             proposedTotal: null,
             valid: false,
             addressError: null,
+            addressWarnings: [],
             amountError: null,
+            feeError: null,
             sendAll: false,
             isConfirming: false,
             isSent: false,
@@ -59,11 +64,27 @@ export default class Send extends Component {
             fiatAmount: null,
             fiatUnit: fiat.state.active,
             signProgress: null,
+            signStage: null,
             signConfirm: null, // show visual BitBox in dialog when instructed to sign.
             coinControl: false,
             activeCoinControl: false,
+            payjoinEndpoint: null,
+            opReturnData: '',
+            opReturnError: null,
+            rbf: true,
+            ensName: null,
+            ensResolved: null,
+            nonce: '',
+            nonceQueue: null,
+            replacing: null,
+            data: '',
+            signedPSBT: null,
+            dataError: null,
+            gasLimit: '',
+            proposedData: null,
+            proposedGasLimit: null,
         };
-        this.selectedUTXOs = [];
+        this.selectedUTXOs = {};
     }
 
     componentDidMount() {
@@ -74,6 +95,7 @@ export default class Send extends Component {
             });
         }
         apiGet('config').then(config => this.setState({ coinControl: !!(config.frontend || {}).coinControl }));
+        this.loadNonceQueue();
         this.unsubscribe = apiWebsocket(({ type, data, meta }) => {
             switch (type) {
             case 'device':
@@ -88,6 +110,9 @@ export default class Send extends Component {
                 break;
             }
         });
+        this.unsubscribeSignStage = apiSubscribe(`account/${this.props.code}/sign-progress`, ({ object }) => {
+            this.setState({ signStage: object });
+        });
     }
 
     componentWillMount() {
@@ -99,6 +124,9 @@ export default class Send extends Component {
         if (this.unsubscribe) {
             this.unsubscribe();
         }
+        if (this.unsubscribeSignStage) {
+            this.unsubscribeSignStage();
+        }
     }
 
     registerEvents = () => {
@@ -116,8 +144,12 @@ export default class Send extends Component {
         }
     }
 
+    cancelSigning = () => {
+        apiPost(`account/${this.getAccount().code}/cancel-signing`);
+    }
+
     send = () => {
-        this.setState({ signProgress: null, isConfirming: true });
+        this.setState({ signProgress: null, signStage: null, isConfirming: true });
         apiPost('account/' + this.getAccount().code + '/sendtx', this.txInput()).then(result => {
             if (result.success) {
                 this.setState({
@@ -125,6 +157,14 @@ export default class Send extends Component {
                     isConfirming: false,
                     isSent: true,
                     recipientAddress: null,
+                    ensName: null,
+                    nonce: '',
+                    data: '',
+                    gasLimit: '',
+                    proposedData: null,
+                    proposedGasLimit: null,
+                    payjoinEndpoint: null,
+                    opReturnData: '',
                     proposedAmount: null,
                     proposedFee: null,
                     proposedTotal: null,
@@ -134,6 +174,7 @@ export default class Send extends Component {
                 if (this.utxos) {
                     this.utxos.getWrappedInstance().clear();
                 }
+                this.loadNonceQueue();
                 setTimeout(() => this.setState({ isSent: false, isConfirming: false }), 5000);
             } else {
                 this.setState({
@@ -142,9 +183,67 @@ export default class Send extends Component {
                 setTimeout(() => this.setState({ isAborted: false }), 5000);
             }
             // The following method allows pressing escape again.
-            this.setState({ isConfirming: false,signProgress: null, signConfirm: null });
+            this.setState({ isConfirming: false, signProgress: null, signStage: null, signConfirm: null });
         }).catch(() => {
-            this.setState({ isConfirming: false,signProgress: null, signConfirm: null });
+            this.setState({ isConfirming: false, signProgress: null, signStage: null, signConfirm: null });
+        });
+    }
+
+    exportPSBTFile = () => {
+        apiPost(`account/${this.getAccount().code}/psbt/export-file`, this.txInput()).then(({ success, filename, data, errorCode }) => {
+            if (!success) {
+                alertUser(errorCode ? this.props.t(`send.error.${errorCode}`) : this.props.t('send.psbtFile.exportFailed'));
+                return;
+            }
+            const bytes = Uint8Array.from(atob(data), c => c.charCodeAt(0));
+            const link = document.createElement('a');
+            link.href = URL.createObjectURL(new Blob([bytes], { type: 'application/octet-stream' }));
+            link.download = filename;
+            link.click();
+            URL.revokeObjectURL(link.href);
+            this.setState({ signedPSBT: null });
+        });
+    }
+
+    importPSBTFile = event => {
+        const file = event.target.files[0];
+        event.target.value = '';
+        if (!file) {
+            return;
+        }
+        const reader = new FileReader();
+        reader.onload = () => {
+            const bytes = new Uint8Array(reader.result);
+            let binary = '';
+            bytes.forEach(byte => {
+                binary += String.fromCharCode(byte);
+            });
+            apiPost(`account/${this.getAccount().code}/psbt/import-file`, { data: btoa(binary) }).then(result => {
+                if (!result.success) {
+                    alertUser(result.errorMessage);
+                    return;
+                }
+                if (!result.complete) {
+                    const signedCount = result.signed.filter(signed => signed).length;
+                    alertUser(this.props.t('send.psbtFile.incomplete', {
+                        signed: signedCount,
+                        threshold: result.signingThreshold,
+                    }));
+                }
+                this.setState({ signedPSBT: result });
+            });
+        };
+        reader.readAsArrayBuffer(file);
+    }
+
+    sendPSBT = () => {
+        apiPost(`account/${this.getAccount().code}/psbt/send`, { psbt: this.state.signedPSBT.psbt }).then(({ success, errorMessage }) => {
+            if (!success) {
+                alertUser(errorMessage);
+                return;
+            }
+            this.setState({ signedPSBT: null, isSent: true });
+            setTimeout(() => this.setState({ isSent: false }), 5000);
         });
     }
 
@@ -152,20 +251,46 @@ export default class Send extends Component {
         address: this.state.recipientAddress,
         amount: this.state.amount,
         feeTarget: this.state.feeTarget,
+        customFee: this.state.customFee,
         sendAll: this.state.sendAll ? 'yes' : 'no',
         selectedUTXOs: Object.keys(this.selectedUTXOs),
+        payjoinEndpoint: this.state.payjoinEndpoint,
+        opReturnData: this.state.opReturnData,
+        disableRBF: !this.state.rbf,
+        nonce: this.state.nonce === '' ? null : parseInt(this.state.nonce, 10),
+        data: this.state.data,
+        gasLimit: this.state.gasLimit === '' ? null : parseInt(this.state.gasLimit, 10),
     })
 
     sendDisabled = () => {
         const txInput = this.txInput();
-        return !txInput.address || !txInput.feeTarget || (txInput.sendAll === 'no' && !txInput.amount);
+        return !txInput.address || !txInput.feeTarget || (txInput.sendAll === 'no' && !txInput.amount)
+            || (txInput.feeTarget === 'custom' && !txInput.customFee) || !!this.state.opReturnError
+            || !!this.state.dataError;
+    }
+
+    addressWarnings = ({ warnings, recipientContractName }) => {
+        const { t } = this.props;
+        return (warnings || []).map(warning => {
+            switch (warning) {
+            case 'recipientIsContract':
+                return recipientContractName
+                    ? t('send.warning.recipientIsContractName', { name: recipientContractName })
+                    : t('send.warning.recipientIsContract');
+            default:
+                return t(`send.warning.${warning}`);
+            }
+        });
     }
 
     validateAndDisplayFee = updateFiat => {
         this.setState({
             proposedTotal: null,
             addressError: null,
+            addressWarnings: [],
             amountError: null,
+            feeError: null,
+            dustChange: null,
         });
         if (this.sendDisabled()) {
             return;
@@ -180,6 +305,10 @@ export default class Send extends Component {
                     proposedFee: result.fee,
                     proposedAmount: result.amount,
                     proposedTotal: result.total,
+                    dustChange: result.dustChange,
+                    proposedData: result.data,
+                    proposedGasLimit: result.gasLimit,
+                    addressWarnings: this.addressWarnings(result),
                 });
                 if (updateFiat) {
                     this.convertToFiat(result.amount.amount);
@@ -188,12 +317,29 @@ export default class Send extends Component {
                 const errorCode = result.errorCode;
                 switch (errorCode) {
                 case 'invalidAddress':
-                    this.setState({ addressError: this.props.t('send.error.invalidAddress') });
+                case 'invalidAddressChecksum':
+                    this.setState({ addressError: this.props.t(`send.error.${errorCode}`) });
                     break;
                 case 'invalidAmount':
                 case 'insufficientFunds':
+                case 'dustAmount':
                     this.setState({ amountError: this.props.t(`send.error.${errorCode}`) });
                     break;
+                case 'feeTooLow':
+                    this.setState({ feeError: this.props.t(this.isEthereum() ? 'send.error.feeTooLowEthereum' : 'send.error.feeTooLow') });
+                    break;
+                case 'invalidFeeRate':
+                case 'gasLimitTooLow':
+                    this.setState({ feeError: this.props.t(`send.error.${errorCode}`) });
+                    break;
+                case 'txReverted':
+                    this.setState({
+                        proposedFee: null,
+                        amountError: result.errorMessage
+                            ? this.props.t('send.error.txRevertedReason', { reason: result.errorMessage })
+                            : this.props.t('send.error.txReverted'),
+                    });
+                    break;
                 default:
                     this.setState({ proposedFee: null });
                     if (errorCode) {
@@ -212,17 +358,135 @@ export default class Send extends Component {
         if (event.target.type === 'checkbox') {
             value = event.target.checked;
         }
-        if (event.target.id === 'sendAll') {
+        if (event.target.id === 'recipientAddress' && value.includes(':')) {
+            this.parsePaymentURI(value);
+            return;
+        }
+        if (event.target.id === 'recipientAddress') {
+            this.setState({ payjoinEndpoint: null, ensName: null, ensResolved: null });
+            if (this.isEthereum() && /\.eth$/i.test(value.trim())) {
+                // The name is only replaced by its address after the user confirmed it.
+                this.setState({ recipientAddress: value, valid: false, proposedTotal: null, addressError: null });
+                this.resolveENSName(value);
+                return;
+            }
+            if (this.isEthereum() && /^0x[0-9a-fA-F]{40}$/.test(value.trim())) {
+                this.lookupENSName(value.trim());
+            }
+        } else if (event.target.id === 'sendAll') {
             if (!value) {
                 this.convertToFiat(this.state.amount);
             }
         } else if (event.target.id === 'amount') {
             this.convertToFiat(value);
+        } else if (event.target.id === 'nonce' || event.target.id === 'gasLimit') {
+            value = value.replace(/[^0-9]/g, '');
+        } else if (event.target.id === 'data') {
+            value = value.trim();
+            const valid = /^(0x)?([0-9a-fA-F]{2})*$/.test(value);
+            this.setState({ dataError: valid ? null : this.props.t('send.error.invalidData') });
+        } else if (event.target.id === 'opReturnData') {
+            // At most 80 bytes, hex encoded.
+            const valid = /^([0-9a-fA-F]{2}){0,80}$/.test(value);
+            this.setState({ opReturnError: valid ? null : this.props.t('send.error.invalidOpReturnData') });
         }
         this.setState({ [event.target.id]: value });
         this.validateAndDisplayFee(true);
     }
 
+    loadNonceQueue = () => {
+        if (!this.getAccount() || !this.isEthereum()) {
+            return;
+        }
+        apiGet(`account/${this.props.code}/nonces`).then(nonceQueue => {
+            if (nonceQueue.success) {
+                this.setState({ nonceQueue });
+            }
+        });
+    }
+
+    replaceTransaction = nonce => {
+        this.setState({ nonce: String(nonce) });
+        this.validateAndDisplayFee(true);
+    }
+
+    replacePending = (nonce, cancel) => {
+        this.setState({ signProgress: null, signStage: null, replacing: { nonce, cancel } });
+        apiPost('account/' + this.getAccount().code + '/replace-tx', { nonce, cancel }).then(({ success, errorMessage }) => {
+            if (success) {
+                this.setState({ isSent: true });
+                this.loadNonceQueue();
+                setTimeout(() => this.setState({ isSent: false }), 5000);
+            } else if (errorMessage) {
+                alertUser(errorMessage);
+            } else {
+                this.setState({ isAborted: true });
+                setTimeout(() => this.setState({ isAborted: false }), 5000);
+            }
+            this.setState({ replacing: null, signProgress: null, signStage: null, signConfirm: null });
+        }).catch(() => {
+            this.setState({ replacing: null, signProgress: null, signStage: null, signConfirm: null });
+        });
+    }
+
+    isEthereum = () => {
+        return isEVMBased(this.getAccount().coinCode);
+    }
+
+    resolveENSName = name => {
+        apiPost('account/' + this.getAccount().code + '/ens/resolve', name.trim()).then(({ success, address, errorMessage }) => {
+            if (this.state.recipientAddress !== name) {
+                return;
+            }
+            if (success) {
+                this.setState({ ensResolved: { name: name.trim().toLowerCase(), address } });
+            } else {
+                this.setState({ addressError: errorMessage });
+            }
+        });
+    }
+
+    useENSAddress = () => {
+        const { name, address } = this.state.ensResolved;
+        this.setState({ recipientAddress: address, ensName: name, ensResolved: null });
+        this.validateAndDisplayFee(true);
+    }
+
+    lookupENSName = address => {
+        apiPost('account/' + this.getAccount().code + '/ens/lookup', address).then(({ success, name }) => {
+            if (success && name && this.state.recipientAddress.trim() === address) {
+                this.setState({ ensName: name });
+            }
+        });
+    }
+
+    parsePaymentURI = uri => {
+        apiPost('account/' + this.getAccount().code + '/parse-payment-uri', uri).then(result => {
+            if (!result.success) {
+                this.setState({ recipientAddress: uri, addressError: this.props.t('send.error.invalidAddress') });
+                return;
+            }
+            this.setState({
+                recipientAddress: result.address,
+                payjoinEndpoint: result.params && result.params.pj ? result.params.pj : null,
+            });
+            if (result.amount) {
+                this.setState({ amount: result.amount, sendAll: false });
+                this.convertToFiat(result.amount);
+            }
+            if (result.gasLimit) {
+                this.setState({ gasLimit: String(result.gasLimit) });
+            }
+            if (this.isEthereum() && /\.eth$/i.test(result.address)) {
+                // EIP-681 URIs can contain an ENS name, which has to be confirmed like an entered one.
+                this.setState({ valid: false, proposedTotal: null, ensName: null, ensResolved: null });
+                this.resolveENSName(result.address);
+                return;
+            }
+            this.validateAndDisplayFee(true);
+        });
+    }
+
     handleFiatInput = event => {
         const value = event.target.value;
         this.setState({ fiatAmount: value });
@@ -231,8 +495,8 @@ export default class Send extends Component {
 
     convertToFiat = value => {
         if (value) {
-            let coinUnit = this.getAccount().coinCode.toUpperCase();
-            if (coinUnit.length === 4 && coinUnit.startsWith('T')) {
+            let coinUnit = this.getAccount().coinUnit;
+            if (!(fiat.state.rates || {})[coinUnit] && coinUnit.length === 4 && coinUnit.startsWith('T')) {
                 coinUnit = coinUnit.substring(1);
             }
             apiGet(`coins/convertToFiat?from=${coinUnit}&to=${this.state.fiatUnit}&amount=${value}`)
@@ -250,8 +514,8 @@ export default class Send extends Component {
 
     convertFromFiat = value => {
         if (value) {
-            let coinUnit = this.getAccount().coinCode.toUpperCase();
-            if (coinUnit.length === 4 && coinUnit.startsWith('T')) {
+            let coinUnit = this.getAccount().coinUnit;
+            if (!(fiat.state.rates || {})[coinUnit] && coinUnit.length === 4 && coinUnit.startsWith('T')) {
                 coinUnit = coinUnit.substring(1);
             }
             apiGet(`coins/convertFromFiat?from=${this.state.fiatUnit}&to=${coinUnit}&amount=${value}`)
@@ -318,16 +582,35 @@ export default class Send extends Component {
         fiatUnit,
         sendAll,
         feeTarget,
+        customFee,
         isConfirming,
         isSent,
         isAborted,
         addressError,
+        addressWarnings,
         amountError,
+        feeError,
+        dustChange,
         paired,
         signProgress,
+        signStage,
         signConfirm,
         coinControl,
         activeCoinControl,
+        opReturnData,
+        opReturnError,
+        rbf,
+        ensName,
+        ensResolved,
+        nonce,
+        nonceQueue,
+        replacing,
+        signedPSBT,
+        data,
+        dataError,
+        gasLimit,
+        proposedData,
+        proposedGasLimit,
     }) {
         const account = this.getAccount();
         if (!account) return null;
@@ -339,6 +622,13 @@ export default class Send extends Component {
                 })}<br />
                 {t('send.signprogress.label')}: {signProgress.step}/{signProgress.steps}
             </span>
+        ) : signStage ? (
+            <span>
+                {t(`send.signStage.${signStage.stage}`, {
+                    signatures: signStage.signatures,
+                    inputs: signStage.inputs,
+                })}
+            </span>
         ) : null;
         return (
             <div class="contentWithGuide">
@@ -379,6 +669,21 @@ export default class Send extends Component {
                                     value={recipientAddress}
                                     autofocus
                                 />
+                                {ensResolved && (
+                                    <div class={style.ensResolved}>
+                                        <p>{t('send.ens.resolved', { name: ensResolved.name })}</p>
+                                        <p class={style.ensAddress}>{ensResolved.address}</p>
+                                        <Button secondary onClick={this.useENSAddress}>
+                                            {t('send.ens.use')}
+                                        </Button>
+                                    </div>
+                                )}
+                                {ensName && !ensResolved && (
+                                    <p class={style.feeDescription}>{t('send.ens.name', { name: ensName })}</p>
+                                )}
+                                {addressWarnings.map(warning => (
+                                    <p class={style.feeDescription}>{warning}</p>
+                                ))}
                                 { debug && (
                                     <span id="sendToSelf" className={style.action} onClick={this.sendToSelf}>
                                         Send to self
@@ -406,7 +711,7 @@ export default class Send extends Component {
                                 </div>
                                 <div class="flex flex-1 flex-row flex-between flex-items-center spaced">
                                     <Checkbox
-                                        label={t('send.maximum')}
+                                        label={Object.keys(this.selectedUTXOs).length !== 0 ? t('send.maximumSelected') : t('send.maximum')}
                                         id="sendAll"
                                         onChange={this.sendAll}
                                         checked={sendAll}
@@ -420,23 +725,118 @@ export default class Send extends Component {
                                         placeholder={t('send.feeTarget.placeholder')}
                                         accountCode={account.code}
                                         disabled={!amount && !sendAll}
+                                        allowCustom
                                         onFeeTargetChange={this.feeTargetChange} />
                                     <Input
                                         label={t('send.fee.label')}
                                         value={proposedFee ? proposedFee.amount + ' ' + proposedFee.unit + (proposedFee.conversions ? ' = ' + proposedFee.conversions[fiatUnit] + ' ' + fiatUnit : '') : null}
-                                        placeholder={feeTarget === 'custom' ? t('send.fee.customPlaceholder') : t('send.fee.placeholder')}
-                                        disabled={feeTarget !==  'custom'}
-                                        transparent />
-                                    {/*
-                                    <Input
-                                        label={t('send.customFee.label')}
-                                        placeholder={t('send.customFee.placeholder')}
+                                        placeholder={t('send.fee.placeholder')}
                                         disabled
-                                    />
-                                    */}
+                                        transparent />
+                                    {feeTarget === 'custom' && (
+                                        <Input
+                                            label={t(this.isEthereum() ? 'send.customFee.labelEthereum' : 'send.customFee.label')}
+                                            id="customFee"
+                                            onInput={this.handleFormChange}
+                                            error={feeError}
+                                            value={customFee}
+                                            placeholder={t('send.customFee.placeholder')} />
+                                    )}
                                 </div>
-                                <p class={style.feeDescription}>{feeTarget && t('send.feeTarget.description.' + feeTarget) || ''}</p>
+                                <p class={style.feeDescription}>{feeTarget && t('send.feeTarget.description.' + feeTarget + (this.isEthereum() ? 'Ethereum' : '')) || ''}</p>
+                                {dustChange && (
+                                    <p class={style.feeDescription}>
+                                        {t('send.warning.dustChange', { amount: dustChange.amount, unit: dustChange.unit })}
+                                    </p>
+                                )}
                             </div>
+                            {coinControl && this.isEthereum() && (
+                                <div class="row">
+                                    <Input
+                                        label={t('send.nonce.label')}
+                                        id="nonce"
+                                        onInput={this.handleFormChange}
+                                        value={nonce}
+                                        placeholder={nonceQueue ? t('send.nonce.placeholder', { nonce: nonceQueue.pendingNonce }) : ''} />
+                                    {nonceQueue && nonceQueue.pendingNonce > nonceQueue.confirmedNonce && (
+                                        <div>
+                                            <p class={style.feeDescription}>
+                                                {t('send.nonce.pending', {
+                                                    count: nonceQueue.pendingNonce - nonceQueue.confirmedNonce,
+                                                    from: nonceQueue.confirmedNonce,
+                                                    to: nonceQueue.pendingNonce - 1,
+                                                })}
+                                            </p>
+                                            {nonceQueue.transactions.map(transaction => (
+                                                <div key={transaction.id} class="flex flex-row flex-between flex-items-center">
+                                                    <span class={style.feeDescription}>
+                                                        #{transaction.nonce}: {transaction.amount.amount} {transaction.amount.unit} → {transaction.recipient} ({transaction.priorityFee} Gwei)
+                                                    </span>
+                                                    <div class="buttons flex flex-row flex-end">
+                                                        <Button secondary onClick={() => this.replaceTransaction(transaction.nonce)}>
+                                                            {t('send.nonce.replace')}
+                                                        </Button>
+                                                        <Button secondary onClick={() => this.replacePending(transaction.nonce, false)}>
+                                                            {t('send.nonce.speedUp')}
+                                                        </Button>
+                                                        <Button secondary onClick={() => this.replacePending(transaction.nonce, true)}>
+                                                            {t('send.nonce.cancel')}
+                                                        </Button>
+                                                    </div>
+                                                </div>
+                                            ))}
+                                        </div>
+                                    )}
+                                    <Input
+                                        label={t('send.gasLimit.label')}
+                                        id="gasLimit"
+                                        onInput={this.handleFormChange}
+                                        value={gasLimit}
+                                        placeholder={proposedGasLimit ? t('send.gasLimit.placeholder', { gasLimit: proposedGasLimit }) : ''} />
+                                    {isEVMCoin(account.coinCode) && (
+                                        <Input
+                                            label={t('send.data.label')}
+                                            id="data"
+                                            onInput={this.handleFormChange}
+                                            error={dataError}
+                                            value={data}
+                                            placeholder={t('send.data.placeholder')} />
+                                    )}
+                                </div>
+                            )}
+                            {coinControl && !isEVMBased(account.coinCode) && (
+                                <div class="row">
+                                    <Input
+                                        label={t('send.opReturnData.label')}
+                                        id="opReturnData"
+                                        onInput={this.handleFormChange}
+                                        error={opReturnError}
+                                        value={opReturnData}
+                                        placeholder={t('send.opReturnData.placeholder')} />
+                                    <Checkbox
+                                        label={t('send.rbf')}
+                                        id="rbf"
+                                        onChange={this.handleFormChange}
+                                        checked={rbf} />
+                                </div>
+                            )}
+                            {!this.isEthereum() && (
+                                <div class="row">
+                                    <strong>{t('send.psbtFile.title')}</strong>
+                                    <div class="flex flex-row flex-between flex-items-center">
+                                        <Button secondary onClick={this.exportPSBTFile} disabled={this.sendDisabled() || !valid}>
+                                            {t('send.psbtFile.export')}
+                                        </Button>
+                                        <label>
+                                            {t('send.psbtFile.import')}
+                                            <input type="file" accept=".psbt,.txt" onChange={this.importPSBTFile} />
+                                        </label>
+                                        <Button primary onClick={this.sendPSBT} disabled={!signedPSBT || !signedPSBT.complete}>
+                                            {t('send.psbtFile.send')}
+                                        </Button>
+                                    </div>
+                                </div>
+                            )}
                             <div class="row buttons flex flex-row flex-between flex-start">
                                 <ButtonLink
                                     secondary
@@ -449,6 +849,16 @@ export default class Send extends Component {
                             </div>
                         </div>
                     </div>
+                    {
+                        replacing && (
+                            <WaitDialog
+                                title={t(replacing.cancel ? 'send.nonce.cancelTitle' : 'send.nonce.speedUpTitle', { nonce: replacing.nonce })}
+                                paired={paired}
+                                touchConfirm={signConfirm}
+                                onCancel={this.cancelSigning}
+                                includeDefault />
+                        )
+                    }
                     {
                         isConfirming && (
                             <WaitDialog
@@ -456,6 +866,7 @@ export default class Send extends Component {
                                 prequel={confirmPrequel}
                                 paired={paired}
                                 touchConfirm={signConfirm}
+                                onCancel={this.cancelSigning}
                                 includeDefault>
                                 <div class={style.confirmationBox}>
                                     <div class={style.block}>
@@ -463,6 +874,9 @@ export default class Send extends Component {
                                             {t('send.address.label')}
                                         </p>
                                         <p class={style.confirmationValue}>{recipientAddress || 'N/A'}</p>
+                                        {ensName && (
+                                            <p class={style.confirmationValue}>{ensName}</p>
+                                        )}
                                     </div>
                                     <div class={['flex flex-row flex-start', style.block, style.ignorePadding].join(' ')}>
                                         <div class={style.half}>
@@ -507,7 +921,19 @@ export default class Send extends Component {
                                         </div>
                                     </div>
                                     {
-                                        this.selectedUTXOs.length !== 0 && (
+                                        proposedData && (
+                                            <div class={style.block}>
+                                                <p class={['label', style.confirmationLabel].join(' ')}>
+                                                    {t('send.data.label')}
+                                                    {' '}
+                                                    ({t('send.gasLimit.label')}: {proposedGasLimit})
+                                                </p>
+                                                <p class={[style.confirmationValue, style.confirmationData].join(' ')}>{proposedData}</p>
+                                            </div>
+                                        )
+                                    }
+                                    {
+                                        Object.keys(this.selectedUTXOs).length !== 0 && (
                                             <div class={style.block}>
                                                 <p class={['label', style.confirmationLabel].join(' ')}>
                                                     {t('send.confirm.selected-coins')}
@@ -569,6 +995,9 @@ export default class Send extends Component {
                     <Entry key="guide.send.priority" entry={t('guide.send.priority')} />
                     <Entry key="guide.send.fee" entry={t('guide.send.fee')} />
                     <Entry key="guide.send.revert" entry={t('guide.send.revert')} />
+                    {account && !this.isEthereum() && (
+                        <Entry key="guide.send.psbtFile" entry={t('guide.send.psbtFile')} />
+                    )}
                 </Guide>
             </div>
         );
@@ -16,8 +16,8 @@
 
 import { Component, h } from 'preact';
 import { translate } from 'react-i18next';
-import { apiGet } from '../../../utils/request';
-import { Checkbox } from '../../../components/forms';
+import { apiGet, apiPost } from '../../../utils/request';
+import { Button, Checkbox, Input } from '../../../components/forms';
 import { FiatConversion } from '../../../components/rates/rates';
 import * as style from './utxos.css';
 
@@ -27,19 +27,51 @@ export default class UTXOs extends Component {
         super(props);
         this.state = {
             utxos: [],
-            selectedUTXOs: [],
+            selectedUTXOs: {},
+            // freezing is the outpoint of the output for which the freeze reason is entered.
+            freezing: null,
+            freezeReason: '',
         };
     }
 
     componentDidMount() {
+        this.loadUTXOs();
+    }
+
+    loadUTXOs = () => {
         apiGet(`account/${this.props.accountCode}/utxos`).then(utxos => {
             this.setState({ utxos });
         });
     }
 
+    startFreezing = outPoint => {
+        this.setState({ freezing: outPoint, freezeReason: '' });
+    }
+
+    handleFreezeReasonInput = event => {
+        this.setState({ freezeReason: event.target.value });
+    }
+
+    setFrozen = (outPoint, frozen) => {
+        apiPost(`account/${this.props.accountCode}/output-frozen`, {
+            outPoint,
+            frozen,
+            reason: frozen ? this.state.freezeReason : '',
+        }).then(() => {
+            this.setState({ freezing: null });
+            this.loadUTXOs();
+        });
+        if (frozen && this.state.selectedUTXOs[outPoint]) {
+            let selectedUTXOs = Object.assign({}, this.state.selectedUTXOs);
+            delete selectedUTXOs[outPoint];
+            this.setState({ selectedUTXOs });
+            this.props.onChange(selectedUTXOs);
+        }
+    }
+
     clear = () => {
-        this.setState({ selectedUTXOs: [] });
-        this.props.onChange(this.state.selectedUTXOs);
+        this.setState({ selectedUTXOs: {} });
+        this.props.onChange({});
     }
 
     handleUTXOChange = event => {
@@ -51,7 +83,8 @@ export default class UTXOs extends Component {
             delete selectedUTXOs[outPoint];
         }
         this.setState({ selectedUTXOs });
-        this.props.onChange(this.state.selectedUTXOs);
+        // The state is not updated yet.
+        this.props.onChange(selectedUTXOs);
     }
 
     render({
@@ -60,6 +93,8 @@ export default class UTXOs extends Component {
     }, {
         utxos,
         selectedUTXOs,
+        freezing,
+        freezeReason,
     }) {
         return (
             <div class="row">
@@ -73,6 +108,7 @@ export default class UTXOs extends Component {
                                             <td>
                                                 <Checkbox
                                                     checked={!!selectedUTXOs[utxo.outPoint]}
+                                                    disabled={utxo.frozen}
                                                     id={'utxo-' + utxo.outPoint}
                                                     data-outpoint={utxo.outPoint}
                                                     onChange={this.handleUTXOChange}
@@ -81,6 +117,36 @@ export default class UTXOs extends Component {
                                             <td>
                                                 <span><label>{t('send.coincontrol.outpoint')}:</label> {utxo.outPoint}</span>
                                                 <span><label>{t('send.coincontrol.address')}:</label> {utxo.address}</span>
+                                                {utxo.label && (
+                                                    <span><label>{t('send.coincontrol.label')}:</label> {utxo.label}</span>
+                                                )}
+                                                {utxo.frozen && (
+                                                    <span><label>{t('send.coincontrol.frozen')}:</label> {utxo.freezeReason || '-'}</span>
+                                                )}
+                                                {freezing === utxo.outPoint ? (
+                                                    <span>
+                                                        <Input
+                                                            id={'freeze-reason-' + utxo.outPoint}
+                                                            placeholder={t('send.coincontrol.freezeReason')}
+                                                            value={freezeReason}
+                                                            onInput={this.handleFreezeReasonInput} />
+                                                        <Button primary onClick={() => this.setFrozen(utxo.outPoint, true)}>
+                                                            {t('send.coincontrol.freeze')}
+                                                        </Button>
+                                                    </span>
+                                                ) : (
+                                                    <span>
+                                                        {utxo.frozen ? (
+                                                            <Button transparent onClick={() => this.setFrozen(utxo.outPoint, false)}>
+                                                                {t('send.coincontrol.unfreeze')}
+                                                            </Button>
+                                                        ) : (
+                                                            <Button transparent onClick={() => this.startFreezing(utxo.outPoint)}>
+                                                                {t('send.coincontrol.freeze')}
+                                                            </Button>
+                                                        )}
+                                                    </span>
+                                                )}
                                             </td>
                                             <td class={style.right}>
                                                 <table class={style.amountTable} align="right">
@@ -0,0 +1,155 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Component, h } from 'preact';
+import { route } from 'preact-router';
+import { translate } from 'react-i18next';
+import { Button, ButtonLink, Input } from '../../../components/forms';
+import { apiPost } from '../../../utils/request';
+import { alertUser } from '../../../components/alert/Alert';
+import { Guide } from '../../../components/guide/guide';
+import { Entry } from '../../../components/guide/entry';
+import Header from '../../../components/header/Header';
+import FeeTargets from '../send/feetargets';
+
+@translate()
+export default class Sweep extends Component {
+    state = {
+        wif: '',
+        feeTarget: null,
+        customFee: '',
+        proposal: null,
+        proposalError: null,
+        isSweeping: false,
+    }
+
+    sweepInput = () => {
+        const { wif, feeTarget, customFee } = this.state;
+        return { wif, feeTarget, customFee };
+    }
+
+    validateAndDisplayFee = () => {
+        const { wif, feeTarget, customFee } = this.state;
+        this.setState({ proposal: null, proposalError: null });
+        if (!wif || !feeTarget || (feeTarget === 'custom' && !customFee)) {
+            return;
+        }
+        apiPost(`account/${this.props.code}/sweep-proposal`, this.sweepInput())
+            .then(({ success, amount, fee, errorMessage }) => {
+                if (success) {
+                    this.setState({ proposal: { amount, fee } });
+                } else {
+                    this.setState({ proposalError: errorMessage });
+                }
+            });
+    }
+
+    handleFormChange = event => {
+        this.setState({ [event.target.id]: event.target.value }, this.validateAndDisplayFee);
+    }
+
+    feeTargetChange = feeTarget => {
+        this.setState({ feeTarget }, this.validateAndDisplayFee);
+    }
+
+    sweep = event => {
+        event.preventDefault();
+        this.setState({ isSweeping: true });
+        apiPost(`account/${this.props.code}/sweep`, this.sweepInput())
+            .then(({ success, errorMessage }) => {
+                this.setState({ isSweeping: false });
+                if (success) {
+                    alertUser(this.props.t('sweep.success'));
+                    route(`/account/${this.props.code}`);
+                } else {
+                    alertUser(errorMessage);
+                }
+            });
+    }
+
+    render({
+        t,
+        code,
+    }, {
+        wif,
+        feeTarget,
+        customFee,
+        proposal,
+        proposalError,
+        isSweeping,
+    }) {
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('sweep.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            <form onSubmit={this.sweep}>
+                                <Input
+                                    type="password"
+                                    id="wif"
+                                    label={t('sweep.wif.label')}
+                                    placeholder={t('sweep.wif.placeholder')}
+                                    error={proposalError}
+                                    onInput={this.handleFormChange}
+                                    value={wif}
+                                    autoComplete="off" />
+                                <FeeTargets
+                                    label={t('send.feeTarget.label')}
+                                    placeholder={t('send.feeTarget.placeholder')}
+                                    accountCode={code}
+                                    allowCustom
+                                    onFeeTargetChange={this.feeTargetChange} />
+                                {feeTarget === 'custom' && (
+                                    <Input
+                                        label={t('send.customFee.label')}
+                                        id="customFee"
+                                        onInput={this.handleFormChange}
+                                        value={customFee}
+                                        placeholder={t('send.customFee.placeholder')} />
+                                )}
+                                <Input
+                                    label={t('sweep.amount')}
+                                    value={proposal ? proposal.amount.amount + ' ' + proposal.amount.unit : null}
+                                    disabled
+                                    transparent />
+                                <Input
+                                    label={t('send.fee.label')}
+                                    value={proposal ? proposal.fee.amount + ' ' + proposal.fee.unit : null}
+                                    placeholder={t('send.fee.placeholder')}
+                                    disabled
+                                    transparent />
+                                <div class="flex flex-row flex-between">
+                                    <ButtonLink
+                                        secondary
+                                        href={`/account/${code}/info`}>
+                                        {t('button.back')}
+                                    </ButtonLink>
+                                    <Button type="submit" primary disabled={!proposal || isSweeping}>
+                                        {t('sweep.button')}
+                                    </Button>
+                                </div>
+                            </form>
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.sweep.what" entry={t('guide.sweep.what')} />
+                </Guide>
+            </div>
+        );
+    }
+}
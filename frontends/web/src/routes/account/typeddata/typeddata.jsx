@@ -0,0 +1,135 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Component, h } from 'preact';
+import { translate } from 'react-i18next';
+import { Button, ButtonLink } from '../../../components/forms';
+import { apiPost } from '../../../utils/request';
+import { alertUser } from '../../../components/alert/Alert';
+import { CopyableInput } from '../../../components/copy/Copy';
+import { Guide } from '../../../components/guide/guide';
+import { Entry } from '../../../components/guide/entry';
+import Header from '../../../components/header/Header';
+import * as style from '../../settings/settings.css';
+
+@translate()
+export default class TypedData extends Component {
+    state = {
+        typedData: '',
+        hashes: null,
+        signature: null,
+        isSigning: false,
+    }
+
+    handleFormChange = event => {
+        this.setState({
+            typedData: event.target.value,
+            hashes: null,
+            signature: null,
+        });
+    }
+
+    showHashes = () => {
+        apiPost(`account/${this.props.code}/typed-data/hash`, this.state.typedData)
+            .then(({ success, errorMessage, ...hashes }) => {
+                if (success) {
+                    this.setState({ hashes });
+                } else {
+                    alertUser(errorMessage);
+                }
+            });
+    }
+
+    sign = () => {
+        this.setState({ isSigning: true });
+        apiPost(`account/${this.props.code}/typed-data/sign`, this.state.typedData)
+            .then(({ success, signature, errorMessage }) => {
+                this.setState({ isSigning: false });
+                if (success) {
+                    this.setState({ signature });
+                } else if (errorMessage) {
+                    alertUser(errorMessage);
+                }
+            });
+    }
+
+    render({
+        t,
+        code,
+    }, {
+        typedData,
+        hashes,
+        signature,
+        isSigning,
+    }) {
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('typedData.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            <label>{t('typedData.typedData')}</label>
+                            <textarea
+                                id="typedData"
+                                class={style.textarea}
+                                rows={12}
+                                cols={80}
+                                onInput={this.handleFormChange}
+                                value={typedData}
+                                placeholder='{"types": {"EIP712Domain": [...], ...}, "primaryType": "...", "domain": {...}, "message": {...}}' />
+                            {hashes && (
+                                <div>
+                                    <p>{t('typedData.primaryType')}: {hashes.primaryType}</p>
+                                    <label>{t('typedData.domainSeparator')}</label>
+                                    <CopyableInput value={hashes.domainSeparator} />
+                                    <label>{t('typedData.messageHash')}</label>
+                                    <CopyableInput value={hashes.messageHash} />
+                                    <label>{t('typedData.signatureHash')}</label>
+                                    <CopyableInput value={hashes.signatureHash} />
+                                    <p>{t('typedData.verify')}</p>
+                                </div>
+                            )}
+                            {signature && (
+                                <div>
+                                    <label>{t('typedData.signature')}</label>
+                                    <CopyableInput value={signature} />
+                                </div>
+                            )}
+                            <div class="flex flex-row flex-between">
+                                <ButtonLink
+                                    secondary
+                                    href={`/account/${code}/info`}>
+                                    {t('button.back')}
+                                </ButtonLink>
+                                <div>
+                                    <Button secondary disabled={!typedData} onClick={this.showHashes}>
+                                        {t('typedData.showHash')}
+                                    </Button>
+                                    <Button primary disabled={!hashes || isSigning} onClick={this.sign}>
+                                        {t('typedData.sign')}
+                                    </Button>
+                                </div>
+                            </div>
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.typedData.what" entry={t('guide.typedData.what')} />
+                </Guide>
+            </div>
+        );
+    }
+}
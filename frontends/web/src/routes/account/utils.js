@@ -0,0 +1,32 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+// evmCoinCodes are the codes of Ether on Ethereum and on its layer-2 networks, and of the native
+// coins of the EVM sidechains.
+const evmCoinCodes = ['eth', 'sepeth', 'holeth', 'arbeth', 'opeth', 'baseeth', 'pol', 'xdai'];
+
+// isEVMCoin returns whether the coin code is the one of the native coin of an EVM chain.
+export function isEVMCoin(coinCode) {
+    return evmCoinCodes.includes(coinCode.toLowerCase());
+}
+
+// isEVMBased returns whether the coin code is the one of the native coin of an EVM chain or of
+// an ERC20 token on it.
+export function isEVMBased(coinCode) {
+    const code = coinCode.toLowerCase();
+    return evmCoinCodes.some(evmCode => code === evmCode || code.startsWith(`${evmCode}-`));
+}
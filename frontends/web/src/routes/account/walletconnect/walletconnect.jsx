@@ -0,0 +1,211 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Component, h } from 'preact';
+import { translate } from 'react-i18next';
+import { Button, ButtonLink, Input } from '../../../components/forms';
+import { apiGet, apiPost } from '../../../utils/request';
+import { apiWebsocket } from '../../../utils/websocket';
+import { setConfig } from '../../../utils/config';
+import { alertUser } from '../../../components/alert/Alert';
+import { Guide } from '../../../components/guide/guide';
+import { Entry } from '../../../components/guide/entry';
+import Header from '../../../components/header/Header';
+import * as style from '../../settings/settings.css';
+
+@translate()
+export default class WalletConnect extends Component {
+    state = {
+        uri: '',
+        projectID: null,
+        newProjectID: '',
+        proposals: [],
+        sessions: [],
+        requests: [],
+        busy: false,
+    }
+
+    componentDidMount() {
+        apiGet('config').then(config => this.setState({ projectID: config.backend.walletConnectProjectID || '' }));
+        this.load();
+        this.unsubscribe = apiWebsocket(this.onEvent);
+    }
+
+    componentWillUnmount() {
+        if (this.unsubscribe) {
+            this.unsubscribe();
+        }
+    }
+
+    onEvent = data => {
+        if (data.subject === 'walletconnect') {
+            this.load();
+        }
+    }
+
+    load = () => {
+        apiGet('walletconnect').then(({ proposals, sessions, requests }) => {
+            const code = this.props.code;
+            this.setState({
+                proposals: proposals.filter(proposal => proposal.accountCode === code),
+                sessions: sessions.filter(session => session.accountCode === code),
+                requests: requests.filter(request => request.accountCode === code),
+            });
+        });
+    }
+
+    handleResponse = ({ success, errorMessage }) => {
+        this.setState({ busy: false });
+        if (!success && errorMessage) {
+            alertUser(errorMessage);
+        }
+        this.load();
+        return success;
+    }
+
+    saveProjectID = () => {
+        const projectID = this.state.newProjectID.trim();
+        setConfig({ backend: { walletConnectProjectID: projectID } })
+            .then(() => this.setState({ projectID }));
+    }
+
+    pair = () => {
+        this.setState({ busy: true });
+        apiPost('walletconnect/pair', { accountCode: this.props.code, uri: this.state.uri.trim() })
+            .then(this.handleResponse)
+            .then(success => success && this.setState({ uri: '' }));
+    }
+
+    answerProposal = (id, approve) => {
+        this.setState({ busy: true });
+        apiPost('walletconnect/proposal', { id, approve }).then(this.handleResponse);
+    }
+
+    answerRequest = (id, approve) => {
+        this.setState({ busy: true });
+        apiPost('walletconnect/request', { id, approve }).then(this.handleResponse);
+    }
+
+    disconnect = topic => {
+        this.setState({ busy: true });
+        apiPost('walletconnect/disconnect', topic).then(this.handleResponse);
+    }
+
+    renderPeer = peer => (
+        <p>
+            <strong>{peer.name}</strong> {peer.url}
+        </p>
+    )
+
+    render({
+        t,
+        code,
+    }, {
+        uri,
+        projectID,
+        newProjectID,
+        proposals,
+        sessions,
+        requests,
+        busy,
+    }) {
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('walletConnect.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            {projectID === '' ? (
+                                <div>
+                                    <Input
+                                        label={t('walletConnect.projectID.label')}
+                                        placeholder={t('walletConnect.projectID.placeholder')}
+                                        onInput={e => this.setState({ newProjectID: e.target.value })}
+                                        value={newProjectID} />
+                                    <Button primary disabled={!newProjectID.trim()} onClick={this.saveProjectID}>
+                                        {t('button.save')}
+                                    </Button>
+                                </div>
+                            ) : (
+                                <div>
+                                    <Input
+                                        label={t('walletConnect.uri.label')}
+                                        placeholder="wc:..."
+                                        onInput={e => this.setState({ uri: e.target.value })}
+                                        value={uri} />
+                                    <Button primary disabled={!uri.trim() || busy} onClick={this.pair}>
+                                        {t('walletConnect.connect')}
+                                    </Button>
+                                </div>
+                            )}
+                            {proposals.length > 0 && <h3>{t('walletConnect.proposals')}</h3>}
+                            {proposals.map(proposal => (
+                                <div key={proposal.id}>
+                                    {this.renderPeer(proposal.peer)}
+                                    <Button primary disabled={busy} onClick={() => this.answerProposal(proposal.id, true)}>
+                                        {t('walletConnect.approve')}
+                                    </Button>
+                                    <Button secondary disabled={busy} onClick={() => this.answerProposal(proposal.id, false)}>
+                                        {t('walletConnect.reject')}
+                                    </Button>
+                                </div>
+                            ))}
+                            {requests.length > 0 && <h3>{t('walletConnect.requests')}</h3>}
+                            {requests.map(request => (
+                                <div key={request.id}>
+                                    {this.renderPeer(request.peer)}
+                                    <label>{request.method}</label>
+                                    <textarea
+                                        class={style.textarea}
+                                        rows={8}
+                                        cols={80}
+                                        readOnly
+                                        value={JSON.stringify(request.params, null, 2)} />
+                                    <Button primary disabled={busy} onClick={() => this.answerRequest(request.id, true)}>
+                                        {t('walletConnect.approve')}
+                                    </Button>
+                                    <Button secondary disabled={busy} onClick={() => this.answerRequest(request.id, false)}>
+                                        {t('walletConnect.reject')}
+                                    </Button>
+                                </div>
+                            ))}
+                            <h3>{t('walletConnect.sessions')}</h3>
+                            {sessions.length === 0 && <p>{t('walletConnect.noSessions')}</p>}
+                            {sessions.map(session => (
+                                <div key={session.topic}>
+                                    {this.renderPeer(session.peer)}
+                                    <Button secondary disabled={busy} onClick={() => this.disconnect(session.topic)}>
+                                        {t('walletConnect.disconnect')}
+                                    </Button>
+                                </div>
+                            ))}
+                            <div class="flex flex-row flex-between">
+                                <ButtonLink
+                                    secondary
+                                    href={`/account/${code}/info`}>
+                                    {t('button.back')}
+                                </ButtonLink>
+                            </div>
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.walletConnect.what" entry={t('guide.walletConnect.what')} />
+                </Guide>
+            </div>
+        );
+    }
+}
@@ -0,0 +1,98 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Component, h } from 'preact';
+import { route } from 'preact-router';
+import { translate } from 'react-i18next';
+import { Guide } from '../../components/guide/guide';
+import { Entry } from '../../components/guide/entry';
+import { Button, ButtonLink, Input } from '../../components/forms';
+import { apiPost } from '../../utils/request';
+import Header from '../../components/header/Header';
+import { alertUser } from '../../components/alert/Alert';
+
+@translate()
+export default class Airgapped extends Component {
+    state = {
+        descriptor: '',
+        busy: false,
+    }
+
+    handleFormChange = event => {
+        this.setState({ descriptor: event.target.value });
+    }
+
+    handleResult = ({ success, errorMessage }) => {
+        if (success) {
+            route('/', true);
+        } else {
+            alertUser(errorMessage);
+        }
+    }
+
+    register = () => {
+        this.setState({ busy: true });
+        apiPost('airgapped/register', { descriptor: this.state.descriptor.trim() }).then(result => {
+            this.setState({ busy: false });
+            this.handleResult(result);
+        });
+    }
+
+    deregister = () => {
+        apiPost('airgapped/deregister').then(this.handleResult);
+    }
+
+    render({
+        t,
+    }, {
+        descriptor,
+        busy,
+    }) {
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('settings.expert.airgapped.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            <p>{t('settings.expert.airgapped.description')}</p>
+                            <Input
+                                id="descriptor"
+                                label={t('settings.expert.airgapped.descriptor')}
+                                placeholder="wpkh([d34db33f/84h/0h/0h]xpub.../<0;1>/*)"
+                                onInput={this.handleFormChange}
+                                value={descriptor} />
+                            <div class="flex flex-row flex-between">
+                                <ButtonLink secondary href="/settings">{t('button.back')}</ButtonLink>
+                                <div>
+                                    <Button secondary onClick={this.deregister}>
+                                        {t('settings.expert.airgapped.deregister')}
+                                    </Button>
+                                    <Button primary disabled={busy || !descriptor.trim()} onClick={this.register}>
+                                        {t('settings.expert.airgapped.register')}
+                                    </Button>
+                                </div>
+                            </div>
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.settings-airgapped.what" entry={t('guide.settings-airgapped.what')} />
+                    <Entry key="guide.settings-airgapped.scanner" entry={t('guide.settings-airgapped.scanner')} />
+                </Guide>
+            </div>
+        );
+    }
+}
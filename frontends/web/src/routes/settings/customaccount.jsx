@@ -0,0 +1,136 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Component, h } from 'preact';
+import { route } from 'preact-router';
+import { translate } from 'react-i18next';
+import { Guide } from '../../components/guide/guide';
+import { Entry } from '../../components/guide/entry';
+import { Button, ButtonLink, Input, Select } from '../../components/forms';
+import { apiGet, apiPost } from '../../utils/request';
+import Header from '../../components/header/Header';
+import { alertUser } from '../../components/alert/Alert';
+
+@translate()
+export default class CustomAccount extends Component {
+    state = {
+        coins: null,
+        coinCode: '',
+        scriptType: '',
+        keypath: '',
+        name: '',
+    }
+
+    componentDidMount() {
+        apiGet('accounts/custom').then(coins => {
+            const coinCode = Object.keys(coins)[0] || '';
+            this.setState({
+                coins,
+                coinCode,
+                scriptType: (coins[coinCode] || [])[0] || '',
+            });
+        });
+    }
+
+    handleCoinChange = event => {
+        const coinCode = event.target.value;
+        this.setState({
+            coinCode,
+            scriptType: this.state.coins[coinCode][0] || '',
+        });
+    }
+
+    handleFormChange = event => {
+        this.setState({ [event.target.id]: event.target.value });
+    }
+
+    add = event => {
+        event.preventDefault();
+        const { coinCode, scriptType, keypath, name } = this.state;
+        apiPost('accounts/custom', { coinCode, scriptType, keypath, name })
+            .then(({ success, errorMessage }) => {
+                if (success) {
+                    route('/', true);
+                } else {
+                    alertUser(errorMessage);
+                }
+            });
+    }
+
+    render({
+        t,
+    }, {
+        coins,
+        coinCode,
+        scriptType,
+        keypath,
+        name,
+    }) {
+        if (!coins) return null;
+        const scriptTypes = coins[coinCode] || [];
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('settings.expert.customAccount.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            <form onSubmit={this.add}>
+                                <Select
+                                    id="coinCode"
+                                    label={t('settings.expert.customAccount.coin')}
+                                    options={Object.keys(coins).map(code => ({ value: code, text: code.toUpperCase() }))}
+                                    selected={coinCode}
+                                    onChange={this.handleCoinChange} />
+                                {scriptTypes.length > 0 && (
+                                    <Select
+                                        id="scriptType"
+                                        label={t('settings.expert.customAccount.scriptType')}
+                                        options={scriptTypes.map(type => ({ value: type, text: type }))}
+                                        selected={scriptType}
+                                        onChange={this.handleFormChange} />
+                                )}
+                                <Input
+                                    id="keypath"
+                                    label={t('settings.expert.customAccount.keypath')}
+                                    placeholder="m/84'/0'/0'"
+                                    onInput={this.handleFormChange}
+                                    value={keypath} />
+                                <Input
+                                    id="name"
+                                    label={t('settings.expert.customAccount.name')}
+                                    onInput={this.handleFormChange}
+                                    value={name} />
+                                <div class="flex flex-row flex-between">
+                                    <ButtonLink
+                                        secondary
+                                        href={`/settings`}>
+                                        {t('button.back')}
+                                    </ButtonLink>
+                                    <Button type="submit" primary disabled={!keypath}>
+                                        {t('settings.expert.customAccount.add')}
+                                    </Button>
+                                </div>
+                            </form>
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.settings-customAccount.what" entry={t('guide.settings-customAccount.what')} />
+                </Guide>
+            </div>
+        );
+    }
+}
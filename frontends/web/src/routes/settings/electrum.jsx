@@ -321,6 +321,11 @@ export default class ElectrumSettings extends Component {
                             <div class={['tab', activeTab === 'btc' ? 'active' : ''].join(' ')}>
                                 <a href="#" onClick={this.handleTab} data-tab="btc">{testing ? 'TBTC' : 'BTC'}</a>
                             </div>
+                            {testing && (
+                                <div class={['tab', activeTab === 'sbtc' ? 'active' : ''].join(' ')}>
+                                    <a href="#" onClick={this.handleTab} data-tab="sbtc">SBTC</a>
+                                </div>
+                            )}
                             <div class={['tab', activeTab === 'ltc' ? 'active' : ''].join(' ')}>
                                 <a href="#" onClick={this.handleTab} data-tab="ltc">{testing ? 'TLTC' : 'LTC'}</a>
                             </div>
@@ -334,6 +339,14 @@ export default class ElectrumSettings extends Component {
                                     />
                                 )
                             }
+                            {
+                                activeTab === 'sbtc' && (
+                                    <ElectrumServers
+                                        key="sbtc"
+                                        coin="sbtc"
+                                    />
+                                )
+                            }
                             {
                                 activeTab === 'ltc' && (
                                     <ElectrumServers
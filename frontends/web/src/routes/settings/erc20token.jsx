@@ -0,0 +1,115 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { Component, h } from 'preact';
+import { route } from 'preact-router';
+import { translate } from 'react-i18next';
+import { Guide } from '../../components/guide/guide';
+import { Entry } from '../../components/guide/entry';
+import { Button, ButtonLink, Input, Select } from '../../components/forms';
+import { apiGet, apiPost } from '../../utils/request';
+import Header from '../../components/header/Header';
+import { alertUser } from '../../components/alert/Alert';
+
+@translate()
+export default class ERC20Token extends Component {
+    state = {
+        coins: null,
+        coinCode: '',
+        contract: '',
+        adding: false,
+    }
+
+    componentDidMount() {
+        apiGet('accounts/erc20').then(coins => {
+            this.setState({ coins, coinCode: coins[0] || '' });
+        });
+    }
+
+    handleFormChange = event => {
+        this.setState({ [event.target.id]: event.target.value });
+    }
+
+    add = event => {
+        event.preventDefault();
+        const { coinCode, contract } = this.state;
+        this.setState({ adding: true });
+        apiPost('accounts/erc20', { coinCode, contract })
+            .then(({ success, errorMessage }) => {
+                this.setState({ adding: false });
+                if (success) {
+                    route('/', true);
+                } else {
+                    alertUser(errorMessage);
+                }
+            });
+    }
+
+    render({
+        t,
+    }, {
+        coins,
+        coinCode,
+        contract,
+        adding,
+    }) {
+        if (!coins) return null;
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('settings.expert.erc20Token.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            {coins.length === 0 ? (
+                                <p>{t('settings.expert.erc20Token.unavailable')}</p>
+                            ) : (
+                                <form onSubmit={this.add}>
+                                    <Select
+                                        id="coinCode"
+                                        label={t('settings.expert.erc20Token.coin')}
+                                        options={coins.map(code => ({ value: code, text: code.toUpperCase() }))}
+                                        selected={coinCode}
+                                        onChange={this.handleFormChange} />
+                                    <Input
+                                        id="contract"
+                                        label={t('settings.expert.erc20Token.contract')}
+                                        placeholder="0x..."
+                                        onInput={this.handleFormChange}
+                                        value={contract} />
+                                    <div class="flex flex-row flex-between">
+                                        <ButtonLink
+                                            secondary
+                                            href={`/settings`}>
+                                            {t('button.back')}
+                                        </ButtonLink>
+                                        <Button type="submit" primary disabled={!contract || adding}>
+                                            {t('settings.expert.erc20Token.add')}
+                                        </Button>
+                                    </div>
+                                </form>
+                            )}
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.settings-erc20Token.what" entry={t('guide.settings-erc20Token.what')} />
+                    <Entry key="guide.settings-erc20Token.fee" entry={t('guide.settings-erc20Token.fee')} />
+                </Guide>
+            </div>
+        );
+    }
+}
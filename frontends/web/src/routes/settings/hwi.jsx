@@ -0,0 +1,141 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { Component, h } from 'preact';
+import { route } from 'preact-router';
+import { translate } from 'react-i18next';
+import { Guide } from '../../components/guide/guide';
+import { Entry } from '../../components/guide/entry';
+import { Button, ButtonLink, Input } from '../../components/forms';
+import { apiGet, apiPost } from '../../utils/request';
+import { setConfig } from '../../utils/config';
+import Header from '../../components/header/Header';
+import { alertUser } from '../../components/alert/Alert';
+
+@translate()
+export default class HWI extends Component {
+    state = {
+        hwiPath: '',
+        devices: null,
+        busy: false,
+    }
+
+    componentDidMount() {
+        apiGet('config').then(config => this.setState({ hwiPath: config.backend.hwiPath || '' }));
+    }
+
+    handleFormChange = event => {
+        this.setState({ hwiPath: event.target.value });
+    }
+
+    handleResult = ({ success, errorMessage }) => {
+        if (success) {
+            route('/', true);
+        } else {
+            alertUser(errorMessage);
+        }
+    }
+
+    savePath = () => {
+        setConfig({ backend: { hwiPath: this.state.hwiPath.trim() } }).then(this.enumerate);
+    }
+
+    enumerate = () => {
+        this.setState({ busy: true });
+        apiGet('hwi/devices').then(({ success, devices, errorMessage }) => {
+            this.setState({ busy: false, devices: success ? devices : null });
+            if (!success) {
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    register = fingerprint => {
+        this.setState({ busy: true });
+        apiPost('hwi/register', { fingerprint }).then(result => {
+            this.setState({ busy: false });
+            this.handleResult(result);
+        });
+    }
+
+    deregister = () => {
+        apiPost('hwi/deregister').then(this.handleResult);
+    }
+
+    render({
+        t,
+    }, {
+        hwiPath,
+        devices,
+        busy,
+    }) {
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('settings.expert.hwi.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            <Input
+                                id="hwiPath"
+                                label={t('settings.expert.hwi.path')}
+                                placeholder="/usr/local/bin/hwi"
+                                onInput={this.handleFormChange}
+                                value={hwiPath} />
+                            <div class="flex flex-row flex-between">
+                                <ButtonLink secondary href="/settings">{t('button.back')}</ButtonLink>
+                                <div>
+                                    <Button secondary onClick={this.deregister}>
+                                        {t('settings.expert.hwi.deregister')}
+                                    </Button>
+                                    <Button primary disabled={busy || !hwiPath.trim()} onClick={this.savePath}>
+                                        {t('settings.expert.hwi.enumerate')}
+                                    </Button>
+                                </div>
+                            </div>
+                            {
+                                devices && (devices.length === 0 ? (
+                                    <p>{t('settings.expert.hwi.noDevices')}</p>
+                                ) : devices.map(device => (
+                                    <div class="flex flex-row flex-between flex-items-center" key={device.path}>
+                                        <p>
+                                            <strong>{device.model || device.type}</strong> {device.fingerprint}
+                                            {
+                                                (device.needs_pin_sent || device.needs_passphrase_sent) && (
+                                                    <span> ({t('settings.expert.hwi.locked')})</span>
+                                                )
+                                            }
+                                            {device.error && <span> ({device.error})</span>}
+                                        </p>
+                                        <Button
+                                            primary
+                                            disabled={busy || !device.fingerprint || device.needs_pin_sent || device.needs_passphrase_sent}
+                                            onClick={() => this.register(device.fingerprint)}>
+                                            {t('settings.expert.hwi.use')}
+                                        </Button>
+                                    </div>
+                                )))
+                            }
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.settings-hwi.what" entry={t('guide.settings-hwi.what')} />
+                </Guide>
+            </div>
+        );
+    }
+}
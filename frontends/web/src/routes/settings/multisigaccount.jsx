@@ -0,0 +1,138 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Component, h } from 'preact';
+import { route } from 'preact-router';
+import { translate } from 'react-i18next';
+import { Guide } from '../../components/guide/guide';
+import { Entry } from '../../components/guide/entry';
+import { Button, ButtonLink, Input, Select } from '../../components/forms';
+import { apiGet, apiPost } from '../../utils/request';
+import Header from '../../components/header/Header';
+import { alertUser } from '../../components/alert/Alert';
+import * as style from './settings.css';
+
+@translate()
+export default class MultisigAccount extends Component {
+    state = {
+        coins: null,
+        coinCode: '',
+        keypath: '',
+        threshold: '2',
+        cosigners: '',
+        name: '',
+    }
+
+    componentDidMount() {
+        apiGet('accounts/multisig').then(coins => {
+            this.setState({ coins, coinCode: coins[0] || '' });
+        });
+    }
+
+    handleFormChange = event => {
+        this.setState({ [event.target.id]: event.target.value });
+    }
+
+    add = event => {
+        event.preventDefault();
+        const { coinCode, keypath, threshold, cosigners, name } = this.state;
+        apiPost('accounts/multisig', {
+            coinCode,
+            keypath,
+            threshold: parseInt(threshold, 10),
+            cosigners: cosigners.split('\n'),
+            name,
+        }).then(({ success, errorMessage }) => {
+            if (success) {
+                route('/', true);
+            } else {
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    render({
+        t,
+    }, {
+        coins,
+        coinCode,
+        keypath,
+        threshold,
+        cosigners,
+        name,
+    }) {
+        if (!coins) return null;
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('settings.expert.multisigAccount.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            <form onSubmit={this.add}>
+                                <Select
+                                    id="coinCode"
+                                    label={t('settings.expert.multisigAccount.coin')}
+                                    options={coins.map(code => ({ value: code, text: code.toUpperCase() }))}
+                                    selected={coinCode}
+                                    onChange={this.handleFormChange} />
+                                <Input
+                                    id="keypath"
+                                    label={t('settings.expert.multisigAccount.keypath')}
+                                    placeholder="m/48'/0'/0'/2'"
+                                    onInput={this.handleFormChange}
+                                    value={keypath} />
+                                <Input
+                                    id="threshold"
+                                    type="number"
+                                    min="1"
+                                    label={t('settings.expert.multisigAccount.threshold')}
+                                    onInput={this.handleFormChange}
+                                    value={threshold} />
+                                <label>{t('settings.expert.multisigAccount.cosigners')}</label>
+                                <textarea
+                                    id="cosigners"
+                                    class={style.textarea}
+                                    rows={5}
+                                    cols={80}
+                                    onInput={this.handleFormChange}
+                                    value={cosigners}
+                                    placeholder="xpub..." />
+                                <Input
+                                    id="name"
+                                    label={t('settings.expert.multisigAccount.name')}
+                                    onInput={this.handleFormChange}
+                                    value={name} />
+                                <div class="flex flex-row flex-between">
+                                    <ButtonLink
+                                        secondary
+                                        href={`/settings`}>
+                                        {t('button.back')}
+                                    </ButtonLink>
+                                    <Button type="submit" primary disabled={!keypath || !cosigners.trim()}>
+                                        {t('settings.expert.multisigAccount.add')}
+                                    </Button>
+                                </div>
+                            </form>
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.settings-multisigAccount.what" entry={t('guide.settings-multisigAccount.what')} />
+                </Guide>
+            </div>
+        );
+    }
+}
@@ -59,6 +59,24 @@ export default class Settings extends Component {
             .then(config => this.setState({ config }));
     }
 
+    handleToggleTxOrdering = event => {
+        setConfig({
+            backend: {
+                txOrdering: event.target.checked ? 'random' : 'bip69'
+            }
+        })
+            .then(config => this.setState({ config, accountSuccess: true }));
+    }
+
+    handleToggleEthereumKeypathScheme = event => {
+        setConfig({
+            backend: {
+                ethereumKeypathScheme: event.target.checked ? 'account' : ''
+            }
+        })
+            .then(config => this.setState({ config, accountSuccess: true }));
+    }
+
     render({
         t,
     }, {
@@ -95,6 +113,12 @@ export default class Settings extends Component {
                                                     onChange={this.handleToggleAccount}
                                                     label={t('settings.accounts.bitcoinP2WPKH')}
                                                     className="text-medium" />
+                                                <Checkbox
+                                                    checked={config.backend.bitcoinP2TRActive}
+                                                    id="bitcoinP2TRActive"
+                                                    onChange={this.handleToggleAccount}
+                                                    label={t('settings.accounts.bitcoinP2TR')}
+                                                    className="text-medium" />
                                                 <Checkbox
                                                     checked={config.backend.bitcoinP2PKHActive}
                                                     id="bitcoinP2PKHActive"
@@ -132,9 +156,58 @@ export default class Settings extends Component {
                                                     label={t('settings.expert.coinControl')}
                                                     className="text-medium" />
                                             </div>
+                                            <div>
+                                                <Checkbox
+                                                    checked={config.backend.txOrdering === 'random'}
+                                                    id="txOrdering"
+                                                    onChange={this.handleToggleTxOrdering}
+                                                    label={t('settings.expert.randomTxOrdering')}
+                                                    className="text-medium" />
+                                            </div>
+                                            <div>
+                                                <Checkbox
+                                                    checked={config.backend.ethereumKeypathScheme === 'account'}
+                                                    id="ethereumKeypathScheme"
+                                                    onChange={this.handleToggleEthereumKeypathScheme}
+                                                    label={t('settings.expert.ethereumKeypathScheme')}
+                                                    className="text-medium" />
+                                            </div>
                                             <div>
                                                 <ButtonLink primary href="/settings/electrum">{t('settings.expert.electrum.title')}</ButtonLink>
                                             </div>
+                                            <div>
+                                                <ButtonLink primary href="/settings/custom-account">{t('settings.expert.customAccount.title')}</ButtonLink>
+                                            </div>
+                                            <div>
+                                                <ButtonLink primary href="/settings/erc20-token">{t('settings.expert.erc20Token.title')}</ButtonLink>
+                                            </div>
+                                            <div>
+                                                <ButtonLink primary href="/settings/multisig-account">{t('settings.expert.multisigAccount.title')}</ButtonLink>
+                                            </div>
+                                            <div>
+                                                <ButtonLink primary href="/settings/watch-only-account">{t('settings.expert.watchOnlyAccount.title')}</ButtonLink>
+                                            </div>
+                                            <div>
+                                                <Checkbox
+                                                    checked={config.backend.softwareKeystoreEnabled}
+                                                    id="softwareKeystoreEnabled"
+                                                    onChange={this.handleToggleAccount}
+                                                    label={t('settings.expert.softwareKeystoreEnabled')}
+                                                    className="text-medium" />
+                                            </div>
+                                            {
+                                                config.backend.softwareKeystoreEnabled && (
+                                                    <div>
+                                                        <ButtonLink primary href="/settings/software-keystore">{t('settings.expert.softwareKeystore.title')}</ButtonLink>
+                                                    </div>
+                                                )
+                                            }
+                                            <div>
+                                                <ButtonLink primary href="/settings/hwi">{t('settings.expert.hwi.title')}</ButtonLink>
+                                            </div>
+                                            <div>
+                                                <ButtonLink primary href="/settings/airgapped">{t('settings.expert.airgapped.title')}</ButtonLink>
+                                            </div>
                                         </div>
                                         {
                                             accountSuccess && (
@@ -160,6 +233,11 @@ export default class Settings extends Component {
                     <Entry key="guide.settings.btc-p2sh" entry={t('guide.settings.btc-p2sh')} />
                     <Entry key="guide.settings.btc-p2wpkh" entry={t('guide.settings.btc-p2wpkh')} />
                     <Entry key="guide.settings.servers" entry={t('guide.settings.servers')} />
+                    <Entry key="guide.settings.txOrdering" entry={t('guide.settings.txOrdering')} />
+                    <Entry key="guide.settings.ethereumKeypathScheme" entry={t('guide.settings.ethereumKeypathScheme')} />
+                    <Entry key="guide.settings.softwareKeystore" entry={t('guide.settings.softwareKeystore')} />
+                    <Entry key="guide.settings.hwi" entry={t('guide.settings.hwi')} />
+                    <Entry key="guide.settings.airgapped" entry={t('guide.settings.airgapped')} />
                     <Entry key="guide.settings.moreCoins" entry={t('guide.settings.moreCoins')} />
                 </Guide>
             </div>
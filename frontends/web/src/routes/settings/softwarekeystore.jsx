@@ -0,0 +1,206 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+import { Component, h } from 'preact';
+import { route } from 'preact-router';
+import { translate } from 'react-i18next';
+import { Guide } from '../../components/guide/guide';
+import { Entry } from '../../components/guide/entry';
+import { Button, ButtonLink, Input, Select } from '../../components/forms';
+import { apiGet, apiPost } from '../../utils/request';
+import Header from '../../components/header/Header';
+import { alertUser } from '../../components/alert/Alert';
+import * as style from './settings.css';
+
+@translate()
+export default class SoftwareKeystore extends Component {
+    state = {
+        status: null,
+        mnemonic: '',
+        passphrase: '',
+        password: '',
+        bip85Words: '12',
+        bip85Index: '0',
+        bip85Mnemonic: null,
+    }
+
+    componentDidMount() {
+        apiGet('software-keystore').then(status => this.setState({ status }));
+    }
+
+    handleFormChange = event => {
+        this.setState({ [event.target.id]: event.target.value });
+    }
+
+    handleResult = ({ success, errorCode, errorMessage }) => {
+        if (success) {
+            route('/', true);
+        } else if (errorCode) {
+            alertUser(this.props.t(`settings.expert.softwareKeystore.error.${errorCode}`));
+        } else {
+            alertUser(errorMessage);
+        }
+    }
+
+    create = event => {
+        event.preventDefault();
+        const { mnemonic, passphrase, password } = this.state;
+        apiPost('software-keystore/create', { mnemonic, passphrase, password }).then(this.handleResult);
+    }
+
+    unlock = event => {
+        event.preventDefault();
+        apiPost('software-keystore/unlock', { password: this.state.password }).then(this.handleResult);
+    }
+
+    handleBIP85WordsChange = event => {
+        this.setState({ bip85Words: event.target.value, bip85Mnemonic: null });
+    }
+
+    handleBIP85IndexChange = event => {
+        this.setState({ bip85Index: event.target.value, bip85Mnemonic: null });
+    }
+
+    deriveBIP85 = event => {
+        event.preventDefault();
+        const { bip85Words, bip85Index } = this.state;
+        apiPost('software-keystore/bip85', { words: Number(bip85Words), index: Number(bip85Index) })
+            .then(({ success, mnemonic, errorMessage }) => {
+                if (success) {
+                    this.setState({ bip85Mnemonic: mnemonic });
+                } else {
+                    alertUser(errorMessage);
+                }
+            });
+    }
+
+    lock = () => {
+        apiPost('software-keystore/lock').then(this.handleResult);
+    }
+
+    render({
+        t,
+    }, {
+        status,
+        mnemonic,
+        passphrase,
+        password,
+        bip85Words,
+        bip85Index,
+        bip85Mnemonic,
+    }) {
+        if (!status) return null;
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('settings.expert.softwareKeystore.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            {
+                                status.unlocked ? (
+                                    <div>
+                                        <form onSubmit={this.deriveBIP85}>
+                                            <Select
+                                                id="bip85Words"
+                                                label={t('settings.expert.softwareKeystore.bip85.words')}
+                                                options={['12', '18', '24'].map(words => ({ value: words, text: words }))}
+                                                selected={bip85Words}
+                                                onChange={this.handleBIP85WordsChange} />
+                                            <Input
+                                                type="number"
+                                                min="0"
+                                                id="bip85Index"
+                                                label={t('settings.expert.softwareKeystore.bip85.index')}
+                                                onInput={this.handleBIP85IndexChange}
+                                                value={bip85Index} />
+                                            {
+                                                bip85Mnemonic && (
+                                                    <textarea
+                                                        class={style.textarea}
+                                                        rows={3}
+                                                        cols={80}
+                                                        value={bip85Mnemonic}
+                                                        readOnly />
+                                                )
+                                            }
+                                            <Button type="submit" secondary disabled={bip85Index === ''}>
+                                                {t('settings.expert.softwareKeystore.bip85.derive')}
+                                            </Button>
+                                        </form>
+                                        <div class="flex flex-row flex-between">
+                                            <ButtonLink secondary href="/settings">{t('button.back')}</ButtonLink>
+                                            <Button primary onClick={this.lock}>
+                                                {t('settings.expert.softwareKeystore.lock')}
+                                            </Button>
+                                        </div>
+                                    </div>
+                                ) : (
+                                    <form onSubmit={status.exists ? this.unlock : this.create}>
+                                        {
+                                            !status.exists && (
+                                                <div>
+                                                    <label>{t('settings.expert.softwareKeystore.mnemonic')}</label>
+                                                    <textarea
+                                                        id="mnemonic"
+                                                        class={style.textarea}
+                                                        rows={3}
+                                                        cols={80}
+                                                        onInput={this.handleFormChange}
+                                                        value={mnemonic}
+                                                        autoComplete="off" />
+                                                    <Input
+                                                        type="password"
+                                                        id="passphrase"
+                                                        label={t('settings.expert.softwareKeystore.passphrase')}
+                                                        onInput={this.handleFormChange}
+                                                        value={passphrase}
+                                                        autoComplete="off" />
+                                                </div>
+                                            )
+                                        }
+                                        <Input
+                                            type="password"
+                                            id="password"
+                                            label={t('settings.expert.softwareKeystore.password')}
+                                            onInput={this.handleFormChange}
+                                            value={password}
+                                            autoComplete="off" />
+                                        <div class="flex flex-row flex-between">
+                                            <ButtonLink secondary href="/settings">{t('button.back')}</ButtonLink>
+                                            <Button
+                                                type="submit"
+                                                primary
+                                                disabled={!password || (!status.exists && !mnemonic.trim())}>
+                                                {t(status.exists
+                                                    ? 'settings.expert.softwareKeystore.unlock'
+                                                    : 'settings.expert.softwareKeystore.create')}
+                                            </Button>
+                                        </div>
+                                    </form>
+                                )
+                            }
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.settings-softwareKeystore.what" entry={t('guide.settings-softwareKeystore.what')} />
+                    <Entry key="guide.settings-softwareKeystore.bip85" entry={t('guide.settings-softwareKeystore.bip85')} />
+                </Guide>
+            </div>
+        );
+    }
+}
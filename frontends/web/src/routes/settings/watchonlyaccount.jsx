@@ -0,0 +1,115 @@
+/**
+ * Copyright 2018 Shift Devices AG
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+import { Component, h } from 'preact';
+import { route } from 'preact-router';
+import { translate } from 'react-i18next';
+import { Guide } from '../../components/guide/guide';
+import { Entry } from '../../components/guide/entry';
+import { Button, ButtonLink, Input, Select } from '../../components/forms';
+import { apiGet, apiPost } from '../../utils/request';
+import Header from '../../components/header/Header';
+import { alertUser } from '../../components/alert/Alert';
+import * as style from './settings.css';
+
+@translate()
+export default class WatchOnlyAccount extends Component {
+    state = {
+        coins: null,
+        coinCode: '',
+        xpub: '',
+        name: '',
+    }
+
+    componentDidMount() {
+        apiGet('accounts/watch-only').then(coins => {
+            this.setState({ coins, coinCode: coins[0] || '' });
+        });
+    }
+
+    handleFormChange = event => {
+        this.setState({ [event.target.id]: event.target.value });
+    }
+
+    add = event => {
+        event.preventDefault();
+        const { coinCode, xpub, name } = this.state;
+        apiPost('accounts/watch-only', { coinCode, xpub, name }).then(({ success, errorMessage }) => {
+            if (success) {
+                route('/', true);
+            } else {
+                alertUser(errorMessage);
+            }
+        });
+    }
+
+    render({
+        t,
+    }, {
+        coins,
+        coinCode,
+        xpub,
+        name,
+    }) {
+        if (!coins) return null;
+        return (
+            <div class="contentWithGuide">
+                <div class="container">
+                    <Header title={<h2>{t('settings.expert.watchOnlyAccount.title')}</h2>} {...this.props} />
+                    <div class="innerContainer scrollableContainer">
+                        <div class="content padded">
+                            <form onSubmit={this.add}>
+                                <Select
+                                    id="coinCode"
+                                    label={t('settings.expert.watchOnlyAccount.coin')}
+                                    options={coins.map(code => ({ value: code, text: code.toUpperCase() }))}
+                                    selected={coinCode}
+                                    onChange={this.handleFormChange} />
+                                <label>{t('settings.expert.watchOnlyAccount.xpub')}</label>
+                                <textarea
+                                    id="xpub"
+                                    class={style.textarea}
+                                    rows={3}
+                                    cols={80}
+                                    onInput={this.handleFormChange}
+                                    value={xpub}
+                                    placeholder="zpub... / wpkh([fingerprint/84h/0h/0h]xpub.../0/*) / wsh(sortedmulti(2,...))" />
+                                <Input
+                                    id="name"
+                                    label={t('settings.expert.watchOnlyAccount.name')}
+                                    onInput={this.handleFormChange}
+                                    value={name} />
+                                <div class="flex flex-row flex-between">
+                                    <ButtonLink
+                                        secondary
+                                        href={`/settings`}>
+                                        {t('button.back')}
+                                    </ButtonLink>
+                                    <Button type="submit" primary disabled={!xpub.trim()}>
+                                        {t('settings.expert.watchOnlyAccount.add')}
+                                    </Button>
+                                </div>
+                            </form>
+                        </div>
+                    </div>
+                </div>
+                <Guide>
+                    <Entry key="guide.settings-watchOnlyAccount.what" entry={t('guide.settings-watchOnlyAccount.what')} />
+                </Guide>
+            </div>
+        );
+    }
+}
@@ -70,6 +70,8 @@ docker run \
        --name=electrumx-regtest \
        lukechilds/electrumx &
 
+echo "Start the backend against the local Electrum server with:"
+echo "    make servewallet-regtest (or: servewallet -regtest -regtestserver 127.0.0.1:52001)"
 echo "Interact with the regtest chain (e.g. generate 101 blocks and send coins):"
 echo "    bitcoin-cli -regtest -datadir=${BITCOIN_DATADIR} -rpcuser=dbb -rpcpassword=dbb -rpcport=10332 generate 101"
 echo "    bitcoin-cli -regtest -datadir=${BITCOIN_DATADIR} -rpcuser=dbb -rpcpassword=dbb -rpcport=10332 sendtoaddress <address> <amount>"
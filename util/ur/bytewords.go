@@ -0,0 +1,89 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ur
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// bytewords are the words encoding the bytes 0 to 255 (BCR-2020-012). In the minimal encoding
+// used by URs, every byte is encoded by the first and the last letter of its word.
+var bytewords = strings.Fields(`
+	able acid also apex aqua arch atom aunt away axis back bald barn belt beta bias
+	blue body brag brew bulb buzz calm cash cats chef city claw code cola cook cost
+	crux curl cusp cyan dark data days deli dice diet door down draw drop drum dull
+	duty each easy echo edge epic even exam exit eyes fact fair fern figs film fish
+	fizz flap flew flux foxy free frog fuel fund gala game gear gems gift girl glow
+	good gray grim guru gush gyro half hang hard hawk heat help high hill holy hope
+	horn huts iced idea idle inch inky into iris iron item jade jazz join jolt jowl
+	judo jugs jump junk jury keep keno kept keys kick kiln king kite kiwi knob lamb
+	lava lazy leaf legs liar limp lion list logo loud love luau luck lung main many
+	math maze memo menu meow mild mint miss monk nail navy need news next noon note
+	numb obey oboe omit onyx open oval owls paid part peck play plus poem pool pose
+	puff puma purr quad quiz race ramp real redo rich road rock roof ruby ruin runs
+	rust safe saga scar sets silk skew slot soap solo song stub surf swan taco task
+	taxi tent tied time tiny toil tomb toys trip tuna twin ugly undo unit urge user
+	vast very veto vial vibe view visa void vows wall wand warm wasp wave waxy webs
+	what when whiz wolf work yank yawn yell yoga yurt zaps zero zest zinc zone zoom
+`)
+
+// minimalBytewords maps the minimal encodings of the bytewords to their bytes.
+var minimalBytewords = func() map[string]byte {
+	result := map[string]byte{}
+	for index, word := range bytewords {
+		result[word[:1]+word[3:]] = byte(index)
+	}
+	return result
+}()
+
+// encodeBytewords returns the minimal bytewords encoding of the data followed by its CRC32
+// checksum.
+func encodeBytewords(data []byte) string {
+	checksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksum, crc32.ChecksumIEEE(data))
+	var builder strings.Builder
+	for _, b := range append(append([]byte{}, data...), checksum...) {
+		word := bytewords[b]
+		builder.WriteByte(word[0])
+		builder.WriteByte(word[3])
+	}
+	return builder.String()
+}
+
+// decodeBytewords decodes the minimal bytewords encoding and verifies its checksum. The encoding
+// is case insensitive, as QR codes are encoded in upper case.
+func decodeBytewords(encoded string) ([]byte, error) {
+	encoded = strings.ToLower(encoded)
+	if len(encoded)%2 != 0 || len(encoded) < 10 {
+		return nil, errp.New("Invalid length of the bytewords.")
+	}
+	decoded := make([]byte, len(encoded)/2)
+	for index := range decoded {
+		b, ok := minimalBytewords[encoded[2*index:2*index+2]]
+		if !ok {
+			return nil, errp.Newf("Invalid byteword %q.", encoded[2*index:2*index+2])
+		}
+		decoded[index] = b
+	}
+	data, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	if binary.BigEndian.Uint32(checksum) != crc32.ChecksumIEEE(data) {
+		return nil, errp.New("Invalid checksum of the bytewords.")
+	}
+	return data, nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ur
+
+import (
+	"encoding/binary"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// The CBOR (RFC 8949) major types used by URs.
+const (
+	cborUnsigned   = 0
+	cborByteString = 2
+	cborArray      = 4
+)
+
+// appendCBORHeader appends the header of a CBOR item of the given major type, whose argument is
+// the value, length or number of elements of the item.
+func appendCBORHeader(data []byte, majorType byte, argument uint64) []byte {
+	majorType <<= 5
+	switch {
+	case argument < 24:
+		return append(data, majorType|byte(argument))
+	case argument <= 0xff:
+		return append(data, majorType|24, byte(argument))
+	case argument <= 0xffff:
+		data = append(data, majorType|25)
+		return append(data, byte(argument>>8), byte(argument))
+	case argument <= 0xffffffff:
+		data = append(data, majorType|26)
+		return append(data, byte(argument>>24), byte(argument>>16), byte(argument>>8), byte(argument))
+	default:
+		data = append(data, majorType|27, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(data[len(data)-8:], argument)
+		return data
+	}
+}
+
+// EncodeCBORBytes returns the CBOR encoding of the bytes as byte string, which is e.g. the CBOR of
+// a `crypto-psbt` UR.
+func EncodeCBORBytes(data []byte) []byte {
+	return append(appendCBORHeader(nil, cborByteString, uint64(len(data))), data...)
+}
+
+// cborReader reads CBOR items from the front of the data.
+type cborReader struct {
+	data []byte
+}
+
+// readHeader reads the header of an item with the expected major type and returns its argument.
+// Indefinite lengths are not supported.
+func (reader *cborReader) readHeader(majorType byte) (uint64, error) {
+	if len(reader.data) == 0 {
+		return 0, errp.New("Unexpected end of the CBOR data.")
+	}
+	initial := reader.data[0]
+	if initial>>5 != majorType {
+		return 0, errp.Newf("Expected CBOR major type %d, got %d.", majorType, initial>>5)
+	}
+	additional := initial & 0x1f
+	reader.data = reader.data[1:]
+	if additional < 24 {
+		return uint64(additional), nil
+	}
+	if additional > 27 {
+		return 0, errp.New("Unsupported CBOR header.")
+	}
+	length := 1 << (additional - 24)
+	if len(reader.data) < length {
+		return 0, errp.New("Unexpected end of the CBOR data.")
+	}
+	argument := uint64(0)
+	for _, b := range reader.data[:length] {
+		argument = argument<<8 | uint64(b)
+	}
+	reader.data = reader.data[length:]
+	return argument, nil
+}
+
+// readUnsigned reads an unsigned integer.
+func (reader *cborReader) readUnsigned() (uint64, error) {
+	return reader.readHeader(cborUnsigned)
+}
+
+// readBytes reads a byte string.
+func (reader *cborReader) readBytes() ([]byte, error) {
+	length, err := reader.readHeader(cborByteString)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(reader.data)) < length {
+		return nil, errp.New("Unexpected end of the CBOR data.")
+	}
+	data := reader.data[:length]
+	reader.data = reader.data[length:]
+	return data, nil
+}
+
+// DecodeCBORBytes decodes the CBOR byte string, see EncodeCBORBytes.
+func DecodeCBORBytes(data []byte) ([]byte, error) {
+	reader := &cborReader{data: data}
+	decoded, err := reader.readBytes()
+	if err != nil {
+		return nil, err
+	}
+	if len(reader.data) != 0 {
+		return nil, errp.New("Unexpected data after the CBOR byte string.")
+	}
+	return decoded, nil
+}
@@ -0,0 +1,374 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ur
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// xoshiro256 is the xoshiro256** generator with which the encoders and decoders of multipart URs
+// derive the fragments mixed into a part, so its output has to match the reference implementation
+// exactly.
+type xoshiro256 struct {
+	state [4]uint64
+}
+
+// newXoshiro256 returns the generator seeded with the SHA256 hash of the seed.
+func newXoshiro256(seed []byte) *xoshiro256 {
+	hash := sha256.Sum256(seed)
+	rng := &xoshiro256{}
+	for index := range rng.state {
+		rng.state[index] = binary.BigEndian.Uint64(hash[8*index:])
+	}
+	return rng
+}
+
+func (rng *xoshiro256) next() uint64 {
+	state := &rng.state
+	result := bits.RotateLeft64(state[1]*5, 7) * 9
+	t := state[1] << 17
+	state[2] ^= state[0]
+	state[3] ^= state[1]
+	state[1] ^= state[2]
+	state[0] ^= state[3]
+	state[2] ^= t
+	state[3] = bits.RotateLeft64(state[3], 45)
+	return result
+}
+
+// nextDouble returns a number in [0, 1).
+func (rng *xoshiro256) nextDouble() float64 {
+	return float64(rng.next()) / (math.MaxUint64 + 1.0)
+}
+
+// nextInt returns a number in [low, high].
+func (rng *xoshiro256) nextInt(low, high int) int {
+	return int(rng.nextDouble()*float64(high-low+1)) + low
+}
+
+// randomSampler samples indices with the given probabilities using Vose's alias method.
+type randomSampler struct {
+	probabilities []float64
+	aliases       []int
+}
+
+func newRandomSampler(probabilities []float64) *randomSampler {
+	count := len(probabilities)
+	sum := 0.0
+	for _, probability := range probabilities {
+		sum += probability
+	}
+	scaled := make([]float64, count)
+	for index, probability := range probabilities {
+		scaled[index] = probability * float64(count) / sum
+	}
+	small, large := []int{}, []int{}
+	for index := count - 1; index >= 0; index-- {
+		if scaled[index] < 1 {
+			small = append(small, index)
+		} else {
+			large = append(large, index)
+		}
+	}
+	sampler := &randomSampler{
+		probabilities: make([]float64, count),
+		aliases:       make([]int, count),
+	}
+	for len(small) != 0 && len(large) != 0 {
+		less := small[len(small)-1]
+		small = small[:len(small)-1]
+		more := large[len(large)-1]
+		large = large[:len(large)-1]
+		sampler.probabilities[less] = scaled[less]
+		sampler.aliases[less] = more
+		scaled[more] += scaled[less] - 1
+		if scaled[more] < 1 {
+			small = append(small, more)
+		} else {
+			large = append(large, more)
+		}
+	}
+	for _, index := range append(small, large...) {
+		sampler.probabilities[index] = 1
+	}
+	return sampler
+}
+
+func (sampler *randomSampler) next(rng *xoshiro256) int {
+	r1, r2 := rng.nextDouble(), rng.nextDouble()
+	index := int(float64(len(sampler.probabilities)) * r1)
+	if r2 < sampler.probabilities[index] {
+		return index
+	}
+	return sampler.aliases[index]
+}
+
+// chooseFragments returns the sorted indices of the fragments which are mixed into the part with
+// the given sequence number. The first seqLen parts contain one fragment each.
+func chooseFragments(seqNum uint32, seqLen int, checksum uint32) []int {
+	if int(seqNum) <= seqLen {
+		return []int{int(seqNum) - 1}
+	}
+	seed := make([]byte, 8)
+	binary.BigEndian.PutUint32(seed, seqNum)
+	binary.BigEndian.PutUint32(seed[4:], checksum)
+	rng := newXoshiro256(seed)
+	degreeProbabilities := make([]float64, seqLen)
+	for index := range degreeProbabilities {
+		degreeProbabilities[index] = 1 / float64(index+1)
+	}
+	degree := newRandomSampler(degreeProbabilities).next(rng) + 1
+	remaining := make([]int, seqLen)
+	for index := range remaining {
+		remaining[index] = index
+	}
+	shuffled := make([]int, 0, seqLen)
+	for len(remaining) != 0 {
+		index := rng.nextInt(0, len(remaining)-1)
+		shuffled = append(shuffled, remaining[index])
+		remaining = append(remaining[:index], remaining[index+1:]...)
+	}
+	indices := shuffled[:degree]
+	sort.Ints(indices)
+	return indices
+}
+
+// fragmentLength returns the length of the fragments of a message, so that the fragments are of
+// about the same size and not longer than maxFragmentLength.
+func fragmentLength(messageLength int, minFragmentLength int, maxFragmentLength int) int {
+	maxFragmentCount := messageLength / minFragmentLength
+	if maxFragmentCount < 1 {
+		maxFragmentCount = 1
+	}
+	length := messageLength
+	for fragmentCount := 1; fragmentCount <= maxFragmentCount; fragmentCount++ {
+		length = (messageLength + fragmentCount - 1) / fragmentCount
+		if length <= maxFragmentLength {
+			break
+		}
+	}
+	return length
+}
+
+// part is a part of a multipart UR, containing the XOR of the fragments chosen by its sequence
+// number.
+type part struct {
+	seqNum        uint32
+	seqLen        int
+	messageLength int
+	checksum      uint32
+	data          []byte
+}
+
+// encode returns the CBOR encoding of the part.
+func (part *part) encode() []byte {
+	data := appendCBORHeader(nil, cborArray, 5)
+	data = appendCBORHeader(data, cborUnsigned, uint64(part.seqNum))
+	data = appendCBORHeader(data, cborUnsigned, uint64(part.seqLen))
+	data = appendCBORHeader(data, cborUnsigned, uint64(part.messageLength))
+	data = appendCBORHeader(data, cborUnsigned, uint64(part.checksum))
+	return append(appendCBORHeader(data, cborByteString, uint64(len(part.data))), part.data...)
+}
+
+// decodePart decodes the CBOR encoding of a part.
+func decodePart(data []byte) (*part, error) {
+	reader := &cborReader{data: data}
+	count, err := reader.readHeader(cborArray)
+	if err != nil {
+		return nil, err
+	}
+	if count != 5 {
+		return nil, errp.New("A part has to consist of five elements.")
+	}
+	var values [4]uint64
+	for index := range values {
+		if values[index], err = reader.readUnsigned(); err != nil {
+			return nil, err
+		}
+	}
+	fragment, err := reader.readBytes()
+	if err != nil {
+		return nil, err
+	}
+	if values[0] == 0 || values[0] > math.MaxUint32 || values[1] == 0 || values[1] > math.MaxUint32 ||
+		values[2] > math.MaxUint32 || values[3] > math.MaxUint32 || len(fragment) == 0 {
+		return nil, errp.New("Invalid part.")
+	}
+	return &part{
+		seqNum:        uint32(values[0]),
+		seqLen:        int(values[1]),
+		messageLength: int(values[2]),
+		checksum:      uint32(values[3]),
+		data:          fragment,
+	}, nil
+}
+
+// xorInto sets target to target XOR source.
+func xorInto(target []byte, source []byte) {
+	for index := range target {
+		target[index] ^= source[index]
+	}
+}
+
+// fountainEncoder splits a message into fragments and returns an endless sequence of parts: each
+// fragment by itself, followed by random mixes of fragments (fountain codes), so that a decoder
+// can recover the message from any sufficiently large subset of the parts.
+type fountainEncoder struct {
+	messageLength int
+	checksum      uint32
+	fragments     [][]byte
+	seqNum        uint32
+}
+
+func newFountainEncoder(message []byte, maxFragmentLength int) *fountainEncoder {
+	length := fragmentLength(len(message), 10, maxFragmentLength)
+	fragments := [][]byte{}
+	for start := 0; start < len(message); start += length {
+		fragment := make([]byte, length)
+		copy(fragment, message[start:])
+		fragments = append(fragments, fragment)
+	}
+	return &fountainEncoder{
+		messageLength: len(message),
+		checksum:      crc32.ChecksumIEEE(message),
+		fragments:     fragments,
+	}
+}
+
+func (encoder *fountainEncoder) nextPart() *part {
+	encoder.seqNum++
+	data := make([]byte, len(encoder.fragments[0]))
+	for _, index := range chooseFragments(encoder.seqNum, len(encoder.fragments), encoder.checksum) {
+		xorInto(data, encoder.fragments[index])
+	}
+	return &part{
+		seqNum:        encoder.seqNum,
+		seqLen:        len(encoder.fragments),
+		messageLength: encoder.messageLength,
+		checksum:      encoder.checksum,
+		data:          data,
+	}
+}
+
+// mixedPart is a received part of which some fragments are still unknown.
+type mixedPart struct {
+	indices map[int]struct{}
+	data    []byte
+}
+
+// fountainDecoder recovers a message from the parts of a fountainEncoder. The fragments of the
+// parts which have been decoded are removed from the mixed parts, until all fragments are known.
+type fountainDecoder struct {
+	// first is the first received part, with which all other parts have to be consistent.
+	first     *part
+	fragments map[int][]byte
+	mixed     []*mixedPart
+	received  map[uint32]struct{}
+	message   []byte
+}
+
+func newFountainDecoder() *fountainDecoder {
+	return &fountainDecoder{
+		fragments: map[int][]byte{},
+		received:  map[uint32]struct{}{},
+	}
+}
+
+// receive adds the part to the decoder. Parts which have already been received are ignored.
+func (decoder *fountainDecoder) receive(received *part) error {
+	if decoder.message != nil {
+		return nil
+	}
+	if decoder.first == nil {
+		if received.seqLen*len(received.data) < received.messageLength ||
+			(received.seqLen-1)*len(received.data) >= received.messageLength {
+			return errp.New("The part does not match the length of the message.")
+		}
+		decoder.first = received
+	} else if received.seqLen != decoder.first.seqLen ||
+		received.messageLength != decoder.first.messageLength ||
+		received.checksum != decoder.first.checksum ||
+		len(received.data) != len(decoder.first.data) {
+		return errp.New("The part belongs to another message.")
+	}
+	if _, ok := decoder.received[received.seqNum]; ok {
+		return nil
+	}
+	decoder.received[received.seqNum] = struct{}{}
+
+	indices := map[int]struct{}{}
+	for _, index := range chooseFragments(received.seqNum, received.seqLen, received.checksum) {
+		indices[index] = struct{}{}
+	}
+	queue := []*mixedPart{{indices: indices, data: append([]byte{}, received.data...)}}
+	for len(queue) != 0 {
+		mixed := queue[0]
+		queue = queue[1:]
+		for index := range mixed.indices {
+			if fragment, ok := decoder.fragments[index]; ok {
+				xorInto(mixed.data, fragment)
+				delete(mixed.indices, index)
+			}
+		}
+		if len(mixed.indices) != 1 {
+			if len(mixed.indices) > 1 {
+				decoder.mixed = append(decoder.mixed, mixed)
+			}
+			continue
+		}
+		for index := range mixed.indices {
+			decoder.fragments[index] = mixed.data
+		}
+		// Reprocess the mixed parts, which may now be reduced to a single fragment.
+		queue = append(queue, decoder.mixed...)
+		decoder.mixed = nil
+	}
+	if len(decoder.fragments) == decoder.first.seqLen {
+		return decoder.join()
+	}
+	return nil
+}
+
+// join concatenates the fragments and verifies the checksum of the message.
+func (decoder *fountainDecoder) join() error {
+	message := make([]byte, 0, decoder.first.seqLen*len(decoder.first.data))
+	for index := 0; index < decoder.first.seqLen; index++ {
+		message = append(message, decoder.fragments[index]...)
+	}
+	message = message[:decoder.first.messageLength]
+	if crc32.ChecksumIEEE(message) != decoder.first.checksum {
+		return errp.New("Invalid checksum of the message.")
+	}
+	decoder.message = message
+	return nil
+}
+
+// progress returns the share of the fragments which are known.
+func (decoder *fountainDecoder) progress() float64 {
+	if decoder.message != nil {
+		return 1
+	}
+	if decoder.first == nil {
+		return 0
+	}
+	return float64(len(decoder.fragments)) / float64(decoder.first.seqLen)
+}
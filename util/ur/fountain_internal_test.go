@@ -0,0 +1,66 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ur
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestXoshiro256 checks the generator against the test vector of the reference implementation,
+// as the parts of the fountain codes depend on it.
+func TestXoshiro256(t *testing.T) {
+	rng := newXoshiro256([]byte("Wolf"))
+	numbers := make([]uint64, 20)
+	for index := range numbers {
+		numbers[index] = rng.next() % 100
+	}
+	require.Equal(t,
+		[]uint64{42, 81, 85, 8, 82, 84, 76, 73, 70, 88, 2, 74, 40, 48, 77, 54, 88, 7, 5, 88},
+		numbers)
+}
+
+func TestBytewords(t *testing.T) {
+	require.Equal(t, "aeadaolazmjendeoti", encodeBytewords([]byte{0, 1, 2, 128, 255}))
+	decoded, err := decodeBytewords("AEADAOLAZMJENDEOTI")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 1, 2, 128, 255}, decoded)
+
+	_, err = decodeBytewords("aeadaolazmjendeotk")
+	require.Error(t, err)
+	_, err = decodeBytewords("aeadaolazmjendeoto")
+	require.Error(t, err)
+}
+
+func TestChooseFragments(t *testing.T) {
+	for seqNum := uint32(1); seqNum <= 10; seqNum++ {
+		require.Equal(t, []int{int(seqNum) - 1}, chooseFragments(seqNum, 10, 0x12345678))
+	}
+	for seqNum := uint32(11); seqNum <= 100; seqNum++ {
+		indices := chooseFragments(seqNum, 10, 0x12345678)
+		require.NotEmpty(t, indices)
+		require.True(t, len(indices) <= 10)
+		for index := 1; index < len(indices); index++ {
+			require.True(t, indices[index-1] < indices[index])
+		}
+	}
+}
+
+func TestFragmentLength(t *testing.T) {
+	require.Equal(t, 1764, fragmentLength(12345, 10, 1955))
+	require.Equal(t, 12345, fragmentLength(12345, 10, 30000))
+	require.Equal(t, 5, fragmentLength(5, 10, 100))
+}
@@ -0,0 +1,165 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ur implements Uniform Resources (BCR-2020-005), with which air-gapped signers exchange
+// data like PSBTs as (animated) QR codes, e.g. `ur:crypto-psbt/...`. Large URs are split into
+// multipart URs using fountain codes, see BCR-2020-012 for the encoding of the bytes.
+package ur
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/errp"
+)
+
+// TypeCryptoPSBT is the type of a UR containing a PSBT as CBOR byte string.
+const TypeCryptoPSBT = "crypto-psbt"
+
+// UR is a decoded uniform resource.
+type UR struct {
+	// Type is the type of the CBOR encoded data, e.g. TypeCryptoPSBT.
+	Type string
+	// CBOR is the CBOR encoded data.
+	CBOR []byte
+}
+
+// validType returns whether the type consists of lower case letters, digits and hyphens.
+func validType(urType string) bool {
+	if urType == "" {
+		return false
+	}
+	for _, char := range urType {
+		if !(char >= 'a' && char <= 'z' || char >= '0' && char <= '9' || char == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Encoder encodes a UR into parts. A UR which fits into a single part is always encoded as
+// single-part UR.
+type Encoder struct {
+	ur       *UR
+	fountain *fountainEncoder
+}
+
+// NewEncoder returns an encoder splitting the data of the UR into fragments of at most
+// maxFragmentLength bytes.
+func NewEncoder(ur *UR, maxFragmentLength int) (*Encoder, error) {
+	if !validType(ur.Type) {
+		return nil, errp.Newf("Invalid UR type %q.", ur.Type)
+	}
+	if len(ur.CBOR) == 0 {
+		return nil, errp.New("The UR is empty.")
+	}
+	return &Encoder{
+		ur:       ur,
+		fountain: newFountainEncoder(ur.CBOR, maxFragmentLength),
+	}, nil
+}
+
+// SeqLen returns the number of fragments. The decoder needs at least this number of parts.
+func (encoder *Encoder) SeqLen() int {
+	return len(encoder.fountain.fragments)
+}
+
+// NextPart returns the next part in lower case. The parts of a multipart UR continue endlessly.
+func (encoder *Encoder) NextPart() string {
+	if encoder.SeqLen() == 1 {
+		return "ur:" + encoder.ur.Type + "/" + encodeBytewords(encoder.ur.CBOR)
+	}
+	part := encoder.fountain.nextPart()
+	return fmt.Sprintf("ur:%s/%d-%d/%s",
+		encoder.ur.Type, part.seqNum, part.seqLen, encodeBytewords(part.encode()))
+}
+
+// Decoder decodes a UR from its parts, which can be received in any order.
+type Decoder struct {
+	urType   string
+	fountain *fountainDecoder
+	result   *UR
+}
+
+// NewDecoder returns a decoder for a single UR.
+func NewDecoder() *Decoder {
+	return &Decoder{fountain: newFountainDecoder()}
+}
+
+// Receive adds the part, e.g. the text of a scanned QR code, to the decoder. Parts of a different
+// UR than the first received part are rejected.
+func (decoder *Decoder) Receive(encoded string) error {
+	encoded = strings.ToLower(strings.TrimSpace(encoded))
+	if !strings.HasPrefix(encoded, "ur:") {
+		return errp.New("The text is not a UR.")
+	}
+	components := strings.Split(encoded[len("ur:"):], "/")
+	urType := components[0]
+	if !validType(urType) || len(components) < 2 || len(components) > 3 {
+		return errp.New("Invalid UR.")
+	}
+	if decoder.urType != "" && urType != decoder.urType {
+		return errp.Newf("Expected a UR of type %s, got %s.", decoder.urType, urType)
+	}
+	data, err := decodeBytewords(components[len(components)-1])
+	if err != nil {
+		return err
+	}
+	if len(components) == 2 {
+		decoder.urType = urType
+		decoder.result = &UR{Type: urType, CBOR: data}
+		return nil
+	}
+	seq := strings.Split(components[1], "-")
+	if len(seq) != 2 {
+		return errp.New("Invalid sequence of the multipart UR.")
+	}
+	seqNum, err := strconv.ParseUint(seq[0], 10, 32)
+	if err != nil {
+		return errp.New("Invalid sequence of the multipart UR.")
+	}
+	seqLen, err := strconv.ParseUint(seq[1], 10, 32)
+	if err != nil {
+		return errp.New("Invalid sequence of the multipart UR.")
+	}
+	part, err := decodePart(data)
+	if err != nil {
+		return err
+	}
+	if uint64(part.seqNum) != seqNum || uint64(part.seqLen) != seqLen {
+		return errp.New("The sequence of the multipart UR does not match its part.")
+	}
+	if err := decoder.fountain.receive(part); err != nil {
+		return err
+	}
+	decoder.urType = urType
+	if decoder.fountain.message != nil {
+		decoder.result = &UR{Type: urType, CBOR: decoder.fountain.message}
+	}
+	return nil
+}
+
+// Progress returns the share of the UR which has been decoded, between 0 and 1.
+func (decoder *Decoder) Progress() float64 {
+	if decoder.result != nil {
+		return 1
+	}
+	return decoder.fountain.progress()
+}
+
+// Result returns the decoded UR, or nil if more parts are needed.
+func (decoder *Decoder) Result() *UR {
+	return decoder.result
+}
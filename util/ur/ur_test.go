@@ -0,0 +1,86 @@
+// Copyright 2018 Shift Devices AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ur_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/digitalbitbox/bitbox-wallet-app/util/ur"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSinglePart(t *testing.T) {
+	encoder, err := ur.NewEncoder(
+		&ur.UR{Type: ur.TypeCryptoPSBT, CBOR: ur.EncodeCBORBytes([]byte{0, 1, 2, 128, 255})}, 100)
+	require.NoError(t, err)
+	require.Equal(t, 1, encoder.SeqLen())
+	part := encoder.NextPart()
+	require.Equal(t, part, encoder.NextPart())
+
+	decoder := ur.NewDecoder()
+	require.NoError(t, decoder.Receive(strings.ToUpper(part)))
+	require.Equal(t, 1.0, decoder.Progress())
+	result := decoder.Result()
+	require.Equal(t, ur.TypeCryptoPSBT, result.Type)
+	data, err := ur.DecodeCBORBytes(result.CBOR)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 1, 2, 128, 255}, data)
+}
+
+func TestMultipart(t *testing.T) {
+	message := bytes.Repeat([]byte("bitbox"), 200)
+	cbor := ur.EncodeCBORBytes(message)
+	encoder, err := ur.NewEncoder(&ur.UR{Type: ur.TypeCryptoPSBT, CBOR: cbor}, 100)
+	require.NoError(t, err)
+	require.Equal(t, 13, encoder.SeqLen())
+	require.True(t, strings.HasPrefix(encoder.NextPart(), "ur:crypto-psbt/1-13/"))
+
+	// The message is recovered from the mixed parts only, as if the simple parts were missed.
+	for index := 2; index <= encoder.SeqLen(); index++ {
+		encoder.NextPart()
+	}
+	decoder := ur.NewDecoder()
+	for decoder.Result() == nil {
+		part := encoder.NextPart()
+		require.NoError(t, decoder.Receive(part))
+		// Parts received twice are ignored.
+		require.NoError(t, decoder.Receive(part))
+	}
+	require.Equal(t, cbor, decoder.Result().CBOR)
+}
+
+func TestDecoderErrors(t *testing.T) {
+	encoder, err := ur.NewEncoder(
+		&ur.UR{Type: ur.TypeCryptoPSBT, CBOR: ur.EncodeCBORBytes(bytes.Repeat([]byte{1}, 500))}, 100)
+	require.NoError(t, err)
+	part := encoder.NextPart()
+
+	decoder := ur.NewDecoder()
+	require.Error(t, decoder.Receive("cHNidP8B"))
+	require.Error(t, decoder.Receive(strings.Replace(part, "/1-", "/2-", 1)))
+	require.NoError(t, decoder.Receive(part))
+	require.Error(t, decoder.Receive(strings.Replace(part, "crypto-psbt", "bytes", 1)))
+
+	otherEncoder, err := ur.NewEncoder(
+		&ur.UR{Type: ur.TypeCryptoPSBT, CBOR: ur.EncodeCBORBytes(bytes.Repeat([]byte{2}, 600))}, 100)
+	require.NoError(t, err)
+	require.Error(t, decoder.Receive(otherEncoder.NextPart()))
+	require.Nil(t, decoder.Result())
+
+	_, err = ur.NewEncoder(&ur.UR{Type: "Crypto PSBT", CBOR: []byte{0x40}}, 100)
+	require.Error(t, err)
+}